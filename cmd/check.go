@@ -1,16 +1,30 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
-	"github.com/aywengo/ksr-cli/internal/client"
+	"github.com/aywengo/ksr-cli/internal/compat"
 	"github.com/aywengo/ksr-cli/internal/config"
 	"github.com/aywengo/ksr-cli/internal/output"
+	"github.com/aywengo/ksr-cli/internal/schema"
+	"github.com/aywengo/ksr-cli/pkg/client"
 	"github.com/spf13/cobra"
 )
 
+var (
+	localCheck         bool
+	checkAllVersions   bool
+	compatibilityLevel string
+	againstFiles       []string
+	proposedLevel      string
+	checkEngine        string
+	checkFix           bool
+)
+
 // checkCmd represents the check command
 var checkCmd = &cobra.Command{
 	Use:   "check",
@@ -20,7 +34,8 @@ and validation of schema syntax.
 
 Examples:
   ksr-cli check compatibility my-subject --file new-schema.avsc
-  ksr-cli check compatibility my-subject --schema '{"type":"string"}'`,
+  ksr-cli check compatibility my-subject --schema '{"type":"string"}'
+  ksr-cli check level my-subject --level FULL_TRANSITIVE`,
 }
 
 var checkCompatibilityCmd = &cobra.Command{
@@ -33,11 +48,41 @@ The schema can be provided via:
   - Inline using --schema flag
   - Standard input (if neither flag is provided)
 
+With --local, compatibility is evaluated by the CLI instead of the registry
+(currently supported for Avro and JSON Schema). The previous schemas are fetched from the
+registry, or read from --against files (oldest first) for a fully offline check.
+The level defaults to the subject's configured compatibility, or BACKWARD offline.
+
+With --all-versions the schema is checked against every registered version, with a
+pass/fail row and the registry's messages per version, followed by the compatibility
+levels the schema would satisfy. Levels checking other directions than the subject's
+level are evaluated locally when a local checker exists for the schema type.
+
+Incompatibilities are explained: the registry's messages are translated into the
+offending field, why the change breaks readers and a suggested change. With --fix the
+mechanical fixes are applied to the --file schema, which is re-checked and rewritten:
+fields missing a default are made optional with default null, missing enum symbols and
+union branches are added, and renamed types get their previous name as an alias. Other
+issues, such as changed types, are left for manual changes.
+
+Avro IDL (.avdl) files are translated to an Avro schema locally, for the record selected
+with --record.
+
+References are given with --ref NAME=SUBJECT:VERSION or resolved from --ref-dir, as for
+create schema. A check never registers anything: dependencies from --ref-dir that are
+not registered yet are errors.
+
 Examples:
   ksr-cli check compatibility my-subject --file new-schema.avsc
   ksr-cli check compatibility my-subject --schema '{"type":"string"}'
   ksr-cli check compatibility my-subject --version 2 --file new-schema.avsc
-  cat new-schema.avsc | ksr-cli check compatibility my-subject`,
+  ksr-cli check compatibility my-subject --all-versions --file new-schema.avsc
+  ksr-cli check compatibility my-subject --file new-schema.avsc --fix
+  ksr-cli check compatibility my-subject --file events.avdl --record Click
+  ksr-cli check compatibility orders-value --file order.proto --type PROTOBUF --ref-dir protos
+  cat new-schema.avsc | ksr-cli check compatibility my-subject
+  ksr-cli check compatibility my-subject --local --type JSON --file new.json
+  ksr-cli check compatibility my-subject --local --type JSON --file new.json --against old.json --level FULL`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		subject := args[0]
@@ -48,10 +93,25 @@ Examples:
 			return fmt.Errorf("failed to get schema content: %w", err)
 		}
 
-		// Validate schema content is valid JSON
-		var schemaObj interface{}
-		if err := json.Unmarshal([]byte(schemaContent), &schemaObj); err != nil {
-			return fmt.Errorf("invalid schema JSON: %w", err)
+		if checkAllVersions && (localCheck || version != "") {
+			return fmt.Errorf("--all-versions cannot be combined with --local or --version")
+		}
+		if checkFix {
+			if schemaFile == "" || schemaString != "" || isIDLFile(schemaFile) {
+				return fmt.Errorf("--fix requires a schema file given with --file (Avro IDL files cannot be fixed)")
+			}
+			if checkAllVersions {
+				return fmt.Errorf("--fix cannot be combined with --all-versions")
+			}
+		}
+
+		// Validate schema content with the parser for its type
+		actualSchemaType, _ := cmd.Flags().GetString("type")
+		if localCheck {
+			if err := schema.Validate(actualSchemaType, schemaContent); err != nil {
+				return fmt.Errorf("invalid schema: %w", err)
+			}
+			return runLocalCompatibilityCheck(cmd, subject, schemaContent)
 		}
 
 		// Create client
@@ -59,22 +119,35 @@ Examples:
 		if err != nil {
 			return fmt.Errorf("failed to create client: %w", err)
 		}
+		effectiveContext := config.GetEffectiveContext(context)
+
+		references, referenceContents, err := resolveSchemaReferences(c, schemaContent, effectiveContext)
+		if err != nil {
+			return err
+		}
+		if err := validateSchemaContent(actualSchemaType, schemaContent, referenceContents); err != nil {
+			return err
+		}
 
 		// Prepare schema request
 		schemaReq := &client.SchemaRequest{
 			Schema:     schemaContent,
 			SchemaType: schemaType,
+			References: references,
+		}
+
+		if checkAllVersions {
+			return runCompatibilityMatrix(cmd, c, subject, schemaReq, effectiveContext)
 		}
 
 		// Check compatibility
-		effectiveContext := config.GetEffectiveContext(context)
 
 		// Use version if specified, otherwise check against latest
 		var result *client.CompatibilityResponse
 		if version != "" {
-			result, err = c.CheckCompatibilityWithVersion(subject, version, schemaReq, effectiveContext)
+			result, err = c.CheckCompatibilityWithVersion(subject, version, schemaReq, effectiveContext, true)
 		} else {
-			result, err = c.CheckCompatibility(subject, schemaReq, effectiveContext)
+			result, err = c.CheckCompatibility(subject, schemaReq, effectiveContext, true)
 		}
 
 		if err != nil {
@@ -84,48 +157,594 @@ Examples:
 		// Get the actual output format from the command flag
 		actualOutputFormat, _ := cmd.Flags().GetString("output")
 
-		// Only print user-friendly messages when output format is table
+		// Only print user-friendly messages to stdout when output format is table
 		// For structured formats (json/yaml), send messages to stderr to avoid breaking parsing
-		if actualOutputFormat == "table" {
-			if result.IsCompatible {
-				fmt.Printf("✅ Schema is compatible with subject '%s'\n", subject)
-			} else {
-				fmt.Printf("❌ Schema is NOT compatible with subject '%s'\n", subject)
-				if len(result.Messages) > 0 {
-					fmt.Println("Compatibility issues:")
-					for _, msg := range result.Messages {
-						fmt.Printf("  • %s\n", msg)
+		out := os.Stdout
+		if actualOutputFormat != "table" {
+			out = os.Stderr
+		}
+		report := &compatibilityReport{CompatibilityResponse: result}
+		if result.IsCompatible {
+			fmt.Fprintf(out, "✅ Schema is compatible with subject '%s'\n", subject)
+		} else {
+			fmt.Fprintf(out, "❌ Schema is NOT compatible with subject '%s'\n", subject)
+			report.Issues = compat.Annotate(compat.ParseMessages(result.Messages), schemaType, schemaContent, compat.PreviousSchemas(result.Messages)...)
+			if len(report.Issues) > 0 {
+				fmt.Fprintln(out, "Compatibility issues:")
+				printCompatibilityIssues(out, report.Issues)
+			}
+
+			if checkFix {
+				err := fixSchemaFile(out, schemaContent, report.Issues, func(content string) ([]compat.Issue, error) {
+					req := &client.SchemaRequest{Schema: content, SchemaType: schemaType, References: references}
+					var recheck *client.CompatibilityResponse
+					var err error
+					if version != "" {
+						recheck, err = c.CheckCompatibilityWithVersion(subject, version, req, effectiveContext, true)
+					} else {
+						recheck, err = c.CheckCompatibility(subject, req, effectiveContext, true)
+					}
+					if err != nil {
+						return nil, fmt.Errorf("failed to check compatibility: %w", err)
+					}
+					if recheck.IsCompatible {
+						return nil, nil
 					}
+					return compat.Annotate(compat.ParseMessages(recheck.Messages), schemaType, content, compat.PreviousSchemas(recheck.Messages)...), nil
+				})
+				if err != nil {
+					return err
 				}
 			}
-		} else {
-			// For structured output, send user messages to stderr
-			if result.IsCompatible {
-				fmt.Fprintf(os.Stderr, "✅ Schema is compatible with subject '%s'\n", subject)
-			} else {
-				fmt.Fprintf(os.Stderr, "❌ Schema is NOT compatible with subject '%s'\n", subject)
-				if len(result.Messages) > 0 {
-					fmt.Fprintln(os.Stderr, "Compatibility issues:")
-					for _, msg := range result.Messages {
-						fmt.Fprintf(os.Stderr, "  • %s\n", msg)
-					}
+		}
+
+		return output.Print(report, actualOutputFormat)
+	},
+}
+
+// compatibilityReport is the registry's compatibility response with its messages explained
+type compatibilityReport struct {
+	*client.CompatibilityResponse
+	Issues []compat.Issue `json:"issues,omitempty"`
+}
+
+// printCompatibilityIssues prints incompatibilities with their field, explanation and suggested change
+func printCompatibilityIssues(out io.Writer, issues []compat.Issue) {
+	for _, issue := range issues {
+		if issue.Type == "" {
+			fmt.Fprintf(out, "  • %s\n", issue.Message)
+			continue
+		}
+		location := issue.Path
+		if issue.Field != "" {
+			location = fmt.Sprintf("%s (%s)", issue.Field, issue.Path)
+		}
+		var details []string
+		if issue.Direction != "" {
+			details = append(details, issue.Direction)
+		}
+		if issue.Against != "" {
+			details = append(details, "against "+issue.Against)
+		}
+		if len(details) > 0 {
+			location += fmt.Sprintf(" (%s)", strings.Join(details, ", "))
+		}
+		if issue.Message != "" && issue.Message != issue.Field {
+			location += ": " + issue.Message
+		}
+		fmt.Fprintf(out, "  • [%s] %s\n", issue.Type, location)
+		if issue.Explanation != "" {
+			fmt.Fprintf(out, "    Why: %s\n", issue.Explanation)
+		}
+		if issue.Suggestion != "" {
+			fmt.Fprintf(out, "    Fix: %s\n", issue.Suggestion)
+		}
+	}
+}
+
+// maxFixRounds bounds the fix and re-check rounds, as some issues only surface once
+// others are fixed
+const maxFixRounds = 5
+
+// fixSchemaFile applies the mechanical fixes of issues to the schema, re-checking it with
+// recheck after each round, and writes the patched schema back to --file
+func fixSchemaFile(out io.Writer, content string, issues []compat.Issue, recheck func(string) ([]compat.Issue, error)) error {
+	fixes := 0
+	for round := 0; round < maxFixRounds && len(issues) > 0; round++ {
+		patched, applied, err := compat.Fix(schemaType, content, issues)
+		if err != nil {
+			return fmt.Errorf("failed to fix schema: %w", err)
+		}
+		if len(applied) == 0 {
+			break
+		}
+		for _, fix := range applied {
+			fmt.Fprintf(out, "🔧 %s\n", fix)
+		}
+		fixes += len(applied)
+		content = patched
+		if issues, err = recheck(content); err != nil {
+			return err
+		}
+	}
+
+	if fixes == 0 {
+		fmt.Fprintln(out, "No mechanical fix applies; the issues need manual changes")
+		return nil
+	}
+	if err := os.WriteFile(schemaFile, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", schemaFile, err)
+	}
+	fmt.Fprintf(out, "Wrote %d fix(es) to %s\n", fixes, schemaFile)
+	if len(issues) == 0 {
+		fmt.Fprintln(out, "✅ The fixed schema is compatible")
+		return nil
+	}
+	fmt.Fprintf(out, "⚠️  %d issue(s) remain and need manual changes:\n", len(issues))
+	printCompatibilityIssues(out, issues)
+	return nil
+}
+
+// runLocalCompatibilityCheck evaluates compatibility with the local engine instead of the registry
+func runLocalCompatibilityCheck(cmd *cobra.Command, subject, schemaContent string) error {
+	checker, err := compat.CheckerFor(schemaType)
+	if err != nil {
+		return err
+	}
+
+	level := compatibilityLevel
+	var previous []compat.Schema
+
+	if len(againstFiles) > 0 {
+		for _, file := range againstFiles {
+			content, err := readSchemaFile(file)
+			if err != nil {
+				return fmt.Errorf("failed to read schema file %s: %w", file, err)
+			}
+			previous = append(previous, compat.Schema{Label: filepath.Base(file), Content: content})
+		}
+		if level == "" {
+			level = string(client.CompatibilityBackward)
+		}
+	} else {
+		c, err := createClientWithFlags()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		effectiveContext := config.GetEffectiveContext(context)
+
+		if level == "" {
+			level = resolveCompatibilityLevel(c, subject, effectiveContext)
+		}
+
+		previous, err = fetchPreviousSchemas(c, subject, level, effectiveContext)
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := compat.Check(level, checker, schemaContent, previous)
+	if err != nil {
+		return fmt.Errorf("failed to check compatibility: %w", err)
+	}
+
+	actualOutputFormat, _ := cmd.Flags().GetString("output")
+
+	out := os.Stdout
+	if actualOutputFormat != "table" {
+		out = os.Stderr
+	}
+	if result.IsCompatible {
+		fmt.Fprintf(out, "✅ Schema is compatible with subject '%s' (%s, local check)\n", subject, result.Level)
+	} else {
+		fmt.Fprintf(out, "❌ Schema is NOT compatible with subject '%s' (%s, local check)\n", subject, result.Level)
+		result.Issues = compat.Annotate(result.Issues, schemaType, schemaContent, previous...)
+		fmt.Fprintln(out, "Compatibility issues:")
+		printCompatibilityIssues(out, result.Issues)
+
+		if checkFix {
+			err := fixSchemaFile(out, schemaContent, result.Issues, func(content string) ([]compat.Issue, error) {
+				recheck, err := compat.Check(level, checker, content, previous)
+				if err != nil {
+					return nil, fmt.Errorf("failed to check compatibility: %w", err)
+				}
+				return compat.Annotate(recheck.Issues, schemaType, content, previous...), nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return output.Print(result, actualOutputFormat)
+}
+
+// VersionCompatibility is the registry's verdict for a schema against one registered version
+type VersionCompatibility struct {
+	Version      int            `json:"version"`
+	ID           int            `json:"id"`
+	IsCompatible bool           `json:"is_compatible"`
+	Messages     []string       `json:"messages"`
+	Issues       []compat.Issue `json:"issues,omitempty"`
+}
+
+// CompatibilityMatrix is the result of checking a schema against every version of a subject
+type CompatibilityMatrix struct {
+	Subject  string                 `json:"subject"`
+	Level    string                 `json:"level"`
+	Versions []VersionCompatibility `json:"versions"`
+	Levels   []compat.LevelVerdict  `json:"levels"`
+}
+
+// compatibilityRow is a version in the table output of a compatibility matrix
+type compatibilityRow struct {
+	Version    int    `json:"version"`
+	ID         int    `json:"id"`
+	Compatible string `json:"compatible"`
+	Messages   int    `json:"messages"`
+}
+
+// runCompatibilityMatrix checks a schema against every registered version of a subject and
+// derives the compatibility levels it would satisfy
+func runCompatibilityMatrix(cmd *cobra.Command, c *client.Client, subject string, schemaReq *client.SchemaRequest, effectiveContext string) error {
+	level := resolveCompatibilityLevel(c, subject, effectiveContext)
+	versions, err := c.GetSubjectVersions(subject, effectiveContext)
+	if err != nil {
+		return fmt.Errorf("failed to get versions for subject %s: %w", subject, err)
+	}
+
+	matrix := &CompatibilityMatrix{Subject: subject, Level: level, Versions: []VersionCompatibility{}}
+	var verdicts []compat.VersionVerdict
+	var previous []compat.Schema
+	for _, v := range versions {
+		registered, err := c.GetSchema(subject, fmt.Sprintf("%d", v), effectiveContext)
+		if err != nil {
+			return fmt.Errorf("failed to get schema version %d: %w", v, err)
+		}
+		result, err := c.CheckCompatibilityWithVersion(subject, fmt.Sprintf("%d", v), schemaReq, effectiveContext, true)
+		if err != nil {
+			return fmt.Errorf("failed to check compatibility with version %d: %w", v, err)
+		}
+
+		messages := result.Messages
+		if messages == nil {
+			messages = []string{}
+		}
+		against := compat.Schema{Label: fmt.Sprintf("version %d", v), Content: client.SchemaText(registered.Schema)}
+		verdict := VersionCompatibility{Version: v, ID: registered.ID, IsCompatible: result.IsCompatible, Messages: messages}
+		if !result.IsCompatible {
+			verdict.Issues = compat.Annotate(compat.ParseMessages(messages), schemaReq.SchemaType, schemaReq.Schema, against)
+		}
+		matrix.Versions = append(matrix.Versions, verdict)
+		verdicts = append(verdicts, compat.VersionVerdict{Version: v, Compatible: result.IsCompatible})
+		previous = append(previous, against)
+	}
+
+	var local func(string) (bool, error)
+	if checker, err := compat.CheckerFor(schemaType); err == nil {
+		local = func(level string) (bool, error) {
+			result, err := compat.Check(level, checker, schemaReq.Schema, previous)
+			if err != nil {
+				return false, err
+			}
+			return result.IsCompatible, nil
+		}
+	}
+	matrix.Levels, err = compat.EvaluateLevels(level, verdicts, local)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate compatibility levels: %w", err)
+	}
+
+	actualOutputFormat, _ := cmd.Flags().GetString("output")
+	if actualOutputFormat != "table" {
+		return output.Print(matrix, actualOutputFormat)
+	}
+
+	fmt.Printf("Compatibility with each version of '%s' (subject level %s):\n", subject, level)
+	rows := make([]interface{}, 0, len(matrix.Versions))
+	for _, v := range matrix.Versions {
+		compatible := "✅ yes"
+		if !v.IsCompatible {
+			compatible = "❌ no"
+		}
+		rows = append(rows, compatibilityRow{Version: v.Version, ID: v.ID, Compatible: compatible, Messages: len(v.Messages)})
+	}
+	if err := output.Print(rows, actualOutputFormat); err != nil {
+		return err
+	}
+	for _, v := range matrix.Versions {
+		if len(v.Issues) == 0 {
+			continue
+		}
+		fmt.Printf("\nVersion %d:\n", v.Version)
+		printCompatibilityIssues(os.Stdout, v.Issues)
+	}
+
+	fmt.Println("\nCompatibility levels the schema would satisfy:")
+	for _, verdict := range matrix.Levels {
+		mark := map[string]string{compat.Satisfied: "✅", compat.Unsatisfied: "❌", compat.Unknown: "❔"}[verdict.Satisfied]
+		line := fmt.Sprintf("  %s %s", mark, verdict.Level)
+		switch {
+		case verdict.Source != "" && verdict.Reason != "":
+			line += fmt.Sprintf(" (%s: %s)", verdict.Source, verdict.Reason)
+		case verdict.Source != "":
+			line += fmt.Sprintf(" (%s)", verdict.Source)
+		case verdict.Reason != "":
+			line += fmt.Sprintf(" (%s)", verdict.Reason)
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+var checkLevelCmd = &cobra.Command{
+	Use:   "level SUBJECT",
+	Short: "Check whether a subject's history satisfies a compatibility level",
+	Long: `Evaluate the registered versions of a subject under a proposed compatibility level,
+before changing it. Every version is checked against the version before it, or against
+all earlier versions for transitive levels, as if the level had been in force when the
+version was registered. The version pairs that would violate the level are reported
+with their issues.
+
+--engine selects how each pair is checked:
+  - local:    with the CLI's own checker (Avro and JSON Schema), in every direction
+              the level requires
+  - registry: by the registry, which checks the subject's current level; the verdicts
+              for other directions are derived from it where possible and reported as
+              unknown otherwise
+  - auto:     local when the schema type has a local checker and no version has
+              references, registry otherwise (default)
+
+Examples:
+  ksr-cli check level my-subject --level FULL_TRANSITIVE
+  ksr-cli check level my-subject --level FORWARD --engine registry
+  ksr-cli check level my-subject --level BACKWARD_TRANSITIVE -o json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		subject := args[0]
+		level := strings.ToUpper(proposedLevel)
+		if _, _, _, err := compat.ParseLevel(level); err != nil {
+			return err
+		}
+		if checkEngine != "auto" && checkEngine != "local" && checkEngine != "registry" {
+			return fmt.Errorf("unsupported engine: %s (expected auto, local or registry)", checkEngine)
+		}
+
+		c, err := createClientWithFlags()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		effectiveContext := config.GetEffectiveContext(context)
+
+		versions, err := c.GetSubjectVersions(subject, effectiveContext)
+		if err != nil {
+			return fmt.Errorf("failed to get versions for subject %s: %w", subject, err)
+		}
+		registered := make(map[int]*client.Schema, len(versions))
+		contents := make(map[int]string, len(versions))
+		hasReferences := false
+		for _, v := range versions {
+			s, err := c.GetSchema(subject, fmt.Sprintf("%d", v), effectiveContext)
+			if err != nil {
+				return fmt.Errorf("failed to get schema version %d: %w", v, err)
+			}
+			registered[v] = s
+			contents[v] = client.SchemaText(s.Schema)
+			hasReferences = hasReferences || len(s.References) > 0
+		}
+
+		history := &LevelHistory{Subject: subject, Level: level, CurrentLevel: resolveCompatibilityLevel(c, subject, effectiveContext), Engine: checkEngine}
+
+		var checker compat.Checker
+		if len(versions) > 0 && checkEngine != "registry" {
+			checker, err = compat.CheckerFor(registered[versions[0]].Type)
+			if err != nil && checkEngine == "local" {
+				return err
+			}
+		}
+		if checkEngine == "auto" {
+			history.Engine = "registry"
+			if checker != nil && !hasReferences {
+				history.Engine = "local"
+			}
+		}
+
+		pairChecker := compat.LocalPairChecker(checker, contents)
+		if history.Engine == "registry" {
+			pairChecker = compat.RegistryPairChecker(history.CurrentLevel, func(version, against int) (bool, []string, error) {
+				s := registered[version]
+				req := &client.SchemaRequest{Schema: contents[version], SchemaType: s.Type, References: s.References}
+				result, err := c.CheckCompatibilityWithVersion(subject, fmt.Sprintf("%d", against), req, effectiveContext, true)
+				if err != nil {
+					return false, nil, fmt.Errorf("failed to check version %d against version %d: %w", version, against, err)
 				}
+				return result.IsCompatible, result.Messages, nil
+			})
+		}
+
+		history.Pairs, err = compat.CheckHistory(level, versions, pairChecker)
+		if err != nil {
+			return err
+		}
+		for i, pair := range history.Pairs {
+			if pair.Satisfied == compat.Unsatisfied {
+				if len(pair.Issues) == 0 {
+					pair.Issues = compat.ParseMessages(pair.Messages)
+				}
+				against := compat.Schema{Label: fmt.Sprintf("version %d", pair.Against), Content: contents[pair.Against]}
+				history.Pairs[i].Issues = compat.Annotate(pair.Issues, registered[pair.Version].Type, contents[pair.Version], against)
+			}
+			switch pair.Satisfied {
+			case compat.Unsatisfied:
+				history.Violations++
+			case compat.Unknown:
+				history.Unknown++
 			}
 		}
 
-		return output.Print(result, actualOutputFormat)
+		actualOutputFormat, _ := cmd.Flags().GetString("output")
+		if actualOutputFormat != "table" {
+			return output.Print(history, actualOutputFormat)
+		}
+		return printLevelHistory(history)
 	},
 }
 
+// LevelHistory is the result of evaluating a subject's version history under a compatibility level
+type LevelHistory struct {
+	Subject      string               `json:"subject"`
+	Level        string               `json:"level"`
+	CurrentLevel string               `json:"current_level"`
+	Engine       string               `json:"engine"`
+	Violations   int                  `json:"violations"`
+	Unknown      int                  `json:"unknown"`
+	Pairs        []compat.HistoryPair `json:"pairs"`
+}
+
+// historyPairRow is a version pair in the table output of check level
+type historyPairRow struct {
+	Version int    `json:"version"`
+	Against int    `json:"against"`
+	Result  string `json:"result"`
+	Issues  int    `json:"issues"`
+}
+
+func printLevelHistory(history *LevelHistory) error {
+	fmt.Printf("History of '%s' under %s (current level %s, %s engine):\n", history.Subject, history.Level, history.CurrentLevel, history.Engine)
+	if len(history.Pairs) == 0 {
+		fmt.Println("No version pairs to check")
+	} else {
+		rows := make([]interface{}, 0, len(history.Pairs))
+		for _, pair := range history.Pairs {
+			result := map[string]string{compat.Satisfied: "✅ ok", compat.Unsatisfied: "❌ violates", compat.Unknown: "❔ unknown"}[pair.Satisfied]
+			rows = append(rows, historyPairRow{Version: pair.Version, Against: pair.Against, Result: result, Issues: len(pair.Issues)})
+		}
+		if err := output.Print(rows, "table"); err != nil {
+			return err
+		}
+	}
+
+	for _, pair := range history.Pairs {
+		if pair.Satisfied != compat.Unsatisfied {
+			continue
+		}
+		fmt.Printf("\nVersion %d against version %d:\n", pair.Version, pair.Against)
+		printCompatibilityIssues(os.Stdout, pair.Issues)
+	}
+
+	fmt.Println()
+	switch {
+	case history.Violations > 0:
+		fmt.Printf("❌ %d version pair(s) would violate %s\n", history.Violations, history.Level)
+	case history.Unknown > 0:
+		fmt.Printf("❔ No violations found, but %d version pair(s) could not be checked under %s\n", history.Unknown, history.Level)
+	default:
+		fmt.Printf("✅ The history of '%s' satisfies %s\n", history.Subject, history.Level)
+	}
+	if history.Unknown > 0 {
+		fmt.Printf("   %s\n", history.Pairs[firstUnknown(history.Pairs)].Reason)
+	}
+	return nil
+}
+
+func firstUnknown(pairs []compat.HistoryPair) int {
+	for i, pair := range pairs {
+		if pair.Satisfied == compat.Unknown {
+			return i
+		}
+	}
+	return 0
+}
+
+// resolveCompatibilityLevel returns the subject's compatibility level, falling back to the global level
+func resolveCompatibilityLevel(c *client.Client, subject, effectiveContext string) string {
+	if cfg, err := c.GetSubjectConfig(subject, effectiveContext); err == nil {
+		if level := configLevel(cfg); level != "" {
+			return level
+		}
+	}
+	if cfg, err := c.GetGlobalConfig(effectiveContext); err == nil {
+		if level := configLevel(cfg); level != "" {
+			return level
+		}
+	}
+	return string(client.CompatibilityBackward)
+}
+
+// configLevel returns the compatibility level from either field the registry may populate
+func configLevel(cfg *client.Config) string {
+	if cfg.CompatibilityLevel != "" {
+		return cfg.CompatibilityLevel
+	}
+	return cfg.Compatibility
+}
+
+// fetchPreviousSchemas returns the registered schemas a candidate must be checked against,
+// oldest first. A subject that does not exist yet has none, so any schema is compatible with
+// it, as the registry reports; a missing version given with --version is still an error.
+func fetchPreviousSchemas(c *client.Client, subject, level, effectiveContext string) ([]compat.Schema, error) {
+	_, _, transitive, err := compat.ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []string{"latest"}
+	if version != "" {
+		versions = []string{version}
+	} else if transitive {
+		registered, err := c.GetSubjectVersions(subject, effectiveContext)
+		if client.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get versions for subject %s: %w", subject, err)
+		}
+		versions = versions[:0]
+		for _, v := range registered {
+			versions = append(versions, fmt.Sprintf("%d", v))
+		}
+	}
+
+	var previous []compat.Schema
+	for _, v := range versions {
+		schema, err := c.GetSchema(subject, v, effectiveContext)
+		if client.IsNotFound(err) && v == "latest" {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get schema version %s: %w", v, err)
+		}
+		previous = append(previous, compat.Schema{
+			Label:   fmt.Sprintf("version %d", schema.Version),
+			Content: client.SchemaText(schema.Schema),
+		})
+	}
+	return previous, nil
+}
+
 func init() {
 	rootCmd.AddCommand(checkCmd)
 	checkCmd.AddCommand(checkCompatibilityCmd)
+	checkCmd.AddCommand(checkLevelCmd)
 
 	// Flags for compatibility check
 	checkCompatibilityCmd.Flags().StringVarP(&schemaFile, "file", "f", "", "Schema file path")
 	checkCompatibilityCmd.Flags().StringVar(&schemaString, "schema", "", "Schema content as string")
 	checkCompatibilityCmd.Flags().StringVarP(&schemaType, "type", "t", "AVRO", "Schema type (AVRO, JSON, PROTOBUF)")
+	checkCompatibilityCmd.Flags().StringVar(&idlRecord, "record", "", "Record to read from an Avro IDL (.avdl) file (default: its only top-level record)")
 	checkCompatibilityCmd.Flags().StringVar(&context, "context", "", "Schema Registry context")
 	checkCompatibilityCmd.Flags().StringVarP(&version, "version", "V", "", "Check compatibility against specific version (default: latest)")
 	checkCompatibilityCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table, json, yaml)")
+	checkCompatibilityCmd.Flags().BoolVar(&checkAllVersions, "all-versions", false, "Check against every registered version and report the levels the schema satisfies")
+	checkCompatibilityCmd.Flags().BoolVar(&localCheck, "local", false, "Evaluate compatibility locally instead of on the registry")
+	checkCompatibilityCmd.Flags().StringVar(&compatibilityLevel, "level", "", "Compatibility level for local checks (default: subject config, or BACKWARD offline)")
+	checkCompatibilityCmd.Flags().StringArrayVar(&againstFiles, "against", nil, "Previous schema file for offline local checks, oldest first (repeatable)")
+	checkCompatibilityCmd.Flags().BoolVar(&checkFix, "fix", false, "Apply the mechanical fixes to the --file schema and re-check it")
+	addReferenceFlags(checkCompatibilityCmd)
+
+	checkLevelCmd.Flags().StringVar(&proposedLevel, "level", "", "Compatibility level to evaluate the history under")
+	checkLevelCmd.Flags().StringVar(&checkEngine, "engine", "auto", "Engine checking each pair (auto, local, registry)")
+	checkLevelCmd.Flags().StringVar(&context, "context", "", "Schema Registry context")
+	checkLevelCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table, json, yaml)")
+	checkLevelCmd.MarkFlagRequired("level")
 }
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aywengo/ksr-cli/pkg/client"
+)
+
+func TestFetchPreviousSchemas_NewSubject(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error_code":40401,"message":"Subject 'orders-value' not found."}`))
+	}))
+	defer server.Close()
+
+	c, err := client.New(client.Options{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, level := range []string{"BACKWARD", "FULL_TRANSITIVE"} {
+		previous, err := fetchPreviousSchemas(c, "orders-value", level, "")
+		if err != nil || len(previous) != 0 {
+			t.Errorf("%s: expected no previous schemas for a new subject, got %v, %v", level, previous, err)
+		}
+	}
+
+	version = "3"
+	defer func() { version = "" }()
+	if _, err := fetchPreviousSchemas(c, "orders-value", "BACKWARD", ""); err == nil {
+		t.Error("Expected an error for a missing version given explicitly")
+	}
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/aywengo/ksr-cli/internal/convert"
+	"github.com/spf13/cobra"
+)
+
+var (
+	convertFrom       string
+	convertTo         string
+	convertSchemaType string
+	convertReferences []string
+	convertName       string
+	convertNamespace  string
+	convertMessage    string
+	convertOutFile    string
+	convertOutput     string
+	convertStrict     bool
+)
+
+// convertCmd represents the convert command
+var convertCmd = &cobra.Command{
+	Use:   "convert SUBJECT[@VERSION]|FILE",
+	Short: "Convert a schema between Avro, JSON Schema and Protobuf",
+	Long: func() string {
+		return fmt.Sprintf(`Convert a registered schema or a local schema file to another schema format.
+
+Supported conversions:
+
+  avro        -> json-schema  draft-07 schema for the plain JSON form of the records
+  avro        -> protobuf     proto3 file with a message per record, the root first
+  json-schema -> avro         record named after the title, or --name
+  protobuf    -> avro         record for the first message, or --message
+  json-schema <-> protobuf    through Avro, reporting the losses of both steps
+
+Constructs without an exact equivalent in the target format, such as unions, maps of
+non-string keys, logical types, oneofs, tuples, defaults or validation keywords, are
+converted to the closest match and reported on stderr with their field path, rather than
+dropped silently. With --strict a lossy conversion exits non-zero.
+
+The source format is the type of the registered schema, or is inferred from the file
+extension (override with --type); --from checks that it matches. With -o json the schema
+and the losses are written as one JSON document.
+
+Examples:
+  %s convert user-value --to json-schema
+  %s convert user.avsc --to protobuf --out user.proto
+  %s convert order.proto --to avro --message shop.Order
+  %s convert person.json --from json-schema --to avro --name Person --namespace com.example
+  %s convert user-value@2 --to protobuf --strict`, cmdName, cmdName, cmdName, cmdName, cmdName)
+	}(),
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		to, err := convert.ParseFormat(convertTo)
+		if err != nil {
+			return err
+		}
+		if convertOutput != "schema" && convertOutput != "json" {
+			return fmt.Errorf("unsupported output format: %s (expected schema or json)", convertOutput)
+		}
+
+		references, err := loadReferenceFiles(convertReferences)
+		if err != nil {
+			return err
+		}
+		source, err := loadSchemaArg(args[0], convertSchemaType, references)
+		if err != nil {
+			return err
+		}
+
+		from := convert.Format(source.SchemaType)
+		if convertFrom != "" {
+			expected, err := convert.ParseFormat(convertFrom)
+			if err != nil {
+				return err
+			}
+			if expected != from {
+				return fmt.Errorf("%s is a %s schema, not %s", source.Label, source.SchemaType, expected)
+			}
+		}
+
+		result, err := convert.Convert(from, to, source.Content, source.References, convert.Options{
+			Name:      convertName,
+			Namespace: convertNamespace,
+			Message:   convertMessage,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to convert %s: %w", source.Label, err)
+		}
+
+		content := result.Content
+		if convertOutput == "json" {
+			data, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode result: %w", err)
+			}
+			content = string(data) + "\n"
+		} else {
+			printLosses(result.Losses)
+		}
+
+		if err := writeGenerated(convertOutFile, content); err != nil {
+			return err
+		}
+
+		if convertStrict && len(result.Losses) > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("conversion is lossy: %d construct(s) not represented exactly", len(result.Losses))
+		}
+		return nil
+	},
+}
+
+// printLosses reports the losses of a conversion on stderr
+func printLosses(losses []convert.Loss) {
+	if len(losses) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "⚠️  %d construct(s) not represented exactly:\n", len(losses))
+	for _, loss := range losses {
+		fmt.Fprintf(os.Stderr, "  %s: %s\n", loss.Path, loss.Message)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(convertCmd)
+
+	convertCmd.Flags().StringVar(&convertTo, "to", "", "Target format (avro, json-schema, protobuf)")
+	convertCmd.Flags().StringVar(&convertFrom, "from", "", "Source format, checked against the schema type (avro, json-schema, protobuf)")
+	convertCmd.Flags().StringVarP(&convertSchemaType, "type", "t", "", "Schema type of a local file (default: inferred from extension)")
+	addReferenceFileFlag(convertCmd, &convertReferences, "Schema file defining types used by a local schema (repeatable)")
+	convertCmd.Flags().StringVar(&convertName, "name", "", "Name of the root record when converting JSON Schema (default: the title)")
+	convertCmd.Flags().StringVar(&convertNamespace, "namespace", "", "Namespace of the root record, or package of the Protobuf file")
+	convertCmd.Flags().StringVar(&convertMessage, "message", "", "Protobuf message to convert (default: the first message)")
+	convertCmd.Flags().StringVar(&convertOutFile, "out", "", "File to write (default: stdout)")
+	convertCmd.Flags().StringVarP(&convertOutput, "output", "o", "schema", "Output format (schema, json)")
+	convertCmd.Flags().BoolVar(&convertStrict, "strict", false, "Exit non-zero when the conversion is lossy")
+	convertCmd.Flags().StringVar(&idlRecord, "record", "", "Record to read from an Avro IDL (.avdl) file (default: its only top-level record)")
+	convertCmd.Flags().StringVar(&context, "context", "", "Schema Registry context")
+	convertCmd.MarkFlagRequired("to")
+}
@@ -1,13 +1,14 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
-	"github.com/aywengo/ksr-cli/internal/client"
 	"github.com/aywengo/ksr-cli/internal/config"
 	"github.com/aywengo/ksr-cli/internal/output"
+	"github.com/aywengo/ksr-cli/internal/schema"
+	"github.com/aywengo/ksr-cli/pkg/client"
 	"github.com/spf13/cobra"
 )
 
@@ -33,9 +34,21 @@ The schema can be provided via:
   - Inline using --schema flag
   - Standard input (if neither flag is provided)
 
+Avro IDL (.avdl) files are translated to an Avro schema locally. Select the record to
+register with --record when the file defines several top-level records.
+
+References are given with --ref NAME=SUBJECT:VERSION, or resolved from --ref-dir: each
+import of a Protobuf schema, and each named type an Avro schema uses without defining it,
+is matched to a file of the directory and to the subject it is registered under, named
+after the import path or the type with --ref-subject-strategy. With --register-refs,
+dependencies that are not registered yet are registered first.
+
 Examples:
   ksr-cli create schema my-subject --file schema.avsc
+  ksr-cli create schema my-subject --file events.avdl --record Click
   ksr-cli create schema my-subject --schema '{"type":"string"}'
+  ksr-cli create schema orders-value --file order.proto --type PROTOBUF --ref-dir protos --register-refs
+  ksr-cli create schema users-value --file user.avsc --ref com.acme.Address=address-value:latest
   cat schema.avsc | ksr-cli create schema my-subject`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -47,26 +60,33 @@ Examples:
 			return fmt.Errorf("failed to get schema content: %w", err)
 		}
 
-		// Validate schema content is valid JSON
-		var schemaObj interface{}
-		if err := json.Unmarshal([]byte(schemaContent), &schemaObj); err != nil {
-			return fmt.Errorf("invalid schema JSON: %w", err)
-		}
-
 		// Create client
 		c, err := createClientWithFlags()
 		if err != nil {
 			return fmt.Errorf("failed to create client: %w", err)
 		}
+		effectiveContext := config.GetEffectiveContext(context)
+
+		// Resolve references, registering missing dependencies when asked
+		references, referenceContents, err := resolveSchemaReferences(c, schemaContent, effectiveContext)
+		if err != nil {
+			return err
+		}
+
+		// Validate schema content with the parser for its type
+		actualSchemaType, _ := cmd.Flags().GetString("type")
+		if err := validateSchemaContent(actualSchemaType, schemaContent, referenceContents); err != nil {
+			return err
+		}
 
 		// Prepare schema request
 		schemaReq := &client.SchemaRequest{
 			Schema:     schemaContent,
 			SchemaType: schemaType,
+			References: references,
 		}
 
 		// Register schema
-		effectiveContext := config.GetEffectiveContext(context)
 		result, err := c.RegisterSchema(subject, schemaReq, effectiveContext)
 		if err != nil {
 			return fmt.Errorf("failed to register schema: %w", err)
@@ -88,6 +108,22 @@ Examples:
 	},
 }
 
+// validateSchemaContent parses a schema before it is sent to the registry. The Protobuf
+// parser does not model every construct the registry accepts, such as groups and
+// well-known imports like google/type/money.proto, so Protobuf errors are only warnings and
+// the registry has the final say.
+func validateSchemaContent(schemaType, content string, references []string) error {
+	err := schema.Validate(schemaType, content, references...)
+	if err == nil {
+		return nil
+	}
+	if strings.EqualFold(schemaType, "PROTOBUF") {
+		fmt.Fprintf(os.Stderr, "⚠️  Could not validate the Protobuf schema locally, leaving it to the registry: %v\n", err)
+		return nil
+	}
+	return fmt.Errorf("invalid schema: %w", err)
+}
+
 func init() {
 	rootCmd.AddCommand(createCmd)
 	createCmd.AddCommand(createSchemaCmd)
@@ -96,6 +132,9 @@ func init() {
 	createSchemaCmd.Flags().StringVarP(&schemaFile, "file", "f", "", "Schema file path")
 	createSchemaCmd.Flags().StringVar(&schemaString, "schema", "", "Schema content as string")
 	createSchemaCmd.Flags().StringVarP(&schemaType, "type", "t", "AVRO", "Schema type (AVRO, JSON, PROTOBUF)")
+	createSchemaCmd.Flags().StringVar(&idlRecord, "record", "", "Record to read from an Avro IDL (.avdl) file (default: its only top-level record)")
 	createSchemaCmd.Flags().StringVar(&context, "context", "", "Schema Registry context")
+	addReferenceFlags(createSchemaCmd)
+	createSchemaCmd.Flags().BoolVar(&registerReferences, "register-refs", false, "Register dependencies from --ref-dir that are not registered yet")
 	createSchemaCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table, json, yaml)")
 }
@@ -0,0 +1,22 @@
+package cmd
+
+import "testing"
+
+func TestValidateSchemaContent(t *testing.T) {
+	unmodeled := []string{
+		`syntax = "proto3";
+import "google/type/money.proto";
+message Order { google.type.Money total = 1; }`,
+		`syntax = "proto2";
+message Order { optional group Line = 1 { optional string sku = 2; } }`,
+	}
+	for _, content := range unmodeled {
+		if err := validateSchemaContent("PROTOBUF", content, nil); err != nil {
+			t.Errorf("Expected Protobuf schemas the parser does not model to be left to the registry, got %v", err)
+		}
+	}
+
+	if err := validateSchemaContent("AVRO", `{"type":"record","name":"Order","fields":[{"name":"id","type":"uuid"}]}`, nil); err == nil {
+		t.Error("Expected an error for an invalid Avro schema")
+	}
+}
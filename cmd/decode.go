@@ -0,0 +1,377 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/internal/avro"
+	"github.com/aywengo/ksr-cli/internal/config"
+	"github.com/aywengo/ksr-cli/internal/jsonfmt"
+	"github.com/aywengo/ksr-cli/internal/protobuf"
+	"github.com/aywengo/ksr-cli/internal/wire"
+	"github.com/aywengo/ksr-cli/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	decodeFiles    []string
+	decodeEncoding string
+	decodeField    string
+	decodeAvroJSON bool
+	decodeOutput   string
+)
+
+// decodeCmd represents the decode command
+var decodeCmd = &cobra.Command{
+	Use:   "decode [MESSAGE...]",
+	Short: "Decode Confluent wire format messages into JSON",
+	Long: func() string {
+		return fmt.Sprintf(`Decode messages in the Confluent wire format, fetching the writer schema of each
+message from the registry by its schema ID.
+
+A message starts with a zero magic byte and a 4-byte schema ID; Protobuf messages
+follow it with the indexes of their message type. Payloads are decoded as follows:
+
+  AVRO      Avro binary, written as plain JSON or, with --avro-json, in the Avro JSON
+            encoding, which wraps non-null union values by branch type
+  JSON      JSON Schema payloads are JSON already
+  PROTOBUF  Protobuf binary, written in the Protobuf JSON mapping
+
+Messages are given as arguments or read from --file ("-" for stdin), one per line, in
+hex or base64 (detected automatically, or set with --encoding). Lines holding a JSON
+object, such as consumer output in NDJSON, are written back with the message in their
+payload field (--field, default "payload" or "value") replaced by the decoded record.
+With --encoding binary each file holds one raw message.
+
+Schemas are fetched once per ID. Messages that fail to decode are reported on stderr
+and make the command exit non-zero.
+
+Examples:
+  %s decode 0000000001060a6a6f686e
+  %s decode AAAAAAEGCmpvaG4= --avro-json
+  %s decode -f dump.hex
+  %s decode -f message.bin --encoding binary
+  kcat -C -t users -J -e | %s decode -f - --field payload`, cmdName, cmdName, cmdName, cmdName, cmdName)
+	}(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && len(decodeFiles) == 0 {
+			return fmt.Errorf("a message or --file is required")
+		}
+		switch decodeEncoding {
+		case "auto", "hex", "base64", "binary":
+		default:
+			return fmt.Errorf("unsupported encoding: %s (expected auto, hex, base64 or binary)", decodeEncoding)
+		}
+		if decodeEncoding == "binary" && len(args) > 0 {
+			return fmt.Errorf("--encoding binary reads messages from --file only")
+		}
+		if decodeOutput != "ndjson" && decodeOutput != "json" {
+			return fmt.Errorf("unsupported output format: %s (expected ndjson or json)", decodeOutput)
+		}
+
+		c, err := createClientWithFlags()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		decoder := &messageDecoder{
+			client:   c,
+			context:  config.GetEffectiveContext(context),
+			avroJSON: decodeAvroJSON,
+			schemas:  map[int]*writerSchema{},
+		}
+
+		var records []interface{}
+		failures := 0
+		emit := func(location string, record interface{}, err error) error {
+			if err != nil {
+				failures++
+				fmt.Fprintf(os.Stderr, "%s: %v\n", location, err)
+				return nil
+			}
+			if decodeOutput == "json" {
+				records = append(records, record)
+				return nil
+			}
+			line, err := json.Marshal(record)
+			if err != nil {
+				return fmt.Errorf("failed to encode record: %w", err)
+			}
+			fmt.Println(string(line))
+			return nil
+		}
+
+		for i, arg := range args {
+			record, err := decoder.decodeText(arg)
+			if err := emit(fmt.Sprintf("argument %d", i+1), record, err); err != nil {
+				return err
+			}
+		}
+		for _, path := range decodeFiles {
+			if err := decodeFile(decoder, path, emit); err != nil {
+				return err
+			}
+		}
+
+		if decodeOutput == "json" {
+			if records == nil {
+				records = []interface{}{}
+			}
+			data, err := json.MarshalIndent(records, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode records: %w", err)
+			}
+			fmt.Println(string(data))
+		}
+
+		if failures > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("failed to decode %d message(s)", failures)
+		}
+		return nil
+	},
+}
+
+// writerSchema is a parsed schema used to encode or decode messages
+type writerSchema struct {
+	schemaType string
+	avro       *avro.Schema
+	proto      *protobuf.File
+	registry   *protobuf.Registry
+}
+
+// messageDecoder decodes wire format messages, caching the parsed schema for each ID
+type messageDecoder struct {
+	client   *client.Client
+	context  string
+	avroJSON bool
+	schemas  map[int]*writerSchema
+}
+
+// schema fetches and parses the schema registered under an ID
+func (d *messageDecoder) schema(id int) (*writerSchema, error) {
+	if ws, ok := d.schemas[id]; ok {
+		return ws, nil
+	}
+
+	registered, err := d.client.GetSchemaByID(id, d.context)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema ID %d: %w", id, err)
+	}
+	source, err := registrySchemaSource(d.client, fmt.Sprintf("schema ID %d", id), registered, d.context)
+	if err != nil {
+		return nil, err
+	}
+
+	ws, err := parseWriterSchema(source)
+	if err != nil {
+		return nil, err
+	}
+	d.schemas[id] = ws
+	return ws, nil
+}
+
+// parseWriterSchema parses a schema for encoding or decoding messages
+func parseWriterSchema(source *schemaSource) (*writerSchema, error) {
+	var err error
+	ws := &writerSchema{schemaType: source.SchemaType}
+	switch source.SchemaType {
+	case "AVRO":
+		ws.avro, err = avro.ParseWithReferences(source.Content, source.References)
+	case "PROTOBUF":
+		ws.proto, ws.registry, err = protobuf.ParseWithReferences(source.Content, source.References)
+	case "JSON":
+	default:
+		err = fmt.Errorf("unsupported schema type %s", source.SchemaType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", source.Label, err)
+	}
+	return ws, nil
+}
+
+// decode decodes one wire format message
+func (d *messageDecoder) decode(message []byte) (interface{}, error) {
+	id, payload, err := wire.Parse(message)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := d.schema(id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ws.schemaType {
+	case "AVRO":
+		return avro.DecodeBinary(ws.avro, payload, d.avroJSON)
+	case "PROTOBUF":
+		indexes, payload, err := wire.ParseMessageIndexes(payload)
+		if err != nil {
+			return nil, err
+		}
+		m, err := protobuf.MessageByIndexes(ws.proto, indexes)
+		if err != nil {
+			return nil, err
+		}
+		return protobuf.DecodeBinary(m, ws.registry, payload)
+	default:
+		value, err := jsonfmt.Decode(payload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON payload: %w", err)
+		}
+		return value, nil
+	}
+}
+
+// decodeText decodes a message given in hex or base64
+func (d *messageDecoder) decodeText(text string) (interface{}, error) {
+	message, err := parseMessageText(text, decodeEncoding)
+	if err != nil {
+		return nil, err
+	}
+	return d.decode(message)
+}
+
+// decodeLine decodes a line holding a message, or a JSON object with the message in its
+// payload field, which is replaced by the decoded record
+func (d *messageDecoder) decodeLine(line string) (interface{}, error) {
+	if !strings.HasPrefix(line, "{") {
+		return d.decodeText(line)
+	}
+
+	value, err := jsonfmt.Decode([]byte(line))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	object, _ := value.(*jsonfmt.Object)
+
+	fields := []string{decodeField}
+	if decodeField == "" {
+		fields = []string{"payload", "value"}
+	}
+	for _, field := range fields {
+		payload, ok := object.Get(field)
+		if !ok {
+			continue
+		}
+		switch payload := payload.(type) {
+		case nil:
+			// Tombstones have no payload
+		case string:
+			record, err := d.decodeText(payload)
+			if err != nil {
+				return nil, err
+			}
+			object.Set(field, record)
+		default:
+			return nil, fmt.Errorf("field %s does not hold an encoded message", field)
+		}
+		return object, nil
+	}
+	return nil, fmt.Errorf("no %s field in JSON line", strings.Join(fields, " or "))
+}
+
+// decodeFile decodes the messages of a file, one per line, or the whole file as one
+// message with --encoding binary
+func decodeFile(decoder *messageDecoder, path string, emit func(string, interface{}, error) error) error {
+	input := os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	if decodeEncoding == "binary" {
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		record, err := decoder.decode(data)
+		return emit(path, record, err)
+	}
+
+	reader := bufio.NewReader(input)
+	for lineNumber := 1; ; lineNumber++ {
+		data, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		if line := string(bytes.TrimSpace(data)); line != "" {
+			record, decodeErr := decoder.decodeLine(line)
+			if emitErr := emit(fmt.Sprintf("%s:%d", path, lineNumber), record, decodeErr); emitErr != nil {
+				return emitErr
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+	}
+}
+
+// parseMessageText decodes a message written in hex or base64. Hex may contain spaces
+// and a 0x prefix. Automatic detection prefers hex for text starting with the magic byte
+// "00", and accepts payloads that hold the raw bytes as a string.
+func parseMessageText(text, encoding string) ([]byte, error) {
+	text = strings.TrimSpace(text)
+	compact := strings.TrimPrefix(strings.Join(strings.Fields(text), ""), "0x")
+
+	switch encoding {
+	case "hex":
+		data, err := hex.DecodeString(compact)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex message: %w", err)
+		}
+		return data, nil
+	case "base64":
+		if data, ok := decodeBase64(compact); ok {
+			return data, nil
+		}
+		return nil, fmt.Errorf("invalid base64 message")
+	default:
+		if strings.HasPrefix(text, "\x00") {
+			return []byte(text), nil
+		}
+		if strings.HasPrefix(compact, "00") {
+			if data, err := hex.DecodeString(compact); err == nil {
+				return data, nil
+			}
+		}
+		if data, ok := decodeBase64(compact); ok {
+			return data, nil
+		}
+		return nil, fmt.Errorf("message is neither hex nor base64")
+	}
+}
+
+// decodeBase64 accepts standard and URL-safe base64, with or without padding
+func decodeBase64(text string) ([]byte, bool) {
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if data, err := encoding.DecodeString(text); err == nil {
+			return data, true
+		}
+	}
+	return nil, false
+}
+
+func init() {
+	rootCmd.AddCommand(decodeCmd)
+
+	decodeCmd.Flags().StringArrayVarP(&decodeFiles, "file", "f", nil, "File of messages, one per line, or \"-\" for stdin (repeatable)")
+	decodeCmd.Flags().StringVar(&decodeEncoding, "encoding", "auto", "Encoding of the messages (auto, hex, base64, binary)")
+	decodeCmd.Flags().StringVar(&decodeField, "field", "", "Field of JSON lines holding the message (default: payload or value)")
+	decodeCmd.Flags().BoolVar(&decodeAvroJSON, "avro-json", false, "Write Avro records in the Avro JSON encoding, with unions wrapped by branch type")
+	decodeCmd.Flags().StringVarP(&decodeOutput, "output", "o", "ndjson", "Output format (ndjson, json)")
+	decodeCmd.Flags().StringVar(&context, "context", "", "Schema Registry context")
+}
@@ -1,15 +1,23 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
+	"os"
+	"sort"
+	"strings"
 
-	"github.com/aywengo/ksr-cli/internal/client"
 	"github.com/aywengo/ksr-cli/internal/config"
 	"github.com/aywengo/ksr-cli/internal/output"
+	"github.com/aywengo/ksr-cli/internal/schema"
+	"github.com/aywengo/ksr-cli/pkg/client"
 	"github.com/spf13/cobra"
 )
 
+var (
+	describeFingerprints bool
+	describeDepth        int
+)
+
 // describeCmd represents the describe command
 var describeCmd = &cobra.Command{
 	Use:   "describe [SUBJECT]",
@@ -19,13 +27,18 @@ var describeCmd = &cobra.Command{
 
 Without arguments, describes the Schema Registry instance itself.
 With --context flag, describes the specified context.
-With a subject name, describes the specific subject.
+With a subject name, describes the specific subject. Its latest schema is analyzed down to
+nested records, arrays, maps, unions and Protobuf messages: field counts, nesting depth,
+nullable fields, fields without default or doc, logical types and references. Table output
+ends with a tree of the fields, expanded to --depth levels.
 
 Examples:
   %s describe                           # Describe Schema Registry instance
   %s describe --context production      # Describe production context
   %s describe my-subject                # Describe a specific subject
-  %s describe user-value --context dev  # Describe subject in dev context`, cmdName, cmdName, cmdName, cmdName)
+  %s describe user-value --context dev  # Describe subject in dev context
+  %s describe my-subject --depth 0      # Expand every level of the field tree
+  %s describe my-subject --fingerprints # Include fingerprints of the latest schema`, cmdName, cmdName, cmdName, cmdName, cmdName, cmdName)
 	}(),
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -129,6 +142,7 @@ func describeSubject(c *client.Client, subject string, cmd *cobra.Command) error
 	description := &client.SubjectDescription{
 		Name: subject,
 	}
+	var fieldTree *schema.Tree
 
 	// Get subject versions
 	versions, err := c.GetSubjectVersions(subject, effectiveContext)
@@ -143,13 +157,35 @@ func describeSubject(c *client.Client, subject string, cmd *cobra.Command) error
 		description.LatestVersion = latestVersion
 
 		// Get latest schema
-		if schema, err := c.GetSchema(subject, "latest", effectiveContext); err == nil {
-			description.LatestSchema = schema
-			description.SchemaType = schema.Type
+		if latest, err := c.GetSchema(subject, "latest", effectiveContext); err == nil {
+			description.LatestSchema = latest
+			description.SchemaType = latest.Type
+
+			source, err := registrySchemaSource(c, subject, latest, effectiveContext)
+			if err != nil && describeFingerprints {
+				return err
+			}
 
 			// Analyze schema fields
-			if fieldInfo := analyzeSchemaFields(schema); fieldInfo != nil {
-				description.FieldCount = fieldInfo.FieldCount
+			var tree *schema.Tree
+			if err == nil {
+				tree, err = schema.Parse(source.SchemaType, source.Content, source.References...)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "⚠️  Could not analyze the fields of %s: %v\n", subject, err)
+			} else {
+				fieldTree = tree
+				description.Fields = analyzeSchemaFields(tree, latest.References)
+				description.FieldCount = description.Fields.FieldCount
+			}
+
+			if describeFingerprints {
+				fingerprints, err := schemaFingerprints(source)
+				if err != nil {
+					return err
+				}
+				fingerprints.CanonicalForm = ""
+				description.Fingerprints = fingerprints
 			}
 		}
 	}
@@ -167,46 +203,108 @@ func describeSubject(c *client.Client, subject string, cmd *cobra.Command) error
 	// Generate suggested commands
 	description.SuggestedCommands = generateSuggestedCommands(subject, effectiveContext)
 
-	return output.Print(description, outputFormat)
-}
-
-// analyzeSchemaFields analyzes schema to extract field information
-func analyzeSchemaFields(schema *client.Schema) *client.SchemaFieldInfo {
-	if schema == nil || len(schema.Schema) == 0 {
-		return nil
+	if outputFormat != "table" {
+		return output.Print(description, outputFormat)
 	}
 
-	// Try to parse the schema JSON to count fields
-	var schemaObj map[string]interface{}
-	if err := json.Unmarshal(schema.Schema, &schemaObj); err != nil {
-		return nil
+	// The field analysis is printed below the table rather than in a column
+	if err := output.Print(subjectDescriptionRow{
+		Name:              description.Name,
+		Versions:          description.Versions,
+		LatestVersion:     description.LatestVersion,
+		LatestSchema:      description.LatestSchema,
+		Config:            description.Config,
+		Mode:              description.Mode,
+		SchemaType:        description.SchemaType,
+		FieldCount:        description.FieldCount,
+		Fingerprints:      description.Fingerprints,
+		SuggestedCommands: description.SuggestedCommands,
+	}, outputFormat); err != nil {
+		return err
 	}
+	if fieldTree != nil {
+		printFieldAnalysis(description.Fields, fieldTree)
+	}
+	return nil
+}
 
-	fieldInfo := &client.SchemaFieldInfo{}
+// subjectDescriptionRow is a SubjectDescription without the field analysis, for table output
+type subjectDescriptionRow struct {
+	Name              string                     `json:"name"`
+	Versions          []int                      `json:"versions,omitempty"`
+	LatestVersion     int                        `json:"latest_version,omitempty"`
+	LatestSchema      *client.Schema             `json:"latest_schema,omitempty"`
+	Config            *client.Config             `json:"config,omitempty"`
+	Mode              *client.Mode               `json:"mode,omitempty"`
+	SchemaType        string                     `json:"schema_type,omitempty"`
+	FieldCount        int                        `json:"field_count,omitempty"`
+	Fingerprints      *client.SchemaFingerprints `json:"fingerprints,omitempty"`
+	SuggestedCommands []string                   `json:"suggested_commands,omitempty"`
+}
 
-	// For AVRO schemas
-	if fields, ok := schemaObj["fields"].([]interface{}); ok {
-		fieldInfo.FieldCount = len(fields)
+// analyzeSchemaFields walks nested records, arrays, maps, unions and messages to summarize
+// the fields of a schema
+func analyzeSchemaFields(tree *schema.Tree, references []client.Reference) *client.SchemaFieldInfo {
+	fieldInfo := &client.SchemaFieldInfo{FieldCount: len(tree.Fields), References: references}
+	for _, node := range tree.Fields {
+		fieldInfo.FieldNames = append(fieldInfo.FieldNames, node.Name)
+	}
 
-		// Extract field names
-		for _, field := range fields {
-			if fieldMap, ok := field.(map[string]interface{}); ok {
-				if name, ok := fieldMap["name"].(string); ok {
-					fieldInfo.FieldNames = append(fieldInfo.FieldNames, name)
-				}
-			}
+	logicalTypes := map[string]bool{}
+	tree.Walk(func(node *schema.Node, depth int) bool {
+		fieldInfo.TotalFields++
+		if len(node.Children) == 0 {
+			fieldInfo.LeafFields++
 		}
-	} else if properties, ok := schemaObj["properties"].(map[string]interface{}); ok {
-		// For JSON schemas
-		fieldInfo.FieldCount = len(properties)
-		for name := range properties {
-			fieldInfo.FieldNames = append(fieldInfo.FieldNames, name)
+		if depth > fieldInfo.MaxDepth {
+			fieldInfo.MaxDepth = depth
 		}
-	}
+		if node.Nullable {
+			fieldInfo.NullableFields = append(fieldInfo.NullableFields, node.Path)
+		}
+		// Protobuf fields always default to their zero value
+		if !node.HasDefault && tree.Format != schema.FormatProtobuf {
+			fieldInfo.FieldsWithoutDefault = append(fieldInfo.FieldsWithoutDefault, node.Path)
+		}
+		if node.Doc == "" {
+			fieldInfo.FieldsWithoutDoc = append(fieldInfo.FieldsWithoutDoc, node.Path)
+		}
+		if node.LogicalType != "" && !logicalTypes[node.LogicalType] {
+			logicalTypes[node.LogicalType] = true
+			fieldInfo.LogicalTypes = append(fieldInfo.LogicalTypes, node.LogicalType)
+		}
+		return true
+	})
+	sort.Strings(fieldInfo.LogicalTypes)
 
 	return fieldInfo
 }
 
+// printFieldAnalysis prints the field summary and the field tree, expanded to --depth levels
+func printFieldAnalysis(fieldInfo *client.SchemaFieldInfo, tree *schema.Tree) {
+	fmt.Printf("\nFields: %d total, %d leaf, nesting depth %d\n", fieldInfo.TotalFields, fieldInfo.LeafFields, fieldInfo.MaxDepth)
+	fmt.Printf("Nullable: %d, without default: %d, without doc: %d\n",
+		len(fieldInfo.NullableFields), len(fieldInfo.FieldsWithoutDefault), len(fieldInfo.FieldsWithoutDoc))
+	if len(fieldInfo.LogicalTypes) > 0 {
+		fmt.Printf("Logical types: %s\n", strings.Join(fieldInfo.LogicalTypes, ", "))
+	}
+	for _, ref := range fieldInfo.References {
+		fmt.Printf("Reference: %s -> %s version %d\n", ref.Name, ref.Subject, ref.Version)
+	}
+
+	fmt.Println()
+	fmt.Print(formatFieldTree(tree.Fields, "", 1, describeDepth))
+	if describeDepth > 0 && fieldInfo.MaxDepth > describeDepth {
+		fmt.Printf("(nested fields below depth %d are collapsed; expand with --depth %d or --depth 0)\n", describeDepth, fieldInfo.MaxDepth)
+	}
+}
+
+// formatFieldTree draws fields as a tree with the details show prints, collapsing the
+// children of fields deeper than maxDepth unless it is 0
+func formatFieldTree(nodes []*schema.Node, prefix string, depth, maxDepth int) string {
+	return schema.RenderNodes(nodes, prefix, depth, treeOptions(maxDepth))
+}
+
 // generateSuggestedCommands generates helpful commands for the user
 func generateSuggestedCommands(subject, context string) []string {
 	commands := []string{}
@@ -231,5 +329,7 @@ func init() {
 
 	// Add flags
 	describeCmd.Flags().StringVar(&context, "context", "", "Schema Registry context")
+	describeCmd.Flags().BoolVar(&describeFingerprints, "fingerprints", false, "Include fingerprints of the latest schema")
+	describeCmd.Flags().IntVar(&describeDepth, "depth", 2, "Levels of nested fields to expand in the field tree (0 for all)")
 	describeCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table, json, yaml)")
 }
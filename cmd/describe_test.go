@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aywengo/ksr-cli/internal/schema"
+	"github.com/aywengo/ksr-cli/pkg/client"
+)
+
+const describeTestSchema = `{"type":"record","name":"Order","fields":[
+	{"name":"id","type":{"type":"string","logicalType":"uuid"},"doc":"Order id"},
+	{"name":"customer","type":{"type":"record","name":"Customer","fields":[
+		{"name":"address","type":["null",{"type":"record","name":"Address","fields":[{"name":"zip","type":"string"}]}],"default":null}
+	]}},
+	{"name":"items","type":{"type":"array","items":{"type":"record","name":"Item","fields":[{"name":"qty","type":"int","default":1}]}}}
+]}`
+
+func TestAnalyzeSchemaFields(t *testing.T) {
+	tree, err := schema.Parse("AVRO", describeTestSchema)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	refs := []client.Reference{{Name: "x", Subject: "x-value", Version: 1}}
+	info := analyzeSchemaFields(tree, refs)
+
+	if info.FieldCount != 3 || strings.Join(info.FieldNames, ",") != "id,customer,items" {
+		t.Errorf("Expected 3 top-level fields, got %d: %v", info.FieldCount, info.FieldNames)
+	}
+	if info.TotalFields != 6 || info.LeafFields != 3 || info.MaxDepth != 3 {
+		t.Errorf("Expected 6 fields, 3 leaves and depth 3, got %d, %d and %d", info.TotalFields, info.LeafFields, info.MaxDepth)
+	}
+	if strings.Join(info.NullableFields, ",") != "customer.address" {
+		t.Errorf("Expected customer.address to be nullable, got %v", info.NullableFields)
+	}
+	if strings.Join(info.FieldsWithoutDefault, ",") != "id,customer,customer.address.zip,items" {
+		t.Errorf("Unexpected fields without default: %v", info.FieldsWithoutDefault)
+	}
+	if len(info.FieldsWithoutDoc) != 5 || strings.Join(info.LogicalTypes, ",") != "uuid" || len(info.References) != 1 {
+		t.Errorf("Unexpected analysis: %+v", info)
+	}
+}
+
+func TestFormatFieldTree(t *testing.T) {
+	tree, err := schema.Parse("AVRO", describeTestSchema)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `├── id: string(uuid)  # Order id
+├── customer: Customer
+│   └── address: union[null,Address] (nullable, default null) [+1 nested]
+└── items: array<Item>
+    └── qty: int (default 1)
+`
+	if got := formatFieldTree(tree.Fields, "", 1, 2); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+	if got := formatFieldTree(tree.Fields, "", 1, 0); !strings.Contains(got, "│       └── zip: string\n") {
+		t.Errorf("Expected every level to be expanded, got:\n%s", got)
+	}
+}
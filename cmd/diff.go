@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/aywengo/ksr-cli/internal/config"
+	"github.com/aywengo/ksr-cli/internal/output"
+	"github.com/aywengo/ksr-cli/internal/schema"
+	"github.com/aywengo/ksr-cli/internal/textdiff"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffFiles        []string
+	diffSchemaType   string
+	diffOtherContext string
+	diffColor        string
+	diffContextLines int
+)
+
+// DiffResult is the structural comparison of two schemas
+type DiffResult struct {
+	Before  string          `json:"before"`
+	After   string          `json:"after"`
+	Changes []schema.Change `json:"changes"`
+}
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff [SUBJECT [V1 [V2]] | SUBJECT_A[@VERSION] SUBJECT_B[@VERSION]]",
+	Short: "Compare schemas structurally",
+	Long: func() string {
+		return fmt.Sprintf(`Compare two schemas and report added, removed and changed fields, type changes,
+default changes and doc changes.
+
+The schemas to compare can be:
+  - Two versions of a subject:           SUBJECT V1 V2 (V2 defaults to latest)
+  - Two subjects, optionally versioned:  SUBJECT_A[@VERSION] SUBJECT_B[@VERSION]
+  - Two local files:                     --file a.avsc --file b.avsc
+  - A subject and a local file:          SUBJECT[@VERSION] --file new.avsc
+
+Use --other-context to read the second subject from a different context. Avro IDL
+(.avdl) files are compared as the Avro schema of the record selected with --record.
+
+Output formats:
+  table  One row per change (default)
+  json   Structured changes
+  yaml   Structured changes
+  diff   Unified diff of the pretty-printed schemas, colored on terminals
+
+Examples:
+  %s diff my-subject 1 2
+  %s diff my-subject 1                                   # version 1 against latest
+  %s diff orders-value@3 payments-value
+  %s diff my-subject --context dev --other-context prod my-subject
+  %s diff --file old.avsc --file new.avsc -o diff
+  %s diff my-subject --file new.avsc -o json
+  %s diff my-subject --file events.avdl --record Click`, cmdName, cmdName, cmdName, cmdName, cmdName, cmdName, cmdName)
+	}(),
+	Args: cobra.MaximumNArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		before, after, err := resolveDiffSources(args)
+		if err != nil {
+			return err
+		}
+
+		actualOutputFormat, _ := cmd.Flags().GetString("output")
+
+		if actualOutputFormat == "diff" {
+			return printUnifiedSchemaDiff(before, after)
+		}
+
+		beforeTree, err := schema.Parse(before.SchemaType, before.Content, before.References...)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", before.Label, err)
+		}
+		afterTree, err := schema.Parse(after.SchemaType, after.Content, after.References...)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", after.Label, err)
+		}
+
+		result := &DiffResult{
+			Before:  before.Label,
+			After:   after.Label,
+			Changes: schema.Diff(beforeTree, afterTree),
+		}
+
+		if actualOutputFormat != "table" {
+			return output.Print(result, actualOutputFormat)
+		}
+
+		fmt.Printf("Comparing %s → %s\n", result.Before, result.After)
+		if len(result.Changes) == 0 {
+			fmt.Println("No differences found")
+			return nil
+		}
+
+		rows := make([]interface{}, 0, len(result.Changes))
+		for _, change := range result.Changes {
+			rows = append(rows, change)
+		}
+		return output.Print(rows, actualOutputFormat)
+	},
+}
+
+// resolveDiffSources determines the two schemas to compare from files and arguments
+func resolveDiffSources(args []string) (*schemaSource, *schemaSource, error) {
+	var sources []*schemaSource
+
+	if len(args) > 0 {
+		c, err := createClientWithFlags()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create client: %w", err)
+		}
+		effectiveContext := config.GetEffectiveContext(context)
+		otherContext := effectiveContext
+		if diffOtherContext != "" {
+			otherContext = diffOtherContext
+		}
+
+		specs, err := diffSpecs(args)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, spec := range specs {
+			ctx := effectiveContext
+			if i > 0 {
+				ctx = otherContext
+			}
+			source, err := loadRegistrySchema(c, spec.subject, spec.version, ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+			if diffOtherContext != "" {
+				source.Label = fmt.Sprintf("%s [context %s]", source.Label, ctx)
+			}
+			sources = append(sources, source)
+		}
+	}
+
+	for _, file := range diffFiles {
+		source, err := loadSchemaFile(file, diffSchemaType)
+		if err != nil {
+			return nil, nil, err
+		}
+		sources = append(sources, source)
+	}
+
+	if len(sources) != 2 {
+		return nil, nil, fmt.Errorf("exactly two schemas are required: got %d (use SUBJECT V1 V2, two subjects, or --file)", len(sources))
+	}
+	return sources[0], sources[1], nil
+}
+
+// subjectVersionSpec identifies a registered schema version
+type subjectVersionSpec struct {
+	subject string
+	version string
+}
+
+// diffSpecs interprets the positional arguments as subject/version pairs
+func diffSpecs(args []string) ([]subjectVersionSpec, error) {
+	switch {
+	case len(args) == 3:
+		return []subjectVersionSpec{{args[0], args[1]}, {args[0], args[2]}}, nil
+	case len(args) == 2 && isVersionArg(args[1]) && len(diffFiles) > 0:
+		return []subjectVersionSpec{{args[0], args[1]}}, nil
+	case len(args) == 2 && isVersionArg(args[1]):
+		return []subjectVersionSpec{{args[0], args[1]}, {args[0], "latest"}}, nil
+	default:
+		var specs []subjectVersionSpec
+		for _, arg := range args {
+			subject, version := parseSubjectVersion(arg)
+			specs = append(specs, subjectVersionSpec{subject, version})
+		}
+		if len(specs) == 1 && len(diffFiles) == 0 {
+			return nil, fmt.Errorf("a second schema is required: pass a version, another subject, or --file")
+		}
+		return specs, nil
+	}
+}
+
+// parseSubjectVersion splits "subject@version", defaulting to the latest version
+func parseSubjectVersion(arg string) (string, string) {
+	for i := len(arg) - 1; i > 0; i-- {
+		if arg[i] == '@' {
+			if isVersionArg(arg[i+1:]) {
+				return arg[:i], arg[i+1:]
+			}
+			break
+		}
+	}
+	return arg, "latest"
+}
+
+// isVersionArg reports whether an argument names a schema version
+func isVersionArg(arg string) bool {
+	if arg == "latest" {
+		return true
+	}
+	_, err := strconv.Atoi(arg)
+	return err == nil
+}
+
+// printUnifiedSchemaDiff prints a unified diff of the pretty-printed schemas
+func printUnifiedSchemaDiff(before, after *schemaSource) error {
+	diff := textdiff.Unified(before.Label, after.Label, prettySchema(before), prettySchema(after), diffContextLines)
+	if diff == "" {
+		fmt.Println("No differences found")
+		return nil
+	}
+
+	if useColor(diffColor) {
+		diff = textdiff.Colorize(diff)
+	}
+	fmt.Print(diff)
+	return nil
+}
+
+// prettySchema formats schema content for line-based comparison
+func prettySchema(source *schemaSource) string {
+	return indentJSON(source.Content)
+}
+
+// useColor decides whether to emit ANSI colors for a --color setting of auto, always or never
+func useColor(setting string) bool {
+	switch setting {
+	case "always":
+		return true
+	case "never":
+		return false
+	default:
+		if os.Getenv("NO_COLOR") != "" {
+			return false
+		}
+		stat, err := os.Stdout.Stat()
+		return err == nil && (stat.Mode()&os.ModeCharDevice) != 0
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringArrayVarP(&diffFiles, "file", "f", nil, "Schema file to compare (repeatable)")
+	diffCmd.Flags().StringVarP(&diffSchemaType, "type", "t", "", "Schema type of the files (default: inferred from extension)")
+	diffCmd.Flags().StringVar(&idlRecord, "record", "", "Record to read from an Avro IDL (.avdl) file (default: its only top-level record)")
+	diffCmd.Flags().StringVar(&context, "context", "", "Schema Registry context")
+	diffCmd.Flags().StringVar(&diffOtherContext, "other-context", "", "Context of the second subject (default: --context)")
+	diffCmd.Flags().StringVar(&diffColor, "color", "auto", "Color unified diff output (auto, always, never)")
+	diffCmd.Flags().IntVarP(&diffContextLines, "unified", "U", 3, "Number of context lines in unified diff output")
+	diffCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table, json, yaml, diff)")
+}
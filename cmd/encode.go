@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aywengo/ksr-cli/internal/avro"
+	"github.com/aywengo/ksr-cli/internal/config"
+	"github.com/aywengo/ksr-cli/internal/protobuf"
+	"github.com/aywengo/ksr-cli/internal/validate"
+	"github.com/aywengo/ksr-cli/internal/wire"
+	"github.com/aywengo/ksr-cli/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	encodeFile     string
+	encodeVersion  string
+	encodeSchemaID int
+	encodeMessage  string
+	encodeAvroJSON bool
+	encodeOutput   string
+)
+
+// encodeCmd represents the encode command
+var encodeCmd = &cobra.Command{
+	Use:   "encode SUBJECT",
+	Short: "Encode JSON records into Confluent wire format messages",
+	Long: func() string {
+		return fmt.Sprintf(`Encode JSON records with a registered schema into the Confluent wire format: a zero
+magic byte, the 4-byte schema ID and the serialized record.
+
+The schema is the latest version of the subject, the version given by --version or
+the schema ID given by --id. Records are serialized as follows:
+
+  AVRO      Avro binary, from plain JSON or, with --avro-json, from the Avro JSON
+            encoding. Missing fields take their defaults.
+  JSON      compact JSON, after validating the record against the schema
+  PROTOBUF  Protobuf binary, from the Protobuf JSON mapping of the first message or
+            the message given by --message, preceded by its message indexes
+
+Records are read from --file, or from stdin when it is omitted or "-", as
+newline-delimited JSON or as a JSON array. Messages are written one per line in hex
+(default) or base64, or as raw bytes with -o binary for a single record.
+
+Records that fail to encode are reported on stderr and make the command exit non-zero.
+
+Examples:
+  %s encode user-value -f users.ndjson
+  %s encode user-value --version 2 -f users.json -o base64
+  %s generate sample user-value -n 10 | %s encode user-value
+  %s encode order-value --message shop.Order -f orders.ndjson
+  %s encode user-value -f user.json -o binary > user.bin`, cmdName, cmdName, cmdName, cmdName, cmdName, cmdName)
+	}(),
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch encodeOutput {
+		case "hex", "base64", "binary":
+		default:
+			return fmt.Errorf("unsupported output format: %s (expected hex, base64 or binary)", encodeOutput)
+		}
+		if cmd.Flags().Changed("id") && cmd.Flags().Changed("version") {
+			return fmt.Errorf("--id and --version cannot be used together")
+		}
+
+		input := os.Stdin
+		if encodeFile != "" && encodeFile != "-" {
+			file, err := os.Open(encodeFile)
+			if err != nil {
+				return fmt.Errorf("failed to open records file: %w", err)
+			}
+			defer file.Close()
+			input = file
+		}
+		records, err := readRecords(input)
+		if err != nil {
+			return err
+		}
+		if encodeOutput == "binary" && len(records) != 1 {
+			return fmt.Errorf("binary output holds a single record, but %d were given; use -o hex or -o base64", len(records))
+		}
+
+		c, err := createClientWithFlags()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		encoder, err := newMessageEncoder(c, args[0], config.GetEffectiveContext(context))
+		if err != nil {
+			return err
+		}
+
+		failures := 0
+		for _, record := range records {
+			message, err := encoder.encode(record.value)
+			if err != nil {
+				failures++
+				fmt.Fprintf(os.Stderr, "record %d: %v\n", record.number, err)
+				continue
+			}
+
+			switch encodeOutput {
+			case "binary":
+				if _, err := os.Stdout.Write(message); err != nil {
+					return fmt.Errorf("failed to write message: %w", err)
+				}
+			case "base64":
+				fmt.Println(base64.StdEncoding.EncodeToString(message))
+			default:
+				fmt.Println(hex.EncodeToString(message))
+			}
+		}
+
+		if failures > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("failed to encode %d record(s)", failures)
+		}
+		return nil
+	},
+}
+
+// inputRecord is a record to encode with its position in the input: the line of a
+// newline-delimited record or the index of an array item, counted from 1
+type inputRecord struct {
+	number int
+	value  interface{}
+}
+
+// readRecords reads records as newline-delimited JSON or as a JSON array
+func readRecords(input io.Reader) ([]inputRecord, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read records: %w", err)
+	}
+
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("[")) {
+		value, err := validate.Decode(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON array of records: %w", err)
+		}
+		var records []inputRecord
+		for i, item := range value.([]interface{}) {
+			records = append(records, inputRecord{number: i + 1, value: item})
+		}
+		return records, nil
+	}
+
+	var records []inputRecord
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		value, err := validate.Decode(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid JSON: %w", i+1, err)
+		}
+		records = append(records, inputRecord{number: i + 1, value: value})
+	}
+	return records, nil
+}
+
+// messageEncoder serializes records with one registered schema
+type messageEncoder struct {
+	schemaID  int
+	schema    *writerSchema
+	message   *protobuf.Message
+	indexes   []int
+	validator *validate.Validator
+}
+
+// newMessageEncoder resolves the schema to encode with from --id, --version or the latest version
+func newMessageEncoder(c *client.Client, subject, effectiveContext string) (*messageEncoder, error) {
+	var registered *client.Schema
+	var label string
+	var err error
+	if encodeSchemaID > 0 {
+		registered, err = c.GetSchemaByID(encodeSchemaID, effectiveContext)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get schema ID %d: %w", encodeSchemaID, err)
+		}
+		label = fmt.Sprintf("schema ID %d", encodeSchemaID)
+	} else {
+		registered, err = c.GetSchema(subject, encodeVersion, effectiveContext)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get schema %s version %s: %w", subject, encodeVersion, err)
+		}
+		label = subject
+	}
+
+	source, err := registrySchemaSource(c, label, registered, effectiveContext)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := parseWriterSchema(source)
+	if err != nil {
+		return nil, err
+	}
+
+	encoder := &messageEncoder{schemaID: registered.ID, schema: ws}
+	switch ws.schemaType {
+	case "PROTOBUF":
+		if len(ws.proto.Messages) == 0 {
+			return nil, fmt.Errorf("%s defines no messages", source.Label)
+		}
+		encoder.indexes = []int{0}
+		if encodeMessage != "" {
+			if encoder.indexes, err = protobuf.MessageIndexes(ws.proto, encodeMessage); err != nil {
+				return nil, err
+			}
+		}
+		encoder.message, _ = protobuf.MessageByIndexes(ws.proto, encoder.indexes)
+	case "JSON":
+		if encoder.validator, err = validate.New("JSON", source.Content, source.References, validate.Options{}); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", source.Label, err)
+		}
+	}
+	return encoder, nil
+}
+
+// encode serializes a record with the wire format header
+func (e *messageEncoder) encode(record interface{}) ([]byte, error) {
+	message := wire.AppendHeader(nil, e.schemaID)
+
+	switch e.schema.schemaType {
+	case "AVRO":
+		payload, err := avro.EncodeBinary(e.schema.avro, record, encodeAvroJSON)
+		if err != nil {
+			return nil, err
+		}
+		return append(message, payload...), nil
+	case "PROTOBUF":
+		payload, err := protobuf.EncodeBinary(e.message, e.schema.registry, record)
+		if err != nil {
+			return nil, err
+		}
+		message = wire.AppendMessageIndexes(message, e.indexes)
+		return append(message, payload...), nil
+	default:
+		if violations := e.validator.Validate(record); len(violations) > 0 {
+			return nil, fmt.Errorf("%s: %s", violations[0].Path, violations[0].Message)
+		}
+		payload, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		return append(message, payload...), nil
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(encodeCmd)
+
+	encodeCmd.Flags().StringVarP(&encodeFile, "file", "f", "", "Records as newline-delimited JSON or a JSON array (default: stdin)")
+	encodeCmd.Flags().StringVarP(&encodeVersion, "version", "V", "latest", "Schema version of the subject")
+	encodeCmd.Flags().IntVar(&encodeSchemaID, "id", 0, "Schema ID to encode with, instead of a subject version")
+	encodeCmd.Flags().StringVar(&encodeMessage, "message", "", "Protobuf message to encode (default: the first message)")
+	encodeCmd.Flags().BoolVar(&encodeAvroJSON, "avro-json", false, "Read Avro records in the Avro JSON encoding, with unions wrapped by branch type")
+	encodeCmd.Flags().StringVarP(&encodeOutput, "output", "o", "hex", "Output format (hex, base64, binary)")
+	encodeCmd.Flags().StringVar(&context, "context", "", "Schema Registry context")
+}
@@ -7,17 +7,21 @@ import (
 	"path/filepath"
 	"time"
 
-	"github.com/aywengo/ksr-cli/internal/client"
+	"github.com/aywengo/ksr-cli/internal/avro"
 	"github.com/aywengo/ksr-cli/internal/config"
 	"github.com/aywengo/ksr-cli/internal/output"
+	"github.com/aywengo/ksr-cli/pkg/client"
 	"github.com/spf13/cobra"
 )
 
 var (
-	exportFile        string
-	exportAllVersions bool
-	exportDirectory   string
-	includeConfig     bool
+	exportFile         string
+	exportAllVersions  bool
+	exportDirectory    string
+	includeConfig      bool
+	exportFingerprints bool
+	exportAVDL         bool
+	exportLayout       string
 )
 
 // ExportData represents the structure for exported data
@@ -41,11 +45,12 @@ type ExportedSubject struct {
 }
 
 type ExportedSchema struct {
-	ID         int                `json:"id"`
-	Version    int                `json:"version"`
-	Schema     json.RawMessage    `json:"schema"`
-	SchemaType string             `json:"schema_type,omitempty"`
-	References []client.Reference `json:"references,omitempty"`
+	ID           int                        `json:"id"`
+	Version      int                        `json:"version"`
+	Schema       json.RawMessage            `json:"schema"`
+	SchemaType   string                     `json:"schema_type,omitempty"`
+	References   []client.Reference         `json:"references,omitempty"`
+	Fingerprints *client.SchemaFingerprints `json:"fingerprints,omitempty"`
 }
 
 // exportCmd represents the export command
@@ -60,7 +65,17 @@ Examples:
   ksr-cli export subjects --all-versions          # Export all versions of all subjects
   ksr-cli export subject my-subject               # Export specific subject
   ksr-cli export subject my-subject --all-versions # Export all versions of subject
-  ksr-cli export subjects --directory ./exports   # Export each subject to separate files`,
+  ksr-cli export subjects --directory ./exports   # Export each subject to separate files
+  ksr-cli export subjects --fingerprints          # Include schema fingerprints
+  ksr-cli export subjects --directory ./exports --avdl # Also write Avro schemas as Avro IDL
+  ksr-cli export subjects --directory ./schemas --layout files --all-versions # Plain schema files per subject
+
+The files layout writes a directory per subject with a schema file per version
+(v1.avsc, v1.proto or v1.json) and a metadata.json holding the schema IDs, references
+and config, plus a metadata.json for the context and global config. Avro schemas are
+pretty-printed, so the directory can be kept in git with meaningful diffs, while JSON
+and Protobuf schemas are kept as registered, since the registry matches them on their
+text. The directory is imported again with import subjects --directory.`,
 }
 
 var exportSubjectsCmd = &cobra.Command{
@@ -78,6 +93,10 @@ of each schema is exported. Use --all-versions to export all versions.`,
 
 		effectiveContext := config.GetEffectiveContext(context)
 
+		if err := checkExportFlags(); err != nil {
+			return err
+		}
+
 		// Get all subjects
 		subjects, err := c.GetSubjects(effectiveContext)
 		if err != nil {
@@ -115,6 +134,13 @@ By default, only the latest version is exported. Use --all-versions to export al
 		subject := args[0]
 		effectiveContext := config.GetEffectiveContext(context)
 
+		if err := checkExportFlags(); err != nil {
+			return err
+		}
+		if exportDirectory != "" {
+			return exportSubjectsToDirectory(c, []string{subject}, effectiveContext)
+		}
+
 		// Export single subject
 		exportData, err := buildExportData(c, []string{subject}, effectiveContext)
 		if err != nil {
@@ -183,12 +209,9 @@ func exportSubject(c *client.Client, subject string, effectiveContext string) (*
 				return nil, fmt.Errorf("failed to get schema version %d for subject %s: %w", version, subject, err)
 			}
 
-			exportedSchema := ExportedSchema{
-				ID:         schema.ID,
-				Version:    schema.Version,
-				Schema:     schema.Schema,
-				SchemaType: schema.Type,
-				References: schema.References,
+			exportedSchema, err := newExportedSchema(c, subject, schema, effectiveContext)
+			if err != nil {
+				return nil, err
 			}
 			exportedSubject.Versions = append(exportedSubject.Versions, exportedSchema)
 		}
@@ -199,12 +222,9 @@ func exportSubject(c *client.Client, subject string, effectiveContext string) (*
 			return nil, fmt.Errorf("failed to get latest schema for subject %s: %w", subject, err)
 		}
 
-		exportedSchema := ExportedSchema{
-			ID:         schema.ID,
-			Version:    schema.Version,
-			Schema:     schema.Schema,
-			SchemaType: schema.Type,
-			References: schema.References,
+		exportedSchema, err := newExportedSchema(c, subject, schema, effectiveContext)
+		if err != nil {
+			return nil, err
 		}
 		exportedSubject.Versions = append(exportedSubject.Versions, exportedSchema)
 	}
@@ -212,12 +232,62 @@ func exportSubject(c *client.Client, subject string, effectiveContext string) (*
 	return exportedSubject, nil
 }
 
+// newExportedSchema converts a registered schema for export, adding fingerprints when requested
+func newExportedSchema(c *client.Client, subject string, schema *client.Schema, effectiveContext string) (ExportedSchema, error) {
+	exportedSchema := ExportedSchema{
+		ID:         schema.ID,
+		Version:    schema.Version,
+		Schema:     schema.Schema,
+		SchemaType: schema.Type,
+		References: schema.References,
+	}
+
+	if exportFingerprints {
+		source, err := registrySchemaSource(c, subject, schema, effectiveContext)
+		if err != nil {
+			return exportedSchema, err
+		}
+		fingerprints, err := schemaFingerprints(source)
+		if err != nil {
+			return exportedSchema, err
+		}
+		fingerprints.CanonicalForm = ""
+		exportedSchema.Fingerprints = fingerprints
+	}
+
+	return exportedSchema, nil
+}
+
+// checkExportFlags validates the combination of the directory, layout and IDL flags
+func checkExportFlags() error {
+	switch exportLayout {
+	case layoutBundle:
+	case layoutFiles:
+		if exportDirectory == "" {
+			return fmt.Errorf("--layout files requires --directory")
+		}
+		if exportAVDL {
+			return fmt.Errorf("--avdl is not supported with --layout files")
+		}
+	default:
+		return fmt.Errorf("invalid layout %s (expected bundle or files)", exportLayout)
+	}
+	if exportAVDL && exportDirectory == "" {
+		return fmt.Errorf("--avdl requires --directory")
+	}
+	return nil
+}
+
 func exportSubjectsToDirectory(c *client.Client, subjects []string, effectiveContext string) error {
 	// Create directory if it doesn't exist
 	if err := os.MkdirAll(exportDirectory, 0755); err != nil {
 		return fmt.Errorf("failed to create export directory: %w", err)
 	}
 
+	if exportLayout == layoutFiles {
+		return exportSubjectsToLayout(c, subjects, effectiveContext)
+	}
+
 	// Export each subject to its own file
 	for _, subject := range subjects {
 		exportData, err := buildExportData(c, []string{subject}, effectiveContext)
@@ -240,11 +310,82 @@ func exportSubjectsToDirectory(c *client.Client, subjects []string, effectiveCon
 		file.Close()
 
 		fmt.Printf("Exported subject '%s' to %s\n", subject, filename)
+
+		if exportAVDL {
+			if err := exportSubjectIDL(c, exportData.Subjects[0], effectiveContext); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// exportSubjectsToLayout writes the subjects in the files layout: a directory per subject
+// with a schema file per version and a metadata file for IDs, references and config
+func exportSubjectsToLayout(c *client.Client, subjects []string, effectiveContext string) error {
+	metadata := LayoutMetadata{Context: effectiveContext}
+	if includeConfig {
+		globalConfig, err := c.GetGlobalConfig(effectiveContext)
+		if err == nil {
+			metadata.Config = globalConfig
+		}
+	}
+	if err := writeLayoutMetadata(exportDirectory, metadata); err != nil {
+		return err
+	}
+
+	for _, subject := range subjects {
+		exportedSubject, err := exportSubject(c, subject, effectiveContext)
+		if err != nil {
+			return fmt.Errorf("failed to export subject %s: %w", subject, err)
+		}
+		written, err := writeSubjectLayout(exportDirectory, *exportedSubject)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Exported subject '%s' to %s (%d file(s))\n", subject, filepath.Dir(written[0]), len(written))
 	}
 
 	return nil
 }
 
+// exportSubjectIDL writes the Avro schemas of an exported subject as Avro IDL files, named
+// after the subject, with the version when all versions are exported
+func exportSubjectIDL(c *client.Client, subject ExportedSubject, effectiveContext string) error {
+	for _, version := range subject.Versions {
+		if version.SchemaType != "" && version.SchemaType != "AVRO" {
+			fmt.Fprintf(os.Stderr, "Skipped Avro IDL for subject '%s': %s schemas have no IDL form\n", subject.Name, version.SchemaType)
+			return nil
+		}
+
+		references, err := c.ReferenceContents(version.References, effectiveContext)
+		if err != nil {
+			return err
+		}
+		s, err := avro.ParseWithReferences(client.SchemaText(version.Schema), references)
+		if err != nil {
+			return fmt.Errorf("failed to parse subject %s version %d: %w", subject.Name, version.Version, err)
+		}
+		idl, err := avro.FormatIDL(s)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipped Avro IDL for subject '%s': %v\n", subject.Name, err)
+			return nil
+		}
+
+		name := subject.Name + ".avdl"
+		if exportAllVersions {
+			name = fmt.Sprintf("%s.v%d.avdl", subject.Name, version.Version)
+		}
+		filename := filepath.Join(exportDirectory, name)
+		if err := os.WriteFile(filename, []byte(idl), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", filename, err)
+		}
+		fmt.Printf("Exported subject '%s' version %d to %s\n", subject.Name, version.Version, filename)
+	}
+	return nil
+}
+
 func writeExportData(exportData *ExportData) error {
 	if exportFile != "" {
 		file, err := os.Create(exportFile)
@@ -279,6 +420,9 @@ func init() {
 	exportCmd.PersistentFlags().BoolVar(&exportAllVersions, "all-versions", false, "Export all versions of schemas")
 	exportCmd.PersistentFlags().StringVar(&exportDirectory, "directory", "", "Export each subject to separate files in directory")
 	exportCmd.PersistentFlags().BoolVar(&includeConfig, "include-config", true, "Include configuration in export")
+	exportCmd.PersistentFlags().BoolVar(&exportFingerprints, "fingerprints", false, "Include CRC-64-AVRO, MD5 and SHA-256 fingerprints of each schema")
+	exportCmd.PersistentFlags().StringVar(&exportLayout, "layout", layoutBundle, "Directory layout: bundle (a JSON file per subject) or files (plain schema files and metadata per subject)")
+	exportCmd.PersistentFlags().BoolVar(&exportAVDL, "avdl", false, "Also write Avro schemas as Avro IDL (.avdl) files, with --directory")
 
 	// Global flags
 	exportCmd.PersistentFlags().StringVar(&context, "context", "", "Schema Registry context")
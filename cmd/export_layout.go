@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/internal/avro"
+	"github.com/aywengo/ksr-cli/pkg/client"
+)
+
+// Export layouts
+const (
+	// layoutBundle writes one JSON bundle per subject
+	layoutBundle = "bundle"
+	// layoutFiles writes plain schema files per subject and version, with metadata files
+	layoutFiles = "files"
+)
+
+// layoutMetadataFile is the name of the metadata files of the files layout, at the root of
+// the export directory and in the directory of each subject
+const layoutMetadataFile = "metadata.json"
+
+// LayoutMetadata is the root metadata file of the files layout. It has no export time, so
+// exporting an unchanged registry again leaves the directory unchanged.
+type LayoutMetadata struct {
+	Context string         `json:"context,omitempty"`
+	Config  *client.Config `json:"config,omitempty"`
+}
+
+// SubjectMetadata is the metadata file of a subject in the files layout
+type SubjectMetadata struct {
+	Subject  string            `json:"subject"`
+	Config   *client.Config    `json:"config,omitempty"`
+	Versions []VersionMetadata `json:"versions"`
+}
+
+// VersionMetadata describes a schema version of the files layout, stored in File
+type VersionMetadata struct {
+	Version      int                        `json:"version"`
+	ID           int                        `json:"id"`
+	File         string                     `json:"file"`
+	SchemaType   string                     `json:"schema_type,omitempty"`
+	References   []client.Reference         `json:"references,omitempty"`
+	Fingerprints *client.SchemaFingerprints `json:"fingerprints,omitempty"`
+}
+
+// subjectDirName returns the directory name of a subject, escaping the characters that
+// are not valid in file names, such as the separators of context-qualified subjects
+func subjectDirName(subject string) string {
+	return strings.ReplaceAll(url.PathEscape(subject), ":", "%3A")
+}
+
+// schemaFileExtension returns the file extension of a schema type
+func schemaFileExtension(schemaType string) string {
+	switch strings.ToUpper(schemaType) {
+	case "PROTOBUF":
+		return ".proto"
+	case "JSON":
+		return ".json"
+	default:
+		return ".avsc"
+	}
+}
+
+// layoutSchemaContent returns schema content as written to a file. Avro schemas are
+// pretty-printed so that changes show up as line diffs, since the registry matches them on
+// their canonical form. JSON and Protobuf schemas are matched on their text, so they are
+// kept as registered for an import to find the existing versions.
+func layoutSchemaContent(schemaType, content string) string {
+	switch strings.ToUpper(schemaType) {
+	case "", "AVRO":
+		if formatted, err := avro.Format(content); err == nil {
+			return formatted
+		}
+	}
+	return content
+}
+
+// writeLayoutMetadata writes the root metadata file of the files layout
+func writeLayoutMetadata(dir string, metadata LayoutMetadata) error {
+	return writeJSONFile(filepath.Join(dir, layoutMetadataFile), metadata)
+}
+
+// writeSubjectLayout writes the schema files and the metadata file of an exported subject
+// in its directory, returning the paths written
+func writeSubjectLayout(dir string, subject ExportedSubject) ([]string, error) {
+	subjectDir := filepath.Join(dir, subjectDirName(subject.Name))
+	if err := os.MkdirAll(subjectDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for subject %s: %w", subject.Name, err)
+	}
+
+	metadata := SubjectMetadata{
+		Subject:  subject.Name,
+		Config:   subject.Config,
+		Versions: make([]VersionMetadata, 0, len(subject.Versions)),
+	}
+	var written []string
+	for _, version := range subject.Versions {
+		name := fmt.Sprintf("v%d%s", version.Version, schemaFileExtension(version.SchemaType))
+		path := filepath.Join(subjectDir, name)
+		content := layoutSchemaContent(version.SchemaType, client.SchemaText(version.Schema))
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		written = append(written, path)
+
+		metadata.Versions = append(metadata.Versions, VersionMetadata{
+			Version:      version.Version,
+			ID:           version.ID,
+			File:         name,
+			SchemaType:   version.SchemaType,
+			References:   version.References,
+			Fingerprints: version.Fingerprints,
+		})
+	}
+
+	path := filepath.Join(subjectDir, layoutMetadataFile)
+	if err := writeJSONFile(path, metadata); err != nil {
+		return nil, err
+	}
+	return append(written, path), nil
+}
+
+func writeJSONFile(path string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// isFilesLayout reports whether a directory holds an export in the files layout, that
+// is subject directories with metadata files
+func isFilesLayout(dir string) bool {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*", layoutMetadataFile))
+	return len(matches) > 0
+}
+
+// loadFilesLayout reads an export in the files layout. Subjects are ordered with the
+// subjects they reference first, and versions by number, so they can be registered in order.
+func loadFilesLayout(dir string) (*ExportData, error) {
+	exportData := &ExportData{Metadata: ExportMetadata{Version: Version}}
+
+	rootPath := filepath.Join(dir, layoutMetadataFile)
+	if data, err := os.ReadFile(rootPath); err == nil {
+		var metadata LayoutMetadata
+		if err := json.Unmarshal(data, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", rootPath, err)
+		}
+		exportData.Metadata.Context = metadata.Context
+		exportData.Config = metadata.Config
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", rootPath, err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*", layoutMetadataFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find subject directories: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no subject directories with %s found in %s", layoutMetadataFile, dir)
+	}
+
+	for _, path := range paths {
+		subject, err := loadSubjectLayout(path)
+		if err != nil {
+			return nil, err
+		}
+		exportData.Subjects = append(exportData.Subjects, *subject)
+	}
+	exportData.Subjects = orderByReferences(exportData.Subjects)
+	return exportData, nil
+}
+
+// loadSubjectLayout reads the metadata file of a subject and its schema files
+func loadSubjectLayout(path string) (*ExportedSubject, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var metadata SubjectMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	subjectDir := filepath.Dir(path)
+	if metadata.Subject == "" {
+		name, err := url.PathUnescape(filepath.Base(subjectDir))
+		if err != nil {
+			return nil, fmt.Errorf("%s: no subject name", path)
+		}
+		metadata.Subject = name
+	}
+
+	subject := &ExportedSubject{
+		Name:     metadata.Subject,
+		Config:   metadata.Config,
+		Versions: make([]ExportedSchema, 0, len(metadata.Versions)),
+	}
+	for _, version := range metadata.Versions {
+		if version.File == "" {
+			return nil, fmt.Errorf("%s: version %d has no file", path, version.Version)
+		}
+		schemaPath := filepath.Join(subjectDir, version.File)
+		content, err := os.ReadFile(schemaPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", schemaPath, err)
+		}
+		raw, err := json.Marshal(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", schemaPath, err)
+		}
+		subject.Versions = append(subject.Versions, ExportedSchema{
+			ID:         version.ID,
+			Version:    version.Version,
+			Schema:     raw,
+			SchemaType: version.SchemaType,
+			References: version.References,
+		})
+	}
+	sort.Slice(subject.Versions, func(i, j int) bool {
+		return subject.Versions[i].Version < subject.Versions[j].Version
+	})
+	return subject, nil
+}
+
+// orderByReferences sorts subjects by name, then moves the subjects referenced by the
+// schemas of another subject before it. References outside the export are ignored.
+func orderByReferences(subjects []ExportedSubject) []ExportedSubject {
+	sort.Slice(subjects, func(i, j int) bool { return subjects[i].Name < subjects[j].Name })
+	byName := make(map[string]ExportedSubject, len(subjects))
+	for _, subject := range subjects {
+		byName[subject.Name] = subject
+	}
+
+	ordered := make([]ExportedSubject, 0, len(subjects))
+	visited := map[string]bool{}
+	var visit func(subject ExportedSubject)
+	visit = func(subject ExportedSubject) {
+		if visited[subject.Name] {
+			return
+		}
+		visited[subject.Name] = true
+		for _, version := range subject.Versions {
+			for _, ref := range version.References {
+				if referenced, ok := byName[ref.Subject]; ok {
+					visit(referenced)
+				}
+			}
+		}
+		ordered = append(ordered, subject)
+	}
+	for _, subject := range subjects {
+		visit(subject)
+	}
+	return ordered
+}
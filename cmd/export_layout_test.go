@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aywengo/ksr-cli/pkg/client"
+)
+
+func rawSchema(t *testing.T, content string) json.RawMessage {
+	raw, err := json.Marshal(content)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return raw
+}
+
+func TestFilesLayout_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	customer := `{"type":"record","name":"Customer","namespace":"com.acme","fields":[{"name":"id","type":"string"}]}`
+	order := `{"type":"record","name":"Order","namespace":"com.acme","fields":[{"name":"customer","type":"com.acme.Customer"}]}`
+	proto := "syntax = \"proto3\";\nmessage Event {\n  string id = 1;\n}\n"
+
+	subjects := []ExportedSubject{
+		{
+			Name:   "orders-value",
+			Config: &client.Config{CompatibilityLevel: "FULL"},
+			Versions: []ExportedSchema{{
+				ID: 6, Version: 1, Schema: rawSchema(t, order),
+				References: []client.Reference{{Name: "com.acme.Customer", Subject: "customers-value", Version: 1}},
+			}},
+		},
+		{
+			Name: "customers-value",
+			Versions: []ExportedSchema{
+				{ID: 2, Version: 2, Schema: rawSchema(t, customer)},
+				{ID: 1, Version: 1, Schema: rawSchema(t, customer)},
+			},
+		},
+		{
+			Name:     ":.staging:events",
+			Versions: []ExportedSchema{{ID: 3, Version: 1, Schema: rawSchema(t, proto), SchemaType: "PROTOBUF"}},
+		},
+	}
+	if err := writeLayoutMetadata(dir, LayoutMetadata{Context: ".staging", Config: &client.Config{CompatibilityLevel: "BACKWARD"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, subject := range subjects {
+		if _, err := writeSubjectLayout(dir, subject); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	for _, name := range []string{"orders-value/v1.avsc", "customers-value/v2.avsc", "%3A.staging%3Aevents/v1.proto", "%3A.staging%3Aevents/metadata.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected %s to be written: %v", name, err)
+		}
+	}
+	written, _ := os.ReadFile(filepath.Join(dir, "orders-value", "v1.avsc"))
+	if !strings.Contains(string(written), "\n  \"name\": \"Order\",\n") {
+		t.Errorf("Expected the Avro schema to be pretty-printed, got:\n%s", written)
+	}
+	written, _ = os.ReadFile(filepath.Join(dir, "%3A.staging%3Aevents", "v1.proto"))
+	if string(written) != proto {
+		t.Errorf("Expected the Protobuf schema as registered, got:\n%s", written)
+	}
+
+	if !isFilesLayout(dir) {
+		t.Fatal("Expected the directory to be detected as the files layout")
+	}
+	loaded, err := loadFilesLayout(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if loaded.Metadata.Context != ".staging" || loaded.Config == nil || loaded.Config.CompatibilityLevel != "BACKWARD" {
+		t.Errorf("Unexpected metadata %+v, config %+v", loaded.Metadata, loaded.Config)
+	}
+
+	var names []string
+	for _, subject := range loaded.Subjects {
+		names = append(names, subject.Name)
+	}
+	if got := strings.Join(names, ","); got != ":.staging:events,customers-value,orders-value" {
+		t.Errorf("Unexpected subject order %s", got)
+	}
+
+	customers := loaded.Subjects[1]
+	if len(customers.Versions) != 2 || customers.Versions[0].Version != 1 || customers.Versions[0].ID != 1 {
+		t.Errorf("Expected versions in order with their IDs, got %+v", customers.Versions)
+	}
+	orders := loaded.Subjects[2]
+	if orders.Config == nil || orders.Config.CompatibilityLevel != "FULL" || len(orders.Versions[0].References) != 1 {
+		t.Errorf("Expected the config and references of orders-value, got %+v", orders)
+	}
+	var content map[string]interface{}
+	if err := json.Unmarshal([]byte(client.SchemaText(orders.Versions[0].Schema)), &content); err != nil || content["name"] != "Order" {
+		t.Errorf("Expected the schema content of orders-value, got %s", orders.Versions[0].Schema)
+	}
+}
+
+func TestOrderByReferences(t *testing.T) {
+	ref := func(subject string) []ExportedSchema {
+		return []ExportedSchema{{Version: 1, References: []client.Reference{{Name: subject, Subject: subject, Version: 1}}}}
+	}
+	subjects := []ExportedSubject{
+		{Name: "a", Versions: ref("c")},
+		{Name: "b"},
+		{Name: "c", Versions: ref("outside")},
+		{Name: "d", Versions: ref("a")},
+	}
+
+	var names []string
+	for _, subject := range orderByReferences(subjects) {
+		names = append(names, subject.Name)
+	}
+	if got := strings.Join(names, ","); got != "c,a,b,d" {
+		t.Errorf("Unexpected order %s", got)
+	}
+}
+
+func TestFilesLayout_KeepsJSONSchemaText(t *testing.T) {
+	dir := t.TempDir()
+	registered := `{"type":"object","$schema":"http://json-schema.org/draft-07/schema#","properties":{"sku":{"type":"string","title":"SKU"}},"$id":"https://acme.com/item.json"}`
+	subject := ExportedSubject{
+		Name:     "items-value",
+		Versions: []ExportedSchema{{ID: 4, Version: 1, Schema: rawSchema(t, registered), SchemaType: "JSON"}},
+	}
+	if _, err := writeSubjectLayout(dir, subject); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	loaded, err := loadFilesLayout(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := client.SchemaText(loaded.Subjects[0].Versions[0].Schema); got != registered {
+		t.Errorf("Expected the JSON schema as registered, got:\n%s", got)
+	}
+}
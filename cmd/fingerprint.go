@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/aywengo/ksr-cli/internal/config"
+	"github.com/aywengo/ksr-cli/internal/output"
+	"github.com/aywengo/ksr-cli/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fingerprintFiles       []string
+	fingerprintSchemaType  string
+	fingerprintAllVersions bool
+	fingerprintCanonical   bool
+)
+
+// FingerprintResult holds the fingerprints of one schema
+type FingerprintResult struct {
+	Source        string `json:"source"`
+	SchemaType    string `json:"schema_type"`
+	CRC64Avro     string `json:"crc64_avro"`
+	MD5           string `json:"md5"`
+	SHA256        string `json:"sha256"`
+	CanonicalForm string `json:"canonical_form,omitempty"`
+}
+
+// fingerprintRow is a FingerprintResult without the canonical form, for table output
+type fingerprintRow struct {
+	Source     string `json:"source"`
+	SchemaType string `json:"schema_type"`
+	CRC64Avro  string `json:"crc64_avro"`
+	MD5        string `json:"md5"`
+	SHA256     string `json:"sha256"`
+}
+
+// fingerprintCmd represents the fingerprint command
+var fingerprintCmd = &cobra.Command{
+	Use:   "fingerprint [SUBJECT[@VERSION]|FILE...]",
+	Short: "Compute canonical forms and fingerprints of schemas",
+	Long: func() string {
+		return fmt.Sprintf(`Compute the canonical form of schemas and its CRC-64-AVRO (Rabin), MD5 and SHA-256
+fingerprints, for registered schema versions and local files. Arguments that name an
+existing file are read from disk, the others are looked up as subjects.
+
+Avro schemas are normalized to the Parsing Canonical Form from the Avro specification,
+so schemas that differ only in documentation, defaults, aliases, attribute order or
+whitespace have the same fingerprints. JSON schemas are normalized to compact JSON with
+sorted keys, and Protobuf schemas are fingerprinted as text without surrounding whitespace.
+
+Examples:
+  %s fingerprint my-subject                      # Latest version
+  %s fingerprint my-subject@2 other-subject
+  %s fingerprint my-subject --all-versions
+  %s fingerprint user.avsc --canonical
+  %s fingerprint --file a.avsc --file b.avsc -o json`, cmdName, cmdName, cmdName, cmdName, cmdName)
+	}(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && len(fingerprintFiles) == 0 {
+			return fmt.Errorf("a subject, a file or --file is required")
+		}
+
+		sources, err := resolveFingerprintSources(args)
+		if err != nil {
+			return err
+		}
+
+		actualOutputFormat, _ := cmd.Flags().GetString("output")
+
+		results := make([]FingerprintResult, 0, len(sources))
+		for _, source := range sources {
+			fingerprints, err := schemaFingerprints(source)
+			if err != nil {
+				return err
+			}
+
+			result := FingerprintResult{
+				Source:     source.Label,
+				SchemaType: source.SchemaType,
+				CRC64Avro:  fingerprints.CRC64Avro,
+				MD5:        fingerprints.MD5,
+				SHA256:     fingerprints.SHA256,
+			}
+			if fingerprintCanonical {
+				result.CanonicalForm = fingerprints.CanonicalForm
+			}
+			results = append(results, result)
+		}
+
+		if actualOutputFormat != "table" {
+			return output.Print(results, actualOutputFormat)
+		}
+
+		// Canonical forms are too long for table cells, so they are listed below the table
+		rows := make([]interface{}, 0, len(results))
+		for _, result := range results {
+			rows = append(rows, fingerprintRow{
+				Source:     result.Source,
+				SchemaType: result.SchemaType,
+				CRC64Avro:  result.CRC64Avro,
+				MD5:        result.MD5,
+				SHA256:     result.SHA256,
+			})
+		}
+		if err := output.Print(rows, actualOutputFormat); err != nil {
+			return err
+		}
+		for _, result := range results {
+			if result.CanonicalForm != "" {
+				fmt.Printf("\n%s:\n  %s\n", result.Source, result.CanonicalForm)
+			}
+		}
+		return nil
+	},
+}
+
+// resolveFingerprintSources loads the schemas named by the arguments and --file flags.
+// Arguments naming local files are read as files, the others as registered subjects.
+func resolveFingerprintSources(args []string) ([]*schemaSource, error) {
+	var sources []*schemaSource
+
+	var c *client.Client
+	effectiveContext := config.GetEffectiveContext(context)
+	for _, arg := range args {
+		if isSchemaFileArg(arg) {
+			source, err := loadSchemaFile(arg, fingerprintSchemaType)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, source)
+			continue
+		}
+
+		if c == nil {
+			var err error
+			if c, err = createClientWithFlags(); err != nil {
+				return nil, fmt.Errorf("failed to create client: %w", err)
+			}
+		}
+		subject, version := parseSubjectVersion(arg)
+
+		versions := []string{version}
+		if fingerprintAllVersions {
+			registered, err := c.GetSubjectVersions(subject, effectiveContext)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get versions for subject %s: %w", subject, err)
+			}
+			versions = versions[:0]
+			for _, v := range registered {
+				versions = append(versions, fmt.Sprintf("%d", v))
+			}
+		}
+
+		for _, v := range versions {
+			source, err := loadRegistrySchema(c, subject, v, effectiveContext)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, source)
+		}
+	}
+
+	for _, file := range fingerprintFiles {
+		source, err := loadSchemaFile(file, fingerprintSchemaType)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+
+	return sources, nil
+}
+
+func init() {
+	rootCmd.AddCommand(fingerprintCmd)
+
+	fingerprintCmd.Flags().StringArrayVarP(&fingerprintFiles, "file", "f", nil, "Schema file to fingerprint (repeatable)")
+	fingerprintCmd.Flags().StringVarP(&fingerprintSchemaType, "type", "t", "", "Schema type of the files (default: inferred from extension)")
+	fingerprintCmd.Flags().BoolVar(&fingerprintAllVersions, "all-versions", false, "Fingerprint every version of the subjects")
+	fingerprintCmd.Flags().BoolVar(&fingerprintCanonical, "canonical", false, "Include the canonical form in the output")
+	fingerprintCmd.Flags().StringVar(&idlRecord, "record", "", "Record to read from an Avro IDL (.avdl) file (default: its only top-level record)")
+	fingerprintCmd.Flags().StringVar(&context, "context", "", "Schema Registry context")
+	fingerprintCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table, json, yaml)")
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveFingerprintSources_FileArgument(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "user.avsc")
+	content := `{"type":"record","name":"User","fields":[{"name":"id","type":"string"}]}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	sources, err := resolveFingerprintSources([]string{path})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(sources) != 1 || sources[0].Content != content {
+		t.Errorf("Expected the file to be read as a schema, got %+v", sources)
+	}
+}
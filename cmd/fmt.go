@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aywengo/ksr-cli/internal/schema"
+	"github.com/aywengo/ksr-cli/internal/textdiff"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fmtWrite      bool
+	fmtCheck      bool
+	fmtDiff       bool
+	fmtSchemaType string
+	fmtReferences []string
+)
+
+// fmtCmd represents the fmt command
+var fmtCmd = &cobra.Command{
+	Use:   "fmt [FILE...]",
+	Short: "Normalize and pretty-print schema files",
+	Long: func() string {
+		return fmt.Sprintf(`Format Avro, JSON Schema and Protobuf files in a standard layout.
+
+Schemas are checked with the same parsers used by create and check before they are
+formatted, so invalid files are reported instead of rewritten.
+
+  Avro         Attributes in a stable order (type, name, namespace, doc, aliases, then
+               fields, symbols, items, values or size), two-space indentation, unions
+               and symbol lists on one line, primitive types in short form
+  JSON Schema  $schema, $id, title, description, type and other annotations first in
+               every subschema, two-space indentation
+  Protobuf     Syntax, package, sorted imports and options first, two-space
+               indentation, one declaration per line; comments are preserved
+
+The schema type is inferred from the file extension (.avsc, .json, .proto) unless --type
+is given. Without files, a schema is read from standard input and written to standard output.
+
+Avro schemas that use named types defined in other files need those files passed with
+--ref-file.
+
+Examples:
+  %s fmt user.avsc                     # Print the formatted schema
+  %s fmt -w schemas/*.avsc             # Rewrite files in place
+  %s fmt --check schemas/*.proto       # List unformatted files, exit non-zero if any
+  %s fmt -d order.json                 # Show what would change
+  %s fmt -w order.avsc --ref-file address.avsc
+  cat user.avsc | %s fmt`, cmdName, cmdName, cmdName, cmdName, cmdName, cmdName)
+	}(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		references, err := loadReferenceFiles(fmtReferences)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			content, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return fmt.Errorf("failed to read from stdin: %w", err)
+			}
+			formatted, err := schema.Normalize(schemaTypeForFile("", fmtSchemaType), string(content), references...)
+			if err != nil {
+				return fmt.Errorf("failed to format schema: %w", err)
+			}
+			fmt.Print(formatted)
+			return nil
+		}
+
+		var unformatted []string
+		for _, path := range args {
+			if isIDLFile(path) {
+				return fmt.Errorf("%s: Avro IDL files are not formatted", path)
+			}
+			source, err := loadSchemaFile(path, fmtSchemaType)
+			if err != nil {
+				return err
+			}
+			formatted, err := schema.Normalize(source.SchemaType, source.Content, references...)
+			if err != nil {
+				return fmt.Errorf("failed to format %s: %w", path, err)
+			}
+
+			changed := formatted != source.Content
+			if changed {
+				unformatted = append(unformatted, path)
+			}
+
+			switch {
+			case fmtCheck:
+				if changed {
+					fmt.Println(path)
+				}
+			case fmtDiff:
+				fmt.Print(textdiff.Unified(path, path+" (formatted)", source.Content, formatted, 3))
+			case fmtWrite:
+				if !changed {
+					continue
+				}
+				info, err := os.Stat(path)
+				if err != nil {
+					return fmt.Errorf("failed to stat %s: %w", path, err)
+				}
+				if err := os.WriteFile(path, []byte(formatted), info.Mode().Perm()); err != nil {
+					return fmt.Errorf("failed to write %s: %w", path, err)
+				}
+				fmt.Fprintf(os.Stderr, "Formatted %s\n", path)
+			default:
+				fmt.Print(formatted)
+			}
+		}
+
+		if fmtCheck && len(unformatted) > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d of %d file(s) are not formatted", len(unformatted), len(args))
+		}
+		return nil
+	},
+}
+
+// loadReferenceFiles reads referenced schema files in the order given
+func loadReferenceFiles(paths []string) ([]string, error) {
+	var references []string
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read reference file: %w", err)
+		}
+		references = append(references, string(content))
+	}
+	return references, nil
+}
+
+func init() {
+	rootCmd.AddCommand(fmtCmd)
+
+	fmtCmd.Flags().BoolVarP(&fmtWrite, "write", "w", false, "Write the formatted schema back to the source files")
+	fmtCmd.Flags().BoolVar(&fmtCheck, "check", false, "List files that are not formatted and exit non-zero if there are any")
+	fmtCmd.Flags().BoolVarP(&fmtDiff, "diff", "d", false, "Show a unified diff of the changes instead of the formatted schema")
+	fmtCmd.Flags().StringVarP(&fmtSchemaType, "type", "t", "", "Schema type (AVRO, JSON, PROTOBUF; default: inferred from extension)")
+	addReferenceFileFlag(fmtCmd, &fmtReferences, "Schema file defining named types used by the files (repeatable)")
+}
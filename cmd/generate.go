@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/aywengo/ksr-cli/internal/avro"
+	"github.com/aywengo/ksr-cli/internal/codegen"
+	"github.com/aywengo/ksr-cli/internal/sample"
+	"github.com/spf13/cobra"
+)
+
+var (
+	generateOutFile    string
+	generateReferences []string
+	generateGoPackage  string
+	generateSchemaType string
+	sampleCount        int
+	sampleSeed         int64
+	sampleAvroJSON     bool
+	sampleOutput       string
+)
+
+// generateCmd represents the generate command
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate code and data from schemas",
+	Long: func() string {
+		return fmt.Sprintf(`Generate code and data from registered schemas or local schema files.
+
+Schemas are given as SUBJECT[@VERSION] or as the path of a local file. References of
+registered schemas are resolved from the registry; named types used by local files are
+read from --ref-file files.
+
+Examples:
+  %s generate go user-value > user.go
+  %s generate go user.avsc --package events
+  %s generate sample user-value --count 10`, cmdName, cmdName, cmdName)
+	}(),
+}
+
+var generateGoCmd = &cobra.Command{
+	Use:   "go SUBJECT[@VERSION]|FILE",
+	Short: "Generate Go types from an Avro schema",
+	Long: func() string {
+		return fmt.Sprintf(`Generate Go type declarations for the records, enums and fixed types of an Avro schema,
+including the types defined by its references.
+
+  record              struct with avro and json field tags
+  enum                string type with a constant per symbol
+  fixed               [N]byte type
+  ["null", T]         *T (slices and maps stay nil-able without a pointer)
+  other unions        wrapper struct with one pointer field per branch
+  date, timestamp-*   time.Time
+  time-millis/micros  time.Duration
+  decimal             *big.Rat
+
+The package name defaults to the last part of the schema's namespace.
+
+Examples:
+  %s generate go user-value
+  %s generate go user-value@3 --package users --out user.go
+  %s generate go order.avsc --ref-file address.avsc`, cmdName, cmdName, cmdName)
+	}(),
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		references, err := loadReferenceFiles(generateReferences)
+		if err != nil {
+			return err
+		}
+
+		source, err := loadSchemaArg(args[0], "AVRO", references)
+		if err != nil {
+			return err
+		}
+		if source.SchemaType != "AVRO" {
+			return fmt.Errorf("Go generation supports Avro schemas, but %s is a %s schema", source.Label, source.SchemaType)
+		}
+
+		s, err := avro.ParseWithReferences(source.Content, source.References)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", source.Label, err)
+		}
+
+		pkg := generateGoPackage
+		if pkg == "" {
+			pkg = goPackageName(s.Namespace)
+		}
+
+		code, err := codegen.Go(s, codegen.GoOptions{Package: pkg, Source: source.Label})
+		if err != nil {
+			return fmt.Errorf("failed to generate Go code: %w", err)
+		}
+
+		return writeGenerated(generateOutFile, code)
+	},
+}
+
+var generateSampleCmd = &cobra.Command{
+	Use:   "sample SUBJECT[@VERSION]|FILE",
+	Short: "Generate sample records that match a schema",
+	Long: func() string {
+		return fmt.Sprintf(`Generate random records that are valid for an Avro, JSON Schema or Protobuf schema.
+
+Values honor enum symbols, logical types and formats, unions, field defaults and JSON Schema
+constraints (minimum and maximum, multipleOf, lengths, patterns, item counts, required
+properties). Strings are chosen to look realistic for common field names such as email,
+name, country or url.
+
+Avro records are written as plain JSON by default, or in the Avro JSON encoding with
+--avro-json, which wraps non-null union values in an object keyed by the branch type.
+Protobuf records use the Protobuf JSON mapping for the first message of the schema.
+
+Records are written one per line (ndjson) or as a JSON array with -o json. The same --seed
+always produces the same records.
+
+Examples:
+  %s generate sample user-value
+  %s generate sample user-value@2 --count 100 --seed 42 > users.ndjson
+  %s generate sample order.avsc --avro-json
+  %s generate sample order.json --count 3 -o json`, cmdName, cmdName, cmdName, cmdName)
+	}(),
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if sampleCount < 1 {
+			return fmt.Errorf("--count must be at least 1")
+		}
+
+		references, err := loadReferenceFiles(generateReferences)
+		if err != nil {
+			return err
+		}
+		source, err := loadSchemaArg(args[0], generateSchemaType, references)
+		if err != nil {
+			return err
+		}
+
+		seed := sampleSeed
+		if !cmd.Flags().Changed("seed") {
+			seed = time.Now().UnixNano()
+		}
+
+		records, err := sample.Records(source.SchemaType, source.Content, source.References, sampleCount, sample.Options{
+			Seed:     seed,
+			AvroJSON: sampleAvroJSON,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to generate samples for %s: %w", source.Label, err)
+		}
+
+		var out bytes.Buffer
+		switch sampleOutput {
+		case "ndjson":
+			for _, record := range records {
+				line, err := json.Marshal(record)
+				if err != nil {
+					return fmt.Errorf("failed to encode record: %w", err)
+				}
+				out.Write(line)
+				out.WriteByte('\n')
+			}
+		case "json":
+			data, err := json.MarshalIndent(records, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode records: %w", err)
+			}
+			out.Write(data)
+			out.WriteByte('\n')
+		default:
+			return fmt.Errorf("unsupported output format: %s (expected ndjson or json)", sampleOutput)
+		}
+
+		return writeGenerated(generateOutFile, out.String())
+	},
+}
+
+// goPackageName derives a package name from the last part of a namespace
+func goPackageName(namespace string) string {
+	name := strings.ToLower(namespace[strings.LastIndex(namespace, ".")+1:])
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, name)
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		return "schemas"
+	}
+	return name
+}
+
+// writeGenerated writes generated output to a file, or to stdout without one
+func writeGenerated(path, content string) error {
+	if path == "" {
+		fmt.Print(content)
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Fprintf(os.Stderr, "Generated %s\n", path)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+	generateCmd.AddCommand(generateGoCmd)
+	generateCmd.AddCommand(generateSampleCmd)
+
+	generateCmd.PersistentFlags().StringVar(&generateOutFile, "out", "", "File to write (default: stdout)")
+	generateCmd.PersistentFlags().StringVar(&idlRecord, "record", "", "Record to read from an Avro IDL (.avdl) file (default: its only top-level record)")
+	generateCmd.PersistentFlags().StringVar(&context, "context", "", "Schema Registry context")
+
+	for _, sub := range []*cobra.Command{generateGoCmd, generateSampleCmd} {
+		addReferenceFileFlag(sub, &generateReferences, "Schema file defining named types used by a local schema (repeatable)")
+	}
+
+	generateGoCmd.Flags().StringVarP(&generateGoPackage, "package", "p", "", "Go package name (default: last part of the schema namespace)")
+
+	generateSampleCmd.Flags().IntVarP(&sampleCount, "count", "n", 1, "Number of records to generate")
+	generateSampleCmd.Flags().Int64Var(&sampleSeed, "seed", 0, "Random seed for reproducible records (default: random)")
+	generateSampleCmd.Flags().BoolVar(&sampleAvroJSON, "avro-json", false, "Write Avro records in the Avro JSON encoding, with unions wrapped by branch type")
+	generateSampleCmd.Flags().StringVarP(&generateSchemaType, "type", "t", "", "Schema type of a local file (default: inferred from extension)")
+	generateSampleCmd.Flags().StringVarP(&sampleOutput, "output", "o", "ndjson", "Output format (ndjson, json)")
+}
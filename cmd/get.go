@@ -35,12 +35,15 @@ var getSchemasCmd = &cobra.Command{
 	Long: func() string {
 		return fmt.Sprintf(`Get all schemas or a specific schema by subject name.
 
+With -o tree the schema is rendered as an indented tree of its fields, as by "show".
+
 Examples:
   %s get schemas                         # List all subjects
   %s get schemas my-subject              # Get latest schema for subject
   %s get schemas my-subject -v 2         # Get specific version
   %s get schemas my-subject --all        # Get all versions
-  %s get schemas my-subject --all-versions # Get all versions`, cmdName, cmdName, cmdName, cmdName, cmdName)
+  %s get schemas my-subject --all-versions # Get all versions
+  %s get schemas my-subject -o tree      # Show the fields as a tree`, cmdName, cmdName, cmdName, cmdName, cmdName, cmdName)
 	}(),
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -51,6 +54,11 @@ Examples:
 
 		effectiveContext := config.GetEffectiveContext(context)
 
+		treeOutput, _ := cmd.Flags().GetString("output")
+		if treeOutput == "tree" && len(args) == 0 {
+			return fmt.Errorf("tree output requires a SUBJECT")
+		}
+
 		if len(args) == 0 {
 			// List all subjects
 			subjects, err := c.GetSubjects(effectiveContext)
@@ -75,8 +83,17 @@ Examples:
 				if err != nil {
 					return fmt.Errorf("failed to get schema version %d: %w", v, err)
 				}
+				if treeOutput == "tree" {
+					if err := printSchemaTree(c, subject, schema, effectiveContext); err != nil {
+						return err
+					}
+					continue
+				}
 				schemas = append(schemas, schema)
 			}
+			if treeOutput == "tree" {
+				return nil
+			}
 			return output.Print(schemas, outputFormat)
 		}
 
@@ -91,6 +108,9 @@ Examples:
 			return fmt.Errorf("failed to get schema: %w", err)
 		}
 
+		if treeOutput == "tree" {
+			return printSchemaTree(c, subject, schema, effectiveContext)
+		}
 		return output.Print(schema, outputFormat)
 	},
 }
@@ -237,5 +257,5 @@ func init() {
 
 	// Global flags for all get commands
 	getCmd.PersistentFlags().StringVar(&context, "context", "", "Schema Registry context")
-	getCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table, json, yaml)")
+	getCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table, json, yaml, or tree for get schemas SUBJECT)")
 }
@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/internal/config"
+	"github.com/aywengo/ksr-cli/internal/graph"
+	"github.com/aywengo/ksr-cli/internal/output"
+	"github.com/aywengo/ksr-cli/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	graphAllVersions bool
+	graphStrict      bool
+	graphOutput      string
+)
+
+// GraphResult is the reference graph with its problems
+type GraphResult struct {
+	Nodes    []*graph.Node `json:"nodes"`
+	Edges    []*graph.Edge `json:"edges"`
+	Cycles   [][]string    `json:"cycles,omitempty"`
+	Dangling []*graph.Edge `json:"dangling,omitempty"`
+}
+
+// graphCmd represents the graph command
+var graphCmd = &cobra.Command{
+	Use:   "graph [SUBJECT[@VERSION]]",
+	Short: "Show the dependency graph of schema references",
+	Long: func() string {
+		return fmt.Sprintf(`Build the dependency graph formed by schema references.
+
+Without arguments, the graph covers the latest version of every subject (every version
+with --all-versions) and the versions they reference. With a subject, it covers the
+versions that subject version references, directly or not, and the versions referencing
+it. Each node is a subject version and each edge a reference, labeled with the reference
+name and the version it is pinned to.
+
+Cycles and dangling references, to versions that are not in the registry, are reported on
+stderr; with --strict they make the command exit non-zero.
+
+Output formats:
+  tree     Indented tree of references, and of the referencing versions for a subject (default)
+  dot      Graphviz DOT, e.g. piped to: dot -Tsvg -o graph.svg
+  mermaid  Mermaid flowchart
+  json     Nodes, edges, cycles and dangling references
+  yaml     Nodes, edges, cycles and dangling references
+
+Examples:
+  %s graph
+  %s graph orders-value
+  %s graph orders-value@3 -o mermaid
+  %s graph --all-versions -o dot | dot -Tsvg -o references.svg
+  %s graph --strict`, cmdName, cmdName, cmdName, cmdName, cmdName)
+	}(),
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch graphOutput {
+		case "tree", "dot", "mermaid", "json", "yaml":
+		default:
+			return fmt.Errorf("unsupported output format: %s (expected tree, dot, mermaid, json or yaml)", graphOutput)
+		}
+
+		c, err := createClientWithFlags()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		b := &graphBuilder{client: c, context: config.GetEffectiveContext(context), graph: graph.New()}
+
+		var root *graph.Node
+		if len(args) > 0 {
+			subject, version := parseSubjectVersion(args[0])
+			schema, err := c.GetSchema(subject, version, b.context)
+			if err != nil {
+				return fmt.Errorf("failed to get schema %s version %s: %w", subject, version, err)
+			}
+			if root, err = b.add(subject, schema.Version); err != nil {
+				return err
+			}
+			if err := b.addDependents(root, map[string]bool{}); err != nil {
+				return err
+			}
+		} else if err := b.addRegistry(); err != nil {
+			return err
+		}
+
+		g := b.graph
+		result := &GraphResult{Nodes: g.Nodes, Edges: g.Edges, Cycles: g.Cycles(), Dangling: g.Dangling()}
+		switch graphOutput {
+		case "tree":
+			if root == nil {
+				fmt.Print(g.Tree(g.Roots(), false))
+			} else {
+				fmt.Print(g.Tree([]string{root.Key()}, false))
+				if len(g.ReferencedBy(root.Key())) > 0 {
+					fmt.Print("\nReferenced by:\n" + g.Tree([]string{root.Key()}, true))
+				}
+			}
+		case "dot":
+			fmt.Print(g.DOT())
+		case "mermaid":
+			fmt.Print(g.Mermaid())
+		default:
+			if err := output.Print(result, graphOutput); err != nil {
+				return err
+			}
+		}
+
+		printGraphProblems(result)
+		if graphStrict && (len(result.Cycles) > 0 || len(result.Dangling) > 0) {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("reference graph has %d cycle(s) and %d dangling reference(s)", len(result.Cycles), len(result.Dangling))
+		}
+		return nil
+	},
+}
+
+// printGraphProblems reports the cycles and dangling references of a graph on stderr
+func printGraphProblems(result *GraphResult) {
+	for _, cycle := range result.Cycles {
+		fmt.Fprintf(os.Stderr, "⚠️  Reference cycle: %s -> %s\n", strings.Join(cycle, " -> "), cycle[0])
+	}
+	for _, edge := range result.Dangling {
+		fmt.Fprintf(os.Stderr, "⚠️  Dangling reference: %s references %s as %s, which is not registered\n", edge.From, edge.To, edge.Name)
+	}
+}
+
+// graphBuilder fetches schema versions and their references into a graph
+type graphBuilder struct {
+	client  *client.Client
+	context string
+	graph   *graph.Graph
+}
+
+// add adds a subject version and, the first time, the versions it references
+func (b *graphBuilder) add(subject string, version int) (*graph.Node, error) {
+	if n, ok := b.graph.Node(graph.Key(subject, version)); ok {
+		return n, nil
+	}
+
+	schema, err := b.client.GetSchema(subject, strconv.Itoa(version), b.context)
+	if client.IsNotFound(err) {
+		n := &graph.Node{Subject: subject, Version: version, Missing: true}
+		b.graph.AddNode(n)
+		return n, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema %s version %d: %w", subject, version, err)
+	}
+
+	schemaType := schema.Type
+	if schemaType == "" {
+		schemaType = "AVRO"
+	}
+	n := &graph.Node{Subject: subject, Version: version, ID: schema.ID, SchemaType: schemaType}
+	b.graph.AddNode(n)
+	for _, ref := range schema.References {
+		target, err := b.add(ref.Subject, ref.Version)
+		if err != nil {
+			return nil, err
+		}
+		b.graph.AddEdge(n, target, ref.Name)
+	}
+	return n, nil
+}
+
+// addDependents adds the versions referencing a node, and the versions referencing those
+func (b *graphBuilder) addDependents(n *graph.Node, visited map[string]bool) error {
+	if visited[n.Key()] || n.Missing {
+		return nil
+	}
+	visited[n.Key()] = true
+
+	ids, err := b.client.GetReferencedBy(n.Subject, strconv.Itoa(n.Version), b.context)
+	if err != nil {
+		return fmt.Errorf("failed to get schemas referencing %s: %w", n.Key(), err)
+	}
+	for _, id := range ids {
+		versions, err := b.client.GetSchemaVersionsByID(id, b.context)
+		if err != nil {
+			return fmt.Errorf("failed to get subject versions of schema ID %d: %w", id, err)
+		}
+		for _, sv := range versions {
+			parent, err := b.add(sv.Subject, sv.Version)
+			if err != nil {
+				return err
+			}
+			if err := b.addDependents(parent, visited); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addRegistry adds the latest version, or every version, of every subject
+func (b *graphBuilder) addRegistry() error {
+	subjects, err := b.client.GetSubjects(b.context)
+	if err != nil {
+		return fmt.Errorf("failed to get subjects: %w", err)
+	}
+	for _, subject := range subjects {
+		var versions []int
+		if graphAllVersions {
+			if versions, err = b.client.GetSubjectVersions(subject, b.context); err != nil {
+				return fmt.Errorf("failed to get versions of %s: %w", subject, err)
+			}
+		} else {
+			schema, err := b.client.GetSchema(subject, "latest", b.context)
+			if err != nil {
+				return fmt.Errorf("failed to get latest version of %s: %w", subject, err)
+			}
+			versions = []int{schema.Version}
+		}
+		for _, version := range versions {
+			if _, err := b.add(subject, version); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(graphCmd)
+
+	graphCmd.Flags().BoolVar(&graphAllVersions, "all-versions", false, "Include every version of every subject instead of the latest")
+	graphCmd.Flags().BoolVar(&graphStrict, "strict", false, "Exit non-zero when the graph has cycles or dangling references")
+	graphCmd.Flags().StringVar(&context, "context", "", "Schema Registry context")
+	graphCmd.Flags().StringVarP(&graphOutput, "output", "o", "tree", "Output format (tree, dot, mermaid, json, yaml)")
+}
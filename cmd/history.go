@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/internal/config"
+	"github.com/aywengo/ksr-cli/internal/output"
+	"github.com/aywengo/ksr-cli/internal/schema"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyField  string
+	historyOutput string
+)
+
+// HistoryResult is the evolution of a subject across its versions
+type HistoryResult struct {
+	Subject  string                   `json:"subject"`
+	Fields   []*schema.FieldHistory   `json:"fields"`
+	Versions []*schema.VersionChanges `json:"versions"`
+}
+
+// historyRow is a field timeline in table output
+type historyRow struct {
+	Path       string `json:"path"`
+	Introduced string `json:"introduced"`
+	Removed    string `json:"removed"`
+	Type       string `json:"type"`
+	History    string `json:"history"`
+}
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history SUBJECT",
+	Short: "Show the field-level evolution of a subject",
+	Long: func() string {
+		return fmt.Sprintf(`Parse every version of a subject and show how its fields evolved.
+
+For each field path, including the fields of nested records, the timeline lists the
+version it was introduced in and every later change: type, nullability, default, doc,
+enum symbols and logical type changes, removal and re-introduction. The changelog then
+describes each version bump in plain sentences.
+
+Output formats:
+  table      Field timelines followed by the changelog (default)
+  changelog  Changelog of each version bump, as Markdown
+  json       Field timelines and per-version changes
+  yaml       Field timelines and per-version changes
+
+Use --field to follow one field and the fields nested under it.
+
+Examples:
+  %s history user-value
+  %s history user-value --field address
+  %s history user-value -o changelog > CHANGELOG.md
+  %s history user-value -o json`, cmdName, cmdName, cmdName, cmdName)
+	}(),
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		subject := args[0]
+		switch historyOutput {
+		case "table", "changelog", "json", "yaml":
+		default:
+			return fmt.Errorf("unsupported output format: %s (expected table, changelog, json or yaml)", historyOutput)
+		}
+
+		c, err := createClientWithFlags()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		effectiveContext := config.GetEffectiveContext(context)
+
+		versionNumbers, err := c.GetSubjectVersions(subject, effectiveContext)
+		if err != nil {
+			return fmt.Errorf("failed to get versions of %s: %w", subject, err)
+		}
+
+		var versions []schema.Version
+		for _, number := range versionNumbers {
+			registered, err := c.GetSchema(subject, strconv.Itoa(number), effectiveContext)
+			if err != nil {
+				return fmt.Errorf("failed to get schema %s version %d: %w", subject, number, err)
+			}
+			source, err := registrySchemaSource(c, subject, registered, effectiveContext)
+			if err != nil {
+				return err
+			}
+			tree, err := schema.Parse(source.SchemaType, source.Content, source.References...)
+			if err != nil {
+				return fmt.Errorf("failed to parse %s: %w", source.Label, err)
+			}
+			versions = append(versions, schema.Version{Version: number, ID: registered.ID, Tree: tree})
+		}
+
+		history := schema.BuildHistory(versions)
+		if historyField != "" {
+			history = filterHistory(history, historyField)
+			if len(history.Fields) == 0 {
+				return fmt.Errorf("field %s does not appear in any version of %s", historyField, subject)
+			}
+		}
+
+		switch historyOutput {
+		case "json", "yaml":
+			return output.Print(&HistoryResult{Subject: subject, Fields: history.Fields, Versions: history.Versions}, historyOutput)
+		case "changelog":
+			fmt.Print(formatChangelog(subject, history))
+			return nil
+		}
+
+		rows := make([]interface{}, 0, len(history.Fields))
+		for _, field := range history.Fields {
+			row := historyRow{Path: field.Path, Introduced: fmt.Sprintf("v%d", field.Introduced), Type: field.Type, History: field.Summary()}
+			if field.Removed != 0 {
+				row.Removed = fmt.Sprintf("v%d", field.Removed)
+			}
+			rows = append(rows, row)
+		}
+		if err := output.Print(rows, "table"); err != nil {
+			return err
+		}
+		fmt.Println()
+		fmt.Print(formatChangelog(subject, history))
+		return nil
+	},
+}
+
+// filterHistory keeps the timelines of a field and the fields nested under it, and the
+// changes to them
+func filterHistory(history *schema.History, field string) *schema.History {
+	matches := func(path string) bool {
+		if path == field {
+			return true
+		}
+		rest, ok := strings.CutPrefix(path, field)
+		return ok && (strings.HasPrefix(rest, ".") || strings.HasPrefix(rest, "[]") || strings.HasPrefix(rest, "{}"))
+	}
+
+	filtered := &schema.History{Fields: []*schema.FieldHistory{}, Versions: []*schema.VersionChanges{}}
+	for _, f := range history.Fields {
+		if matches(f.Path) {
+			filtered.Fields = append(filtered.Fields, f)
+		}
+	}
+	for _, v := range history.Versions {
+		entry := *v
+		entry.Changes = []schema.Change{}
+		for _, change := range v.Changes {
+			if matches(change.Path) {
+				entry.Changes = append(entry.Changes, change)
+			}
+		}
+		filtered.Versions = append(filtered.Versions, &entry)
+	}
+	return filtered
+}
+
+// formatChangelog describes each version bump as a Markdown section, newest first
+func formatChangelog(subject string, history *schema.History) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Changelog of %s\n", subject)
+	for i := len(history.Versions) - 1; i >= 0; i-- {
+		v := history.Versions[i]
+		fmt.Fprintf(&sb, "\n## Version %d", v.Version)
+		if v.ID != 0 {
+			fmt.Fprintf(&sb, " (ID %d)", v.ID)
+		}
+		sb.WriteString("\n\n")
+		switch {
+		case i == 0:
+			sb.WriteString("- Initial version\n")
+		case len(v.Changes) == 0:
+			fmt.Fprintf(&sb, "- No structural changes since version %d\n", v.Previous)
+		default:
+			for _, change := range v.Changes {
+				fmt.Fprintf(&sb, "- %s\n", schema.Describe(change))
+			}
+		}
+	}
+	return sb.String()
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	historyCmd.Flags().StringVar(&historyField, "field", "", "Only show the timeline of a field path and the fields nested under it")
+	historyCmd.Flags().StringVar(&context, "context", "", "Schema Registry context")
+	historyCmd.Flags().StringVarP(&historyOutput, "output", "o", "table", "Output format (table, changelog, json, yaml)")
+}
@@ -7,8 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/aywengo/ksr-cli/internal/client"
 	"github.com/aywengo/ksr-cli/internal/config"
+	"github.com/aywengo/ksr-cli/pkg/client"
 	"github.com/spf13/cobra"
 )
 
@@ -49,6 +49,7 @@ var importCmd = &cobra.Command{
 Examples:
   ksr-cli import subjects -f subjects.json       # Import subjects from file
   ksr-cli import subjects --directory ./exports  # Import all subjects from directory
+  ksr-cli import subjects --directory ./schemas --dry-run # Preview an import of the files layout
   ksr-cli import subject -f subject.json        # Import single subject from file
   ksr-cli import subjects -f subjects.json --dry-run    # Preview import without changes
   ksr-cli import subjects -f subjects.json --skip-existing # Skip existing schemas`,
@@ -61,7 +62,11 @@ var importSubjectsCmd = &cobra.Command{
 
 This command imports subjects and their schemas. By default, it will attempt to register
 all schemas. Use --skip-existing to skip schemas that already exist, or --dry-run to 
-preview the changes without applying them.`,
+preview the changes without applying them.
+
+A directory is read in the layout it was exported with: JSON bundles, or the files
+layout of export --layout files (a directory per subject with schema files and a
+metadata.json), whose subjects are imported with the subjects they reference first.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		c, err := createClientWithFlags()
 		if err != nil {
@@ -116,6 +121,14 @@ func importFromFile(c *client.Client, filename string) error {
 }
 
 func importFromDirectory(c *client.Client) error {
+	if isFilesLayout(importDirectory) {
+		exportData, err := loadFilesLayout(importDirectory)
+		if err != nil {
+			return err
+		}
+		return processImport(c, exportData, importDirectory)
+	}
+
 	files, err := filepath.Glob(filepath.Join(importDirectory, "*.json"))
 	if err != nil {
 		return fmt.Errorf("failed to find import files: %w", err)
@@ -264,7 +277,7 @@ func importSchema(c *client.Client, subjectName string, schema *ExportedSchema,
 
 	// Prepare schema request
 	schemaReq := &client.SchemaRequest{
-		Schema:     string(schema.Schema),
+		Schema:     client.SchemaText(schema.Schema),
 		SchemaType: schema.SchemaType,
 		References: schema.References,
 	}
@@ -354,7 +367,7 @@ func init() {
 
 	// Flags for import commands
 	importCmd.PersistentFlags().StringVarP(&importFile, "file", "f", "", "Import file")
-	importCmd.PersistentFlags().StringVar(&importDirectory, "directory", "", "Import directory containing JSON files")
+	importCmd.PersistentFlags().StringVar(&importDirectory, "directory", "", "Import directory containing JSON bundles or an export in the files layout")
 	importCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Preview import without making changes")
 	importCmd.PersistentFlags().BoolVar(&skipExisting, "skip-existing", false, "Skip existing schemas")
 	importCmd.PersistentFlags().BoolVar(&forceImport, "force", false, "Force import even if schema registry is in read-only mode")
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aywengo/ksr-cli/internal/convert"
+	"github.com/aywengo/ksr-cli/internal/infer"
+	"github.com/aywengo/ksr-cli/internal/jsonfmt"
+	"github.com/spf13/cobra"
+)
+
+var (
+	inferFormat    string
+	inferDataFiles []string
+	inferName      string
+	inferNamespace string
+	inferOutFile   string
+	inferOutput    string
+)
+
+// inferCmd represents the infer command
+var inferCmd = &cobra.Command{
+	Use:   "infer",
+	Short: "Infer a schema from sample JSON records",
+	Long: func() string {
+		return fmt.Sprintf(`Infer an Avro, JSON Schema or Protobuf schema from sample JSON records.
+
+Records are read as newline-delimited JSON objects from --file (repeatable), or from
+stdin when it is omitted or "-". The more records, the better the result: all of them
+are merged field by field.
+
+  - fields missing from some records, or null in some, become optional
+    (a union with null and a null default in Avro, not required in JSON Schema)
+  - fields holding values of several JSON types become unions
+  - nested objects become records named after their field, arrays of objects records
+    named after the singular of the field (items -> Item)
+  - integers are int when they fit in 32 bits, long otherwise; numbers with a fraction
+    are double
+  - strings in RFC 3339 or YYYY-MM-DD form get format date-time or date in JSON Schema,
+    UUIDs the uuid logical type
+  - integers in fields named like a point in time (created_at, updatedAt, ts) that hold
+    epoch milliseconds or microseconds get the timestamp-millis or timestamp-micros
+    logical type
+
+Assumptions worth reviewing, such as unions or arrays that were always empty, are
+reported on stderr. Protobuf schemas are converted from the inferred Avro schema, with
+the losses of that conversion reported the same way.
+
+The schema is written to stdout, or --out, so it can be piped into "create schema" or
+"check compatibility". With -o json the schema, record count and notes are written as
+one JSON document.
+
+Examples:
+  %s infer --format avro -f samples.ndjson --name Order --namespace com.acme
+  %s infer --format json-schema -f day1.ndjson -f day2.ndjson --out order.json
+  kafka-console-consumer ... | %s infer --format protobuf --name Order
+  %s infer -f samples.ndjson | %s check compatibility orders-value`, cmdName, cmdName, cmdName, cmdName, cmdName)
+	}(),
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := convert.ParseFormat(inferFormat)
+		if err != nil {
+			return err
+		}
+		if inferOutput != "schema" && inferOutput != "json" {
+			return fmt.Errorf("unsupported output format: %s (expected schema or json)", inferOutput)
+		}
+
+		inferrer := infer.New()
+		if len(inferDataFiles) == 0 {
+			inferDataFiles = []string{"-"}
+		}
+		for _, path := range inferDataFiles {
+			if err := inferRecords(inferrer, path); err != nil {
+				return err
+			}
+		}
+
+		result, err := inferrer.Schema(format, infer.Options{Name: inferName, Namespace: inferNamespace})
+		if err != nil {
+			return fmt.Errorf("failed to infer schema: %w", err)
+		}
+
+		content := result.Content
+		if inferOutput == "json" {
+			data, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode result: %w", err)
+			}
+			content = string(data) + "\n"
+		} else if len(result.Notes) > 0 {
+			fmt.Fprintf(os.Stderr, "⚠️  Inferred from %d record(s) with %d note(s):\n", result.Records, len(result.Notes))
+			for _, note := range result.Notes {
+				fmt.Fprintf(os.Stderr, "  %s: %s\n", note.Path, note.Message)
+			}
+		}
+
+		return writeGenerated(inferOutFile, content)
+	},
+}
+
+// inferRecords adds the newline-delimited JSON records of a file, or stdin for "-"
+func inferRecords(inferrer *infer.Inferrer, path string) error {
+	input := os.Stdin
+	name := "stdin"
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open data file: %w", err)
+		}
+		defer file.Close()
+		input = file
+		name = path
+	}
+
+	reader := bufio.NewReader(input)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		if record := bytes.TrimSpace(data); len(record) > 0 {
+			value, decodeErr := jsonfmt.Decode(record)
+			if decodeErr == nil {
+				decodeErr = inferrer.Add(value)
+			}
+			if decodeErr != nil {
+				return fmt.Errorf("%s line %d: %w", name, line, decodeErr)
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(inferCmd)
+
+	inferCmd.Flags().StringVar(&inferFormat, "format", "avro", "Schema format to infer (avro, json-schema, protobuf)")
+	inferCmd.Flags().StringArrayVarP(&inferDataFiles, "file", "f", nil, "Newline-delimited JSON records (repeatable, default: stdin)")
+	inferCmd.Flags().StringVar(&inferName, "name", "Record", "Name of the root record or message")
+	inferCmd.Flags().StringVar(&inferNamespace, "namespace", "", "Namespace of the records, or package of the Protobuf file")
+	inferCmd.Flags().StringVar(&inferOutFile, "out", "", "File to write (default: stdout)")
+	inferCmd.Flags().StringVarP(&inferOutput, "output", "o", "schema", "Output format (schema, json)")
+}
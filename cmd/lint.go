@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/aywengo/ksr-cli/internal/config"
+	"github.com/aywengo/ksr-cli/internal/lint"
+	"github.com/aywengo/ksr-cli/internal/output"
+	"github.com/aywengo/ksr-cli/internal/schema"
+	"github.com/aywengo/ksr-cli/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lintFiles      []string
+	lintSchemaType string
+	lintConfigFile string
+	lintPrevious   string
+	lintFailOn     string
+	lintListRules  bool
+)
+
+// LintReport is the result of linting one or more schemas
+type LintReport struct {
+	Schemas  int            `json:"schemas"`
+	Errors   int            `json:"errors"`
+	Warnings int            `json:"warnings"`
+	Infos    int            `json:"infos"`
+	Findings []lint.Finding `json:"findings"`
+}
+
+// lintTarget is a schema to lint together with the version it replaces
+type lintTarget struct {
+	source   *schemaSource
+	previous *schemaSource
+}
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint [SUBJECT[@VERSION]|FILE...]",
+	Short: "Check schemas against style and evolution rules",
+	Long: func() string {
+		return fmt.Sprintf(`Check registered subjects or local schema files against a configurable set of rules.
+Arguments that name an existing file are read from disk, the others are looked up as subjects.
+
+Built-in rules cover documentation, defaults for new fields, namespaces, unions, naming
+styles and nesting depth; run with --list-rules to see them with their severities and
+options. Rules are configured in a YAML file, read from --config or from %s in
+the current directory:
+
+  rules:
+    field-doc:
+      severity: error          # error, warning or info
+      ignore: [id]
+    namespace-pattern:         # off by default, enabled by setting its pattern
+      pattern: '^com\.acme(\.[a-z0-9]+)*$'
+    field-name-case:
+      style: snake_case        # snake_case, camelCase, PascalCase or consistent
+    max-depth: false           # shorthand for enabled: false
+
+Rules about schema evolution compare a subject version with the version before it, and a
+file with the schema given by --previous.
+
+The command exits non-zero when a finding is at or above the --fail-on severity.
+
+Examples:
+  %s lint my-subject                          # Latest version
+  %s lint my-subject@3 other-subject
+  %s lint user.avsc order.proto
+  %s lint user.avsc --previous user-v1.avsc
+  %s lint -f user.avsc --config lint.yaml --fail-on warning
+  %s lint --list-rules`, lint.DefaultConfigFile, cmdName, cmdName, cmdName, cmdName, cmdName, cmdName)
+	}(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadLintConfig()
+		if err != nil {
+			return err
+		}
+
+		actualOutputFormat, _ := cmd.Flags().GetString("output")
+
+		if lintListRules {
+			infos, err := lint.Describe(cfg)
+			if err != nil {
+				return err
+			}
+			if actualOutputFormat != "table" {
+				return output.Print(infos, actualOutputFormat)
+			}
+			rows := make([]interface{}, 0, len(infos))
+			for _, info := range infos {
+				rows = append(rows, info)
+			}
+			return output.Print(rows, actualOutputFormat)
+		}
+
+		if len(args) == 0 && len(lintFiles) == 0 {
+			return fmt.Errorf("a subject, a file or --file is required")
+		}
+
+		failOn := lint.Severity("")
+		if lintFailOn != "none" {
+			if failOn, err = lint.ParseSeverity(lintFailOn); err != nil {
+				return fmt.Errorf("invalid --fail-on: %w", err)
+			}
+		}
+
+		targets, err := resolveLintTargets(args)
+		if err != nil {
+			return err
+		}
+
+		report := LintReport{Schemas: len(targets), Findings: []lint.Finding{}}
+		for _, target := range targets {
+			findings, err := lintSchema(target, cfg)
+			if err != nil {
+				return err
+			}
+			report.Findings = append(report.Findings, findings...)
+		}
+
+		counts := lint.Count(report.Findings)
+		report.Errors = counts[lint.SeverityError]
+		report.Warnings = counts[lint.SeverityWarning]
+		report.Infos = counts[lint.SeverityInfo]
+
+		if actualOutputFormat == "table" {
+			if len(report.Findings) == 0 {
+				fmt.Printf("✅ No lint findings in %d schema(s)\n", report.Schemas)
+			} else {
+				rows := make([]interface{}, 0, len(report.Findings))
+				for _, finding := range report.Findings {
+					rows = append(rows, finding)
+				}
+				if err := output.Print(rows, actualOutputFormat); err != nil {
+					return err
+				}
+				fmt.Printf("\n%d error(s), %d warning(s), %d info(s) in %d schema(s)\n",
+					report.Errors, report.Warnings, report.Infos, report.Schemas)
+			}
+		} else if err := output.Print(report, actualOutputFormat); err != nil {
+			return err
+		}
+
+		if failOn != "" {
+			for _, finding := range report.Findings {
+				if finding.Severity.Rank() >= failOn.Rank() {
+					cmd.SilenceUsage = true
+					return fmt.Errorf("lint failed with findings at or above %s severity", failOn)
+				}
+			}
+		}
+		return nil
+	},
+}
+
+// loadLintConfig reads the lint configuration from --config or the default file, if present
+func loadLintConfig() (*lint.Config, error) {
+	path := lintConfigFile
+	if path == "" {
+		if _, err := os.Stat(lint.DefaultConfigFile); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return lint.DefaultConfig(), nil
+			}
+			return nil, fmt.Errorf("failed to read lint config: %w", err)
+		}
+		path = lint.DefaultConfigFile
+	}
+	return lint.LoadConfig(path)
+}
+
+// resolveLintTargets loads the schemas named by the arguments and --file flags. Arguments
+// naming local files are read as files, the others as registered subjects.
+func resolveLintTargets(args []string) ([]lintTarget, error) {
+	var targets []lintTarget
+
+	var previous *schemaSource
+	if lintPrevious != "" {
+		var err error
+		if previous, err = loadSchemaFile(lintPrevious, lintSchemaType); err != nil {
+			return nil, err
+		}
+	}
+
+	var c *client.Client
+	effectiveContext := config.GetEffectiveContext(context)
+	for _, arg := range args {
+		if isSchemaFileArg(arg) {
+			source, err := loadSchemaFile(arg, lintSchemaType)
+			if err != nil {
+				return nil, err
+			}
+			targets = append(targets, lintTarget{source: source, previous: previous})
+			continue
+		}
+
+		if c == nil {
+			var err error
+			if c, err = createClientWithFlags(); err != nil {
+				return nil, fmt.Errorf("failed to create client: %w", err)
+			}
+		}
+		subject, version := parseSubjectVersion(arg)
+		target, err := subjectLintTarget(c, subject, version, effectiveContext)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+
+	for _, file := range lintFiles {
+		source, err := loadSchemaFile(file, lintSchemaType)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, lintTarget{source: source, previous: previous})
+	}
+
+	return targets, nil
+}
+
+// subjectLintTarget loads a subject version and the version registered before it
+func subjectLintTarget(c *client.Client, subject, version, effectiveContext string) (lintTarget, error) {
+	registeredSchema, err := c.GetSchema(subject, version, effectiveContext)
+	if err != nil {
+		return lintTarget{}, fmt.Errorf("failed to get schema %s version %s: %w", subject, version, err)
+	}
+	source, err := registrySchemaSource(c, subject, registeredSchema, effectiveContext)
+	if err != nil {
+		return lintTarget{}, err
+	}
+	target := lintTarget{source: source}
+
+	registered, err := c.GetSubjectVersions(subject, effectiveContext)
+	if err != nil {
+		return lintTarget{}, fmt.Errorf("failed to get versions for subject %s: %w", subject, err)
+	}
+	previousVersion := 0
+	for _, v := range registered {
+		if v < registeredSchema.Version && v > previousVersion {
+			previousVersion = v
+		}
+	}
+	if previousVersion > 0 {
+		target.previous, err = loadRegistrySchema(c, subject, strconv.Itoa(previousVersion), effectiveContext)
+		if err != nil {
+			return lintTarget{}, err
+		}
+	}
+	return target, nil
+}
+
+// lintSchema parses a target and runs the configured rules against it
+func lintSchema(target lintTarget, cfg *lint.Config) ([]lint.Finding, error) {
+	tree, err := schema.Parse(target.source.SchemaType, target.source.Content, target.source.References...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", target.source.Label, err)
+	}
+
+	lt := &lint.Target{Source: target.source.Label, Tree: tree}
+	if target.previous != nil {
+		lt.Previous, err = schema.Parse(target.previous.SchemaType, target.previous.Content, target.previous.References...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", target.previous.Label, err)
+		}
+	}
+
+	return lint.Lint(lt, cfg)
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+
+	lintCmd.Flags().StringArrayVarP(&lintFiles, "file", "f", nil, "Schema file to lint (repeatable)")
+	lintCmd.Flags().StringVarP(&lintSchemaType, "type", "t", "", "Schema type of the files (default: inferred from extension)")
+	lintCmd.Flags().StringVar(&lintConfigFile, "config", "", fmt.Sprintf("Lint configuration file (default: %s if present)", lint.DefaultConfigFile))
+	lintCmd.Flags().StringVar(&lintPrevious, "previous", "", "Previous version of the files, for rules about schema evolution")
+	lintCmd.Flags().StringVar(&lintFailOn, "fail-on", "error", "Exit non-zero on findings at or above this severity (error, warning, info, none)")
+	lintCmd.Flags().BoolVar(&lintListRules, "list-rules", false, "List the rules with their effective settings")
+	lintCmd.Flags().StringVar(&idlRecord, "record", "", "Record to read from an Avro IDL (.avdl) file (default: its only top-level record)")
+	lintCmd.Flags().StringVar(&context, "context", "", "Schema Registry context")
+	lintCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table, json, yaml)")
+}
@@ -0,0 +1,580 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/internal/compat"
+	"github.com/aywengo/ksr-cli/internal/config"
+	"github.com/aywengo/ksr-cli/internal/manifest"
+	"github.com/aywengo/ksr-cli/internal/output"
+	"github.com/aywengo/ksr-cli/internal/schema"
+	"github.com/aywengo/ksr-cli/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	manifestFile string
+	applyYes     bool
+)
+
+const manifestHelp = `The manifest lists the desired subjects with their schema file (relative to the
+manifest), references, compatibility level and mode, and optionally the global level
+and mode and the context. Subjects that are not listed are left alone.
+
+  context: .staging
+  compatibility: BACKWARD
+  mode: READWRITE
+  subjects:
+    customers-value:
+      schema: schemas/customer.avsc
+    orders-value:
+      schema: schemas/order.avsc      # type from the extension, or type: AVRO|JSON|PROTOBUF
+      references:
+        - name: com.acme.Customer
+          subject: customers-value
+          version: latest             # or a version number
+      compatibility: FULL
+    audit-value:
+      mode: READONLY
+
+A schema is registered when no version of its subject matches it. New versions are
+pre-checked for compatibility, under the compatibility level the plan sets when it
+changes one (locally when a local checker exists for the schema type).`
+
+// planCmd represents the plan command
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Show the changes needed to bring the registry to a desired state",
+	Long: func() string {
+		return fmt.Sprintf(`Compare a declarative manifest with the registry and show the plan of changes:
+subjects to create, new schema versions to register, and compatibility levels and
+modes to set, with the compatibility pre-check of every new version.
+
+%s
+
+Examples:
+  %s plan -f desired.yaml
+  %s plan -f desired.yaml -o json
+  %s apply -f desired.yaml`, manifestHelp, cmdName, cmdName, cmdName)
+	}(),
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, m, effectiveContext, err := loadManifestWithClient()
+		if err != nil {
+			return err
+		}
+		plan, _, err := buildPlan(c, m, effectiveContext)
+		if err != nil {
+			return err
+		}
+
+		actualOutputFormat, _ := cmd.Flags().GetString("output")
+		if actualOutputFormat != "table" {
+			return output.Print(plan, actualOutputFormat)
+		}
+		return printPlan(os.Stdout, plan)
+	},
+}
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Apply the changes bringing the registry to a desired state",
+	Long: func() string {
+		return fmt.Sprintf(`Compare a declarative manifest with the registry, show the plan of changes as
+"plan" does and apply it after confirmation, then report the result of every change.
+
+Nothing is applied when a new schema version fails its compatibility pre-check. Changes
+are applied in the order of the plan, referenced subjects first, and applying stops at
+the first failure.
+
+%s
+
+Examples:
+  %s apply -f desired.yaml
+  %s apply -f desired.yaml --yes
+  %s apply -f desired.yaml --yes -o json`, manifestHelp, cmdName, cmdName, cmdName)
+	}(),
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, m, effectiveContext, err := loadManifestWithClient()
+		if err != nil {
+			return err
+		}
+		plan, schemas, err := buildPlan(c, m, effectiveContext)
+		if err != nil {
+			return err
+		}
+
+		// Structured output is reserved for the report, the plan goes to stderr
+		actualOutputFormat, _ := cmd.Flags().GetString("output")
+		out := os.Stdout
+		if actualOutputFormat != "table" {
+			out = os.Stderr
+		}
+		if err := printPlan(out, plan); err != nil {
+			return err
+		}
+		if len(plan.Changes) == 0 {
+			return nil
+		}
+		if _, _, _, incompatible := plan.Count(); incompatible > 0 {
+			return fmt.Errorf("%d schema change(s) failed the compatibility pre-check, nothing was applied", incompatible)
+		}
+
+		if !applyYes {
+			confirmed, err := confirmApply(out, len(plan.Changes))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				fmt.Fprintln(out, "Apply cancelled")
+				return nil
+			}
+		}
+
+		report := applyPlan(c, m, plan, schemas, effectiveContext)
+		if actualOutputFormat != "table" {
+			if err := output.Print(report, actualOutputFormat); err != nil {
+				return err
+			}
+		} else if err := printApplyReport(report); err != nil {
+			return err
+		}
+		if report.Failed > 0 {
+			return fmt.Errorf("failed to apply %d change(s)", report.Failed)
+		}
+		return nil
+	},
+}
+
+// loadManifestWithClient reads the manifest and connects to the registry, in the context
+// of --context, the manifest or the configuration
+func loadManifestWithClient() (*client.Client, *manifest.Manifest, string, error) {
+	if manifestFile == "" {
+		return nil, nil, "", fmt.Errorf("a manifest is required: use --file")
+	}
+	m, err := manifest.Load(manifestFile)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	c, err := createClientWithFlags()
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to create client: %w", err)
+	}
+	contextName := context
+	if contextName == "" {
+		contextName = m.Context
+	}
+	return c, m, config.GetEffectiveContext(contextName), nil
+}
+
+// buildPlan reads the schemas of the manifest, observes the registry and plans the changes,
+// pre-checking the compatibility of new schema versions
+func buildPlan(c *client.Client, m *manifest.Manifest, effectiveContext string) (*manifest.Plan, map[string]*schemaSource, error) {
+	schemas := map[string]*schemaSource{}
+	for _, name := range m.SubjectNames() {
+		s := m.Subjects[name]
+		if s.Schema == "" {
+			continue
+		}
+		source, err := loadSchemaFileRecord(m.SchemaPath(s), s.Type, s.Record)
+		if err != nil {
+			return nil, nil, fmt.Errorf("subject %s: %w", name, err)
+		}
+		schemas[name] = source
+	}
+
+	state := &manifest.State{Subjects: map[string]manifest.SubjectState{}}
+	if cfg, err := c.GetGlobalConfig(effectiveContext); err == nil {
+		state.Compatibility = configLevel(cfg)
+	} else {
+		return nil, nil, fmt.Errorf("failed to get global config: %w", err)
+	}
+	if mode, err := c.GetGlobalMode(effectiveContext); err == nil {
+		state.Mode = mode.Mode
+	} else {
+		return nil, nil, fmt.Errorf("failed to get global mode: %w", err)
+	}
+
+	for _, name := range m.SubjectNames() {
+		current, err := observeSubject(c, name, schemas[name], m.Subjects[name], effectiveContext)
+		if err != nil {
+			return nil, nil, err
+		}
+		state.Subjects[name] = *current
+	}
+
+	plan, err := manifest.Diff(m, state)
+	if err != nil {
+		return nil, nil, err
+	}
+	plan.Context = effectiveContext
+
+	for i := range plan.Changes {
+		change := &plan.Changes[i]
+		if change.Kind != manifest.KindSchema || change.Check == manifest.CheckDeferred {
+			continue
+		}
+		if err := precheckSchema(c, plan, state, change, m.Subjects[change.Subject], schemas[change.Subject], effectiveContext); err != nil {
+			return nil, nil, err
+		}
+	}
+	return plan, schemas, nil
+}
+
+// observeSubject reads the current state of a subject: its versions, the version matching
+// the desired schema and its own compatibility level and mode
+func observeSubject(c *client.Client, name string, source *schemaSource, desired *manifest.Subject, effectiveContext string) (*manifest.SubjectState, error) {
+	current := &manifest.SubjectState{}
+
+	versions, err := c.GetSubjectVersions(name, effectiveContext)
+	if err != nil && !client.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get versions for subject %s: %w", name, err)
+	}
+	for _, v := range versions {
+		if v > current.Latest {
+			current.Latest = v
+		}
+	}
+
+	if source != nil && current.Latest > 0 {
+		references, err := manifestReferences(c, desired, effectiveContext)
+		if err != nil && !client.IsNotFound(err) {
+			return nil, err
+		}
+		if err == nil {
+			req := &client.SchemaRequest{Schema: source.Content, SchemaType: source.SchemaType, References: references}
+			registered, err := c.LookupSchema(name, req, effectiveContext)
+			if err != nil && !client.IsNotFound(err) {
+				return nil, fmt.Errorf("failed to look up schema of subject %s: %w", name, err)
+			}
+			if err == nil {
+				current.Matching = registered.Version
+			}
+		}
+	}
+
+	cfg, err := c.GetSubjectConfig(name, effectiveContext)
+	if err != nil && !client.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get config for subject %s: %w", name, err)
+	}
+	if err == nil {
+		current.Compatibility = configLevel(cfg)
+	}
+	mode, err := c.GetSubjectMode(name, effectiveContext)
+	if err != nil && !client.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get mode for subject %s: %w", name, err)
+	}
+	if err == nil {
+		current.Mode = mode.Mode
+	}
+	return current, nil
+}
+
+// manifestReferences resolves the references of a manifest subject, latest to its number
+func manifestReferences(c *client.Client, s *manifest.Subject, effectiveContext string) ([]client.Reference, error) {
+	var references []client.Reference
+	for _, ref := range s.References {
+		version, _ := ref.VersionNumber()
+		if version == 0 {
+			latest, err := c.GetSchema(ref.Subject, "latest", effectiveContext)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get latest version of reference subject %s: %w", ref.Subject, err)
+			}
+			version = latest.Version
+		}
+		references = append(references, client.Reference{Name: ref.Name, Subject: ref.Subject, Version: version})
+	}
+	return references, nil
+}
+
+// precheckSchema validates a planned schema and checks a new version for compatibility,
+// under the level the plan sets for the subject when it changes it
+func precheckSchema(c *client.Client, plan *manifest.Plan, state *manifest.State, change *manifest.Change, desired *manifest.Subject, source *schemaSource, effectiveContext string) error {
+	references, err := manifestReferences(c, desired, effectiveContext)
+	if err != nil {
+		change.Check = manifest.CheckSkipped
+		change.Note = err.Error()
+		return nil
+	}
+	referenceContents, err := c.ReferenceContents(references, effectiveContext)
+	if err != nil {
+		return err
+	}
+	if err := schema.Validate(source.SchemaType, source.Content, referenceContents...); err != nil {
+		return fmt.Errorf("subject %s: invalid schema %s: %w", change.Subject, source.Label, err)
+	}
+	if change.Action == manifest.ActionCreate {
+		return nil
+	}
+
+	level := plannedLevel(plan, state, change.Subject)
+	if level != "" {
+		if checker, err := compat.CheckerFor(source.SchemaType); err == nil && len(references) == 0 {
+			previous, err := fetchPreviousSchemas(c, change.Subject, level, effectiveContext)
+			if err != nil {
+				return err
+			}
+			result, err := compat.Check(level, checker, source.Content, previous)
+			if err != nil {
+				return fmt.Errorf("subject %s: failed to check compatibility: %w", change.Subject, err)
+			}
+			change.Check = manifest.CheckCompatible
+			if !result.IsCompatible {
+				change.Check = manifest.CheckIncompatible
+				change.Issues = compat.Annotate(result.Issues, source.SchemaType, source.Content, previous...)
+			}
+			change.Note = fmt.Sprintf("checked locally under the planned %s level", level)
+			return nil
+		}
+	}
+
+	req := &client.SchemaRequest{Schema: source.Content, SchemaType: source.SchemaType, References: references}
+	result, err := c.CheckCompatibility(change.Subject, req, effectiveContext, true)
+	if err != nil {
+		return fmt.Errorf("subject %s: failed to check compatibility: %w", change.Subject, err)
+	}
+	change.Check = manifest.CheckCompatible
+	if !result.IsCompatible {
+		change.Check = manifest.CheckIncompatible
+		change.Issues = compat.Annotate(compat.ParseMessages(result.Messages), source.SchemaType, source.Content, compat.PreviousSchemas(result.Messages)...)
+	}
+	if level != "" {
+		change.Note = fmt.Sprintf("checked under the current level, before the planned %s level is set", level)
+	}
+	return nil
+}
+
+// plannedLevel returns the compatibility level the plan sets for a subject, directly or
+// through the global level when the subject has none of its own, or "" when unchanged
+func plannedLevel(plan *manifest.Plan, state *manifest.State, subject string) string {
+	global := ""
+	for _, change := range plan.Changes {
+		if change.Kind != manifest.KindCompatibility {
+			continue
+		}
+		if change.Subject == subject {
+			return change.To
+		}
+		if change.Subject == "" {
+			global = change.To
+		}
+	}
+	if state.Subjects[subject].Compatibility != "" {
+		return ""
+	}
+	return global
+}
+
+// planRow is a change in the table output of a plan
+type planRow struct {
+	Subject string `json:"subject"`
+	Change  string `json:"change"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Check   string `json:"check"`
+}
+
+func printPlan(out io.Writer, plan *manifest.Plan) error {
+	if len(plan.Changes) == 0 {
+		fmt.Fprintf(out, "✅ No changes: the registry matches the manifest (%d subject(s))\n", len(plan.Unchanged))
+		return nil
+	}
+
+	rows := make([]interface{}, 0, len(plan.Changes))
+	for _, change := range plan.Changes {
+		from := change.From
+		switch {
+		case change.Action == manifest.ActionCreate:
+			from = "-"
+		case from == "" && change.Subject != "":
+			from = "(inherited)"
+		case from == "":
+			from = "-"
+		}
+		check := map[string]string{
+			manifest.CheckCompatible:   "✅ compatible",
+			manifest.CheckIncompatible: "❌ incompatible",
+			manifest.CheckDeferred:     "⏳ deferred",
+			manifest.CheckSkipped:      "❔ skipped",
+		}[change.Check]
+		if check == "" {
+			check = "-"
+		}
+		rows = append(rows, planRow{Subject: change.Target(), Change: change.Action + " " + change.Kind, From: from, To: change.To, Check: check})
+	}
+	if err := output.PrintTo(out, rows, "table"); err != nil {
+		return err
+	}
+
+	for _, change := range plan.Changes {
+		if change.Note == "" && len(change.Issues) == 0 {
+			continue
+		}
+		fmt.Fprintf(out, "\n%s (%s %s):\n", change.Target(), change.Action, change.Kind)
+		if change.Note != "" {
+			fmt.Fprintf(out, "  %s\n", change.Note)
+		}
+		printCompatibilityIssues(out, change.Issues)
+	}
+
+	creates, registers, sets, incompatible := plan.Count()
+	fmt.Fprintf(out, "\nPlan: %d subject(s) to create, %d schema version(s) to register, %d setting(s) to change", creates, registers, sets)
+	if incompatible > 0 {
+		fmt.Fprintf(out, ", ❌ %d incompatible", incompatible)
+	}
+	fmt.Fprintln(out)
+	return nil
+}
+
+// confirmApply asks for confirmation on the terminal
+func confirmApply(out io.Writer, changes int) (bool, error) {
+	stat, err := os.Stdin.Stat()
+	if err != nil || (stat.Mode()&os.ModeCharDevice) == 0 {
+		return false, fmt.Errorf("apply needs confirmation: run it in a terminal or pass --yes")
+	}
+	fmt.Fprintf(out, "\nApply %d change(s)? [y/N] ", changes)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// ApplyResult is the outcome of one change of an applied plan
+type ApplyResult struct {
+	Subject string `json:"subject,omitempty"`
+	Kind    string `json:"kind"`
+	Action  string `json:"action"`
+	To      string `json:"to"`
+	Status  string `json:"status"` // "applied", "failed", "skipped"
+	Detail  string `json:"detail,omitempty"`
+}
+
+// ApplyReport is the outcome of an applied plan
+type ApplyReport struct {
+	Context string        `json:"context,omitempty"`
+	Applied int           `json:"applied"`
+	Failed  int           `json:"failed"`
+	Skipped int           `json:"skipped"`
+	Results []ApplyResult `json:"results"`
+}
+
+// applyPlan applies the changes of a plan in order, stopping at the first failure
+func applyPlan(c *client.Client, m *manifest.Manifest, plan *manifest.Plan, schemas map[string]*schemaSource, effectiveContext string) *ApplyReport {
+	report := &ApplyReport{Context: plan.Context, Results: []ApplyResult{}}
+	for _, change := range plan.Changes {
+		result := ApplyResult{Subject: change.Subject, Kind: change.Kind, Action: change.Action, To: change.To}
+		if report.Failed > 0 {
+			result.Status = "skipped"
+			result.Detail = "an earlier change failed"
+			report.Skipped++
+			report.Results = append(report.Results, result)
+			continue
+		}
+
+		detail, err := applyChange(c, m, change, schemas[change.Subject], effectiveContext)
+		if err != nil {
+			result.Status = "failed"
+			result.Detail = err.Error()
+			report.Failed++
+		} else {
+			result.Status = "applied"
+			result.Detail = detail
+			report.Applied++
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+// applyChange applies one change and describes its outcome
+func applyChange(c *client.Client, m *manifest.Manifest, change manifest.Change, source *schemaSource, effectiveContext string) (string, error) {
+	switch change.Kind {
+	case manifest.KindCompatibility:
+		cfg := &client.Config{Compatibility: change.To}
+		var err error
+		if change.Subject == "" {
+			_, err = c.SetGlobalConfig(cfg, effectiveContext)
+		} else {
+			_, err = c.SetSubjectConfig(change.Subject, cfg, effectiveContext)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to set compatibility: %w", err)
+		}
+		return "", nil
+	case manifest.KindMode:
+		var err error
+		if change.Subject == "" {
+			_, err = c.SetGlobalMode(change.To, effectiveContext)
+		} else {
+			_, err = c.SetSubjectMode(change.Subject, change.To, effectiveContext)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to set mode: %w", err)
+		}
+		return "", nil
+	default:
+		// References to the latest version are resolved now, after their subjects were applied
+		references, err := manifestReferences(c, m.Subjects[change.Subject], effectiveContext)
+		if err != nil {
+			return "", err
+		}
+		req := &client.SchemaRequest{Schema: source.Content, SchemaType: source.SchemaType, References: references}
+		response, err := c.RegisterSchema(change.Subject, req, effectiveContext)
+		if err != nil {
+			return "", fmt.Errorf("failed to register schema: %w", err)
+		}
+		return fmt.Sprintf("schema ID %d", response.ID), nil
+	}
+}
+
+func printApplyReport(report *ApplyReport) error {
+	fmt.Println()
+	rows := make([]interface{}, 0, len(report.Results))
+	for _, result := range report.Results {
+		status := map[string]string{"applied": "✅ applied", "failed": "❌ failed", "skipped": "⏭  skipped"}[result.Status]
+		subject := result.Subject
+		if subject == "" {
+			subject = "(global)"
+		}
+		rows = append(rows, applyRow{Subject: subject, Change: result.Action + " " + result.Kind, To: result.To, Result: status, Detail: result.Detail})
+	}
+	if err := output.Print(rows, "table"); err != nil {
+		return err
+	}
+	fmt.Printf("\nApplied %d of %d change(s)", report.Applied, len(report.Results))
+	if report.Failed > 0 {
+		fmt.Printf(", %d failed, %d skipped", report.Failed, report.Skipped)
+	}
+	fmt.Println()
+	return nil
+}
+
+// applyRow is a change in the table output of an apply report
+type applyRow struct {
+	Subject string `json:"subject"`
+	Change  string `json:"change"`
+	To      string `json:"to"`
+	Result  string `json:"result"`
+	Detail  string `json:"detail"`
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+	rootCmd.AddCommand(applyCmd)
+
+	for _, c := range []*cobra.Command{planCmd, applyCmd} {
+		c.Flags().StringVarP(&manifestFile, "file", "f", "", "Manifest of the desired state (YAML or JSON)")
+		c.Flags().StringVar(&context, "context", "", "Schema Registry context (default: the manifest's context)")
+		c.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table, json, yaml)")
+	}
+	applyCmd.Flags().BoolVarP(&applyYes, "yes", "y", false, "Apply without asking for confirmation")
+}
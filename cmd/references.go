@@ -0,0 +1,294 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/internal/avro"
+	"github.com/aywengo/ksr-cli/internal/protobuf"
+	"github.com/aywengo/ksr-cli/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	referenceSpecs           []string
+	referenceDir             string
+	referenceSubjectStrategy string
+	registerReferences       bool
+)
+
+// addReferenceFlags adds the flags that resolve the references of a schema to a command
+func addReferenceFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&referenceSpecs, "ref", nil, "Schema reference as NAME=SUBJECT:VERSION, with a version number or latest (repeatable)")
+	cmd.Flags().StringVar(&referenceDir, "ref-dir", "", "Directory holding the imported .proto files or the Avro schemas of external named types")
+	cmd.Flags().StringVar(&referenceSubjectStrategy, "ref-subject-strategy", "name", "Subject of a dependency from --ref-dir: name, or a template such as {name}-value")
+}
+
+// addReferenceFileFlag adds --ref-file, naming local schema files that define the types a
+// local schema uses, as opposed to the registered references of --ref and --ref-dir
+func addReferenceFileFlag(cmd *cobra.Command, files *[]string, usage string) {
+	cmd.Flags().StringArrayVarP(files, "ref-file", "r", nil, usage)
+}
+
+// resolveSchemaReferences resolves the references of the schema being registered or checked
+// and fetches their contents, to validate the schema against
+func resolveSchemaReferences(c *client.Client, content, effectiveContext string) ([]client.Reference, []string, error) {
+	references, err := schemaReferences(c, schemaType, content, effectiveContext)
+	if err != nil {
+		return nil, nil, err
+	}
+	contents, err := c.ReferenceContents(references, effectiveContext)
+	if err != nil {
+		return nil, nil, err
+	}
+	return references, contents, nil
+}
+
+// schemaReferences resolves the references of a schema from --ref and --ref-dir
+func schemaReferences(c *client.Client, schemaType, content, effectiveContext string) ([]client.Reference, error) {
+	var references []client.Reference
+	explicit := map[string]bool{}
+	for _, spec := range referenceSpecs {
+		ref, err := parseReferenceSpec(c, spec, effectiveContext)
+		if err != nil {
+			return nil, err
+		}
+		references = append(references, *ref)
+		explicit[ref.Name] = true
+	}
+	if referenceDir == "" {
+		return references, nil
+	}
+
+	if referenceSubjectStrategy != "name" && !strings.Contains(referenceSubjectStrategy, "{name}") {
+		return nil, fmt.Errorf("invalid --ref-subject-strategy %q: expected name or a template containing {name}", referenceSubjectStrategy)
+	}
+	r := &referenceResolver{
+		client:     c,
+		context:    effectiveContext,
+		explicit:   explicit,
+		resolved:   map[string]*client.Reference{},
+		inProgress: map[string]bool{},
+	}
+	found, err := r.dependencies(schemaType, content)
+	if err != nil {
+		return nil, err
+	}
+	return append(references, found...), nil
+}
+
+// parseReferenceSpec parses NAME=SUBJECT:VERSION, resolving the latest version to its number
+func parseReferenceSpec(c *client.Client, spec, effectiveContext string) (*client.Reference, error) {
+	name, target, ok := strings.Cut(spec, "=")
+	i := strings.LastIndex(target, ":")
+	if !ok || name == "" || i <= 0 || i == len(target)-1 {
+		return nil, fmt.Errorf("invalid reference %q: expected NAME=SUBJECT:VERSION", spec)
+	}
+	ref := &client.Reference{Name: name, Subject: target[:i]}
+
+	versionText := target[i+1:]
+	if versionText == "latest" {
+		schema, err := c.GetSchema(ref.Subject, "latest", effectiveContext)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get latest version of reference subject %s: %w", ref.Subject, err)
+		}
+		ref.Version = schema.Version
+		return ref, nil
+	}
+	version, err := strconv.Atoi(versionText)
+	if err != nil || version < 1 {
+		return nil, fmt.Errorf("invalid reference %q: version must be a positive number or latest", spec)
+	}
+	ref.Version = version
+	return ref, nil
+}
+
+// referenceResolver maps the imports of Protobuf schemas and the external named types of
+// Avro schemas to files in --ref-dir and to the subjects those files are registered under
+type referenceResolver struct {
+	client  *client.Client
+	context string
+	// explicit holds the reference names given with --ref
+	explicit map[string]bool
+	// resolved holds the references of the files resolved so far, by path
+	resolved   map[string]*client.Reference
+	inProgress map[string]bool
+	// avroTypes maps the full names defined by the Avro files of the directory to their file
+	avroTypes map[string]string
+}
+
+// dependencies returns the references of a schema to files of the directory
+func (r *referenceResolver) dependencies(schemaType, content string) ([]client.Reference, error) {
+	switch strings.ToUpper(schemaType) {
+	case "PROTOBUF":
+		return r.protobufDependencies(content)
+	case "AVRO", "":
+		return r.avroDependencies(content)
+	default:
+		return nil, fmt.Errorf("--ref-dir supports Avro and Protobuf schemas; pass JSON Schema references with --ref")
+	}
+}
+
+func (r *referenceResolver) protobufDependencies(content string) ([]client.Reference, error) {
+	f, err := protobuf.Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Protobuf schema: %w", err)
+	}
+
+	var references []client.Reference
+	for _, imp := range f.Imports {
+		if r.explicit[imp.Path] || protobuf.IsWellKnownImport(imp.Path) {
+			continue
+		}
+		path := filepath.Join(referenceDir, filepath.FromSlash(imp.Path))
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("import %q is not in %s; pass it with --ref", imp.Path, referenceDir)
+		}
+		ref, err := r.dependency(imp.Path, path, "PROTOBUF")
+		if err != nil {
+			return nil, err
+		}
+		references = append(references, *ref)
+	}
+	return references, nil
+}
+
+func (r *referenceResolver) avroDependencies(content string) ([]client.Reference, error) {
+	_, external, err := avro.NamedTypes(content)
+	if err != nil {
+		return nil, err
+	}
+	if len(external) > 0 && r.avroTypes == nil {
+		if err := r.indexAvroTypes(); err != nil {
+			return nil, err
+		}
+	}
+
+	var references []client.Reference
+	added := map[string]bool{}
+	for _, name := range external {
+		if r.explicit[name] {
+			continue
+		}
+		path, ok := r.avroTypes[name]
+		if !ok {
+			path, ok = r.avroTypes[name[strings.LastIndex(name, ".")+1:]]
+		}
+		if !ok {
+			return nil, fmt.Errorf("type %s is not defined by a schema in %s; pass it with --ref", name, referenceDir)
+		}
+		if added[path] {
+			continue
+		}
+		added[path] = true
+
+		ref, err := r.dependency("", path, "AVRO")
+		if err != nil {
+			return nil, err
+		}
+		if !r.explicit[ref.Name] {
+			references = append(references, *ref)
+		}
+	}
+	return references, nil
+}
+
+// indexAvroTypes finds the Avro schema files (.avsc and .json) of the directory and the
+// named types they define, by full and by short name
+func (r *referenceResolver) indexAvroTypes() error {
+	r.avroTypes = map[string]string{}
+	return filepath.WalkDir(referenceDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if d.IsDir() || ext != ".avsc" && ext != ".json" {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		defined, _, err := avro.NamedTypes(string(content))
+		if err != nil {
+			// Not an Avro schema
+			return nil
+		}
+		for _, name := range defined {
+			short := name[strings.LastIndex(name, ".")+1:]
+			for _, key := range []string{name, short} {
+				if _, exists := r.avroTypes[key]; !exists {
+					r.avroTypes[key] = path
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// dependency returns the reference to a file of the directory, resolving its own
+// dependencies and registering it when it is missing and --register-refs is set, which
+// only create schema offers, so that checks have no side effects. Avro
+// references are named after the type the file defines.
+func (r *referenceResolver) dependency(name, path, schemaType string) (*client.Reference, error) {
+	if ref, ok := r.resolved[path]; ok {
+		return ref, nil
+	}
+	if r.inProgress[path] {
+		return nil, fmt.Errorf("circular reference through %s", path)
+	}
+	r.inProgress[path] = true
+	defer delete(r.inProgress, path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	content := string(data)
+	if name == "" {
+		defined, _, err := avro.NamedTypes(content)
+		if err != nil || len(defined) == 0 {
+			return nil, fmt.Errorf("%s does not define a named Avro type", path)
+		}
+		name = defined[0]
+	}
+	references, err := r.dependencies(schemaType, content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	subject := referenceSubject(name)
+	request := &client.SchemaRequest{Schema: content, SchemaType: schemaType, References: references}
+	schema, err := r.client.LookupSchema(subject, request, r.context)
+	if client.IsNotFound(err) {
+		if !registerReferences {
+			return nil, fmt.Errorf("%s is not registered under subject %s; register it first, e.g. with create schema --register-refs", path, subject)
+		}
+		result, err := r.client.RegisterSchema(subject, request, r.context)
+		if err != nil {
+			return nil, fmt.Errorf("failed to register %s under subject %s: %w", path, subject, err)
+		}
+		if schema, err = r.client.LookupSchema(subject, request, r.context); err != nil {
+			return nil, fmt.Errorf("failed to look up %s under subject %s: %w", path, subject, err)
+		}
+		fmt.Fprintf(os.Stderr, "Registered dependency %s as %s version %d (ID: %d)\n", path, subject, schema.Version, result.ID)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to look up %s under subject %s: %w", path, subject, err)
+	}
+
+	ref := &client.Reference{Name: name, Subject: subject, Version: schema.Version}
+	r.resolved[path] = ref
+	return ref, nil
+}
+
+// referenceSubject names the subject of a dependency with --ref-subject-strategy
+func referenceSubject(name string) string {
+	if referenceSubjectStrategy == "name" {
+		return name
+	}
+	return strings.ReplaceAll(referenceSubjectStrategy, "{name}", name)
+}
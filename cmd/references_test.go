@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aywengo/ksr-cli/pkg/client"
+)
+
+// fakeRegistry is an in-memory Schema Registry serving the endpoints used to resolve references
+type fakeRegistry struct {
+	mu         sync.Mutex
+	schemas    []client.Schema
+	registered []string
+}
+
+func newFakeRegistry(t *testing.T) (*fakeRegistry, *client.Client) {
+	registry := &fakeRegistry{}
+	server := httptest.NewServer(registry)
+	t.Cleanup(server.Close)
+
+	c, err := client.New(client.Options{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return registry, c
+}
+
+func (f *fakeRegistry) register(subject, schemaType, schema string, references []client.Reference) client.Schema {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	version := 1
+	for _, s := range f.schemas {
+		if s.Subject == subject {
+			version = s.Version + 1
+		}
+	}
+	raw, _ := json.Marshal(schema)
+	registered := client.Schema{ID: len(f.schemas) + 1, Version: version, Subject: subject, Type: schemaType, Schema: raw, References: references}
+	f.schemas = append(f.schemas, registered)
+	f.registered = append(f.registered, subject)
+	return registered
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/")
+	if len(parts) < 2 || parts[0] != "subjects" {
+		http.NotFound(w, r)
+		return
+	}
+	subject, _ := url.PathUnescape(parts[1])
+
+	switch {
+	case r.Method == http.MethodGet && len(parts) == 4 && parts[2] == "versions":
+		f.mu.Lock()
+		var found *client.Schema
+		for i := range f.schemas {
+			s := &f.schemas[i]
+			if s.Subject == subject && (parts[3] == "latest" || parts[3] == strconv.Itoa(s.Version)) {
+				found = s
+			}
+		}
+		f.mu.Unlock()
+		if found == nil {
+			notFound(w, 40401, "Subject not found")
+			return
+		}
+		json.NewEncoder(w).Encode(found)
+
+	case r.Method == http.MethodPost:
+		var request client.SchemaRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(parts) == 3 && parts[2] == "versions" {
+			registered := f.register(subject, request.SchemaType, request.Schema, request.References)
+			json.NewEncoder(w).Encode(client.RegisterResponse{ID: registered.ID})
+			return
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for _, s := range f.schemas {
+			if s.Subject == subject && client.SchemaText(s.Schema) == request.Schema {
+				json.NewEncoder(w).Encode(s)
+				return
+			}
+		}
+		notFound(w, 40403, "Schema not found")
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func notFound(w http.ResponseWriter, code int, message string) {
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error_code": code, "message": message})
+}
+
+// setReferenceFlags sets the reference flags for a test, restoring the defaults afterwards
+func setReferenceFlags(t *testing.T, specs []string, dir, strategy string, register bool) {
+	referenceSpecs, referenceDir, referenceSubjectStrategy, registerReferences = specs, dir, strategy, register
+	t.Cleanup(func() {
+		referenceSpecs, referenceDir, referenceSubjectStrategy, registerReferences = nil, "", "name", false
+	})
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestParseReferenceSpec(t *testing.T) {
+	registry, c := newFakeRegistry(t)
+	registry.register("address-value", "AVRO", `"string"`, nil)
+	registry.register("address-value", "AVRO", `"bytes"`, nil)
+
+	ref, err := parseReferenceSpec(c, "com.acme.Address=address-value:latest", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if *ref != (client.Reference{Name: "com.acme.Address", Subject: "address-value", Version: 2}) {
+		t.Errorf("Expected latest to resolve to version 2, got %+v", ref)
+	}
+
+	ref, err = parseReferenceSpec(c, "common/money.proto=ns:money:3", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if *ref != (client.Reference{Name: "common/money.proto", Subject: "ns:money", Version: 3}) {
+		t.Errorf("Expected the subject up to the last colon, got %+v", ref)
+	}
+
+	for _, spec := range []string{"address-value:1", "=address-value:1", "Address=address-value", "Address=address-value:", "Address=:1", "Address=address-value:0", "Address=address-value:two"} {
+		if _, err := parseReferenceSpec(c, spec, ""); err == nil {
+			t.Errorf("Expected an error for %q", spec)
+		}
+	}
+	if _, err := parseReferenceSpec(c, "Missing=missing-value:latest", ""); err == nil {
+		t.Error("Expected an error for the latest version of a missing subject")
+	}
+}
+
+func TestSchemaReferences_TransitiveProtobufImports(t *testing.T) {
+	currency := "syntax = \"proto3\";\npackage common;\nmessage Currency { string code = 1; }\n"
+	money := "syntax = \"proto3\";\npackage common;\nimport \"common/currency.proto\";\nmessage Money { int64 units = 1; Currency currency = 2; }\n"
+	dir := writeFiles(t, map[string]string{"common/currency.proto": currency, "common/money.proto": money})
+	order := "syntax = \"proto3\";\nimport \"common/money.proto\";\nimport \"google/protobuf/timestamp.proto\";\nmessage Order { common.Money total = 1; }\n"
+
+	registry, c := newFakeRegistry(t)
+	setReferenceFlags(t, nil, dir, "name", true)
+
+	references, err := schemaReferences(c, "PROTOBUF", order, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(references) != 1 || references[0] != (client.Reference{Name: "common/money.proto", Subject: "common/money.proto", Version: 1}) {
+		t.Errorf("Expected a reference to common/money.proto, got %+v", references)
+	}
+	if got := strings.Join(registry.registered, ","); got != "common/currency.proto,common/money.proto" {
+		t.Errorf("Expected dependencies to be registered before the schemas importing them, got %s", got)
+	}
+	if refs := registry.schemas[1].References; len(refs) != 1 || refs[0].Subject != "common/currency.proto" {
+		t.Errorf("Expected common/money.proto to be registered with its own reference, got %+v", refs)
+	}
+
+	// Registered dependencies are found again without registering them twice
+	if _, err := schemaReferences(c, "PROTOBUF", order, ""); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(registry.registered) != 2 {
+		t.Errorf("Expected no new registrations, got %v", registry.registered)
+	}
+}
+
+func TestSchemaReferences_MissingDependency(t *testing.T) {
+	address := `{"type":"record","name":"Address","namespace":"com.acme","fields":[{"name":"city","type":"string"}]}`
+	dir := writeFiles(t, map[string]string{"address.avsc": address})
+	user := `{"type":"record","name":"User","namespace":"com.acme","fields":[{"name":"address","type":"com.acme.Address"}]}`
+
+	registry, c := newFakeRegistry(t)
+	setReferenceFlags(t, nil, dir, "name", false)
+	_, err := schemaReferences(c, "AVRO", user, "")
+	if err == nil || !strings.Contains(err.Error(), "is not registered under subject com.acme.Address") {
+		t.Fatalf("Expected an error for an unregistered dependency, got %v", err)
+	}
+	if len(registry.registered) != 0 {
+		t.Errorf("Expected nothing to be registered without --register-refs, got %v", registry.registered)
+	}
+
+	registerReferences = true
+	references, err := schemaReferences(c, "AVRO", user, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(references) != 1 || references[0] != (client.Reference{Name: "com.acme.Address", Subject: "com.acme.Address", Version: 1}) {
+		t.Errorf("Expected a reference to the registered dependency, got %+v", references)
+	}
+	if got := strings.Join(registry.registered, ","); got != "com.acme.Address" {
+		t.Errorf("Expected the dependency to be registered, got %s", got)
+	}
+}
+
+func TestSchemaReferences_SubjectStrategy(t *testing.T) {
+	address := `{"type":"record","name":"Address","namespace":"com.acme","fields":[{"name":"city","type":"string"}]}`
+	dir := writeFiles(t, map[string]string{"types/address.avsc": address})
+	user := `{"type":"record","name":"User","namespace":"com.acme","fields":[{"name":"address","type":"Address"}]}`
+
+	registry, c := newFakeRegistry(t)
+	registry.register("com.acme.Address-value", "AVRO", address, nil)
+	setReferenceFlags(t, nil, dir, "{name}-value", false)
+
+	references, err := schemaReferences(c, "AVRO", user, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(references) != 1 || references[0] != (client.Reference{Name: "com.acme.Address", Subject: "com.acme.Address-value", Version: 1}) {
+		t.Errorf("Expected the subject named by the template, got %+v", references)
+	}
+
+	referenceSubjectStrategy = "value"
+	if _, err := schemaReferences(c, "AVRO", user, ""); err == nil {
+		t.Error("Expected an error for a strategy without {name}")
+	}
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/aywengo/ksr-cli/internal/config"
+	"github.com/aywengo/ksr-cli/internal/output"
+	"github.com/aywengo/ksr-cli/internal/schema"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchQuery       schema.Query
+	searchAllVersions bool
+)
+
+// SearchHit is a field or schema selected by a search
+type SearchHit struct {
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+	Path    string `json:"path"`
+	Type    string `json:"type"`
+}
+
+// searchCmd represents the search command
+var searchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "Search fields and types across all subjects",
+	Long: func() string {
+		return fmt.Sprintf(`Search the fields of every subject in a context by name, type, namespace or
+documentation.
+
+Predicates are combined: a hit must match every one given. Names, types and namespaces
+are compared case-insensitively and exactly, documentation as a substring. With --regex
+every value is a regular expression instead, which matches anywhere in the value unless
+anchored with ^ and $, and a --field expression is matched against both the field name
+and its path.
+
+  --field      field name, or field path when it contains a dot (address.zip); paths
+               leave out the [] and {} of arrays and maps, so items.sku finds items[].sku
+  --type       type of the field or a named type it uses, so --type com.acme.Money finds
+               Money fields, arrays of Money and optional Money alike
+  --namespace  namespace of the named types a field uses
+  --doc        documentation of the field
+
+The schemas themselves are reported at path <root> for type, namespace and doc
+predicates. The latest version of every subject is searched, or every version with
+--all-versions.
+
+Examples:
+  %s search --field customer_id
+  %s search --type com.acme.Money
+  %s search --namespace com.acme.legacy --all-versions
+  %s search --doc deprecated
+  %s search --field '.*_id$' --regex -o json`, cmdName, cmdName, cmdName, cmdName, cmdName)
+	}(),
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		matcher, err := searchQuery.Compile()
+		if err != nil {
+			return err
+		}
+
+		c, err := createClientWithFlags()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		effectiveContext := config.GetEffectiveContext(context)
+
+		subjects, err := c.GetSubjects(effectiveContext)
+		if err != nil {
+			return fmt.Errorf("failed to get subjects: %w", err)
+		}
+
+		hits := []SearchHit{}
+		for _, subject := range subjects {
+			versions := []string{"latest"}
+			if searchAllVersions {
+				numbers, err := c.GetSubjectVersions(subject, effectiveContext)
+				if err != nil {
+					return fmt.Errorf("failed to get versions of %s: %w", subject, err)
+				}
+				versions = versions[:0]
+				for _, number := range numbers {
+					versions = append(versions, strconv.Itoa(number))
+				}
+			}
+
+			for _, version := range versions {
+				registered, err := c.GetSchema(subject, version, effectiveContext)
+				if err != nil {
+					return fmt.Errorf("failed to get schema %s version %s: %w", subject, version, err)
+				}
+				// A schema that cannot be read must not hide the hits in the others
+				source, err := registrySchemaSource(c, subject, registered, effectiveContext)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "⚠️  Skipping %s version %d: %v\n", subject, registered.Version, err)
+					continue
+				}
+				tree, err := schema.Parse(source.SchemaType, source.Content, source.References...)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "⚠️  Skipping %s: %v\n", source.Label, err)
+					continue
+				}
+				for _, match := range matcher.Search(tree) {
+					hits = append(hits, SearchHit{Subject: subject, Version: registered.Version, Path: match.Path, Type: match.Type})
+				}
+			}
+		}
+
+		actualOutputFormat, _ := cmd.Flags().GetString("output")
+		if actualOutputFormat != "table" {
+			return output.Print(hits, actualOutputFormat)
+		}
+		if len(hits) == 0 {
+			fmt.Printf("No matches in %d subject(s)\n", len(subjects))
+			return nil
+		}
+		rows := make([]interface{}, 0, len(hits))
+		for _, hit := range hits {
+			rows = append(rows, hit)
+		}
+		return output.Print(rows, actualOutputFormat)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().StringVar(&searchQuery.Field, "field", "", "Field name, or field path when it contains a dot")
+	searchCmd.Flags().StringVar(&searchQuery.Type, "type", "", "Type of the field or a named type it uses")
+	searchCmd.Flags().StringVar(&searchQuery.Namespace, "namespace", "", "Namespace of the named types a field uses")
+	searchCmd.Flags().StringVar(&searchQuery.Doc, "doc", "", "Text in the documentation of the field")
+	searchCmd.Flags().BoolVar(&searchQuery.Regex, "regex", false, "Treat the values of the predicates as regular expressions")
+	searchCmd.Flags().BoolVar(&searchAllVersions, "all-versions", false, "Search every version instead of the latest")
+	searchCmd.Flags().StringVar(&context, "context", "", "Schema Registry context")
+	searchCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table, json, yaml)")
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/aywengo/ksr-cli/internal/output"
+	"github.com/aywengo/ksr-cli/internal/schema"
+	"github.com/aywengo/ksr-cli/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	showSchemaType string
+	showReferences []string
+	showDepth      int
+	showNoDetails  bool
+	showColor      string
+	showOutput     string
+)
+
+// showCmd represents the show command
+var showCmd = &cobra.Command{
+	Use:   "show SUBJECT[@VERSION]|FILE",
+	Short: "Show a schema as a tree of its fields",
+	Long: func() string {
+		return fmt.Sprintf(`Render a registered schema or a local schema file as an indented tree of its fields.
+
+Every field is shown with its type and, unless --no-details is set, its nullability,
+default, requiredness (JSON Schema and Protobuf), enum symbols and documentation.
+Nested records, arrays, maps, unions and Protobuf messages are expanded to --depth
+levels (every level by default). Avro, JSON Schema and Protobuf schemas are supported,
+as well as Avro IDL files.
+
+Output formats:
+  tree  Indented field tree, colored on terminals (default)
+  json  The parsed tree of fields
+  yaml  The parsed tree of fields
+
+The same tree is printed by "get schemas SUBJECT -o tree".
+
+Examples:
+  %s show user-value
+  %s show user-value@2 --depth 1
+  %s show order.proto --color never
+  %s show user.avsc -o json`, cmdName, cmdName, cmdName, cmdName)
+	}(),
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch showOutput {
+		case "tree", "json", "yaml":
+		default:
+			return fmt.Errorf("unsupported output format: %s (expected tree, json or yaml)", showOutput)
+		}
+
+		references, err := loadReferenceFiles(showReferences)
+		if err != nil {
+			return err
+		}
+		source, err := loadSchemaArg(args[0], showSchemaType, references)
+		if err != nil {
+			return err
+		}
+		tree, err := schema.Parse(source.SchemaType, source.Content, source.References...)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", source.Label, err)
+		}
+
+		if showOutput != "tree" {
+			return output.Print(tree, showOutput)
+		}
+		fmt.Print(formatSchemaTree(source.Label, tree, schema.RenderOptions{
+			MaxDepth: showDepth,
+			Details:  !showNoDetails,
+			Color:    useColor(showColor),
+		}))
+		return nil
+	},
+}
+
+// printSchemaTree prints a registered schema as a tree of its fields
+func printSchemaTree(c *client.Client, subject string, registered *client.Schema, effectiveContext string) error {
+	source, err := registrySchemaSource(c, subject, registered, effectiveContext)
+	if err != nil {
+		return err
+	}
+	tree, err := schema.Parse(source.SchemaType, source.Content, source.References...)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", source.Label, err)
+	}
+	fmt.Print(formatSchemaTree(source.Label, tree, treeOptions(0)))
+	return nil
+}
+
+// treeOptions are the options of the field trees printed by get schemas -o tree and
+// describe, matching the defaults of show's flags
+func treeOptions(maxDepth int) schema.RenderOptions {
+	return schema.RenderOptions{MaxDepth: maxDepth, Details: true, Color: useColor("auto")}
+}
+
+// formatSchemaTree renders a parsed schema under a line naming where it came from
+func formatSchemaTree(label string, tree *schema.Tree, opts schema.RenderOptions) string {
+	return fmt.Sprintf("%s: %s\n%s", label, tree.Header(opts), tree.Render(opts))
+}
+
+func init() {
+	rootCmd.AddCommand(showCmd)
+
+	showCmd.Flags().IntVar(&showDepth, "depth", 0, "Levels of nested fields to expand (0 for all)")
+	showCmd.Flags().BoolVar(&showNoDetails, "no-details", false, "Only show field names, types and nullability")
+	showCmd.Flags().StringVar(&showColor, "color", "auto", "Color the tree (auto, always, never)")
+	showCmd.Flags().StringVarP(&showSchemaType, "type", "t", "", "Schema type of a local file (default: inferred from extension)")
+	addReferenceFileFlag(showCmd, &showReferences, "Schema file defining named types used by a local schema (repeatable)")
+	showCmd.Flags().StringVar(&idlRecord, "record", "", "Record to read from an Avro IDL (.avdl) file (default: its only top-level record)")
+	showCmd.Flags().StringVar(&context, "context", "", "Schema Registry context")
+	showCmd.Flags().StringVarP(&showOutput, "output", "o", "tree", "Output format (tree, json, yaml)")
+}
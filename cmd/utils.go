@@ -1,12 +1,22 @@
 package cmd
 
 import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
-	"github.com/aywengo/ksr-cli/internal/client"
+	"github.com/aywengo/ksr-cli/internal/avro"
 	"github.com/aywengo/ksr-cli/internal/config"
+	"github.com/aywengo/ksr-cli/internal/schema"
+	"github.com/aywengo/ksr-cli/pkg/client"
 )
 
 var (
@@ -16,6 +26,7 @@ var (
 	outputFormat string
 	context      string
 	version      string
+	idlRecord    string
 )
 
 // getSchemaContent gets schema content from file, inline, or stdin
@@ -26,11 +37,11 @@ func getSchemaContent() (string, error) {
 	}
 
 	if schemaFile != "" {
-		content, err := os.ReadFile(schemaFile)
+		content, err := readSchemaFile(schemaFile)
 		if err != nil {
 			return "", fmt.Errorf("failed to read schema file: %w", err)
 		}
-		return string(content), nil
+		return content, nil
 	}
 
 	// Read from stdin
@@ -51,6 +62,196 @@ func getSchemaContent() (string, error) {
 	return string(content), nil
 }
 
+// schemaSource is a schema loaded from a local file or from the registry
+type schemaSource struct {
+	Label      string
+	SchemaType string
+	Content    string
+	References []string
+}
+
+// isIDLFile reports whether a path names an Avro IDL file
+func isIDLFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".avdl")
+}
+
+// readSchemaFile reads a schema file. Avro IDL files are translated to the Avro JSON
+// schema of the record selected by --record.
+func readSchemaFile(path string) (string, error) {
+	return readSchemaFileRecord(path, idlRecord)
+}
+
+// readSchemaFileRecord reads a schema file, translating Avro IDL files to the Avro JSON
+// schema of the given record (by default the only top-level one)
+func readSchemaFileRecord(path, record string) (string, error) {
+	if !isIDLFile(path) {
+		content, err := os.ReadFile(path)
+		return string(content), err
+	}
+
+	idl, err := avro.ReadIDL(path)
+	if err != nil {
+		return "", err
+	}
+	content, err := idl.Schema(record)
+	if errors.Is(err, avro.ErrSeveralRecords) {
+		return "", fmt.Errorf("%s: %w with --record", path, err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return content, nil
+}
+
+// schemaTypeForFile infers the schema type from a file extension, unless one is given explicitly
+func schemaTypeForFile(path, explicitType string) string {
+	if explicitType != "" {
+		return strings.ToUpper(explicitType)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".proto":
+		return "PROTOBUF"
+	case ".json":
+		return "JSON"
+	default:
+		return "AVRO"
+	}
+}
+
+// loadSchemaFile reads a schema from a local file
+func loadSchemaFile(path, explicitType string) (*schemaSource, error) {
+	return loadSchemaFileRecord(path, explicitType, idlRecord)
+}
+
+// loadSchemaFileRecord reads a schema from a local file, selecting a record of Avro IDL files
+func loadSchemaFileRecord(path, explicitType, record string) (*schemaSource, error) {
+	schemaType := schemaTypeForFile(path, explicitType)
+	if isIDLFile(path) && schemaType != "AVRO" {
+		return nil, fmt.Errorf("%s is an Avro IDL file, not a %s schema", path, schemaType)
+	}
+
+	content, err := readSchemaFileRecord(path, record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema file: %w", err)
+	}
+
+	if schemaType == "JSON" && explicitType == "" && looksLikeAvro([]byte(content)) {
+		schemaType = "AVRO"
+	}
+
+	return &schemaSource{
+		Label:      path,
+		SchemaType: schemaType,
+		Content:    content,
+	}, nil
+}
+
+// looksLikeAvro reports whether JSON content is an Avro schema rather than a JSON schema,
+// since both are commonly stored in .json files
+func looksLikeAvro(content []byte) bool {
+	var raw interface{}
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return false
+	}
+
+	switch v := raw.(type) {
+	case string, []interface{}:
+		return true
+	case map[string]interface{}:
+		switch v["type"] {
+		case "record", "error", "enum", "fixed", "map", "int", "long", "float", "double", "bytes":
+			return true
+		case "array":
+			_, isSchema := v["items"].(string)
+			return isSchema
+		}
+	}
+	return false
+}
+
+// isSchemaFileArg reports whether a command argument names a local file rather than a
+// registered SUBJECT[@VERSION]
+func isSchemaFileArg(arg string) bool {
+	info, err := os.Stat(arg)
+	return err == nil && !info.IsDir()
+}
+
+// loadSchemaArg loads a schema named by a command argument: a local file if one exists at
+// that path, otherwise a registered SUBJECT[@VERSION]. Files get the given reference contents.
+func loadSchemaArg(arg, explicitType string, references []string) (*schemaSource, error) {
+	if isSchemaFileArg(arg) {
+		source, err := loadSchemaFile(arg, explicitType)
+		if err != nil {
+			return nil, err
+		}
+		source.References = references
+		return source, nil
+	}
+
+	c, err := createClientWithFlags()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	subject, version := parseSubjectVersion(arg)
+	return loadRegistrySchema(c, subject, version, config.GetEffectiveContext(context))
+}
+
+// loadRegistrySchema fetches a registered schema version together with the contents of its references
+func loadRegistrySchema(c *client.Client, subject, version, effectiveContext string) (*schemaSource, error) {
+	schema, err := c.GetSchema(subject, version, effectiveContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema %s version %s: %w", subject, version, err)
+	}
+	return registrySchemaSource(c, subject, schema, effectiveContext)
+}
+
+// registrySchemaSource builds a schema source from a registry response, resolving its references
+func registrySchemaSource(c *client.Client, subject string, schema *client.Schema, effectiveContext string) (*schemaSource, error) {
+	references, err := c.ReferenceContents(schema.References, effectiveContext)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaType := schema.Type
+	if schemaType == "" {
+		schemaType = "AVRO"
+	}
+
+	return &schemaSource{
+		Label:      fmt.Sprintf("%s (version %d)", subject, schema.Version),
+		SchemaType: schemaType,
+		Content:    client.SchemaText(schema.Schema),
+		References: references,
+	}, nil
+}
+
+// schemaFingerprints computes the canonical form of a schema and its CRC-64-AVRO, MD5 and SHA-256 fingerprints
+func schemaFingerprints(source *schemaSource) (*client.SchemaFingerprints, error) {
+	canonical, err := schema.CanonicalForm(source.SchemaType, source.Content, source.References...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute canonical form of %s: %w", source.Label, err)
+	}
+
+	md5Sum := md5.Sum([]byte(canonical))
+	sha256Sum := sha256.Sum256([]byte(canonical))
+
+	return &client.SchemaFingerprints{
+		CanonicalForm: canonical,
+		CRC64Avro:     fmt.Sprintf("%016x", avro.Rabin([]byte(canonical))),
+		MD5:           hex.EncodeToString(md5Sum[:]),
+		SHA256:        hex.EncodeToString(sha256Sum[:]),
+	}, nil
+}
+
+// indentJSON pretty-prints JSON content, preserving key order; other content is returned unchanged
+func indentJSON(content string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(strings.TrimSpace(content)), "", "  "); err != nil {
+		return content
+	}
+	return buf.String() + "\n"
+}
+
 // getEffectiveRegistryURL returns the registry URL to use (flag value or configured default)
 func getEffectiveRegistryURL() string {
 	if registryURL != "" {
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aywengo/ksr-cli/internal/output"
+	"github.com/aywengo/ksr-cli/internal/validate"
+	"github.com/spf13/cobra"
+)
+
+var (
+	validateDataFile   string
+	validateVersion    string
+	validateSchemaType string
+	validateReferences []string
+	validateAvroJSON   bool
+)
+
+// DataViolation is a violation found in one record of a data file
+type DataViolation struct {
+	Line    int    `json:"line"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// DataValidationReport is the result of validating a data file against a schema
+type DataValidationReport struct {
+	Schema     string          `json:"schema"`
+	Records    int             `json:"records"`
+	Valid      int             `json:"valid"`
+	Invalid    int             `json:"invalid"`
+	Violations []DataViolation `json:"violations"`
+}
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate data against schemas",
+	Long: func() string {
+		return fmt.Sprintf(`Validate data against registered schemas or local schema files.
+
+Examples:
+  %s validate data user-value -f users.ndjson
+  %s validate data user-value --version 3 -f users.ndjson`, cmdName, cmdName)
+	}(),
+}
+
+var validateDataCmd = &cobra.Command{
+	Use:   "data SUBJECT|FILE",
+	Short: "Validate JSON records against a schema",
+	Long: func() string {
+		return fmt.Sprintf(`Validate newline-delimited JSON records against an Avro, JSON Schema or Protobuf schema.
+
+The schema is the given version of a subject (default: latest), or a local schema file.
+Records are read from --file, or from stdin when it is omitted or "-". Blank lines are
+skipped. Every violation is reported with the line of its record and a JSON path such as
+$.lines[0].qty.
+
+  AVRO      Plain JSON records, as written by generate sample and decode, or with
+            --avro-json the Avro JSON encoding, where non-null union values are
+            wrapped as {"<type>": value}
+  JSON      JSON Schema validation, including $ref, combinators and common formats
+  PROTOBUF  Protobuf JSON mapping of the first message in the schema
+
+Avro IDL (.avdl) files are translated to the Avro schema of the record selected with
+--record, or of their only top-level record.
+
+The command exits non-zero when any record is invalid.
+
+Examples:
+  %s validate data user-value -f users.ndjson
+  %s validate data user-value --version 2 -f users.ndjson -o json
+  %s generate sample user-value -n 100 | %s validate data user-value
+  %s validate data order.proto -f orders.ndjson
+  %s validate data events.avdl --record Click -f clicks.ndjson`, cmdName, cmdName, cmdName, cmdName, cmdName, cmdName)
+	}(),
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		references, err := loadReferenceFiles(validateReferences)
+		if err != nil {
+			return err
+		}
+
+		arg := args[0]
+		if isSchemaFileArg(arg) {
+			if cmd.Flags().Changed("version") {
+				return fmt.Errorf("--version cannot be used with a schema file")
+			}
+		} else {
+			arg = arg + "@" + validateVersion
+		}
+		source, err := loadSchemaArg(arg, validateSchemaType, references)
+		if err != nil {
+			return err
+		}
+
+		validator, err := validate.New(source.SchemaType, source.Content, source.References, validate.Options{
+			AvroJSON: validateAvroJSON,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", source.Label, err)
+		}
+
+		input := os.Stdin
+		if validateDataFile != "" && validateDataFile != "-" {
+			file, err := os.Open(validateDataFile)
+			if err != nil {
+				return fmt.Errorf("failed to open data file: %w", err)
+			}
+			defer file.Close()
+			input = file
+		}
+
+		report, err := validateRecords(validator, input)
+		if err != nil {
+			return err
+		}
+		report.Schema = source.Label
+
+		actualOutputFormat, _ := cmd.Flags().GetString("output")
+		if actualOutputFormat == "table" {
+			if len(report.Violations) > 0 {
+				rows := make([]interface{}, 0, len(report.Violations))
+				for _, violation := range report.Violations {
+					rows = append(rows, violation)
+				}
+				if err := output.Print(rows, actualOutputFormat); err != nil {
+					return err
+				}
+				fmt.Println()
+			}
+			if report.Invalid == 0 {
+				fmt.Printf("✅ All %d record(s) are valid against %s\n", report.Records, report.Schema)
+			} else {
+				fmt.Printf("❌ %d of %d record(s) are invalid against %s\n", report.Invalid, report.Records, report.Schema)
+			}
+		} else if err := output.Print(report, actualOutputFormat); err != nil {
+			return err
+		}
+
+		if report.Invalid > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d invalid record(s)", report.Invalid)
+		}
+		return nil
+	},
+}
+
+// validateRecords validates each non-blank line of the input as one JSON record
+func validateRecords(validator *validate.Validator, input io.Reader) (*DataValidationReport, error) {
+	report := &DataValidationReport{Violations: []DataViolation{}}
+	reader := bufio.NewReader(input)
+
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to read data: %w", err)
+		}
+
+		if record := bytes.TrimSpace(data); len(record) > 0 {
+			report.Records++
+
+			var violations []validate.Violation
+			value, decodeErr := validate.Decode(record)
+			if decodeErr != nil {
+				violations = []validate.Violation{{Path: "$", Message: fmt.Sprintf("invalid JSON: %v", decodeErr)}}
+			} else {
+				violations = validator.Validate(value)
+			}
+
+			if len(violations) == 0 {
+				report.Valid++
+			} else {
+				report.Invalid++
+			}
+			for _, violation := range violations {
+				report.Violations = append(report.Violations, DataViolation{Line: line, Path: violation.Path, Message: violation.Message})
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			return report, nil
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+	validateCmd.AddCommand(validateDataCmd)
+
+	validateDataCmd.Flags().StringVarP(&validateDataFile, "file", "f", "", "Newline-delimited JSON records to validate (default: stdin)")
+	validateDataCmd.Flags().StringVarP(&validateVersion, "version", "V", "latest", "Schema version of the subject")
+	validateDataCmd.Flags().StringVarP(&validateSchemaType, "type", "t", "", "Schema type of a local file (default: inferred from extension)")
+	addReferenceFileFlag(validateDataCmd, &validateReferences, "Schema file defining named types used by a local schema (repeatable)")
+	validateDataCmd.Flags().BoolVar(&validateAvroJSON, "avro-json", false, "Read Avro records in the Avro JSON encoding, with unions wrapped by branch type")
+	validateDataCmd.Flags().StringVar(&idlRecord, "record", "", "Record to read from an Avro IDL (.avdl) file (default: its only top-level record)")
+	validateDataCmd.Flags().StringVar(&context, "context", "", "Schema Registry context")
+	validateDataCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table, json, yaml)")
+}
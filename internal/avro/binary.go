@@ -0,0 +1,511 @@
+package avro
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/aywengo/ksr-cli/internal/jsonfmt"
+)
+
+// DecodeBinary decodes Avro binary data written with a schema into a JSON value. Records
+// and maps keep their order as *jsonfmt.Object. Bytes and fixed values are strings of code
+// points U+0000 to U+00FF, as in the Avro JSON encoding. With avroJSON, non-null union
+// values are wrapped in an object keyed by the branch type; otherwise they are plain.
+func DecodeBinary(s *Schema, data []byte, avroJSON bool) (interface{}, error) {
+	d := &binaryDecoder{data: data, avroJSON: avroJSON}
+	value, err := d.decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, fmt.Errorf("%d unexpected bytes after the end of the value", len(d.data)-d.pos)
+	}
+	return value, nil
+}
+
+// maxNullItems limits the item count of a block beyond the remaining data
+const maxNullItems = 1 << 20
+
+type binaryDecoder struct {
+	data     []byte
+	pos      int
+	avroJSON bool
+}
+
+func (d *binaryDecoder) decode(s *Schema) (interface{}, error) {
+	switch s.Type {
+	case Null:
+		return nil, nil
+	case Boolean:
+		b, err := d.read(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0] != 0, nil
+	case Int:
+		n, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		if n < math.MinInt32 || n > math.MaxInt32 {
+			return nil, fmt.Errorf("int value %d out of range", n)
+		}
+		return n, nil
+	case Long:
+		return d.long()
+	case Float:
+		b, err := d.read(4)
+		if err != nil {
+			return nil, err
+		}
+		return jsonfmt.Float(float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))), nil
+	case Double:
+		b, err := d.read(8)
+		if err != nil {
+			return nil, err
+		}
+		return jsonfmt.Float(math.Float64frombits(binary.LittleEndian.Uint64(b))), nil
+	case String:
+		b, err := d.bytes()
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case Bytes:
+		b, err := d.bytes()
+		if err != nil {
+			return nil, err
+		}
+		return BytesToString(b), nil
+	case Fixed:
+		b, err := d.read(s.Size)
+		if err != nil {
+			return nil, err
+		}
+		return BytesToString(b), nil
+	case Enum:
+		index, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		if index < 0 || index >= int64(len(s.Symbols)) {
+			return nil, fmt.Errorf("enum index %d out of range for %s", index, s.FullName())
+		}
+		return s.Symbols[index], nil
+	case Array:
+		items := []interface{}{}
+		err := d.blocks(func() error {
+			item, err := d.decode(s.Items)
+			items = append(items, item)
+			return err
+		})
+		return items, err
+	case Map:
+		entries := &jsonfmt.Object{Members: []jsonfmt.Member{}}
+		err := d.blocks(func() error {
+			key, err := d.bytes()
+			if err != nil {
+				return err
+			}
+			value, err := d.decode(s.Values)
+			entries.Set(string(key), value)
+			return err
+		})
+		return entries, err
+	case Record, Error:
+		record := &jsonfmt.Object{Members: make([]jsonfmt.Member, 0, len(s.Fields))}
+		for _, field := range s.Fields {
+			value, err := d.decode(field.Type)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			record.Members = append(record.Members, jsonfmt.Member{Key: field.Name, Value: value})
+		}
+		return record, nil
+	case Union:
+		index, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		if index < 0 || index >= int64(len(s.Types)) {
+			return nil, fmt.Errorf("union index %d out of range for %s", index, s.String())
+		}
+		branch := s.Types[index]
+		value, err := d.decode(branch)
+		if err != nil || !d.avroJSON || branch.Type == Null {
+			return value, err
+		}
+		return &jsonfmt.Object{Members: []jsonfmt.Member{{Key: branch.TypeName(), Value: value}}}, nil
+	default:
+		return nil, fmt.Errorf("cannot decode type %s", s.Type)
+	}
+}
+
+// blocks reads the blocks of an array or map, calling item for each item
+func (d *binaryDecoder) blocks(item func() error) error {
+	for {
+		count, err := d.long()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+		if count < 0 {
+			// A negative count is followed by the size of the block in bytes
+			count = -count
+			if _, err := d.long(); err != nil {
+				return err
+			}
+		}
+		// Items take at least a byte unless they are null, so larger counts are corrupt
+		if count > int64(len(d.data)-d.pos) && count > maxNullItems {
+			return fmt.Errorf("block of %d items exceeds the data", count)
+		}
+		for ; count > 0; count-- {
+			if err := item(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (d *binaryDecoder) read(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.data) {
+		return nil, fmt.Errorf("unexpected end of data at byte %d", d.pos)
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// long reads a zig-zag varint
+func (d *binaryDecoder) long() (int64, error) {
+	n, size := binary.Varint(d.data[d.pos:])
+	if size <= 0 {
+		return 0, fmt.Errorf("invalid varint at byte %d", d.pos)
+	}
+	d.pos += size
+	return n, nil
+}
+
+// bytes reads a length-prefixed byte sequence
+func (d *binaryDecoder) bytes() ([]byte, error) {
+	length, err := d.long()
+	if err != nil {
+		return nil, err
+	}
+	if length < 0 || length > int64(len(d.data)-d.pos) {
+		return nil, fmt.Errorf("invalid length %d at byte %d", length, d.pos)
+	}
+	return d.read(int(length))
+}
+
+// BytesToString maps each byte to the code point with the same value, as the Avro JSON
+// encoding writes bytes and fixed values
+func BytesToString(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// EncodeBinary encodes a JSON value, as decoded by encoding/json with UseNumber, in the
+// Avro binary format. Missing record fields take their defaults. With avroJSON, non-null
+// union values must be wrapped in an object keyed by the branch type; otherwise the first
+// branch that accepts the value is used.
+func EncodeBinary(s *Schema, value interface{}, avroJSON bool) ([]byte, error) {
+	e := &binaryEncoder{avroJSON: avroJSON}
+	if err := e.encode(s, value, ""); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+type binaryEncoder struct {
+	buf      []byte
+	avroJSON bool
+}
+
+// encodeError reports a value that does not match its schema at a field path
+func encodeError(path, format string, args ...interface{}) error {
+	if path == "" {
+		return fmt.Errorf(format, args...)
+	}
+	return fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...))
+}
+
+func (e *binaryEncoder) encode(s *Schema, value interface{}, path string) error {
+	switch s.Type {
+	case Null:
+		if value != nil {
+			return encodeError(path, "expected null, got %s", jsonfmt.Kind(value))
+		}
+	case Boolean:
+		b, ok := value.(bool)
+		if !ok {
+			return encodeError(path, "expected boolean, got %s", jsonfmt.Kind(value))
+		}
+		if b {
+			e.buf = append(e.buf, 1)
+		} else {
+			e.buf = append(e.buf, 0)
+		}
+	case Int, Long:
+		n, ok := jsonInteger(value)
+		if !ok {
+			return encodeError(path, "expected %s, got %v", s.Type, value)
+		}
+		if s.Type == Int && (n < math.MinInt32 || n > math.MaxInt32) {
+			return encodeError(path, "value %d is out of range for int", n)
+		}
+		e.buf = binary.AppendVarint(e.buf, n)
+	case Float, Double:
+		f, ok := jsonNumber(value)
+		if !ok {
+			return encodeError(path, "expected %s, got %v", s.Type, value)
+		}
+		if s.Type == Float {
+			e.buf = binary.LittleEndian.AppendUint32(e.buf, math.Float32bits(float32(f)))
+		} else {
+			e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(f))
+		}
+	case String:
+		str, ok := value.(string)
+		if !ok {
+			return encodeError(path, "expected string, got %s", jsonfmt.Kind(value))
+		}
+		e.buf = binary.AppendVarint(e.buf, int64(len(str)))
+		e.buf = append(e.buf, str...)
+	case Bytes, Fixed:
+		str, ok := value.(string)
+		if !ok {
+			return encodeError(path, "expected %s as a string, got %s", s.Type, jsonfmt.Kind(value))
+		}
+		b, ok := StringToBytes(str)
+		if !ok {
+			return encodeError(path, "bytes must be encoded as characters U+0000 to U+00FF")
+		}
+		if s.Type == Fixed {
+			if len(b) != s.Size {
+				return encodeError(path, "expected %d bytes for fixed %s, got %d", s.Size, s.FullName(), len(b))
+			}
+		} else {
+			e.buf = binary.AppendVarint(e.buf, int64(len(b)))
+		}
+		e.buf = append(e.buf, b...)
+	case Enum:
+		str, _ := value.(string)
+		for i, symbol := range s.Symbols {
+			if symbol == str {
+				e.buf = binary.AppendVarint(e.buf, int64(i))
+				return nil
+			}
+		}
+		return encodeError(path, "%v is not a symbol of enum %s", value, s.FullName())
+	case Array:
+		items, ok := value.([]interface{})
+		if !ok {
+			return encodeError(path, "expected array, got %s", jsonfmt.Kind(value))
+		}
+		if len(items) > 0 {
+			e.buf = binary.AppendVarint(e.buf, int64(len(items)))
+			for i, item := range items {
+				if err := e.encode(s.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+		e.buf = append(e.buf, 0)
+	case Map:
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			return encodeError(path, "expected map as an object, got %s", jsonfmt.Kind(value))
+		}
+		if len(entries) > 0 {
+			e.buf = binary.AppendVarint(e.buf, int64(len(entries)))
+			for _, key := range sortedKeys(entries) {
+				e.buf = binary.AppendVarint(e.buf, int64(len(key)))
+				e.buf = append(e.buf, key...)
+				if err := e.encode(s.Values, entries[key], joinPath(path, key)); err != nil {
+					return err
+				}
+			}
+		}
+		e.buf = append(e.buf, 0)
+	case Record, Error:
+		return e.encodeRecord(s, value, path)
+	case Union:
+		return e.encodeUnion(s, value, path)
+	default:
+		return encodeError(path, "cannot encode type %s", s.Type)
+	}
+	return nil
+}
+
+func (e *binaryEncoder) encodeRecord(s *Schema, value interface{}, path string) error {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return encodeError(path, "expected record %s as an object, got %s", s.FullName(), jsonfmt.Kind(value))
+	}
+	for key := range object {
+		if s.FieldByName(key) == nil {
+			return encodeError(joinPath(path, key), "field is not defined in record %s", s.FullName())
+		}
+	}
+
+	for _, field := range s.Fields {
+		fieldPath := joinPath(path, field.Name)
+		fieldValue, present := object[field.Name]
+		if !present {
+			if !field.HasDefault {
+				return encodeError(fieldPath, "missing required field")
+			}
+			// Union defaults belong to the first branch and are never wrapped
+			if err := e.encodeDefault(field.Type, field.Default, fieldPath); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := e.encode(field.Type, fieldValue, fieldPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeDefault encodes a field default, which for a union has the type of the first branch
+func (e *binaryEncoder) encodeDefault(s *Schema, value interface{}, path string) error {
+	if s.Type == Union && len(s.Types) > 0 {
+		e.buf = binary.AppendVarint(e.buf, 0)
+		s = s.Types[0]
+	}
+	plain := &binaryEncoder{buf: e.buf}
+	err := plain.encode(s, value, path)
+	e.buf = plain.buf
+	return err
+}
+
+func (e *binaryEncoder) encodeUnion(s *Schema, value interface{}, path string) error {
+	if value == nil {
+		for i, branch := range s.Types {
+			if branch.Type == Null {
+				e.buf = binary.AppendVarint(e.buf, int64(i))
+				return nil
+			}
+		}
+		return encodeError(path, "null is not allowed by %s", s.String())
+	}
+
+	if e.avroJSON {
+		wrapper, ok := value.(map[string]interface{})
+		if ok && len(wrapper) == 1 {
+			for name, inner := range wrapper {
+				for i, branch := range s.Types {
+					if branch.Type != Null && branch.TypeName() == name {
+						e.buf = binary.AppendVarint(e.buf, int64(i))
+						return e.encode(branch, inner, path)
+					}
+				}
+				return encodeError(path, "%q is not a branch of %s", name, s.String())
+			}
+		}
+		return encodeError(path, "expected union value wrapped as {\"<type>\": value} for %s", s.String())
+	}
+
+	var firstErr error
+	for i, branch := range s.Types {
+		if branch.Type == Null {
+			continue
+		}
+		candidate := &binaryEncoder{buf: binary.AppendVarint(nil, int64(i))}
+		err := candidate.encode(branch, value, path)
+		if err == nil {
+			e.buf = append(e.buf, candidate.buf...)
+			return nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if len(s.Types) == 2 && s.IsNullable() {
+		// Optional values report the error of their only branch
+		return firstErr
+	}
+	return encodeError(path, "value does not match any branch of %s", s.String())
+}
+
+// jsonInteger returns an integral JSON number as an int64
+func jsonInteger(value interface{}) (int64, bool) {
+	switch n := value.(type) {
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return i, true
+		}
+		f, err := n.Float64()
+		if err != nil || f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(f), true
+	case float64:
+		if n != math.Trunc(n) || n < math.MinInt64 || n >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(n), true
+	default:
+		return 0, false
+	}
+}
+
+// jsonNumber returns a JSON number, or one of the strings NaN, Infinity and -Infinity, as a float64
+func jsonNumber(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	case string:
+		switch n {
+		case "NaN":
+			return math.NaN(), true
+		case "Infinity":
+			return math.Inf(1), true
+		case "-Infinity":
+			return math.Inf(-1), true
+		}
+	}
+	return 0, false
+}
+
+// StringToBytes reverses BytesToString, failing on code points above U+00FF
+func StringToBytes(s string) ([]byte, bool) {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xFF {
+			return nil, false
+		}
+		b = append(b, byte(r))
+	}
+	return b, true
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,134 @@
+package avro
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDecodeBinary(t *testing.T) {
+	s, err := Parse(`{"type":"record","name":"Order","namespace":"com.acme","fields":[
+		{"name":"id","type":"long"},
+		{"name":"status","type":{"type":"enum","name":"Status","symbols":["NEW","DONE"]}},
+		{"name":"note","type":["null","string"]},
+		{"name":"tags","type":{"type":"array","items":"string"}},
+		{"name":"attrs","type":{"type":"map","values":"int"}},
+		{"name":"hash","type":{"type":"fixed","name":"Hash","size":2}},
+		{"name":"price","type":"double"},
+		{"name":"paid","type":"boolean"}
+	]}`)
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	data := []byte{
+		0x96, 0x01, // id 75
+		0x02,                 // status DONE
+		0x02, 0x04, 'h', 'i', // note: branch 1, "hi"
+		0x03, 0x04, 0x02, 'a', // tags: block of -2 items, 4 bytes
+		0x02, 'b', 0x00,
+		0x02, 0x02, 'k', 0x0e, 0x00, // attrs {"k": 7}
+		0x00, 0xff, // hash
+		0, 0, 0, 0, 0, 0, 0xf8, 0x3f, // price 1.5
+		0x01, // paid
+	}
+
+	tests := []struct {
+		name     string
+		avroJSON bool
+		expected string
+	}{
+		{
+			name:     "plain JSON",
+			expected: `{"id":75,"status":"DONE","note":"hi","tags":["a","b"],"attrs":{"k":7},"hash":"\u0000ÿ","price":1.5,"paid":true}`,
+		},
+		{
+			name:     "Avro JSON encoding",
+			avroJSON: true,
+			expected: `{"id":75,"status":"DONE","note":{"string":"hi"},"tags":["a","b"],"attrs":{"k":7},"hash":"\u0000ÿ","price":1.5,"paid":true}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := DecodeBinary(s, data, tt.avroJSON)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				t.Fatalf("Failed to encode value: %v", err)
+			}
+			if string(encoded) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, encoded)
+			}
+		})
+	}
+
+	if _, err := DecodeBinary(s, data[:10], false); err == nil {
+		t.Error("Expected error for truncated data")
+	}
+	if _, err := DecodeBinary(s, append(data, 0), false); err == nil {
+		t.Error("Expected error for trailing data")
+	}
+}
+
+func TestEncodeBinary(t *testing.T) {
+	s, err := Parse(`{"type":"record","name":"User","fields":[
+		{"name":"id","type":"long"},
+		{"name":"email","type":["null","string"],"default":null},
+		{"name":"score","type":["int","string"]},
+		{"name":"tags","type":{"type":"array","items":"string"},"default":["new"]},
+		{"name":"hash","type":{"type":"fixed","name":"Hash","size":2}}
+	]}`)
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		avroJSON bool
+		record   string
+	}{
+		{name: "plain JSON", record: `{"id":75,"email":"a@b.c","score":"high","hash":"\u0000ÿ"}`},
+		{name: "Avro JSON encoding", avroJSON: true, record: `{"id":75,"email":{"string":"a@b.c"},"score":{"string":"high"},"hash":"\u0000ÿ"}`},
+	}
+	expected := `{"id":75,"email":"a@b.c","score":"high","tags":["new"],"hash":"\u0000ÿ"}`
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var record interface{}
+			decoder := json.NewDecoder(strings.NewReader(tt.record))
+			decoder.UseNumber()
+			if err := decoder.Decode(&record); err != nil {
+				t.Fatalf("Failed to decode record: %v", err)
+			}
+
+			data, err := EncodeBinary(s, record, tt.avroJSON)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			value, err := DecodeBinary(s, data, false)
+			if err != nil {
+				t.Fatalf("Failed to decode the encoded record: %v", err)
+			}
+			if encoded, _ := json.Marshal(value); string(encoded) != expected {
+				t.Errorf("Expected %s, got %s", expected, encoded)
+			}
+		})
+	}
+
+	invalid := []string{
+		`{"email":null,"score":1,"hash":"ab"}`,
+		`{"id":1,"score":1,"hash":"abc"}`,
+		`{"id":1,"score":true,"hash":"ab"}`,
+		`{"id":1,"score":1,"hash":"ab","extra":1}`,
+	}
+	for _, record := range invalid {
+		var value interface{}
+		_ = json.Unmarshal([]byte(record), &value)
+		if _, err := EncodeBinary(s, value, false); err == nil {
+			t.Errorf("Expected error for %s", record)
+		}
+	}
+}
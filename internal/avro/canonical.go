@@ -0,0 +1,96 @@
+package avro
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// CanonicalForm returns the Parsing Canonical Form of a schema as defined by the Avro
+// specification: full names, only the attributes relevant to parsing (name, type, fields,
+// symbols, items, values, size) in that order, and no whitespace. Named types are written
+// in full at their first occurrence and by name afterwards.
+func CanonicalForm(s *Schema) string {
+	var sb strings.Builder
+	writeCanonical(&sb, s, map[string]bool{})
+	return sb.String()
+}
+
+func writeCanonical(sb *strings.Builder, s *Schema, defined map[string]bool) {
+	switch s.Type {
+	case Record, Error:
+		if defined[s.FullName()] {
+			writeString(sb, s.FullName())
+			return
+		}
+		defined[s.FullName()] = true
+
+		sb.WriteString(`{"name":`)
+		writeString(sb, s.FullName())
+		sb.WriteString(`,"type":"record","fields":[`)
+		for i, field := range s.Fields {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(`{"name":`)
+			writeString(sb, field.Name)
+			sb.WriteString(`,"type":`)
+			writeCanonical(sb, field.Type, defined)
+			sb.WriteByte('}')
+		}
+		sb.WriteString("]}")
+	case Enum:
+		if defined[s.FullName()] {
+			writeString(sb, s.FullName())
+			return
+		}
+		defined[s.FullName()] = true
+
+		sb.WriteString(`{"name":`)
+		writeString(sb, s.FullName())
+		sb.WriteString(`,"type":"enum","symbols":[`)
+		for i, symbol := range s.Symbols {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			writeString(sb, symbol)
+		}
+		sb.WriteString("]}")
+	case Fixed:
+		if defined[s.FullName()] {
+			writeString(sb, s.FullName())
+			return
+		}
+		defined[s.FullName()] = true
+
+		sb.WriteString(`{"name":`)
+		writeString(sb, s.FullName())
+		sb.WriteString(`,"type":"fixed","size":`)
+		sb.WriteString(strconv.Itoa(s.Size))
+		sb.WriteByte('}')
+	case Array:
+		sb.WriteString(`{"type":"array","items":`)
+		writeCanonical(sb, s.Items, defined)
+		sb.WriteByte('}')
+	case Map:
+		sb.WriteString(`{"type":"map","values":`)
+		writeCanonical(sb, s.Values, defined)
+		sb.WriteByte('}')
+	case Union:
+		sb.WriteByte('[')
+		for i, branch := range s.Types {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			writeCanonical(sb, branch, defined)
+		}
+		sb.WriteByte(']')
+	default:
+		writeString(sb, string(s.Type))
+	}
+}
+
+func writeString(sb *strings.Builder, s string) {
+	data, _ := json.Marshal(s)
+	sb.Write(data)
+}
@@ -0,0 +1,73 @@
+package avro
+
+import (
+	"testing"
+)
+
+func TestCanonicalForm(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		expected string
+	}{
+		{
+			name:     "primitive object form",
+			schema:   `{"type":"int"}`,
+			expected: `"int"`,
+		},
+		{
+			name:     "logical type is stripped",
+			schema:   `{"type":"long","logicalType":"timestamp-millis"}`,
+			expected: `"long"`,
+		},
+		{
+			name: "record with full names and stripped attributes",
+			schema: `{"doc":"A user","namespace":"com.example","type":"record","name":"User","aliases":["Person"],"fields":[
+				{"type":"long","name":"id","doc":"Identifier"},
+				{"name":"status","type":{"type":"enum","name":"Status","symbols":["ACTIVE","INACTIVE"],"default":"ACTIVE"},"default":"ACTIVE"},
+				{"name":"previous","type":["null","Status"],"default":null}
+			]}`,
+			expected: `{"name":"com.example.User","type":"record","fields":[{"name":"id","type":"long"},` +
+				`{"name":"status","type":{"name":"com.example.Status","type":"enum","symbols":["ACTIVE","INACTIVE"]}},` +
+				`{"name":"previous","type":["null","com.example.Status"]}]}`,
+		},
+		{
+			name:     "collections and fixed",
+			schema:   `{"type":"map","values":{"type":"array","items":{"type":"fixed","name":"md5","size":16}}}`,
+			expected: `{"type":"map","values":{"type":"array","items":{"name":"md5","type":"fixed","size":16}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.schema)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := CanonicalForm(s); got != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRabin(t *testing.T) {
+	// Fingerprints from the Avro specification test suite, as signed 64-bit values
+	tests := []struct {
+		canonical string
+		expected  int64
+	}{
+		{`"null"`, 7195948357588979594},
+		{`"boolean"`, -6970731678124411036},
+		{`"int"`, 8247732601305521295},
+		{`"long"`, -3434872931120570953},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.canonical, func(t *testing.T) {
+			if got := int64(Rabin([]byte(tt.canonical))); got != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package avro
+
+// emptyFingerprint is the CRC-64-AVRO seed defined by the Avro specification
+const emptyFingerprint uint64 = 0xc15d213aa4d7a795
+
+var fingerprintTable = func() [256]uint64 {
+	var table [256]uint64
+	for i := range table {
+		fp := uint64(i)
+		for j := 0; j < 8; j++ {
+			fp = (fp >> 1) ^ (emptyFingerprint & -(fp & 1))
+		}
+		table[i] = fp
+	}
+	return table
+}()
+
+// Rabin computes the 64-bit CRC-64-AVRO (Rabin) fingerprint of data, normally the
+// Parsing Canonical Form of a schema
+func Rabin(data []byte) uint64 {
+	fp := emptyFingerprint
+	for _, b := range data {
+		fp = (fp >> 8) ^ fingerprintTable[byte(fp)^b]
+	}
+	return fp
+}
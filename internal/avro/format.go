@@ -0,0 +1,77 @@
+package avro
+
+import (
+	"fmt"
+
+	"github.com/aywengo/ksr-cli/internal/jsonfmt"
+)
+
+// Format pretty-prints an Avro schema with a stable attribute order: type, name, namespace,
+// doc and aliases first, then the attributes of the kind of schema, then any custom
+// properties in their original order. Primitive types without attributes are written in
+// their short form. Defaults and custom properties are kept as written.
+func Format(content string) (string, error) {
+	raw, err := jsonfmt.Decode([]byte(content))
+	if err != nil {
+		return "", fmt.Errorf("invalid schema JSON: %w", err)
+	}
+	return jsonfmt.Marshal(formatSchema(raw)), nil
+}
+
+func formatSchema(raw interface{}) interface{} {
+	switch v := raw.(type) {
+	case []interface{}:
+		for i, branch := range v {
+			v[i] = formatSchema(branch)
+		}
+		return v
+	case *jsonfmt.Object:
+		typeValue, _ := v.Get("type")
+		typeName, isName := typeValue.(string)
+		if !isName {
+			v.Set("type", formatSchema(typeValue))
+		}
+
+		switch Type(typeName) {
+		case Record, Error:
+			fieldsValue, _ := v.Get("fields")
+			if fields, ok := fieldsValue.([]interface{}); ok {
+				for _, field := range fields {
+					if obj, ok := field.(*jsonfmt.Object); ok {
+						formatField(obj)
+					}
+				}
+			}
+			v.Reorder("type", "name", "namespace", "doc", "aliases", "fields")
+		case Enum:
+			v.Reorder("type", "name", "namespace", "doc", "aliases", "symbols", "default")
+		case Fixed:
+			v.Reorder("type", "name", "namespace", "doc", "aliases", "size", "logicalType", "precision", "scale")
+		case Array:
+			if items, ok := v.Get("items"); ok {
+				v.Set("items", formatSchema(items))
+			}
+			v.Reorder("type", "items")
+		case Map:
+			if values, ok := v.Get("values"); ok {
+				v.Set("values", formatSchema(values))
+			}
+			v.Reorder("type", "values")
+		default:
+			if isName && len(v.Members) == 1 {
+				return typeName
+			}
+			v.Reorder("type", "logicalType", "precision", "scale")
+		}
+		return v
+	default:
+		return raw
+	}
+}
+
+func formatField(field *jsonfmt.Object) {
+	if fieldType, ok := field.Get("type"); ok {
+		field.Set("type", formatSchema(fieldType))
+	}
+	field.Reorder("name", "type", "doc", "default", "order", "aliases")
+}
@@ -0,0 +1,68 @@
+package avro
+
+import (
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	input := `{"fields":[{"type":{"type":"string"},"doc":"Identifier","name":"id"},` +
+		`{"default":null,"name":"tags","type":["null",{"items":"string","type":"array"}]},` +
+		`{"name":"status","type":{"symbols":["A","B"],"name":"Status","type":"enum","x-owner":"team"}}],` +
+		`"name":"User","namespace":"com.example","type":"record"}`
+
+	expected := `{
+  "type": "record",
+  "name": "User",
+  "namespace": "com.example",
+  "fields": [
+    {
+      "name": "id",
+      "type": "string",
+      "doc": "Identifier"
+    },
+    {
+      "name": "tags",
+      "type": [
+        "null",
+        {
+          "type": "array",
+          "items": "string"
+        }
+      ],
+      "default": null
+    },
+    {
+      "name": "status",
+      "type": {
+        "type": "enum",
+        "name": "Status",
+        "symbols": ["A", "B"],
+        "x-owner": "team"
+      }
+    }
+  ]
+}
+`
+
+	got, err := Format(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	again, err := Format(got)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if again != got {
+		t.Errorf("Format is not idempotent:\n%s", again)
+	}
+}
+
+func TestFormat_InvalidJSON(t *testing.T) {
+	if _, err := Format(`{"type":`); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
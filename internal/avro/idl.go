@@ -0,0 +1,1180 @@
+package avro
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/internal/jsonfmt"
+)
+
+// ErrSeveralRecords is returned when no record is selected from an IDL file defining
+// several top-level records
+var ErrSeveralRecords = errors.New("the IDL defines several top-level records")
+
+// IDL is a parsed Avro IDL file: a protocol, or a file in the schema syntax of Avro 1.12
+// ("namespace x; schema T; record T {...}"). Protocol messages are parsed and ignored.
+type IDL struct {
+	Protocol  string
+	Namespace string
+
+	// types holds the named types in declaration order, imported types included
+	types  []*idlType
+	byName map[string]*idlType
+	// main is the type named by a "schema" declaration
+	main interface{}
+}
+
+// idlType is a named type of an IDL file. Field types in def are type expressions, in
+// which references to named types are idlRefs resolved when a schema is written.
+type idlType struct {
+	fullName  string
+	namespace string
+	def       *jsonfmt.Object
+}
+
+// idlRef is a reference to a named type, resolved against the namespace it appears in
+type idlRef struct {
+	name      string
+	namespace string
+}
+
+// idlLogicalTypes are the IDL keywords for primitive types with a logical type
+var idlLogicalTypes = map[string][2]string{
+	"date":               {"int", "date"},
+	"time_ms":            {"int", "time-millis"},
+	"timestamp_ms":       {"long", "timestamp-millis"},
+	"local_timestamp_ms": {"long", "local-timestamp-millis"},
+	"uuid":               {"string", "uuid"},
+}
+
+// ParseIDL parses Avro IDL content. Imports are read relative to dir; without a dir,
+// imports are an error.
+func ParseIDL(content, dir string) (*IDL, error) {
+	f := &IDL{byName: map[string]*idlType{}}
+	if err := f.parse(content, dir); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// ReadIDL reads and parses an Avro IDL file, resolving its imports
+func ReadIDL(path string) (*IDL, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := ParseIDL(string(content), filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// TypeNames returns the full names of the named types of the file in declaration order
+func (f *IDL) TypeNames() []string {
+	names := make([]string, len(f.types))
+	for i, t := range f.types {
+		names[i] = t.fullName
+	}
+	return names
+}
+
+// Schema returns the Avro JSON schema of a named type of the file, defining the named
+// types it uses inline. The name may be a full name or a unique short name. Without a
+// name, the type of the "schema" declaration is used, or else the only record that no
+// other type refers to.
+func (f *IDL) Schema(name string) (string, error) {
+	main, err := f.mainType(name)
+	if err != nil {
+		return "", err
+	}
+
+	w := &idlSchemaWriter{file: f, written: map[string]bool{}}
+	value, err := w.write(main, "")
+	if err != nil {
+		return "", err
+	}
+	formatted, err := Format(jsonfmt.Marshal(value))
+	if err != nil {
+		return "", err
+	}
+	if _, err := Parse(formatted); err != nil {
+		return "", fmt.Errorf("invalid schema: %w", err)
+	}
+	return formatted, nil
+}
+
+// mainType selects the type written by Schema
+func (f *IDL) mainType(name string) (interface{}, error) {
+	if name != "" {
+		if t, ok := f.byName[name]; ok {
+			return idlRef{name: t.fullName}, nil
+		}
+		var matches []string
+		for _, t := range f.types {
+			if t.fullName[strings.LastIndex(t.fullName, ".")+1:] == name {
+				matches = append(matches, t.fullName)
+			}
+		}
+		switch len(matches) {
+		case 1:
+			return idlRef{name: matches[0]}, nil
+		case 0:
+			return nil, fmt.Errorf("type %s is not defined (defined types: %s)", name, strings.Join(f.TypeNames(), ", "))
+		default:
+			return nil, fmt.Errorf("type name %s is ambiguous: %s", name, strings.Join(matches, ", "))
+		}
+	}
+	if f.main != nil {
+		return f.main, nil
+	}
+
+	used := map[string]bool{}
+	for _, t := range f.types {
+		f.collectRefs(t.def, used)
+	}
+	var candidates []string
+	for _, t := range f.types {
+		typeName, _ := t.def.Get("type")
+		if typeName == string(Record) && !used[t.fullName] {
+			candidates = append(candidates, t.fullName)
+		}
+	}
+	switch len(candidates) {
+	case 1:
+		return idlRef{name: candidates[0]}, nil
+	case 0:
+		return nil, fmt.Errorf("no record to select: every record is used by another type")
+	default:
+		return nil, fmt.Errorf("%w (%s); select one by name", ErrSeveralRecords, strings.Join(candidates, ", "))
+	}
+}
+
+// collectRefs records the named types a type expression refers to
+func (f *IDL) collectRefs(expr interface{}, used map[string]bool) {
+	switch v := expr.(type) {
+	case idlRef:
+		if t, err := f.resolve(v); err == nil {
+			used[t.fullName] = true
+		}
+	case []interface{}:
+		for _, branch := range v {
+			f.collectRefs(branch, used)
+		}
+	case *jsonfmt.Object:
+		for _, key := range []string{"items", "values"} {
+			if value, ok := v.Get(key); ok {
+				f.collectRefs(value, used)
+			}
+		}
+		if fields, ok := v.Get("fields"); ok {
+			for _, field := range fields.([]interface{}) {
+				fieldType, _ := field.(*jsonfmt.Object).Get("type")
+				f.collectRefs(fieldType, used)
+			}
+		}
+	}
+}
+
+// resolve finds the named type a reference refers to: relative to the namespace it
+// appears in, then to the namespace of the file, then as a full name
+func (f *IDL) resolve(ref idlRef) (*idlType, error) {
+	if !strings.Contains(ref.name, ".") {
+		for _, namespace := range []string{ref.namespace, f.Namespace} {
+			if namespace == "" {
+				continue
+			}
+			if t, ok := f.byName[namespace+"."+ref.name]; ok {
+				return t, nil
+			}
+		}
+	}
+	if t, ok := f.byName[ref.name]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("unknown type: %s", ref.name)
+}
+
+// define registers a named type
+func (f *IDL) define(def *jsonfmt.Object, namespace string) (*idlType, error) {
+	name, _ := def.Get("name")
+	t := &idlType{fullName: name.(string), namespace: namespace, def: def}
+	if namespace != "" {
+		t.fullName = namespace + "." + t.fullName
+	}
+	if _, exists := f.byName[t.fullName]; exists {
+		return nil, fmt.Errorf("duplicate definition of type %s", t.fullName)
+	}
+	f.types = append(f.types, t)
+	f.byName[t.fullName] = t
+	return t, nil
+}
+
+// idlSchemaWriter writes a type expression as an Avro JSON schema, defining each named
+// type at its first use
+type idlSchemaWriter struct {
+	file    *IDL
+	written map[string]bool
+}
+
+func (w *idlSchemaWriter) write(expr interface{}, namespace string) (interface{}, error) {
+	switch v := expr.(type) {
+	case idlRef:
+		t, err := w.file.resolve(v)
+		if err != nil {
+			return nil, err
+		}
+		if w.written[t.fullName] {
+			if t.namespace == namespace && namespace != "" {
+				return t.fullName[len(namespace)+1:], nil
+			}
+			return t.fullName, nil
+		}
+		w.written[t.fullName] = true
+		return w.define(t, namespace)
+	case []interface{}:
+		branches := make([]interface{}, len(v))
+		for i, branch := range v {
+			written, err := w.write(branch, namespace)
+			if err != nil {
+				return nil, err
+			}
+			branches[i] = written
+		}
+		return branches, nil
+	case *jsonfmt.Object:
+		result := &jsonfmt.Object{}
+		for _, member := range v.Members {
+			value := member.Value
+			if member.Key == "items" || member.Key == "values" {
+				written, err := w.write(value, namespace)
+				if err != nil {
+					return nil, err
+				}
+				value = written
+			}
+			result.Set(member.Key, value)
+		}
+		return result, nil
+	default:
+		return expr, nil
+	}
+}
+
+// define writes the definition of a named type, with its namespace when it differs from
+// the enclosing one
+func (w *idlSchemaWriter) define(t *idlType, namespace string) (interface{}, error) {
+	result := &jsonfmt.Object{}
+	for _, member := range t.def.Members {
+		switch member.Key {
+		case "name":
+			result.Set("name", member.Value)
+			if t.namespace != namespace {
+				result.Set("namespace", t.namespace)
+			}
+		case "fields":
+			var fields []interface{}
+			for _, raw := range member.Value.([]interface{}) {
+				field := &jsonfmt.Object{}
+				for _, fieldMember := range raw.(*jsonfmt.Object).Members {
+					value := fieldMember.Value
+					if fieldMember.Key == "type" {
+						written, err := w.write(value, t.namespace)
+						if err != nil {
+							return nil, err
+						}
+						value = written
+					}
+					field.Set(fieldMember.Key, value)
+				}
+				fields = append(fields, field)
+			}
+			if fields == nil {
+				fields = []interface{}{}
+			}
+			result.Set("fields", fields)
+		default:
+			result.Set(member.Key, member.Value)
+		}
+	}
+	return result, nil
+}
+
+// parse parses IDL content into the file
+func (f *IDL) parse(content, dir string) error {
+	p := &idlParser{lexer: &idlLexer{src: content}, file: f, dir: dir}
+	p.next()
+	if err := p.parseFile(); err != nil {
+		line := 1 + strings.Count(content[:p.tok.pos], "\n")
+		return fmt.Errorf("line %d: %w", line, err)
+	}
+	return nil
+}
+
+// importJSON registers the named types of an Avro schema or protocol in JSON form
+func (f *IDL) importJSON(raw interface{}, namespace string) (interface{}, error) {
+	switch v := raw.(type) {
+	case string:
+		if IsPrimitive(Type(v)) {
+			return v, nil
+		}
+		return idlRef{name: v, namespace: namespace}, nil
+	case []interface{}:
+		branches := make([]interface{}, len(v))
+		for i, branch := range v {
+			imported, err := f.importJSON(branch, namespace)
+			if err != nil {
+				return nil, err
+			}
+			branches[i] = imported
+		}
+		return branches, nil
+	case *jsonfmt.Object:
+		typeValue, _ := v.Get("type")
+		typeName, ok := typeValue.(string)
+		if !ok {
+			return f.importJSON(typeValue, namespace)
+		}
+		switch Type(typeName) {
+		case Record, Error, Enum, Fixed:
+			return f.importNamed(v, namespace)
+		case Array, Map:
+			key := "items"
+			if Type(typeName) == Map {
+				key = "values"
+			}
+			inner, _ := v.Get(key)
+			imported, err := f.importJSON(inner, namespace)
+			if err != nil {
+				return nil, err
+			}
+			result := &jsonfmt.Object{Members: append([]jsonfmt.Member(nil), v.Members...)}
+			result.Set(key, imported)
+			return result, nil
+		default:
+			if !IsPrimitive(Type(typeName)) {
+				return idlRef{name: typeName, namespace: namespace}, nil
+			}
+			return v, nil
+		}
+	default:
+		return nil, fmt.Errorf("invalid schema: unexpected %s", describeJSON(raw))
+	}
+}
+
+// importNamed registers a named type in JSON form and returns a reference to it
+func (f *IDL) importNamed(obj *jsonfmt.Object, namespace string) (interface{}, error) {
+	nameValue, _ := obj.Get("name")
+	name, _ := nameValue.(string)
+	if name == "" {
+		return nil, fmt.Errorf("named type is missing 'name'")
+	}
+	if explicit, ok := obj.Get("namespace"); ok {
+		namespace, _ = explicit.(string)
+	}
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		namespace, name = name[:i], name[i+1:]
+	}
+
+	def := &jsonfmt.Object{}
+	for _, member := range obj.Members {
+		switch member.Key {
+		case "namespace":
+		case "name":
+			def.Set("name", name)
+		case "fields":
+			var fields []interface{}
+			rawFields, _ := member.Value.([]interface{})
+			for _, raw := range rawFields {
+				fieldObj, ok := raw.(*jsonfmt.Object)
+				if !ok {
+					return nil, fmt.Errorf("record %s: field is not an object", name)
+				}
+				field := &jsonfmt.Object{Members: append([]jsonfmt.Member(nil), fieldObj.Members...)}
+				fieldType, _ := fieldObj.Get("type")
+				imported, err := f.importJSON(fieldType, namespace)
+				if err != nil {
+					return nil, err
+				}
+				field.Set("type", imported)
+				fields = append(fields, field)
+			}
+			def.Set("fields", fields)
+		default:
+			def.Set(member.Key, member.Value)
+		}
+	}
+
+	t, err := f.define(def, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return idlRef{name: t.fullName}, nil
+}
+
+// Parser
+
+type idlParser struct {
+	lexer *idlLexer
+	tok   idlToken
+	file  *IDL
+	dir   string
+}
+
+func (p *idlParser) next() {
+	p.tok = p.lexer.token()
+}
+
+// is reports whether the current token is a keyword or punctuation
+func (p *idlParser) is(text string) bool {
+	return (p.tok.kind == idlPunct || (p.tok.kind == idlIdent && !p.tok.quoted)) && p.tok.text == text
+}
+
+func (p *idlParser) expect(text string) error {
+	if !p.is(text) {
+		return fmt.Errorf("expected %q, got %s", text, p.tok.describe())
+	}
+	p.next()
+	return nil
+}
+
+func (p *idlParser) ident() (string, error) {
+	if p.tok.kind != idlIdent {
+		return "", fmt.Errorf("expected an identifier, got %s", p.tok.describe())
+	}
+	name := p.tok.text
+	p.next()
+	return name, nil
+}
+
+func (p *idlParser) str() (string, error) {
+	if p.tok.kind != idlString {
+		return "", fmt.Errorf("expected a string, got %s", p.tok.describe())
+	}
+	value := p.tok.text
+	p.next()
+	return value, nil
+}
+
+// json parses a JSON value starting at the current token
+func (p *idlParser) json() (interface{}, error) {
+	if p.tok.kind == idlEOF {
+		return nil, fmt.Errorf("expected a JSON value, got end of file")
+	}
+	end := scanJSON(p.lexer.src, p.tok.pos)
+	value, err := jsonfmt.Decode([]byte(p.lexer.src[p.tok.pos:end]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON value %s: %w", p.lexer.src[p.tok.pos:end], err)
+	}
+	p.lexer.pos = end
+	p.next()
+	return value, nil
+}
+
+// annotation is a schema property written as @name(value)
+type annotation struct {
+	name  string
+	value interface{}
+}
+
+func (p *idlParser) annotations() ([]annotation, error) {
+	var result []annotation
+	for p.is("@") {
+		p.next()
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		value, err := p.json()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		result = append(result, annotation{name: name, value: value})
+	}
+	return result, nil
+}
+
+func (p *idlParser) parseFile() error {
+	doc := p.tok.doc
+	annotations, err := p.annotations()
+	if err != nil {
+		return err
+	}
+
+	if p.is("protocol") {
+		p.next()
+		if p.file.Protocol, err = p.ident(); err != nil {
+			return err
+		}
+		for _, a := range annotations {
+			if a.name == "namespace" {
+				p.file.Namespace, _ = a.value.(string)
+			}
+		}
+		if err := p.expect("{"); err != nil {
+			return err
+		}
+		for !p.is("}") {
+			if p.tok.kind == idlEOF {
+				return fmt.Errorf("unexpected end of file in protocol %s", p.file.Protocol)
+			}
+			if err := p.parseDeclaration(true, nil, ""); err != nil {
+				return err
+			}
+		}
+		p.next()
+		if p.tok.kind != idlEOF {
+			return fmt.Errorf("unexpected %s after protocol", p.tok.describe())
+		}
+		return nil
+	}
+
+	// Schema syntax
+	pending, pendingDoc := annotations, doc
+	for p.tok.kind != idlEOF {
+		switch {
+		case p.is("namespace") && pending == nil:
+			p.next()
+			if p.file.Namespace, err = p.ident(); err != nil {
+				return err
+			}
+			if err := p.expect(";"); err != nil {
+				return err
+			}
+		case p.is("schema") && pending == nil:
+			p.next()
+			if p.file.main, err = p.fullType(p.file.Namespace); err != nil {
+				return err
+			}
+			if err := p.expect(";"); err != nil {
+				return err
+			}
+		default:
+			if err := p.parseDeclaration(false, pending, pendingDoc); err != nil {
+				return err
+			}
+		}
+		pending, pendingDoc = nil, p.tok.doc
+		if pending, err = p.annotations(); err != nil {
+			return err
+		}
+	}
+	if pending != nil {
+		return fmt.Errorf("annotations without a declaration at end of file")
+	}
+	return nil
+}
+
+// parseDeclaration parses an import, a named type or, in a protocol, a message
+func (p *idlParser) parseDeclaration(inProtocol bool, annotations []annotation, doc string) error {
+	if annotations == nil {
+		doc = p.tok.doc
+		var err error
+		if annotations, err = p.annotations(); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case p.is("import"):
+		return p.parseImport()
+	case p.is("record"), p.is("error"), p.is("enum"), p.is("fixed"):
+		_, err := p.namedType(annotations, doc, p.file.Namespace)
+		return err
+	case inProtocol:
+		return p.skipMessage()
+	default:
+		return fmt.Errorf("unexpected %s", p.tok.describe())
+	}
+}
+
+func (p *idlParser) parseImport() error {
+	p.next()
+	kind, err := p.ident()
+	if err != nil {
+		return err
+	}
+	path, err := p.str()
+	if err != nil {
+		return err
+	}
+	if err := p.expect(";"); err != nil {
+		return err
+	}
+	if p.dir == "" {
+		return fmt.Errorf("cannot import %s: imports are only resolved for files", path)
+	}
+
+	full := path
+	if !filepath.IsAbs(path) {
+		full = filepath.Join(p.dir, path)
+	}
+	content, err := os.ReadFile(full)
+	if err != nil {
+		return fmt.Errorf("import %s: %w", path, err)
+	}
+
+	switch kind {
+	case "idl":
+		imported := &IDL{byName: p.file.byName}
+		if err := imported.parse(string(content), filepath.Dir(full)); err != nil {
+			return fmt.Errorf("import %s: %w", path, err)
+		}
+		p.file.types = append(p.file.types, imported.types...)
+	case "schema", "protocol":
+		raw, err := jsonfmt.Decode(content)
+		if err != nil {
+			return fmt.Errorf("import %s: %w", path, err)
+		}
+		if kind == "protocol" {
+			obj, ok := raw.(*jsonfmt.Object)
+			if !ok {
+				return fmt.Errorf("import %s: protocol is not an object", path)
+			}
+			namespaceValue, _ := obj.Get("namespace")
+			namespace, _ := namespaceValue.(string)
+			typesValue, _ := obj.Get("types")
+			types, _ := typesValue.([]interface{})
+			for _, t := range types {
+				if _, err := p.file.importJSON(t, namespace); err != nil {
+					return fmt.Errorf("import %s: %w", path, err)
+				}
+			}
+		} else if _, err := p.file.importJSON(raw, ""); err != nil {
+			return fmt.Errorf("import %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unsupported import kind %q (expected idl, schema or protocol)", kind)
+	}
+	return nil
+}
+
+// skipMessage parses a protocol message, which has no schema equivalent
+func (p *idlParser) skipMessage() error {
+	if p.is("void") {
+		p.next()
+	} else if _, err := p.fullType(p.file.Namespace); err != nil {
+		return err
+	}
+	if _, err := p.ident(); err != nil {
+		return err
+	}
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	for depth := 1; depth > 0; p.next() {
+		switch {
+		case p.tok.kind == idlEOF:
+			return fmt.Errorf("unexpected end of file in message parameters")
+		case p.is("("):
+			depth++
+		case p.is(")"):
+			depth--
+		case p.is("="):
+			// Parameter defaults are JSON values, which may hold parentheses
+			p.next()
+			if _, err := p.json(); err != nil {
+				return err
+			}
+			if p.is(")") {
+				depth--
+			}
+		}
+	}
+	if p.is("oneway") {
+		p.next()
+	}
+	if p.is("throws") {
+		p.next()
+		for {
+			if _, err := p.ident(); err != nil {
+				return err
+			}
+			if !p.is(",") {
+				break
+			}
+			p.next()
+		}
+	}
+	return p.expect(";")
+}
+
+// namedType parses a record, error, enum or fixed declaration and returns a reference to it
+func (p *idlParser) namedType(annotations []annotation, doc, namespace string) (interface{}, error) {
+	kind := p.tok.text
+	p.next()
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+
+	def := jsonfmt.NewObject("type", kind)
+	def.Set("name", name)
+	for _, a := range annotations {
+		if a.name == "namespace" {
+			namespace, _ = a.value.(string)
+		}
+	}
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		namespace, name = name[:i], name[i+1:]
+		def.Set("name", name)
+	}
+	if doc != "" {
+		def.Set("doc", doc)
+	}
+	for _, a := range annotations {
+		if a.name != "namespace" {
+			def.Set(a.name, a.value)
+		}
+	}
+
+	switch kind {
+	case "record", "error":
+		if err := p.expect("{"); err != nil {
+			return nil, err
+		}
+		fields := []interface{}{}
+		for !p.is("}") {
+			if p.tok.kind == idlEOF {
+				return nil, fmt.Errorf("unexpected end of file in record %s", name)
+			}
+			declared, err := p.fields(namespace)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, declared...)
+		}
+		p.next()
+		def.Set("fields", fields)
+	case "enum":
+		if err := p.expect("{"); err != nil {
+			return nil, err
+		}
+		symbols := []interface{}{}
+		for !p.is("}") {
+			symbol, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			symbols = append(symbols, symbol)
+			if !p.is(",") {
+				break
+			}
+			p.next()
+		}
+		if err := p.expect("}"); err != nil {
+			return nil, err
+		}
+		def.Set("symbols", symbols)
+		if p.is("=") {
+			p.next()
+			symbol, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			def.Set("default", symbol)
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		}
+	case "fixed":
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		if p.tok.kind != idlNumber {
+			return nil, fmt.Errorf("expected the size of fixed %s, got %s", name, p.tok.describe())
+		}
+		size, err := p.json()
+		if err != nil {
+			return nil, err
+		}
+		def.Set("size", size)
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		if err := p.expect(";"); err != nil {
+			return nil, err
+		}
+	}
+	def.Reorder("type", "name", "doc")
+
+	t, err := p.file.define(def, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return idlRef{name: t.fullName}, nil
+}
+
+// fields parses a field declaration, which may declare several fields of one type
+func (p *idlParser) fields(namespace string) ([]interface{}, error) {
+	doc := p.tok.doc
+	fieldType, err := p.fullType(namespace)
+	if err != nil {
+		return nil, err
+	}
+	nullable := false
+	if p.is("?") {
+		nullable = true
+		p.next()
+	}
+
+	var fields []interface{}
+	for {
+		variableDoc := p.tok.doc
+		annotations, err := p.annotations()
+		if err != nil {
+			return nil, err
+		}
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+
+		field := jsonfmt.NewObject("name", name)
+		if variableDoc == "" {
+			variableDoc = doc
+		}
+		fieldType := fieldType
+		var defaultValue interface{}
+		hasDefault := false
+		if p.is("=") {
+			p.next()
+			if defaultValue, err = p.json(); err != nil {
+				return nil, err
+			}
+			hasDefault = true
+		}
+		if nullable {
+			if _, isUnion := fieldType.([]interface{}); isUnion {
+				return nil, fmt.Errorf("field %s: a union cannot be made nullable with ?", name)
+			}
+			if hasDefault && defaultValue != nil {
+				fieldType = []interface{}{fieldType, "null"}
+			} else {
+				fieldType = []interface{}{"null", fieldType}
+			}
+		}
+		field.Set("type", fieldType)
+		if variableDoc != "" {
+			field.Set("doc", variableDoc)
+		}
+		if hasDefault {
+			field.Set("default", defaultValue)
+		}
+		for _, a := range annotations {
+			field.Set(a.name, a.value)
+		}
+		fields = append(fields, field)
+
+		if p.is(";") {
+			p.next()
+			return fields, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// fullType parses a type with its annotations
+func (p *idlParser) fullType(namespace string) (interface{}, error) {
+	annotations, err := p.annotations()
+	if err != nil {
+		return nil, err
+	}
+	t, err := p.plainType(namespace)
+	if err != nil {
+		return nil, err
+	}
+	if len(annotations) == 0 {
+		return t, nil
+	}
+
+	var obj *jsonfmt.Object
+	switch v := t.(type) {
+	case string:
+		obj = jsonfmt.NewObject("type", v)
+	case *jsonfmt.Object:
+		obj = v
+	default:
+		return nil, fmt.Errorf("annotations are not supported on unions and named type references")
+	}
+	for _, a := range annotations {
+		obj.Set(a.name, a.value)
+	}
+	return obj, nil
+}
+
+func (p *idlParser) plainType(namespace string) (interface{}, error) {
+	if p.tok.kind != idlIdent {
+		return nil, fmt.Errorf("expected a type, got %s", p.tok.describe())
+	}
+	name := p.tok.text
+	if p.tok.quoted {
+		p.next()
+		return idlRef{name: name, namespace: namespace}, nil
+	}
+
+	switch {
+	case IsPrimitive(Type(name)):
+		p.next()
+		return name, nil
+	case idlLogicalTypes[name] != [2]string{}:
+		p.next()
+		logical := idlLogicalTypes[name]
+		return jsonfmt.NewObject("type", logical[0], "logicalType", logical[1]), nil
+	case name == "decimal":
+		p.next()
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		precision, err := p.json()
+		if err != nil {
+			return nil, err
+		}
+		scale := interface{}(nil)
+		if p.is(",") {
+			p.next()
+			if scale, err = p.json(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		result := jsonfmt.NewObject("type", "bytes", "logicalType", "decimal", "precision", precision)
+		if scale != nil {
+			result.Set("scale", scale)
+		}
+		return result, nil
+	case name == "array", name == "map":
+		p.next()
+		if err := p.expect("<"); err != nil {
+			return nil, err
+		}
+		inner, err := p.fullType(namespace)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(">"); err != nil {
+			return nil, err
+		}
+		if name == "array" {
+			return jsonfmt.NewObject("type", "array", "items", inner), nil
+		}
+		return jsonfmt.NewObject("type", "map", "values", inner), nil
+	case name == "union":
+		p.next()
+		if err := p.expect("{"); err != nil {
+			return nil, err
+		}
+		var branches []interface{}
+		for {
+			branch, err := p.fullType(namespace)
+			if err != nil {
+				return nil, err
+			}
+			branches = append(branches, branch)
+			if !p.is(",") {
+				break
+			}
+			p.next()
+		}
+		if err := p.expect("}"); err != nil {
+			return nil, err
+		}
+		return branches, nil
+	case name == "record", name == "error", name == "enum", name == "fixed":
+		// Named types may be declared inline in schema syntax
+		doc := p.tok.doc
+		return p.namedType(nil, doc, namespace)
+	default:
+		p.next()
+		return idlRef{name: name, namespace: namespace}, nil
+	}
+}
+
+// Lexer
+
+type idlTokenKind int
+
+const (
+	idlEOF idlTokenKind = iota
+	idlIdent
+	idlString
+	idlNumber
+	idlPunct
+)
+
+type idlToken struct {
+	kind idlTokenKind
+	text string
+	pos  int
+	// quoted is set for identifiers escaped with backticks, which are never keywords
+	quoted bool
+	// doc is the text of the doc comment right before the token
+	doc string
+}
+
+func (t idlToken) describe() string {
+	switch t.kind {
+	case idlEOF:
+		return "end of file"
+	case idlString:
+		return fmt.Sprintf("string %q", t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+type idlLexer struct {
+	src string
+	pos int
+}
+
+func (l *idlLexer) token() idlToken {
+	doc := l.skipSpace()
+	tok := idlToken{pos: l.pos, doc: doc}
+	if l.pos >= len(l.src) {
+		return tok
+	}
+
+	c := l.src[l.pos]
+	switch {
+	case isIdentStart(c):
+		start := l.pos
+		for l.pos < len(l.src) && (isIdentStart(l.src[l.pos]) || isDigit(l.src[l.pos]) || l.src[l.pos] == '.' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		tok.kind, tok.text = idlIdent, l.src[start:l.pos]
+	case c == '`':
+		end := strings.IndexByte(l.src[l.pos+1:], '`')
+		if end < 0 {
+			tok.kind, tok.text = idlPunct, "`"
+			l.pos++
+			return tok
+		}
+		tok.kind, tok.text, tok.quoted = idlIdent, l.src[l.pos+1:l.pos+1+end], true
+		l.pos += end + 2
+	case c == '"':
+		end := scanJSON(l.src, l.pos)
+		value, err := jsonfmt.Decode([]byte(l.src[l.pos:end]))
+		text, _ := value.(string)
+		if err != nil {
+			text = l.src[l.pos+1 : end]
+		}
+		tok.kind, tok.text = idlString, text
+		l.pos = end
+	case isDigit(c) || c == '-':
+		start := l.pos
+		l.pos++
+		for l.pos < len(l.src) && (isDigit(l.src[l.pos]) || strings.IndexByte(".eE+-", l.src[l.pos]) >= 0) {
+			l.pos++
+		}
+		tok.kind, tok.text = idlNumber, l.src[start:l.pos]
+	default:
+		tok.kind, tok.text = idlPunct, string(c)
+		l.pos++
+	}
+	return tok
+}
+
+// skipSpace skips whitespace and comments, returning the text of the last doc comment
+func (l *idlLexer) skipSpace() string {
+	doc := ""
+	for l.pos < len(l.src) {
+		switch {
+		case strings.IndexByte(" \t\r\n", l.src[l.pos]) >= 0:
+			l.pos++
+		case strings.HasPrefix(l.src[l.pos:], "//"):
+			end := strings.IndexByte(l.src[l.pos:], '\n')
+			if end < 0 {
+				l.pos = len(l.src)
+			} else {
+				l.pos += end + 1
+			}
+		case strings.HasPrefix(l.src[l.pos:], "/*"):
+			end := strings.Index(l.src[l.pos+2:], "*/")
+			if end < 0 {
+				end = len(l.src) - l.pos - 2
+			}
+			comment := l.src[l.pos+2 : l.pos+2+end]
+			l.pos = min(l.pos+end+4, len(l.src))
+			if strings.HasPrefix(comment, "*") && comment != "*" {
+				doc = docText(comment[1:])
+			}
+		default:
+			return doc
+		}
+	}
+	return doc
+}
+
+// docText strips the leading asterisks of the lines of a doc comment
+func docText(comment string) string {
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if i > 0 {
+			line = strings.TrimSpace(strings.TrimPrefix(line, "*"))
+		}
+		lines[i] = line
+	}
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// scanJSON returns the end of the JSON value starting at pos
+func scanJSON(src string, pos int) int {
+	depth := 0
+	for i := pos; i < len(src); i++ {
+		switch c := src[i]; c {
+		case '"':
+			for i++; i < len(src) && src[i] != '"'; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+			if depth == 0 {
+				return min(i+1, len(src))
+			}
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+			if depth < 0 {
+				return i
+			}
+		case ',', ';', ')', ' ', '\t', '\r', '\n':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(src)
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,246 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// idlKeywords are the words that must be escaped with backticks when used as names
+var idlKeywords = map[string]bool{
+	"protocol": true, "import": true, "idl": true, "schema": true, "namespace": true,
+	"record": true, "error": true, "enum": true, "fixed": true, "array": true, "map": true,
+	"union": true, "boolean": true, "int": true, "long": true, "float": true, "double": true,
+	"null": true, "string": true, "bytes": true, "void": true, "oneway": true, "throws": true,
+	"true": true, "false": true, "decimal": true, "date": true, "time_ms": true,
+	"timestamp_ms": true, "local_timestamp_ms": true, "uuid": true,
+}
+
+// FormatIDL writes a schema as an Avro IDL protocol named after the root type. Named
+// types are declared at the protocol level, the types a declaration uses before it.
+func FormatIDL(s *Schema) (string, error) {
+	if !s.IsNamed() {
+		return "", fmt.Errorf("Avro IDL requires a named type at the root, got %s", s.String())
+	}
+
+	w := &idlWriter{namespace: s.Namespace, declared: map[string]bool{}}
+	w.collect(s)
+
+	var sb strings.Builder
+	if w.namespace != "" {
+		fmt.Fprintf(&sb, "@namespace(%s)\n", compactJSON(w.namespace))
+	}
+	fmt.Fprintf(&sb, "protocol %s {\n", idlName(s.Name))
+	for i, t := range w.order {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		w.declare(&sb, t)
+	}
+	sb.WriteString("}\n")
+	return sb.String(), nil
+}
+
+type idlWriter struct {
+	// namespace is the namespace of the protocol
+	namespace string
+	declared  map[string]bool
+	// order holds the named types in declaration order
+	order []*Schema
+}
+
+// collect orders the named types of a schema so that types come after the types they use,
+// where recursion allows it
+func (w *idlWriter) collect(s *Schema) {
+	switch s.Type {
+	case Record, Error, Enum, Fixed:
+		if w.declared[s.FullName()] {
+			return
+		}
+		w.declared[s.FullName()] = true
+		for _, field := range s.Fields {
+			w.collect(field.Type)
+		}
+		w.order = append(w.order, s)
+	case Array:
+		w.collect(s.Items)
+	case Map:
+		w.collect(s.Values)
+	case Union:
+		for _, branch := range s.Types {
+			w.collect(branch)
+		}
+	}
+}
+
+// declare writes the declaration of a named type
+func (w *idlWriter) declare(sb *strings.Builder, s *Schema) {
+	const indent = "  "
+	writeDoc(sb, s.Doc, indent)
+	if s.Namespace != w.namespace {
+		fmt.Fprintf(sb, "%s@namespace(%s)\n", indent, compactJSON(s.Namespace))
+	}
+	if len(s.Aliases) > 0 {
+		fmt.Fprintf(sb, "%s@aliases(%s)\n", indent, compactJSON(s.Aliases))
+	}
+	if s.Type == Fixed && s.LogicalType != "" {
+		fmt.Fprintf(sb, "%s%s\n", indent, strings.TrimSpace(logicalAnnotations(s)))
+	}
+	for _, key := range sortedProps(s.Props) {
+		fmt.Fprintf(sb, "%s@%s(%s)\n", indent, key, compactJSON(s.Props[key]))
+	}
+
+	name := idlName(s.Name)
+	switch s.Type {
+	case Enum:
+		symbols := make([]string, len(s.Symbols))
+		for i, symbol := range s.Symbols {
+			symbols[i] = idlName(symbol)
+		}
+		fmt.Fprintf(sb, "%senum %s {\n%s%s%s\n%s}", indent, name, indent, indent, strings.Join(symbols, ", "), indent)
+		if s.EnumDefault != "" {
+			fmt.Fprintf(sb, " = %s;", idlName(s.EnumDefault))
+		}
+		sb.WriteString("\n")
+	case Fixed:
+		fmt.Fprintf(sb, "%sfixed %s(%d);\n", indent, name, s.Size)
+	default:
+		fmt.Fprintf(sb, "%s%s %s {\n", indent, s.Type, name)
+		for _, field := range s.Fields {
+			w.field(sb, field, s.Namespace, indent+indent)
+		}
+		fmt.Fprintf(sb, "%s}\n", indent)
+	}
+}
+
+// field writes a record field
+func (w *idlWriter) field(sb *strings.Builder, field *Field, namespace, indent string) {
+	writeDoc(sb, field.Doc, indent)
+	sb.WriteString(indent)
+	sb.WriteString(w.typeName(field.Type, namespace))
+	if field.Order != "" {
+		fmt.Fprintf(sb, " @order(%s)", compactJSON(field.Order))
+	}
+	if len(field.Aliases) > 0 {
+		fmt.Fprintf(sb, " @aliases(%s)", compactJSON(field.Aliases))
+	}
+	for _, key := range sortedProps(field.Props) {
+		fmt.Fprintf(sb, " @%s(%s)", key, compactJSON(field.Props[key]))
+	}
+	sb.WriteString(" ")
+	sb.WriteString(idlName(field.Name))
+	if field.HasDefault {
+		sb.WriteString(" = ")
+		sb.WriteString(compactJSON(field.Default))
+	}
+	sb.WriteString(";\n")
+}
+
+// typeName writes a type reference, with annotations for logical types and properties
+func (w *idlWriter) typeName(s *Schema, namespace string) string {
+	switch s.Type {
+	case Array:
+		return "array<" + w.typeName(s.Items, namespace) + ">"
+	case Map:
+		return "map<" + w.typeName(s.Values, namespace) + ">"
+	case Union:
+		branches := make([]string, len(s.Types))
+		for i, branch := range s.Types {
+			branches[i] = w.typeName(branch, namespace)
+		}
+		return "union { " + strings.Join(branches, ", ") + " }"
+	case Record, Error, Enum, Fixed:
+		// Short names resolve against the namespace of the enclosing type or the protocol
+		if s.Namespace != "" && s.Namespace == namespace && s.Namespace == w.namespace {
+			return idlName(s.Name)
+		}
+		name := strings.Split(s.FullName(), ".")
+		for i, part := range name {
+			name[i] = idlName(part)
+		}
+		return strings.Join(name, ".")
+	}
+
+	var annotations string
+	for _, key := range sortedProps(s.Props) {
+		annotations += fmt.Sprintf("@%s(%s) ", key, compactJSON(s.Props[key]))
+	}
+	if keyword := idlLogicalKeyword(s); keyword != "" {
+		return annotations + keyword
+	}
+	if s.LogicalType != "" {
+		annotations += logicalAnnotations(s)
+	}
+	return annotations + string(s.Type)
+}
+
+// idlLogicalKeyword returns the IDL keyword of a primitive type with a logical type
+func idlLogicalKeyword(s *Schema) string {
+	if s.LogicalType == "decimal" && s.Type == Bytes {
+		return fmt.Sprintf("decimal(%d,%d)", s.Precision, s.Scale)
+	}
+	for keyword, logical := range idlLogicalTypes {
+		if string(s.Type) == logical[0] && s.LogicalType == logical[1] {
+			return keyword
+		}
+	}
+	return ""
+}
+
+// logicalAnnotations writes a logical type as annotations
+func logicalAnnotations(s *Schema) string {
+	result := fmt.Sprintf("@logicalType(%s) ", compactJSON(s.LogicalType))
+	if s.LogicalType == "decimal" {
+		result += fmt.Sprintf("@precision(%d) @scale(%d) ", s.Precision, s.Scale)
+	}
+	return result
+}
+
+// writeDoc writes documentation as a doc comment
+func writeDoc(sb *strings.Builder, doc, indent string) {
+	if doc == "" {
+		return
+	}
+	doc = strings.ReplaceAll(doc, "*/", "* /")
+	lines := strings.Split(doc, "\n")
+	if len(lines) == 1 {
+		fmt.Fprintf(sb, "%s/** %s */\n", indent, doc)
+		return
+	}
+	fmt.Fprintf(sb, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(sb, "%s%s\n", indent, strings.TrimRight(" * "+line, " "))
+	}
+	fmt.Fprintf(sb, "%s */\n", indent)
+}
+
+// idlName escapes a name that is a keyword
+func idlName(name string) string {
+	if idlKeywords[name] {
+		return "`" + name + "`"
+	}
+	return name
+}
+
+// compactJSON writes a value as single-line JSON
+func compactJSON(value interface{}) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return "null"
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// sortedProps returns the keys of schema properties in sorted order
+func sortedProps(props map[string]interface{}) []string {
+	keys := make([]string, 0, len(props))
+	for key := range props {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,274 @@
+package avro
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const userIDL = `/** Users service */
+@namespace("com.acme")
+protocol Users {
+  /** Postal address */
+  record Address {
+    string zip;
+    union { null, string } city = null;
+  }
+
+  enum Status { ACTIVE, ` + "`error`" + ` } = ACTIVE;
+
+  fixed Hash(16);
+
+  @aliases(["Person"])
+  record User {
+    /** Unique id */
+    uuid id;
+    string @order("ignore") name = "";
+    Address address;
+    string? email;
+    timestamp_ms created;
+    decimal(9,2) balance;
+    array<Address> history = [];
+    map<long> counters = {};
+    Status status = "ACTIVE";
+    @logicalType("timestamp-micros") long updated;
+    Hash hash;
+    int a, b = 1;
+  }
+
+  error Failure { string reason; }
+
+  User get(string id, int retries = 3) throws Failure;
+  void ping() oneway;
+}`
+
+func TestParseIDL(t *testing.T) {
+	f, err := ParseIDL(userIDL, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if f.Protocol != "Users" || f.Namespace != "com.acme" {
+		t.Errorf("Expected protocol com.acme.Users, got %s.%s", f.Namespace, f.Protocol)
+	}
+	want := "com.acme.Address com.acme.Status com.acme.Hash com.acme.User com.acme.Failure"
+	if got := strings.Join(f.TypeNames(), " "); got != want {
+		t.Errorf("Expected types %s, got %s", want, got)
+	}
+
+	content, err := f.Schema("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Failed to parse translated schema: %v\n%s", err, content)
+	}
+	if s.FullName() != "com.acme.User" || len(s.Aliases) != 1 {
+		t.Errorf("Expected record com.acme.User with an alias, got %s", s.FullName())
+	}
+
+	types := map[string]string{}
+	for _, field := range s.Fields {
+		types[field.Name] = field.Type.String()
+	}
+	for name, want := range map[string]string{
+		"id":       "string(uuid)",
+		"email":    "union[null,string]",
+		"created":  "long(timestamp-millis)",
+		"balance":  "bytes(decimal)",
+		"history":  "array<com.acme.Address>",
+		"counters": "map<long>",
+		"status":   "com.acme.Status",
+		"updated":  "long(timestamp-micros)",
+		"hash":     "com.acme.Hash",
+		"b":        "int",
+	} {
+		if types[name] != want {
+			t.Errorf("Expected field %s of type %s, got %s", name, want, types[name])
+		}
+	}
+	if field := s.FieldByName("id"); field.Doc != "Unique id" {
+		t.Errorf("Expected doc comment on id, got %q", field.Doc)
+	}
+	if field := s.FieldByName("name"); field.Order != "ignore" || field.Default != "" {
+		t.Errorf("Expected name with order ignore and an empty default, got %+v", field)
+	}
+	if address := s.FieldByName("address").Type; address.Doc != "Postal address" {
+		t.Errorf("Expected doc on Address, got %q", address.Doc)
+	}
+	if status := s.FieldByName("status").Type; status.EnumDefault != "ACTIVE" || status.Symbols[1] != "error" {
+		t.Errorf("Expected enum with default and escaped symbol, got %+v", status)
+	}
+	if balance := s.FieldByName("balance").Type; balance.Precision != 9 || balance.Scale != 2 {
+		t.Errorf("Expected decimal(9,2), got %d,%d", balance.Precision, balance.Scale)
+	}
+}
+
+func TestParseIDL_SelectRecord(t *testing.T) {
+	f, err := ParseIDL(userIDL, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	content, err := f.Schema("Address")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s, err := Parse(content); err != nil || s.FullName() != "com.acme.Address" {
+		t.Errorf("Expected com.acme.Address, got %v", err)
+	}
+
+	if _, err := f.Schema("Missing"); err == nil || !strings.Contains(err.Error(), "com.acme.User") {
+		t.Errorf("Expected error listing the defined types, got %v", err)
+	}
+
+	multiple, err := ParseIDL(`protocol P { record A { int x; } record B { int y; } }`, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := multiple.Schema(""); err == nil || !strings.Contains(err.Error(), "A, B") {
+		t.Errorf("Expected error naming the candidate records, got %v", err)
+	}
+}
+
+func TestParseIDL_SchemaSyntax(t *testing.T) {
+	f, err := ParseIDL(`namespace com.acme;
+schema Event;
+
+record Event {
+  string? note = "none";
+  Kind kind;
+}
+
+enum Kind { A, B }`, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	content, err := f.Schema("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Failed to parse translated schema: %v", err)
+	}
+	if s.FullName() != "com.acme.Event" {
+		t.Errorf("Expected com.acme.Event, got %s", s.FullName())
+	}
+	// A nullable field with a non-null default lists null last
+	if note := s.FieldByName("note").Type.String(); note != "union[string,null]" {
+		t.Errorf("Expected union[string,null], got %s", note)
+	}
+}
+
+func TestParseIDL_Imports(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"money.avsc":   `{"type":"record","name":"Money","namespace":"com.acme","fields":[{"name":"cents","type":"long"}]}`,
+		"common.avdl":  `@namespace("com.acme") protocol Common { enum Currency { EUR, USD } }`,
+		"order.avdl":   `@namespace("com.acme") protocol Orders { import schema "money.avsc"; import idl "common.avdl"; record Order { Money total; Currency currency; } }`,
+		"invalid.avdl": `protocol P { import idl "missing.avdl"; }`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := ReadIDL(filepath.Join(dir, "order.avdl"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	content, err := f.Schema("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Failed to parse translated schema: %v", err)
+	}
+	if s.FieldByName("total").Type.FullName() != "com.acme.Money" || s.FieldByName("currency").Type.Type != Enum {
+		t.Errorf("Expected imported types to be inlined, got:\n%s", content)
+	}
+
+	if _, err := ReadIDL(filepath.Join(dir, "invalid.avdl")); err == nil {
+		t.Error("Expected error for a missing import")
+	}
+	if _, err := ParseIDL(files["order.avdl"], ""); err == nil {
+		t.Error("Expected error for imports without a directory")
+	}
+}
+
+func TestParseIDL_Errors(t *testing.T) {
+	for name, content := range map[string]string{
+		"unterminated record": `protocol P { record A { int x; }`,
+		"missing semicolon":   `protocol P { record A { int x } }`,
+		"unknown type":        `protocol P { record A { B b; } }`,
+		"duplicate type":      `protocol P { record A { int x; } record A { int y; } }`,
+		"invalid default":     `protocol P { record A { int x = {; } }`,
+	} {
+		f, err := ParseIDL(content, "")
+		if err == nil {
+			_, err = f.Schema("A")
+		}
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestFormatIDL(t *testing.T) {
+	f, err := ParseIDL(userIDL, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	content, err := f.Schema("User")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Failed to parse translated schema: %v", err)
+	}
+
+	idl, err := FormatIDL(s)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"@namespace(\"com.acme\")\nprotocol User {",
+		"  /** Postal address */\n  record Address {",
+		"  enum Status {\n    ACTIVE, `error`\n  } = ACTIVE;",
+		"  fixed Hash(16);",
+		"  @aliases([\"Person\"])\n  record User {",
+		"    string @order(\"ignore\") name = \"\";",
+		"    union { null, string } email;",
+		"    decimal(9,2) balance;",
+		"    @logicalType(\"timestamp-micros\") long updated;",
+	} {
+		if !strings.Contains(idl, want) {
+			t.Errorf("Expected IDL to contain:\n%s\ngot:\n%s", want, idl)
+		}
+	}
+	if strings.Index(idl, "record Address") > strings.Index(idl, "record User") {
+		t.Errorf("Expected types to be declared before the types using them")
+	}
+
+	// The IDL reads back to the same schema
+	reparsed, err := ParseIDL(idl, "")
+	if err != nil {
+		t.Fatalf("Failed to parse written IDL: %v\n%s", err, idl)
+	}
+	roundTrip, err := reparsed.Schema("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if roundTrip != content {
+		t.Errorf("Expected round trip to preserve the schema:\n%s\ngot:\n%s", content, roundTrip)
+	}
+
+	if _, err := FormatIDL(&Schema{Type: String}); err == nil {
+		t.Error("Expected error for a schema without a named root")
+	}
+}
@@ -0,0 +1,103 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// NamedTypes returns the full names of the named types an Avro schema defines and of the
+// named types it uses without defining them, which must come from references. Both are in
+// order of appearance. Unqualified names are qualified with the enclosing namespace.
+func NamedTypes(content string) (defined, external []string, err error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(content)))
+	decoder.UseNumber()
+	var raw interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, nil, fmt.Errorf("invalid schema JSON: %w", err)
+	}
+
+	w := &nameWalker{defined: map[string]bool{}}
+	w.walk(raw, "")
+
+	seen := map[string]bool{}
+	for _, use := range w.uses {
+		if w.defined[use.qualified] || w.defined[use.name] || seen[use.qualified] {
+			continue
+		}
+		seen[use.qualified] = true
+		external = append(external, use.qualified)
+	}
+	return w.order, external, nil
+}
+
+type nameUse struct {
+	name      string
+	qualified string
+}
+
+type nameWalker struct {
+	defined map[string]bool
+	order   []string
+	uses    []nameUse
+}
+
+func (w *nameWalker) walk(raw interface{}, namespace string) {
+	switch v := raw.(type) {
+	case string:
+		w.use(v, namespace)
+	case []interface{}:
+		for _, branch := range v {
+			w.walk(branch, namespace)
+		}
+	case map[string]interface{}:
+		typeName, ok := v["type"].(string)
+		if !ok {
+			w.walk(v["type"], namespace)
+			return
+		}
+		switch Type(typeName) {
+		case Record, Error, Enum, Fixed:
+			name, _ := v["name"].(string)
+			if explicit, ok := v["namespace"].(string); ok {
+				namespace = explicit
+			}
+			if i := strings.LastIndex(name, "."); i >= 0 {
+				namespace, name = name[:i], name[i+1:]
+			}
+			fullName := name
+			if namespace != "" {
+				fullName = namespace + "." + name
+			}
+			if !w.defined[fullName] {
+				w.defined[fullName] = true
+				w.order = append(w.order, fullName)
+			}
+			fields, _ := v["fields"].([]interface{})
+			for _, field := range fields {
+				if obj, ok := field.(map[string]interface{}); ok {
+					w.walk(obj["type"], namespace)
+				}
+			}
+		case Array:
+			w.walk(v["items"], namespace)
+		case Map:
+			w.walk(v["values"], namespace)
+		default:
+			w.use(typeName, namespace)
+		}
+	}
+}
+
+// use records a reference to a type by name
+func (w *nameWalker) use(name, namespace string) {
+	if IsPrimitive(Type(name)) {
+		return
+	}
+	qualified := name
+	if !strings.Contains(name, ".") && namespace != "" {
+		qualified = namespace + "." + name
+	}
+	w.uses = append(w.uses, nameUse{name: name, qualified: qualified})
+}
@@ -0,0 +1,37 @@
+package avro
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNamedTypes(t *testing.T) {
+	defined, external, err := NamedTypes(`{
+  "type": "record",
+  "name": "User",
+  "namespace": "com.acme",
+  "fields": [
+    {"name": "address", "type": "Address"},
+    {"name": "billing", "type": ["null", "com.acme.Address"]},
+    {"name": "money", "type": "com.shared.Money"},
+    {"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["A"]}},
+    {"name": "previous", "type": ["null", "Status"]},
+    {"name": "tags", "type": {"type": "array", "items": "Tag"}},
+    {"name": "friends", "type": {"type": "map", "values": "User"}},
+    {"name": "id", "type": {"type": "string", "logicalType": "uuid"}}
+  ]
+}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := strings.Join(defined, " "); got != "com.acme.User com.acme.Status" {
+		t.Errorf("Expected defined types com.acme.User com.acme.Status, got %s", got)
+	}
+	if got := strings.Join(external, " "); got != "com.acme.Address com.shared.Money com.acme.Tag" {
+		t.Errorf("Expected external types com.acme.Address com.shared.Money com.acme.Tag, got %s", got)
+	}
+
+	if _, _, err := NamedTypes(`{"type":`); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
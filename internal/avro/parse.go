@@ -0,0 +1,399 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var nameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reservedProps are the attributes defined by the specification for each kind of schema
+var reservedProps = map[string]bool{
+	"type": true, "name": true, "namespace": true, "doc": true, "aliases": true,
+	"fields": true, "symbols": true, "default": true, "items": true, "values": true,
+	"size": true, "logicalType": true, "precision": true, "scale": true,
+}
+
+var reservedFieldProps = map[string]bool{
+	"name": true, "doc": true, "type": true, "default": true, "order": true, "aliases": true,
+}
+
+// Names holds the named types known to a parser, keyed by full name
+type Names struct {
+	types map[string]*Schema
+}
+
+// NewNames creates an empty set of named types
+func NewNames() *Names {
+	return &Names{types: map[string]*Schema{}}
+}
+
+// Get returns a named type by full name
+func (n *Names) Get(fullName string) (*Schema, bool) {
+	s, ok := n.types[fullName]
+	return s, ok
+}
+
+// All returns the named types in the set
+func (n *Names) All() map[string]*Schema {
+	return n.types
+}
+
+// Parse parses an Avro schema in JSON form
+func Parse(content string) (*Schema, error) {
+	return ParseWithNames(content, NewNames())
+}
+
+// ParseWithReferences parses an Avro schema whose named types may be defined by
+// referenced schemas, which are parsed first in the order given
+func ParseWithReferences(content string, references []string) (*Schema, error) {
+	names := NewNames()
+	for i, ref := range references {
+		if _, err := ParseWithNames(ref, names); err != nil {
+			return nil, fmt.Errorf("failed to parse reference %d: %w", i+1, err)
+		}
+	}
+	return ParseWithNames(content, names)
+}
+
+// ParseWithNames parses an Avro schema, resolving and registering named types in names
+func ParseWithNames(content string, names *Names) (*Schema, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(content)))
+	decoder.UseNumber()
+
+	var raw interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid schema JSON: %w", err)
+	}
+
+	p := &parser{names: names}
+	return p.parse(raw, "")
+}
+
+type parser struct {
+	names *Names
+}
+
+func (p *parser) parse(raw interface{}, namespace string) (*Schema, error) {
+	switch v := raw.(type) {
+	case string:
+		return p.resolve(v, namespace)
+	case []interface{}:
+		return p.parseUnion(v, namespace)
+	case map[string]interface{}:
+		return p.parseObject(v, namespace)
+	default:
+		return nil, fmt.Errorf("invalid schema: unexpected %s", describeJSON(raw))
+	}
+}
+
+// resolve resolves a type name to a primitive or a previously defined named type
+func (p *parser) resolve(name, namespace string) (*Schema, error) {
+	if IsPrimitive(Type(name)) {
+		return &Schema{Type: Type(name)}, nil
+	}
+
+	if !strings.Contains(name, ".") && namespace != "" {
+		if s, ok := p.names.types[namespace+"."+name]; ok {
+			return s, nil
+		}
+	}
+	if s, ok := p.names.types[name]; ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("unknown type: %s", name)
+}
+
+func (p *parser) parseUnion(branches []interface{}, namespace string) (*Schema, error) {
+	union := &Schema{Type: Union}
+	seen := map[string]bool{}
+
+	for i, branch := range branches {
+		s, err := p.parse(branch, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("union branch %d: %w", i, err)
+		}
+		if s.Type == Union {
+			return nil, fmt.Errorf("unions may not immediately contain other unions")
+		}
+		key := string(s.Type)
+		if s.IsNamed() {
+			key = s.FullName()
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate type in union: %s", key)
+		}
+		seen[key] = true
+		union.Types = append(union.Types, s)
+	}
+
+	return union, nil
+}
+
+func (p *parser) parseObject(obj map[string]interface{}, namespace string) (*Schema, error) {
+	typeValue, ok := obj["type"]
+	if !ok {
+		return nil, fmt.Errorf("schema object is missing 'type'")
+	}
+
+	// {"type": {...}} or {"type": [...]} wraps another schema
+	typeName, ok := typeValue.(string)
+	if !ok {
+		s, err := p.parse(typeValue, namespace)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+
+	var s *Schema
+	var err error
+
+	switch Type(typeName) {
+	case Record, Error:
+		s, err = p.parseRecord(obj, Type(typeName), namespace)
+	case Enum:
+		s, err = p.parseEnum(obj, namespace)
+	case Fixed:
+		s, err = p.parseFixed(obj, namespace)
+	case Array:
+		items, ok := obj["items"]
+		if !ok {
+			return nil, fmt.Errorf("array schema is missing 'items'")
+		}
+		itemSchema, itemErr := p.parse(items, namespace)
+		if itemErr != nil {
+			return nil, fmt.Errorf("array items: %w", itemErr)
+		}
+		s = &Schema{Type: Array, Items: itemSchema}
+	case Map:
+		values, ok := obj["values"]
+		if !ok {
+			return nil, fmt.Errorf("map schema is missing 'values'")
+		}
+		valueSchema, valueErr := p.parse(values, namespace)
+		if valueErr != nil {
+			return nil, fmt.Errorf("map values: %w", valueErr)
+		}
+		s = &Schema{Type: Map, Values: valueSchema}
+	default:
+		if !IsPrimitive(Type(typeName)) {
+			// A named type reference written as an object
+			return p.resolve(typeName, namespace)
+		}
+		s = &Schema{Type: Type(typeName)}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if logicalType, ok := obj["logicalType"].(string); ok {
+		s.LogicalType = logicalType
+		s.Precision = intValue(obj["precision"])
+		s.Scale = intValue(obj["scale"])
+	}
+
+	for key, value := range obj {
+		if !reservedProps[key] {
+			if s.Props == nil {
+				s.Props = map[string]interface{}{}
+			}
+			s.Props[key] = value
+		}
+	}
+
+	return s, nil
+}
+
+// define computes the name and namespace of a named type and registers it
+func (p *parser) define(s *Schema, obj map[string]interface{}, namespace string) error {
+	name, ok := obj["name"].(string)
+	if !ok || name == "" {
+		return fmt.Errorf("%s schema is missing 'name'", s.Type)
+	}
+
+	if explicit, ok := obj["namespace"].(string); ok {
+		namespace = explicit
+	}
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		namespace = name[:idx]
+		name = name[idx+1:]
+	}
+
+	if !nameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid name: %s", name)
+	}
+	if namespace != "" {
+		for _, part := range strings.Split(namespace, ".") {
+			if !nameRegexp.MatchString(part) {
+				return fmt.Errorf("invalid namespace: %s", namespace)
+			}
+		}
+	}
+	if IsPrimitive(Type(name)) && namespace == "" {
+		return fmt.Errorf("cannot redefine primitive type: %s", name)
+	}
+
+	s.Name = name
+	s.Namespace = namespace
+	s.Doc, _ = obj["doc"].(string)
+	s.Aliases = stringList(obj["aliases"])
+
+	fullName := s.FullName()
+	if _, exists := p.names.types[fullName]; exists {
+		return fmt.Errorf("duplicate definition of type %s", fullName)
+	}
+	p.names.types[fullName] = s
+	return nil
+}
+
+func (p *parser) parseRecord(obj map[string]interface{}, recordType Type, namespace string) (*Schema, error) {
+	s := &Schema{Type: recordType}
+	if err := p.define(s, obj, namespace); err != nil {
+		return nil, err
+	}
+
+	rawFields, ok := obj["fields"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("record %s is missing 'fields'", s.FullName())
+	}
+
+	seen := map[string]bool{}
+	for i, rawField := range rawFields {
+		fieldObj, ok := rawField.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("record %s: field %d is not an object", s.FullName(), i)
+		}
+
+		name, _ := fieldObj["name"].(string)
+		if !nameRegexp.MatchString(name) {
+			return nil, fmt.Errorf("record %s: invalid field name '%s'", s.FullName(), name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("record %s: duplicate field '%s'", s.FullName(), name)
+		}
+		seen[name] = true
+
+		rawType, ok := fieldObj["type"]
+		if !ok {
+			return nil, fmt.Errorf("record %s: field '%s' is missing 'type'", s.FullName(), name)
+		}
+		fieldType, err := p.parse(rawType, s.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("record %s: field '%s': %w", s.FullName(), name, err)
+		}
+
+		field := &Field{
+			Name:    name,
+			Type:    fieldType,
+			Aliases: stringList(fieldObj["aliases"]),
+		}
+		field.Doc, _ = fieldObj["doc"].(string)
+		field.Order, _ = fieldObj["order"].(string)
+		if def, ok := fieldObj["default"]; ok {
+			field.Default = def
+			field.HasDefault = true
+		}
+		for key, value := range fieldObj {
+			if !reservedFieldProps[key] {
+				if field.Props == nil {
+					field.Props = map[string]interface{}{}
+				}
+				field.Props[key] = value
+			}
+		}
+
+		s.Fields = append(s.Fields, field)
+	}
+
+	return s, nil
+}
+
+func (p *parser) parseEnum(obj map[string]interface{}, namespace string) (*Schema, error) {
+	s := &Schema{Type: Enum}
+	if err := p.define(s, obj, namespace); err != nil {
+		return nil, err
+	}
+
+	rawSymbols, ok := obj["symbols"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("enum %s is missing 'symbols'", s.FullName())
+	}
+
+	seen := map[string]bool{}
+	for _, raw := range rawSymbols {
+		symbol, ok := raw.(string)
+		if !ok || !nameRegexp.MatchString(symbol) {
+			return nil, fmt.Errorf("enum %s: invalid symbol %v", s.FullName(), raw)
+		}
+		if seen[symbol] {
+			return nil, fmt.Errorf("enum %s: duplicate symbol %s", s.FullName(), symbol)
+		}
+		seen[symbol] = true
+		s.Symbols = append(s.Symbols, symbol)
+	}
+
+	if def, ok := obj["default"].(string); ok {
+		if !seen[def] {
+			return nil, fmt.Errorf("enum %s: default '%s' is not a symbol", s.FullName(), def)
+		}
+		s.EnumDefault = def
+	}
+
+	return s, nil
+}
+
+func (p *parser) parseFixed(obj map[string]interface{}, namespace string) (*Schema, error) {
+	s := &Schema{Type: Fixed}
+	if err := p.define(s, obj, namespace); err != nil {
+		return nil, err
+	}
+
+	size, ok := obj["size"].(json.Number)
+	if !ok {
+		return nil, fmt.Errorf("fixed %s is missing 'size'", s.FullName())
+	}
+	n, err := size.Int64()
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("fixed %s: invalid size %s", s.FullName(), size)
+	}
+	s.Size = int(n)
+
+	return s, nil
+}
+
+func intValue(raw interface{}) int {
+	if n, ok := raw.(json.Number); ok {
+		if v, err := n.Int64(); err == nil {
+			return int(v)
+		}
+	}
+	return 0
+}
+
+func stringList(raw interface{}) []string {
+	values, _ := raw.([]interface{})
+	var result []string
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func describeJSON(raw interface{}) string {
+	switch raw.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	default:
+		return fmt.Sprintf("%T", raw)
+	}
+}
@@ -0,0 +1,131 @@
+package avro
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		schema      string
+		expectError bool
+		check       func(t *testing.T, s *Schema)
+	}{
+		{
+			name:   "primitive type",
+			schema: `"string"`,
+			check: func(t *testing.T, s *Schema) {
+				if s.Type != String {
+					t.Errorf("Expected string type, got %s", s.Type)
+				}
+			},
+		},
+		{
+			name:   "record with nested types",
+			schema: `{"type":"record","name":"User","namespace":"com.acme","fields":[{"name":"id","type":"long"},{"name":"email","type":["null","string"],"default":null},{"name":"status","type":{"type":"enum","name":"Status","symbols":["ACTIVE","INACTIVE"]}},{"name":"created","type":{"type":"long","logicalType":"timestamp-millis"}}]}`,
+			check: func(t *testing.T, s *Schema) {
+				if s.FullName() != "com.acme.User" {
+					t.Errorf("Expected full name com.acme.User, got %s", s.FullName())
+				}
+				if len(s.Fields) != 4 {
+					t.Fatalf("Expected 4 fields, got %d", len(s.Fields))
+				}
+				email := s.FieldByName("email")
+				if !email.HasDefault || !email.Type.IsNullable() {
+					t.Errorf("Expected email to be nullable with default")
+				}
+				status := s.FieldByName("status").Type
+				if status.FullName() != "com.acme.Status" {
+					t.Errorf("Expected enum to inherit namespace, got %s", status.FullName())
+				}
+				if s.FieldByName("created").Type.LogicalType != "timestamp-millis" {
+					t.Errorf("Expected logical type timestamp-millis")
+				}
+			},
+		},
+		{
+			name:   "recursive record",
+			schema: `{"type":"record","name":"Node","fields":[{"name":"next","type":["null","Node"]}]}`,
+			check: func(t *testing.T, s *Schema) {
+				next := s.FieldByName("next").Type.NonNull()
+				if next != s {
+					t.Errorf("Expected recursive reference to resolve to the same schema")
+				}
+			},
+		},
+		{
+			name:        "unknown type",
+			schema:      `{"type":"record","name":"A","fields":[{"name":"b","type":"B"}]}`,
+			expectError: true,
+		},
+		{
+			name:        "duplicate field",
+			schema:      `{"type":"record","name":"A","fields":[{"name":"b","type":"int"},{"name":"b","type":"int"}]}`,
+			expectError: true,
+		},
+		{
+			name:        "nested union",
+			schema:      `["null",["int","string"]]`,
+			expectError: true,
+		},
+		{
+			name:        "duplicate union branch",
+			schema:      `["int","int"]`,
+			expectError: true,
+		},
+		{
+			name:        "invalid enum default",
+			schema:      `{"type":"enum","name":"E","symbols":["A"],"default":"B"}`,
+			expectError: true,
+		},
+		{
+			name:        "fixed without size",
+			schema:      `{"type":"fixed","name":"F"}`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.schema)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			tt.check(t, s)
+		})
+	}
+}
+
+func TestParseWithReferences(t *testing.T) {
+	address := `{"type":"record","name":"Address","namespace":"com.acme","fields":[{"name":"zip","type":"string"}]}`
+	user := `{"type":"record","name":"User","namespace":"com.acme","fields":[{"name":"address","type":"Address"}]}`
+
+	if _, err := Parse(user); err == nil {
+		t.Error("Expected error parsing schema without its reference")
+	}
+
+	s, err := ParseWithReferences(user, []string{address})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := s.FieldByName("address").Type.FullName(); got != "com.acme.Address" {
+		t.Errorf("Expected referenced type com.acme.Address, got %s", got)
+	}
+}
+
+func TestSchemaString(t *testing.T) {
+	s, err := Parse(`{"type":"map","values":{"type":"array","items":["null","string"]}}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "map<array<union[null,string]>>"
+	if s.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, s.String())
+	}
+}
@@ -0,0 +1,190 @@
+package avro
+
+import (
+	"strings"
+)
+
+// Type is an Avro schema type
+type Type string
+
+const (
+	Null    Type = "null"
+	Boolean Type = "boolean"
+	Int     Type = "int"
+	Long    Type = "long"
+	Float   Type = "float"
+	Double  Type = "double"
+	Bytes   Type = "bytes"
+	String  Type = "string"
+	Record  Type = "record"
+	Error   Type = "error"
+	Enum    Type = "enum"
+	Array   Type = "array"
+	Map     Type = "map"
+	Union   Type = "union"
+	Fixed   Type = "fixed"
+)
+
+// primitiveTypes lists the types that are referenced by name only
+var primitiveTypes = map[Type]bool{
+	Null:    true,
+	Boolean: true,
+	Int:     true,
+	Long:    true,
+	Float:   true,
+	Double:  true,
+	Bytes:   true,
+	String:  true,
+}
+
+// IsPrimitive reports whether a type is one of the Avro primitive types
+func IsPrimitive(t Type) bool {
+	return primitiveTypes[t]
+}
+
+// Schema is a parsed Avro schema. Named types (records, enums, fixed) are shared:
+// every reference to a named type points to the same *Schema, so recursive schemas
+// form cycles and walkers must track the names they have visited.
+type Schema struct {
+	Type Type
+
+	// Named types
+	Name      string
+	Namespace string
+	Doc       string
+	Aliases   []string
+
+	// Records
+	Fields []*Field
+
+	// Enums
+	Symbols     []string
+	EnumDefault string
+
+	// Arrays and maps
+	Items  *Schema
+	Values *Schema
+
+	// Unions
+	Types []*Schema
+
+	// Fixed
+	Size int
+
+	// Logical types
+	LogicalType string
+	Precision   int
+	Scale       int
+
+	// Props holds attributes not defined by the specification
+	Props map[string]interface{}
+}
+
+// Field is a record field
+type Field struct {
+	Name       string
+	Doc        string
+	Type       *Schema
+	Default    interface{}
+	HasDefault bool
+	Order      string
+	Aliases    []string
+	Props      map[string]interface{}
+}
+
+// IsNamed reports whether the schema is a named type
+func (s *Schema) IsNamed() bool {
+	return s.Type == Record || s.Type == Error || s.Type == Enum || s.Type == Fixed
+}
+
+// IsRecord reports whether the schema is a record or error
+func (s *Schema) IsRecord() bool {
+	return s.Type == Record || s.Type == Error
+}
+
+// FullName returns the namespace-qualified name of a named type
+func (s *Schema) FullName() string {
+	if s.Namespace == "" {
+		return s.Name
+	}
+	return s.Namespace + "." + s.Name
+}
+
+// TypeName returns the name used to reference this schema: the full name for named
+// types, the type itself otherwise
+func (s *Schema) TypeName() string {
+	if s.IsNamed() {
+		return s.FullName()
+	}
+	return string(s.Type)
+}
+
+// IsNullable reports whether the schema is null or a union containing null
+func (s *Schema) IsNullable() bool {
+	if s.Type == Null {
+		return true
+	}
+	if s.Type == Union {
+		for _, t := range s.Types {
+			if t.Type == Null {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NonNull returns the single non-null branch of an optional union (["null", T]),
+// or the schema itself when it is not such a union
+func (s *Schema) NonNull() *Schema {
+	if s.Type != Union {
+		return s
+	}
+	var branch *Schema
+	for _, t := range s.Types {
+		if t.Type == Null {
+			continue
+		}
+		if branch != nil {
+			return s
+		}
+		branch = t
+	}
+	if branch == nil {
+		return s
+	}
+	return branch
+}
+
+// FieldByName returns a record field by name
+func (s *Schema) FieldByName(name string) *Field {
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// String returns a short human-readable description of the type, such as
+// "array<string>", "map<long>", "union[null,string]" or "com.acme.User"
+func (s *Schema) String() string {
+	switch s.Type {
+	case Array:
+		return "array<" + s.Items.String() + ">"
+	case Map:
+		return "map<" + s.Values.String() + ">"
+	case Union:
+		names := make([]string, 0, len(s.Types))
+		for _, t := range s.Types {
+			names = append(names, t.String())
+		}
+		return "union[" + strings.Join(names, ",") + "]"
+	default:
+		name := s.TypeName()
+		if s.LogicalType != "" {
+			return name + "(" + s.LogicalType + ")"
+		}
+		return name
+	}
+}
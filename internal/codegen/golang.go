@@ -0,0 +1,378 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/aywengo/ksr-cli/internal/avro"
+)
+
+// GoOptions controls Go code generation
+type GoOptions struct {
+	// Package is the name of the generated package
+	Package string
+	// Source describes where the schema came from, for the generated file header
+	Source string
+}
+
+// Go generates Go type declarations for the named types of an Avro schema:
+//
+//   - records become structs with avro and json tags
+//   - enums become string types with a constant per symbol
+//   - fixed types become byte arrays
+//   - optional unions (["null", T]) become pointers, or plain slices and maps
+//   - other unions become wrapper structs with a pointer field per branch, tagged with
+//     the branch name used by the Avro JSON encoding
+//   - date and timestamp logical types become time.Time, time-millis and time-micros
+//     become time.Duration, and decimals become *big.Rat
+func Go(s *avro.Schema, opts GoOptions) (string, error) {
+	g := &goGenerator{
+		names:   map[string]string{},
+		used:    map[string]bool{},
+		imports: map[string]bool{},
+	}
+
+	g.collect(s, map[string]bool{})
+	if len(g.named) == 0 {
+		return "", fmt.Errorf("the schema defines no records, enums or fixed types to generate")
+	}
+	for _, named := range g.named {
+		g.names[named.FullName()] = g.uniqueName(goTypeName(named))
+	}
+
+	for _, named := range g.named {
+		g.declare(named)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by ksr-cli generate go. DO NOT EDIT.\n")
+	if opts.Source != "" {
+		fmt.Fprintf(&out, "// Source: %s\n", opts.Source)
+	}
+	fmt.Fprintf(&out, "\npackage %s\n", opts.Package)
+
+	if len(g.imports) > 0 {
+		imports := make([]string, 0, len(g.imports))
+		for path := range g.imports {
+			imports = append(imports, path)
+		}
+		sort.Strings(imports)
+		out.WriteString("\nimport (\n")
+		for _, path := range imports {
+			fmt.Fprintf(&out, "\t%q\n", path)
+		}
+		out.WriteString(")\n")
+	}
+
+	out.Write(g.body.Bytes())
+
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return string(formatted), nil
+}
+
+// goGenerator accumulates declarations for the named types of a schema
+type goGenerator struct {
+	named   []*avro.Schema
+	names   map[string]string
+	used    map[string]bool
+	imports map[string]bool
+	body    bytes.Buffer
+}
+
+// collect records the named types reachable from a schema in discovery order
+func (g *goGenerator) collect(s *avro.Schema, seen map[string]bool) {
+	if s.IsNamed() {
+		if seen[s.FullName()] {
+			return
+		}
+		seen[s.FullName()] = true
+		// Decimals are mapped to *big.Rat, so their fixed type is not needed
+		if !(s.Type == avro.Fixed && s.LogicalType == "decimal") {
+			g.named = append(g.named, s)
+		}
+	}
+
+	switch s.Type {
+	case avro.Record, avro.Error:
+		for _, field := range s.Fields {
+			g.collect(field.Type, seen)
+		}
+	case avro.Array:
+		g.collect(s.Items, seen)
+	case avro.Map:
+		g.collect(s.Values, seen)
+	case avro.Union:
+		for _, branch := range s.Types {
+			g.collect(branch, seen)
+		}
+	}
+}
+
+// uniqueName reserves a package-level Go name, numbering it if it is already taken
+func (g *goGenerator) uniqueName(name string) string {
+	return reserveName(g.used, name)
+}
+
+// reserveName reserves a name in a scope, numbering it if it is already taken. Distinct
+// Avro names such as "user_id" and "userId" can map to the same Go name.
+func reserveName(used map[string]bool, name string) string {
+	candidate := name
+	for i := 2; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// declare writes the declaration of a named type
+func (g *goGenerator) declare(s *avro.Schema) {
+	name := g.names[s.FullName()]
+
+	switch s.Type {
+	case avro.Record, avro.Error:
+		var wrappers []func()
+		var fields bytes.Buffer
+		fieldNames := map[string]bool{}
+		for _, field := range s.Fields {
+			fieldName := reserveName(fieldNames, goName(field.Name))
+			goType := g.goType(field.Type, name+fieldName, &wrappers)
+			writeComment(&fields, "\t", field.Doc)
+			fmt.Fprintf(&fields, "\t%s %s `avro:%q json:%q`\n", fieldName, goType, field.Name, field.Name)
+		}
+
+		fmt.Fprintf(&g.body, "\n// %s is the Avro record %s.\n", name, s.FullName())
+		if s.Doc != "" {
+			g.body.WriteString("//\n")
+			writeComment(&g.body, "", s.Doc)
+		}
+		fmt.Fprintf(&g.body, "type %s struct {\n%s}\n", name, fields.String())
+
+		for _, wrapper := range wrappers {
+			wrapper()
+		}
+
+	case avro.Enum:
+		fmt.Fprintf(&g.body, "\n// %s is the Avro enum %s.\n", name, s.FullName())
+		if s.Doc != "" {
+			g.body.WriteString("//\n")
+			writeComment(&g.body, "", s.Doc)
+		}
+		fmt.Fprintf(&g.body, "type %s string\n\n", name)
+		fmt.Fprintf(&g.body, "// %s values\nconst (\n", name)
+		for _, symbol := range s.Symbols {
+			comment := ""
+			if symbol == s.EnumDefault {
+				comment = " // default"
+			}
+			fmt.Fprintf(&g.body, "\t%s %s = %q%s\n", g.uniqueName(name+goName(symbol)), name, symbol, comment)
+		}
+		g.body.WriteString(")\n")
+
+	case avro.Fixed:
+		fmt.Fprintf(&g.body, "\n// %s is the Avro fixed type %s.\n", name, s.FullName())
+		if s.Doc != "" {
+			g.body.WriteString("//\n")
+			writeComment(&g.body, "", s.Doc)
+		}
+		fmt.Fprintf(&g.body, "type %s [%d]byte\n", name, s.Size)
+	}
+}
+
+// goType returns the Go type for a schema. Unions other than optional ones are declared
+// as wrapper structs named after hint, after the declaration being written.
+func (g *goGenerator) goType(s *avro.Schema, hint string, wrappers *[]func()) string {
+	if logical := g.logicalType(s); logical != "" {
+		return logical
+	}
+
+	switch s.Type {
+	case avro.Null:
+		return "any"
+	case avro.Boolean:
+		return "bool"
+	case avro.Int:
+		return "int32"
+	case avro.Long:
+		return "int64"
+	case avro.Float:
+		return "float32"
+	case avro.Double:
+		return "float64"
+	case avro.Bytes:
+		return "[]byte"
+	case avro.String:
+		return "string"
+	case avro.Record, avro.Error, avro.Enum, avro.Fixed:
+		return g.names[s.FullName()]
+	case avro.Array:
+		return "[]" + g.goType(s.Items, hint+"Item", wrappers)
+	case avro.Map:
+		return "map[string]" + g.goType(s.Values, hint+"Value", wrappers)
+	case avro.Union:
+		nonNull := s.NonNull()
+		if nonNull != s {
+			return nullable(g.goType(nonNull, hint, wrappers))
+		}
+		return g.unionWrapper(s, hint, wrappers)
+	default:
+		return "any"
+	}
+}
+
+// logicalType maps logical types to Go types, or returns "" for plain types
+func (g *goGenerator) logicalType(s *avro.Schema) string {
+	switch {
+	case s.LogicalType == "date" && s.Type == avro.Int,
+		strings.HasSuffix(s.LogicalType, "timestamp-millis") && s.Type == avro.Long,
+		strings.HasSuffix(s.LogicalType, "timestamp-micros") && s.Type == avro.Long,
+		strings.HasSuffix(s.LogicalType, "timestamp-nanos") && s.Type == avro.Long:
+		g.imports["time"] = true
+		return "time.Time"
+	case s.LogicalType == "time-millis" && s.Type == avro.Int,
+		s.LogicalType == "time-micros" && s.Type == avro.Long:
+		g.imports["time"] = true
+		return "time.Duration"
+	case s.LogicalType == "decimal" && (s.Type == avro.Bytes || s.Type == avro.Fixed):
+		g.imports["math/big"] = true
+		return "*big.Rat"
+	default:
+		return ""
+	}
+}
+
+// unionWrapper declares a struct with one pointer field per non-null branch of a union.
+// A wrapper with every field nil stands for null.
+func (g *goGenerator) unionWrapper(s *avro.Schema, hint string, wrappers *[]func()) string {
+	name := g.uniqueName(hint)
+
+	var fields bytes.Buffer
+	var nullableBranch bool
+	branchNames := map[string]bool{}
+	for _, branch := range s.Types {
+		if branch.Type == avro.Null {
+			nullableBranch = true
+			continue
+		}
+		branchName := reserveName(branchNames, goBranchName(branch))
+		goType := nullable(g.goType(branch, name+branchName, wrappers))
+		fmt.Fprintf(&fields, "\t%s %s `json:\"%s,omitempty\"`\n", branchName, goType, branch.TypeName())
+	}
+
+	*wrappers = append(*wrappers, func() {
+		fmt.Fprintf(&g.body, "\n// %s holds one branch of %s; at most one field is set.\n", name, s.String())
+		if nullableBranch {
+			g.body.WriteString("// All fields are nil for null.\n")
+		}
+		fmt.Fprintf(&g.body, "type %s struct {\n%s}\n", name, fields.String())
+	})
+	return name
+}
+
+// nullable returns a Go type that can hold nil
+func nullable(goType string) string {
+	if goType == "any" || strings.HasPrefix(goType, "*") || strings.HasPrefix(goType, "[]") || strings.HasPrefix(goType, "map[") {
+		return goType
+	}
+	return "*" + goType
+}
+
+// goBranchName names the wrapper field for a union branch
+func goBranchName(s *avro.Schema) string {
+	if s.IsNamed() {
+		return goName(s.Name)
+	}
+	return goName(string(s.Type))
+}
+
+// goTypeName returns the Go name of a named type. Names written in capitals, such as
+// "MD5", are kept as they are.
+func goTypeName(s *avro.Schema) string {
+	if s.Name == strings.ToUpper(s.Name) && !strings.ContainsAny(s.Name, "_-") && !unicode.IsDigit([]rune(s.Name)[0]) {
+		return s.Name
+	}
+	return goName(s.Name)
+}
+
+// writeComment writes documentation as Go line comments
+func writeComment(buf *bytes.Buffer, indent, doc string) {
+	doc = strings.TrimSpace(doc)
+	if doc == "" {
+		return
+	}
+	for _, line := range strings.Split(doc, "\n") {
+		fmt.Fprintf(buf, "%s// %s\n", indent, strings.TrimSpace(line))
+	}
+}
+
+// commonInitialisms are written in upper case in Go identifiers
+var commonInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true,
+	"GUID": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true,
+	"QPS": true, "RAM": true, "RPC": true, "SKU": true, "SLA": true, "SMTP": true, "SQL": true,
+	"SSH": true, "TCP": true, "TLS": true, "TTL": true, "UDP": true, "UI": true, "UID": true,
+	"URI": true, "URL": true, "UTF8": true, "UUID": true, "VM": true, "XML": true,
+}
+
+// goName converts an Avro name such as "user_id", "createdAt" or "IN_PROGRESS" to an
+// exported Go identifier: "UserID", "CreatedAt", "InProgress"
+func goName(name string) string {
+	var b strings.Builder
+	for _, word := range splitWords(name) {
+		upper := strings.ToUpper(word)
+		switch {
+		case commonInitialisms[upper]:
+			b.WriteString(upper)
+		default:
+			runes := []rune(strings.ToLower(word))
+			if word != strings.ToUpper(word) {
+				// Mixed case words keep their inner capitals
+				runes = []rune(word)
+			}
+			runes[0] = unicode.ToUpper(runes[0])
+			b.WriteString(string(runes))
+		}
+	}
+
+	result := b.String()
+	if result == "" {
+		return "X"
+	}
+	if unicode.IsDigit([]rune(result)[0]) {
+		return "X" + result
+	}
+	return result
+}
+
+// splitWords splits an identifier at separators and lower-to-upper case changes
+func splitWords(name string) []string {
+	var words []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, string(current))
+			current = nil
+		}
+	}
+
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])):
+			flush()
+			current = append(current, r)
+		default:
+			current = append(current, r)
+		}
+	}
+	flush()
+	return words
+}
@@ -0,0 +1,127 @@
+package codegen
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/aywengo/ksr-cli/internal/avro"
+)
+
+func TestGo(t *testing.T) {
+	s, err := avro.Parse(`{"type":"record","name":"Order","namespace":"com.acme","doc":"An order","fields":[
+		{"name":"order_id","type":"string","doc":"Identifier"},
+		{"name":"created_at","type":{"type":"long","logicalType":"timestamp-millis"}},
+		{"name":"status","type":{"type":"enum","name":"Status","symbols":["NEW","IN_PROGRESS"]}},
+		{"name":"lines","type":{"type":"array","items":{"type":"record","name":"Line","fields":[{"name":"sku","type":"string"}]}}},
+		{"name":"note","type":["null","string"],"default":null},
+		{"name":"tags","type":["null",{"type":"array","items":"string"}],"default":null},
+		{"name":"payment","type":["null","string","Line"],"default":null},
+		{"name":"parent","type":["null","Order"],"default":null}
+	]}`)
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	code, err := Go(s, GoOptions{Package: "acme", Source: "order.avsc"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		"// Code generated by ksr-cli generate go. DO NOT EDIT.\n// Source: order.avsc\n\npackage acme\n",
+		"import (\n\t\"time\"\n)",
+		"// Order is the Avro record com.acme.Order.\n//\n// An order\ntype Order struct {",
+		"\t// Identifier\n\tOrderID   string       `avro:\"order_id\" json:\"order_id\"`",
+		"CreatedAt time.Time    `avro:\"created_at\" json:\"created_at\"`",
+		"Lines     []Line       `avro:\"lines\" json:\"lines\"`",
+		"Note      *string      `avro:\"note\" json:\"note\"`",
+		"Tags      []string     `avro:\"tags\" json:\"tags\"`",
+		"Payment   OrderPayment `avro:\"payment\" json:\"payment\"`",
+		"Parent    *Order       `avro:\"parent\" json:\"parent\"`",
+		"type OrderPayment struct {\n\tString *string `json:\"string,omitempty\"`\n\tLine   *Line   `json:\"com.acme.Line,omitempty\"`\n}",
+		"type Status string",
+		"StatusInProgress Status = \"IN_PROGRESS\"",
+		"type Line struct {\n\tSKU string `avro:\"sku\" json:\"sku\"`\n}",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("Expected generated code to contain:\n%s\ngot:\n%s", want, code)
+		}
+	}
+}
+
+func TestGo_NameCollisions(t *testing.T) {
+	s, err := avro.Parse(`{"type":"record","name":"Account","namespace":"com.acme","fields":[
+		{"name":"user_id","type":"string"},
+		{"name":"userId","type":"string"},
+		{"name":"state","type":{"type":"enum","name":"State","symbols":["IN_PROGRESS","InProgress"]}},
+		{"name":"owner","type":[
+			{"type":"record","name":"Party","namespace":"com.acme.people","fields":[{"name":"name","type":"string"}]},
+			{"type":"record","name":"Party","namespace":"com.acme.orgs","fields":[{"name":"name","type":"string"}]}
+		]},
+		{"name":"created","type":{"type":"long","logicalType":"timestamp-millis"}}
+	]}`)
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	code, err := Go(s, GoOptions{Package: "acme"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "account.go", code, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse generated code: %v\n%s", err, code)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("acme", fset, []*ast.File{file}, nil); err != nil {
+		t.Fatalf("Generated code does not compile: %v\n%s", err, code)
+	}
+
+	// Field alignment depends on the longest name, so spaces are compared collapsed
+	flat := strings.Join(strings.Fields(code), " ")
+	for _, want := range []string{
+		"UserID string `avro:\"user_id\" json:\"user_id\"`",
+		"UserID2 string `avro:\"userId\" json:\"userId\"`",
+		"StateInProgress State = \"IN_PROGRESS\"",
+		"StateInProgress2 State = \"InProgress\"",
+		"Party *Party `json:\"com.acme.people.Party,omitempty\"`",
+		"Party2 *Party2 `json:\"com.acme.orgs.Party,omitempty\"`",
+	} {
+		if !strings.Contains(flat, want) {
+			t.Errorf("Expected generated code to contain:\n%s\ngot:\n%s", want, code)
+		}
+	}
+}
+
+func TestGo_NoNamedTypes(t *testing.T) {
+	s, err := avro.Parse(`"string"`)
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	if _, err := Go(s, GoOptions{Package: "x"}); err == nil {
+		t.Error("Expected error for a schema without named types")
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"user_id":     "UserID",
+		"createdAt":   "CreatedAt",
+		"IN_PROGRESS": "InProgress",
+		"url":         "URL",
+		"2fa_enabled": "X2faEnabled",
+		"name":        "Name",
+	}
+	for input, expected := range tests {
+		if got := goName(input); got != expected {
+			t.Errorf("goName(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package compat
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/internal/avro"
+)
+
+// Avro incompatibility types, named as Schema Registry names them
+const (
+	AvroReaderFieldMissingDefault = "READER_FIELD_MISSING_DEFAULT_VALUE"
+	AvroTypeMismatch              = "TYPE_MISMATCH"
+	AvroNameMismatch              = "NAME_MISMATCH"
+	AvroFixedSizeMismatch         = "FIXED_SIZE_MISMATCH"
+	AvroMissingEnumSymbols        = "MISSING_ENUM_SYMBOLS"
+	AvroMissingUnionBranch        = "MISSING_UNION_BRANCH"
+)
+
+var avroExplanations = map[string]string{
+	AvroReaderFieldMissingDefault: "the reader expects a field the writer's data does not have, and the field has no default to fill it in",
+	AvroTypeMismatch:              "the reader's type cannot be resolved from the writer's type, not even by promotion (int to long, float or double; long to float or double; float to double; string and bytes)",
+	AvroNameMismatch:              "named types only resolve when their unqualified names match, or the reader lists the writer's name as an alias",
+	AvroFixedSizeMismatch:         "fixed types only resolve when they have the same size",
+	AvroMissingEnumSymbols:        "the writer can produce enum symbols the reader does not know, and the reader enum has no default symbol",
+	AvroMissingUnionBranch:        "the writer can produce a type that no branch of the reader's union can read",
+}
+
+// checkAvro reports the issues preventing data written with writer from being read with
+// reader, following the schema resolution rules of the Avro specification
+func checkAvro(reader, writer string) ([]Issue, error) {
+	readerSchema, err := avro.Parse(reader)
+	if err != nil {
+		return nil, err
+	}
+	writerSchema, err := avro.Parse(writer)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &avroResolver{visited: map[string]bool{}}
+	r.resolve(readerSchema, writerSchema, "/")
+	return r.issues, nil
+}
+
+type avroResolver struct {
+	issues []Issue
+	// visited holds the pairs of named types being resolved, so recursive types terminate
+	visited map[string]bool
+}
+
+func (r *avroResolver) add(issueType, path, format string, args ...interface{}) {
+	r.issues = append(r.issues, Issue{
+		Type:        issueType,
+		Path:        path,
+		Message:     fmt.Sprintf(format, args...),
+		Explanation: avroExplanations[issueType],
+	})
+}
+
+// compatible reports whether writer resolves to reader without recording issues
+func (r *avroResolver) compatible(reader, writer *avro.Schema) bool {
+	visited := make(map[string]bool, len(r.visited))
+	for key := range r.visited {
+		visited[key] = true
+	}
+	sub := &avroResolver{visited: visited}
+	sub.resolve(reader, writer, "")
+	return len(sub.issues) == 0
+}
+
+func (r *avroResolver) resolve(reader, writer *avro.Schema, path string) {
+	// Every branch the writer may have used must be readable
+	if writer.Type == avro.Union {
+		for _, branch := range writer.Types {
+			r.resolve(reader, branch, path)
+		}
+		return
+	}
+	if reader.Type == avro.Union {
+		for _, branch := range reader.Types {
+			if r.compatible(branch, writer) {
+				return
+			}
+		}
+		r.add(AvroMissingUnionBranch, path, "reader union lacking writer type: %s", writer.TypeName())
+		return
+	}
+
+	if reader.Type != writer.Type {
+		if !promotable(writer.Type, reader.Type) {
+			r.add(AvroTypeMismatch, path, "reader type: %s not compatible with writer type: %s", reader.TypeName(), writer.TypeName())
+		}
+		return
+	}
+
+	switch reader.Type {
+	case avro.Record, avro.Error:
+		if !r.namesMatch(reader, writer, path) {
+			return
+		}
+		key := reader.FullName() + "|" + writer.FullName()
+		if r.visited[key] {
+			return
+		}
+		r.visited[key] = true
+		for i, field := range reader.Fields {
+			fieldPath := joinPointer(path, fmt.Sprintf("fields/%d", i))
+			writerField := writerFieldFor(writer, field)
+			if writerField == nil {
+				if !field.HasDefault {
+					r.add(AvroReaderFieldMissingDefault, fieldPath, "%s", field.Name)
+				}
+				continue
+			}
+			r.resolve(field.Type, writerField.Type, joinPointer(fieldPath, "type"))
+		}
+	case avro.Enum:
+		if !r.namesMatch(reader, writer, path) {
+			return
+		}
+		if reader.EnumDefault != "" {
+			return
+		}
+		known := map[string]bool{}
+		for _, symbol := range reader.Symbols {
+			known[symbol] = true
+		}
+		var missing []string
+		for _, symbol := range writer.Symbols {
+			if !known[symbol] {
+				missing = append(missing, symbol)
+			}
+		}
+		if len(missing) > 0 {
+			r.add(AvroMissingEnumSymbols, joinPointer(path, "symbols"), "[%s]", strings.Join(missing, ", "))
+		}
+	case avro.Fixed:
+		if !r.namesMatch(reader, writer, path) {
+			return
+		}
+		if reader.Size != writer.Size {
+			r.add(AvroFixedSizeMismatch, joinPointer(path, "size"), "expected: %d, found: %d", writer.Size, reader.Size)
+		}
+	case avro.Array:
+		r.resolve(reader.Items, writer.Items, joinPointer(path, "items"))
+	case avro.Map:
+		r.resolve(reader.Values, writer.Values, joinPointer(path, "values"))
+	}
+}
+
+// namesMatch checks that named types resolve by unqualified name or reader alias
+func (r *avroResolver) namesMatch(reader, writer *avro.Schema, path string) bool {
+	if reader.Name == writer.Name {
+		return true
+	}
+	for _, alias := range reader.Aliases {
+		if alias == writer.Name || alias == writer.FullName() {
+			return true
+		}
+	}
+	r.add(AvroNameMismatch, joinPointer(path, "name"), "expected: %s", writer.FullName())
+	return false
+}
+
+// writerFieldFor finds the writer field a reader field reads, by name or alias
+func writerFieldFor(writer *avro.Schema, field *avro.Field) *avro.Field {
+	if f := writer.FieldByName(field.Name); f != nil {
+		return f
+	}
+	for _, alias := range field.Aliases {
+		if f := writer.FieldByName(alias); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// promotable reports whether values of the writer's primitive type can be read as the reader's
+func promotable(writer, reader avro.Type) bool {
+	switch writer {
+	case avro.Int:
+		return reader == avro.Long || reader == avro.Float || reader == avro.Double
+	case avro.Long:
+		return reader == avro.Float || reader == avro.Double
+	case avro.Float:
+		return reader == avro.Double
+	case avro.String:
+		return reader == avro.Bytes
+	case avro.Bytes:
+		return reader == avro.String
+	default:
+		return false
+	}
+}
+
+// joinPointer appends a segment to a JSON pointer into the reader schema
+func joinPointer(path, segment string) string {
+	return strings.TrimSuffix(path, "/") + "/" + segment
+}
@@ -0,0 +1,39 @@
+package compat
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/internal/jsonschema"
+)
+
+// CheckerFor returns the local compatibility checker for a schema type
+func CheckerFor(schemaType string) (Checker, error) {
+	switch strings.ToUpper(schemaType) {
+	case "", "AVRO":
+		return checkAvro, nil
+	case "JSON":
+		return checkJSONSchema, nil
+	default:
+		return nil, fmt.Errorf("local compatibility checking is not supported for schema type %s", schemaType)
+	}
+}
+
+// checkJSONSchema adapts the JSON Schema difference engine to a Checker
+func checkJSONSchema(reader, writer string) ([]Issue, error) {
+	differences, err := jsonschema.CheckCompatibility(reader, writer)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]Issue, 0, len(differences))
+	for _, diff := range differences {
+		issues = append(issues, Issue{
+			Type:        string(diff.Type),
+			Path:        diff.Path,
+			Message:     diff.Message,
+			Explanation: diff.Explanation(),
+		})
+	}
+	return issues, nil
+}
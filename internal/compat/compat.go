@@ -0,0 +1,105 @@
+package compat
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Issue describes a single incompatibility, found by a local checker or reported by the registry
+type Issue struct {
+	Type        string `json:"type"`
+	Path        string `json:"path"`
+	Field       string `json:"field,omitempty"`
+	Message     string `json:"message"`
+	Explanation string `json:"explanation,omitempty"`
+	Suggestion  string `json:"suggestion,omitempty"`
+	Direction   string `json:"direction,omitempty"`
+	Against     string `json:"against,omitempty"`
+}
+
+// Checker reports the issues preventing data written with writer from being read with reader
+type Checker func(reader, writer string) ([]Issue, error)
+
+// Schema is a previously registered schema the candidate is checked against
+type Schema struct {
+	Label   string
+	Content string
+}
+
+// Result is the outcome of a local compatibility check
+type Result struct {
+	IsCompatible bool     `json:"is_compatible"`
+	Level        string   `json:"level"`
+	Messages     []string `json:"messages,omitempty"`
+	Issues       []Issue  `json:"issues,omitempty"`
+}
+
+// Check evaluates a candidate schema against previous schemas (oldest first) under a
+// compatibility level, using the same semantics as Schema Registry:
+// BACKWARD checks that the candidate can read the previous data, FORWARD that the previous
+// schemas can read the candidate's data, FULL both, and *_TRANSITIVE against every version.
+func Check(level string, checker Checker, candidate string, previous []Schema) (*Result, error) {
+	level = strings.ToUpper(level)
+	result := &Result{IsCompatible: true, Level: level}
+
+	backward, forward, transitive, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	against := previous
+	if !transitive && len(previous) > 0 {
+		against = previous[len(previous)-1:]
+	}
+
+	for _, prev := range against {
+		if backward {
+			issues, err := checker(candidate, prev.Content)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check against %s: %w", prev.Label, err)
+			}
+			result.add(issues, "backward", prev.Label)
+		}
+		if forward {
+			issues, err := checker(prev.Content, candidate)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check against %s: %w", prev.Label, err)
+			}
+			result.add(issues, "forward", prev.Label)
+		}
+	}
+
+	return result, nil
+}
+
+// ParseLevel splits a compatibility level into the directions it checks and whether it is transitive
+func ParseLevel(level string) (backward, forward, transitive bool, err error) {
+	switch strings.ToUpper(level) {
+	case "NONE":
+		return false, false, false, nil
+	case "BACKWARD":
+		return true, false, false, nil
+	case "BACKWARD_TRANSITIVE":
+		return true, false, true, nil
+	case "FORWARD":
+		return false, true, false, nil
+	case "FORWARD_TRANSITIVE":
+		return false, true, true, nil
+	case "FULL":
+		return true, true, false, nil
+	case "FULL_TRANSITIVE":
+		return true, true, true, nil
+	default:
+		return false, false, false, fmt.Errorf("invalid compatibility level: %s", level)
+	}
+}
+
+func (r *Result) add(issues []Issue, direction, against string) {
+	for _, issue := range issues {
+		issue.Direction = direction
+		issue.Against = against
+		r.IsCompatible = false
+		r.Issues = append(r.Issues, issue)
+		r.Messages = append(r.Messages, fmt.Sprintf("%s (%s, %s): %s at %s", issue.Type, direction, against, issue.Message, issue.Path))
+	}
+}
@@ -0,0 +1,440 @@
+package compat
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCheck_Levels(t *testing.T) {
+	v1 := `{"type":"object","properties":{"id":{"type":"integer"}},"additionalProperties":false}`
+	v2 := `{"type":"object","properties":{"id":{"type":"integer"},"name":{"type":"string"}},"additionalProperties":false}`
+	// Removing "name" from a closed model breaks readers of v2 data, adding "email" is optional
+	candidate := `{"type":"object","properties":{"id":{"type":"integer"},"email":{"type":"string"}},"additionalProperties":false}`
+
+	previous := []Schema{{Label: "version 1", Content: v1}, {Label: "version 2", Content: v2}}
+
+	checker, err := CheckerFor("JSON")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		level      string
+		compatible bool
+	}{
+		{level: "NONE", compatible: true},
+		{level: "BACKWARD", compatible: false},
+		{level: "FORWARD", compatible: false},
+		{level: "FULL", compatible: false},
+		{level: "backward_transitive", compatible: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			result, err := Check(tt.level, checker, candidate, previous)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.IsCompatible != tt.compatible {
+				t.Errorf("Expected compatible=%v, got %v: %v", tt.compatible, result.IsCompatible, result.Messages)
+			}
+			for _, issue := range result.Issues {
+				if issue.Against == "" || issue.Direction == "" {
+					t.Errorf("Expected issue to record direction and version, got %+v", issue)
+				}
+			}
+		})
+	}
+}
+
+func TestCheck_TransitiveChecksAllVersions(t *testing.T) {
+	v1 := `{"type":"object","properties":{"id":{"type":"integer"},"legacy":{"type":"string"}},"additionalProperties":false}`
+	v2 := `{"type":"object","properties":{"id":{"type":"integer"}},"additionalProperties":{"type":"string"}}`
+	candidate := `{"type":"object","properties":{"id":{"type":"integer"}},"additionalProperties":false}`
+
+	checker, _ := CheckerFor("JSON")
+	previous := []Schema{{Label: "version 1", Content: v1}, {Label: "version 2", Content: v2}}
+
+	result, err := Check("BACKWARD_TRANSITIVE", checker, candidate, previous)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	againstV1 := false
+	for _, issue := range result.Issues {
+		if issue.Against == "version 1" {
+			againstV1 = true
+		}
+	}
+	if !againstV1 {
+		t.Errorf("Expected an issue against version 1, got %v", result.Messages)
+	}
+}
+
+func TestCheck_InvalidLevel(t *testing.T) {
+	checker, _ := CheckerFor("JSON")
+	if _, err := Check("SIDEWAYS", checker, `{}`, nil); err == nil {
+		t.Error("Expected error for invalid level")
+	}
+}
+
+func TestCheckerFor_UnsupportedType(t *testing.T) {
+	if _, err := CheckerFor("UNKNOWN"); err == nil {
+		t.Error("Expected error for unsupported schema type")
+	}
+}
+
+func TestEvaluateLevels(t *testing.T) {
+	// Compatible with the latest version but not with version 1, under BACKWARD
+	verdicts := []VersionVerdict{{Version: 1, Compatible: false}, {Version: 2, Compatible: true}}
+
+	summarize := func(levels []LevelVerdict) map[string]string {
+		summary := map[string]string{}
+		for _, level := range levels {
+			summary[level.Level] = level.Satisfied
+		}
+		return summary
+	}
+
+	levels, err := EvaluateLevels("BACKWARD", verdicts, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]string{
+		"NONE":                Satisfied,
+		"BACKWARD":            Satisfied,
+		"BACKWARD_TRANSITIVE": Unsatisfied,
+		"FORWARD":             Unknown,
+		"FORWARD_TRANSITIVE":  Unknown,
+		"FULL":                Unknown,
+		"FULL_TRANSITIVE":     Unsatisfied,
+	}
+	if got := summarize(levels); len(got) != len(expected) {
+		t.Fatalf("Expected %d levels, got %v", len(expected), got)
+	} else {
+		for level, satisfied := range expected {
+			if got[level] != satisfied {
+				t.Errorf("Expected %s to be %s, got %s", level, satisfied, got[level])
+			}
+		}
+	}
+	if levels[2].Reason != "incompatible with version 1" {
+		t.Errorf("Unexpected reason %q", levels[2].Reason)
+	}
+
+	// FULL passing everywhere implies the weaker levels; a local checker decides nothing then
+	allPass := []VersionVerdict{{Version: 1, Compatible: true}, {Version: 2, Compatible: true}}
+	levels, err = EvaluateLevels("FULL_TRANSITIVE", allPass, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, level := range levels {
+		if level.Satisfied != Satisfied {
+			t.Errorf("Expected %s to be satisfied, got %+v", level.Level, level)
+		}
+	}
+
+	// Levels the registry verdicts do not decide go to the local checker
+	local := func(level string) (bool, error) { return level == "FORWARD", nil }
+	levels, err = EvaluateLevels("BACKWARD", verdicts, local)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got := summarize(levels)
+	if got["FORWARD"] != Satisfied || got["FULL"] != Unsatisfied || levels[3].Source != "local" {
+		t.Errorf("Expected local verdicts for the forward levels, got %+v", levels)
+	}
+
+	if _, err := EvaluateLevels("SIDEWAYS", verdicts, nil); err == nil {
+		t.Error("Expected error for an invalid level")
+	}
+}
+
+func TestCheckAvro(t *testing.T) {
+	v1 := `{"type":"record","name":"User","fields":[
+		{"name":"id","type":"int"},
+		{"name":"status","type":{"type":"enum","name":"Status","symbols":["NEW","DONE"]}}
+	]}`
+
+	tests := []struct {
+		name     string
+		reader   string
+		writer   string
+		expected string
+	}{
+		{"identical", v1, v1, ""},
+		{"added field with default", `{"type":"record","name":"User","fields":[
+			{"name":"id","type":"int"},
+			{"name":"email","type":["null","string"],"default":null}
+		]}`, v1, ""},
+		{"added field without default", `{"type":"record","name":"User","fields":[
+			{"name":"id","type":"int"},
+			{"name":"email","type":"string"}
+		]}`, v1, "READER_FIELD_MISSING_DEFAULT_VALUE at /fields/1"},
+		{"promotion", `{"type":"record","name":"User","fields":[{"name":"id","type":"long"}]}`, v1, ""},
+		{"narrowing", v1, `{"type":"record","name":"User","fields":[
+			{"name":"id","type":"long"},
+			{"name":"status","type":{"type":"enum","name":"Status","symbols":["NEW","DONE"]}}
+		]}`, "TYPE_MISMATCH at /fields/0/type"},
+		{"renamed with alias", `{"type":"record","name":"Account","aliases":["User"],"fields":[{"name":"key","aliases":["id"],"type":"int"}]}`, v1, ""},
+		{"renamed record", `{"type":"record","name":"Account","fields":[]}`, v1, "NAME_MISMATCH at /name"},
+		{"enum symbol added by writer", v1, `{"type":"record","name":"User","fields":[
+			{"name":"id","type":"int"},
+			{"name":"status","type":{"type":"enum","name":"Status","symbols":["NEW","DONE","LOST"]}}
+		]}`, "MISSING_ENUM_SYMBOLS at /fields/1/type/symbols"},
+		{"enum default", `{"type":"record","name":"User","fields":[
+			{"name":"status","type":{"type":"enum","name":"Status","symbols":["NEW","DONE"],"default":"NEW"}}
+		]}`, `{"type":"record","name":"User","fields":[
+			{"name":"status","type":{"type":"enum","name":"Status","symbols":["NEW","DONE","LOST"]}}
+		]}`, ""},
+		{"optional to required", `{"type":"array","items":"string"}`, `{"type":"array","items":["null","string"]}`, "TYPE_MISMATCH at /items"},
+		{"union lacking branch", `{"type":"map","values":["null","string"]}`, `{"type":"map","values":"int"}`, "MISSING_UNION_BRANCH at /values"},
+		{"fixed size", `{"type":"fixed","name":"Hash","size":16}`, `{"type":"fixed","name":"Hash","size":32}`, "FIXED_SIZE_MISMATCH at /size"},
+		{"recursive", `{"type":"record","name":"Node","fields":[{"name":"next","type":["null","Node"],"default":null}]}`,
+			`{"type":"record","name":"Node","fields":[{"name":"next","type":["null","Node"],"default":null}]}`, ""},
+	}
+
+	checker, err := CheckerFor("AVRO")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := checker(tt.reader, tt.writer)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var got []string
+			for _, issue := range issues {
+				got = append(got, issue.Type+" at "+issue.Path)
+				if issue.Explanation == "" {
+					t.Errorf("Expected an explanation for %s", issue.Type)
+				}
+			}
+			if strings.Join(got, "; ") != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, strings.Join(got, "; "))
+			}
+		})
+	}
+}
+
+func TestCheckHistory(t *testing.T) {
+	contents := map[int]string{
+		1: `{"type":"record","name":"User","fields":[{"name":"id","type":"int"}]}`,
+		// Adds a field with a default: compatible both ways
+		2: `{"type":"record","name":"User","fields":[{"name":"id","type":"int"},{"name":"age","type":"int","default":0}]}`,
+		// Removes id: readers of versions 1 and 2 miss it
+		3: `{"type":"record","name":"User","fields":[{"name":"age","type":"int","default":0}]}`,
+	}
+	checker, _ := CheckerFor("AVRO")
+	versions := []int{1, 2, 3}
+
+	summarize := func(pairs []HistoryPair) string {
+		var parts []string
+		for _, pair := range pairs {
+			parts = append(parts, fmt.Sprintf("%d>%d:%s", pair.Version, pair.Against, pair.Satisfied))
+		}
+		return strings.Join(parts, " ")
+	}
+
+	tests := []struct {
+		level    string
+		expected string
+	}{
+		{"NONE", ""},
+		{"BACKWARD", "2>1:yes 3>2:yes"},
+		{"FORWARD_TRANSITIVE", "2>1:yes 3>1:no 3>2:no"},
+		{"FULL", "2>1:yes 3>2:no"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			pairs, err := CheckHistory(tt.level, versions, LocalPairChecker(checker, contents))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := summarize(pairs); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	pairs, _ := CheckHistory("FORWARD", versions, LocalPairChecker(checker, contents))
+	if len(pairs[1].Issues) != 1 || pairs[1].Issues[0].Type != AvroReaderFieldMissingDefault || pairs[1].Issues[0].Direction != "forward" {
+		t.Errorf("Expected a missing default issue read forward, got %+v", pairs[1].Issues)
+	}
+
+	// The registry under BACKWARD rejects 3 against 1 only; FULL_TRANSITIVE follows from that
+	registry := RegistryPairChecker("BACKWARD", func(version, against int) (bool, []string, error) {
+		if version == 3 && against == 1 {
+			return false, []string{"READER_FIELD_MISSING_DEFAULT_VALUE"}, nil
+		}
+		return true, nil, nil
+	})
+	pairs, err := CheckHistory("FULL_TRANSITIVE", versions, registry)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := summarize(pairs); got != "2>1:unknown 3>1:no 3>2:unknown" {
+		t.Errorf("Unexpected registry verdicts %q", got)
+	}
+	if len(pairs[1].Messages) != 1 || pairs[0].Reason == "" {
+		t.Errorf("Expected messages for the failing pair and a reason for unknown ones, got %+v", pairs)
+	}
+}
+
+func TestParseMessages(t *testing.T) {
+	issues := ParseMessages([]string{
+		`{errorType:'READER_FIELD_MISSING_DEFAULT_VALUE', description:'The field 'email' at path '/fields/2' in the new schema has no default value and is missing in the old schema', additionalInfo:'email'}`,
+		`{errorType:'READER_FIELD_MISSING_DEFAULT_VALUE', description:'The field 'age' at path '/fields/1' in the old schema has no default value and is missing in the new schema', additionalInfo:'age'}`,
+		`{oldSchemaVersion: 3}`,
+		`{oldSchema: '{"type":"record","name":"User","fields":[]}'}`,
+		`{validateFields: 'false', compatibility: 'FULL'}`,
+		`Incompatibility{type:MISSING_ENUM_SYMBOLS, location:/fields/0/type/symbols, message:[C], reader:{"type":"enum"}, writer:{"type":"enum"}}`,
+		`Found incompatible change: Difference{jsonPath='#/properties/age', type=TYPE_CHANGED}`,
+		`Schema being registered is incompatible with an earlier schema`,
+	})
+	if len(issues) != 5 {
+		t.Fatalf("Expected 5 issues, got %d: %+v", len(issues), issues)
+	}
+
+	expected := []Issue{
+		{Type: AvroReaderFieldMissingDefault, Path: "/fields/2", Message: "The field 'email' at path '/fields/2' in the new schema has no default value and is missing in the old schema", Against: "version 3"},
+		{Type: AvroReaderFieldMissingDefault, Path: "/fields/1", Direction: "forward", Against: "version 3"},
+		{Type: AvroMissingEnumSymbols, Path: "/fields/0/type/symbols", Message: "[C]", Against: "version 3"},
+		{Type: "TYPE_CHANGED", Path: "#/properties/age", Against: "version 3"},
+		{Message: "Schema being registered is incompatible with an earlier schema", Against: "version 3"},
+	}
+	for i, want := range expected {
+		got := issues[i]
+		if got.Type != want.Type || got.Path != want.Path || got.Direction != want.Direction || got.Against != want.Against {
+			t.Errorf("Issue %d: expected %+v, got %+v", i, want, got)
+		}
+		if want.Message != "" && got.Message != want.Message {
+			t.Errorf("Issue %d: expected message %q, got %q", i, want.Message, got.Message)
+		}
+	}
+}
+
+func TestAnnotateAndFix(t *testing.T) {
+	candidate := `{
+  "type": "record",
+  "name": "Order",
+  "namespace": "com.acme",
+  "fields": [
+    {"name": "id", "type": "string"},
+    {"name": "customer", "type": {"type": "record", "name": "Client", "fields": [
+      {"name": "email", "type": "string"},
+      {"name": "tier", "type": {"type": "enum", "name": "Tier", "symbols": ["GOLD"]}}
+    ]}},
+    {"name": "note", "type": ["string", "null"]},
+    {"name": "amount", "type": ["int", "long"]}
+  ]
+}`
+	issues := Annotate([]Issue{
+		{Type: AvroReaderFieldMissingDefault, Path: "/fields/1/type/fields/0", Message: "email"},
+		{Type: AvroReaderFieldMissingDefault, Path: "/fields/2", Message: "note"},
+		{Type: AvroMissingEnumSymbols, Path: "/fields/1/type/fields/1/type/symbols", Message: "[SILVER, GOLD]"},
+		{Type: AvroNameMismatch, Path: "/fields/1/type/name", Message: "expected: com.acme.Customer"},
+		{Type: AvroMissingUnionBranch, Path: "/fields/3/type", Message: "reader union lacking writer type: DOUBLE"},
+		{Type: AvroTypeMismatch, Path: "/fields/0/type", Message: "reader type: STRING not compatible with writer type: INT"},
+		{Type: AvroReaderFieldMissingDefault, Path: "/fields/4", Message: "gone", Direction: "forward"},
+	}, "AVRO", candidate)
+
+	fields := []string{"customer.email", "note", "customer.tier", "customer", "amount", "id", ""}
+	for i, field := range fields {
+		if issues[i].Field != field {
+			t.Errorf("Issue %d: expected field %q, got %q", i, field, issues[i].Field)
+		}
+		if issues[i].Explanation == "" || issues[i].Suggestion == "" {
+			t.Errorf("Issue %d: expected an explanation and a suggestion, got %+v", i, issues[i])
+		}
+	}
+	if !strings.Contains(issues[0].Suggestion, "customer.email") {
+		t.Errorf("Expected the suggestion to name the field, got %q", issues[0].Suggestion)
+	}
+
+	patched, applied, err := Fix("AVRO", candidate, issues)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(applied) != 5 {
+		t.Fatalf("Expected 5 fixes, got %v", applied)
+	}
+	for _, fragment := range []string{
+		`"name": "email", "type": ["null", "string"], "default": null`,
+		`"name": "note", "type": ["null", "string"], "default": null`,
+		`"symbols": ["GOLD", "SILVER"]`,
+		`"name": "Client",` + "\n",
+		`"aliases": ["com.acme.Customer"]`,
+		`"type": ["int", "long", "double"]`,
+	} {
+		if !strings.Contains(compactJSON(patched), compactJSON(fragment)) {
+			t.Errorf("Expected %s in the patched schema:\n%s", fragment, patched)
+		}
+	}
+
+	// Fixing the issues found round by round resolves them: the enum is only checked once
+	// the record name matches
+	writer := `{"type":"record","name":"Customer","namespace":"com.acme","fields":[{"name":"tier","type":{"type":"enum","name":"Tier","symbols":["GOLD","SILVER"]}}]}`
+	reader := `{"type":"record","name":"Client","namespace":"com.acme","fields":[{"name":"tier","type":{"type":"enum","name":"Tier","symbols":["GOLD"]}}]}`
+	for round := 1; ; round++ {
+		found, _ := checkAvro(reader, writer)
+		if len(found) == 0 {
+			if round != 3 {
+				t.Errorf("Expected two rounds of fixes, needed %d", round-1)
+			}
+			break
+		}
+		var applied []string
+		reader, applied, _ = Fix("AVRO", reader, Annotate(found, "AVRO", reader))
+		if len(applied) == 0 {
+			t.Fatalf("Round %d: no fix for %+v", round, found)
+		}
+	}
+
+	if _, applied, _ := Fix("JSON", `{"type":"object"}`, issues); len(applied) != 0 {
+		t.Errorf("Expected no fixes for JSON Schema, got %v", applied)
+	}
+}
+
+// compactJSON strips whitespace so fragments match regardless of layout
+func compactJSON(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
+
+func TestAnnotate_ForwardRemovedField(t *testing.T) {
+	previous := `{"type":"record","name":"User","fields":[{"name":"id","type":"string"},{"name":"address","type":{"type":"record","name":"Address","fields":[{"name":"zip","type":"string"}]}}]}`
+	candidate := `{"type":"record","name":"User","fields":[{"name":"id","type":"string"},{"name":"address","type":{"type":"record","name":"Address","fields":[]}}]}`
+
+	// Local check: the removed field is resolved against the previous version
+	against := []Schema{{Label: "version 1", Content: previous}}
+	result, err := Check("FORWARD", checkAvro, candidate, against)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Issues) != 1 || result.Issues[0].Direction != "forward" {
+		t.Fatalf("Expected one forward issue, got %+v", result.Issues)
+	}
+	issues := Annotate(result.Issues, "AVRO", candidate, against...)
+	if issues[0].Field != "address.zip" || !strings.Contains(issues[0].Suggestion, "keep address.zip in the new schema") {
+		t.Errorf("Expected the removed field by name, got field %q, suggestion %q", issues[0].Field, issues[0].Suggestion)
+	}
+
+	// Registry check: the previous schema comes from the verbose messages
+	messages := []string{
+		`{errorType:'READER_FIELD_MISSING_DEFAULT_VALUE', description:'The field 'zip' at path '/fields/1/type/fields/0' in the old schema has no default value and is missing in the new schema', additionalInfo:'zip'}`,
+		`{oldSchemaVersion: 1}`,
+		`{oldSchema: '` + previous + `'}`,
+	}
+	issues = Annotate(ParseMessages(messages), "AVRO", candidate, PreviousSchemas(messages)...)
+	if issues[0].Field != "address.zip" {
+		t.Errorf("Expected the field resolved against the old schema, got %q", issues[0].Field)
+	}
+
+	// Without the previous schema, the field named in the message is used
+	issues = Annotate(ParseMessages(messages[:1]), "AVRO", candidate)
+	if issues[0].Field != "zip" || strings.Contains(issues[0].Suggestion, "/fields") {
+		t.Errorf("Expected the field named in the message, got field %q, suggestion %q", issues[0].Field, issues[0].Suggestion)
+	}
+}
@@ -0,0 +1,543 @@
+package compat
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/internal/avro"
+	"github.com/aywengo/ksr-cli/internal/jsonfmt"
+	"github.com/aywengo/ksr-cli/internal/jsonschema"
+)
+
+// Patterns of the incompatibility messages returned by Schema Registry: the current
+// format ({errorType:'...', description:'...', additionalInfo:'...'}) and the older
+// Avro (Incompatibility{type:..., location:..., message:...}) and JSON Schema or
+// Protobuf (Difference{jsonPath='...', type=...}) formats
+var (
+	messageTypePattern       = regexp.MustCompile(`errorType:'([A-Z_]+)'|\btype[:=]([A-Z_]+)`)
+	messagePathPattern       = regexp.MustCompile(`at path '([^']*)'|location:([^,}]+)|(?:jsonPath|fullPath)='([^']*)'`)
+	messageDescPattern       = regexp.MustCompile(`description:'(.*?)'(?:, [A-Za-z]+:|\}$)`)
+	messageInfoPattern       = regexp.MustCompile(`additionalInfo:'(.*?)'(?:, [A-Za-z]+:|\}$)|message:([^,}]+)`)
+	messagePathSchemaPattern = regexp.MustCompile(`at path '[^']*' in the (new|old) schema`)
+	messageVersionPattern    = regexp.MustCompile(`oldSchemaVersion: ?(\d+)`)
+	messageFieldPattern      = regexp.MustCompile(`[Ff]ield '([^']+)'`)
+	bracketPattern           = regexp.MustCompile(`\[([^\]]*)\]`)
+	unionBranchPattern       = regexp.MustCompile(`writer type: ?([A-Za-z0-9_.]+)`)
+	expectedNamePattern      = regexp.MustCompile(`expected: ?([A-Za-z0-9_.]+)`)
+)
+
+// Protobuf incompatibility types reported by Schema Registry
+var protobufExplanations = map[string]string{
+	"PACKAGE_CHANGED":                "the package changed, so the fully qualified names of all messages changed",
+	"MESSAGE_REMOVED":                "a message type used by existing data was removed",
+	"FIELD_KIND_CHANGED":             "a field changed between scalar, message and enum kinds, so its wire encoding changed",
+	"FIELD_SCALAR_KIND_CHANGED":      "a scalar field changed to a type with a different wire encoding",
+	"FIELD_NAMED_TYPE_CHANGED":       "a field now refers to a different message or enum type",
+	"REQUIRED_FIELD_ADDED":           "a required field was added, which data written before it lacks",
+	"REQUIRED_FIELD_REMOVED":         "a required field was removed, which old readers still expect",
+	"ONEOF_FIELD_REMOVED":            "a field was removed from a oneof, so values set in it can no longer be read",
+	"MULTIPLE_FIELDS_MOVED_TO_ONEOF": "several existing fields were moved into one oneof, where only one of them can be set",
+	"FIELD_MOVED_TO_EXISTING_ONEOF":  "an existing field was moved into a oneof that already existed",
+	"SYNTAX_CHANGED":                 "the syntax changed between proto2 and proto3",
+}
+
+// suggestions proposes a change for each incompatibility type; %s is the offending field
+var suggestions = map[string]string{
+	AvroReaderFieldMissingDefault: "add a default to %s, e.g. make it optional with type [\"null\", ...] and default null",
+	AvroTypeMismatch:              "keep the previous type of %s, or add a new field with the new type and deprecate the old one",
+	AvroNameMismatch:              "keep the previous name of %s, or list the previous full name in its aliases",
+	AvroFixedSizeMismatch:         "keep the previous size of %s, or add a new field with the new size",
+	AvroMissingEnumSymbols:        "add the missing symbols to %s, or give the enum a default symbol",
+	AvroMissingUnionBranch:        "add the missing type to the union of %s",
+
+	string(jsonschema.TypeNarrowed):                              "keep accepting the previous types in %s",
+	string(jsonschema.TypeChanged):                               "keep the previous type of %s, or add a new property with the new type",
+	string(jsonschema.RequiredAttributeAdded):                    "make %s optional, or give it a default",
+	string(jsonschema.RequiredPropertyAddedToUnopenContentModel): "make %s optional, or give it a default",
+	string(jsonschema.PropertyAddedToOpenContentModel):           "declare %s with a schema that accepts any value existing data may hold in it, or close the content model",
+	string(jsonschema.PropertyRemovedFromClosedContentModel):     "keep %s in the schema, possibly deprecated",
+	string(jsonschema.AdditionalPropertiesRemoved):               "keep allowing additional properties in %s",
+	string(jsonschema.AdditionalPropertiesNarrowed):              "keep the previous additionalProperties of %s",
+	string(jsonschema.EnumArrayNarrowed):                         "keep the removed values in the enum of %s",
+
+	"MESSAGE_REMOVED":                "keep the message %s, possibly deprecated",
+	"FIELD_KIND_CHANGED":             "keep the previous type of %s, or add a new field with a new number",
+	"FIELD_SCALAR_KIND_CHANGED":      "keep the previous type of %s, or add a new field with a new number",
+	"FIELD_NAMED_TYPE_CHANGED":       "keep the previous type of %s, or add a new field with a new number",
+	"REQUIRED_FIELD_ADDED":           "make %s optional",
+	"REQUIRED_FIELD_REMOVED":         "keep %s, or make it optional first",
+	"ONEOF_FIELD_REMOVED":            "keep %s in its oneof, possibly deprecated",
+	"MULTIPLE_FIELDS_MOVED_TO_ONEOF": "keep the existing fields out of the oneof at %s",
+	"PACKAGE_CHANGED":                "keep the previous package",
+}
+
+// constraintSuggestions propose changes for the JSON Schema constraint types, by suffix
+var constraintSuggestions = map[string]string{
+	"_ADDED":     "remove the new constraint from %s",
+	"_DECREASED": "keep the previous limit of %s",
+	"_INCREASED": "keep the previous limit of %s",
+	"_NARROWED":  "keep accepting the previous values in %s",
+	"_CHANGED":   "keep the previous constraint of %s",
+}
+
+// ParseMessages turns the incompatibility messages of a registry compatibility check into
+// issues. Messages carrying only context, such as the old schema version, are folded into
+// the issues; messages in an unknown format are kept as issues with only a message.
+func ParseMessages(messages []string) []Issue {
+	var issues []Issue
+	against := ""
+	for _, msg := range messages {
+		if m := messageVersionPattern.FindStringSubmatch(msg); m != nil {
+			against = "version " + m[1]
+			continue
+		}
+		if strings.HasPrefix(msg, "{oldSchema:") || strings.HasPrefix(msg, "{validateFields:") || strings.HasPrefix(msg, "{compatibility:") {
+			continue
+		}
+
+		issue := Issue{Message: msg}
+		if m := messageTypePattern.FindStringSubmatch(msg); m != nil {
+			issue.Type = firstGroup(m)
+		}
+		if m := messagePathPattern.FindStringSubmatch(msg); m != nil {
+			issue.Path = strings.TrimSpace(firstGroup(m))
+		}
+		if m := messagePathSchemaPattern.FindStringSubmatch(msg); m != nil && m[1] == "old" {
+			issue.Direction = "forward"
+		}
+		var info string
+		if m := messageInfoPattern.FindStringSubmatch(msg); m != nil && issue.Type != "" {
+			info = strings.TrimSpace(firstGroup(m))
+		}
+		if m := messageDescPattern.FindStringSubmatch(msg); m != nil {
+			issue.Message = m[1]
+			if info != "" && !strings.Contains(issue.Message, info) {
+				issue.Message += " (" + info + ")"
+			}
+		} else if info != "" {
+			issue.Message = info
+		}
+		issues = append(issues, issue)
+	}
+
+	for i := range issues {
+		issues[i].Against = against
+	}
+	return issues
+}
+
+func firstGroup(match []string) string {
+	for _, group := range match[1:] {
+		if group != "" {
+			return group
+		}
+	}
+	return ""
+}
+
+// Annotate completes issues with the field they concern, an explanation when they have
+// none and a suggested change. Fields are resolved against the candidate schema for
+// issues found in it, and against the previous schema they were checked against for
+// issues read forward (found in the previous schema): the one labelled as the issue's
+// Against, or the only one given. Forward issues without a previous schema fall back to
+// the field named in their message.
+func Annotate(issues []Issue, schemaType, candidate string, previous ...Schema) []Issue {
+	root := decodeSchema(schemaType, candidate)
+	previousRoots := map[string]interface{}{}
+	for _, schema := range previous {
+		previousRoots[schema.Label] = decodeSchema(schemaType, schema.Content)
+	}
+
+	annotated := make([]Issue, len(issues))
+	for i, issue := range issues {
+		if issue.Field == "" && issue.Path != "" {
+			if issue.Direction == "forward" {
+				previousRoot, ok := previousRoots[issue.Against]
+				if !ok && len(previous) == 1 {
+					previousRoot = previousRoots[previous[0].Label]
+				}
+				if previousRoot != nil {
+					issue.Field = fieldFromPointer(previousRoot, issue.Path)
+				}
+				if issue.Field == "" {
+					if m := messageFieldPattern.FindStringSubmatch(issue.Message); m != nil {
+						issue.Field = m[1]
+					}
+				}
+			} else {
+				issue.Field = fieldFromPointer(root, issue.Path)
+			}
+		}
+		if issue.Explanation == "" {
+			issue.Explanation = explanationFor(issue.Type)
+		}
+		if issue.Suggestion == "" {
+			issue.Suggestion = suggestionFor(issue)
+		}
+		annotated[i] = issue
+	}
+	return annotated
+}
+
+// decodeSchema decodes JSON schema content for resolving pointers, or returns nil
+func decodeSchema(schemaType, content string) interface{} {
+	if content == "" || strings.EqualFold(schemaType, "PROTOBUF") {
+		return nil
+	}
+	value, err := jsonfmt.Decode([]byte(content))
+	if err != nil {
+		return nil
+	}
+	return value
+}
+
+// PreviousSchemas returns the schema a registry compatibility check compared against, as
+// given in its verbose messages, labelled like the Against of the parsed issues
+func PreviousSchemas(messages []string) []Schema {
+	against := ""
+	content := ""
+	for _, msg := range messages {
+		if m := messageVersionPattern.FindStringSubmatch(msg); m != nil {
+			against = "version " + m[1]
+		}
+		if strings.HasPrefix(msg, "{oldSchema: '") && strings.HasSuffix(msg, "'}") {
+			content = strings.TrimSuffix(strings.TrimPrefix(msg, "{oldSchema: '"), "'}")
+		}
+	}
+	if content == "" {
+		return nil
+	}
+	return []Schema{{Label: against, Content: content}}
+}
+
+func explanationFor(issueType string) string {
+	if issueType == "" {
+		return ""
+	}
+	if text, ok := avroExplanations[issueType]; ok {
+		return text
+	}
+	if text, ok := protobufExplanations[issueType]; ok {
+		return text
+	}
+	if text := (jsonschema.Difference{Type: jsonschema.DifferenceType(issueType)}).Explanation(); text != "incompatible change" {
+		return text
+	}
+	return ""
+}
+
+func suggestionFor(issue Issue) string {
+	field := issue.Field
+	if field == "" {
+		field = "the field"
+		if issue.Path != "" {
+			field = issue.Path
+		}
+	}
+
+	template, ok := suggestions[issue.Type]
+	if !ok {
+		for suffix, text := range constraintSuggestions {
+			if strings.HasSuffix(issue.Type, suffix) && (strings.HasPrefix(issue.Type, "MAX_") || strings.HasPrefix(issue.Type, "MIN_") ||
+				strings.HasPrefix(issue.Type, "EXCLUSIVE_") || strings.HasPrefix(issue.Type, "PATTERN_") || strings.HasPrefix(issue.Type, "MULTIPLE_OF_")) {
+				template, ok = text, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return ""
+	}
+	if issue.Direction == "forward" && issue.Type == AvroReaderFieldMissingDefault {
+		return fmt.Sprintf("keep %s in the new schema, or add a default to it in the previous versions", field)
+	}
+	if strings.Contains(template, "%s") {
+		return fmt.Sprintf(template, field)
+	}
+	return template
+}
+
+// fieldFromPointer turns a JSON pointer into a schema into a field path ("customer.email",
+// "items[].sku"). Avro field indexes are resolved to names with the schema when given;
+// JSON Schema and Protobuf pointers carry the names. Unresolvable pointers give "".
+func fieldFromPointer(root interface{}, pointer string) string {
+	tokens := pointerTokens(pointer)
+	var parts []string
+	node := root
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		switch token {
+		case "fields":
+			if i+1 < len(tokens) {
+				i++
+				field := pointerChild(node, "fields")
+				node = pointerChild(field, tokens[i])
+				name, ok := pointerChild(node, "name").(string)
+				if !ok {
+					return ""
+				}
+				parts = append(parts, name)
+			}
+			continue
+		case "properties":
+			if i+1 < len(tokens) {
+				i++
+				node = pointerChild(pointerChild(node, "properties"), tokens[i])
+				parts = append(parts, tokens[i])
+			}
+			continue
+		case "items":
+			appendSuffix(&parts, "[]")
+		case "values", "additionalProperties":
+			appendSuffix(&parts, "{}")
+		case "type", "symbols", "name", "size", "default":
+		default:
+			if root == nil && !isIndex(token) {
+				parts = append(parts, token)
+			}
+		}
+		node = pointerChild(node, token)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, ".")
+}
+
+func appendSuffix(parts *[]string, suffix string) {
+	if len(*parts) == 0 {
+		*parts = append(*parts, suffix)
+		return
+	}
+	(*parts)[len(*parts)-1] += suffix
+}
+
+// pointerTokens splits a JSON pointer, with or without a leading "#"
+func pointerTokens(pointer string) []string {
+	pointer = strings.TrimPrefix(strings.TrimPrefix(pointer, "#"), "/")
+	if pointer == "" {
+		return nil
+	}
+	tokens := strings.Split(pointer, "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens
+}
+
+// pointerChild returns a member of an object or an element of an array, or nil
+func pointerChild(node interface{}, token string) interface{} {
+	switch v := node.(type) {
+	case *jsonfmt.Object:
+		value, _ := v.Get(token)
+		return value
+	case []interface{}:
+		if index, err := strconv.Atoi(token); err == nil && index >= 0 && index < len(v) {
+			return v[index]
+		}
+	}
+	return nil
+}
+
+func isIndex(token string) bool {
+	_, err := strconv.Atoi(token)
+	return err == nil
+}
+
+// Fix applies the mechanical fixes of issues to an Avro candidate schema and returns the
+// patched schema and a description of each fix. Issues found in the previous schemas
+// (read forward) and issues needing a decision, such as a changed type, are left alone.
+func Fix(schemaType, candidate string, issues []Issue) (string, []string, error) {
+	if t := strings.ToUpper(schemaType); t != "" && t != "AVRO" {
+		return candidate, nil, nil
+	}
+	root, err := jsonfmt.Decode([]byte(candidate))
+	if err != nil {
+		return "", nil, err
+	}
+
+	var applied []string
+	done := map[string]bool{}
+	for _, issue := range issues {
+		if issue.Direction == "forward" || issue.Path == "" || done[issue.Type+issue.Path] {
+			continue
+		}
+		var fixed string
+		switch issue.Type {
+		case AvroReaderFieldMissingDefault:
+			fixed = fixMissingDefault(root, issue)
+		case AvroMissingEnumSymbols:
+			fixed = fixEnumSymbols(root, issue)
+		case AvroNameMismatch:
+			fixed = fixNameMismatch(root, issue)
+		case AvroMissingUnionBranch:
+			fixed = fixUnionBranch(root, issue)
+		}
+		if fixed != "" {
+			done[issue.Type+issue.Path] = true
+			applied = append(applied, fixed)
+		}
+	}
+	if len(applied) == 0 {
+		return candidate, nil, nil
+	}
+	return jsonfmt.Marshal(root), applied, nil
+}
+
+// resolvePointer returns the value a pointer designates, or nil
+func resolvePointer(root interface{}, pointer string) interface{} {
+	node := root
+	for _, token := range pointerTokens(pointer) {
+		node = pointerChild(node, token)
+	}
+	return node
+}
+
+// fixMissingDefault makes a field optional with a null default
+func fixMissingDefault(root interface{}, issue Issue) string {
+	field, ok := resolvePointer(root, issue.Path).(*jsonfmt.Object)
+	if !ok {
+		return ""
+	}
+	if _, has := field.Get("default"); has {
+		return ""
+	}
+	typ, _ := field.Get("type")
+	union, isUnion := typ.([]interface{})
+	if !isUnion {
+		union = []interface{}{typ}
+	}
+	branches := []interface{}{"null"}
+	for _, branch := range union {
+		if branch != "null" {
+			branches = append(branches, branch)
+		}
+	}
+	field.Set("type", branches)
+	insertAfter(field, "type", "default", nil)
+	return fmt.Sprintf("made %s optional with default null", fieldName(root, issue))
+}
+
+// fixEnumSymbols appends the symbols the enum is missing
+func fixEnumSymbols(root interface{}, issue Issue) string {
+	symbols, ok := resolvePointer(root, issue.Path).([]interface{})
+	if !ok {
+		return ""
+	}
+	m := bracketPattern.FindStringSubmatch(issue.Message)
+	if m == nil {
+		return ""
+	}
+	known := map[interface{}]bool{}
+	for _, symbol := range symbols {
+		known[symbol] = true
+	}
+	var added []string
+	for _, symbol := range strings.Split(m[1], ",") {
+		symbol = strings.TrimSpace(symbol)
+		if symbol != "" && !known[symbol] {
+			symbols = append(symbols, symbol)
+			added = append(added, symbol)
+		}
+	}
+	if len(added) == 0 {
+		return ""
+	}
+	tokens := pointerTokens(issue.Path)
+	if enum, ok := resolvePointer(root, "/"+strings.Join(tokens[:len(tokens)-1], "/")).(*jsonfmt.Object); ok {
+		enum.Set("symbols", symbols)
+	} else {
+		return ""
+	}
+	return fmt.Sprintf("added symbols %s to %s", strings.Join(added, ", "), fieldName(root, issue))
+}
+
+// fixNameMismatch lists the previous name in the aliases of the renamed type
+func fixNameMismatch(root interface{}, issue Issue) string {
+	m := expectedNamePattern.FindStringSubmatch(issue.Message)
+	if m == nil {
+		return ""
+	}
+	tokens := pointerTokens(issue.Path)
+	if len(tokens) > 0 && tokens[len(tokens)-1] == "name" {
+		tokens = tokens[:len(tokens)-1]
+	}
+	named, ok := resolvePointer(root, "/"+strings.Join(tokens, "/")).(*jsonfmt.Object)
+	if !ok {
+		return ""
+	}
+	aliases, _ := named.Get("aliases")
+	list, _ := aliases.([]interface{})
+	for _, alias := range list {
+		if alias == m[1] {
+			return ""
+		}
+	}
+	if len(list) == 0 {
+		after := "name"
+		if _, ok := named.Get("namespace"); ok {
+			after = "namespace"
+		}
+		insertAfter(named, after, "aliases", []interface{}{m[1]})
+	} else {
+		named.Set("aliases", append(list, m[1]))
+	}
+	name, _ := named.Get("name")
+	return fmt.Sprintf("added alias %s to %v", m[1], name)
+}
+
+// fixUnionBranch adds a missing primitive type to a union
+func fixUnionBranch(root interface{}, issue Issue) string {
+	m := unionBranchPattern.FindStringSubmatch(issue.Message)
+	if m == nil {
+		return ""
+	}
+	branch := strings.ToLower(m[1])
+	if !avro.IsPrimitive(avro.Type(branch)) {
+		return ""
+	}
+	tokens := pointerTokens(issue.Path)
+	if len(tokens) == 0 {
+		return ""
+	}
+	parent := resolvePointer(root, "/"+strings.Join(tokens[:len(tokens)-1], "/"))
+	last := tokens[len(tokens)-1]
+	union, ok := pointerChild(parent, last).([]interface{})
+	if !ok {
+		return ""
+	}
+	union = append(union, branch)
+	switch p := parent.(type) {
+	case *jsonfmt.Object:
+		p.Set(last, union)
+	case []interface{}:
+		index, _ := strconv.Atoi(last)
+		p[index] = union
+	}
+	return fmt.Sprintf("added %s to the union of %s", branch, fieldName(root, issue))
+}
+
+func fieldName(root interface{}, issue Issue) string {
+	if name := fieldFromPointer(root, issue.Path); name != "" {
+		return name
+	}
+	return "the schema"
+}
+
+// insertAfter sets a key right after another one, or at the end when that one is missing
+func insertAfter(o *jsonfmt.Object, after, key string, value interface{}) {
+	if _, ok := o.Get(after); !ok {
+		o.Set(key, value)
+		return
+	}
+	members := make([]jsonfmt.Member, 0, len(o.Members)+1)
+	for _, m := range o.Members {
+		members = append(members, m)
+		if m.Key == after {
+			members = append(members, jsonfmt.Member{Key: key, Value: value})
+		}
+	}
+	o.Members = members
+}
@@ -0,0 +1,103 @@
+package compat
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HistoryPair is the check of a registered version against an earlier one under a level
+type HistoryPair struct {
+	Version   int      `json:"version"`
+	Against   int      `json:"against"`
+	Satisfied string   `json:"satisfied"`
+	Source    string   `json:"source,omitempty"`
+	Reason    string   `json:"reason,omitempty"`
+	Messages  []string `json:"messages,omitempty"`
+	Issues    []Issue  `json:"issues,omitempty"`
+}
+
+// PairChecker checks a version against an earlier version in the given directions:
+// backward when the version must read the earlier version's data, forward for the reverse
+type PairChecker func(version, against int, backward, forward bool) (*HistoryPair, error)
+
+// CheckHistory evaluates a chain of registered versions (oldest first) under a level, as
+// if the level had been in force when each version was registered: every version is
+// checked against the one before it, or against all earlier versions for transitive levels
+func CheckHistory(level string, versions []int, check PairChecker) ([]HistoryPair, error) {
+	backward, forward, transitive, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := []HistoryPair{}
+	if !backward && !forward {
+		return pairs, nil
+	}
+	for i := 1; i < len(versions); i++ {
+		start := i - 1
+		if transitive {
+			start = 0
+		}
+		for j := start; j < i; j++ {
+			pair, err := check(versions[i], versions[j], backward, forward)
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, *pair)
+		}
+	}
+	return pairs, nil
+}
+
+// LocalPairChecker checks pairs with a local checker, given the content of every version
+func LocalPairChecker(checker Checker, contents map[int]string) PairChecker {
+	return func(version, against int, backward, forward bool) (*HistoryPair, error) {
+		result := &Result{IsCompatible: true}
+		label := fmt.Sprintf("version %d", against)
+		if backward {
+			issues, err := checker(contents[version], contents[against])
+			if err != nil {
+				return nil, fmt.Errorf("failed to check version %d against %s: %w", version, label, err)
+			}
+			result.add(issues, "backward", label)
+		}
+		if forward {
+			issues, err := checker(contents[against], contents[version])
+			if err != nil {
+				return nil, fmt.Errorf("failed to check version %d against %s: %w", version, label, err)
+			}
+			result.add(issues, "forward", label)
+		}
+
+		pair := &HistoryPair{Version: version, Against: against, Satisfied: Satisfied, Source: "local"}
+		if !result.IsCompatible {
+			pair.Satisfied = Unsatisfied
+			pair.Messages = result.Messages
+			pair.Issues = result.Issues
+		}
+		return pair, nil
+	}
+}
+
+// RegistryPairChecker checks pairs with the registry, which only evaluates the configured
+// level: compatible(version, against) returns its verdict and messages for the content of
+// version against the registered against version. Verdicts in other directions are derived
+// with DeriveVerdict, or unknown.
+func RegistryPairChecker(configured string, compatible func(version, against int) (bool, []string, error)) PairChecker {
+	return func(version, against int, backward, forward bool) (*HistoryPair, error) {
+		ok, messages, err := compatible(version, against)
+		if err != nil {
+			return nil, err
+		}
+		pair := &HistoryPair{Version: version, Against: against, Source: "registry"}
+		pair.Satisfied = DeriveVerdict(configured, backward, forward, ok)
+		switch pair.Satisfied {
+		case Unsatisfied:
+			pair.Messages = messages
+		case Unknown:
+			pair.Source = ""
+			pair.Reason = fmt.Sprintf("the registry only checks the subject's %s level", strings.ToUpper(configured))
+		}
+		return pair, nil
+	}
+}
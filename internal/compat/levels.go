@@ -0,0 +1,119 @@
+package compat
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Levels lists every compatibility level, from the weakest to the strictest
+var Levels = []string{"NONE", "BACKWARD", "BACKWARD_TRANSITIVE", "FORWARD", "FORWARD_TRANSITIVE", "FULL", "FULL_TRANSITIVE"}
+
+// Satisfaction values of a level verdict
+const (
+	Satisfied   = "yes"
+	Unsatisfied = "no"
+	Unknown     = "unknown"
+)
+
+// VersionVerdict is the registry's verdict for a candidate against one registered version,
+// under the subject's configured level
+type VersionVerdict struct {
+	Version    int
+	Compatible bool
+}
+
+// LevelVerdict tells whether a candidate would satisfy a compatibility level, and how that
+// was determined: from the registry's verdicts or with a local checker
+type LevelVerdict struct {
+	Level     string `json:"level"`
+	Satisfied string `json:"satisfied"`
+	Source    string `json:"source,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// EvaluateLevels derives the levels a candidate satisfies from the registry's verdicts
+// against every version (oldest first), given under the configured level. A level checking
+// the same directions is decided by those verdicts directly; a weaker one is satisfied when
+// they all pass and a stricter one fails when they do not. The remaining levels are checked
+// with local, when a local checker exists for the schema type, or reported as unknown.
+func EvaluateLevels(configured string, verdicts []VersionVerdict, local func(level string) (bool, error)) ([]LevelVerdict, error) {
+	if _, _, _, err := ParseLevel(configured); err != nil {
+		return nil, err
+	}
+
+	var results []LevelVerdict
+	for _, level := range Levels {
+		backward, forward, transitive, _ := ParseLevel(level)
+		verdict := LevelVerdict{Level: level}
+
+		relevant := verdicts
+		if !transitive && len(verdicts) > 0 {
+			relevant = verdicts[len(verdicts)-1:]
+		}
+		var failing []string
+		for _, v := range relevant {
+			if !v.Compatible {
+				failing = append(failing, fmt.Sprintf("%d", v.Version))
+			}
+		}
+		derived := DeriveVerdict(configured, backward, forward, len(failing) == 0)
+
+		switch {
+		case level == "NONE" || len(verdicts) == 0:
+			verdict.Satisfied = Satisfied
+		case derived != Unknown && (local == nil || sameDirections(configured, backward, forward)):
+			verdict.Satisfied, verdict.Source = derived, "registry"
+			if derived == Unsatisfied {
+				verdict.Reason = fmt.Sprintf("incompatible with version %s", strings.Join(failing, ", "))
+			}
+		case local != nil:
+			ok, err := local(level)
+			if err != nil {
+				return nil, err
+			}
+			verdict.Satisfied, verdict.Source = Unsatisfied, "local"
+			if ok {
+				verdict.Satisfied = Satisfied
+			}
+		default:
+			verdict.Satisfied = Unknown
+			verdict.Reason = unknownReason(configured)
+		}
+		results = append(results, verdict)
+	}
+	return results, nil
+}
+
+// DeriveVerdict decides whether a check in the given directions passes from the registry's
+// verdict under the configured level: the same directions are decided by the verdict, fewer
+// directions pass when it passes and more directions fail when it fails. Anything else is
+// unknown, as is everything when the configured level is NONE.
+func DeriveVerdict(configured string, backward, forward, compatible bool) string {
+	cfgBackward, cfgForward, _, err := ParseLevel(configured)
+	if err != nil || !(cfgBackward || cfgForward) {
+		return Unknown
+	}
+	switch {
+	case backward == cfgBackward && forward == cfgForward:
+		if compatible {
+			return Satisfied
+		}
+		return Unsatisfied
+	case compatible && (!backward || cfgBackward) && (!forward || cfgForward):
+		return Satisfied
+	case !compatible && (!cfgBackward || backward) && (!cfgForward || forward):
+		return Unsatisfied
+	default:
+		return Unknown
+	}
+}
+
+// sameDirections reports whether a level checks the same directions as the configured one
+func sameDirections(configured string, backward, forward bool) bool {
+	cfgBackward, cfgForward, _, _ := ParseLevel(configured)
+	return backward == cfgBackward && forward == cfgForward
+}
+
+func unknownReason(configured string) string {
+	return fmt.Sprintf("the registry checks the subject's %s level, and no local checker is available", strings.ToUpper(configured))
+}
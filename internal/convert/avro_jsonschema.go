@@ -0,0 +1,240 @@
+package convert
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/aywengo/ksr-cli/internal/avro"
+	"github.com/aywengo/ksr-cli/internal/jsonfmt"
+)
+
+// jsonSchemaDraft is the JSON Schema draft written by conversions
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// avroToJSONSchema converts an Avro schema to a draft-07 JSON Schema for its plain JSON
+// form. Named types used more than once are written to "definitions".
+func avroToJSONSchema(content string, references []string) (*Result, error) {
+	s, err := avro.ParseWithReferences(content, references)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &avroJSONConverter{
+		root:        s,
+		uses:        map[string]int{},
+		defined:     map[string]bool{},
+		definitions: &jsonfmt.Object{},
+	}
+	c.countUses(s)
+
+	root := &jsonfmt.Object{}
+	root.Set("$schema", jsonSchemaDraft)
+	root.Members = append(root.Members, c.body(s, "").Members...)
+	if len(c.definitions.Members) > 0 {
+		root.Set("definitions", c.definitions)
+	}
+
+	return &Result{Content: jsonfmt.Marshal(root), Losses: c.losses.list}, nil
+}
+
+type avroJSONConverter struct {
+	losses
+	root *avro.Schema
+	// uses counts the references to each named type
+	uses        map[string]int
+	defined     map[string]bool
+	definitions *jsonfmt.Object
+}
+
+// countUses counts how often each named type is used, visiting each definition once
+func (c *avroJSONConverter) countUses(s *avro.Schema) {
+	if s.IsNamed() {
+		c.uses[s.FullName()]++
+		if c.uses[s.FullName()] > 1 {
+			return
+		}
+	}
+	switch s.Type {
+	case avro.Record, avro.Error:
+		for _, field := range s.Fields {
+			c.countUses(field.Type)
+		}
+	case avro.Array:
+		c.countUses(s.Items)
+	case avro.Map:
+		c.countUses(s.Values)
+	case avro.Union:
+		for _, branch := range s.Types {
+			c.countUses(branch)
+		}
+	}
+}
+
+// schema converts a type, referring to named types that are used more than once
+func (c *avroJSONConverter) schema(s *avro.Schema, path string) *jsonfmt.Object {
+	if !s.IsNamed() || c.uses[s.FullName()] < 2 {
+		return c.body(s, path)
+	}
+	if s == c.root {
+		return jsonfmt.NewObject("$ref", "#")
+	}
+
+	name := s.FullName()
+	if !c.defined[name] {
+		c.defined[name] = true
+		// Reserve the position before converting, so nested definitions follow it
+		c.definitions.Set(name, nil)
+		c.definitions.Set(name, c.body(s, path))
+	}
+	return jsonfmt.NewObject("$ref", "#/definitions/"+name)
+}
+
+// body converts a type in place
+func (c *avroJSONConverter) body(s *avro.Schema, path string) *jsonfmt.Object {
+	if s.LogicalType != "" && !(s.LogicalType == "uuid" && s.Type == avro.String) {
+		c.add(path, "logical type %s is not represented; values use the underlying %s", s.LogicalType, s.Type)
+	}
+	if len(s.Aliases) > 0 {
+		c.add(path, "aliases of %s are dropped", s.FullName())
+	}
+
+	switch s.Type {
+	case avro.Null:
+		return jsonfmt.NewObject("type", "null")
+	case avro.Boolean:
+		return jsonfmt.NewObject("type", "boolean")
+	case avro.Int:
+		return jsonfmt.NewObject("type", "integer", "minimum", jsonNumber(-1<<31), "maximum", jsonNumber(1<<31-1))
+	case avro.Long:
+		return jsonfmt.NewObject("type", "integer")
+	case avro.Float, avro.Double:
+		return jsonfmt.NewObject("type", "number")
+	case avro.String:
+		if s.LogicalType == "uuid" {
+			return jsonfmt.NewObject("type", "string", "format", "uuid")
+		}
+		return jsonfmt.NewObject("type", "string")
+	case avro.Bytes:
+		c.add(path, "bytes become a string of code points U+0000 to U+00FF")
+		return jsonfmt.NewObject("type", "string")
+	case avro.Fixed:
+		c.add(path, "fixed %s becomes a string of %d code points U+0000 to U+00FF", s.FullName(), s.Size)
+		return jsonfmt.NewObject("type", "string", "minLength", jsonNumber(int64(s.Size)), "maxLength", jsonNumber(int64(s.Size)))
+	case avro.Enum:
+		symbols := make([]interface{}, len(s.Symbols))
+		for i, symbol := range s.Symbols {
+			symbols[i] = symbol
+		}
+		result := jsonfmt.NewObject("title", s.Name)
+		setDoc(result, "description", s.Doc)
+		result.Set("type", "string")
+		result.Set("enum", symbols)
+		return result
+	case avro.Array:
+		return jsonfmt.NewObject("type", "array", "items", c.schema(s.Items, path+"[]"))
+	case avro.Map:
+		return jsonfmt.NewObject("type", "object", "additionalProperties", c.schema(s.Values, path+"{}"))
+	case avro.Union:
+		return c.union(s, path)
+	default:
+		return c.record(s, path)
+	}
+}
+
+func (c *avroJSONConverter) record(s *avro.Schema, path string) *jsonfmt.Object {
+	result := jsonfmt.NewObject("title", s.Name)
+	setDoc(result, "description", s.Doc)
+	result.Set("type", "object")
+
+	properties := &jsonfmt.Object{}
+	var required []interface{}
+	for _, field := range s.Fields {
+		fieldPath := joinPath(path, field.Name)
+		property := c.schema(field.Type, fieldPath)
+		if field.Doc != "" || field.HasDefault {
+			// Keywords next to $ref are ignored before draft 2019-09
+			if isRef(property) {
+				property = jsonfmt.NewObject("allOf", []interface{}{property})
+			}
+			setDoc(property, "description", field.Doc)
+			if field.HasDefault {
+				property.Set("default", field.Default)
+			}
+			property.Reorder("title", "description", "type", "format", "enum", "default")
+		}
+		if len(field.Aliases) > 0 {
+			c.add(fieldPath, "aliases of field %s are dropped", field.Name)
+		}
+		properties.Set(field.Name, property)
+		if !field.HasDefault {
+			required = append(required, field.Name)
+		}
+	}
+
+	result.Set("properties", properties)
+	if len(required) > 0 {
+		result.Set("required", required)
+	}
+	result.Set("additionalProperties", false)
+	return result
+}
+
+// union converts a union to anyOf, reporting branches that plain JSON cannot tell apart
+func (c *avroJSONConverter) union(s *avro.Schema, path string) *jsonfmt.Object {
+	branches := make([]interface{}, 0, len(s.Types))
+	kinds := map[string]string{}
+	for _, branch := range s.Types {
+		branches = append(branches, c.schema(branch, path))
+
+		kind := jsonKind(branch)
+		if other, ok := kinds[kind]; ok {
+			c.add(path, "union branches %s and %s are both JSON %ss and cannot be told apart", other, branch.TypeName(), kind)
+		}
+		kinds[kind] = branch.TypeName()
+		if kind == "integer" {
+			kinds["number"] = branch.TypeName()
+		}
+		if kind == "number" {
+			kinds["integer"] = branch.TypeName()
+		}
+	}
+	return jsonfmt.NewObject("anyOf", branches)
+}
+
+// jsonKind returns the JSON type of the values of an Avro type
+func jsonKind(s *avro.Schema) string {
+	switch s.Type {
+	case avro.Null:
+		return "null"
+	case avro.Boolean:
+		return "boolean"
+	case avro.Int, avro.Long:
+		return "integer"
+	case avro.Float, avro.Double:
+		return "number"
+	case avro.Array:
+		return "array"
+	case avro.Map, avro.Record, avro.Error:
+		return "object"
+	default:
+		return "string"
+	}
+}
+
+// setDoc sets a documentation attribute when the text is not empty
+func setDoc(obj *jsonfmt.Object, key, text string) {
+	if text != "" {
+		obj.Set(key, text)
+	}
+}
+
+// isRef reports whether a schema object is a bare reference
+func isRef(obj *jsonfmt.Object) bool {
+	_, ok := obj.Get("$ref")
+	return ok
+}
+
+// jsonNumber formats an integer as a JSON number
+func jsonNumber(n int64) json.Number {
+	return json.Number(strconv.FormatInt(n, 10))
+}
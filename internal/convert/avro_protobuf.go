@@ -0,0 +1,353 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/internal/avro"
+	"github.com/aywengo/ksr-cli/internal/protobuf"
+)
+
+// avroToProtobuf converts an Avro schema to a proto3 file. Records become top-level
+// messages, the root record first, and the package is the namespace of the root record.
+func avroToProtobuf(content string, references []string, opts Options) (*Result, error) {
+	s, err := avro.ParseWithReferences(content, references)
+	if err != nil {
+		return nil, err
+	}
+	if !s.IsRecord() {
+		return nil, fmt.Errorf("Protobuf conversion requires a record at the root, got %s", s.String())
+	}
+
+	pkg := s.Namespace
+	if opts.Namespace != "" {
+		pkg = opts.Namespace
+	}
+	f := &protobuf.File{Syntax: "proto3", Package: pkg}
+	f.Decls = append(f.Decls, &protobuf.SyntaxDecl{Keyword: "syntax", Value: "proto3"})
+	if pkg != "" {
+		f.Decls = append(f.Decls, &protobuf.PackageDecl{Name: pkg})
+	}
+
+	c := &avroProtoConverter{
+		file:       f,
+		types:      map[string]string{},
+		names:      map[string]bool{},
+		enumValues: map[string]bool{},
+		imports:    map[string]bool{},
+	}
+	c.message(s, "")
+
+	for _, path := range sortedKeys(c.imports) {
+		f.Decls = append(f.Decls, &protobuf.Import{Path: path})
+	}
+	f.Decls = append(f.Decls, c.defs...)
+
+	return &Result{Content: protobuf.Format(f), Losses: c.losses.list}, nil
+}
+
+type avroProtoConverter struct {
+	losses
+	file *protobuf.File
+	defs []protobuf.Decl
+	// types maps Avro full names to Protobuf type names
+	types map[string]string
+	// names holds the top-level type names in use
+	names map[string]bool
+	// enumValues holds the enum value names in use, which share the package scope
+	enumValues map[string]bool
+	imports    map[string]bool
+}
+
+// typeName returns the top-level Protobuf name of a named Avro type, renaming types whose
+// names collide across namespaces
+func (c *avroProtoConverter) typeName(s *avro.Schema, path string) string {
+	name := uniqueName(s.Name, c.names)
+	if name != s.Name {
+		c.add(path, "%s is renamed to %s, as its name is taken by a type from another namespace", s.FullName(), name)
+	}
+	c.types[s.FullName()] = name
+	return name
+}
+
+// message defines the message for a record once and returns its name
+func (c *avroProtoConverter) message(s *avro.Schema, path string) string {
+	if name, ok := c.types[s.FullName()]; ok {
+		return name
+	}
+
+	m := &protobuf.Message{Name: c.typeName(s, path)}
+	m.LeadingComments = docComments(s.Doc)
+	c.defs = append(c.defs, m)
+	c.file.Messages = append(c.file.Messages, m)
+	if len(s.Aliases) > 0 {
+		c.add(path, "aliases of %s are dropped", s.FullName())
+	}
+
+	number := 0
+	for _, field := range s.Fields {
+		fieldPath := joinPath(path, field.Name)
+		if field.HasDefault && !isZeroDefault(field.Type, field.Default) {
+			c.add(fieldPath, "default %s is dropped, as proto3 fields default to zero values", describeValue(field.Default))
+		}
+		if len(field.Aliases) > 0 {
+			c.add(fieldPath, "aliases of field %s are dropped", field.Name)
+		}
+		if json := jsonName(field.Name); json != field.Name {
+			c.add(fieldPath, "field %s is written as %s in the Protobuf JSON mapping", field.Name, json)
+		}
+		c.field(m, field.Name, field.Type, fieldPath, docComments(field.Doc), &number)
+	}
+	return m.Name
+}
+
+// field adds a field for an Avro type to a message; unions other than ["null", T]
+// become oneofs
+func (c *avroProtoConverter) field(m *protobuf.Message, name string, t *avro.Schema, path string, comments []string, number *int) {
+	if t.Type == avro.Union {
+		nonNull := t.NonNull()
+		if nonNull == t {
+			c.oneof(m, name, t, path, comments, number)
+			return
+		}
+		t = nonNull
+		switch t.Type {
+		case avro.Array, avro.Map:
+			c.add(path, "null is not distinguished from an empty %s", t.Type)
+			c.addField(m, name, t, path, comments, number, "")
+		case avro.Record, avro.Error:
+			c.addField(m, name, t, path, comments, number, "")
+		default:
+			c.addField(m, name, t, path, comments, number, "optional")
+		}
+		return
+	}
+	c.addField(m, name, t, path, comments, number, "")
+}
+
+// addField adds a field for a non-union type with a label
+func (c *avroProtoConverter) addField(m *protobuf.Message, name string, t *avro.Schema, path string, comments []string, number *int, label string) {
+	*number++
+	field := &protobuf.Field{Name: name, Number: *number, Label: label, Message: m}
+	field.LeadingComments = comments
+
+	switch t.Type {
+	case avro.Array:
+		field.Label = "repeated"
+		field.Type = c.elementType(m, name, "Item", t.Items, path+"[]")
+	case avro.Map:
+		field.IsMap = true
+		field.KeyType = "string"
+		field.Type = c.elementType(m, name, "Value", t.Values, path+"{}")
+	default:
+		field.Type = c.scalarType(t, path)
+	}
+
+	m.Fields = append(m.Fields, field)
+	m.Decls = append(m.Decls, field)
+}
+
+// elementType returns the type of array items or map values. Types that cannot be
+// repeated, such as nested arrays, maps and unions, are wrapped in a nested message.
+func (c *avroProtoConverter) elementType(m *protobuf.Message, name, suffix string, t *avro.Schema, path string) string {
+	if t.Type == avro.Union {
+		if nonNull := t.NonNull(); nonNull != t && nonNull.Type != avro.Array && nonNull.Type != avro.Map {
+			c.add(path, "null %ss are not supported in repeated fields and maps", strings.ToLower(suffix))
+			t = nonNull
+		}
+	}
+	switch t.Type {
+	case avro.Array, avro.Map, avro.Union:
+		wrapper := &protobuf.Message{Name: pascalCase(name) + suffix, Parent: m}
+		c.add(path, "%s %ss are wrapped in message %s with a single field \"value\"", t.Type, strings.ToLower(suffix), wrapper.Name)
+		number := 0
+		c.field(wrapper, "value", t, path, nil, &number)
+		m.Messages = append(m.Messages, wrapper)
+		m.Decls = append(m.Decls, wrapper)
+		return wrapper.Name
+	default:
+		return c.scalarType(t, path)
+	}
+}
+
+// oneof adds a oneof with a member per non-null branch of a union
+func (c *avroProtoConverter) oneof(m *protobuf.Message, name string, t *avro.Schema, path string, comments []string, number *int) {
+	oneof := &protobuf.Oneof{Name: name}
+	oneof.LeadingComments = comments
+	c.add(path, "union becomes oneof %s; the Protobuf JSON mapping names the branch in the member field", name)
+
+	members := map[string]bool{}
+	for _, branch := range t.Types {
+		if branch.Type == avro.Null {
+			continue
+		}
+		memberName := uniqueName(name+"_"+strings.ToLower(branchName(branch)), members)
+		*number++
+		member := &protobuf.Field{Name: memberName, Number: *number, Message: m, Oneof: oneof}
+		if branch.Type == avro.Array || branch.Type == avro.Map {
+			// Oneof members cannot be repeated or maps
+			member.Type = c.elementType(m, memberName, "Value", branch, path)
+		} else {
+			member.Type = c.scalarType(branch, path)
+		}
+		oneof.Fields = append(oneof.Fields, member)
+		oneof.Decls = append(oneof.Decls, member)
+		m.Fields = append(m.Fields, member)
+	}
+
+	m.Oneofs = append(m.Oneofs, oneof)
+	m.Decls = append(m.Decls, oneof)
+}
+
+// branchName names a union branch for its oneof member
+func branchName(s *avro.Schema) string {
+	if s.IsNamed() {
+		return s.Name
+	}
+	return string(s.Type)
+}
+
+// scalarType returns the Protobuf type of a primitive or named Avro type
+func (c *avroProtoConverter) scalarType(t *avro.Schema, path string) string {
+	if t.LogicalType != "" {
+		c.add(path, "logical type %s is not represented; values use the underlying %s", t.LogicalType, t.Type)
+	}
+
+	switch t.Type {
+	case avro.Null:
+		c.add(path, "null type becomes google.protobuf.Empty")
+		c.imports["google/protobuf/empty.proto"] = true
+		return "google.protobuf.Empty"
+	case avro.Boolean:
+		return "bool"
+	case avro.Int:
+		return "int32"
+	case avro.Long:
+		return "int64"
+	case avro.Float:
+		return "float"
+	case avro.Double:
+		return "double"
+	case avro.String:
+		return "string"
+	case avro.Bytes:
+		return "bytes"
+	case avro.Fixed:
+		c.add(path, "fixed %s becomes bytes without its size of %d", t.FullName(), t.Size)
+		return "bytes"
+	case avro.Enum:
+		return c.enum(t, path)
+	default:
+		return c.message(t, path)
+	}
+}
+
+// enum defines the enum for an Avro enum once and returns its name. Values keep the
+// symbols, numbered from zero, unless a symbol is already used by another enum of the
+// package.
+func (c *avroProtoConverter) enum(s *avro.Schema, path string) string {
+	if name, ok := c.types[s.FullName()]; ok {
+		return name
+	}
+
+	e := &protobuf.Enum{Name: c.typeName(s, path)}
+	e.LeadingComments = docComments(s.Doc)
+	prefix := strings.ToUpper(snakeCase(e.Name)) + "_"
+	for i, symbol := range s.Symbols {
+		name := symbol
+		if c.enumValues[name] || c.names[name] {
+			name = prefix + symbol
+			c.add(path, "enum symbol %s of %s becomes %s, as enum values share the package scope", symbol, s.FullName(), name)
+		}
+		c.enumValues[name] = true
+		value := &protobuf.EnumValue{Name: name, Number: i}
+		e.Values = append(e.Values, value)
+		e.Decls = append(e.Decls, value)
+	}
+	if s.EnumDefault != "" {
+		c.add(path, "enum default %s is dropped; unknown values read as %s", s.EnumDefault, s.Symbols[0])
+	}
+
+	c.defs = append(c.defs, e)
+	c.file.Enums = append(c.file.Enums, e)
+	return e.Name
+}
+
+// docComments turns documentation into comment lines
+func docComments(doc string) []string {
+	if doc == "" {
+		return nil
+	}
+	var comments []string
+	for _, line := range strings.Split(strings.TrimSpace(doc), "\n") {
+		comments = append(comments, strings.TrimSpace("// "+strings.TrimSpace(line)))
+	}
+	return comments
+}
+
+// isZeroDefault reports whether a default is null or the zero value of its type, which
+// proto3 fields take when unset
+func isZeroDefault(t *avro.Schema, value interface{}) bool {
+	if t.Type == avro.Union {
+		t = t.Types[0]
+	}
+	if t.Type == avro.Enum {
+		return value == t.Symbols[0]
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	default:
+		return strings.Trim(fmt.Sprint(v), "0.-") == ""
+	}
+}
+
+// describeValue formats a default value for messages
+func describeValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprint(value)
+}
+
+// jsonName returns the lowerCamelCase name of a field in the Protobuf JSON mapping
+func jsonName(name string) string {
+	var sb strings.Builder
+	upper := false
+	for _, r := range name {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper && r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		upper = false
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// snakeCase turns a type name into snake case: "OrderStatus" becomes "order_status"
+func snakeCase(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				sb.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
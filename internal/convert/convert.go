@@ -0,0 +1,191 @@
+// Package convert translates schemas between Avro, JSON Schema and Protobuf. Constructs
+// without an exact equivalent in the target format are converted to the closest match
+// and reported as losses, so that nothing is dropped silently.
+package convert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Format is a schema format, named as Schema Registry names schema types
+type Format string
+
+const (
+	Avro       Format = "AVRO"
+	JSONSchema Format = "JSON"
+	Protobuf   Format = "PROTOBUF"
+)
+
+// ParseFormat accepts the schema type names used by Schema Registry and the common names
+// of the formats: avro, json-schema, json, protobuf and proto
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "avro":
+		return Avro, nil
+	case "json", "json-schema", "jsonschema":
+		return JSONSchema, nil
+	case "protobuf", "proto":
+		return Protobuf, nil
+	default:
+		return "", fmt.Errorf("unsupported schema format: %s (expected avro, json-schema or protobuf)", name)
+	}
+}
+
+// Loss is a construct of the source schema that the converted schema does not represent
+// exactly. Path is the field path in the source schema, such as "address.city",
+// "items[]" or "attributes{}".
+type Loss struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Result is a converted schema and the losses of the conversion
+type Result struct {
+	Content string `json:"schema"`
+	Losses  []Loss `json:"losses"`
+}
+
+// Options tune a conversion
+type Options struct {
+	// Name and Namespace name the root type when the source does not, as for a JSON
+	// Schema without a title. Namespace also overrides the Protobuf package.
+	Name      string
+	Namespace string
+
+	// Message selects the Protobuf message to convert; the first message by default
+	Message string
+}
+
+// Convert translates a schema from one format to another. References hold the contents
+// of the schemas the source refers to. Conversions between JSON Schema and Protobuf go
+// through Avro and report the losses of both steps.
+func Convert(from, to Format, content string, references []string, opts Options) (*Result, error) {
+	if from == to {
+		return nil, fmt.Errorf("source and target formats are both %s", formatName(from))
+	}
+
+	if from != Avro && to != Avro {
+		intermediate, err := Convert(from, Avro, content, references, opts)
+		if err != nil {
+			return nil, err
+		}
+		result, err := Convert(Avro, to, intermediate.Content, nil, Options{Namespace: opts.Namespace})
+		if err != nil {
+			return nil, err
+		}
+		result.Losses = append(intermediate.Losses, result.Losses...)
+		return result, nil
+	}
+
+	var result *Result
+	var err error
+	switch {
+	case from == Avro && to == JSONSchema:
+		result, err = avroToJSONSchema(content, references)
+	case from == Avro && to == Protobuf:
+		result, err = avroToProtobuf(content, references, opts)
+	case from == JSONSchema:
+		result, err = jsonSchemaToAvro(content, references, opts)
+	case from == Protobuf:
+		result, err = protobufToAvro(content, references, opts)
+	default:
+		err = fmt.Errorf("unsupported conversion from %s to %s", formatName(from), formatName(to))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result.Losses == nil {
+		result.Losses = []Loss{}
+	}
+	return result, nil
+}
+
+// formatName returns the name of a format used in messages
+func formatName(f Format) string {
+	switch f {
+	case Avro:
+		return "Avro"
+	case JSONSchema:
+		return "JSON Schema"
+	case Protobuf:
+		return "Protobuf"
+	default:
+		return string(f)
+	}
+}
+
+// losses collects the losses of a conversion, once per path and message
+type losses struct {
+	list []Loss
+	seen map[Loss]bool
+}
+
+func (l *losses) add(path, format string, args ...interface{}) {
+	if path == "" {
+		path = "<root>"
+	}
+	loss := Loss{Path: path, Message: fmt.Sprintf(format, args...)}
+	if l.seen == nil {
+		l.seen = map[Loss]bool{}
+	}
+	if l.seen[loss] {
+		return
+	}
+	l.seen[loss] = true
+	l.list = append(l.list, loss)
+}
+
+// joinPath appends a field name to a path
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// uniqueName returns name, or name with a numeric suffix if it is already taken, and
+// marks the result as taken
+func uniqueName(name string, taken map[string]bool) string {
+	unique := name
+	for i := 2; taken[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	taken[unique] = true
+	return unique
+}
+
+// pascalCase turns a field or definition name into a type name: "line_items" becomes
+// "LineItems"
+func pascalCase(name string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+	result := sb.String()
+	if result == "" || unicode.IsDigit([]rune(result)[0]) {
+		result = "T" + result
+	}
+	return result
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
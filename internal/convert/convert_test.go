@@ -0,0 +1,294 @@
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aywengo/ksr-cli/internal/avro"
+	"github.com/aywengo/ksr-cli/internal/jsonfmt"
+	"github.com/aywengo/ksr-cli/internal/protobuf"
+)
+
+const orderAvro = `{"type":"record","name":"Order","namespace":"com.acme","doc":"An order","fields":[
+	{"name":"id","type":{"type":"string","logicalType":"uuid"}},
+	{"name":"total","type":{"type":"bytes","logicalType":"decimal","precision":10,"scale":2}},
+	{"name":"status","type":{"type":"enum","name":"Status","symbols":["NEW","DONE"]},"default":"DONE"},
+	{"name":"note","type":["null","string"],"default":null},
+	{"name":"payment","type":["string","long","double"]},
+	{"name":"attributes","type":{"type":"map","values":"string"}},
+	{"name":"matrix","type":{"type":"array","items":{"type":"array","items":"int"}}},
+	{"name":"billing","type":{"type":"record","name":"Address","fields":[{"name":"city","type":"string"}]}},
+	{"name":"shipping","type":["null","Address"],"default":null}
+]}`
+
+// hasLoss reports whether a loss with a message containing text was reported at path
+func hasLoss(losses []Loss, path, text string) bool {
+	for _, loss := range losses {
+		if loss.Path == path && strings.Contains(loss.Message, text) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestParseFormat(t *testing.T) {
+	for name, want := range map[string]Format{
+		"avro": Avro, "AVRO": Avro, "json-schema": JSONSchema, "JSON": JSONSchema, "proto": Protobuf, "PROTOBUF": Protobuf,
+	} {
+		got, err := ParseFormat(name)
+		if err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("Expected error for an unsupported format")
+	}
+}
+
+func TestConvert_SameFormat(t *testing.T) {
+	if _, err := Convert(Avro, Avro, orderAvro, nil, Options{}); err == nil {
+		t.Error("Expected error when converting a format to itself")
+	}
+}
+
+func TestConvert_AvroToJSONSchema(t *testing.T) {
+	result, err := Convert(Avro, JSONSchema, orderAvro, nil, Options{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		`"$schema": "http://json-schema.org/draft-07/schema#"`,
+		`"title": "Order"`,
+		`"description": "An order"`,
+		`"format": "uuid"`,
+		`"enum": ["NEW", "DONE"]`,
+		`"default": "DONE"`,
+		`"additionalProperties": {`,
+		`"$ref": "#/definitions/com.acme.Address"`,
+		`"required": ["id", "total", "payment", "attributes", "matrix", "billing"]`,
+	} {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("Expected converted schema to contain %s, got:\n%s", want, result.Content)
+		}
+	}
+	if _, err := jsonfmt.Decode([]byte(result.Content)); err != nil {
+		t.Errorf("Converted schema is not valid JSON: %v", err)
+	}
+
+	if !hasLoss(result.Losses, "total", "logical type decimal") {
+		t.Errorf("Expected a loss for the decimal logical type, got %v", result.Losses)
+	}
+	if !hasLoss(result.Losses, "payment", "cannot be told apart") {
+		t.Errorf("Expected a loss for the long and double branches, got %v", result.Losses)
+	}
+	if hasLoss(result.Losses, "id", "uuid") {
+		t.Errorf("Expected the uuid logical type to be represented, got %v", result.Losses)
+	}
+}
+
+func TestConvert_AvroToProtobuf(t *testing.T) {
+	result, err := Convert(Avro, Protobuf, orderAvro, nil, Options{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		"package com.acme;",
+		"// An order\nmessage Order {",
+		"string id = 1;",
+		"Status status = 3;",
+		"optional string note = 4;",
+		"oneof payment {\n    string payment_string = 5;\n    int64 payment_long = 6;\n    double payment_double = 7;\n  }",
+		"map<string, string> attributes = 8;",
+		"repeated MatrixItem matrix = 9;",
+		"message MatrixItem {\n    repeated int32 value = 1;\n  }",
+		"Address shipping = 11;",
+		"enum Status {\n  NEW = 0;\n  DONE = 1;\n}",
+		"message Address {\n  string city = 1;\n}",
+	} {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("Expected converted schema to contain %q, got:\n%s", want, result.Content)
+		}
+	}
+	if _, err := protobuf.Parse(result.Content); err != nil {
+		t.Errorf("Converted schema is not valid Protobuf: %v", err)
+	}
+
+	for path, text := range map[string]string{
+		"status":   "default \"DONE\" is dropped",
+		"payment":  "oneof payment",
+		"matrix[]": "wrapped in message MatrixItem",
+		"total":    "logical type decimal",
+	} {
+		if !hasLoss(result.Losses, path, text) {
+			t.Errorf("Expected a loss at %s containing %q, got %v", path, text, result.Losses)
+		}
+	}
+}
+
+func TestConvert_AvroToProtobuf_RequiresRecord(t *testing.T) {
+	if _, err := Convert(Avro, Protobuf, `"string"`, nil, Options{}); err == nil {
+		t.Error("Expected error for a schema without a root record")
+	}
+}
+
+func TestConvert_JSONSchemaToAvro(t *testing.T) {
+	result, err := Convert(JSONSchema, Avro, `{
+		"title": "Person",
+		"type": "object",
+		"properties": {
+			"name": {"type": "string", "minLength": 1},
+			"age": {"type": "integer", "minimum": 0, "maximum": 150},
+			"id": {"type": "string", "format": "uuid"},
+			"kind": {"enum": ["a", "b"]},
+			"scores": {"type": "object", "additionalProperties": {"type": "number"}},
+			"first-name": {"type": "string"},
+			"address": {"$ref": "#/definitions/Address"}
+		},
+		"required": ["name", "address"],
+		"definitions": {
+			"Address": {"type": "object", "properties": {"city": {"type": "string"}}, "required": ["city"]}
+		}
+	}`, nil, Options{Namespace: "com.example"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	s, err := avro.Parse(result.Content)
+	if err != nil {
+		t.Fatalf("Converted schema is not valid Avro: %v\n%s", err, result.Content)
+	}
+	if s.FullName() != "com.example.Person" {
+		t.Errorf("Expected root record com.example.Person, got %s", s.FullName())
+	}
+
+	types := map[string]string{}
+	for _, field := range s.Fields {
+		types[field.Name] = field.Type.String()
+	}
+	for name, want := range map[string]string{
+		"name":       "string",
+		"age":        "union[null,int]",
+		"first_name": "union[null,string]",
+	} {
+		if types[name] != want {
+			t.Errorf("Expected field %s of type %s, got %s", name, want, types[name])
+		}
+	}
+	for _, want := range []string{`"logicalType": "uuid"`, `"type": "enum"`, `"type": "map"`, `"name": "Address"`} {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("Expected converted schema to contain %s, got:\n%s", want, result.Content)
+		}
+	}
+
+	for path, text := range map[string]string{
+		"name":       "minLength",
+		"age":        "minimum, maximum",
+		"first-name": "becomes field first_name",
+	} {
+		if !hasLoss(result.Losses, path, text) {
+			t.Errorf("Expected a loss at %s containing %q, got %v", path, text, result.Losses)
+		}
+	}
+}
+
+func TestConvert_ProtobufToAvro(t *testing.T) {
+	const order = `syntax = "proto3";
+package shop;
+import "google/protobuf/timestamp.proto";
+import "google/protobuf/wrappers.proto";
+
+// An order
+message Order {
+  int64 total_cents = 1;
+  oneof payment {
+    string card = 2;
+    string iban = 3;
+  }
+  Status status = 4;
+  google.protobuf.Timestamp created_at = 5;
+  repeated Line lines = 6;
+  map<int32, string> notes = 7;
+  optional string comment = 8;
+  google.protobuf.StringValue coupon = 9;
+  uint64 sequence = 10;
+  Line first = 11;
+  message Line {
+    string sku = 1;
+  }
+}
+
+enum Status {
+  STATUS_UNKNOWN = 0;
+  STATUS_OPEN = 5;
+}`
+
+	result, err := Convert(Protobuf, Avro, order, nil, Options{Message: "Order"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s, err := avro.Parse(result.Content)
+	if err != nil {
+		t.Fatalf("Converted schema is not valid Avro: %v\n%s", err, result.Content)
+	}
+	if s.FullName() != "shop.Order" || s.Doc != "An order" {
+		t.Errorf("Expected root record shop.Order with its doc, got %s (%q)", s.FullName(), s.Doc)
+	}
+
+	fields := map[string]*avro.Field{}
+	for _, field := range s.Fields {
+		fields[field.Name] = field
+	}
+	for name, want := range map[string]string{
+		"total_cents": "long",
+		"card":        "union[null,string]",
+		"comment":     "union[null,string]",
+		"coupon":      "union[null,string]",
+		"sequence":    "long",
+		"first":       "union[null,shop.Order.Line]",
+	} {
+		if fields[name] == nil || fields[name].Type.String() != want {
+			t.Errorf("Expected field %s of type %s", name, want)
+		}
+	}
+	if fields["status"].Default != "STATUS_UNKNOWN" {
+		t.Errorf("Expected status to default to STATUS_UNKNOWN, got %v", fields["status"].Default)
+	}
+	if fields["lines"].Type.Items.FullName() != "shop.Order.Line" {
+		t.Errorf("Expected lines of shop.Order.Line, got %s", fields["lines"].Type.Items.FullName())
+	}
+
+	for path, text := range map[string]string{
+		"payment":    "at most one",
+		"status":     "enum numbers",
+		"created_at": "timestamp-micros",
+		"notes":      "map keys of type int32",
+		"sequence":   "do not fit",
+	} {
+		if !hasLoss(result.Losses, path, text) {
+			t.Errorf("Expected a loss at %s containing %q, got %v", path, text, result.Losses)
+		}
+	}
+}
+
+func TestConvert_JSONSchemaToProtobuf(t *testing.T) {
+	result, err := Convert(JSONSchema, Protobuf, `{
+		"title": "Event",
+		"type": "object",
+		"properties": {"name": {"type": "string", "maxLength": 10}, "count": {"type": "integer"}},
+		"required": ["name"]
+	}`, nil, Options{Namespace: "events"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{"package events;", "message Event {", "string name = 1;", "optional int64 count = 2;"} {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("Expected converted schema to contain %q, got:\n%s", want, result.Content)
+		}
+	}
+	// Losses of both steps are reported
+	if !hasLoss(result.Losses, "name", "maxLength") {
+		t.Errorf("Expected a loss for maxLength, got %v", result.Losses)
+	}
+}
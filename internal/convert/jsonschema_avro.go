@@ -0,0 +1,639 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/internal/avro"
+	"github.com/aywengo/ksr-cli/internal/jsonfmt"
+)
+
+// avroNamePattern matches valid Avro names of records, fields and enum symbols
+var avroNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// droppedKeywords are validation keywords without an Avro equivalent
+var droppedKeywords = []string{
+	"minLength", "maxLength", "pattern", "format", "minimum", "maximum", "exclusiveMinimum",
+	"exclusiveMaximum", "multipleOf", "minItems", "maxItems", "uniqueItems", "contains",
+	"minContains", "maxContains", "minProperties", "maxProperties", "propertyNames",
+	"patternProperties", "dependencies", "dependentRequired", "dependentSchemas", "const",
+	"not", "if", "then", "else",
+}
+
+// jsonSchemaToAvro converts a JSON Schema to an Avro schema whose plain JSON records are
+// the instances of the JSON Schema. Objects with properties become records, named after
+// their title, definition name or property; other objects become maps.
+func jsonSchemaToAvro(content string, references []string, opts Options) (*Result, error) {
+	root, err := jsonfmt.Decode([]byte(content))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+
+	c := &jsonAvroConverter{
+		documents: map[string]interface{}{"": root},
+		namespace: opts.Namespace,
+		names:     map[string]bool{},
+		refs:      map[string]string{},
+		resolving: map[string]bool{},
+	}
+	for _, reference := range references {
+		document, err := jsonfmt.Decode([]byte(reference))
+		if err != nil {
+			return nil, fmt.Errorf("invalid referenced JSON schema: %w", err)
+		}
+		if id, ok := member(document, "$id").(string); ok {
+			c.documents[strings.TrimSuffix(id, "#")] = document
+		}
+	}
+
+	name := opts.Name
+	if title, ok := member(root, "title").(string); ok && name == "" {
+		name = title
+	}
+	if name == "" {
+		name = "Record"
+	}
+
+	converted := c.convert(root, "", name, "#", true)
+	formatted, err := avro.Format(jsonfmt.Marshal(converted))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := avro.Parse(formatted); err != nil {
+		return nil, fmt.Errorf("converted schema is not valid Avro: %w", err)
+	}
+	return &Result{Content: formatted, Losses: c.losses.list}, nil
+}
+
+type jsonAvroConverter struct {
+	losses
+	// documents holds the schema being converted under "" and referenced schemas by $id
+	documents map[string]interface{}
+	namespace string
+	// names holds the names of the named types defined so far
+	names map[string]bool
+	// refs maps references to the named types defined for their targets
+	refs map[string]string
+	// resolving holds the references being inlined, to detect cycles
+	resolving map[string]bool
+}
+
+// convert converts a schema. Hint names the type if it becomes a named type; ref is the
+// reference the schema was reached through, so other uses of it refer to the same type.
+func (c *jsonAvroConverter) convert(schema interface{}, path, hint, ref string, root bool) interface{} {
+	obj, ok := schema.(*jsonfmt.Object)
+	if !ok {
+		if schema == false {
+			c.add(path, "schema false accepts no value; mapped to null")
+			return "null"
+		}
+		return c.any(path)
+	}
+
+	if target, ok := member(obj, "$ref").(string); ok {
+		return c.reference(target, path, hint)
+	}
+	c.dropKeywords(obj, path)
+	if branches, keyword := combinator(obj); branches != nil {
+		if _, typed := obj.Get("type"); !typed && member(obj, "properties") == nil {
+			return c.union(branches, path, hint)
+		}
+		c.add(path, "%s alternatives are dropped, as the schema also has a type", keyword)
+	}
+	if branches, ok := member(obj, "allOf").([]interface{}); ok {
+		obj = c.mergeAllOf(obj, branches, path)
+	}
+
+	types := c.types(obj)
+	if len(types) == 0 {
+		return c.any(path)
+	}
+	if len(types) == 1 {
+		return c.convertType(obj, types[0], path, hint, ref, root)
+	}
+	var branches []interface{}
+	for _, t := range types {
+		branches = append(branches, c.convertType(obj, t, path, hint, ref, root))
+	}
+	return flattenUnion(branches)
+}
+
+// reference converts the target of a $ref: local pointers and pointers into referenced
+// schemas with an $id. Named types are defined once and referred to by name after that.
+func (c *jsonAvroConverter) reference(ref, path, hint string) interface{} {
+	if name, ok := c.refs[ref]; ok {
+		return name
+	}
+	target, err := c.resolve(ref)
+	if err != nil {
+		c.add(path, "reference %s is not resolved (%v); mapped to string", ref, err)
+		return "string"
+	}
+	if c.resolving[ref] {
+		c.add(path, "recursive reference %s to a type without a name is mapped to string", ref)
+		return "string"
+	}
+
+	c.resolving[ref] = true
+	defer delete(c.resolving, ref)
+	if segments := strings.Split(ref, "/"); len(segments) > 1 {
+		hint = unescapePointer(segments[len(segments)-1])
+	}
+	return c.convert(target, path, hint, ref, false)
+}
+
+// resolve evaluates a reference as a JSON pointer into the schema or a referenced schema
+func (c *jsonAvroConverter) resolve(ref string) (interface{}, error) {
+	base, fragment, _ := strings.Cut(ref, "#")
+	document, ok := c.documents[base]
+	if !ok {
+		return nil, fmt.Errorf("unknown schema %s", base)
+	}
+
+	current := document
+	if fragment == "" {
+		return current, nil
+	}
+	for _, segment := range strings.Split(strings.TrimPrefix(fragment, "/"), "/") {
+		segment = unescapePointer(segment)
+		switch v := current.(type) {
+		case *jsonfmt.Object:
+			value, ok := v.Get(segment)
+			if !ok {
+				return nil, fmt.Errorf("%s not found", segment)
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, fmt.Errorf("invalid index %s", segment)
+			}
+			current = v[index]
+		default:
+			return nil, fmt.Errorf("%s not found", segment)
+		}
+	}
+	return current, nil
+}
+
+// unescapePointer decodes a JSON pointer segment
+func unescapePointer(segment string) string {
+	if unescaped, err := url.PathUnescape(segment); err == nil {
+		segment = unescaped
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+}
+
+// dropKeywords reports the validation keywords of a schema that Avro cannot express
+func (c *jsonAvroConverter) dropKeywords(obj *jsonfmt.Object, path string) {
+	var dropped []string
+	for _, keyword := range droppedKeywords {
+		if _, ok := obj.Get(keyword); !ok {
+			continue
+		}
+		if keyword == "format" && member(obj, "format") == "uuid" {
+			continue
+		}
+		dropped = append(dropped, keyword)
+	}
+	if len(dropped) > 0 {
+		c.add(path, "keywords %s are not represented in Avro", strings.Join(dropped, ", "))
+	}
+}
+
+// combinator returns the branches of anyOf or oneOf
+func combinator(obj *jsonfmt.Object) ([]interface{}, string) {
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if branches, ok := member(obj, keyword).([]interface{}); ok {
+			return branches, keyword
+		}
+	}
+	return nil, ""
+}
+
+// mergeAllOf merges the properties and required lists of allOf branches into the schema
+func (c *jsonAvroConverter) mergeAllOf(obj *jsonfmt.Object, branches []interface{}, path string) *jsonfmt.Object {
+	merged := &jsonfmt.Object{}
+	for _, m := range obj.Members {
+		if m.Key != "allOf" {
+			merged.Set(m.Key, m.Value)
+		}
+	}
+
+	properties, _ := member(merged, "properties").(*jsonfmt.Object)
+	if properties == nil {
+		properties = &jsonfmt.Object{}
+	}
+	required, _ := member(merged, "required").([]interface{})
+	for _, branch := range branches {
+		if ref, ok := member(branch, "$ref").(string); ok {
+			if target, err := c.resolve(ref); err == nil {
+				branch = target
+			}
+		}
+		branchObj, ok := branch.(*jsonfmt.Object)
+		if !ok {
+			continue
+		}
+		for _, m := range branchObj.Members {
+			switch m.Key {
+			case "properties":
+				if props, ok := m.Value.(*jsonfmt.Object); ok {
+					for _, p := range props.Members {
+						properties.Set(p.Key, p.Value)
+					}
+				}
+			case "required":
+				if list, ok := m.Value.([]interface{}); ok {
+					required = append(required, list...)
+				}
+			case "type", "title", "description", "$schema", "$id", "additionalProperties":
+				if _, ok := merged.Get(m.Key); !ok {
+					merged.Set(m.Key, m.Value)
+				}
+			default:
+				c.add(path, "keyword %s of an allOf branch is dropped", m.Key)
+			}
+		}
+	}
+
+	if len(properties.Members) > 0 {
+		merged.Set("properties", properties)
+	}
+	if len(required) > 0 {
+		merged.Set("required", required)
+	}
+	return merged
+}
+
+// types returns the JSON types a schema allows, inferred from its keywords when it has no
+// type keyword
+func (c *jsonAvroConverter) types(obj *jsonfmt.Object) []string {
+	switch t := member(obj, "type").(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		var types []string
+		for _, item := range t {
+			if name, ok := item.(string); ok {
+				types = append(types, name)
+			}
+		}
+		// Null first, so optional values default to null
+		sort.SliceStable(types, func(i, j int) bool { return types[i] == "null" && types[j] != "null" })
+		return types
+	}
+
+	if member(obj, "properties") != nil || member(obj, "additionalProperties") != nil {
+		return []string{"object"}
+	}
+	if member(obj, "items") != nil {
+		return []string{"array"}
+	}
+	if values, ok := member(obj, "enum").([]interface{}); ok {
+		var types []string
+		seen := map[string]bool{}
+		for _, value := range values {
+			t := valueType(value)
+			if !seen[t] {
+				seen[t] = true
+				types = append(types, t)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// valueType returns the JSON type of a value
+func valueType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// convertType converts a schema restricted to one JSON type
+func (c *jsonAvroConverter) convertType(obj *jsonfmt.Object, t, path, hint, ref string, root bool) interface{} {
+	switch t {
+	case "null":
+		return "null"
+	case "boolean":
+		return "boolean"
+	case "integer":
+		minimum, hasMin := numberMember(obj, "minimum")
+		maximum, hasMax := numberMember(obj, "maximum")
+		if hasMin && hasMax && minimum >= -1<<31 && maximum <= 1<<31-1 {
+			return "int"
+		}
+		return "long"
+	case "number":
+		return "double"
+	case "string":
+		if symbols, ok := c.enumSymbols(obj, path); ok {
+			return c.enum(obj, symbols, hint, ref, root)
+		}
+		if member(obj, "format") == "uuid" {
+			return jsonfmt.NewObject("type", "string", "logicalType", "uuid")
+		}
+		return "string"
+	case "array":
+		return c.array(obj, path, hint)
+	case "object":
+		if properties, ok := member(obj, "properties").(*jsonfmt.Object); ok && len(properties.Members) > 0 {
+			return c.record(obj, properties, path, hint, ref, root)
+		}
+		return c.mapType(obj, path, hint)
+	default:
+		c.add(path, "unknown type %s is mapped to string", t)
+		return "string"
+	}
+}
+
+// enumSymbols returns the string values of an enum if they are all valid Avro symbols
+func (c *jsonAvroConverter) enumSymbols(obj *jsonfmt.Object, path string) ([]interface{}, bool) {
+	values, ok := member(obj, "enum").([]interface{})
+	if !ok {
+		return nil, false
+	}
+	var symbols []interface{}
+	for _, value := range values {
+		symbol, ok := value.(string)
+		if !ok {
+			continue
+		}
+		if !avroNamePattern.MatchString(symbol) {
+			c.add(path, "enum value %q is not a valid Avro symbol; the enum becomes a string", symbol)
+			return nil, false
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols, len(symbols) > 0
+}
+
+// name returns a unique name for a named type and records the reference it was reached by
+func (c *jsonAvroConverter) name(obj *jsonfmt.Object, hint, ref string) string {
+	base := hint
+	if title, ok := member(obj, "title").(string); ok && title != "" {
+		base = title
+	}
+	name := uniqueName(pascalCase(base), c.names)
+	if ref != "" {
+		c.refs[ref] = name
+	}
+	return name
+}
+
+func (c *jsonAvroConverter) enum(obj *jsonfmt.Object, symbols []interface{}, hint, ref string, root bool) interface{} {
+	result := jsonfmt.NewObject("type", "enum", "name", c.name(obj, hint, ref))
+	if root && c.namespace != "" {
+		result.Set("namespace", c.namespace)
+	}
+	setDoc(result, "doc", stringMember(obj, "description"))
+	result.Set("symbols", symbols)
+	return result
+}
+
+func (c *jsonAvroConverter) record(obj, properties *jsonfmt.Object, path, hint, ref string, root bool) interface{} {
+	result := jsonfmt.NewObject("type", "record", "name", c.name(obj, hint, ref))
+	if root && c.namespace != "" {
+		result.Set("namespace", c.namespace)
+	}
+	setDoc(result, "doc", stringMember(obj, "description"))
+
+	required := map[string]bool{}
+	if list, ok := member(obj, "required").([]interface{}); ok {
+		for _, item := range list {
+			if name, ok := item.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+	switch additional := member(obj, "additionalProperties").(type) {
+	case nil, bool:
+		if additional == true {
+			c.add(path, "additional properties are dropped")
+		}
+	default:
+		c.add(path, "additional properties are dropped")
+	}
+
+	fieldNames := map[string]bool{}
+	var fields []interface{}
+	for _, property := range properties.Members {
+		fieldPath := joinPath(path, property.Key)
+		name := property.Key
+		if !avroNamePattern.MatchString(name) {
+			name = uniqueName(avroName(name), fieldNames)
+			c.add(fieldPath, "property %q becomes field %s", property.Key, name)
+		} else {
+			fieldNames[name] = true
+		}
+
+		fieldType := c.convert(property.Value, fieldPath, property.Key, "", false)
+		field := jsonfmt.NewObject("name", name)
+		setDoc(field, "doc", stringMember(property.Value, "description"))
+		defaultValue, hasDefault := memberOK(property.Value, "default")
+		if !required[property.Key] {
+			fieldType = nullable(fieldType, hasDefault && defaultValue != nil)
+			if !hasDefault {
+				defaultValue, hasDefault = nil, true
+			}
+		}
+		field.Set("type", fieldType)
+		if hasDefault {
+			field.Set("default", defaultValue)
+		}
+		fields = append(fields, field)
+	}
+	result.Set("fields", fields)
+	return result
+}
+
+func (c *jsonAvroConverter) array(obj *jsonfmt.Object, path, hint string) interface{} {
+	switch items := member(obj, "items").(type) {
+	case nil:
+		if prefix, ok := member(obj, "prefixItems").([]interface{}); ok {
+			return jsonfmt.NewObject("type", "array", "items", c.tuple(prefix, path, hint))
+		}
+		c.add(path+"[]", "array items of any type are mapped to string")
+		return jsonfmt.NewObject("type", "array", "items", "string")
+	case []interface{}:
+		return jsonfmt.NewObject("type", "array", "items", c.tuple(items, path, hint))
+	default:
+		return jsonfmt.NewObject("type", "array", "items", c.convert(items, path+"[]", hint+"Item", "", false))
+	}
+}
+
+// tuple converts positional items to a union of their types
+func (c *jsonAvroConverter) tuple(items []interface{}, path, hint string) interface{} {
+	c.add(path+"[]", "item positions are not represented; items become a union of the positional types")
+	var branches []interface{}
+	for i, item := range items {
+		branches = append(branches, c.convert(item, fmt.Sprintf("%s[%d]", path, i), fmt.Sprintf("%sItem%d", hint, i), "", false))
+	}
+	return flattenUnion(branches)
+}
+
+func (c *jsonAvroConverter) mapType(obj *jsonfmt.Object, path, hint string) interface{} {
+	switch additional := member(obj, "additionalProperties").(type) {
+	case *jsonfmt.Object:
+		return jsonfmt.NewObject("type", "map", "values", c.convert(additional, path+"{}", hint+"Value", "", false))
+	case bool:
+		if !additional {
+			c.add(path, "object without properties becomes an empty record")
+			return jsonfmt.NewObject("type", "record", "name", c.name(obj, hint, ""), "fields", []interface{}{})
+		}
+	}
+	c.add(path+"{}", "object values of any type are mapped to string")
+	return jsonfmt.NewObject("type", "map", "values", "string")
+}
+
+// union converts anyOf or oneOf branches to a union
+func (c *jsonAvroConverter) union(branches []interface{}, path, hint string) interface{} {
+	var converted []interface{}
+	for i, branch := range branches {
+		converted = append(converted, c.convert(branch, path, fmt.Sprintf("%sOption%d", hint, i+1), "", false))
+	}
+	union := flattenUnion(converted)
+	if list, ok := union.([]interface{}); ok && len(list) < len(converted) {
+		c.add(path, "alternatives of the same Avro type are merged")
+	}
+	return union
+}
+
+// any maps a schema that allows any value to string
+func (c *jsonAvroConverter) any(path string) interface{} {
+	c.add(path, "values of any type have no Avro equivalent; mapped to string")
+	return "string"
+}
+
+// flattenUnion builds a union from types, flattening nested unions and dropping
+// duplicate unnamed types, which Avro does not allow in one union
+func flattenUnion(types []interface{}) interface{} {
+	var branches []interface{}
+	seen := map[string]bool{}
+	var add func(t interface{})
+	add = func(t interface{}) {
+		if list, ok := t.([]interface{}); ok {
+			for _, item := range list {
+				add(item)
+			}
+			return
+		}
+		key := unionKey(t)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		branches = append(branches, t)
+	}
+	for _, t := range types {
+		add(t)
+	}
+	if len(branches) == 1 {
+		return branches[0]
+	}
+	return branches
+}
+
+// unionKey identifies a union branch: its name for named types, its type otherwise
+func unionKey(t interface{}) string {
+	switch v := t.(type) {
+	case string:
+		return v
+	case *jsonfmt.Object:
+		if name, ok := member(v, "name").(string); ok {
+			return name
+		}
+		return fmt.Sprint(member(v, "type"))
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// nullable adds null to a type: first, or last when the field has a non-null default,
+// which must match the first branch
+func nullable(t interface{}, nonNullDefault bool) interface{} {
+	branches, ok := t.([]interface{})
+	if !ok {
+		branches = []interface{}{t}
+	}
+	var rest []interface{}
+	for _, branch := range branches {
+		if branch != "null" {
+			rest = append(rest, branch)
+		}
+	}
+	if nonNullDefault {
+		return append(rest, "null")
+	}
+	return append([]interface{}{"null"}, rest...)
+}
+
+// avroName replaces the characters of a name that Avro does not allow
+func avroName(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		valid := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9')
+		if !valid {
+			if i == 0 && r >= '0' && r <= '9' {
+				sb.WriteByte('_')
+				sb.WriteRune(r)
+				continue
+			}
+			r = '_'
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// member returns a member of a JSON object, or nil
+func member(value interface{}, key string) interface{} {
+	result, _ := memberOK(value, key)
+	return result
+}
+
+// memberOK returns a member of a JSON object and whether it is present
+func memberOK(value interface{}, key string) (interface{}, bool) {
+	obj, ok := value.(*jsonfmt.Object)
+	if !ok {
+		return nil, false
+	}
+	return obj.Get(key)
+}
+
+// stringMember returns a string member of a JSON object, or ""
+func stringMember(value interface{}, key string) string {
+	s, _ := member(value, key).(string)
+	return s
+}
+
+// numberMember returns a numeric member of a JSON object
+func numberMember(obj *jsonfmt.Object, key string) (float64, bool) {
+	n, ok := member(obj, key).(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
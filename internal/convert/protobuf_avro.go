@@ -0,0 +1,256 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/internal/avro"
+	"github.com/aywengo/ksr-cli/internal/jsonfmt"
+	"github.com/aywengo/ksr-cli/internal/protobuf"
+)
+
+// protobufScalars maps Protobuf scalar types to Avro types
+var protobufScalars = map[string]string{
+	"double": "double", "float": "float",
+	"int32": "int", "sint32": "int", "sfixed32": "int",
+	"uint32": "long", "fixed32": "long",
+	"int64": "long", "sint64": "long", "sfixed64": "long",
+	"uint64": "long", "fixed64": "long",
+	"bool": "boolean", "string": "string", "bytes": "bytes",
+}
+
+// protobufWrappers maps the wrapper well-known types to the Avro type they wrap
+var protobufWrappers = map[string]string{
+	"google.protobuf.DoubleValue": "double", "google.protobuf.FloatValue": "float",
+	"google.protobuf.Int64Value": "long", "google.protobuf.UInt64Value": "long",
+	"google.protobuf.Int32Value": "int", "google.protobuf.UInt32Value": "long",
+	"google.protobuf.BoolValue": "boolean", "google.protobuf.StringValue": "string",
+	"google.protobuf.BytesValue": "bytes",
+}
+
+// protobufToAvro converts a message of a Protobuf schema to an Avro record. Messages
+// become records named after the message, in a namespace made of the package and the
+// enclosing messages.
+func protobufToAvro(content string, references []string, opts Options) (*Result, error) {
+	f, registry, err := protobuf.ParseWithReferences(content, references)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Messages) == 0 {
+		return nil, fmt.Errorf("schema defines no messages")
+	}
+
+	indexes := []int{0}
+	if opts.Message != "" {
+		if indexes, err = protobuf.MessageIndexes(f, opts.Message); err != nil {
+			return nil, err
+		}
+	}
+	m, err := protobuf.MessageByIndexes(f, indexes)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &protoAvroConverter{registry: registry, defined: map[string]bool{}}
+	if len(f.Services) > 0 {
+		c.add("", "services are dropped")
+	}
+	root := c.message(m, "")
+	if opts.Namespace != "" {
+		root.Set("namespace", opts.Namespace)
+	}
+
+	formatted, err := avro.Format(jsonfmt.Marshal(root))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := avro.Parse(formatted); err != nil {
+		return nil, fmt.Errorf("converted schema is not valid Avro: %w", err)
+	}
+	return &Result{Content: formatted, Losses: c.losses.list}, nil
+}
+
+type protoAvroConverter struct {
+	losses
+	registry *protobuf.Registry
+	// defined holds the full names of the records and enums written so far
+	defined map[string]bool
+}
+
+// namespace returns the Avro namespace of a message or enum: its full name without the
+// last part
+func namespace(fullName string) string {
+	if i := strings.LastIndex(fullName, "."); i >= 0 {
+		return fullName[:i]
+	}
+	return ""
+}
+
+// message converts a message to a record, or to its name if it was written before
+func (c *protoAvroConverter) message(m *protobuf.Message, path string) *jsonfmt.Object {
+	result := jsonfmt.NewObject("type", "record", "name", m.Name)
+	if ns := namespace(m.FullName); ns != "" {
+		result.Set("namespace", ns)
+	}
+	c.defined[m.FullName] = true
+	setDoc(result, "doc", commentText(m.LeadingComments))
+
+	var fields []interface{}
+	for _, oneof := range m.Oneofs {
+		c.add(joinPath(path, oneof.Name), "oneof %s becomes optional fields; setting at most one of them is not enforced", oneof.Name)
+	}
+	for _, field := range m.Fields {
+		fields = append(fields, c.field(field, joinPath(path, field.Name)))
+	}
+	result.Set("fields", fields)
+	return result
+}
+
+// field converts a field. Fields without presence default to the zero value of their
+// type, as proto3 readers assume; fields with presence are nullable.
+func (c *protoAvroConverter) field(field *protobuf.Field, path string) *jsonfmt.Object {
+	result := jsonfmt.NewObject("name", field.Name)
+	setDoc(result, "doc", commentText(field.LeadingComments))
+
+	switch {
+	case field.IsMap:
+		if field.KeyType != "string" {
+			c.add(path, "map keys of type %s become strings", field.KeyType)
+		}
+		result.Set("type", jsonfmt.NewObject("type", "map", "values", c.valueType(field, path+"{}")))
+		result.Set("default", &jsonfmt.Object{})
+	case field.Label == "repeated":
+		result.Set("type", jsonfmt.NewObject("type", "array", "items", c.valueType(field, path+"[]")))
+		result.Set("default", []interface{}{})
+	default:
+		t := c.valueType(field, path)
+		message, _, _ := c.registry.ResolveField(field)
+		switch {
+		case field.Label == "required":
+			result.Set("type", t)
+		case field.Oneof != nil || field.Label == "optional" || message != nil:
+			result.Set("type", nullable(t, false))
+			result.Set("default", nil)
+		default:
+			result.Set("type", t)
+			result.Set("default", c.zeroValue(field, t))
+		}
+	}
+	return result
+}
+
+// valueType converts the type of a field's values
+func (c *protoAvroConverter) valueType(field *protobuf.Field, path string) interface{} {
+	if scalar, ok := protobufScalars[field.Type]; ok {
+		if field.Type == "uint64" || field.Type == "fixed64" {
+			c.add(path, "%s values above 2^63-1 do not fit the Avro long", field.Type)
+		}
+		return scalar
+	}
+
+	message, enum, err := c.registry.ResolveField(field)
+	if err != nil {
+		c.add(path, "type %s is not resolved; mapped to string", field.Type)
+		return "string"
+	}
+	if enum != nil {
+		return c.enum(enum, path)
+	}
+	if protobuf.IsWellKnownType(message.FullName) {
+		return c.wellKnown(message, path)
+	}
+	if c.defined[message.FullName] {
+		return message.FullName
+	}
+	return c.message(message, path)
+}
+
+// wellKnown converts a well-known type
+func (c *protoAvroConverter) wellKnown(m *protobuf.Message, path string) interface{} {
+	if wrapped, ok := protobufWrappers[m.FullName]; ok {
+		return []interface{}{"null", wrapped}
+	}
+	switch m.FullName {
+	case "google.protobuf.Timestamp":
+		c.add(path, "Timestamp becomes a timestamp-micros long; nanoseconds are truncated")
+		return jsonfmt.NewObject("type", "long", "logicalType", "timestamp-micros")
+	case "google.protobuf.Empty":
+		if c.defined[m.FullName] {
+			return m.FullName
+		}
+		c.defined[m.FullName] = true
+		return jsonfmt.NewObject("type", "record", "name", "Empty", "namespace", "google.protobuf", "fields", []interface{}{})
+	default:
+		c.add(path, "%s is mapped to a string holding its Protobuf JSON form", m.FullName)
+		return "string"
+	}
+}
+
+// enum converts an enum, or returns its name if it was written before. Values become
+// symbols in declaration order; their numbers are dropped.
+func (c *protoAvroConverter) enum(e *protobuf.Enum, path string) interface{} {
+	if c.defined[e.FullName] {
+		return e.FullName
+	}
+	c.defined[e.FullName] = true
+
+	var symbols []interface{}
+	seen := map[int]bool{}
+	for i, value := range e.Values {
+		if value.Number != i && !seen[value.Number] {
+			c.add(path, "enum numbers of %s are dropped; symbols are numbered by position", e.FullName)
+		}
+		if seen[value.Number] {
+			c.add(path, "enum value %s is an alias and is dropped", value.Name)
+			continue
+		}
+		seen[value.Number] = true
+		symbols = append(symbols, value.Name)
+	}
+
+	result := jsonfmt.NewObject("type", "enum", "name", e.Name)
+	if ns := namespace(e.FullName); ns != "" {
+		result.Set("namespace", ns)
+	}
+	setDoc(result, "doc", commentText(e.LeadingComments))
+	result.Set("symbols", symbols)
+	return result
+}
+
+// zeroValue returns the default of a field without presence: the zero value of its type
+func (c *protoAvroConverter) zeroValue(field *protobuf.Field, t interface{}) interface{} {
+	if _, enum, err := c.registry.ResolveField(field); err == nil && enum != nil {
+		for _, value := range enum.Values {
+			if value.Number == 0 {
+				return value.Name
+			}
+		}
+		return enum.Values[0].Name
+	}
+	switch t {
+	case "boolean":
+		return false
+	case "string", "bytes":
+		return ""
+	default:
+		return jsonNumber(0)
+	}
+}
+
+// commentText turns comment lines into documentation
+func commentText(comments []string) string {
+	var lines []string
+	for _, comment := range comments {
+		for _, line := range strings.Split(comment, "\n") {
+			line = strings.TrimSpace(line)
+			line = strings.TrimPrefix(line, "//")
+			line = strings.TrimPrefix(line, "/*")
+			line = strings.TrimSuffix(line, "*/")
+			line = strings.TrimPrefix(strings.TrimSpace(line), "* ")
+			if line = strings.TrimSpace(line); line != "" && line != "*" {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
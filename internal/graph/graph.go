@@ -0,0 +1,214 @@
+// Package graph models the dependency graph formed by schema references.
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Node is a registered schema version
+type Node struct {
+	Subject    string `json:"subject"`
+	Version    int    `json:"version"`
+	ID         int    `json:"id,omitempty"`
+	SchemaType string `json:"schemaType,omitempty"`
+	// Missing marks a referenced version that is not in the registry
+	Missing bool `json:"missing,omitempty"`
+}
+
+// Key identifies a node as SUBJECT@VERSION
+func (n *Node) Key() string {
+	return Key(n.Subject, n.Version)
+}
+
+// Key identifies the node of a subject version
+func Key(subject string, version int) string {
+	return fmt.Sprintf("%s@%d", subject, version)
+}
+
+// Edge is a reference from a schema to the subject version it is pinned to
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Name is the reference name: an import path or a type name
+	Name string `json:"name"`
+}
+
+// Graph is a directed graph of schema versions and their references
+type Graph struct {
+	Nodes []*Node `json:"nodes"`
+	Edges []*Edge `json:"edges"`
+
+	byKey map[string]*Node
+	edges map[string]bool
+	// from and to index the edges by the node they leave and enter, in insertion order
+	from map[string][]*Edge
+	to   map[string][]*Edge
+}
+
+// New creates an empty graph
+func New() *Graph {
+	return &Graph{
+		byKey: map[string]*Node{},
+		edges: map[string]bool{},
+		from:  map[string][]*Edge{},
+		to:    map[string][]*Edge{},
+	}
+}
+
+// Node returns the node with a key
+func (g *Graph) Node(key string) (*Node, bool) {
+	n, ok := g.byKey[key]
+	return n, ok
+}
+
+// AddNode adds a node, returning false when a node with the same key exists
+func (g *Graph) AddNode(n *Node) bool {
+	if _, ok := g.byKey[n.Key()]; ok {
+		return false
+	}
+	g.byKey[n.Key()] = n
+	g.Nodes = append(g.Nodes, n)
+	return true
+}
+
+// AddEdge adds a reference between two nodes, once per reference name
+func (g *Graph) AddEdge(from, to *Node, name string) {
+	id := from.Key() + "\x00" + to.Key() + "\x00" + name
+	if g.edges[id] {
+		return
+	}
+	g.edges[id] = true
+	e := &Edge{From: from.Key(), To: to.Key(), Name: name}
+	g.Edges = append(g.Edges, e)
+	g.from[e.From] = append(g.from[e.From], e)
+	g.to[e.To] = append(g.to[e.To], e)
+}
+
+// References returns the edges leaving a node, in the order they were added. The slice
+// is shared with the graph and must not be modified.
+func (g *Graph) References(key string) []*Edge {
+	return g.from[key]
+}
+
+// ReferencedBy returns the edges entering a node, in the order they were added. The slice
+// is shared with the graph and must not be modified.
+func (g *Graph) ReferencedBy(key string) []*Edge {
+	return g.to[key]
+}
+
+// Roots returns the keys of the nodes no other node references, in sorted order. Nodes
+// only reachable through a cycle are included, so that every node is under a root.
+func (g *Graph) Roots() []string {
+	referenced := map[string]bool{}
+	for _, e := range g.Edges {
+		referenced[e.To] = true
+	}
+	var roots []string
+	for _, n := range g.Nodes {
+		if !referenced[n.Key()] {
+			roots = append(roots, n.Key())
+		}
+	}
+	sort.Strings(roots)
+
+	reached := map[string]bool{}
+	var reach func(key string)
+	reach = func(key string) {
+		if reached[key] {
+			return
+		}
+		reached[key] = true
+		for _, e := range g.References(key) {
+			reach(e.To)
+		}
+	}
+	for _, root := range roots {
+		reach(root)
+	}
+	keys := g.sortedKeys()
+	for _, key := range keys {
+		if !reached[key] {
+			roots = append(roots, key)
+			reach(key)
+		}
+	}
+	return roots
+}
+
+// Dangling returns the references to versions that are not in the registry
+func (g *Graph) Dangling() []*Edge {
+	var edges []*Edge
+	for _, e := range g.Edges {
+		if n, ok := g.byKey[e.To]; ok && n.Missing {
+			edges = append(edges, e)
+		}
+	}
+	return edges
+}
+
+// Cycles returns the reference cycles of the graph, each as the keys of its nodes starting
+// from the smallest one
+func (g *Graph) Cycles() [][]string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	var stack []string
+	var cycles [][]string
+	seen := map[string]bool{}
+
+	var visit func(key string)
+	visit = func(key string) {
+		state[key] = visiting
+		stack = append(stack, key)
+		for _, e := range g.References(key) {
+			switch state[e.To] {
+			case unvisited:
+				visit(e.To)
+			case visiting:
+				start := len(stack) - 1
+				for stack[start] != e.To {
+					start--
+				}
+				cycle := rotate(append([]string(nil), stack[start:]...))
+				id := strings.Join(cycle, "\x00")
+				if !seen[id] {
+					seen[id] = true
+					cycles = append(cycles, cycle)
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[key] = done
+	}
+	for _, key := range g.sortedKeys() {
+		if state[key] == unvisited {
+			visit(key)
+		}
+	}
+	return cycles
+}
+
+// rotate starts a cycle at its smallest key
+func rotate(cycle []string) []string {
+	smallest := 0
+	for i, key := range cycle {
+		if key < cycle[smallest] {
+			smallest = i
+		}
+	}
+	return append(cycle[smallest:], cycle[:smallest]...)
+}
+
+func (g *Graph) sortedKeys() []string {
+	keys := make([]string, 0, len(g.Nodes))
+	for _, n := range g.Nodes {
+		keys = append(keys, n.Key())
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,135 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+)
+
+// sample builds orders@2 -> money@1 -> currency@1, orders@2 -> customer@3 -> currency@1,
+// and customer@3 -> address@4, which is missing
+func sample() *Graph {
+	g := New()
+	orders := &Node{Subject: "orders", Version: 2, ID: 10, SchemaType: "PROTOBUF"}
+	money := &Node{Subject: "money", Version: 1, ID: 3, SchemaType: "PROTOBUF"}
+	currency := &Node{Subject: "currency", Version: 1, ID: 1, SchemaType: "PROTOBUF"}
+	customer := &Node{Subject: "customer", Version: 3, ID: 7, SchemaType: "PROTOBUF"}
+	address := &Node{Subject: "address", Version: 4, Missing: true}
+	for _, n := range []*Node{orders, money, currency, customer, address} {
+		g.AddNode(n)
+	}
+	g.AddEdge(orders, money, "money.proto")
+	g.AddEdge(orders, customer, "customer.proto")
+	g.AddEdge(money, currency, "currency.proto")
+	g.AddEdge(customer, currency, "currency.proto")
+	g.AddEdge(customer, address, "address.proto")
+	g.AddEdge(customer, address, "address.proto")
+	return g
+}
+
+func TestGraph(t *testing.T) {
+	g := sample()
+	if len(g.Edges) != 5 {
+		t.Errorf("Expected duplicate edges to be merged, got %d edges", len(g.Edges))
+	}
+	if g.AddNode(&Node{Subject: "orders", Version: 2}) {
+		t.Error("Expected an existing node not to be added again")
+	}
+	if roots := strings.Join(g.Roots(), " "); roots != "orders@2" {
+		t.Errorf("Expected root orders@2, got %s", roots)
+	}
+	if dangling := g.Dangling(); len(dangling) != 1 || dangling[0].From != "customer@3" || dangling[0].To != "address@4" {
+		t.Errorf("Expected one dangling reference from customer@3, got %+v", dangling)
+	}
+	if cycles := g.Cycles(); len(cycles) != 0 {
+		t.Errorf("Expected no cycles, got %v", cycles)
+	}
+	if refs := g.References("customer@3"); len(refs) != 2 || refs[0].To != "currency@1" || refs[1].To != "address@4" {
+		t.Errorf("Expected the references of customer@3 in order, got %+v", refs)
+	}
+	if refs := g.ReferencedBy("currency@1"); len(refs) != 2 || refs[0].From != "money@1" || refs[1].From != "customer@3" {
+		t.Errorf("Expected the references to currency@1 in order, got %+v", refs)
+	}
+}
+
+func TestGraph_Cycles(t *testing.T) {
+	g := New()
+	a := &Node{Subject: "a", Version: 1}
+	b := &Node{Subject: "b", Version: 1}
+	c := &Node{Subject: "c", Version: 1}
+	for _, n := range []*Node{c, b, a} {
+		g.AddNode(n)
+	}
+	g.AddEdge(b, c, "c")
+	g.AddEdge(c, a, "a")
+	g.AddEdge(a, b, "b")
+
+	cycles := g.Cycles()
+	if len(cycles) != 1 || strings.Join(cycles[0], " ") != "a@1 b@1 c@1" {
+		t.Errorf("Expected cycle a@1 b@1 c@1, got %v", cycles)
+	}
+	// Nodes only reachable through a cycle still get a root
+	if roots := strings.Join(g.Roots(), " "); roots != "a@1" {
+		t.Errorf("Expected root a@1, got %s", roots)
+	}
+	if tree := g.Tree(g.Roots(), false); !strings.Contains(tree, "a → a@1 (cycle)") {
+		t.Errorf("Expected the cycle to be marked in the tree, got:\n%s", tree)
+	}
+}
+
+func TestTree(t *testing.T) {
+	g := sample()
+	expected := `orders@2 [PROTOBUF, id 10]
+├── money.proto → money@1 [PROTOBUF, id 3]
+│   └── currency.proto → currency@1 [PROTOBUF, id 1]
+└── customer.proto → customer@3 [PROTOBUF, id 7]
+    ├── currency.proto → currency@1 [PROTOBUF, id 1]
+    └── address.proto → address@4 (missing)
+`
+	if got := g.Tree(g.Roots(), false); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	expected = `currency@1 [PROTOBUF, id 1]
+├── money@1 [PROTOBUF, id 3] via currency.proto
+│   └── orders@2 [PROTOBUF, id 10] via money.proto
+└── customer@3 [PROTOBUF, id 7] via currency.proto
+    └── orders@2 [PROTOBUF, id 10] via customer.proto
+`
+	if got := g.Tree([]string{"currency@1"}, true); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	// A node expanded before is not expanded again
+	g.AddEdge(&Node{Subject: "invoices", Version: 1}, &Node{Subject: "customer", Version: 3}, "customer.proto")
+	if got := g.Tree([]string{"orders@2", "invoices@1"}, false); !strings.HasSuffix(got, "invoices@1\n└── customer.proto → customer@3 [PROTOBUF, id 7] (see above)\n") {
+		t.Errorf("Expected customer@3 to be shown once, got:\n%s", got)
+	}
+}
+
+func TestDOT(t *testing.T) {
+	dot := sample().DOT()
+	for _, want := range []string{
+		"digraph references {",
+		`"orders@2" [label="orders\nv2"];`,
+		`"address@4" [label="address\nv4", style=dashed, color=red];`,
+		`"customer@3" -> "address@4" [label="address.proto (v4)"];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("Expected DOT to contain %s, got:\n%s", want, dot)
+		}
+	}
+}
+
+func TestMermaid(t *testing.T) {
+	mermaid := sample().Mermaid()
+	for _, want := range []string{
+		"flowchart LR\n",
+		`  n0["orders v2"]`,
+		`  n0 -->|"money.proto (v1)"| n1`,
+		"  class n4 missing\n",
+	} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("Expected Mermaid to contain %s, got:\n%s", want, mermaid)
+		}
+	}
+}
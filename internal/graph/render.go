@@ -0,0 +1,152 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tree renders the graph as an indented tree under the given roots, following references,
+// or the references to each node when dependents is set. A node already expanded is shown
+// again without its children.
+func (g *Graph) Tree(roots []string, dependents bool) string {
+	var sb strings.Builder
+	expanded := map[string]bool{}
+
+	var walk func(key, prefix string, path map[string]bool)
+	walk = func(key, prefix string, path map[string]bool) {
+		edges := g.References(key)
+		if dependents {
+			edges = g.ReferencedBy(key)
+		}
+		for i, e := range edges {
+			branch, indent := "├── ", "│   "
+			if i == len(edges)-1 {
+				branch, indent = "└── ", "    "
+			}
+			next := e.To
+			if dependents {
+				next = e.From
+			}
+			if dependents {
+				fmt.Fprintf(&sb, "%s%s%s via %s", prefix, branch, g.label(next), e.Name)
+			} else {
+				fmt.Fprintf(&sb, "%s%s%s → %s", prefix, branch, e.Name, g.label(next))
+			}
+			switch {
+			case path[next]:
+				sb.WriteString(" (cycle)\n")
+			case expanded[next] && len(g.children(next, dependents)) > 0:
+				sb.WriteString(" (see above)\n")
+			default:
+				sb.WriteString("\n")
+				expanded[next] = true
+				path[next] = true
+				walk(next, prefix+indent, path)
+				delete(path, next)
+			}
+		}
+	}
+
+	for i, root := range roots {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(g.label(root))
+		sb.WriteString("\n")
+		expanded[root] = true
+		walk(root, "", map[string]bool{root: true})
+	}
+	return sb.String()
+}
+
+func (g *Graph) children(key string, dependents bool) []*Edge {
+	if dependents {
+		return g.ReferencedBy(key)
+	}
+	return g.References(key)
+}
+
+// label describes a node for the tree
+func (g *Graph) label(key string) string {
+	n, ok := g.byKey[key]
+	if !ok {
+		return key
+	}
+	if n.Missing {
+		return key + " (missing)"
+	}
+	var details []string
+	if n.SchemaType != "" {
+		details = append(details, n.SchemaType)
+	}
+	if n.ID != 0 {
+		details = append(details, fmt.Sprintf("id %d", n.ID))
+	}
+	if len(details) == 0 {
+		return key
+	}
+	return fmt.Sprintf("%s [%s]", key, strings.Join(details, ", "))
+}
+
+// DOT renders the graph in the Graphviz DOT language. Edges point from a schema to the
+// version it references and are labeled with the reference name and the pinned version.
+func (g *Graph) DOT() string {
+	var sb strings.Builder
+	sb.WriteString("digraph references {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box];\n")
+	for _, n := range g.Nodes {
+		attrs := fmt.Sprintf("label=%s", dotQuote(fmt.Sprintf("%s\nv%d", n.Subject, n.Version)))
+		if n.Missing {
+			attrs += ", style=dashed, color=red"
+		}
+		fmt.Fprintf(&sb, "  %s [%s];\n", dotQuote(n.Key()), attrs)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&sb, "  %s -> %s [label=%s];\n", dotQuote(e.From), dotQuote(e.To), dotQuote(g.edgeLabel(e)))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart
+func (g *Graph) Mermaid() string {
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+	ids := map[string]string{}
+	var missing []string
+	for i, n := range g.Nodes {
+		id := fmt.Sprintf("n%d", i)
+		ids[n.Key()] = id
+		fmt.Fprintf(&sb, "  %s[\"%s v%d\"]\n", id, mermaidEscape(n.Subject), n.Version)
+		if n.Missing {
+			missing = append(missing, id)
+		}
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&sb, "  %s -->|\"%s\"| %s\n", ids[e.From], mermaidEscape(g.edgeLabel(e)), ids[e.To])
+	}
+	if len(missing) > 0 {
+		sb.WriteString("  classDef missing stroke:#d00,stroke-dasharray:5 5\n")
+		fmt.Fprintf(&sb, "  class %s missing\n", strings.Join(missing, ","))
+	}
+	return sb.String()
+}
+
+// edgeLabel shows the reference name and the version it is pinned to
+func (g *Graph) edgeLabel(e *Edge) string {
+	if n, ok := g.byKey[e.To]; ok {
+		return fmt.Sprintf("%s (v%d)", e.Name, n.Version)
+	}
+	return e.Name
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + strings.ReplaceAll(s, "\n", `\n`) + `"`
+}
+
+func mermaidEscape(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
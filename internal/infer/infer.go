@@ -0,0 +1,515 @@
+// Package infer derives Avro, JSON Schema and Protobuf schemas from sample JSON records.
+// Records are merged field by field: fields missing from some records or null in some
+// become optional, fields holding values of several JSON types become unions, and nested
+// objects and arrays are inferred recursively. Strings and numbers are inspected for
+// timestamps, dates and UUIDs.
+package infer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/aywengo/ksr-cli/internal/convert"
+	"github.com/aywengo/ksr-cli/internal/jsonfmt"
+)
+
+// Options names the inferred root type
+type Options struct {
+	// Name of the root record, "Record" by default
+	Name string
+	// Namespace of the Avro records and package of the Protobuf file
+	Namespace string
+}
+
+// Note reports an assumption made while inferring, or a loss of the Protobuf conversion.
+// Path is the field path, such as "address.city" or "items[]".
+type Note struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Result is an inferred schema
+type Result struct {
+	Content string `json:"schema"`
+	Records int    `json:"records"`
+	Notes   []Note `json:"notes"`
+}
+
+// Inferrer accumulates sample records
+type Inferrer struct {
+	root    *shape
+	records int
+}
+
+// New returns an inferrer without records
+func New() *Inferrer {
+	return &Inferrer{root: &shape{}}
+}
+
+// Add merges a record decoded by jsonfmt.Decode. Records must be JSON objects.
+func (i *Inferrer) Add(record interface{}) error {
+	if _, ok := record.(*jsonfmt.Object); !ok {
+		return fmt.Errorf("record is a JSON %s, not an object", kindOf(record))
+	}
+	i.root.observe(record)
+	i.records++
+	return nil
+}
+
+// Records returns the number of records added
+func (i *Inferrer) Records() int {
+	return i.records
+}
+
+// Schema writes the inferred schema in a format
+func (i *Inferrer) Schema(format convert.Format, opts Options) (*Result, error) {
+	if i.records == 0 {
+		return nil, fmt.Errorf("no records to infer a schema from")
+	}
+	if opts.Name == "" {
+		opts.Name = "Record"
+	}
+
+	w := &writer{opts: opts, names: map[string]bool{}}
+	var content string
+	switch format {
+	case convert.Avro:
+		content = jsonfmt.Marshal(w.avroRecord(i.root, opts.Name, ""))
+	case convert.JSONSchema:
+		root := &jsonfmt.Object{}
+		root.Set("$schema", jsonSchemaDraft)
+		root.Set("title", opts.Name)
+		root.Members = append(root.Members, w.jsonSchema(i.root, "", "").Members...)
+		content = jsonfmt.Marshal(root)
+	case convert.Protobuf:
+		// Protobuf goes through Avro, whose converter already maps unions to oneofs and
+		// wraps nested collections
+		avro := jsonfmt.Marshal(w.avroRecord(i.root, opts.Name, ""))
+		converted, err := convert.Convert(convert.Avro, convert.Protobuf, avro, nil, convert.Options{Namespace: opts.Namespace})
+		if err != nil {
+			return nil, err
+		}
+		content = converted.Content
+		for _, loss := range converted.Losses {
+			w.note(loss.Path, "%s", loss.Message)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported schema format: %s", format)
+	}
+
+	if w.notes == nil {
+		w.notes = []Note{}
+	}
+	return &Result{Content: content, Records: i.records, Notes: w.notes}, nil
+}
+
+// shape accumulates the values seen at one position of the records
+type shape struct {
+	count    int // values seen, nulls included
+	nulls    int
+	booleans int
+	integers int
+	numbers  int // numbers with a fraction or beyond 64 bits
+	min, max int64
+
+	strings   int
+	dateTimes int
+	dates     int
+	uuids     int
+
+	arrays int
+	items  *shape
+
+	objects int
+	fields  map[string]*shape
+	order   []string
+}
+
+func (s *shape) observe(value interface{}) {
+	s.count++
+	switch v := value.(type) {
+	case nil:
+		s.nulls++
+	case bool:
+		s.booleans++
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil {
+			s.numbers++
+			return
+		}
+		if s.integers == 0 || n < s.min {
+			s.min = n
+		}
+		if s.integers == 0 || n > s.max {
+			s.max = n
+		}
+		s.integers++
+	case string:
+		s.strings++
+		switch {
+		case isDateTime(v):
+			s.dateTimes++
+		case isDate(v):
+			s.dates++
+		case uuidPattern.MatchString(v):
+			s.uuids++
+		}
+	case []interface{}:
+		s.arrays++
+		if s.items == nil {
+			s.items = &shape{}
+		}
+		for _, item := range v {
+			s.items.observe(item)
+		}
+	case *jsonfmt.Object:
+		s.objects++
+		if s.fields == nil {
+			s.fields = map[string]*shape{}
+		}
+		for _, member := range v.Members {
+			field, ok := s.fields[member.Key]
+			if !ok {
+				field = &shape{}
+				s.fields[member.Key] = field
+				s.order = append(s.order, member.Key)
+			}
+			field.observe(member.Value)
+		}
+	}
+}
+
+// kinds lists the JSON types seen, other than null, in a fixed order
+func (s *shape) kinds() []string {
+	var kinds []string
+	if s.booleans > 0 {
+		kinds = append(kinds, "boolean")
+	}
+	if s.numbers > 0 {
+		kinds = append(kinds, "number")
+	} else if s.integers > 0 {
+		kinds = append(kinds, "integer")
+	}
+	if s.strings > 0 {
+		kinds = append(kinds, "string")
+	}
+	if s.arrays > 0 {
+		kinds = append(kinds, "array")
+	}
+	if s.objects > 0 {
+		kinds = append(kinds, "object")
+	}
+	return kinds
+}
+
+// stringFormat returns the format every string seen matches: date-time, date, uuid or ""
+func (s *shape) stringFormat() string {
+	switch s.strings {
+	case 0:
+		return ""
+	case s.dateTimes:
+		return "date-time"
+	case s.dates:
+		return "date"
+	case s.uuids:
+		return "uuid"
+	default:
+		return ""
+	}
+}
+
+// timestampUnit returns the unit of the epoch timestamps held by an integer field named
+// like a point in time (created_at, updatedAt, event_time, ts), or "" if the values are
+// not plausible timestamps between 2000 and 2100
+func (s *shape) timestampUnit(name string) string {
+	if s.integers == 0 || s.numbers > 0 || !temporalName(name) {
+		return ""
+	}
+	for _, unit := range []struct {
+		name  string
+		scale int64
+	}{{"millis", 1e3}, {"micros", 1e6}} {
+		if s.min >= epoch2000*unit.scale && s.max < epoch2100*unit.scale {
+			return unit.name
+		}
+	}
+	return ""
+}
+
+const (
+	epoch2000 = 946684800
+	epoch2100 = 4102444800
+
+	jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func isDateTime(s string) bool {
+	_, err := time.Parse(time.RFC3339Nano, s)
+	return err == nil
+}
+
+func isDate(s string) bool {
+	_, err := time.Parse(time.DateOnly, s)
+	return err == nil
+}
+
+// temporalName reports whether a field name suggests a point in time
+func temporalName(name string) bool {
+	lower := strings.ToLower(name)
+	for _, suffix := range []string{"_at", "_ts", "time", "timestamp", "date"} {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	runes := []rune(name)
+	n := len(runes)
+	// camelCase names such as createdAt
+	return lower == "ts" || (n > 2 && runes[n-2] == 'A' && runes[n-1] == 't' && unicode.IsLower(runes[n-3]))
+}
+
+func kindOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// writer turns shapes into schemas, naming records and collecting notes
+type writer struct {
+	opts  Options
+	names map[string]bool
+	notes []Note
+}
+
+func (w *writer) note(path, format string, args ...interface{}) {
+	if path == "" {
+		path = "<root>"
+	}
+	w.notes = append(w.notes, Note{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// avroRecord writes an Avro record for the objects seen in a shape
+func (w *writer) avroRecord(s *shape, name, path string) *jsonfmt.Object {
+	record := &jsonfmt.Object{}
+	record.Set("type", "record")
+	record.Set("name", w.recordName(name))
+	if path == "" && w.opts.Namespace != "" {
+		record.Set("namespace", w.opts.Namespace)
+	}
+
+	fields := []interface{}{}
+	for _, key := range s.order {
+		fieldPath := joinPath(path, key)
+		if !avroNamePattern.MatchString(key) {
+			w.note(fieldPath, "%q is not a valid Avro name; rename the field before using the schema", key)
+		}
+		field := s.fields[key]
+		optional := field.count < s.objects || field.nulls > 0
+		fieldType := w.avroType(field, key, fieldPath, optional)
+
+		entry := &jsonfmt.Object{}
+		entry.Set("name", key)
+		entry.Set("type", fieldType)
+		if optional {
+			entry.Set("default", nil)
+		}
+		fields = append(fields, entry)
+	}
+	record.Set("fields", fields)
+	return record
+}
+
+var avroNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// avroType writes the Avro type of the values seen in a shape; optional types are unions
+// with null first, so that null can be the default
+func (w *writer) avroType(s *shape, name, path string, optional bool) interface{} {
+	if len(s.kinds()) == 0 {
+		w.note(path, "only null values seen; the type is null")
+		return "null"
+	}
+
+	var branches []interface{}
+	if optional {
+		branches = append(branches, "null")
+	}
+	for _, kind := range s.kinds() {
+		switch kind {
+		case "boolean":
+			branches = append(branches, "boolean")
+		case "number":
+			branches = append(branches, "double")
+		case "integer":
+			switch unit := s.timestampUnit(name); {
+			case unit != "":
+				branches = append(branches, jsonfmt.NewObject("type", "long", "logicalType", "timestamp-"+unit))
+			case s.min >= math.MinInt32 && s.max <= math.MaxInt32:
+				branches = append(branches, "int")
+			default:
+				branches = append(branches, "long")
+			}
+		case "string":
+			if s.stringFormat() == "uuid" {
+				branches = append(branches, jsonfmt.NewObject("type", "string", "logicalType", "uuid"))
+			} else {
+				branches = append(branches, "string")
+			}
+		case "array":
+			branches = append(branches, jsonfmt.NewObject("type", "array", "items", w.avroItems(s, name, path)))
+		case "object":
+			branches = append(branches, w.avroRecord(s, name, path))
+		}
+	}
+
+	if len(branches) == 1 {
+		return branches[0]
+	}
+	if len(branches) > 2 || !optional {
+		w.note(path, "values of several JSON types seen; the type is a union")
+	}
+	return branches
+}
+
+func (w *writer) avroItems(s *shape, name, path string) interface{} {
+	items := s.items
+	if items == nil || items.count == 0 {
+		w.note(path+"[]", "only empty arrays seen; items are assumed to be strings")
+		return "string"
+	}
+	return w.avroType(items, singular(name), path+"[]", items.nulls > 0)
+}
+
+// recordName returns a unique record name
+func (w *writer) recordName(name string) string {
+	name = pascalCase(name)
+	unique := name
+	for i := 2; w.names[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	w.names[unique] = true
+	return unique
+}
+
+// jsonSchema writes the JSON Schema keywords for the values seen in a shape
+func (w *writer) jsonSchema(s *shape, name, path string) *jsonfmt.Object {
+	result := &jsonfmt.Object{}
+	kinds := s.kinds()
+	if s.nulls > 0 {
+		kinds = append(kinds, "null")
+	}
+	switch len(kinds) {
+	case 0:
+		// Arrays whose items were never seen accept any item
+		return result
+	case 1:
+		result.Set("type", kinds[0])
+	default:
+		types := make([]interface{}, len(kinds))
+		for i, kind := range kinds {
+			types[i] = kind
+		}
+		result.Set("type", types)
+		if len(kinds) > 2 || s.nulls == 0 {
+			w.note(path, "values of several JSON types seen")
+		}
+	}
+
+	if format := s.stringFormat(); format != "" {
+		result.Set("format", format)
+	}
+	switch s.timestampUnit(name) {
+	case "millis":
+		result.Set("description", "Epoch timestamp in milliseconds")
+	case "micros":
+		result.Set("description", "Epoch timestamp in microseconds")
+	}
+	if s.arrays > 0 {
+		if s.items == nil || s.items.count == 0 {
+			w.note(path+"[]", "only empty arrays seen; items accept any value")
+			result.Set("items", &jsonfmt.Object{})
+		} else {
+			result.Set("items", w.jsonSchema(s.items, singular(name), path+"[]"))
+		}
+	}
+	if s.objects > 0 {
+		properties := &jsonfmt.Object{}
+		var required []interface{}
+		for _, key := range s.order {
+			field := s.fields[key]
+			properties.Set(key, w.jsonSchema(field, key, joinPath(path, key)))
+			if field.count == s.objects {
+				required = append(required, key)
+			}
+		}
+		result.Set("properties", properties)
+		if len(required) > 0 {
+			result.Set("required", required)
+		}
+	}
+	return result
+}
+
+// joinPath appends a field name to a path
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// singular names the element of a collection field: "addresses" becomes "address",
+// "categories" becomes "category" and "items" becomes "item"
+func singular(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(name) > 4:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "xes"),
+		strings.HasSuffix(lower, "ches"), strings.HasSuffix(lower, "shes"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(lower, "s") && !strings.HasSuffix(lower, "ss") && !strings.HasSuffix(lower, "us") && len(name) > 3:
+		return name[:len(name)-1]
+	default:
+		return name
+	}
+}
+
+// pascalCase turns a field name into a record name: "line_items" becomes "LineItems"
+func pascalCase(name string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+	result := sb.String()
+	if result == "" || unicode.IsDigit([]rune(result)[0]) {
+		result = "T" + result
+	}
+	return result
+}
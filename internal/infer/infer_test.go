@@ -0,0 +1,145 @@
+package infer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aywengo/ksr-cli/internal/convert"
+	"github.com/aywengo/ksr-cli/internal/jsonfmt"
+	"github.com/aywengo/ksr-cli/internal/schema"
+)
+
+var samples = []string{
+	`{"id":"3f1c1e9a-7a6b-4c3d-9e2f-0a1b2c3d4e5f","created_at":1718000000000,"total":12.5,"customer":{"name":"Ann","email":"a@x.io"},"line_items":[{"sku":"A1"}],"placed":"2024-06-10T08:00:00Z"}`,
+	`{"id":"4f1c1e9a-7a6b-4c3d-9e2f-0a1b2c3d4e5f","created_at":1718000001000,"total":7,"customer":{"name":"Bob"},"line_items":[],"placed":"2024-06-10T09:00:00+02:00","code":5}`,
+	`{"id":"5f1c1e9a-7a6b-4c3d-9e2f-0a1b2c3d4e5f","created_at":1718000002000,"total":3,"customer":{"name":"Cy","email":null},"line_items":[],"placed":"2024-06-11T00:00:00Z","code":"X"}`,
+}
+
+func newInferrer(t *testing.T) *Inferrer {
+	t.Helper()
+	inferrer := New()
+	for _, sample := range samples {
+		record, err := jsonfmt.Decode([]byte(sample))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := inferrer.Add(record); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	return inferrer
+}
+
+func TestInferAvro(t *testing.T) {
+	result, err := newInferrer(t).Schema(convert.Avro, Options{Name: "Order", Namespace: "com.acme"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Records != 3 {
+		t.Errorf("Expected 3 records, got %d", result.Records)
+	}
+
+	tree, err := schema.Parse("AVRO", result.Content)
+	if err != nil {
+		t.Fatalf("Inferred schema does not parse: %v\n%s", err, result.Content)
+	}
+	_, fields := tree.Flatten()
+	expected := map[string]string{
+		"id":               "string(uuid)",
+		"created_at":       "long(timestamp-millis)",
+		"total":            "double",
+		"customer":         "com.acme.Customer",
+		"customer.email":   "union[null,string]",
+		"line_items":       "array<com.acme.LineItem>",
+		"line_items[].sku": "string",
+		"placed":           "string",
+		"code":             "union[null,int,string]",
+	}
+	for path, typ := range expected {
+		if field, ok := fields[path]; !ok || field.Type != typ {
+			t.Errorf("Expected %s to be %s, got %+v", path, typ, field)
+		}
+	}
+	if !fields["code"].HasDefault || fields["customer.name"].Nullable {
+		t.Errorf("Expected code to default to null and customer.name to be required")
+	}
+
+	var notes []string
+	for _, note := range result.Notes {
+		notes = append(notes, note.Path)
+	}
+	if strings.Join(notes, ",") != "code" {
+		t.Errorf("Expected a note for the union in code, got %v", result.Notes)
+	}
+}
+
+func TestInferJSONSchema(t *testing.T) {
+	result, err := newInferrer(t).Schema(convert.JSONSchema, Options{Name: "Order"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := schema.Parse("JSON", result.Content); err != nil {
+		t.Fatalf("Inferred schema does not parse: %v\n%s", err, result.Content)
+	}
+	for _, fragment := range []string{
+		`"title": "Order"`,
+		`"format": "date-time"`,
+		`"format": "uuid"`,
+		`"type": ["string", "null"]`,
+		`"type": ["integer", "string"]`,
+		`"required": ["id", "created_at", "total", "customer", "line_items", "placed"]`,
+	} {
+		if !strings.Contains(result.Content, fragment) {
+			t.Errorf("Expected %s in:\n%s", fragment, result.Content)
+		}
+	}
+}
+
+func TestInferProtobuf(t *testing.T) {
+	result, err := newInferrer(t).Schema(convert.Protobuf, Options{Name: "Order", Namespace: "com.acme"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := schema.Parse("PROTOBUF", result.Content); err != nil {
+		t.Fatalf("Inferred schema does not parse: %v\n%s", err, result.Content)
+	}
+	if !strings.Contains(result.Content, "repeated LineItem line_items = 5;") || !strings.Contains(result.Content, "oneof code {") {
+		t.Errorf("Unexpected schema:\n%s", result.Content)
+	}
+}
+
+func TestInferErrors(t *testing.T) {
+	if _, err := New().Schema(convert.Avro, Options{}); err == nil {
+		t.Error("Expected error without records")
+	}
+	if err := New().Add([]interface{}{}); err == nil {
+		t.Error("Expected error for a record that is not an object")
+	}
+}
+
+func TestHeuristics(t *testing.T) {
+	tests := []struct {
+		name     string
+		temporal bool
+	}{
+		{"created_at", true},
+		{"updatedAt", true},
+		{"event_time", true},
+		{"ts", true},
+		{"birth_date", true},
+		{"format", false},
+		{"count", false},
+		{"Cat", false},
+	}
+	for _, tt := range tests {
+		if got := temporalName(tt.name); got != tt.temporal {
+			t.Errorf("temporalName(%q) = %v, expected %v", tt.name, got, tt.temporal)
+		}
+	}
+
+	for name, expected := range map[string]string{"items": "item", "addresses": "address", "categories": "category", "boxes": "box", "status": "status"} {
+		if got := singular(name); got != expected {
+			t.Errorf("singular(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+// Package jsonfmt decodes JSON while keeping object keys in source order and prints it
+// in a stable, readable layout for schema files.
+package jsonfmt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Member is a key/value pair of an Object
+type Member struct {
+	Key   string
+	Value interface{}
+}
+
+// Object is a JSON object that keeps its members in source order
+type Object struct {
+	Members []Member
+}
+
+// NewObject builds an object from alternating keys and values
+func NewObject(pairs ...interface{}) *Object {
+	o := &Object{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		o.Set(pairs[i].(string), pairs[i+1])
+	}
+	return o
+}
+
+// Get returns the value of a key
+func (o *Object) Get(key string) (interface{}, bool) {
+	for _, m := range o.Members {
+		if m.Key == key {
+			return m.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Set replaces the value of a key, appending it when missing
+func (o *Object) Set(key string, value interface{}) {
+	for i := range o.Members {
+		if o.Members[i].Key == key {
+			o.Members[i].Value = value
+			return
+		}
+	}
+	o.Members = append(o.Members, Member{Key: key, Value: value})
+}
+
+// Reorder moves the given keys to the front, in the order listed; other keys keep their relative order
+func (o *Object) Reorder(keys ...string) {
+	ordered := make([]Member, 0, len(o.Members))
+	used := map[string]bool{}
+	for _, key := range keys {
+		if value, ok := o.Get(key); ok && !used[key] {
+			ordered = append(ordered, Member{Key: key, Value: value})
+			used[key] = true
+		}
+	}
+	for _, m := range o.Members {
+		if !used[m.Key] {
+			ordered = append(ordered, m)
+		}
+	}
+	o.Members = ordered
+}
+
+// MarshalJSON encodes the object compactly, keeping its members in order
+func (o *Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o.Members {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(m.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Decode parses JSON into *Object, []interface{}, string, json.Number, bool or nil values
+func Decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	value, err := decodeValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return value, nil
+}
+
+func decodeValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			obj := &Object{}
+			for decoder.More() {
+				keyToken, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, _ := keyToken.(string)
+				value, err := decodeValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				obj.Members = append(obj.Members, Member{Key: key, Value: value})
+			}
+			if _, err := decoder.Token(); err != nil {
+				return nil, err
+			}
+			return obj, nil
+		case '[':
+			array := []interface{}{}
+			for decoder.More() {
+				value, err := decodeValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			}
+			if _, err := decoder.Token(); err != nil {
+				return nil, err
+			}
+			return array, nil
+		default:
+			return nil, fmt.Errorf("unexpected delimiter %q", t)
+		}
+	default:
+		return t, nil
+	}
+}
+
+// Kind names the JSON type of a decoded value for error messages
+func Kind(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}, *Object:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// Float returns NaN and infinities, which JSON numbers cannot hold, as strings
+func Float(f float64) interface{} {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	default:
+		return f
+	}
+}
+
+// Marshal prints a decoded value with two-space indentation. Arrays holding only scalars
+// are kept on one line, so unions and enum symbols stay compact.
+func Marshal(value interface{}) string {
+	var sb strings.Builder
+	write(&sb, value, "")
+	sb.WriteByte('\n')
+	return sb.String()
+}
+
+func write(sb *strings.Builder, value interface{}, indent string) {
+	switch v := value.(type) {
+	case *Object:
+		if len(v.Members) == 0 {
+			sb.WriteString("{}")
+			return
+		}
+		sb.WriteString("{\n")
+		for i, m := range v.Members {
+			sb.WriteString(indent + "  ")
+			writeString(sb, m.Key)
+			sb.WriteString(": ")
+			write(sb, m.Value, indent+"  ")
+			if i < len(v.Members)-1 {
+				sb.WriteByte(',')
+			}
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(indent + "}")
+	case []interface{}:
+		if len(v) == 0 {
+			sb.WriteString("[]")
+			return
+		}
+		if isScalarArray(v) {
+			sb.WriteByte('[')
+			for i, item := range v {
+				if i > 0 {
+					sb.WriteString(", ")
+				}
+				write(sb, item, indent)
+			}
+			sb.WriteByte(']')
+			return
+		}
+		sb.WriteString("[\n")
+		for i, item := range v {
+			sb.WriteString(indent + "  ")
+			write(sb, item, indent+"  ")
+			if i < len(v)-1 {
+				sb.WriteByte(',')
+			}
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(indent + "]")
+	case string:
+		writeString(sb, v)
+	case json.Number:
+		sb.WriteString(v.String())
+	case bool:
+		if v {
+			sb.WriteString("true")
+		} else {
+			sb.WriteString("false")
+		}
+	case nil:
+		sb.WriteString("null")
+	default:
+		data, _ := json.Marshal(v)
+		sb.Write(data)
+	}
+}
+
+func isScalarArray(array []interface{}) bool {
+	for _, item := range array {
+		switch item.(type) {
+		case *Object, []interface{}:
+			return false
+		}
+	}
+	return true
+}
+
+func writeString(sb *strings.Builder, s string) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s)
+	sb.WriteString(strings.TrimSuffix(buf.String(), "\n"))
+}
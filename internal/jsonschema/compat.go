@@ -0,0 +1,874 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// DifferenceType identifies a kind of change between two JSON schemas.
+// The names follow the difference types reported by Confluent Schema Registry.
+type DifferenceType string
+
+const (
+	TypeExtended DifferenceType = "TYPE_EXTENDED"
+	TypeNarrowed DifferenceType = "TYPE_NARROWED"
+	TypeChanged  DifferenceType = "TYPE_CHANGED"
+
+	MaxLengthAdded     DifferenceType = "MAX_LENGTH_ADDED"
+	MaxLengthRemoved   DifferenceType = "MAX_LENGTH_REMOVED"
+	MaxLengthIncreased DifferenceType = "MAX_LENGTH_INCREASED"
+	MaxLengthDecreased DifferenceType = "MAX_LENGTH_DECREASED"
+	MinLengthAdded     DifferenceType = "MIN_LENGTH_ADDED"
+	MinLengthRemoved   DifferenceType = "MIN_LENGTH_REMOVED"
+	MinLengthIncreased DifferenceType = "MIN_LENGTH_INCREASED"
+	MinLengthDecreased DifferenceType = "MIN_LENGTH_DECREASED"
+	PatternAdded       DifferenceType = "PATTERN_ADDED"
+	PatternRemoved     DifferenceType = "PATTERN_REMOVED"
+	PatternChanged     DifferenceType = "PATTERN_CHANGED"
+
+	MaximumAdded              DifferenceType = "MAXIMUM_ADDED"
+	MaximumRemoved            DifferenceType = "MAXIMUM_REMOVED"
+	MaximumIncreased          DifferenceType = "MAXIMUM_INCREASED"
+	MaximumDecreased          DifferenceType = "MAXIMUM_DECREASED"
+	MinimumAdded              DifferenceType = "MINIMUM_ADDED"
+	MinimumRemoved            DifferenceType = "MINIMUM_REMOVED"
+	MinimumIncreased          DifferenceType = "MINIMUM_INCREASED"
+	MinimumDecreased          DifferenceType = "MINIMUM_DECREASED"
+	ExclusiveMaximumAdded     DifferenceType = "EXCLUSIVE_MAXIMUM_ADDED"
+	ExclusiveMaximumRemoved   DifferenceType = "EXCLUSIVE_MAXIMUM_REMOVED"
+	ExclusiveMaximumIncreased DifferenceType = "EXCLUSIVE_MAXIMUM_INCREASED"
+	ExclusiveMaximumDecreased DifferenceType = "EXCLUSIVE_MAXIMUM_DECREASED"
+	ExclusiveMinimumAdded     DifferenceType = "EXCLUSIVE_MINIMUM_ADDED"
+	ExclusiveMinimumRemoved   DifferenceType = "EXCLUSIVE_MINIMUM_REMOVED"
+	ExclusiveMinimumIncreased DifferenceType = "EXCLUSIVE_MINIMUM_INCREASED"
+	ExclusiveMinimumDecreased DifferenceType = "EXCLUSIVE_MINIMUM_DECREASED"
+	MultipleOfAdded           DifferenceType = "MULTIPLE_OF_ADDED"
+	MultipleOfRemoved         DifferenceType = "MULTIPLE_OF_REMOVED"
+	MultipleOfExpanded        DifferenceType = "MULTIPLE_OF_EXPANDED"
+	MultipleOfReduced         DifferenceType = "MULTIPLE_OF_REDUCED"
+	MultipleOfChanged         DifferenceType = "MULTIPLE_OF_CHANGED"
+
+	RequiredAttributeAdded   DifferenceType = "REQUIRED_ATTRIBUTE_ADDED"
+	RequiredAttributeRemoved DifferenceType = "REQUIRED_ATTRIBUTE_REMOVED"
+
+	PropertyAddedToOpenContentModel                 DifferenceType = "PROPERTY_ADDED_TO_OPEN_CONTENT_MODEL"
+	RequiredPropertyAddedToUnopenContentModel       DifferenceType = "REQUIRED_PROPERTY_ADDED_TO_UNOPEN_CONTENT_MODEL"
+	RequiredPropertyWithDefaultAddedToUnopenContent DifferenceType = "REQUIRED_PROPERTY_WITH_DEFAULT_ADDED_TO_UNOPEN_CONTENT_MODEL"
+	OptionalPropertyAddedToUnopenContentModel       DifferenceType = "OPTIONAL_PROPERTY_ADDED_TO_UNOPEN_CONTENT_MODEL"
+	PropertyAddedIsCoveredByPartiallyOpenModel      DifferenceType = "PROPERTY_ADDED_IS_COVERED_BY_PARTIALLY_OPEN_CONTENT_MODEL"
+	PropertyAddedNotCoveredByPartiallyOpenModel     DifferenceType = "PROPERTY_ADDED_NOT_COVERED_BY_PARTIALLY_OPEN_CONTENT_MODEL"
+	PropertyRemovedFromOpenContentModel             DifferenceType = "PROPERTY_REMOVED_FROM_OPEN_CONTENT_MODEL"
+	PropertyRemovedFromClosedContentModel           DifferenceType = "PROPERTY_REMOVED_FROM_CLOSED_CONTENT_MODEL"
+	PropertyRemovedIsCoveredByPartiallyOpenModel    DifferenceType = "PROPERTY_REMOVED_IS_COVERED_BY_PARTIALLY_OPEN_CONTENT_MODEL"
+	PropertyRemovedNotCoveredByPartiallyOpenModel   DifferenceType = "PROPERTY_REMOVED_NOT_COVERED_BY_PARTIALLY_OPEN_CONTENT_MODEL"
+
+	AdditionalPropertiesAdded    DifferenceType = "ADDITIONAL_PROPERTIES_ADDED"
+	AdditionalPropertiesRemoved  DifferenceType = "ADDITIONAL_PROPERTIES_REMOVED"
+	AdditionalPropertiesExtended DifferenceType = "ADDITIONAL_PROPERTIES_EXTENDED"
+	AdditionalPropertiesNarrowed DifferenceType = "ADDITIONAL_PROPERTIES_NARROWED"
+
+	MaxPropertiesAdded     DifferenceType = "MAX_PROPERTIES_ADDED"
+	MaxPropertiesRemoved   DifferenceType = "MAX_PROPERTIES_REMOVED"
+	MaxPropertiesIncreased DifferenceType = "MAX_PROPERTIES_INCREASED"
+	MaxPropertiesDecreased DifferenceType = "MAX_PROPERTIES_DECREASED"
+	MinPropertiesAdded     DifferenceType = "MIN_PROPERTIES_ADDED"
+	MinPropertiesRemoved   DifferenceType = "MIN_PROPERTIES_REMOVED"
+	MinPropertiesIncreased DifferenceType = "MIN_PROPERTIES_INCREASED"
+	MinPropertiesDecreased DifferenceType = "MIN_PROPERTIES_DECREASED"
+
+	ItemAddedToOpenContentModel       DifferenceType = "ITEM_ADDED_TO_OPEN_CONTENT_MODEL"
+	ItemAddedToClosedContentModel     DifferenceType = "ITEM_ADDED_TO_CLOSED_CONTENT_MODEL"
+	ItemRemovedFromOpenContentModel   DifferenceType = "ITEM_REMOVED_FROM_OPEN_CONTENT_MODEL"
+	ItemRemovedFromClosedContentModel DifferenceType = "ITEM_REMOVED_FROM_CLOSED_CONTENT_MODEL"
+	AdditionalItemsAdded              DifferenceType = "ADDITIONAL_ITEMS_ADDED"
+	AdditionalItemsRemoved            DifferenceType = "ADDITIONAL_ITEMS_REMOVED"
+	MaxItemsAdded                     DifferenceType = "MAX_ITEMS_ADDED"
+	MaxItemsRemoved                   DifferenceType = "MAX_ITEMS_REMOVED"
+	MaxItemsIncreased                 DifferenceType = "MAX_ITEMS_INCREASED"
+	MaxItemsDecreased                 DifferenceType = "MAX_ITEMS_DECREASED"
+	MinItemsAdded                     DifferenceType = "MIN_ITEMS_ADDED"
+	MinItemsRemoved                   DifferenceType = "MIN_ITEMS_REMOVED"
+	MinItemsIncreased                 DifferenceType = "MIN_ITEMS_INCREASED"
+	MinItemsDecreased                 DifferenceType = "MIN_ITEMS_DECREASED"
+	UniqueItemsAdded                  DifferenceType = "UNIQUE_ITEMS_ADDED"
+	UniqueItemsRemoved                DifferenceType = "UNIQUE_ITEMS_REMOVED"
+
+	EnumArrayExtended DifferenceType = "ENUM_ARRAY_EXTENDED"
+	EnumArrayNarrowed DifferenceType = "ENUM_ARRAY_NARROWED"
+	EnumArrayChanged  DifferenceType = "ENUM_ARRAY_CHANGED"
+	ConstAdded        DifferenceType = "CONST_ADDED"
+	ConstRemoved      DifferenceType = "CONST_REMOVED"
+	ConstChanged      DifferenceType = "CONST_CHANGED"
+
+	CombinedTypeChanged           DifferenceType = "COMBINED_TYPE_CHANGED"
+	CombinedTypeSubschemasChanged DifferenceType = "COMBINED_TYPE_SUBSCHEMAS_CHANGED"
+	SumTypeExtended               DifferenceType = "SUM_TYPE_EXTENDED"
+	SumTypeNarrowed               DifferenceType = "SUM_TYPE_NARROWED"
+	ProductTypeExtended           DifferenceType = "PRODUCT_TYPE_EXTENDED"
+	ProductTypeNarrowed           DifferenceType = "PRODUCT_TYPE_NARROWED"
+	NotTypeExtended               DifferenceType = "NOT_TYPE_EXTENDED"
+	NotTypeNarrowed               DifferenceType = "NOT_TYPE_NARROWED"
+	NotTypeAdded                  DifferenceType = "NOT_TYPE_ADDED"
+	NotTypeRemoved                DifferenceType = "NOT_TYPE_REMOVED"
+
+	ReferenceChanged DifferenceType = "REFERENCE_CHANGED"
+)
+
+// compatibleChanges lists the differences that keep data written with the original
+// schema readable with the updated schema
+var compatibleChanges = map[DifferenceType]bool{
+	TypeExtended:              true,
+	MaxLengthRemoved:          true,
+	MaxLengthIncreased:        true,
+	MinLengthRemoved:          true,
+	MinLengthDecreased:        true,
+	PatternRemoved:            true,
+	MaximumRemoved:            true,
+	MaximumIncreased:          true,
+	MinimumRemoved:            true,
+	MinimumDecreased:          true,
+	ExclusiveMaximumRemoved:   true,
+	ExclusiveMaximumIncreased: true,
+	ExclusiveMinimumRemoved:   true,
+	ExclusiveMinimumDecreased: true,
+	MultipleOfRemoved:         true,
+	MultipleOfReduced:         true,
+
+	RequiredAttributeRemoved:                        true,
+	RequiredPropertyWithDefaultAddedToUnopenContent: true,
+	OptionalPropertyAddedToUnopenContentModel:       true,
+	PropertyAddedIsCoveredByPartiallyOpenModel:      true,
+	PropertyRemovedFromOpenContentModel:             true,
+	PropertyRemovedIsCoveredByPartiallyOpenModel:    true,
+	AdditionalPropertiesAdded:                       true,
+	AdditionalPropertiesExtended:                    true,
+	MaxPropertiesRemoved:                            true,
+	MaxPropertiesIncreased:                          true,
+	MinPropertiesRemoved:                            true,
+	MinPropertiesDecreased:                          true,
+
+	ItemRemovedFromOpenContentModel: true,
+	AdditionalItemsAdded:            true,
+	MaxItemsRemoved:                 true,
+	MaxItemsIncreased:               true,
+	MinItemsRemoved:                 true,
+	MinItemsDecreased:               true,
+	UniqueItemsRemoved:              true,
+
+	EnumArrayExtended: true,
+	ConstRemoved:      true,
+
+	SumTypeExtended:     true,
+	ProductTypeNarrowed: true,
+	NotTypeNarrowed:     true,
+	NotTypeRemoved:      true,
+}
+
+// explanations describes why each incompatible difference breaks readers
+var explanations = map[DifferenceType]string{
+	TypeNarrowed:       "the new schema accepts fewer types than the old one, so existing values of the removed types are rejected",
+	TypeChanged:        "the type changed to one that does not accept the old values",
+	MaxLengthAdded:     "a maxLength constraint was added, so longer existing strings are rejected",
+	MaxLengthDecreased: "maxLength was lowered, so existing strings between the old and new limit are rejected",
+	MinLengthAdded:     "a minLength constraint was added, so shorter existing strings are rejected",
+	MinLengthIncreased: "minLength was raised, so existing strings between the old and new limit are rejected",
+	PatternAdded:       "a pattern was added, so existing strings that do not match it are rejected",
+	PatternChanged:     "the pattern changed, so existing strings matching only the old pattern are rejected",
+
+	MaximumAdded:              "a maximum was added, so larger existing values are rejected",
+	MaximumDecreased:          "the maximum was lowered, so existing values above the new limit are rejected",
+	MinimumAdded:              "a minimum was added, so smaller existing values are rejected",
+	MinimumIncreased:          "the minimum was raised, so existing values below the new limit are rejected",
+	ExclusiveMaximumAdded:     "an exclusiveMaximum was added, so larger existing values are rejected",
+	ExclusiveMaximumDecreased: "exclusiveMaximum was lowered, so existing values above the new limit are rejected",
+	ExclusiveMinimumAdded:     "an exclusiveMinimum was added, so smaller existing values are rejected",
+	ExclusiveMinimumIncreased: "exclusiveMinimum was raised, so existing values below the new limit are rejected",
+	MultipleOfAdded:           "a multipleOf constraint was added, so existing values that are not multiples are rejected",
+	MultipleOfExpanded:        "multipleOf was changed to a multiple of the old divisor, so some existing values are rejected",
+	MultipleOfChanged:         "multipleOf changed to an unrelated divisor, so some existing values are rejected",
+
+	RequiredAttributeAdded:                        "an existing property became required, so old records without it are rejected",
+	PropertyAddedToOpenContentModel:               "a property was added to an open content model; old records may already carry that name with any type",
+	RequiredPropertyAddedToUnopenContentModel:     "a new required property without a default was added, so old records without it are rejected",
+	PropertyAddedNotCoveredByPartiallyOpenModel:   "a property was added whose schema does not accept everything the old additionalProperties schema allowed",
+	PropertyRemovedFromClosedContentModel:         "a property was removed from a closed content model, so old records carrying it are rejected",
+	PropertyRemovedNotCoveredByPartiallyOpenModel: "a property was removed and the new additionalProperties schema does not accept its old values",
+
+	AdditionalPropertiesRemoved:  "additionalProperties was disallowed, so old records with extra properties are rejected",
+	AdditionalPropertiesNarrowed: "the additionalProperties schema now accepts fewer values than before",
+	MaxPropertiesAdded:           "a maxProperties constraint was added, so larger existing objects are rejected",
+	MaxPropertiesDecreased:       "maxProperties was lowered, so existing objects above the new limit are rejected",
+	MinPropertiesAdded:           "a minProperties constraint was added, so smaller existing objects are rejected",
+	MinPropertiesIncreased:       "minProperties was raised, so existing objects below the new limit are rejected",
+
+	ItemAddedToOpenContentModel:       "a tuple item was added to an open array; old arrays may already hold any value at that position",
+	ItemAddedToClosedContentModel:     "a tuple item was added to a closed array model",
+	ItemRemovedFromClosedContentModel: "a tuple item was removed from a closed array, so old arrays carrying it are rejected",
+	AdditionalItemsRemoved:            "additionalItems was disallowed, so old arrays with extra items are rejected",
+	MaxItemsAdded:                     "a maxItems constraint was added, so longer existing arrays are rejected",
+	MaxItemsDecreased:                 "maxItems was lowered, so existing arrays above the new limit are rejected",
+	MinItemsAdded:                     "a minItems constraint was added, so shorter existing arrays are rejected",
+	MinItemsIncreased:                 "minItems was raised, so existing arrays below the new limit are rejected",
+	UniqueItemsAdded:                  "uniqueItems was added, so existing arrays with duplicates are rejected",
+
+	EnumArrayNarrowed: "enum values were removed, so existing values using them are rejected",
+	EnumArrayChanged:  "the enum changed, so existing values that are no longer listed are rejected",
+	ConstAdded:        "a const constraint was added, so any other existing value is rejected",
+	ConstChanged:      "the const value changed, so existing values are rejected",
+
+	CombinedTypeChanged:           "the combination keyword (oneOf/anyOf/allOf) changed",
+	CombinedTypeSubschemasChanged: "a subschema of the combination no longer matches any subschema of the old one",
+	SumTypeNarrowed:               "alternatives were removed from oneOf/anyOf, so values matching only those are rejected",
+	ProductTypeExtended:           "subschemas were added to allOf, so existing values must now satisfy more constraints",
+	NotTypeExtended:               "the 'not' subschema now excludes more values",
+	NotTypeAdded:                  "a 'not' constraint was added, so some existing values are rejected",
+
+	ReferenceChanged: "an external $ref points to a different schema",
+}
+
+// Difference describes a single change between an original and an updated JSON schema
+type Difference struct {
+	Type       DifferenceType `json:"type"`
+	Path       string         `json:"path"`
+	Compatible bool           `json:"compatible"`
+	Message    string         `json:"message"`
+}
+
+// Explanation returns a plain description of why the difference breaks compatibility
+func (d Difference) Explanation() string {
+	if d.Compatible {
+		return "compatible change"
+	}
+	if text, ok := explanations[d.Type]; ok {
+		return text
+	}
+	return "incompatible change"
+}
+
+// String formats the difference in the style of the registry's compatibility messages
+func (d Difference) String() string {
+	return fmt.Sprintf("%s at %s: %s", d.Type, d.Path, d.Message)
+}
+
+// differ walks two schema documents and accumulates differences
+type differ struct {
+	original    *Document
+	update      *Document
+	differences []Difference
+	visited     map[string]bool
+}
+
+// Compare reports every difference between the original and the updated schema.
+// Compatible differences keep data valid under the original valid under the update.
+func Compare(original, update *Document) []Difference {
+	d := &differ{
+		original: original,
+		update:   update,
+		visited:  map[string]bool{},
+	}
+	d.compare("#", original.Root, update.Root)
+
+	sort.SliceStable(d.differences, func(i, j int) bool {
+		return d.differences[i].Path < d.differences[j].Path
+	})
+	return d.differences
+}
+
+// Incompatibilities returns only the differences that break compatibility
+func Incompatibilities(differences []Difference) []Difference {
+	var result []Difference
+	for _, diff := range differences {
+		if !diff.Compatible {
+			result = append(result, diff)
+		}
+	}
+	return result
+}
+
+// CheckCompatibility checks whether data written with the writer schema can be read
+// with the reader schema and returns the incompatible differences
+func CheckCompatibility(reader, writer string) ([]Difference, error) {
+	readerDoc, err := Parse(reader)
+	if err != nil {
+		return nil, err
+	}
+	writerDoc, err := Parse(writer)
+	if err != nil {
+		return nil, err
+	}
+	return Incompatibilities(Compare(writerDoc, readerDoc)), nil
+}
+
+func (d *differ) add(diffType DifferenceType, path, format string, args ...interface{}) {
+	d.differences = append(d.differences, Difference{
+		Type:       diffType,
+		Path:       path,
+		Compatible: compatibleChanges[diffType],
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+// isCompatible checks whether everything accepted by original is accepted by update,
+// without recording the differences found along the way
+func (d *differ) isCompatible(path string, original, update interface{}) bool {
+	sub := &differ{original: d.original, update: d.update, visited: d.visited}
+	sub.compare(path, original, update)
+	return len(Incompatibilities(sub.differences)) == 0
+}
+
+func (d *differ) compare(path string, originalSchema, updateSchema interface{}) {
+	originalResolved, originalRef := d.original.Resolve(originalSchema)
+	updateResolved, updateRef := d.update.Resolve(updateSchema)
+
+	// Guard against recursive definitions
+	if originalRef != "" || updateRef != "" {
+		key := originalRef + "|" + updateRef
+		if d.visited[key] {
+			return
+		}
+		d.visited[key] = true
+		defer delete(d.visited, key)
+	}
+
+	original := asObject(originalResolved)
+	update := asObject(updateResolved)
+
+	// Unresolvable (external) references are compared by name only
+	originalExternal, _ := original["$ref"].(string)
+	updateExternal, _ := update["$ref"].(string)
+	if originalExternal != updateExternal && (originalExternal != "" || updateExternal != "") {
+		d.add(ReferenceChanged, path, "reference changed from '%s' to '%s'", originalExternal, updateExternal)
+		return
+	}
+
+	if d.compareCombined(path, original, update) {
+		return
+	}
+
+	d.compareTypes(path, original, update)
+	d.compareNot(path, original, update)
+	d.compareEnum(path, original, update)
+	d.compareStrings(path, original, update)
+	d.compareNumbers(path, original, update)
+	d.compareObjects(path, original, update)
+	d.compareArrays(path, original, update)
+}
+
+// combinedKeyword returns the combination keyword used by a schema and its subschemas
+func combinedKeyword(schema map[string]interface{}) (string, []interface{}) {
+	for _, keyword := range []string{"oneOf", "anyOf", "allOf"} {
+		if subschemas, ok := schema[keyword].([]interface{}); ok {
+			return keyword, subschemas
+		}
+	}
+	return "", nil
+}
+
+// compareCombined handles oneOf/anyOf/allOf. It returns true when the comparison is complete.
+func (d *differ) compareCombined(path string, original, update map[string]interface{}) bool {
+	originalKeyword, originalSubs := combinedKeyword(original)
+	updateKeyword, updateSubs := combinedKeyword(update)
+
+	if originalKeyword == "" && updateKeyword == "" {
+		return false
+	}
+
+	// A plain schema turning into a sum type is compatible if it matches one of the alternatives
+	if originalKeyword == "" {
+		if updateKeyword != "allOf" {
+			for _, sub := range updateSubs {
+				if d.isCompatible(path, original, sub) {
+					d.add(SumTypeExtended, path, "schema extended to %s with %d alternatives", updateKeyword, len(updateSubs))
+					return true
+				}
+			}
+		}
+		d.add(CombinedTypeChanged, path, "schema changed to %s", updateKeyword)
+		return true
+	}
+
+	if updateKeyword == "" {
+		if originalKeyword == "allOf" {
+			for _, sub := range originalSubs {
+				if d.isCompatible(path, sub, update) {
+					d.add(ProductTypeNarrowed, path, "allOf reduced to a single schema")
+					return true
+				}
+			}
+		}
+		d.add(CombinedTypeChanged, path, "%s replaced by a plain schema", originalKeyword)
+		return true
+	}
+
+	if originalKeyword != updateKeyword {
+		// oneOf -> anyOf accepts everything that was accepted before
+		if !(originalKeyword == "oneOf" && updateKeyword == "anyOf") {
+			d.add(CombinedTypeChanged, path, "combination changed from %s to %s", originalKeyword, updateKeyword)
+			return true
+		}
+	}
+
+	keywordPath := childPath(path, updateKeyword)
+
+	if updateKeyword == "allOf" {
+		// Every update constraint must already be implied by an original one
+		used := make([]bool, len(originalSubs))
+		for i, updateSub := range updateSubs {
+			matched := false
+			for j, originalSub := range originalSubs {
+				if !used[j] && d.isCompatible(childPath(keywordPath, fmt.Sprint(i)), originalSub, updateSub) {
+					used[j] = true
+					matched = true
+					d.compare(childPath(keywordPath, fmt.Sprint(i)), originalSub, updateSub)
+					break
+				}
+			}
+			if !matched {
+				if len(updateSubs) > len(originalSubs) {
+					d.add(ProductTypeExtended, keywordPath, "allOf extended from %d to %d subschemas", len(originalSubs), len(updateSubs))
+				} else {
+					d.add(CombinedTypeSubschemasChanged, childPath(keywordPath, fmt.Sprint(i)), "allOf subschema has no compatible counterpart")
+				}
+				return true
+			}
+		}
+		if len(updateSubs) < len(originalSubs) {
+			d.add(ProductTypeNarrowed, keywordPath, "allOf reduced from %d to %d subschemas", len(originalSubs), len(updateSubs))
+		}
+		return true
+	}
+
+	// oneOf/anyOf: every original alternative must be accepted by some update alternative
+	used := make([]bool, len(updateSubs))
+	for i, originalSub := range originalSubs {
+		matched := false
+		for j, updateSub := range updateSubs {
+			if !used[j] && d.isCompatible(childPath(keywordPath, fmt.Sprint(j)), originalSub, updateSub) {
+				used[j] = true
+				matched = true
+				d.compare(childPath(keywordPath, fmt.Sprint(j)), originalSub, updateSub)
+				break
+			}
+		}
+		if !matched {
+			if len(updateSubs) < len(originalSubs) {
+				d.add(SumTypeNarrowed, keywordPath, "%s narrowed from %d to %d alternatives", updateKeyword, len(originalSubs), len(updateSubs))
+			} else {
+				d.add(CombinedTypeSubschemasChanged, childPath(keywordPath, fmt.Sprint(i)), "alternative has no compatible counterpart in the new schema")
+			}
+			return true
+		}
+	}
+	if len(updateSubs) > len(originalSubs) {
+		d.add(SumTypeExtended, keywordPath, "%s extended from %d to %d alternatives", updateKeyword, len(originalSubs), len(updateSubs))
+	}
+	return true
+}
+
+// covers reports whether a set of types accepts values of the given type
+func covers(types []string, t string) bool {
+	for _, candidate := range types {
+		if candidate == t || (t == "integer" && candidate == "number") {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *differ) compareTypes(path string, original, update map[string]interface{}) {
+	originalTypes := typeSet(original)
+	updateTypes := typeSet(update)
+
+	switch {
+	case originalTypes == nil && updateTypes == nil:
+		return
+	case originalTypes == nil:
+		d.add(TypeNarrowed, path, "type restricted to %v", updateTypes)
+		return
+	case updateTypes == nil:
+		d.add(TypeExtended, path, "type restriction %v removed", originalTypes)
+		return
+	}
+
+	originalCovered := true
+	for _, t := range originalTypes {
+		if !covers(updateTypes, t) {
+			originalCovered = false
+		}
+	}
+	updateCovered := true
+	for _, t := range updateTypes {
+		if !covers(originalTypes, t) {
+			updateCovered = false
+		}
+	}
+
+	switch {
+	case originalCovered && updateCovered:
+		return
+	case originalCovered:
+		d.add(TypeExtended, path, "type extended from %v to %v", originalTypes, updateTypes)
+	case updateCovered:
+		d.add(TypeNarrowed, path, "type narrowed from %v to %v", originalTypes, updateTypes)
+	default:
+		d.add(TypeChanged, path, "type changed from %v to %v", originalTypes, updateTypes)
+	}
+}
+
+func (d *differ) compareNot(path string, original, update map[string]interface{}) {
+	originalNot, hasOriginal := original["not"]
+	updateNot, hasUpdate := update["not"]
+
+	switch {
+	case !hasOriginal && !hasUpdate:
+		return
+	case !hasOriginal:
+		d.add(NotTypeAdded, childPath(path, "not"), "'not' constraint added")
+	case !hasUpdate:
+		d.add(NotTypeRemoved, childPath(path, "not"), "'not' constraint removed")
+	default:
+		// The excluded set must shrink: the old exclusion has to cover the new one
+		reversed := &differ{original: d.update, update: d.original, visited: map[string]bool{}}
+		if reversed.isCompatible(childPath(path, "not"), updateNot, originalNot) {
+			if !reflect.DeepEqual(originalNot, updateNot) {
+				d.add(NotTypeNarrowed, childPath(path, "not"), "'not' subschema excludes fewer values")
+			}
+		} else {
+			d.add(NotTypeExtended, childPath(path, "not"), "'not' subschema excludes more values")
+		}
+	}
+}
+
+func (d *differ) compareEnum(path string, original, update map[string]interface{}) {
+	originalEnum, hasOriginal := original["enum"].([]interface{})
+	updateEnum, hasUpdate := update["enum"].([]interface{})
+
+	if hasOriginal || hasUpdate {
+		switch {
+		case !hasUpdate:
+			d.add(EnumArrayExtended, childPath(path, "enum"), "enum restriction removed")
+		case !hasOriginal:
+			d.add(EnumArrayNarrowed, childPath(path, "enum"), "enum restriction added: %s", toJSON(updateEnum))
+		default:
+			originalInUpdate := containsAll(updateEnum, originalEnum)
+			updateInOriginal := containsAll(originalEnum, updateEnum)
+			switch {
+			case originalInUpdate && updateInOriginal:
+			case originalInUpdate:
+				d.add(EnumArrayExtended, childPath(path, "enum"), "enum extended from %s to %s", toJSON(originalEnum), toJSON(updateEnum))
+			case updateInOriginal:
+				d.add(EnumArrayNarrowed, childPath(path, "enum"), "enum narrowed from %s to %s", toJSON(originalEnum), toJSON(updateEnum))
+			default:
+				d.add(EnumArrayChanged, childPath(path, "enum"), "enum changed from %s to %s", toJSON(originalEnum), toJSON(updateEnum))
+			}
+		}
+	}
+
+	originalConst, hasOriginalConst := original["const"]
+	updateConst, hasUpdateConst := update["const"]
+	switch {
+	case !hasOriginalConst && !hasUpdateConst:
+	case !hasOriginalConst:
+		d.add(ConstAdded, childPath(path, "const"), "const %s added", toJSON(updateConst))
+	case !hasUpdateConst:
+		d.add(ConstRemoved, childPath(path, "const"), "const %s removed", toJSON(originalConst))
+	case !reflect.DeepEqual(originalConst, updateConst):
+		d.add(ConstChanged, childPath(path, "const"), "const changed from %s to %s", toJSON(originalConst), toJSON(updateConst))
+	}
+}
+
+// boundChanges maps the four possible changes of an upper or lower bound
+type boundChanges struct {
+	added, removed, increased, decreased DifferenceType
+}
+
+func (d *differ) compareBound(path string, original, update map[string]interface{}, key string, changes boundChanges) {
+	originalValue, hasOriginal := number(original, key)
+	updateValue, hasUpdate := number(update, key)
+
+	switch {
+	case !hasOriginal && !hasUpdate:
+	case !hasOriginal:
+		d.add(changes.added, childPath(path, key), "%s %v added", key, updateValue)
+	case !hasUpdate:
+		d.add(changes.removed, childPath(path, key), "%s %v removed", key, originalValue)
+	case updateValue > originalValue:
+		d.add(changes.increased, childPath(path, key), "%s increased from %v to %v", key, originalValue, updateValue)
+	case updateValue < originalValue:
+		d.add(changes.decreased, childPath(path, key), "%s decreased from %v to %v", key, originalValue, updateValue)
+	}
+}
+
+func (d *differ) compareStrings(path string, original, update map[string]interface{}) {
+	d.compareBound(path, original, update, "maxLength", boundChanges{MaxLengthAdded, MaxLengthRemoved, MaxLengthIncreased, MaxLengthDecreased})
+	d.compareBound(path, original, update, "minLength", boundChanges{MinLengthAdded, MinLengthRemoved, MinLengthIncreased, MinLengthDecreased})
+
+	originalPattern, hasOriginal := original["pattern"].(string)
+	updatePattern, hasUpdate := update["pattern"].(string)
+	switch {
+	case !hasOriginal && !hasUpdate:
+	case !hasOriginal:
+		d.add(PatternAdded, childPath(path, "pattern"), "pattern '%s' added", updatePattern)
+	case !hasUpdate:
+		d.add(PatternRemoved, childPath(path, "pattern"), "pattern '%s' removed", originalPattern)
+	case originalPattern != updatePattern:
+		d.add(PatternChanged, childPath(path, "pattern"), "pattern changed from '%s' to '%s'", originalPattern, updatePattern)
+	}
+}
+
+func (d *differ) compareNumbers(path string, original, update map[string]interface{}) {
+	d.compareBound(path, original, update, "maximum", boundChanges{MaximumAdded, MaximumRemoved, MaximumIncreased, MaximumDecreased})
+	d.compareBound(path, original, update, "minimum", boundChanges{MinimumAdded, MinimumRemoved, MinimumIncreased, MinimumDecreased})
+	d.compareBound(path, original, update, "exclusiveMaximum", boundChanges{ExclusiveMaximumAdded, ExclusiveMaximumRemoved, ExclusiveMaximumIncreased, ExclusiveMaximumDecreased})
+	d.compareBound(path, original, update, "exclusiveMinimum", boundChanges{ExclusiveMinimumAdded, ExclusiveMinimumRemoved, ExclusiveMinimumIncreased, ExclusiveMinimumDecreased})
+
+	originalMultiple, hasOriginal := number(original, "multipleOf")
+	updateMultiple, hasUpdate := number(update, "multipleOf")
+	switch {
+	case !hasOriginal && !hasUpdate:
+	case !hasOriginal:
+		d.add(MultipleOfAdded, childPath(path, "multipleOf"), "multipleOf %v added", updateMultiple)
+	case !hasUpdate:
+		d.add(MultipleOfRemoved, childPath(path, "multipleOf"), "multipleOf %v removed", originalMultiple)
+	case originalMultiple == updateMultiple:
+	case isMultiple(originalMultiple, updateMultiple):
+		// Every multiple of the old divisor is still a multiple of the new one
+		d.add(MultipleOfReduced, childPath(path, "multipleOf"), "multipleOf reduced from %v to %v", originalMultiple, updateMultiple)
+	case isMultiple(updateMultiple, originalMultiple):
+		d.add(MultipleOfExpanded, childPath(path, "multipleOf"), "multipleOf expanded from %v to %v", originalMultiple, updateMultiple)
+	default:
+		d.add(MultipleOfChanged, childPath(path, "multipleOf"), "multipleOf changed from %v to %v", originalMultiple, updateMultiple)
+	}
+}
+
+// isMultiple reports whether value is an integer multiple of divisor
+func isMultiple(value, divisor float64) bool {
+	if divisor == 0 {
+		return false
+	}
+	quotient := value / divisor
+	return math.Abs(quotient-math.Round(quotient)) < 1e-9
+}
+
+// contentModel describes how an object treats properties that are not declared
+type contentModel int
+
+const (
+	openContentModel contentModel = iota
+	partiallyOpenContentModel
+	closedContentModel
+)
+
+func objectContentModel(schema map[string]interface{}) contentModel {
+	switch v := schema["additionalProperties"].(type) {
+	case nil:
+		return openContentModel
+	case bool:
+		if v {
+			return openContentModel
+		}
+		return closedContentModel
+	default:
+		return partiallyOpenContentModel
+	}
+}
+
+func (d *differ) compareObjects(path string, original, update map[string]interface{}) {
+	originalProps, _ := original["properties"].(map[string]interface{})
+	updateProps, _ := update["properties"].(map[string]interface{})
+	originalRequired := toSet(stringSlice(original, "required"))
+	updateRequired := toSet(stringSlice(update, "required"))
+	originalModel := objectContentModel(original)
+	updateModel := objectContentModel(update)
+
+	for _, name := range sortedKeys(originalProps) {
+		propPath := childPath(path, "properties", name)
+		updateProp, ok := updateProps[name]
+		if !ok {
+			switch updateModel {
+			case openContentModel:
+				d.add(PropertyRemovedFromOpenContentModel, propPath, "property '%s' removed", name)
+			case closedContentModel:
+				d.add(PropertyRemovedFromClosedContentModel, propPath, "property '%s' removed from closed content model", name)
+			default:
+				if d.isCompatible(propPath, originalProps[name], update["additionalProperties"]) {
+					d.add(PropertyRemovedIsCoveredByPartiallyOpenModel, propPath, "property '%s' removed, covered by additionalProperties", name)
+				} else {
+					d.add(PropertyRemovedNotCoveredByPartiallyOpenModel, propPath, "property '%s' removed, not covered by additionalProperties", name)
+				}
+			}
+			continue
+		}
+
+		d.compare(propPath, originalProps[name], updateProp)
+
+		if updateRequired[name] && !originalRequired[name] {
+			d.add(RequiredAttributeAdded, propPath, "property '%s' is now required", name)
+		} else if !updateRequired[name] && originalRequired[name] {
+			d.add(RequiredAttributeRemoved, propPath, "property '%s' is no longer required", name)
+		}
+	}
+
+	for _, name := range sortedKeys(updateProps) {
+		if _, ok := originalProps[name]; ok {
+			continue
+		}
+		propPath := childPath(path, "properties", name)
+		switch originalModel {
+		case openContentModel:
+			d.add(PropertyAddedToOpenContentModel, propPath, "property '%s' added to open content model", name)
+		case partiallyOpenContentModel:
+			if d.isCompatible(propPath, original["additionalProperties"], updateProps[name]) {
+				d.add(PropertyAddedIsCoveredByPartiallyOpenModel, propPath, "property '%s' added, covered by additionalProperties", name)
+			} else {
+				d.add(PropertyAddedNotCoveredByPartiallyOpenModel, propPath, "property '%s' added, not covered by additionalProperties", name)
+			}
+		default:
+			if !updateRequired[name] {
+				d.add(OptionalPropertyAddedToUnopenContentModel, propPath, "optional property '%s' added", name)
+			} else if hasDefault(updateProps[name]) {
+				d.add(RequiredPropertyWithDefaultAddedToUnopenContent, propPath, "required property '%s' with default added", name)
+			} else {
+				d.add(RequiredPropertyAddedToUnopenContentModel, propPath, "required property '%s' added", name)
+			}
+		}
+	}
+
+	// Required names that are not declared as properties
+	for _, name := range sortedKeys(updateRequired) {
+		_, inOriginal := originalProps[name]
+		_, inUpdate := updateProps[name]
+		if !inOriginal && !inUpdate && !originalRequired[name] {
+			d.add(RequiredAttributeAdded, childPath(path, "required"), "'%s' is now required", name)
+		}
+	}
+
+	additionalPath := childPath(path, "additionalProperties")
+	switch {
+	case originalModel == updateModel && originalModel != partiallyOpenContentModel:
+	case originalModel == closedContentModel:
+		d.add(AdditionalPropertiesAdded, additionalPath, "additional properties allowed")
+	case updateModel == closedContentModel:
+		d.add(AdditionalPropertiesRemoved, additionalPath, "additional properties no longer allowed")
+	case updateModel == openContentModel:
+		d.add(AdditionalPropertiesExtended, additionalPath, "additionalProperties schema removed")
+	case originalModel == openContentModel:
+		d.add(AdditionalPropertiesNarrowed, additionalPath, "additionalProperties restricted to a schema")
+	default:
+		if d.isCompatible(additionalPath, original["additionalProperties"], update["additionalProperties"]) {
+			if !reflect.DeepEqual(original["additionalProperties"], update["additionalProperties"]) {
+				d.add(AdditionalPropertiesExtended, additionalPath, "additionalProperties schema extended")
+			}
+		} else {
+			d.add(AdditionalPropertiesNarrowed, additionalPath, "additionalProperties schema narrowed")
+		}
+	}
+
+	d.compareBound(path, original, update, "maxProperties", boundChanges{MaxPropertiesAdded, MaxPropertiesRemoved, MaxPropertiesIncreased, MaxPropertiesDecreased})
+	d.compareBound(path, original, update, "minProperties", boundChanges{MinPropertiesAdded, MinPropertiesRemoved, MinPropertiesIncreased, MinPropertiesDecreased})
+}
+
+func (d *differ) compareArrays(path string, original, update map[string]interface{}) {
+	originalItems, originalHasItems := original["items"]
+	updateItems, updateHasItems := update["items"]
+	originalTuple, originalIsTuple := originalItems.([]interface{})
+	updateTuple, updateIsTuple := updateItems.([]interface{})
+
+	switch {
+	case originalIsTuple || updateIsTuple:
+		originalOpen := original["additionalItems"] != false
+		updateOpen := update["additionalItems"] != false
+		for i := 0; i < len(originalTuple) || i < len(updateTuple); i++ {
+			itemPath := childPath(path, "items", fmt.Sprint(i))
+			switch {
+			case i < len(originalTuple) && i < len(updateTuple):
+				d.compare(itemPath, originalTuple[i], updateTuple[i])
+			case i < len(originalTuple):
+				if updateOpen {
+					d.add(ItemRemovedFromOpenContentModel, itemPath, "tuple item %d removed", i)
+				} else {
+					d.add(ItemRemovedFromClosedContentModel, itemPath, "tuple item %d removed from closed content model", i)
+				}
+			default:
+				if originalOpen {
+					d.add(ItemAddedToOpenContentModel, itemPath, "tuple item %d added to open content model", i)
+				} else {
+					d.add(ItemAddedToClosedContentModel, itemPath, "tuple item %d added to closed content model", i)
+				}
+			}
+		}
+		if originalOpen && !updateOpen {
+			d.add(AdditionalItemsRemoved, childPath(path, "additionalItems"), "additional items no longer allowed")
+		} else if !originalOpen && updateOpen {
+			d.add(AdditionalItemsAdded, childPath(path, "additionalItems"), "additional items allowed")
+		}
+	case originalHasItems || updateHasItems:
+		d.compare(childPath(path, "items"), originalItems, updateItems)
+	}
+
+	d.compareBound(path, original, update, "maxItems", boundChanges{MaxItemsAdded, MaxItemsRemoved, MaxItemsIncreased, MaxItemsDecreased})
+	d.compareBound(path, original, update, "minItems", boundChanges{MinItemsAdded, MinItemsRemoved, MinItemsIncreased, MinItemsDecreased})
+
+	originalUnique, _ := original["uniqueItems"].(bool)
+	updateUnique, _ := update["uniqueItems"].(bool)
+	if !originalUnique && updateUnique {
+		d.add(UniqueItemsAdded, childPath(path, "uniqueItems"), "uniqueItems added")
+	} else if originalUnique && !updateUnique {
+		d.add(UniqueItemsRemoved, childPath(path, "uniqueItems"), "uniqueItems removed")
+	}
+}
+
+// containsAll reports whether every value of subset appears in set
+func containsAll(set, subset []interface{}) bool {
+	for _, v := range subset {
+		found := false
+		for _, candidate := range set {
+			if reflect.DeepEqual(v, candidate) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+// hasDefault reports whether a schema declares a default, including an explicit null
+func hasDefault(schema interface{}) bool {
+	obj, ok := schema.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = obj["default"]
+	return ok
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func toJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
@@ -0,0 +1,192 @@
+package jsonschema
+
+import (
+	"testing"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	tests := []struct {
+		name          string
+		writer        string
+		reader        string
+		expectedTypes []DifferenceType
+	}{
+		{
+			name:          "identical schemas",
+			writer:        `{"type":"object","properties":{"id":{"type":"integer"}}}`,
+			reader:        `{"type":"object","properties":{"id":{"type":"integer"}}}`,
+			expectedTypes: nil,
+		},
+		{
+			name:          "type extended from integer to number",
+			writer:        `{"type":"integer"}`,
+			reader:        `{"type":"number"}`,
+			expectedTypes: nil,
+		},
+		{
+			name:          "type narrowed from number to integer",
+			writer:        `{"type":"number"}`,
+			reader:        `{"type":"integer"}`,
+			expectedTypes: []DifferenceType{TypeNarrowed},
+		},
+		{
+			name:          "property added to open content model",
+			writer:        `{"type":"object","properties":{"id":{"type":"integer"}}}`,
+			reader:        `{"type":"object","properties":{"id":{"type":"integer"},"name":{"type":"string"}}}`,
+			expectedTypes: []DifferenceType{PropertyAddedToOpenContentModel},
+		},
+		{
+			name:          "optional property added to closed content model",
+			writer:        `{"type":"object","properties":{"id":{"type":"integer"}},"additionalProperties":false}`,
+			reader:        `{"type":"object","properties":{"id":{"type":"integer"},"name":{"type":"string"}},"additionalProperties":false}`,
+			expectedTypes: nil,
+		},
+		{
+			name:          "required property added to closed content model",
+			writer:        `{"type":"object","properties":{"id":{"type":"integer"}},"additionalProperties":false}`,
+			reader:        `{"type":"object","properties":{"id":{"type":"integer"},"name":{"type":"string"}},"required":["name"],"additionalProperties":false}`,
+			expectedTypes: []DifferenceType{RequiredPropertyAddedToUnopenContentModel},
+		},
+		{
+			name:          "required property with a null default added to closed content model",
+			writer:        `{"type":"object","properties":{"id":{"type":"integer"}},"additionalProperties":false}`,
+			reader:        `{"type":"object","properties":{"id":{"type":"integer"},"note":{"type":["string","null"],"default":null}},"required":["note"],"additionalProperties":false}`,
+			expectedTypes: nil,
+		},
+		{
+			name:          "property removed from closed content model",
+			writer:        `{"type":"object","properties":{"id":{"type":"integer"},"name":{"type":"string"}},"additionalProperties":false}`,
+			reader:        `{"type":"object","properties":{"id":{"type":"integer"}},"additionalProperties":false}`,
+			expectedTypes: []DifferenceType{PropertyRemovedFromClosedContentModel},
+		},
+		{
+			name:          "property removed from open content model",
+			writer:        `{"type":"object","properties":{"id":{"type":"integer"},"name":{"type":"string"}}}`,
+			reader:        `{"type":"object","properties":{"id":{"type":"integer"}}}`,
+			expectedTypes: nil,
+		},
+		{
+			name:          "property added covered by partially open content model",
+			writer:        `{"type":"object","properties":{"id":{"type":"integer"}},"additionalProperties":{"type":"string"}}`,
+			reader:        `{"type":"object","properties":{"id":{"type":"integer"},"name":{"type":["string","null"]}},"additionalProperties":{"type":"string"}}`,
+			expectedTypes: nil,
+		},
+		{
+			name:          "property added not covered by partially open content model",
+			writer:        `{"type":"object","properties":{"id":{"type":"integer"}},"additionalProperties":{"type":"string"}}`,
+			reader:        `{"type":"object","properties":{"id":{"type":"integer"},"count":{"type":"integer"}},"additionalProperties":{"type":"string"}}`,
+			expectedTypes: []DifferenceType{PropertyAddedNotCoveredByPartiallyOpenModel},
+		},
+		{
+			name:          "existing property made required",
+			writer:        `{"type":"object","properties":{"id":{"type":"integer"}}}`,
+			reader:        `{"type":"object","properties":{"id":{"type":"integer"}},"required":["id"]}`,
+			expectedTypes: []DifferenceType{RequiredAttributeAdded},
+		},
+		{
+			name:          "additional properties removed",
+			writer:        `{"type":"object"}`,
+			reader:        `{"type":"object","additionalProperties":false}`,
+			expectedTypes: []DifferenceType{AdditionalPropertiesRemoved},
+		},
+		{
+			name:          "numeric range narrowed",
+			writer:        `{"type":"integer","minimum":0,"maximum":100}`,
+			reader:        `{"type":"integer","minimum":10,"maximum":50}`,
+			expectedTypes: []DifferenceType{MaximumDecreased, MinimumIncreased},
+		},
+		{
+			name:          "numeric range widened",
+			writer:        `{"type":"integer","minimum":10,"maximum":50}`,
+			reader:        `{"type":"integer","minimum":0}`,
+			expectedTypes: nil,
+		},
+		{
+			name:          "string length limit added",
+			writer:        `{"type":"string"}`,
+			reader:        `{"type":"string","maxLength":10}`,
+			expectedTypes: []DifferenceType{MaxLengthAdded},
+		},
+		{
+			name:          "enum extended",
+			writer:        `{"enum":["A","B"]}`,
+			reader:        `{"enum":["A","B","C"]}`,
+			expectedTypes: nil,
+		},
+		{
+			name:          "enum narrowed",
+			writer:        `{"enum":["A","B","C"]}`,
+			reader:        `{"enum":["A","B"]}`,
+			expectedTypes: []DifferenceType{EnumArrayNarrowed},
+		},
+		{
+			name:          "sum type extended",
+			writer:        `{"type":"string"}`,
+			reader:        `{"oneOf":[{"type":"string"},{"type":"null"}]}`,
+			expectedTypes: nil,
+		},
+		{
+			name:          "sum type narrowed",
+			writer:        `{"oneOf":[{"type":"string"},{"type":"null"}]}`,
+			reader:        `{"oneOf":[{"type":"string"}]}`,
+			expectedTypes: []DifferenceType{SumTypeNarrowed},
+		},
+		{
+			name:          "nested change resolved through local reference",
+			writer:        `{"type":"object","properties":{"address":{"$ref":"#/definitions/Address"}},"definitions":{"Address":{"type":"object","properties":{"zip":{"type":"string"}}}}}`,
+			reader:        `{"type":"object","properties":{"address":{"$ref":"#/definitions/Address"}},"definitions":{"Address":{"type":"object","properties":{"zip":{"type":"integer"}}}}}`,
+			expectedTypes: []DifferenceType{TypeChanged},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			differences, err := CheckCompatibility(tt.reader, tt.writer)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(differences) != len(tt.expectedTypes) {
+				t.Fatalf("Expected %d incompatibilities, got %d: %v", len(tt.expectedTypes), len(differences), differences)
+			}
+
+			for i, diff := range differences {
+				if diff.Type != tt.expectedTypes[i] {
+					t.Errorf("Expected difference %d to be %s, got %s", i, tt.expectedTypes[i], diff.Type)
+				}
+				if diff.Explanation() == "" {
+					t.Errorf("Expected an explanation for %s", diff.Type)
+				}
+			}
+		})
+	}
+}
+
+func TestCompare_ReportsPaths(t *testing.T) {
+	original, err := Parse(`{"type":"object","properties":{"user":{"type":"object","properties":{"age":{"type":"integer"}}}}}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	update, err := Parse(`{"type":"object","properties":{"user":{"type":"object","properties":{"age":{"type":"string"}}}}}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	differences := Compare(original, update)
+	if len(differences) != 1 {
+		t.Fatalf("Expected 1 difference, got %d: %v", len(differences), differences)
+	}
+
+	expectedPath := "#/properties/user/properties/age"
+	if differences[0].Path != expectedPath {
+		t.Errorf("Expected path %s, got %s", expectedPath, differences[0].Path)
+	}
+}
+
+func TestParse_InvalidSchema(t *testing.T) {
+	for _, content := range []string{`not json`, `"string"`, `[1,2]`} {
+		if _, err := Parse(content); err == nil {
+			t.Errorf("Expected error parsing %s", content)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package jsonschema
+
+import (
+	"fmt"
+
+	"github.com/aywengo/ksr-cli/internal/jsonfmt"
+)
+
+// leadingKeywords are written first in every schema object, in this order
+var leadingKeywords = []string{
+	"$schema", "$id", "$ref", "$comment", "title", "description", "type", "format",
+	"enum", "const", "default", "examples",
+}
+
+// schemaMapKeywords hold objects whose values are schemas
+var schemaMapKeywords = map[string]bool{
+	"properties": true, "patternProperties": true, "definitions": true, "$defs": true,
+	"dependentSchemas": true,
+}
+
+// schemaListKeywords hold arrays of schemas
+var schemaListKeywords = map[string]bool{
+	"allOf": true, "anyOf": true, "oneOf": true, "prefixItems": true,
+}
+
+// schemaKeywords hold a single schema
+var schemaKeywords = map[string]bool{
+	"items": true, "additionalItems": true, "additionalProperties": true, "contains": true,
+	"propertyNames": true, "not": true, "if": true, "then": true, "else": true,
+	"unevaluatedItems": true, "unevaluatedProperties": true,
+}
+
+// Format pretty-prints a JSON schema. Identifying and annotation keywords ($schema, $id,
+// title, description, type, ...) come first in every subschema; other keywords keep their
+// original order.
+func Format(content string) (string, error) {
+	raw, err := jsonfmt.Decode([]byte(content))
+	if err != nil {
+		return "", fmt.Errorf("invalid schema JSON: %w", err)
+	}
+	formatSchema(raw)
+	return jsonfmt.Marshal(raw), nil
+}
+
+func formatSchema(raw interface{}) {
+	obj, ok := raw.(*jsonfmt.Object)
+	if !ok {
+		return
+	}
+
+	for _, m := range obj.Members {
+		switch {
+		case schemaMapKeywords[m.Key]:
+			if children, ok := m.Value.(*jsonfmt.Object); ok {
+				for _, child := range children.Members {
+					formatSchema(child.Value)
+				}
+			}
+		case schemaListKeywords[m.Key]:
+			if children, ok := m.Value.([]interface{}); ok {
+				for _, child := range children {
+					formatSchema(child)
+				}
+			}
+		case schemaKeywords[m.Key]:
+			if children, ok := m.Value.([]interface{}); ok {
+				for _, child := range children {
+					formatSchema(child)
+				}
+			} else {
+				formatSchema(m.Value)
+			}
+		}
+	}
+	obj.Reorder(leadingKeywords...)
+}
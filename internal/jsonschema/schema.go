@@ -0,0 +1,152 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Document is a parsed JSON Schema together with its root, used to resolve local references
+type Document struct {
+	Root interface{}
+}
+
+// Parse parses JSON Schema content into a Document
+func Parse(content string) (*Document, error) {
+	var root interface{}
+	if err := json.Unmarshal([]byte(content), &root); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+
+	switch root.(type) {
+	case map[string]interface{}, bool:
+		return &Document{Root: root}, nil
+	default:
+		return nil, fmt.Errorf("invalid JSON schema: expected an object or boolean at the root")
+	}
+}
+
+// asObject returns the schema as an object, mapping boolean schemas to their object equivalents
+func asObject(schema interface{}) map[string]interface{} {
+	switch v := schema.(type) {
+	case map[string]interface{}:
+		return v
+	case bool:
+		if v {
+			return map[string]interface{}{}
+		}
+		return map[string]interface{}{"not": map[string]interface{}{}}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// Resolve follows a local "$ref" ("#/definitions/x", "#/$defs/x") within the document.
+// It returns the referenced schema and the reference string, or the schema itself when
+// it is not a resolvable local reference.
+func (d *Document) Resolve(schema interface{}) (interface{}, string) {
+	seen := map[string]bool{}
+	current := schema
+	lastRef := ""
+
+	for {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return current, lastRef
+		}
+		ref, ok := obj["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#") || seen[ref] {
+			return current, lastRef
+		}
+		seen[ref] = true
+
+		target, err := d.Pointer(ref)
+		if err != nil {
+			return current, lastRef
+		}
+		lastRef = ref
+		current = target
+	}
+}
+
+// Pointer evaluates a JSON pointer fragment such as "#/definitions/Address" against the document root
+func (d *Document) Pointer(ref string) (interface{}, error) {
+	fragment := strings.TrimPrefix(ref, "#")
+	if fragment == "" {
+		return d.Root, nil
+	}
+
+	if unescaped, err := url.PathUnescape(fragment); err == nil {
+		fragment = unescaped
+	}
+
+	current := d.Root
+	for _, token := range strings.Split(strings.TrimPrefix(fragment, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch v := current.(type) {
+		case map[string]interface{}:
+			next, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("unresolvable reference %s", ref)
+			}
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, fmt.Errorf("unresolvable reference %s", ref)
+			}
+			current = v[index]
+		default:
+			return nil, fmt.Errorf("unresolvable reference %s", ref)
+		}
+	}
+
+	return current, nil
+}
+
+// typeSet returns the declared types of a schema, or nil when any type is allowed
+func typeSet(schema map[string]interface{}) []string {
+	switch v := schema["type"].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var types []string
+		for _, t := range v {
+			if s, ok := t.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	default:
+		return nil
+	}
+}
+
+// number returns a numeric keyword value and whether it is present
+func number(schema map[string]interface{}, key string) (float64, bool) {
+	v, ok := schema[key].(float64)
+	return v, ok
+}
+
+// stringSlice returns a keyword holding an array of strings
+func stringSlice(schema map[string]interface{}, key string) []string {
+	values, _ := schema[key].([]interface{})
+	var result []string
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// childPath appends a JSON pointer segment to a path
+func childPath(path string, segments ...string) string {
+	for _, segment := range segments {
+		segment = strings.ReplaceAll(strings.ReplaceAll(segment, "~", "~0"), "/", "~1")
+		path += "/" + segment
+	}
+	return path
+}
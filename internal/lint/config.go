@@ -0,0 +1,220 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFile is the configuration file looked up in the working directory
+const DefaultConfigFile = ".ksr-lint.yaml"
+
+// Config enables, disables and parameterizes rules. Rules not mentioned keep their defaults.
+// Setting an option of a rule that is disabled by default enables it, unless the rule is
+// also given enabled: false.
+//
+//	rules:
+//	  field-doc:
+//	    severity: error
+//	  namespace-pattern:
+//	    pattern: '^com\.acme(\.[a-z0-9]+)*$'
+//	  max-depth: false
+type Config struct {
+	Rules map[string]RuleConfig `yaml:"rules" json:"rules"`
+}
+
+// RuleConfig holds the "enabled" and "severity" settings of a rule and its options
+type RuleConfig map[string]interface{}
+
+// UnmarshalYAML accepts a boolean as shorthand for enabling or disabling a rule
+func (rc *RuleConfig) UnmarshalYAML(value *yaml.Node) error {
+	var enabled bool
+	if value.Kind == yaml.ScalarNode && value.Decode(&enabled) == nil {
+		*rc = RuleConfig{"enabled": enabled}
+		return nil
+	}
+	var m map[string]interface{}
+	if err := value.Decode(&m); err != nil {
+		return err
+	}
+	*rc = m
+	return nil
+}
+
+// Options holds the effective options of a rule
+type Options map[string]interface{}
+
+// String returns a string option
+func (o Options) String(name string) string {
+	s, _ := o[name].(string)
+	return s
+}
+
+// Int returns an integer option
+func (o Options) Int(name string) int {
+	n, _ := o[name].(int)
+	return n
+}
+
+// Strings returns a list option
+func (o Options) Strings(name string) []string {
+	switch v := o[name].(type) {
+	case []string:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+type ruleSettings struct {
+	Enabled  bool
+	Severity Severity
+	Options  Options
+}
+
+// DefaultConfig returns a configuration that runs the built-in rules with their defaults
+func DefaultConfig() *Config {
+	return &Config{Rules: map[string]RuleConfig{}}
+}
+
+// LoadConfig reads and validates a YAML configuration file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lint config: %w", err)
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig parses and validates a YAML configuration
+func ParseConfig(data []byte) (*Config, error) {
+	cfg := DefaultConfig()
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse lint config: %w", err)
+	}
+	if cfg.Rules == nil {
+		cfg.Rules = map[string]RuleConfig{}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks that every configured rule and option exists and has a valid value
+func (c *Config) Validate() error {
+	ids := make([]string, 0, len(c.Rules))
+	for id := range c.Rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		rule := RuleByID(id)
+		if rule == nil {
+			return fmt.Errorf("unknown lint rule %q", id)
+		}
+		if _, err := c.settingsFor(rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settingsFor merges the configuration of a rule over its defaults
+func (c *Config) settingsFor(rule *Rule) (*ruleSettings, error) {
+	settings := &ruleSettings{
+		Enabled:  rule.Enabled,
+		Severity: rule.Severity,
+		Options:  Options{},
+	}
+	for name, value := range rule.Options {
+		settings.Options[name] = value
+	}
+
+	explicit, optionSet := false, false
+	for key, value := range c.Rules[rule.ID] {
+		switch key {
+		case "enabled":
+			enabled, ok := value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("rule %s: enabled must be true or false", rule.ID)
+			}
+			settings.Enabled = enabled
+			explicit = true
+		case "severity":
+			severity, err := ParseSeverity(fmt.Sprint(value))
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %w", rule.ID, err)
+			}
+			settings.Severity = severity
+		default:
+			def, known := rule.Options[key]
+			if !known {
+				return nil, fmt.Errorf("rule %s has no option %q", rule.ID, key)
+			}
+			if err := checkOptionType(def, value); err != nil {
+				return nil, fmt.Errorf("rule %s: option %s %w", rule.ID, key, err)
+			}
+			settings.Options[key] = value
+			optionSet = true
+		}
+	}
+	if optionSet && !explicit {
+		settings.Enabled = true
+	}
+
+	if rule.validate != nil {
+		if err := rule.validate(settings.Options); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.ID, err)
+		}
+	}
+	return settings, nil
+}
+
+// checkOptionType checks that a configured value has the type of the option's default
+func checkOptionType(def, value interface{}) error {
+	switch def.(type) {
+	case string:
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("must be a string")
+		}
+	case int:
+		if _, ok := value.(int); !ok {
+			return fmt.Errorf("must be an integer")
+		}
+	case []string:
+		if _, ok := value.([]interface{}); !ok {
+			return fmt.Errorf("must be a list")
+		}
+	}
+	return nil
+}
+
+// describeOptions renders option defaults for rule listings
+func describeOptions(options map[string]interface{}) string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		value := options[name]
+		if list, ok := value.([]string); ok {
+			value = "[" + strings.Join(list, ", ") + "]"
+		}
+		parts = append(parts, fmt.Sprintf("%s=%v", name, value))
+	}
+	return strings.Join(parts, " ")
+}
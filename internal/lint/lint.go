@@ -0,0 +1,112 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/internal/schema"
+)
+
+// Severity ranks how serious a finding is
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// ParseSeverity normalizes a severity name
+func ParseSeverity(name string) (Severity, error) {
+	switch Severity(strings.ToLower(name)) {
+	case SeverityError:
+		return SeverityError, nil
+	case SeverityWarning:
+		return SeverityWarning, nil
+	case SeverityInfo:
+		return SeverityInfo, nil
+	default:
+		return "", fmt.Errorf("unknown severity %q (expected error, warning or info)", name)
+	}
+}
+
+// Rank orders severities from info (1) to error (3)
+func (s Severity) Rank() int {
+	switch s {
+	case SeverityError:
+		return 3
+	case SeverityWarning:
+		return 2
+	case SeverityInfo:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Finding is a single rule violation
+type Finding struct {
+	Source   string   `json:"source"`
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule"`
+	Path     string   `json:"path"`
+	Message  string   `json:"message"`
+}
+
+// Target is a schema to lint. Previous is the schema it replaces, if any, which rules
+// about schema evolution compare against.
+type Target struct {
+	Source   string
+	Tree     *schema.Tree
+	Previous *schema.Tree
+}
+
+// Lint runs the rules enabled by the configuration against a schema. Findings are
+// ordered by severity, most serious first, then by rule and field order.
+func Lint(target *Target, cfg *Config) ([]Finding, error) {
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+
+	var findings []Finding
+	for _, rule := range Rules() {
+		settings, err := cfg.settingsFor(rule)
+		if err != nil {
+			return nil, err
+		}
+		if !settings.Enabled {
+			continue
+		}
+
+		report := func(path, format string, args ...interface{}) {
+			if path == "" {
+				path = "."
+			}
+			findings = append(findings, Finding{
+				Source:   target.Source,
+				Severity: settings.Severity,
+				Rule:     rule.ID,
+				Path:     path,
+				Message:  fmt.Sprintf(format, args...),
+			})
+		}
+		if err := rule.check(target, settings.Options, report); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.ID, err)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Severity.Rank() > findings[j].Severity.Rank()
+	})
+	return findings, nil
+}
+
+// Count returns the number of findings at each severity
+func Count(findings []Finding) map[Severity]int {
+	counts := map[Severity]int{}
+	for _, finding := range findings {
+		counts[finding.Severity]++
+	}
+	return counts
+}
@@ -0,0 +1,227 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aywengo/ksr-cli/internal/schema"
+)
+
+func mustParse(t *testing.T, schemaType, content string) *schema.Tree {
+	t.Helper()
+	tree, err := schema.Parse(schemaType, content)
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	return tree
+}
+
+// findingKeys returns "rule path" for every finding
+func findingKeys(findings []Finding) []string {
+	keys := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		keys = append(keys, finding.Rule+" "+finding.Path)
+	}
+	return keys
+}
+
+func TestLint_DefaultRules(t *testing.T) {
+	tree := mustParse(t, "AVRO", `{"type":"record","name":"user","namespace":"com.acme","fields":[
+		{"name":"id","type":"string","doc":"Identifier"},
+		{"name":"created_at","type":"long"},
+		{"name":"value","type":["null","string","int"],"doc":"Value"},
+		{"name":"status","type":{"type":"enum","name":"Status","symbols":["ACTIVE","inactive"]},"doc":"Status"}
+	]}`)
+
+	findings, err := Lint(&Target{Source: "user.avsc", Tree: tree}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"record-doc .",
+		"field-doc created_at",
+		"no-complex-unions value",
+		"nullable-default value",
+		"type-name-case .",
+		"enum-symbol-case status",
+	}
+	if got := findingKeys(findings); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected findings %v, got %v", expected, got)
+	}
+	for _, finding := range findings {
+		if finding.Source != "user.avsc" || finding.Severity != SeverityWarning {
+			t.Errorf("Unexpected finding %+v", finding)
+		}
+	}
+}
+
+func TestLint_NewFieldDefault(t *testing.T) {
+	previous := mustParse(t, "AVRO", `{"type":"record","name":"User","fields":[{"name":"id","type":"string"}]}`)
+	current := mustParse(t, "AVRO", `{"type":"record","name":"User","fields":[
+		{"name":"id","type":"string"},
+		{"name":"email","type":"string"},
+		{"name":"phone","type":["null","string"],"default":null},
+		{"name":"address","type":{"type":"record","name":"Address","fields":[{"name":"zip","type":"string"}]}}
+	]}`)
+
+	cfg, err := ParseConfig([]byte("rules:\n  new-field-default: true\n  field-doc: false\n  record-doc: false\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	findings, err := Lint(&Target{Tree: current, Previous: previous}, cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"new-field-default email", "new-field-default address"}
+	if got := findingKeys(findings); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected findings %v, got %v", expected, got)
+	}
+	if findings[0].Severity != SeverityError {
+		t.Errorf("Expected error severity, got %s", findings[0].Severity)
+	}
+
+	// Without a previous version the rule has nothing to compare against
+	findings, err = Lint(&Target{Tree: current}, cfg)
+	if err != nil || len(findings) != 0 {
+		t.Errorf("Expected no findings without a previous version, got %v (%v)", findings, err)
+	}
+}
+
+func TestLint_Config(t *testing.T) {
+	tree := mustParse(t, "AVRO", `{"type":"record","name":"User","namespace":"org.other","doc":"A user","fields":[
+		{"name":"userId","type":"string","doc":"Identifier"},
+		{"name":"id","type":"string"}
+	]}`)
+
+	cfg, err := ParseConfig([]byte(`rules:
+  namespace-pattern:
+    enabled: true
+    pattern: '^com\.acme'
+  field-doc:
+    severity: error
+    ignore: [id]
+  field-name-case:
+    style: snake_case
+    severity: info
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	findings, err := Lint(&Target{Tree: tree}, cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"namespace-pattern .", "field-name-case userId"}
+	if got := findingKeys(findings); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected findings %v, got %v", expected, got)
+	}
+	if findings[1].Severity != SeverityInfo {
+		t.Errorf("Expected info severity for field-name-case, got %s", findings[1].Severity)
+	}
+}
+
+func TestLint_JSONSchema(t *testing.T) {
+	tree := mustParse(t, "JSON", `{"type":"object","description":"An order","properties":{
+		"orderId":{"type":"string","description":"Id"},
+		"customerName":{"type":"string","description":"Name"},
+		"order_total":{"type":"number","description":"Total"},
+		"extra":{"description":"Anything"}
+	}}`)
+
+	findings, err := Lint(&Target{Tree: tree}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"field-name-case order_total", "no-untyped-fields extra"}
+	if got := findingKeys(findings); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected findings %v, got %v", expected, got)
+	}
+}
+
+func TestLint_MaxDepth(t *testing.T) {
+	tree := mustParse(t, "PROTOBUF", `syntax = "proto3";
+// Outer
+message A {
+  // b
+  B b = 1;
+}
+message B {
+  // c
+  C c = 1;
+}
+message C {
+  // value
+  string value = 1;
+}`)
+
+	cfg, err := ParseConfig([]byte("rules:\n  max-depth:\n    max: 2\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	findings, err := Lint(&Target{Tree: tree}, cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"max-depth b.c.value"}
+	if got := findingKeys(findings); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected findings %v, got %v", expected, got)
+	}
+}
+
+func TestParseConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		contains string
+	}{
+		{name: "unknown rule", config: "rules:\n  bogus: true\n", contains: `unknown lint rule "bogus"`},
+		{name: "unknown option", config: "rules:\n  field-doc:\n    style: x\n", contains: `has no option "style"`},
+		{name: "invalid severity", config: "rules:\n  field-doc:\n    severity: fatal\n", contains: "unknown severity"},
+		{name: "option type", config: "rules:\n  max-depth:\n    max: deep\n", contains: "must be an integer"},
+		{name: "invalid pattern", config: "rules:\n  namespace-pattern:\n    pattern: '('\n", contains: "invalid pattern"},
+		{name: "invalid style", config: "rules:\n  field-name-case:\n    style: kebab\n", contains: "unknown style"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseConfig([]byte(tt.config))
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("Expected error containing %q, got %v", tt.contains, err)
+			}
+		})
+	}
+}
+
+func TestParseConfig_OptionsEnableRule(t *testing.T) {
+	tree := mustParse(t, "AVRO", `{"type":"record","name":"User","namespace":"org.other","doc":"A user","fields":[]}`)
+
+	tests := []struct {
+		name     string
+		config   string
+		expected string
+	}{
+		{"option enables a disabled rule", "rules:\n  namespace-pattern:\n    pattern: '^com'\n", "namespace-pattern ."},
+		{"explicitly disabled", "rules:\n  namespace-pattern:\n    enabled: false\n    pattern: '^com'\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseConfig([]byte(tt.config))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			findings, err := Lint(&Target{Tree: tree}, cfg)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := strings.Join(findingKeys(findings), ","); got != tt.expected {
+				t.Errorf("Expected findings %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
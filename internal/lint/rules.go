@@ -0,0 +1,382 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/internal/schema"
+)
+
+// Rule is a built-in lint rule. Severity, Enabled and Options are the defaults a
+// configuration can override.
+type Rule struct {
+	ID          string
+	Description string
+	Severity    Severity
+	Enabled     bool
+	Options     map[string]interface{}
+
+	validate func(opts Options) error
+	check    func(t *Target, opts Options, report reportFunc) error
+}
+
+// reportFunc records a finding at a path
+type reportFunc func(path, format string, args ...interface{})
+
+// RuleInfo describes a rule and its effective settings, for listings
+type RuleInfo struct {
+	ID          string   `json:"id"`
+	Severity    Severity `json:"severity"`
+	Enabled     bool     `json:"enabled"`
+	Options     string   `json:"options,omitempty"`
+	Description string   `json:"description"`
+}
+
+var (
+	snakeCase      = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+	camelCase      = regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
+	pascalCase     = regexp.MustCompile(`^[A-Z][a-zA-Z0-9]*$`)
+	upperSnakeCase = regexp.MustCompile(`^[A-Z][A-Z0-9]*(_[A-Z0-9]+)*$`)
+)
+
+// caseStyles maps the naming styles rules accept to the patterns names must match
+var caseStyles = map[string]*regexp.Regexp{
+	"snake_case":       snakeCase,
+	"camelCase":        camelCase,
+	"PascalCase":       pascalCase,
+	"UPPER_SNAKE_CASE": upperSnakeCase,
+}
+
+var rules = []*Rule{
+	{
+		ID:          "record-doc",
+		Description: "The root record or message has documentation",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+		check: func(t *Target, opts Options, report reportFunc) error {
+			if t.Tree.Kind == schema.KindRecord && strings.TrimSpace(t.Tree.Doc) == "" {
+				report("", "%s has no documentation", rootName(t.Tree))
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "field-doc",
+		Description: "Every field has documentation",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+		Options:     map[string]interface{}{"ignore": []string{}},
+		check: func(t *Target, opts Options, report reportFunc) error {
+			ignored := stringSet(opts.Strings("ignore"))
+			t.Tree.Walk(func(node *schema.Node, depth int) bool {
+				if strings.TrimSpace(node.Doc) == "" && !ignored[node.Name] && !ignored[node.Path] {
+					report(node.Path, "field %s has no documentation", node.Name)
+				}
+				return true
+			})
+			return nil
+		},
+	},
+	{
+		ID:          "new-field-default",
+		Description: "Fields added since the previous version have defaults and are not required",
+		Severity:    SeverityError,
+		Enabled:     true,
+		check: func(t *Target, opts Options, report reportFunc) error {
+			if t.Previous == nil {
+				return nil
+			}
+			_, previous := t.Previous.Flatten()
+			t.Tree.Walk(func(node *schema.Node, depth int) bool {
+				if previous[node.Path] != nil {
+					return true
+				}
+				if node.Required {
+					if t.Tree.Format == schema.FormatAvro {
+						report(node.Path, "new field %s has no default, so data written with the previous version cannot be read", node.Name)
+					} else {
+						report(node.Path, "new field %s is required, so data written with the previous version is rejected", node.Name)
+					}
+				}
+				// Fields nested in a new field are new as well
+				return false
+			})
+			return nil
+		},
+	},
+	{
+		ID:          "namespace-pattern",
+		Description: "Namespaces and Protobuf packages match a regular expression",
+		Severity:    SeverityError,
+		Enabled:     false,
+		Options:     map[string]interface{}{"pattern": ""},
+		validate: func(opts Options) error {
+			if _, err := regexp.Compile(opts.String("pattern")); err != nil {
+				return fmt.Errorf("invalid pattern: %w", err)
+			}
+			return nil
+		},
+		check: func(t *Target, opts Options, report reportFunc) error {
+			if opts.String("pattern") == "" {
+				return fmt.Errorf("the pattern option is required")
+			}
+			if t.Tree.Format == schema.FormatJSON {
+				return nil
+			}
+			pattern := regexp.MustCompile(opts.String("pattern"))
+
+			if t.Tree.Name != "" || t.Tree.Namespace != "" {
+				if !pattern.MatchString(t.Tree.Namespace) {
+					report("", "namespace %q of %s does not match %s", t.Tree.Namespace, rootName(t.Tree), pattern)
+				}
+			}
+			seen := map[string]bool{}
+			t.Tree.Walk(func(node *schema.Node, depth int) bool {
+				if node.TypeName != "" && node.Namespace != "" && !seen[node.TypeName] {
+					seen[node.TypeName] = true
+					if !pattern.MatchString(node.Namespace) {
+						report(node.Path, "namespace %q of %s does not match %s", node.Namespace, node.TypeName, pattern)
+					}
+				}
+				return true
+			})
+			return nil
+		},
+	},
+	{
+		ID:          "no-complex-unions",
+		Description: "Unions contain at most null and one other type",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+		check: func(t *Target, opts Options, report reportFunc) error {
+			if t.Tree.Kind == schema.KindUnion {
+				report("", "the schema is a %s; only null and one other type are allowed", t.Tree.Type)
+			}
+			t.Tree.Walk(func(node *schema.Node, depth int) bool {
+				if node.Kind == schema.KindUnion {
+					report(node.Path, "field %s is a %s; only null and one other type are allowed", node.Name, node.Type)
+				}
+				return true
+			})
+			return nil
+		},
+	},
+	{
+		ID:          "nullable-default",
+		Description: "Nullable Avro fields default to null",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+		check: func(t *Target, opts Options, report reportFunc) error {
+			if t.Tree.Format != schema.FormatAvro {
+				return nil
+			}
+			t.Tree.Walk(func(node *schema.Node, depth int) bool {
+				if node.Nullable && !node.HasDefault {
+					report(node.Path, "nullable field %s has no default; add \"default\": null", node.Name)
+				}
+				return true
+			})
+			return nil
+		},
+	},
+	{
+		ID:          "field-name-case",
+		Description: "Field names follow a naming style: snake_case, camelCase, PascalCase or consistent (one style per schema)",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+		Options:     map[string]interface{}{"style": "consistent"},
+		validate: func(opts Options) error {
+			return validateStyle(opts.String("style"))
+		},
+		check: func(t *Target, opts Options, report reportFunc) error {
+			nodes, _ := t.Tree.Flatten()
+			style := opts.String("style")
+			if style == "consistent" {
+				style = dominantStyle(nodes)
+			}
+			pattern := caseStyles[style]
+			for _, node := range nodes {
+				if !pattern.MatchString(node.Name) {
+					report(node.Path, "field name %s is not %s", node.Name, style)
+				}
+			}
+			return nil
+		},
+	},
+	{
+		ID:          "type-name-case",
+		Description: "Avro record, enum and fixed names and Protobuf message and enum names are PascalCase",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+		check: func(t *Target, opts Options, report reportFunc) error {
+			if t.Tree.Format == schema.FormatJSON {
+				return nil
+			}
+			if t.Tree.Name != "" && !pascalCase.MatchString(shortName(t.Tree.Name)) {
+				report("", "type name %s is not PascalCase", t.Tree.Name)
+			}
+			seen := map[string]bool{}
+			t.Tree.Walk(func(node *schema.Node, depth int) bool {
+				if node.TypeName != "" && !seen[node.TypeName] {
+					seen[node.TypeName] = true
+					if !pascalCase.MatchString(shortName(node.TypeName)) {
+						report(node.Path, "type name %s is not PascalCase", node.TypeName)
+					}
+				}
+				return true
+			})
+			return nil
+		},
+	},
+	{
+		ID:          "enum-symbol-case",
+		Description: "Enum symbols are UPPER_SNAKE_CASE",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+		check: func(t *Target, opts Options, report reportFunc) error {
+			seen := map[string]bool{}
+			t.Tree.Walk(func(node *schema.Node, depth int) bool {
+				if node.TypeName != "" {
+					if seen[node.TypeName] {
+						return true
+					}
+					seen[node.TypeName] = true
+				}
+				for _, symbol := range node.Symbols {
+					if !upperSnakeCase.MatchString(symbol) {
+						report(node.Path, "enum symbol %s is not UPPER_SNAKE_CASE", symbol)
+					}
+				}
+				return true
+			})
+			return nil
+		},
+	},
+	{
+		ID:          "no-untyped-fields",
+		Description: "JSON Schema properties declare a type",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+		check: func(t *Target, opts Options, report reportFunc) error {
+			t.Tree.Walk(func(node *schema.Node, depth int) bool {
+				if node.Kind == schema.KindAny {
+					report(node.Path, "field %s accepts any value", node.Name)
+				}
+				return true
+			})
+			return nil
+		},
+	},
+	{
+		ID:          "max-depth",
+		Description: "Records are nested at most a number of levels deep",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+		Options:     map[string]interface{}{"max": 5},
+		validate: func(opts Options) error {
+			if opts.Int("max") < 1 {
+				return fmt.Errorf("max must be at least 1")
+			}
+			return nil
+		},
+		check: func(t *Target, opts Options, report reportFunc) error {
+			max := opts.Int("max")
+			t.Tree.Walk(func(node *schema.Node, depth int) bool {
+				if depth > max {
+					report(node.Path, "field %s is nested %d levels deep (maximum %d)", node.Name, depth, max)
+					return false
+				}
+				return true
+			})
+			return nil
+		},
+	},
+}
+
+// Rules returns the built-in rules
+func Rules() []*Rule {
+	return rules
+}
+
+// RuleByID returns the built-in rule with an ID, or nil
+func RuleByID(id string) *Rule {
+	for _, rule := range rules {
+		if rule.ID == id {
+			return rule
+		}
+	}
+	return nil
+}
+
+// Describe lists the built-in rules with the settings a configuration gives them
+func Describe(cfg *Config) ([]RuleInfo, error) {
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+	infos := make([]RuleInfo, 0, len(rules))
+	for _, rule := range rules {
+		settings, err := cfg.settingsFor(rule)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, RuleInfo{
+			ID:          rule.ID,
+			Severity:    settings.Severity,
+			Enabled:     settings.Enabled,
+			Options:     describeOptions(settings.Options),
+			Description: rule.Description,
+		})
+	}
+	return infos, nil
+}
+
+// validateStyle checks a naming style option
+func validateStyle(style string) error {
+	if _, ok := caseStyles[style]; ok || style == "consistent" {
+		return nil
+	}
+	return fmt.Errorf("unknown style %q (expected snake_case, camelCase, PascalCase, UPPER_SNAKE_CASE or consistent)", style)
+}
+
+// dominantStyle returns the naming style most field names follow, preferring snake_case on a tie.
+// Single lowercase words fit both snake_case and camelCase and are not counted.
+func dominantStyle(nodes []*schema.Node) string {
+	var snake, camel int
+	for _, node := range nodes {
+		switch {
+		case strings.Contains(node.Name, "_") && snakeCase.MatchString(node.Name):
+			snake++
+		case node.Name != strings.ToLower(node.Name) && camelCase.MatchString(node.Name):
+			camel++
+		}
+	}
+	if camel > snake {
+		return "camelCase"
+	}
+	return "snake_case"
+}
+
+// rootName names the root type of a tree in messages
+func rootName(tree *schema.Tree) string {
+	if tree.Name == "" {
+		return "the schema"
+	}
+	if tree.Namespace != "" && !strings.Contains(tree.Name, ".") {
+		return tree.Namespace + "." + tree.Name
+	}
+	return tree.Name
+}
+
+// shortName strips the namespace from a full name
+func shortName(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
@@ -0,0 +1,225 @@
+// Package manifest reads declarative manifests of the desired state of a Schema Registry
+// (subjects with their schema files, references, compatibility levels and modes) and
+// plans the changes that bring a registry to that state.
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/internal/compat"
+	"gopkg.in/yaml.v3"
+)
+
+// Modes lists the modes a registry or subject can be set to
+var Modes = []string{"READWRITE", "READONLY", "READONLY_OVERRIDE", "IMPORT"}
+
+// Manifest is the desired state of a registry. Subjects not listed are left alone.
+//
+//	context: .staging
+//	compatibility: BACKWARD
+//	mode: READWRITE
+//	subjects:
+//	  customers-value:
+//	    schema: schemas/customer.avsc
+//	  orders-value:
+//	    schema: schemas/order.avsc
+//	    references:
+//	      - name: com.acme.Customer
+//	        subject: customers-value
+//	        version: latest
+//	    compatibility: FULL
+type Manifest struct {
+	Context       string              `yaml:"context,omitempty" json:"context,omitempty"`
+	Compatibility string              `yaml:"compatibility,omitempty" json:"compatibility,omitempty"`
+	Mode          string              `yaml:"mode,omitempty" json:"mode,omitempty"`
+	Subjects      map[string]*Subject `yaml:"subjects" json:"subjects"`
+
+	// Dir is the directory the schema paths are relative to
+	Dir string `yaml:"-" json:"-"`
+}
+
+// Subject is the desired state of a subject. Without a schema only its compatibility
+// level and mode are managed.
+type Subject struct {
+	Schema        string      `yaml:"schema,omitempty" json:"schema,omitempty"`
+	Type          string      `yaml:"type,omitempty" json:"type,omitempty"`
+	Record        string      `yaml:"record,omitempty" json:"record,omitempty"`
+	References    []Reference `yaml:"references,omitempty" json:"references,omitempty"`
+	Compatibility string      `yaml:"compatibility,omitempty" json:"compatibility,omitempty"`
+	Mode          string      `yaml:"mode,omitempty" json:"mode,omitempty"`
+}
+
+// Reference is a reference of a schema to a version of another subject, a number or latest
+type Reference struct {
+	Name    string `yaml:"name" json:"name"`
+	Subject string `yaml:"subject" json:"subject"`
+	Version string `yaml:"version" json:"version"`
+}
+
+// Load reads and validates a manifest file
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	m, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	m.Dir = filepath.Dir(path)
+	return m, nil
+}
+
+// Parse parses and validates a YAML (or JSON) manifest
+func Parse(data []byte) (*Manifest, error) {
+	m := &Manifest{}
+	if err := yaml.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if m.Subjects == nil {
+		m.Subjects = map[string]*Subject{}
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Validate checks levels, modes and references, and normalizes their case
+func (m *Manifest) Validate() error {
+	var err error
+	if m.Compatibility, err = validLevel(m.Compatibility); err != nil {
+		return err
+	}
+	if m.Mode, err = validMode(m.Mode); err != nil {
+		return err
+	}
+
+	for _, name := range m.SubjectNames() {
+		s := m.Subjects[name]
+		if s == nil {
+			return fmt.Errorf("subject %s: nothing to manage", name)
+		}
+		if s.Compatibility, err = validLevel(s.Compatibility); err != nil {
+			return fmt.Errorf("subject %s: %w", name, err)
+		}
+		if s.Mode, err = validMode(s.Mode); err != nil {
+			return fmt.Errorf("subject %s: %w", name, err)
+		}
+		if s.Schema == "" && (s.Type != "" || s.Record != "" || len(s.References) > 0) {
+			return fmt.Errorf("subject %s: type, record and references need a schema", name)
+		}
+		if s.Schema == "" && s.Compatibility == "" && s.Mode == "" {
+			return fmt.Errorf("subject %s: nothing to manage", name)
+		}
+		s.Type = strings.ToUpper(s.Type)
+		switch s.Type {
+		case "", "AVRO", "JSON", "PROTOBUF":
+		default:
+			return fmt.Errorf("subject %s: unsupported schema type %s (expected AVRO, JSON or PROTOBUF)", name, s.Type)
+		}
+		for _, ref := range s.References {
+			if ref.Name == "" || ref.Subject == "" {
+				return fmt.Errorf("subject %s: references need a name and a subject", name)
+			}
+			if _, err := ref.VersionNumber(); err != nil {
+				return fmt.Errorf("subject %s: reference %s: %w", name, ref.Name, err)
+			}
+		}
+	}
+
+	_, err = m.Order()
+	return err
+}
+
+func validLevel(level string) (string, error) {
+	if level == "" {
+		return "", nil
+	}
+	level = strings.ToUpper(level)
+	if _, _, _, err := compat.ParseLevel(level); err != nil {
+		return "", err
+	}
+	return level, nil
+}
+
+func validMode(mode string) (string, error) {
+	if mode == "" {
+		return "", nil
+	}
+	mode = strings.ToUpper(mode)
+	for _, valid := range Modes {
+		if mode == valid {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("invalid mode %s (expected %s)", mode, strings.Join(Modes, ", "))
+}
+
+// VersionNumber returns the referenced version, or 0 for latest
+func (r Reference) VersionNumber() (int, error) {
+	if r.Version == "" || r.Version == "latest" {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(r.Version)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("version must be a positive number or latest, got %q", r.Version)
+	}
+	return version, nil
+}
+
+// SchemaPath returns the path of a subject's schema file, relative to the working directory
+func (m *Manifest) SchemaPath(s *Subject) string {
+	if s.Schema == "" || filepath.IsAbs(s.Schema) {
+		return s.Schema
+	}
+	return filepath.Join(m.Dir, s.Schema)
+}
+
+// SubjectNames returns the names of the subjects, sorted
+func (m *Manifest) SubjectNames() []string {
+	names := make([]string, 0, len(m.Subjects))
+	for name := range m.Subjects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Order returns the subjects with the subjects they reference first, otherwise sorted by
+// name, and fails when references between subjects of the manifest form a cycle
+func (m *Manifest) Order() ([]string, error) {
+	var order []string
+	state := map[string]int{} // 1: visiting, 2: done
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("references form a cycle: %s", strings.Join(append(path, name), " -> "))
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		for _, ref := range m.Subjects[name].References {
+			if _, ok := m.Subjects[ref.Subject]; ok {
+				if err := visit(ref.Subject, append(path, name)); err != nil {
+					return err
+				}
+			}
+		}
+		state[name] = 2
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range m.SubjectNames() {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
@@ -0,0 +1,151 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const desired = `
+compatibility: backward
+mode: READWRITE
+subjects:
+  orders-value:
+    schema: schemas/order.avsc
+    references:
+      - name: com.acme.Customer
+        subject: customers-value
+        version: latest
+      - name: com.acme.Money
+        subject: money-value
+        version: 2
+    compatibility: full
+  customers-value:
+    schema: schemas/customer.avsc
+  audit-value:
+    mode: readonly
+`
+
+func TestParse(t *testing.T) {
+	m, err := Parse([]byte(desired))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if m.Compatibility != "BACKWARD" || m.Subjects["orders-value"].Compatibility != "FULL" || m.Subjects["audit-value"].Mode != "READONLY" {
+		t.Errorf("Expected levels and modes in upper case, got %+v", m)
+	}
+	if version, _ := m.Subjects["orders-value"].References[1].VersionNumber(); version != 2 {
+		t.Errorf("Expected reference version 2, got %d", version)
+	}
+
+	order, _ := m.Order()
+	if got := strings.Join(order, ","); got != "audit-value,customers-value,orders-value" {
+		t.Errorf("Unexpected order %s", got)
+	}
+
+	m.Dir = "deploy"
+	if got := m.SchemaPath(m.Subjects["orders-value"]); got != "deploy/schemas/order.avsc" {
+		t.Errorf("Expected the schema path relative to the manifest, got %s", got)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		expected string
+	}{
+		{"invalid level", "compatibility: STRICT", "invalid compatibility level"},
+		{"invalid mode", "subjects:\n  a:\n    mode: OFF", "invalid mode"},
+		{"empty subject", "subjects:\n  a: {}", "nothing to manage"},
+		{"references without schema", "subjects:\n  a:\n    mode: IMPORT\n    references: [{name: x, subject: b}]", "need a schema"},
+		{"invalid type", "subjects:\n  a:\n    schema: a.xsd\n    type: XML", "unsupported schema type"},
+		{"invalid reference", "subjects:\n  a:\n    schema: a.avsc\n    references: [{name: x, subject: b, version: first}]", "positive number or latest"},
+		{"cycle", "subjects:\n  a:\n    schema: a.avsc\n    references: [{name: x, subject: b}]\n  b:\n    schema: b.avsc\n    references: [{name: y, subject: a}]", "cycle: a -> b -> a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.manifest))
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	m, err := Parse([]byte(desired))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	summarize := func(plan *Plan) string {
+		var parts []string
+		for _, c := range plan.Changes {
+			part := fmt.Sprintf("%s %s %s %s->%s", c.Target(), c.Kind, c.Action, c.From, c.To)
+			if c.Check != "" {
+				part += " [" + c.Check + "]"
+			}
+			parts = append(parts, part)
+		}
+		return strings.Join(parts, "; ")
+	}
+
+	// An empty registry gets everything, dependencies first and levels before schemas
+	plan, err := Diff(m, &State{Compatibility: "BACKWARD", Mode: "READWRITE", Subjects: map[string]SubjectState{}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "audit-value mode set ->READONLY; " +
+		"customers-value schema create ->schemas/customer.avsc; " +
+		"orders-value compatibility set ->FULL; " +
+		"orders-value schema create ->schemas/order.avsc [deferred]"
+	if got := summarize(plan); got != expected {
+		t.Errorf("Unexpected plan:\n%s\nexpected:\n%s", got, expected)
+	}
+	if creates, registers, sets, _ := plan.Count(); creates != 2 || registers != 0 || sets != 2 {
+		t.Errorf("Unexpected counts %d %d %d", creates, registers, sets)
+	}
+
+	// A registry in the desired state needs no changes
+	plan, _ = Diff(m, &State{Compatibility: "BACKWARD", Mode: "READWRITE", Subjects: map[string]SubjectState{
+		"customers-value": {Latest: 3, Matching: 3},
+		"orders-value":    {Latest: 2, Matching: 1, Compatibility: "FULL"},
+		"audit-value":     {Mode: "READONLY"},
+	}})
+	if len(plan.Changes) != 0 || len(plan.Unchanged) != 3 {
+		t.Errorf("Expected no changes, got %s (unchanged %v)", summarize(plan), plan.Unchanged)
+	}
+
+	// Read-only modes are set after the schema of their subject, or of all subjects
+	m.Mode = "READONLY"
+	m.Subjects["customers-value"].Mode = "READONLY"
+	plan, _ = Diff(m, &State{Compatibility: "BACKWARD", Mode: "READWRITE", Subjects: map[string]SubjectState{
+		"customers-value": {Latest: 1},
+		"orders-value":    {Latest: 1, Matching: 1, Compatibility: "FULL"},
+		"audit-value":     {Mode: "READONLY"},
+	}})
+	expected = "customers-value schema register version 1->schemas/customer.avsc; " +
+		"customers-value mode set ->READONLY; " +
+		"orders-value schema register version 1->schemas/order.avsc [deferred]; " +
+		"(global) mode set READWRITE->READONLY"
+	if got := summarize(plan); got != expected {
+		t.Errorf("Unexpected plan:\n%s\nexpected:\n%s", got, expected)
+	}
+	m.Mode = "READWRITE"
+	m.Subjects["customers-value"].Mode = ""
+
+	// A new customer schema makes the orders schema, referencing its latest version, change too
+	plan, _ = Diff(m, &State{Compatibility: "FORWARD", Mode: "READONLY", Subjects: map[string]SubjectState{
+		"customers-value": {Latest: 3},
+		"orders-value":    {Latest: 2, Matching: 2, Compatibility: "FULL"},
+		"audit-value":     {Mode: "READONLY"},
+	}})
+	expected = "(global) compatibility set FORWARD->BACKWARD; " +
+		"(global) mode set READONLY->READWRITE; " +
+		"customers-value schema register version 3->schemas/customer.avsc; " +
+		"orders-value schema register version 2->schemas/order.avsc [deferred]"
+	if got := summarize(plan); got != expected {
+		t.Errorf("Unexpected plan:\n%s\nexpected:\n%s", got, expected)
+	}
+}
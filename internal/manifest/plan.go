@@ -0,0 +1,183 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/internal/compat"
+)
+
+// Kinds of changes
+const (
+	KindSchema        = "schema"
+	KindCompatibility = "compatibility"
+	KindMode          = "mode"
+)
+
+// Actions of changes
+const (
+	// ActionCreate registers the first version of a subject
+	ActionCreate = "create"
+	// ActionRegister registers a new version of an existing subject
+	ActionRegister = "register"
+	// ActionSet sets a compatibility level or mode
+	ActionSet = "set"
+)
+
+// Results of the compatibility pre-check of schema changes
+const (
+	CheckCompatible   = "compatible"
+	CheckIncompatible = "incompatible"
+	// CheckDeferred is for schemas referencing the latest version of a subject that
+	// the plan registers a new version of, which only exists once applied
+	CheckDeferred = "deferred"
+	// CheckSkipped is for schemas that could not be checked
+	CheckSkipped = "skipped"
+)
+
+// State is the current state of a registry, for the subjects of a manifest
+type State struct {
+	Compatibility string
+	Mode          string
+	Subjects      map[string]SubjectState
+}
+
+// SubjectState is the current state of a subject
+type SubjectState struct {
+	// Latest is the latest version, 0 when the subject does not exist
+	Latest int
+	// Matching is the version registered with the desired schema, 0 when none is
+	Matching int
+	// Compatibility and Mode are the subject's own settings, "" when inherited
+	Compatibility string
+	Mode          string
+}
+
+// Change is a change of a plan. Global changes have no subject.
+type Change struct {
+	Subject string         `json:"subject,omitempty"`
+	Kind    string         `json:"kind"`
+	Action  string         `json:"action"`
+	From    string         `json:"from,omitempty"`
+	To      string         `json:"to"`
+	Check   string         `json:"check,omitempty"`
+	Note    string         `json:"note,omitempty"`
+	Issues  []compat.Issue `json:"issues,omitempty"`
+}
+
+// Target names what a change applies to
+func (c Change) Target() string {
+	if c.Subject == "" {
+		return "(global)"
+	}
+	return c.Subject
+}
+
+// Plan lists the changes bringing a registry to the state of a manifest, in the order
+// they must be applied
+type Plan struct {
+	Context   string   `json:"context,omitempty"`
+	Changes   []Change `json:"changes"`
+	Unchanged []string `json:"unchanged"`
+}
+
+// Diff compares a manifest with the current state of a registry. Within a subject the
+// compatibility level is set before a new schema is registered, so the schema is checked
+// under the desired level, and modes are set before when they allow writes and after when
+// they make the subject read-only. Subjects are planned with the subjects they reference
+// first, between global changes following the same rule for the mode.
+func Diff(m *Manifest, state *State) (*Plan, error) {
+	order, err := m.Order()
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Context: m.Context, Changes: []Change{}, Unchanged: []string{}}
+	var last []Change
+	if m.Compatibility != "" && !strings.EqualFold(m.Compatibility, state.Compatibility) {
+		plan.Changes = append(plan.Changes, Change{Kind: KindCompatibility, Action: ActionSet, From: state.Compatibility, To: m.Compatibility})
+	}
+	if m.Mode != "" && !strings.EqualFold(m.Mode, state.Mode) {
+		change := Change{Kind: KindMode, Action: ActionSet, From: state.Mode, To: m.Mode}
+		if writable(m.Mode) {
+			plan.Changes = append(plan.Changes, change)
+		} else {
+			last = append(last, change)
+		}
+	}
+
+	pendingSchemas := map[string]bool{}
+	for _, name := range order {
+		s := m.Subjects[name]
+		current := state.Subjects[name]
+		var changes, after []Change
+
+		if s.Compatibility != "" && !strings.EqualFold(s.Compatibility, current.Compatibility) {
+			changes = append(changes, Change{Subject: name, Kind: KindCompatibility, Action: ActionSet, From: current.Compatibility, To: s.Compatibility})
+		}
+		if s.Mode != "" && !strings.EqualFold(s.Mode, current.Mode) {
+			change := Change{Subject: name, Kind: KindMode, Action: ActionSet, From: current.Mode, To: s.Mode}
+			if writable(s.Mode) {
+				changes = append(changes, change)
+			} else {
+				after = append(after, change)
+			}
+		}
+		if s.Schema != "" {
+			change := Change{Subject: name, Kind: KindSchema, To: s.Schema}
+			var deferredBy []string
+			for _, ref := range s.References {
+				if version, _ := ref.VersionNumber(); version == 0 && pendingSchemas[ref.Subject] {
+					deferredBy = append(deferredBy, ref.Subject)
+				}
+			}
+			switch {
+			case current.Latest == 0:
+				change.Action = ActionCreate
+			case current.Matching == 0 || len(deferredBy) > 0:
+				change.Action = ActionRegister
+				change.From = fmt.Sprintf("version %d", current.Latest)
+			}
+			if len(deferredBy) > 0 {
+				change.Check = CheckDeferred
+				change.Note = fmt.Sprintf("references the new version of %s", strings.Join(deferredBy, ", "))
+			}
+			if change.Action != "" {
+				changes = append(changes, change)
+				pendingSchemas[name] = true
+			}
+		}
+
+		changes = append(changes, after...)
+		if len(changes) == 0 {
+			plan.Unchanged = append(plan.Unchanged, name)
+		}
+		plan.Changes = append(plan.Changes, changes...)
+	}
+
+	plan.Changes = append(plan.Changes, last...)
+	return plan, nil
+}
+
+// writable reports whether a mode allows registering schemas
+func writable(mode string) bool {
+	return !strings.HasPrefix(strings.ToUpper(mode), "READONLY")
+}
+
+// Count returns the number of changes of each action, and of failing pre-checks
+func (p *Plan) Count() (creates, registers, sets, incompatible int) {
+	for _, change := range p.Changes {
+		switch change.Action {
+		case ActionCreate:
+			creates++
+		case ActionRegister:
+			registers++
+		case ActionSet:
+			sets++
+		}
+		if change.Check == CheckIncompatible {
+			incompatible++
+		}
+	}
+	return creates, registers, sets, incompatible
+}
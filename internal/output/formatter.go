@@ -3,6 +3,7 @@ package output
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
 	"strconv"
@@ -16,43 +17,48 @@ import (
 
 // Print outputs data in the specified format
 func Print(data interface{}, format string) error {
+	return PrintTo(os.Stdout, data, format)
+}
+
+// PrintTo outputs data in the specified format to a writer
+func PrintTo(w io.Writer, data interface{}, format string) error {
 	switch strings.ToLower(format) {
 	case "json":
-		return printJSON(data)
+		return printJSON(w, data)
 	case "yaml", "yml":
-		return printYAML(data)
+		return printYAML(w, data)
 	case "table":
-		return printTable(data)
+		return printTable(w, data)
 	default:
 		return fmt.Errorf("unsupported output format: %s", format)
 	}
 }
 
 // printJSON outputs data as JSON
-func printJSON(data interface{}) error {
-	encoder := json.NewEncoder(os.Stdout)
+func printJSON(w io.Writer, data interface{}) error {
+	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(data)
 }
 
 // printYAML outputs data as YAML
-func printYAML(data interface{}) error {
-	encoder := yaml.NewEncoder(os.Stdout)
+func printYAML(w io.Writer, data interface{}) error {
+	encoder := yaml.NewEncoder(w)
 	defer encoder.Close()
 	return encoder.Encode(data)
 }
 
 // printTable outputs data as a formatted table
-func printTable(data interface{}) error {
+func printTable(w io.Writer, data interface{}) error {
 	t := table.NewWriter()
-	t.SetOutputMirror(os.Stdout)
+	t.SetOutputMirror(w)
 
 	// Handle different data types
 	switch v := data.(type) {
 	case []string:
-		return printStringSliceTable(v)
+		return printStringSliceTable(w, v)
 	case []int:
-		return printIntSliceTable(v)
+		return printIntSliceTable(w, v)
 	case []interface{}:
 		return printInterfaceSliceTable(v, t)
 	default:
@@ -61,9 +67,9 @@ func printTable(data interface{}) error {
 }
 
 // printStringSliceTable prints a slice of strings as a table
-func printStringSliceTable(data []string) error {
+func printStringSliceTable(w io.Writer, data []string) error {
 	t := table.NewWriter()
-	t.SetOutputMirror(os.Stdout)
+	t.SetOutputMirror(w)
 	t.AppendHeader(table.Row{"Subjects"})
 
 	for _, item := range data {
@@ -75,9 +81,9 @@ func printStringSliceTable(data []string) error {
 }
 
 // printIntSliceTable prints a slice of integers as a table
-func printIntSliceTable(data []int) error {
+func printIntSliceTable(w io.Writer, data []int) error {
 	t := table.NewWriter()
-	t.SetOutputMirror(os.Stdout)
+	t.SetOutputMirror(w)
 	t.AppendHeader(table.Row{"Versions"})
 
 	for _, item := range data {
@@ -0,0 +1,288 @@
+package protobuf
+
+import (
+	"strings"
+)
+
+// Node holds the source position and comments shared by all declarations
+type Node struct {
+	Line            int
+	LeadingComments []string
+	TrailingComment string
+	// SpaceBefore records a blank line before the declaration in the source
+	SpaceBefore bool
+}
+
+func (n *Node) node() *Node { return n }
+
+// Decl is a declaration in a file or in the body of a message, enum, service, oneof or extend block
+type Decl interface {
+	node() *Node
+}
+
+// File is a parsed .proto file
+type File struct {
+	Syntax  string // "proto2" or "proto3"; empty when the file uses editions
+	Edition string
+	Package string
+	Imports []*Import
+	Options []*Option
+
+	Messages []*Message
+	Enums    []*Enum
+	Services []*Service
+	Extends  []*Extend
+
+	// Decls holds every top-level declaration in source order
+	Decls       []Decl
+	EndComments []string
+}
+
+// SyntaxDecl is a syntax or edition statement
+type SyntaxDecl struct {
+	Node
+	Keyword string // "syntax" or "edition"
+	Value   string
+}
+
+// PackageDecl is a package statement
+type PackageDecl struct {
+	Node
+	Name string
+}
+
+// Import is an import statement
+type Import struct {
+	Node
+	Path     string
+	Modifier string // "public", "weak" or empty
+}
+
+// Option is an option statement or a field option. Value holds the value in protobuf
+// syntax: a quoted string, a number, an identifier or an aggregate in text format.
+type Option struct {
+	Node
+	Name  string
+	Value string
+}
+
+// Message is a message definition
+type Message struct {
+	Node
+	Name     string
+	FullName string
+	Parent   *Message
+
+	// Fields holds every field in declaration order, including oneof members
+	Fields     []*Field
+	Oneofs     []*Oneof
+	Messages   []*Message
+	Enums      []*Enum
+	Extends    []*Extend
+	Options    []*Option
+	Reserved   []*Reserved
+	Extensions []*Extensions
+
+	Decls       []Decl
+	EndComments []string
+}
+
+// Field is a message field
+type Field struct {
+	Node
+	Label    string // "optional", "repeated", "required" or empty
+	Type     string // as written, for map fields the value type
+	KeyType  string // key type of a map field
+	Name     string
+	Number   int
+	Options  []*Option
+	Oneof    *Oneof
+	Message  *Message
+	IsMap    bool
+	JSONName string
+}
+
+// Oneof is a oneof block
+type Oneof struct {
+	Node
+	Name        string
+	Fields      []*Field
+	Options     []*Option
+	Decls       []Decl
+	EndComments []string
+}
+
+// Enum is an enum definition
+type Enum struct {
+	Node
+	Name     string
+	FullName string
+	Parent   *Message
+	Values   []*EnumValue
+	Options  []*Option
+	Reserved []*Reserved
+
+	Decls       []Decl
+	EndComments []string
+}
+
+// EnumValue is a value of an enum
+type EnumValue struct {
+	Node
+	Name    string
+	Number  int
+	Options []*Option
+}
+
+// Range is a range of field or enum numbers; Max marks an open-ended range
+type Range struct {
+	Start int
+	End   int
+	Max   bool
+}
+
+// Reserved is a reserved statement listing numbers or names
+type Reserved struct {
+	Node
+	Ranges []Range
+	Names  []string
+}
+
+// Extensions is an extensions statement
+type Extensions struct {
+	Node
+	Ranges  []Range
+	Options []*Option
+}
+
+// Extend is an extend block
+type Extend struct {
+	Node
+	Extendee    string
+	Fields      []*Field
+	Decls       []Decl
+	EndComments []string
+}
+
+// Service is a service definition
+type Service struct {
+	Node
+	Name        string
+	Methods     []*Method
+	Options     []*Option
+	Decls       []Decl
+	EndComments []string
+}
+
+// Method is an rpc definition
+type Method struct {
+	Node
+	Name         string
+	InputType    string
+	InputStream  bool
+	OutputType   string
+	OutputStream bool
+	Options      []*Option
+	HasBody      bool
+	Decls        []Decl
+	EndComments  []string
+}
+
+// optionValue returns the value of an option by name
+func optionValue(options []*Option, name string) (string, bool) {
+	for _, option := range options {
+		if option.Name == name {
+			return option.Value, true
+		}
+	}
+	return "", false
+}
+
+// Option returns the value of a field option by name
+func (f *Field) Option(name string) (string, bool) {
+	return optionValue(f.Options, name)
+}
+
+// Option returns the value of a message option by name
+func (m *Message) Option(name string) (string, bool) {
+	return optionValue(m.Options, name)
+}
+
+// Option returns the value of a file option by name
+func (f *File) Option(name string) (string, bool) {
+	return optionValue(f.Options, name)
+}
+
+// IsRepeated reports whether a field is repeated; map fields are repeated entries on the wire
+func (f *Field) IsRepeated() bool {
+	return f.Label == "repeated" || f.IsMap
+}
+
+// FieldByName returns a field of the message by name
+func (m *Message) FieldByName(name string) *Field {
+	for _, field := range m.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// FieldByNumber returns a field of the message by number
+func (m *Message) FieldByNumber(number int) *Field {
+	for _, field := range m.Fields {
+		if field.Number == number {
+			return field
+		}
+	}
+	return nil
+}
+
+// ValueByNumber returns the first enum value with the given number
+func (e *Enum) ValueByNumber(number int) *EnumValue {
+	for _, value := range e.Values {
+		if value.Number == number {
+			return value
+		}
+	}
+	return nil
+}
+
+// ValueByName returns an enum value by name
+func (e *Enum) ValueByName(name string) *EnumValue {
+	for _, value := range e.Values {
+		if value.Name == name {
+			return value
+		}
+	}
+	return nil
+}
+
+// Doc returns the text of the comments directly preceding a declaration, without comment
+// markers; comments separated from the declaration by a blank line are not included
+func (n *Node) Doc() string {
+	comments := n.LeadingComments
+	for i := len(comments) - 1; i >= 0; i-- {
+		if comments[i] == "" {
+			comments = comments[i+1:]
+			break
+		}
+	}
+
+	var lines []string
+	for _, comment := range comments {
+		if strings.HasPrefix(comment, "/*") {
+			comment = strings.TrimSuffix(strings.TrimPrefix(comment, "/*"), "*/")
+			for _, line := range strings.Split(comment, "\n") {
+				line = strings.TrimSpace(line)
+				line = strings.TrimSpace(strings.TrimPrefix(line, "*"))
+				if line != "" {
+					lines = append(lines, line)
+				}
+			}
+			continue
+		}
+		lines = append(lines, strings.TrimSpace(strings.TrimLeft(comment, "/")))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,1161 @@
+package protobuf
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aywengo/ksr-cli/internal/jsonfmt"
+)
+
+// Wire types of the Protobuf binary format
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireStart   = 3
+	wireEnd     = 4
+	wireFixed32 = 5
+)
+
+// MessageByIndexes finds a message by its indexes in a file: the first index selects a
+// top-level message and each following index a message nested in the previous one
+func MessageByIndexes(f *File, indexes []int) (*Message, error) {
+	messages := f.Messages
+	var m *Message
+	for _, index := range indexes {
+		if index < 0 || index >= len(messages) {
+			return nil, fmt.Errorf("message index %v not found in schema", indexes)
+		}
+		m = messages[index]
+		messages = m.Messages
+	}
+	if m == nil {
+		return nil, fmt.Errorf("no message indexes given")
+	}
+	return m, nil
+}
+
+// MessageIndexes returns the indexes of a message in a file, found by its full name or,
+// when that is unique, by a trailing part of it such as "Line" or "Order.Line"
+func MessageIndexes(f *File, name string) ([]int, error) {
+	var byFullName []int
+	var byName [][]int
+	name = strings.TrimPrefix(name, ".")
+	var walk func(messages []*Message, path []int)
+	walk = func(messages []*Message, path []int) {
+		for i, m := range messages {
+			indexes := append(append([]int{}, path...), i)
+			if m.FullName == name {
+				byFullName = indexes
+			} else if strings.HasSuffix(m.FullName, "."+name) {
+				byName = append(byName, indexes)
+			}
+			walk(m.Messages, indexes)
+		}
+	}
+	walk(f.Messages, nil)
+
+	switch {
+	case byFullName != nil:
+		return byFullName, nil
+	case len(byName) == 1:
+		return byName[0], nil
+	case len(byName) > 1:
+		return nil, fmt.Errorf("message name %s is ambiguous; use its full name", name)
+	default:
+		return nil, fmt.Errorf("message %s not found in schema", name)
+	}
+}
+
+// DecodeBinary decodes a message in the Protobuf binary format into its JSON mapping:
+// fields use their JSON names in declaration order, 64-bit integers are strings, bytes
+// are base64, enums are value names and well-known types use their special forms.
+// Unknown fields are skipped.
+func DecodeBinary(m *Message, registry *Registry, data []byte) (interface{}, error) {
+	value, err := decodeMessage(m, registry, data)
+	if err != nil {
+		return nil, err
+	}
+	return value.toJSON(registry), nil
+}
+
+// messageValue is a decoded message holding the native values of the fields it sets:
+// int64, uint64, float64, bool, string, []byte, enumValue and *messageValue, in a
+// []interface{} for repeated fields and a *mapValue for map fields
+type messageValue struct {
+	message *Message
+	fields  map[*Field]interface{}
+}
+
+type enumValue struct {
+	enum   *Enum
+	number int32
+}
+
+type mapValue struct {
+	keys   []interface{}
+	values []interface{}
+}
+
+// set stores a map entry; a repeated key replaces the earlier value
+func (mv *mapValue) set(key, value interface{}) {
+	for i, existing := range mv.keys {
+		if existing == key {
+			mv.values[i] = value
+			return
+		}
+	}
+	mv.keys = append(mv.keys, key)
+	mv.values = append(mv.values, value)
+}
+
+func decodeMessage(m *Message, registry *Registry, data []byte) (*messageValue, error) {
+	byNumber := make(map[int]*Field, len(m.Fields))
+	for _, field := range m.Fields {
+		byNumber[field.Number] = field
+	}
+
+	value := &messageValue{message: m, fields: map[*Field]interface{}{}}
+	r := &wireReader{data: data}
+	for !r.done() {
+		tag, err := r.varint()
+		if err != nil {
+			return nil, err
+		}
+		number, wireType := int(tag>>3), int(tag&7)
+
+		field, ok := byNumber[number]
+		if !ok {
+			if err := r.skip(wireType); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err := value.decodeField(field, wireType, r, registry); err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+	}
+	return value, nil
+}
+
+func (mv *messageValue) decodeField(field *Field, wireType int, r *wireReader, registry *Registry) error {
+	switch {
+	case field.IsMap:
+		if wireType != wireBytes {
+			return fmt.Errorf("unexpected wire type %d for a map entry", wireType)
+		}
+		data, err := r.bytes()
+		if err != nil {
+			return err
+		}
+		key, value, err := decodeMapEntry(field, data, registry)
+		if err != nil {
+			return err
+		}
+		entries, _ := mv.fields[field].(*mapValue)
+		if entries == nil {
+			entries = &mapValue{}
+			mv.fields[field] = entries
+		}
+		entries.set(key, value)
+	case field.Label == "repeated":
+		items, _ := mv.fields[field].([]interface{})
+		if wireType == wireBytes && isPackable(field, registry) {
+			data, err := r.bytes()
+			if err != nil {
+				return err
+			}
+			packed := &wireReader{data: data}
+			for !packed.done() {
+				item, err := decodeSingle(field, scalarWireType(field.Type), packed, registry)
+				if err != nil {
+					return err
+				}
+				items = append(items, item)
+			}
+		} else {
+			item, err := decodeSingle(field, wireType, r, registry)
+			if err != nil {
+				return err
+			}
+			items = append(items, item)
+		}
+		mv.fields[field] = items
+	default:
+		value, err := decodeSingle(field, wireType, r, registry)
+		if err != nil {
+			return err
+		}
+		// The last value wins; a oneof keeps only its last member
+		if field.Oneof != nil {
+			for _, member := range field.Oneof.Fields {
+				delete(mv.fields, member)
+			}
+		}
+		mv.fields[field] = value
+	}
+	return nil
+}
+
+// decodeMapEntry decodes a map entry message with the key as field 1 and the value as field 2
+func decodeMapEntry(field *Field, data []byte, registry *Registry) (interface{}, interface{}, error) {
+	keyField := &Field{Type: field.KeyType, Message: field.Message}
+	valueField := &Field{Type: field.Type, Message: field.Message}
+
+	var key, value interface{}
+	r := &wireReader{data: data}
+	for !r.done() {
+		tag, err := r.varint()
+		if err != nil {
+			return nil, nil, err
+		}
+		switch number, wireType := int(tag>>3), int(tag&7); number {
+		case 1:
+			key, err = decodeSingle(keyField, wireType, r, registry)
+		case 2:
+			value, err = decodeSingle(valueField, wireType, r, registry)
+		default:
+			err = r.skip(wireType)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Missing keys and values take their default values
+	if key == nil {
+		key = defaultScalar(field.KeyType)
+	}
+	if value == nil {
+		if IsScalar(field.Type) {
+			value = defaultScalar(field.Type)
+		} else if message, enum, err := registry.ResolveField(valueField); err != nil {
+			return nil, nil, err
+		} else if enum != nil {
+			value = enumValue{enum: enum}
+		} else {
+			value = &messageValue{message: message, fields: map[*Field]interface{}{}}
+		}
+	}
+	return key, value, nil
+}
+
+// decodeSingle decodes one value of a field's type
+func decodeSingle(field *Field, wireType int, r *wireReader, registry *Registry) (interface{}, error) {
+	if IsScalar(field.Type) {
+		return decodeScalar(field.Type, wireType, r)
+	}
+
+	message, enum, err := registry.ResolveField(field)
+	if err != nil {
+		return nil, err
+	}
+	if enum != nil {
+		if wireType != wireVarint {
+			return nil, fmt.Errorf("unexpected wire type %d for enum %s", wireType, enum.FullName)
+		}
+		n, err := r.varint()
+		if err != nil {
+			return nil, err
+		}
+		return enumValue{enum: enum, number: int32(n)}, nil
+	}
+
+	if wireType != wireBytes {
+		return nil, fmt.Errorf("unexpected wire type %d for message %s", wireType, message.FullName)
+	}
+	data, err := r.bytes()
+	if err != nil {
+		return nil, err
+	}
+	return decodeMessage(message, registry, data)
+}
+
+func decodeScalar(scalarType string, wireType int, r *wireReader) (interface{}, error) {
+	if expected := scalarWireType(scalarType); wireType != expected {
+		return nil, fmt.Errorf("unexpected wire type %d for %s", wireType, scalarType)
+	}
+
+	switch scalarType {
+	case "int32":
+		n, err := r.varint()
+		return int64(int32(n)), err
+	case "int64":
+		n, err := r.varint()
+		return int64(n), err
+	case "uint32":
+		n, err := r.varint()
+		return uint64(uint32(n)), err
+	case "uint64":
+		return r.varint()
+	case "sint32", "sint64":
+		n, err := r.varint()
+		return int64(n>>1) ^ -int64(n&1), err
+	case "bool":
+		n, err := r.varint()
+		return n != 0, err
+	case "fixed32":
+		n, err := r.fixed32()
+		return uint64(n), err
+	case "sfixed32":
+		n, err := r.fixed32()
+		return int64(int32(n)), err
+	case "float":
+		n, err := r.fixed32()
+		return float64(math.Float32frombits(n)), err
+	case "fixed64":
+		return r.fixed64()
+	case "sfixed64":
+		n, err := r.fixed64()
+		return int64(n), err
+	case "double":
+		n, err := r.fixed64()
+		return math.Float64frombits(n), err
+	case "string":
+		b, err := r.bytes()
+		return string(b), err
+	default: // bytes
+		b, err := r.bytes()
+		return append([]byte(nil), b...), err
+	}
+}
+
+// scalarWireType is the wire type of a scalar type; enums use varints like the integers
+func scalarWireType(scalarType string) int {
+	switch scalarType {
+	case "fixed64", "sfixed64", "double":
+		return wireFixed64
+	case "fixed32", "sfixed32", "float":
+		return wireFixed32
+	case "string", "bytes":
+		return wireBytes
+	default:
+		return wireVarint
+	}
+}
+
+// isPackable reports whether a repeated field of this type may be packed: numeric
+// scalars, bools and enums
+func isPackable(field *Field, registry *Registry) bool {
+	if IsScalar(field.Type) {
+		return field.Type != "string" && field.Type != "bytes"
+	}
+	_, enum, err := registry.ResolveField(field)
+	return err == nil && enum != nil
+}
+
+func defaultScalar(scalarType string) interface{} {
+	switch scalarType {
+	case "bool":
+		return false
+	case "string":
+		return ""
+	case "bytes":
+		return []byte{}
+	case "double", "float":
+		return float64(0)
+	case "uint32", "uint64", "fixed32", "fixed64":
+		return uint64(0)
+	default:
+		return int64(0)
+	}
+}
+
+// toJSON renders a decoded message in the JSON mapping
+func (mv *messageValue) toJSON(registry *Registry) interface{} {
+	if IsWellKnownType(mv.message.FullName) {
+		return mv.wellKnownJSON(registry)
+	}
+
+	object := &jsonfmt.Object{Members: []jsonfmt.Member{}}
+	for _, field := range mv.message.Fields {
+		value, ok := mv.fields[field]
+		if !ok {
+			continue
+		}
+		object.Members = append(object.Members, jsonfmt.Member{Key: field.JSONName, Value: fieldJSON(field, value, registry)})
+	}
+	return object
+}
+
+func fieldJSON(field *Field, value interface{}, registry *Registry) interface{} {
+	switch v := value.(type) {
+	case *mapValue:
+		entries := &jsonfmt.Object{Members: []jsonfmt.Member{}}
+		for i, key := range v.keys {
+			entries.Members = append(entries.Members, jsonfmt.Member{
+				Key:   fmt.Sprint(key),
+				Value: singleJSON(field.Type, v.values[i], registry),
+			})
+		}
+		return entries
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = singleJSON(field.Type, item, registry)
+		}
+		return items
+	default:
+		return singleJSON(field.Type, value, registry)
+	}
+}
+
+// singleJSON renders one native value of a type
+func singleJSON(typeName string, value interface{}, registry *Registry) interface{} {
+	switch v := value.(type) {
+	case *messageValue:
+		return v.toJSON(registry)
+	case enumValue:
+		for _, enumValue := range v.enum.Values {
+			if int32(enumValue.Number) == v.number {
+				return enumValue.Name
+			}
+		}
+		return v.number
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	case float64:
+		return jsonfmt.Float(v)
+	case int64:
+		if strings.HasSuffix(typeName, "64") {
+			return strconv.FormatInt(v, 10)
+		}
+		return v
+	case uint64:
+		if strings.HasSuffix(typeName, "64") {
+			return strconv.FormatUint(v, 10)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// wellKnownJSON renders the special JSON forms of the google.protobuf types
+func (mv *messageValue) wellKnownJSON(registry *Registry) interface{} {
+	get := func(name string) interface{} {
+		for _, field := range mv.message.Fields {
+			if field.Name == name {
+				if value, ok := mv.fields[field]; ok {
+					return value
+				}
+				if IsScalar(field.Type) && field.Label != "repeated" {
+					return defaultScalar(field.Type)
+				}
+			}
+		}
+		return nil
+	}
+	integer := func(name string) int64 {
+		n, _ := get(name).(int64)
+		return n
+	}
+
+	switch mv.message.FullName {
+	case "google.protobuf.Timestamp":
+		return time.Unix(integer("seconds"), integer("nanos")).UTC().Format(time.RFC3339Nano)
+	case "google.protobuf.Duration":
+		return formatDuration(integer("seconds"), integer("nanos"))
+	case "google.protobuf.FieldMask":
+		paths, _ := get("paths").([]interface{})
+		names := make([]string, len(paths))
+		for i, path := range paths {
+			segments := strings.Split(path.(string), ".")
+			for j, segment := range segments {
+				segments[j] = jsonName(segment)
+			}
+			names[i] = strings.Join(segments, ".")
+		}
+		return strings.Join(names, ",")
+	case "google.protobuf.Struct":
+		object := &jsonfmt.Object{Members: []jsonfmt.Member{}}
+		if fields, ok := get("fields").(*mapValue); ok {
+			for i, key := range fields.keys {
+				object.Members = append(object.Members, jsonfmt.Member{Key: key.(string), Value: singleJSON("", fields.values[i], registry)})
+			}
+		}
+		return object
+	case "google.protobuf.ListValue":
+		values, _ := get("values").([]interface{})
+		items := make([]interface{}, len(values))
+		for i, value := range values {
+			items[i] = singleJSON("", value, registry)
+		}
+		return items
+	case "google.protobuf.Value":
+		for _, field := range mv.message.Fields {
+			if value, ok := mv.fields[field]; ok {
+				if field.Name == "null_value" {
+					return nil
+				}
+				return singleJSON(field.Type, value, registry)
+			}
+		}
+		return nil
+	case "google.protobuf.Any":
+		typeURL, _ := get("type_url").(string)
+		data, _ := get("value").([]byte)
+		return anyJSON(typeURL, data, registry)
+	case "google.protobuf.Empty":
+		return &jsonfmt.Object{Members: []jsonfmt.Member{}}
+	default:
+		// Wrapper types are their value
+		if len(mv.message.Fields) == 0 {
+			return nil
+		}
+		field := mv.message.Fields[0]
+		return singleJSON(field.Type, get(field.Name), registry)
+	}
+}
+
+// anyJSON renders an Any with the fields of its embedded message when the type is known
+// to the registry, and the raw value in base64 otherwise
+func anyJSON(typeURL string, data []byte, registry *Registry) interface{} {
+	object := &jsonfmt.Object{Members: []jsonfmt.Member{{Key: "@type", Value: typeURL}}}
+
+	m, ok := registry.Message(typeURL[strings.LastIndex(typeURL, "/")+1:])
+	if ok {
+		if embedded, err := decodeMessage(m, registry, data); err == nil {
+			value := embedded.toJSON(registry)
+			if fields, isObject := value.(*jsonfmt.Object); isObject && !IsWellKnownType(m.FullName) {
+				object.Members = append(object.Members, fields.Members...)
+			} else {
+				object.Set("value", value)
+			}
+			return object
+		}
+	}
+	object.Set("value", base64.StdEncoding.EncodeToString(data))
+	return object
+}
+
+// formatDuration writes a duration as seconds with 0, 3, 6 or 9 fractional digits
+func formatDuration(seconds, nanos int64) string {
+	sign := ""
+	if seconds < 0 || nanos < 0 {
+		sign = "-"
+	}
+	if seconds < 0 {
+		seconds = -seconds
+	}
+	if nanos < 0 {
+		nanos = -nanos
+	}
+	if nanos == 0 {
+		return fmt.Sprintf("%s%ds", sign, seconds)
+	}
+
+	fraction := fmt.Sprintf("%09d", nanos)
+	for len(fraction) > 3 && strings.HasSuffix(fraction, "000") {
+		fraction = fraction[:len(fraction)-3]
+	}
+	return fmt.Sprintf("%s%d.%ss", sign, seconds, fraction)
+}
+
+// wireReader reads values of the binary format
+type wireReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *wireReader) done() bool {
+	return r.pos >= len(r.data)
+}
+
+func (r *wireReader) varint() (uint64, error) {
+	n, size := binary.Uvarint(r.data[r.pos:])
+	if size <= 0 {
+		return 0, fmt.Errorf("invalid varint at byte %d", r.pos)
+	}
+	r.pos += size
+	return n, nil
+}
+
+func (r *wireReader) fixed32() (uint32, error) {
+	if r.pos+4 > len(r.data) {
+		return 0, fmt.Errorf("unexpected end of data at byte %d", r.pos)
+	}
+	n := binary.LittleEndian.Uint32(r.data[r.pos:])
+	r.pos += 4
+	return n, nil
+}
+
+func (r *wireReader) fixed64() (uint64, error) {
+	if r.pos+8 > len(r.data) {
+		return 0, fmt.Errorf("unexpected end of data at byte %d", r.pos)
+	}
+	n := binary.LittleEndian.Uint64(r.data[r.pos:])
+	r.pos += 8
+	return n, nil
+}
+
+func (r *wireReader) bytes() ([]byte, error) {
+	length, err := r.varint()
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(len(r.data)-r.pos) {
+		return nil, fmt.Errorf("invalid length %d at byte %d", length, r.pos)
+	}
+	b := r.data[r.pos : r.pos+int(length)]
+	r.pos += int(length)
+	return b, nil
+}
+
+// skip skips a field of an unknown number
+func (r *wireReader) skip(wireType int) error {
+	var err error
+	switch wireType {
+	case wireVarint:
+		_, err = r.varint()
+	case wireFixed64:
+		_, err = r.fixed64()
+	case wireBytes:
+		_, err = r.bytes()
+	case wireFixed32:
+		_, err = r.fixed32()
+	case wireStart:
+		// Groups end with an end tag
+		for err == nil {
+			var tag uint64
+			if tag, err = r.varint(); err == nil {
+				if int(tag&7) == wireEnd {
+					return nil
+				}
+				err = r.skip(int(tag & 7))
+			}
+		}
+	default:
+		err = fmt.Errorf("unexpected wire type %d at byte %d", wireType, r.pos)
+	}
+	return err
+}
+
+// EncodeBinary encodes a message given in the Protobuf JSON mapping, as decoded by
+// encoding/json with UseNumber, in the binary format. Fields may use their JSON or
+// original names; null fields are left out. Repeated numeric fields are packed.
+func EncodeBinary(m *Message, registry *Registry, value interface{}) ([]byte, error) {
+	return encodeMessage(m, registry, value, "")
+}
+
+func encodeMessage(m *Message, registry *Registry, value interface{}, path string) ([]byte, error) {
+	if IsWellKnownType(m.FullName) {
+		return encodeWellKnown(m, registry, value, path)
+	}
+
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, encodeError(path, "expected message %s as an object, got %s", m.FullName, jsonfmt.Kind(value))
+	}
+	return encodeFields(m, registry, object, path)
+}
+
+// encodeFields encodes the fields of a message given as a JSON object
+func encodeFields(m *Message, registry *Registry, object map[string]interface{}, path string) ([]byte, error) {
+	values := map[*Field]interface{}{}
+	setOneofs := map[*Oneof]string{}
+	for key, fieldValue := range object {
+		field := fieldByName(m, key)
+		if field == nil {
+			return nil, encodeError(joinPath(path, key), "field is not defined in message %s", m.FullName)
+		}
+		if _, duplicate := values[field]; duplicate {
+			return nil, encodeError(joinPath(path, key), "field %s is set twice", field.Name)
+		}
+		if fieldValue == nil {
+			continue
+		}
+		if field.Oneof != nil {
+			if other, ok := setOneofs[field.Oneof]; ok {
+				return nil, encodeError(joinPath(path, key), "oneof %s already has %s set", field.Oneof.Name, other)
+			}
+			setOneofs[field.Oneof] = key
+		}
+		values[field] = fieldValue
+	}
+
+	var buf []byte
+	for _, field := range m.Fields {
+		fieldValue, ok := values[field]
+		if !ok {
+			continue
+		}
+		encoded, err := encodeField(field, registry, fieldValue, joinPath(path, field.JSONName))
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, encoded...)
+	}
+	for _, field := range m.Fields {
+		if _, ok := values[field]; !ok && field.Label == "required" {
+			return nil, encodeError(joinPath(path, field.JSONName), "missing required field")
+		}
+	}
+	return buf, nil
+}
+
+// fieldByName finds a field by its JSON name or its original name
+func fieldByName(m *Message, name string) *Field {
+	for _, field := range m.Fields {
+		if field.JSONName == name || field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// encodeField encodes a field with its tags
+func encodeField(field *Field, registry *Registry, value interface{}, path string) ([]byte, error) {
+	var buf []byte
+	switch {
+	case field.IsMap:
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, encodeError(path, "expected map as an object, got %s", jsonfmt.Kind(value))
+		}
+		keyField := &Field{Type: field.KeyType, Message: field.Message}
+		valueField := &Field{Type: field.Type, Message: field.Message}
+		keys := make([]string, 0, len(entries))
+		for key := range entries {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			entryPath := joinPath(path, key)
+			keyValue, err := mapKeyValue(field.KeyType, key)
+			if err != nil {
+				return nil, encodeError(entryPath, "%v", err)
+			}
+			entry, err := encodeTagged(keyField, 1, registry, keyValue, entryPath)
+			if err != nil {
+				return nil, err
+			}
+			encodedValue, err := encodeTagged(valueField, 2, registry, entries[key], entryPath)
+			if err != nil {
+				return nil, err
+			}
+			entry = append(entry, encodedValue...)
+			buf = appendTag(buf, field.Number, wireBytes)
+			buf = binary.AppendUvarint(buf, uint64(len(entry)))
+			buf = append(buf, entry...)
+		}
+	case field.Label == "repeated":
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, encodeError(path, "expected repeated field as an array, got %s", jsonfmt.Kind(value))
+		}
+		if isPackable(field, registry) {
+			var packed []byte
+			for i, item := range items {
+				encoded, _, err := encodeValue(field, registry, item, fmt.Sprintf("%s[%d]", path, i))
+				if err != nil {
+					return nil, err
+				}
+				packed = append(packed, encoded...)
+			}
+			if len(items) > 0 {
+				buf = appendTag(buf, field.Number, wireBytes)
+				buf = binary.AppendUvarint(buf, uint64(len(packed)))
+				buf = append(buf, packed...)
+			}
+			return buf, nil
+		}
+		for i, item := range items {
+			encoded, err := encodeTagged(field, field.Number, registry, item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			buf = append(buf, encoded...)
+		}
+	default:
+		return encodeTagged(field, field.Number, registry, value, path)
+	}
+	return buf, nil
+}
+
+// encodeTagged encodes one value of a field's type with a tag for the given number
+func encodeTagged(field *Field, number int, registry *Registry, value interface{}, path string) ([]byte, error) {
+	encoded, wireType, err := encodeValue(field, registry, value, path)
+	if err != nil {
+		return nil, err
+	}
+	buf := appendTag(nil, number, wireType)
+	if wireType == wireBytes {
+		buf = binary.AppendUvarint(buf, uint64(len(encoded)))
+	}
+	return append(buf, encoded...), nil
+}
+
+// encodeValue encodes one value of a field's type without a tag or length, returning its wire type
+func encodeValue(field *Field, registry *Registry, value interface{}, path string) ([]byte, int, error) {
+	if IsScalar(field.Type) {
+		encoded, err := encodeScalar(field.Type, value)
+		if err != nil {
+			return nil, 0, encodeError(path, "%v", err)
+		}
+		return encoded, scalarWireType(field.Type), nil
+	}
+
+	message, enum, err := registry.ResolveField(field)
+	if err != nil {
+		return nil, 0, encodeError(path, "%v", err)
+	}
+	if enum != nil {
+		number, err := enumNumber(enum, value)
+		if err != nil {
+			return nil, 0, encodeError(path, "%v", err)
+		}
+		return binary.AppendUvarint(nil, uint64(int64(number))), wireVarint, nil
+	}
+	encoded, err := encodeMessage(message, registry, value, path)
+	return encoded, wireBytes, err
+}
+
+func enumNumber(enum *Enum, value interface{}) (int32, error) {
+	switch v := value.(type) {
+	case string:
+		for _, enumValue := range enum.Values {
+			if enumValue.Name == v {
+				return int32(enumValue.Number), nil
+			}
+		}
+		return 0, fmt.Errorf("%q is not a value of enum %s", v, enum.FullName)
+	case json.Number:
+		n, err := strconv.ParseInt(v.String(), 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("%s is not a valid enum number", v)
+		}
+		return int32(n), nil
+	default:
+		return 0, fmt.Errorf("expected enum %s as a name or number, got %s", enum.FullName, jsonfmt.Kind(value))
+	}
+}
+
+// encodeScalar encodes a scalar from its JSON form, where integers may be numbers or strings
+func encodeScalar(scalarType string, value interface{}) ([]byte, error) {
+	switch scalarType {
+	case "bool":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected bool, got %s", jsonfmt.Kind(value))
+		}
+		if b {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string, got %s", jsonfmt.Kind(value))
+		}
+		return []byte(s), nil
+	case "bytes":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected bytes as a base64 string, got %s", jsonfmt.Kind(value))
+		}
+		for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+			if b, err := encoding.DecodeString(s); err == nil {
+				return b, nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not valid base64", s)
+	case "double", "float":
+		f, err := jsonFloatValue(value)
+		if err != nil {
+			return nil, err
+		}
+		if scalarType == "float" {
+			return binary.LittleEndian.AppendUint32(nil, math.Float32bits(float32(f))), nil
+		}
+		return binary.LittleEndian.AppendUint64(nil, math.Float64bits(f)), nil
+	}
+
+	text, err := integerText(value)
+	if err != nil {
+		return nil, err
+	}
+	bits := 64
+	if strings.HasSuffix(scalarType, "32") {
+		bits = 32
+	}
+
+	if strings.HasPrefix(scalarType, "uint") || strings.HasPrefix(scalarType, "fixed") {
+		n, err := strconv.ParseUint(text, 10, bits)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid %s", text, scalarType)
+		}
+		switch scalarType {
+		case "fixed32":
+			return binary.LittleEndian.AppendUint32(nil, uint32(n)), nil
+		case "fixed64":
+			return binary.LittleEndian.AppendUint64(nil, n), nil
+		default:
+			return binary.AppendUvarint(nil, n), nil
+		}
+	}
+
+	n, err := strconv.ParseInt(text, 10, bits)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a valid %s", text, scalarType)
+	}
+	switch scalarType {
+	case "sint32", "sint64":
+		return binary.AppendVarint(nil, n), nil
+	case "sfixed32":
+		return binary.LittleEndian.AppendUint32(nil, uint32(int32(n))), nil
+	case "sfixed64":
+		return binary.LittleEndian.AppendUint64(nil, uint64(n)), nil
+	default:
+		// Negative int32 and int64 values are sign-extended to ten bytes
+		return binary.AppendUvarint(nil, uint64(n)), nil
+	}
+}
+
+// integerText returns an integer given as a JSON number or string in decimal, accepting
+// integral values written with a fraction or exponent
+func integerText(value interface{}) (string, error) {
+	var text string
+	switch v := value.(type) {
+	case json.Number:
+		text = v.String()
+	case string:
+		text = v
+	default:
+		return "", fmt.Errorf("expected an integer, got %s", jsonfmt.Kind(value))
+	}
+	if strings.ContainsAny(text, ".eE") {
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil || f != math.Trunc(f) || math.Abs(f) >= 1<<63 {
+			return "", fmt.Errorf("%s is not an integer", text)
+		}
+		return strconv.FormatFloat(f, 'f', 0, 64), nil
+	}
+	return text, nil
+}
+
+// jsonFloatValue reads a float from a JSON number or a string, including NaN and infinities
+func jsonFloatValue(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return v.Float64()
+	case string:
+		switch v {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", v)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("expected a number, got %s", jsonfmt.Kind(value))
+	}
+}
+
+// mapKeyValue converts a map key, which the JSON mapping writes as a string, to its JSON value
+func mapKeyValue(keyType, key string) (interface{}, error) {
+	switch keyType {
+	case "string":
+		return key, nil
+	case "bool":
+		switch key {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("%q is not a valid bool map key", key)
+	default:
+		return key, nil
+	}
+}
+
+// encodeWellKnown encodes the special JSON forms of the google.protobuf types
+func encodeWellKnown(m *Message, registry *Registry, value interface{}, path string) ([]byte, error) {
+	str, isString := value.(string)
+
+	switch m.FullName {
+	case "google.protobuf.Timestamp":
+		t, err := time.Parse(time.RFC3339Nano, str)
+		if !isString || err != nil {
+			return nil, encodeError(path, "expected %s as an RFC 3339 string", m.FullName)
+		}
+		return encodeSecondsNanos(t.Unix(), int64(t.Nanosecond())), nil
+	case "google.protobuf.Duration":
+		seconds, nanos, err := parseDuration(str)
+		if !isString || err != nil {
+			return nil, encodeError(path, "expected %s as a string of seconds such as \"1.5s\"", m.FullName)
+		}
+		return encodeSecondsNanos(seconds, nanos), nil
+	case "google.protobuf.FieldMask":
+		if !isString {
+			return nil, encodeError(path, "expected %s as a comma-separated string", m.FullName)
+		}
+		var buf []byte
+		for _, mask := range strings.Split(str, ",") {
+			if mask == "" {
+				continue
+			}
+			segments := strings.Split(mask, ".")
+			for i, segment := range segments {
+				segments[i] = snakeName(segment)
+			}
+			buf = appendBytesField(buf, 1, []byte(strings.Join(segments, ".")))
+		}
+		return buf, nil
+	case "google.protobuf.Struct":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, encodeError(path, "expected %s as an object, got %s", m.FullName, jsonfmt.Kind(value))
+		}
+		return encodeFields(m, registry, map[string]interface{}{"fields": object}, path)
+	case "google.protobuf.ListValue":
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, encodeError(path, "expected %s as an array, got %s", m.FullName, jsonfmt.Kind(value))
+		}
+		return encodeFields(m, registry, map[string]interface{}{"values": items}, path)
+	case "google.protobuf.Value":
+		kind := map[string]interface{}{}
+		switch v := value.(type) {
+		case nil:
+			kind["null_value"] = "NULL_VALUE"
+		case json.Number:
+			kind["number_value"] = v
+		case string:
+			kind["string_value"] = v
+		case bool:
+			kind["bool_value"] = v
+		case map[string]interface{}:
+			kind["struct_value"] = v
+		case []interface{}:
+			kind["list_value"] = v
+		default:
+			return nil, encodeError(path, "unsupported value %s", jsonfmt.Kind(value))
+		}
+		return encodeFields(m, registry, kind, path)
+	case "google.protobuf.Any":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, encodeError(path, "expected %s as an object, got %s", m.FullName, jsonfmt.Kind(value))
+		}
+		typeURL, _ := object["@type"].(string)
+		embedded, ok := registry.Message(typeURL[strings.LastIndex(typeURL, "/")+1:])
+		if !ok {
+			return nil, encodeError(path, "cannot encode %s of unknown type %q", m.FullName, typeURL)
+		}
+		var inner interface{} = object["value"]
+		if !IsWellKnownType(embedded.FullName) {
+			fields := make(map[string]interface{}, len(object))
+			for key, fieldValue := range object {
+				if key != "@type" {
+					fields[key] = fieldValue
+				}
+			}
+			inner = fields
+		}
+		encoded, err := encodeMessage(embedded, registry, inner, path)
+		if err != nil {
+			return nil, err
+		}
+		return appendBytesField(appendBytesField(nil, 1, []byte(typeURL)), 2, encoded), nil
+	case "google.protobuf.Empty":
+		if object, ok := value.(map[string]interface{}); !ok || len(object) > 0 {
+			return nil, encodeError(path, "expected %s as an empty object", m.FullName)
+		}
+		return nil, nil
+	default:
+		// Wrapper types are their value
+		return encodeFields(m, registry, map[string]interface{}{"value": value}, path)
+	}
+}
+
+func encodeSecondsNanos(seconds, nanos int64) []byte {
+	var buf []byte
+	if seconds != 0 {
+		buf = binary.AppendUvarint(appendTag(buf, 1, wireVarint), uint64(seconds))
+	}
+	if nanos != 0 {
+		buf = binary.AppendUvarint(appendTag(buf, 2, wireVarint), uint64(nanos))
+	}
+	return buf
+}
+
+// parseDuration parses a duration in seconds such as "-1.5s" into seconds and nanos,
+// which share the sign
+func parseDuration(s string) (int64, int64, error) {
+	if !strings.HasSuffix(s, "s") {
+		return 0, 0, fmt.Errorf("missing unit")
+	}
+	s = strings.TrimSuffix(s, "s")
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	seconds, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || len(fraction) > 9 {
+		return 0, 0, fmt.Errorf("invalid duration")
+	}
+	var nanos int64
+	if fraction != "" {
+		if nanos, err = strconv.ParseInt((fraction + "000000000")[:9], 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid duration")
+		}
+	}
+	if negative {
+		return -seconds, -nanos, nil
+	}
+	return seconds, nanos, nil
+}
+
+// snakeName reverses the JSON name of a field: "fooBar" becomes "foo_bar"
+func snakeName(name string) string {
+	var sb strings.Builder
+	for _, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			sb.WriteByte('_')
+			r += 'a' - 'A'
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func appendTag(buf []byte, number, wireType int) []byte {
+	return binary.AppendUvarint(buf, uint64(number)<<3|uint64(wireType))
+}
+
+func appendBytesField(buf []byte, number int, value []byte) []byte {
+	buf = appendTag(buf, number, wireBytes)
+	buf = binary.AppendUvarint(buf, uint64(len(value)))
+	return append(buf, value...)
+}
+
+// encodeError reports a value that does not match its message at a field path
+func encodeError(path, format string, args ...interface{}) error {
+	if path == "" {
+		return fmt.Errorf(format, args...)
+	}
+	return fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...))
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
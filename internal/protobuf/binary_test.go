@@ -0,0 +1,168 @@
+package protobuf
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeBinary(t *testing.T) {
+	f, registry, err := ParseWithReferences(`syntax = "proto3";
+package shop;
+import "google/protobuf/timestamp.proto";
+import "google/protobuf/wrappers.proto";
+message Order {
+  int64 total_cents = 1;
+  oneof payment { string card = 2; string iban = 3; }
+  Status status = 4;
+  google.protobuf.Timestamp created_at = 5;
+  repeated sint32 deltas = 6;
+  map<string, int32> notes = 7;
+  message Line { string sku = 1; }
+  repeated Line lines = 8;
+  bytes checksum = 9;
+  google.protobuf.StringValue comment = 10;
+}
+enum Status { STATUS_UNKNOWN = 0; STATUS_OPEN = 1; }`, nil)
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	data := []byte{
+		0x08, 0xb9, 0x60, // total_cents 12345
+		0x12, 0x01, 'x', 0x1a, 0x01, 'y', // card, then iban replaces it
+		0x20, 0x01, // status
+		0x2a, 0x08, 0x08, 0x80, 0x8b, 0xd2, 0xbb, 0x06, 0x10, 0x01, // created_at
+		0x32, 0x02, 0x02, 0x03, // packed deltas 1, -2
+		0x3a, 0x05, 0x0a, 0x01, 'a', 0x10, 0x03, // notes {"a": 3}
+		0x42, 0x03, 0x0a, 0x01, 'z', // lines
+		0x4a, 0x02, 0x01, 0x02, // checksum
+		0x52, 0x03, 0x0a, 0x01, 'c', // comment
+		0xf8, 0x06, 0x05, // unknown field 111
+	}
+
+	m, err := MessageByIndexes(f, []int{0})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	value, err := DecodeBinary(m, registry, data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	encoded, _ := json.Marshal(value)
+	expected := `{"totalCents":"12345","iban":"y","status":"STATUS_OPEN","createdAt":"2025-01-01T00:00:00.000000001Z",` +
+		`"deltas":[1,-2],"notes":{"a":3},"lines":[{"sku":"z"}],"checksum":"AQI=","comment":"c"}`
+	if string(encoded) != expected {
+		t.Errorf("Expected %s, got %s", expected, encoded)
+	}
+
+	line, err := MessageByIndexes(f, []int{0, 0})
+	if err != nil || line.FullName != "shop.Order.Line" {
+		t.Errorf("Expected the nested message, got %v (%v)", line, err)
+	}
+	if _, err := MessageByIndexes(f, []int{3}); err == nil {
+		t.Error("Expected error for an unknown message index")
+	}
+	if _, err := DecodeBinary(m, registry, []byte{0x12, 0x05, 'x'}); err == nil {
+		t.Error("Expected error for a truncated field")
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[string][2]int64{"1s": {1, 0}, "1.500s": {1, 500000000}, "-0.000001s": {0, -1000}, "3.000000001s": {3, 1}}
+	for expected, value := range tests {
+		if got := formatDuration(value[0], value[1]); got != expected {
+			t.Errorf("Expected %s, got %s", expected, got)
+		}
+	}
+}
+
+func TestEncodeBinary(t *testing.T) {
+	f, registry, err := ParseWithReferences(`syntax = "proto3";
+package shop;
+import "google/protobuf/duration.proto";
+import "google/protobuf/struct.proto";
+message Other { string x = 1; }
+message Order {
+  int64 total_cents = 1;
+  oneof payment { string card = 2; string iban = 3; }
+  Status status = 4;
+  google.protobuf.Duration ttl = 5;
+  repeated sint32 deltas = 6;
+  map<string, int32> notes = 7;
+  message Line { string sku = 1; }
+  repeated Line lines = 8;
+  bytes checksum = 9;
+  google.protobuf.Struct attrs = 10;
+}
+enum Status { STATUS_UNKNOWN = 0; STATUS_OPEN = 1; }`, nil)
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	indexes, err := MessageIndexes(f, "Order")
+	if err != nil || !reflect.DeepEqual(indexes, []int{1}) {
+		t.Fatalf("Expected indexes [1], got %v (%v)", indexes, err)
+	}
+	m, _ := MessageByIndexes(f, indexes)
+
+	var record interface{}
+	decoder := json.NewDecoder(strings.NewReader(`{"total_cents":12345,"iban":"y","status":1,"ttl":"1.5s",` +
+		`"deltas":[1,-2],"notes":{"b":2,"a":1},"lines":[{"sku":"z"}],"checksum":"AQI=","attrs":{"k":[true,null]}}`))
+	decoder.UseNumber()
+	if err := decoder.Decode(&record); err != nil {
+		t.Fatalf("Failed to decode record: %v", err)
+	}
+	data, err := EncodeBinary(m, registry, record)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	value, err := DecodeBinary(m, registry, data)
+	if err != nil {
+		t.Fatalf("Failed to decode the encoded message: %v", err)
+	}
+	encoded, _ := json.Marshal(value)
+	expected := `{"totalCents":"12345","iban":"y","status":"STATUS_OPEN","ttl":"1.500s",` +
+		`"deltas":[1,-2],"notes":{"a":1,"b":2},"lines":[{"sku":"z"}],"checksum":"AQI=","attrs":{"k":[true,null]}}`
+	if string(encoded) != expected {
+		t.Errorf("Expected %s, got %s", expected, encoded)
+	}
+
+	invalid := []string{
+		`{"card":"x","iban":"y"}`,
+		`{"status":"STATUS_CLOSED"}`,
+		`{"totalCents":1.5}`,
+		`{"unknown":1}`,
+	}
+	for _, record := range invalid {
+		var value interface{}
+		_ = json.Unmarshal([]byte(record), &value)
+		if _, err := EncodeBinary(m, registry, value); err == nil {
+			t.Errorf("Expected error for %s", record)
+		}
+	}
+}
+
+func TestMessageIndexes(t *testing.T) {
+	f, _, err := ParseWithReferences(`syntax = "proto3";
+package shop;
+message Order { message Line { string sku = 1; } }
+message Invoice { message Line { string sku = 1; } }`, nil)
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	tests := map[string][]int{"Order": {0}, "shop.Invoice": {1}, ".shop.Order.Line": {0, 0}, "Invoice.Line": {1, 0}}
+	for name, expected := range tests {
+		if indexes, err := MessageIndexes(f, name); err != nil || !reflect.DeepEqual(indexes, expected) {
+			t.Errorf("Expected %v for %s, got %v (%v)", expected, name, indexes, err)
+		}
+	}
+	if _, err := MessageIndexes(f, "Line"); err == nil {
+		t.Error("Expected error for an ambiguous name")
+	}
+	if _, err := MessageIndexes(f, "Payment"); err == nil {
+		t.Error("Expected error for an unknown message")
+	}
+}
@@ -0,0 +1,271 @@
+package protobuf
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const indentUnit = "  "
+
+// Format prints a parsed file in a consistent layout: two-space indentation, one
+// declaration per line, double-quoted strings, and the syntax, package, imports (sorted)
+// and file options ahead of the definitions. Comments and single blank lines between
+// declarations are preserved.
+func Format(f *File) string {
+	pr := &printer{}
+
+	var syntax, pkg, imports, options, defs []Decl
+	for _, decl := range f.Decls {
+		switch decl.(type) {
+		case *SyntaxDecl:
+			syntax = append(syntax, decl)
+		case *PackageDecl:
+			pkg = append(pkg, decl)
+		case *Import:
+			imports = append(imports, decl)
+		case *Option:
+			options = append(options, decl)
+		default:
+			defs = append(defs, decl)
+		}
+	}
+	sort.SliceStable(imports, func(i, j int) bool {
+		return imports[i].(*Import).Path < imports[j].(*Import).Path
+	})
+
+	first := true
+
+	// Comments separated from the first declaration by a blank line, such as a license,
+	// stay at the top of the file when the declarations are reordered
+	if len(f.Decls) > 0 {
+		n := f.Decls[0].node()
+		for i := len(n.LeadingComments) - 1; i >= 0; i-- {
+			if n.LeadingComments[i] == "" {
+				pr.comments(trimBlank(n.LeadingComments[:i]), "")
+				pr.header = n
+				pr.headerSkip = i + 1
+				first = false
+				break
+			}
+		}
+	}
+
+	for _, group := range [][]Decl{syntax, pkg, imports, options} {
+		if len(group) == 0 {
+			continue
+		}
+		if !first {
+			pr.blank()
+		}
+		for _, decl := range group {
+			pr.leadingComments(decl.node(), "")
+			pr.decl(decl, "")
+		}
+		first = false
+	}
+	for _, decl := range defs {
+		if !first {
+			pr.blank()
+		}
+		pr.leadingComments(decl.node(), "")
+		pr.decl(decl, "")
+		first = false
+	}
+	if endComments := trimBlank(f.EndComments); len(endComments) > 0 {
+		if !first {
+			pr.blank()
+		}
+		pr.comments(endComments, "")
+	}
+	return pr.sb.String()
+}
+
+type printer struct {
+	sb strings.Builder
+	// header is the node whose leading comments were printed as the file header
+	header *Node
+	// headerSkip is the number of its leading comments already printed
+	headerSkip int
+}
+
+// trimBlank removes blank line markers around a list of comments
+func trimBlank(comments []string) []string {
+	for len(comments) > 0 && comments[0] == "" {
+		comments = comments[1:]
+	}
+	for len(comments) > 0 && comments[len(comments)-1] == "" {
+		comments = comments[:len(comments)-1]
+	}
+	return comments
+}
+
+func (pr *printer) blank() {
+	pr.sb.WriteByte('\n')
+}
+
+func (pr *printer) line(indent, text string, n *Node) {
+	pr.sb.WriteString(indent)
+	pr.sb.WriteString(text)
+	if n != nil && n.TrailingComment != "" {
+		pr.sb.WriteByte(' ')
+		pr.sb.WriteString(strings.ReplaceAll(n.TrailingComment, "\n", " "))
+	}
+	pr.sb.WriteByte('\n')
+}
+
+func (pr *printer) leadingComments(n *Node, indent string) {
+	if n == pr.header {
+		pr.comments(n.LeadingComments[pr.headerSkip:], indent)
+		return
+	}
+	pr.comments(n.LeadingComments, indent)
+}
+
+// comments prints comment lines; empty entries are blank lines between comment groups
+func (pr *printer) comments(comments []string, indent string) {
+	for _, comment := range comments {
+		if comment == "" {
+			pr.blank()
+			continue
+		}
+		for i, line := range strings.Split(comment, "\n") {
+			line = strings.TrimSpace(line)
+			if i > 0 && strings.HasPrefix(line, "*") {
+				line = " " + line
+			}
+			if line == "" {
+				pr.blank()
+				continue
+			}
+			pr.line(indent, line, nil)
+		}
+	}
+}
+
+// body prints the declarations of a block, keeping single blank lines from the source
+func (pr *printer) body(decls []Decl, endComments []string, indent string) {
+	for i, decl := range decls {
+		n := decl.node()
+		if i > 0 && n.SpaceBefore {
+			pr.blank()
+		}
+		pr.leadingComments(n, indent)
+		pr.decl(decl, indent)
+	}
+	if len(decls) == 0 && len(endComments) > 0 && endComments[0] == "" {
+		endComments = endComments[1:]
+	}
+	pr.comments(endComments, indent)
+}
+
+func (pr *printer) decl(decl Decl, indent string) {
+	inner := indent + indentUnit
+	switch d := decl.(type) {
+	case *SyntaxDecl:
+		pr.line(indent, fmt.Sprintf("%s = %s;", d.Keyword, quote(d.Value)), &d.Node)
+	case *PackageDecl:
+		pr.line(indent, fmt.Sprintf("package %s;", d.Name), &d.Node)
+	case *Import:
+		modifier := ""
+		if d.Modifier != "" {
+			modifier = d.Modifier + " "
+		}
+		pr.line(indent, fmt.Sprintf("import %s%s;", modifier, quote(d.Path)), &d.Node)
+	case *Option:
+		pr.line(indent, fmt.Sprintf("option %s = %s;", d.Name, d.Value), &d.Node)
+	case *Message:
+		pr.line(indent, fmt.Sprintf("message %s {", d.Name), &d.Node)
+		pr.body(d.Decls, d.EndComments, inner)
+		pr.line(indent, "}", nil)
+	case *Enum:
+		pr.line(indent, fmt.Sprintf("enum %s {", d.Name), &d.Node)
+		pr.body(d.Decls, d.EndComments, inner)
+		pr.line(indent, "}", nil)
+	case *EnumValue:
+		pr.line(indent, fmt.Sprintf("%s = %d%s;", d.Name, d.Number, formatOptions(d.Options)), &d.Node)
+	case *Field:
+		pr.line(indent, formatField(d)+";", &d.Node)
+	case *Oneof:
+		pr.line(indent, fmt.Sprintf("oneof %s {", d.Name), &d.Node)
+		pr.body(d.Decls, d.EndComments, inner)
+		pr.line(indent, "}", nil)
+	case *Reserved:
+		var items []string
+		if len(d.Names) > 0 {
+			for _, name := range d.Names {
+				items = append(items, quote(name))
+			}
+		} else {
+			items = formatRanges(d.Ranges)
+		}
+		pr.line(indent, fmt.Sprintf("reserved %s;", strings.Join(items, ", ")), &d.Node)
+	case *Extensions:
+		pr.line(indent, fmt.Sprintf("extensions %s%s;", strings.Join(formatRanges(d.Ranges), ", "), formatOptions(d.Options)), &d.Node)
+	case *Extend:
+		pr.line(indent, fmt.Sprintf("extend %s {", d.Extendee), &d.Node)
+		pr.body(d.Decls, d.EndComments, inner)
+		pr.line(indent, "}", nil)
+	case *Service:
+		pr.line(indent, fmt.Sprintf("service %s {", d.Name), &d.Node)
+		pr.body(d.Decls, d.EndComments, inner)
+		pr.line(indent, "}", nil)
+	case *Method:
+		signature := fmt.Sprintf("rpc %s(%s) returns (%s)", d.Name, streamType(d.InputType, d.InputStream), streamType(d.OutputType, d.OutputStream))
+		if !d.HasBody || (len(d.Decls) == 0 && len(d.EndComments) == 0) {
+			pr.line(indent, signature+";", &d.Node)
+			return
+		}
+		pr.line(indent, signature+" {", &d.Node)
+		pr.body(d.Decls, d.EndComments, inner)
+		pr.line(indent, "}", nil)
+	}
+}
+
+func formatField(f *Field) string {
+	var sb strings.Builder
+	if f.Label != "" {
+		sb.WriteString(f.Label + " ")
+	}
+	if f.IsMap {
+		fmt.Fprintf(&sb, "map<%s, %s>", f.KeyType, f.Type)
+	} else {
+		sb.WriteString(f.Type)
+	}
+	fmt.Fprintf(&sb, " %s = %d%s", f.Name, f.Number, formatOptions(f.Options))
+	return sb.String()
+}
+
+func formatOptions(options []*Option) string {
+	if len(options) == 0 {
+		return ""
+	}
+	items := make([]string, len(options))
+	for i, option := range options {
+		items[i] = option.Name + " = " + option.Value
+	}
+	return " [" + strings.Join(items, ", ") + "]"
+}
+
+func formatRanges(ranges []Range) []string {
+	items := make([]string, len(ranges))
+	for i, r := range ranges {
+		switch {
+		case r.Max:
+			items[i] = strconv.Itoa(r.Start) + " to max"
+		case r.Start == r.End:
+			items[i] = strconv.Itoa(r.Start)
+		default:
+			items[i] = fmt.Sprintf("%d to %d", r.Start, r.End)
+		}
+	}
+	return items
+}
+
+func streamType(typeName string, stream bool) string {
+	if stream {
+		return "stream " + typeName
+	}
+	return typeName
+}
@@ -0,0 +1,74 @@
+package protobuf
+
+import (
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	input := `// Copyright Acme
+
+package acme;
+syntax='proto3';
+import "b.proto";
+import "a.proto";
+option java_package='com.acme';
+/* A user */
+message User{
+    string id=1;   // primary key
+  repeated string tags = 2 [deprecated=true];
+
+  map<string,int32> scores=3;
+  oneof contact { string email = 4; string phone = 5; }
+  reserved 6, 8 to 10;
+  // end of fields
+}
+service Users { rpc Get(User) returns (stream User); }
+`
+
+	expected := `// Copyright Acme
+
+syntax = "proto3";
+
+package acme;
+
+import "a.proto";
+import "b.proto";
+
+option java_package = "com.acme";
+
+/* A user */
+message User {
+  string id = 1; // primary key
+  repeated string tags = 2 [deprecated = true];
+
+  map<string, int32> scores = 3;
+  oneof contact {
+    string email = 4;
+    string phone = 5;
+  }
+  reserved 6, 8 to 10;
+  // end of fields
+}
+
+service Users {
+  rpc Get(User) returns (stream User);
+}
+`
+
+	f, err := Parse(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got := Format(f)
+	if got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	f, err = Parse(got)
+	if err != nil {
+		t.Fatalf("Formatted output does not parse: %v", err)
+	}
+	if again := Format(f); again != got {
+		t.Errorf("Format is not idempotent:\n%s", again)
+	}
+}
@@ -0,0 +1,275 @@
+package protobuf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenInt
+	tokenFloat
+	tokenString
+	tokenSymbol
+	tokenComment
+)
+
+type token struct {
+	kind tokenKind
+	text string // source text; for strings, the decoded value
+	line int
+	col  int
+	// endLine is the last line of the token, which differs from line for block comments
+	endLine int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of file"
+	case tokenString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// lex splits protobuf source into tokens, keeping comments as tokens
+func lex(src string) ([]token, error) {
+	var tokens []token
+	line, col := 1, 1
+	i := 0
+
+	advance := func(n int) {
+		for _, r := range src[i : i+n] {
+			if r == '\n' {
+				line++
+				col = 1
+			} else {
+				col++
+			}
+		}
+		i += n
+	}
+
+	for i < len(src) {
+		c := src[i]
+		startLine, startCol := line, col
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == '\v':
+			advance(1)
+		case strings.HasPrefix(src[i:], "//"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			text := strings.TrimRight(src[i:i+end], " \t\r")
+			advance(end)
+			tokens = append(tokens, token{kind: tokenComment, text: text, line: startLine, col: startCol, endLine: startLine})
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("%d:%d: unterminated block comment", startLine, startCol)
+			}
+			text := src[i : i+end+4]
+			advance(end + 4)
+			tokens = append(tokens, token{kind: tokenComment, text: text, line: startLine, col: startCol, endLine: line})
+		case isLetter(c):
+			j := i
+			for j < len(src) && (isLetter(src[j]) || isDigit(src[j])) {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: src[i:j], line: startLine, col: startCol, endLine: startLine})
+			advance(j - i)
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			j, kind := scanNumber(src, i)
+			tokens = append(tokens, token{kind: kind, text: src[i:j], line: startLine, col: startCol, endLine: startLine})
+			advance(j - i)
+		case c == '"' || c == '\'':
+			value, n, err := scanString(src[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%d:%d: %w", startLine, startCol, err)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: value, line: startLine, col: startCol, endLine: startLine})
+			advance(n)
+		case strings.ContainsRune("=;{}[]()<>,.:-+/", rune(c)):
+			tokens = append(tokens, token{kind: tokenSymbol, text: string(c), line: startLine, col: startCol, endLine: startLine})
+			advance(1)
+		default:
+			r, _ := utf8.DecodeRuneInString(src[i:])
+			return nil, fmt.Errorf("%d:%d: unexpected character %q", startLine, startCol, r)
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, line: line, col: col, endLine: line})
+	return tokens, nil
+}
+
+func isLetter(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// scanNumber returns the end of a numeric literal starting at i and whether it is a float
+func scanNumber(src string, i int) (int, tokenKind) {
+	j := i
+	if strings.HasPrefix(src[i:], "0x") || strings.HasPrefix(src[i:], "0X") {
+		j += 2
+		for j < len(src) && strings.IndexByte("0123456789abcdefABCDEF", src[j]) >= 0 {
+			j++
+		}
+		return j, tokenInt
+	}
+
+	kind := tokenInt
+	for j < len(src) && isDigit(src[j]) {
+		j++
+	}
+	if j < len(src) && src[j] == '.' {
+		kind = tokenFloat
+		j++
+		for j < len(src) && isDigit(src[j]) {
+			j++
+		}
+	}
+	if j < len(src) && (src[j] == 'e' || src[j] == 'E') {
+		k := j + 1
+		if k < len(src) && (src[k] == '+' || src[k] == '-') {
+			k++
+		}
+		if k < len(src) && isDigit(src[k]) {
+			kind = tokenFloat
+			j = k
+			for j < len(src) && isDigit(src[j]) {
+				j++
+			}
+		}
+	}
+	return j, kind
+}
+
+// scanString decodes a quoted string literal and returns its value and source length
+func scanString(src string) (string, int, error) {
+	quote := src[0]
+	var sb strings.Builder
+	i := 1
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == quote:
+			return sb.String(), i + 1, nil
+		case c == '\n':
+			return "", 0, fmt.Errorf("unterminated string literal")
+		case c == '\\':
+			if i+1 >= len(src) {
+				return "", 0, fmt.Errorf("unterminated string literal")
+			}
+			n, err := decodeEscape(src[i:], &sb)
+			if err != nil {
+				return "", 0, err
+			}
+			i += n
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string literal")
+}
+
+// decodeEscape decodes the escape sequence at the start of src and returns its length
+func decodeEscape(src string, sb *strings.Builder) (int, error) {
+	switch e := src[1]; e {
+	case 'a':
+		sb.WriteByte('\a')
+	case 'b':
+		sb.WriteByte('\b')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'n':
+		sb.WriteByte('\n')
+	case 'r':
+		sb.WriteByte('\r')
+	case 't':
+		sb.WriteByte('\t')
+	case 'v':
+		sb.WriteByte('\v')
+	case '\\', '\'', '"', '?':
+		sb.WriteByte(e)
+	case 'x', 'X':
+		j := 2
+		for j < len(src) && j < 4 && strings.IndexByte("0123456789abcdefABCDEF", src[j]) >= 0 {
+			j++
+		}
+		if j == 2 {
+			return 0, fmt.Errorf("invalid hex escape")
+		}
+		value, _ := strconv.ParseUint(src[2:j], 16, 8)
+		sb.WriteByte(byte(value))
+		return j, nil
+	case 'u', 'U':
+		size := 4
+		if e == 'U' {
+			size = 8
+		}
+		if len(src) < 2+size {
+			return 0, fmt.Errorf("invalid unicode escape")
+		}
+		value, err := strconv.ParseUint(src[2:2+size], 16, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid unicode escape")
+		}
+		sb.WriteRune(rune(value))
+		return 2 + size, nil
+	default:
+		if e >= '0' && e <= '7' {
+			j := 1
+			for j < len(src) && j < 4 && src[j] >= '0' && src[j] <= '7' {
+				j++
+			}
+			value, _ := strconv.ParseUint(src[1:j], 8, 8)
+			sb.WriteByte(byte(value))
+			return j, nil
+		}
+		return 0, fmt.Errorf("invalid escape sequence \\%c", e)
+	}
+	return 2, nil
+}
+
+// quote writes a string literal in protobuf syntax with double quotes
+func quote(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				fmt.Fprintf(&sb, `\%03o`, c)
+			} else {
+				sb.WriteByte(c)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
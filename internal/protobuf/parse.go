@@ -0,0 +1,1050 @@
+package protobuf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxFieldNumber     = 536870911
+	reservedRangeStart = 19000
+	reservedRangeEnd   = 19999
+)
+
+var scalarTypes = map[string]bool{
+	"double": true, "float": true, "int32": true, "int64": true, "uint32": true, "uint64": true,
+	"sint32": true, "sint64": true, "fixed32": true, "fixed64": true, "sfixed32": true,
+	"sfixed64": true, "bool": true, "string": true, "bytes": true,
+}
+
+// IsScalar reports whether a type name is a protobuf scalar type
+func IsScalar(typeName string) bool {
+	return scalarTypes[typeName]
+}
+
+// Parse parses the source of a .proto file. Comments are attached to the declarations
+// they precede, or follow on the same line, so the file can be printed back with Format.
+// Type names are not resolved; use a Registry for that.
+func Parse(content string) (*File, error) {
+	tokens, err := lex(content)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, file: &File{}}
+	if err := p.parseFile(); err != nil {
+		return nil, err
+	}
+	if err := validateFile(p.file); err != nil {
+		return nil, err
+	}
+	return p.file, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	file   *File
+
+	// lastLine is the last line of the most recently consumed token
+	lastLine int
+	// pending holds comments not yet attached to a declaration; "" marks a blank line
+	pending        []string
+	pendingGap     bool
+	pendingEndLine int
+}
+
+// skipComments moves past comment tokens, collecting them as pending comments
+func (p *parser) skipComments() {
+	for p.tokens[p.pos].kind == tokenComment {
+		t := p.tokens[p.pos]
+		if len(p.pending) == 0 {
+			p.pendingGap = t.line > p.lastLine+1
+		} else if t.line > p.pendingEndLine+1 {
+			p.pending = append(p.pending, "")
+		}
+		p.pending = append(p.pending, t.text)
+		p.pendingEndLine = t.endLine
+		p.lastLine = t.endLine
+		p.pos++
+	}
+}
+
+func (p *parser) peek() token {
+	p.skipComments()
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	p.skipComments()
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	p.lastLine = t.endLine
+	return t
+}
+
+// begin starts a declaration at the next token, attaching the pending comments to it
+func (p *parser) begin(n *Node) {
+	t := p.peek()
+	n.Line = t.line
+	if len(p.pending) > 0 {
+		n.SpaceBefore = p.pendingGap
+		n.LeadingComments = p.pending
+		if t.line > p.pendingEndLine+1 {
+			n.LeadingComments = append(n.LeadingComments, "")
+		}
+	} else {
+		n.SpaceBefore = t.line > p.lastLine+1 && p.lastLine > 0
+	}
+	p.pending = nil
+}
+
+// end finishes a declaration, attaching a comment on the same line as its last token
+func (p *parser) end(n *Node) {
+	if t := p.tokens[p.pos]; t.kind == tokenComment && t.line == p.lastLine {
+		n.TrailingComment = t.text
+		p.lastLine = t.endLine
+		p.pos++
+	}
+}
+
+// endComments returns the pending comments before the end of a body, starting with a
+// blank line marker when they are separated from the preceding declaration
+func (p *parser) endComments() []string {
+	p.skipComments()
+	comments := p.pending
+	for len(comments) > 0 && comments[len(comments)-1] == "" {
+		comments = comments[:len(comments)-1]
+	}
+	if len(comments) > 0 && p.pendingGap {
+		comments = append([]string{""}, comments...)
+	}
+	p.pending = nil
+	return comments
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return fmt.Errorf("%d:%d: %s", t.line, t.col, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) expect(text string) (token, error) {
+	t := p.next()
+	if (t.kind != tokenSymbol && t.kind != tokenIdent) || t.text != text {
+		return t, p.errorf(t, "expected %q, found %s", text, t)
+	}
+	return t, nil
+}
+
+func (p *parser) accept(text string) bool {
+	t := p.peek()
+	if (t.kind == tokenSymbol || t.kind == tokenIdent) && t.text == text {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) ident() (string, error) {
+	t := p.next()
+	if t.kind != tokenIdent {
+		return "", p.errorf(t, "expected identifier, found %s", t)
+	}
+	return t.text, nil
+}
+
+// fullIdent parses a dotted name, optionally fully qualified with a leading dot
+func (p *parser) fullIdent() (string, error) {
+	var sb strings.Builder
+	if p.accept(".") {
+		sb.WriteByte('.')
+	}
+	name, err := p.ident()
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString(name)
+	for p.accept(".") {
+		name, err := p.ident()
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte('.')
+		sb.WriteString(name)
+	}
+	return sb.String(), nil
+}
+
+func (p *parser) stringLiteral() (string, error) {
+	t := p.next()
+	if t.kind != tokenString {
+		return "", p.errorf(t, "expected string, found %s", t)
+	}
+	value := t.text
+	// Adjacent string literals are concatenated
+	for p.peek().kind == tokenString {
+		value += p.next().text
+	}
+	return value, nil
+}
+
+func (p *parser) intLiteral() (int, error) {
+	negative := p.accept("-")
+	t := p.next()
+	if t.kind != tokenInt {
+		return 0, p.errorf(t, "expected integer, found %s", t)
+	}
+	value, err := strconv.ParseInt(t.text, 0, 64)
+	if err != nil {
+		return 0, p.errorf(t, "invalid integer %s", t.text)
+	}
+	if negative {
+		value = -value
+	}
+	return int(value), nil
+}
+
+func (p *parser) parseFile() error {
+	for {
+		t := p.peek()
+		if t.kind == tokenEOF {
+			p.file.EndComments = p.endComments()
+			return nil
+		}
+		if p.accept(";") {
+			continue
+		}
+		if t.kind != tokenIdent {
+			return p.errorf(t, "unexpected %s", t)
+		}
+
+		switch t.text {
+		case "syntax", "edition":
+			decl := &SyntaxDecl{Keyword: t.text}
+			p.begin(&decl.Node)
+			p.next()
+			if _, err := p.expect("="); err != nil {
+				return err
+			}
+			value, err := p.stringLiteral()
+			if err != nil {
+				return err
+			}
+			if _, err := p.expect(";"); err != nil {
+				return err
+			}
+			p.end(&decl.Node)
+			decl.Value = value
+			if t.text == "syntax" {
+				if value != "proto2" && value != "proto3" {
+					return p.errorf(t, "unsupported syntax %q", value)
+				}
+				p.file.Syntax = value
+			} else {
+				p.file.Edition = value
+			}
+			p.file.Decls = append(p.file.Decls, decl)
+		case "package":
+			decl := &PackageDecl{}
+			p.begin(&decl.Node)
+			p.next()
+			name, err := p.fullIdent()
+			if err != nil {
+				return err
+			}
+			if _, err := p.expect(";"); err != nil {
+				return err
+			}
+			p.end(&decl.Node)
+			if p.file.Package != "" {
+				return p.errorf(t, "multiple package statements")
+			}
+			decl.Name = name
+			p.file.Package = name
+			p.file.Decls = append(p.file.Decls, decl)
+		case "import":
+			decl := &Import{}
+			p.begin(&decl.Node)
+			p.next()
+			if p.accept("public") {
+				decl.Modifier = "public"
+			} else if p.accept("weak") {
+				decl.Modifier = "weak"
+			}
+			path, err := p.stringLiteral()
+			if err != nil {
+				return err
+			}
+			if _, err := p.expect(";"); err != nil {
+				return err
+			}
+			p.end(&decl.Node)
+			decl.Path = path
+			p.file.Imports = append(p.file.Imports, decl)
+			p.file.Decls = append(p.file.Decls, decl)
+		case "option":
+			option, err := p.parseOptionStatement()
+			if err != nil {
+				return err
+			}
+			p.file.Options = append(p.file.Options, option)
+			p.file.Decls = append(p.file.Decls, option)
+		case "message":
+			message, err := p.parseMessage(nil)
+			if err != nil {
+				return err
+			}
+			p.file.Messages = append(p.file.Messages, message)
+			p.file.Decls = append(p.file.Decls, message)
+		case "enum":
+			enum, err := p.parseEnum(nil)
+			if err != nil {
+				return err
+			}
+			p.file.Enums = append(p.file.Enums, enum)
+			p.file.Decls = append(p.file.Decls, enum)
+		case "service":
+			service, err := p.parseService()
+			if err != nil {
+				return err
+			}
+			p.file.Services = append(p.file.Services, service)
+			p.file.Decls = append(p.file.Decls, service)
+		case "extend":
+			extend, err := p.parseExtend(nil)
+			if err != nil {
+				return err
+			}
+			p.file.Extends = append(p.file.Extends, extend)
+			p.file.Decls = append(p.file.Decls, extend)
+		default:
+			return p.errorf(t, "unexpected %s", t)
+		}
+	}
+}
+
+func (p *parser) fullName(parent *Message, name string) string {
+	switch {
+	case parent != nil:
+		return parent.FullName + "." + name
+	case p.file.Package != "":
+		return p.file.Package + "." + name
+	default:
+		return name
+	}
+}
+
+// parseOptionStatement parses "option name = value;"
+func (p *parser) parseOptionStatement() (*Option, error) {
+	option := &Option{}
+	p.begin(&option.Node)
+	if _, err := p.expect("option"); err != nil {
+		return nil, err
+	}
+	if err := p.parseOptionAssignment(option); err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(";"); err != nil {
+		return nil, err
+	}
+	p.end(&option.Node)
+	return option, nil
+}
+
+// parseOptionAssignment parses "name = value"
+func (p *parser) parseOptionAssignment(option *Option) error {
+	name, err := p.optionName()
+	if err != nil {
+		return err
+	}
+	if _, err := p.expect("="); err != nil {
+		return err
+	}
+	value, err := p.constant()
+	if err != nil {
+		return err
+	}
+	option.Name = name
+	option.Value = value
+	return nil
+}
+
+func (p *parser) optionName() (string, error) {
+	var sb strings.Builder
+	for {
+		if p.accept("(") {
+			name, err := p.fullIdent()
+			if err != nil {
+				return "", err
+			}
+			if _, err := p.expect(")"); err != nil {
+				return "", err
+			}
+			sb.WriteString("(" + name + ")")
+		} else {
+			name, err := p.ident()
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(name)
+		}
+		if !p.accept(".") {
+			return sb.String(), nil
+		}
+		sb.WriteByte('.')
+	}
+}
+
+// constant parses an option value and returns it in protobuf syntax
+func (p *parser) constant() (string, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenString:
+		value, err := p.stringLiteral()
+		if err != nil {
+			return "", err
+		}
+		return quote(value), nil
+	case t.kind == tokenSymbol && t.text == "{":
+		return p.aggregate()
+	case t.kind == tokenSymbol && (t.text == "-" || t.text == "+"):
+		p.next()
+		value := p.next()
+		if value.kind != tokenInt && value.kind != tokenFloat && value.text != "inf" && value.text != "nan" {
+			return "", p.errorf(value, "expected number, found %s", value)
+		}
+		if t.text == "-" {
+			return "-" + value.text, nil
+		}
+		return value.text, nil
+	case t.kind == tokenInt || t.kind == tokenFloat:
+		return p.next().text, nil
+	case t.kind == tokenIdent:
+		return p.fullIdent()
+	default:
+		return "", p.errorf(t, "expected option value, found %s", t)
+	}
+}
+
+// aggregate parses a message literal in text format and returns it on a single line
+func (p *parser) aggregate() (string, error) {
+	var parts []string
+	depth := 0
+	for {
+		t := p.next()
+		switch {
+		case t.kind == tokenEOF:
+			return "", p.errorf(t, "unterminated message literal")
+		case t.kind == tokenString:
+			parts = append(parts, quote(t.text))
+		case t.kind == tokenSymbol && (t.text == "{" || t.text == "<"):
+			depth++
+			parts = append(parts, "{")
+		case t.kind == tokenSymbol && (t.text == "}" || t.text == ">"):
+			depth--
+			parts = append(parts, "}")
+		default:
+			parts = append(parts, t.text)
+		}
+		if depth == 0 {
+			break
+		}
+	}
+
+	var sb strings.Builder
+	for i, part := range parts {
+		if i > 0 {
+			prev := parts[i-1]
+			attach := part == ":" || part == "," || part == "]" || part == "." || prev == "[" || prev == "." || prev == "-"
+			if !attach {
+				sb.WriteByte(' ')
+			}
+		}
+		sb.WriteString(part)
+	}
+	return sb.String(), nil
+}
+
+// fieldOptions parses an optional "[name = value, ...]" list
+func (p *parser) fieldOptions() ([]*Option, error) {
+	if !p.accept("[") {
+		return nil, nil
+	}
+	var options []*Option
+	for {
+		option := &Option{}
+		if err := p.parseOptionAssignment(option); err != nil {
+			return nil, err
+		}
+		options = append(options, option)
+		if p.accept("]") {
+			return options, nil
+		}
+		if _, err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parseMessage(parent *Message) (*Message, error) {
+	message := &Message{Parent: parent}
+	p.begin(&message.Node)
+	if _, err := p.expect("message"); err != nil {
+		return nil, err
+	}
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	message.Name = name
+	message.FullName = p.fullName(parent, name)
+
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	p.end(&message.Node)
+
+	for {
+		t := p.peek()
+		if t.kind == tokenSymbol && t.text == "}" {
+			message.EndComments = p.endComments()
+			p.next()
+			return message, nil
+		}
+		if p.accept(";") {
+			continue
+		}
+		if t.kind != tokenIdent && !(t.kind == tokenSymbol && t.text == ".") {
+			return nil, p.errorf(t, "unexpected %s in message %s", t, message.Name)
+		}
+
+		switch {
+		case t.text == "message":
+			nested, err := p.parseMessage(message)
+			if err != nil {
+				return nil, err
+			}
+			message.Messages = append(message.Messages, nested)
+			message.Decls = append(message.Decls, nested)
+		case t.text == "enum":
+			enum, err := p.parseEnum(message)
+			if err != nil {
+				return nil, err
+			}
+			message.Enums = append(message.Enums, enum)
+			message.Decls = append(message.Decls, enum)
+		case t.text == "extend":
+			extend, err := p.parseExtend(message)
+			if err != nil {
+				return nil, err
+			}
+			message.Extends = append(message.Extends, extend)
+			message.Decls = append(message.Decls, extend)
+		case t.text == "option":
+			option, err := p.parseOptionStatement()
+			if err != nil {
+				return nil, err
+			}
+			message.Options = append(message.Options, option)
+			message.Decls = append(message.Decls, option)
+		case t.text == "oneof":
+			oneof, err := p.parseOneof(message)
+			if err != nil {
+				return nil, err
+			}
+			message.Oneofs = append(message.Oneofs, oneof)
+			message.Decls = append(message.Decls, oneof)
+		case t.text == "reserved":
+			reserved, err := p.parseReserved()
+			if err != nil {
+				return nil, err
+			}
+			message.Reserved = append(message.Reserved, reserved)
+			message.Decls = append(message.Decls, reserved)
+		case t.text == "extensions":
+			extensions, err := p.parseExtensions()
+			if err != nil {
+				return nil, err
+			}
+			message.Extensions = append(message.Extensions, extensions)
+			message.Decls = append(message.Decls, extensions)
+		default:
+			field, err := p.parseField(message, true)
+			if err != nil {
+				return nil, err
+			}
+			message.Fields = append(message.Fields, field)
+			message.Decls = append(message.Decls, field)
+		}
+	}
+}
+
+// parseField parses a field or map field; labels are not allowed inside oneofs
+func (p *parser) parseField(message *Message, allowLabel bool) (*Field, error) {
+	field := &Field{Message: message}
+	p.begin(&field.Node)
+
+	t := p.peek()
+	if allowLabel && t.kind == tokenIdent && (t.text == "optional" || t.text == "repeated" || t.text == "required") {
+		field.Label = p.next().text
+	}
+
+	if t := p.peek(); t.kind == tokenIdent && t.text == "group" {
+		return nil, p.errorf(t, "groups are not supported")
+	}
+
+	if t := p.peek(); t.kind == tokenIdent && t.text == "map" && p.tokens[p.nextTokenIndex()].text == "<" {
+		p.next()
+		p.next()
+		keyType, err := p.fullIdent()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(","); err != nil {
+			return nil, err
+		}
+		valueType, err := p.fullIdent()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(">"); err != nil {
+			return nil, err
+		}
+		if field.Label != "" {
+			return nil, p.errorf(t, "map fields cannot have a label")
+		}
+		field.IsMap = true
+		field.KeyType = keyType
+		field.Type = valueType
+	} else {
+		typeName, err := p.fullIdent()
+		if err != nil {
+			return nil, err
+		}
+		field.Type = typeName
+	}
+
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	field.Name = name
+
+	if _, err := p.expect("="); err != nil {
+		return nil, err
+	}
+	numberToken := p.peek()
+	number, err := p.intLiteral()
+	if err != nil {
+		return nil, err
+	}
+	if number < 1 || number > maxFieldNumber {
+		return nil, p.errorf(numberToken, "field %s has invalid number %d", name, number)
+	}
+	if number >= reservedRangeStart && number <= reservedRangeEnd {
+		return nil, p.errorf(numberToken, "field %s uses number %d, which is reserved for the protobuf implementation", name, number)
+	}
+	field.Number = number
+
+	options, err := p.fieldOptions()
+	if err != nil {
+		return nil, err
+	}
+	field.Options = options
+
+	if _, err := p.expect(";"); err != nil {
+		return nil, err
+	}
+	p.end(&field.Node)
+
+	field.JSONName = jsonName(name)
+	if value, ok := field.Option("json_name"); ok {
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			field.JSONName = unquoted
+		}
+	}
+	return field, nil
+}
+
+// nextTokenIndex returns the index of the token after the current one, skipping comments
+func (p *parser) nextTokenIndex() int {
+	i := p.pos + 1
+	for i < len(p.tokens)-1 && p.tokens[i].kind == tokenComment {
+		i++
+	}
+	return i
+}
+
+func (p *parser) parseOneof(message *Message) (*Oneof, error) {
+	oneof := &Oneof{}
+	p.begin(&oneof.Node)
+	p.next()
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	oneof.Name = name
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	p.end(&oneof.Node)
+
+	for {
+		t := p.peek()
+		if t.kind == tokenSymbol && t.text == "}" {
+			oneof.EndComments = p.endComments()
+			p.next()
+			if len(oneof.Fields) == 0 {
+				return nil, p.errorf(t, "oneof %s has no fields", name)
+			}
+			return oneof, nil
+		}
+		if p.accept(";") {
+			continue
+		}
+		if t.kind == tokenIdent && t.text == "option" {
+			option, err := p.parseOptionStatement()
+			if err != nil {
+				return nil, err
+			}
+			oneof.Options = append(oneof.Options, option)
+			oneof.Decls = append(oneof.Decls, option)
+			continue
+		}
+
+		field, err := p.parseField(message, false)
+		if err != nil {
+			return nil, err
+		}
+		if field.IsMap {
+			return nil, p.errorf(t, "map fields are not allowed in oneofs")
+		}
+		field.Oneof = oneof
+		oneof.Fields = append(oneof.Fields, field)
+		oneof.Decls = append(oneof.Decls, field)
+		message.Fields = append(message.Fields, field)
+	}
+}
+
+func (p *parser) parseRanges() ([]Range, error) {
+	var ranges []Range
+	for {
+		start, err := p.intLiteral()
+		if err != nil {
+			return nil, err
+		}
+		r := Range{Start: start, End: start}
+		if p.accept("to") {
+			if p.accept("max") {
+				r.Max = true
+				r.End = maxFieldNumber
+			} else {
+				end, err := p.intLiteral()
+				if err != nil {
+					return nil, err
+				}
+				r.End = end
+			}
+		}
+		ranges = append(ranges, r)
+		if !p.accept(",") {
+			return ranges, nil
+		}
+	}
+}
+
+func (p *parser) parseReserved() (*Reserved, error) {
+	reserved := &Reserved{}
+	p.begin(&reserved.Node)
+	p.next()
+
+	t := p.peek()
+	if t.kind == tokenString || t.kind == tokenIdent {
+		for {
+			t := p.next()
+			if t.kind != tokenString && t.kind != tokenIdent {
+				return nil, p.errorf(t, "expected reserved name, found %s", t)
+			}
+			reserved.Names = append(reserved.Names, t.text)
+			if !p.accept(",") {
+				break
+			}
+		}
+	} else {
+		ranges, err := p.parseRanges()
+		if err != nil {
+			return nil, err
+		}
+		reserved.Ranges = ranges
+	}
+
+	if _, err := p.expect(";"); err != nil {
+		return nil, err
+	}
+	p.end(&reserved.Node)
+	return reserved, nil
+}
+
+func (p *parser) parseExtensions() (*Extensions, error) {
+	extensions := &Extensions{}
+	p.begin(&extensions.Node)
+	p.next()
+	ranges, err := p.parseRanges()
+	if err != nil {
+		return nil, err
+	}
+	extensions.Ranges = ranges
+	options, err := p.fieldOptions()
+	if err != nil {
+		return nil, err
+	}
+	extensions.Options = options
+	if _, err := p.expect(";"); err != nil {
+		return nil, err
+	}
+	p.end(&extensions.Node)
+	return extensions, nil
+}
+
+func (p *parser) parseEnum(parent *Message) (*Enum, error) {
+	enum := &Enum{Parent: parent}
+	p.begin(&enum.Node)
+	p.next()
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	enum.Name = name
+	enum.FullName = p.fullName(parent, name)
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	p.end(&enum.Node)
+
+	for {
+		t := p.peek()
+		if t.kind == tokenSymbol && t.text == "}" {
+			enum.EndComments = p.endComments()
+			p.next()
+			if len(enum.Values) == 0 {
+				return nil, p.errorf(t, "enum %s has no values", name)
+			}
+			return enum, nil
+		}
+		if p.accept(";") {
+			continue
+		}
+		if t.kind != tokenIdent {
+			return nil, p.errorf(t, "unexpected %s in enum %s", t, name)
+		}
+
+		switch t.text {
+		case "option":
+			option, err := p.parseOptionStatement()
+			if err != nil {
+				return nil, err
+			}
+			enum.Options = append(enum.Options, option)
+			enum.Decls = append(enum.Decls, option)
+		case "reserved":
+			reserved, err := p.parseReserved()
+			if err != nil {
+				return nil, err
+			}
+			enum.Reserved = append(enum.Reserved, reserved)
+			enum.Decls = append(enum.Decls, reserved)
+		default:
+			value := &EnumValue{}
+			p.begin(&value.Node)
+			value.Name = p.next().text
+			if _, err := p.expect("="); err != nil {
+				return nil, err
+			}
+			number, err := p.intLiteral()
+			if err != nil {
+				return nil, err
+			}
+			value.Number = number
+			options, err := p.fieldOptions()
+			if err != nil {
+				return nil, err
+			}
+			value.Options = options
+			if _, err := p.expect(";"); err != nil {
+				return nil, err
+			}
+			p.end(&value.Node)
+			enum.Values = append(enum.Values, value)
+			enum.Decls = append(enum.Decls, value)
+		}
+	}
+}
+
+func (p *parser) parseExtend(parent *Message) (*Extend, error) {
+	extend := &Extend{}
+	p.begin(&extend.Node)
+	p.next()
+	extendee, err := p.fullIdent()
+	if err != nil {
+		return nil, err
+	}
+	extend.Extendee = extendee
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	p.end(&extend.Node)
+
+	for {
+		t := p.peek()
+		if t.kind == tokenSymbol && t.text == "}" {
+			extend.EndComments = p.endComments()
+			p.next()
+			return extend, nil
+		}
+		if p.accept(";") {
+			continue
+		}
+		field, err := p.parseField(parent, true)
+		if err != nil {
+			return nil, err
+		}
+		extend.Fields = append(extend.Fields, field)
+		extend.Decls = append(extend.Decls, field)
+	}
+}
+
+func (p *parser) parseService() (*Service, error) {
+	service := &Service{}
+	p.begin(&service.Node)
+	p.next()
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	service.Name = name
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	p.end(&service.Node)
+
+	for {
+		t := p.peek()
+		if t.kind == tokenSymbol && t.text == "}" {
+			service.EndComments = p.endComments()
+			p.next()
+			return service, nil
+		}
+		if p.accept(";") {
+			continue
+		}
+
+		switch {
+		case t.kind == tokenIdent && t.text == "option":
+			option, err := p.parseOptionStatement()
+			if err != nil {
+				return nil, err
+			}
+			service.Options = append(service.Options, option)
+			service.Decls = append(service.Decls, option)
+		case t.kind == tokenIdent && t.text == "rpc":
+			method, err := p.parseMethod()
+			if err != nil {
+				return nil, err
+			}
+			service.Methods = append(service.Methods, method)
+			service.Decls = append(service.Decls, method)
+		default:
+			return nil, p.errorf(t, "unexpected %s in service %s", t, name)
+		}
+	}
+}
+
+func (p *parser) parseMethod() (*Method, error) {
+	method := &Method{}
+	p.begin(&method.Node)
+	p.next()
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	method.Name = name
+
+	messageType := func() (string, bool, error) {
+		if _, err := p.expect("("); err != nil {
+			return "", false, err
+		}
+		stream := false
+		if t := p.peek(); t.kind == tokenIdent && t.text == "stream" && p.tokens[p.nextTokenIndex()].text != ")" {
+			p.next()
+			stream = true
+		}
+		typeName, err := p.fullIdent()
+		if err != nil {
+			return "", false, err
+		}
+		if _, err := p.expect(")"); err != nil {
+			return "", false, err
+		}
+		return typeName, stream, nil
+	}
+
+	if method.InputType, method.InputStream, err = messageType(); err != nil {
+		return nil, err
+	}
+	if _, err := p.expect("returns"); err != nil {
+		return nil, err
+	}
+	if method.OutputType, method.OutputStream, err = messageType(); err != nil {
+		return nil, err
+	}
+
+	if p.accept(";") {
+		p.end(&method.Node)
+		return method, nil
+	}
+
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	method.HasBody = true
+	p.end(&method.Node)
+	for {
+		t := p.peek()
+		if t.kind == tokenSymbol && t.text == "}" {
+			method.EndComments = p.endComments()
+			p.next()
+			p.accept(";")
+			return method, nil
+		}
+		if p.accept(";") {
+			continue
+		}
+		option, err := p.parseOptionStatement()
+		if err != nil {
+			return nil, err
+		}
+		method.Options = append(method.Options, option)
+		method.Decls = append(method.Decls, option)
+	}
+}
+
+// jsonName converts a field name to its default JSON name, as protoc does
+func jsonName(name string) string {
+	var sb strings.Builder
+	upper := false
+	for _, r := range name {
+		switch {
+		case r == '_':
+			upper = true
+		case upper && r >= 'a' && r <= 'z':
+			sb.WriteRune(r - 'a' + 'A')
+			upper = false
+		default:
+			sb.WriteRune(r)
+			upper = false
+		}
+	}
+	return sb.String()
+}
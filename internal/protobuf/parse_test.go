@@ -0,0 +1,141 @@
+package protobuf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	content := `syntax = "proto3";
+package com.acme;
+
+import "google/protobuf/timestamp.proto";
+
+// An order
+message Order {
+  string id = 1 [json_name = "orderId"];
+  repeated Line lines = 2;
+  map<string, int32> counts = 3;
+  oneof payment {
+    string card = 4;
+    string iban = 5;
+  }
+  optional Status status = 6;
+  google.protobuf.Timestamp created_at = 7;
+
+  message Line {
+    string sku = 1;
+  }
+  reserved 10 to 20;
+}
+
+enum Status {
+  STATUS_UNKNOWN = 0;
+  STATUS_OPEN = 1;
+}`
+
+	f, registry, err := ParseWithReferences(content, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if f.Syntax != "proto3" || f.Package != "com.acme" {
+		t.Errorf("Expected proto3 package com.acme, got %s %s", f.Syntax, f.Package)
+	}
+	if len(f.Messages) != 1 || len(f.Enums) != 1 {
+		t.Fatalf("Expected 1 message and 1 enum, got %d and %d", len(f.Messages), len(f.Enums))
+	}
+
+	order := f.Messages[0]
+	if order.FullName != "com.acme.Order" || order.Doc() != "An order" {
+		t.Errorf("Unexpected message %s with doc %q", order.FullName, order.Doc())
+	}
+	if len(order.Fields) != 7 {
+		t.Fatalf("Expected 7 fields including oneof members, got %d", len(order.Fields))
+	}
+	if order.FieldByName("id").JSONName != "orderId" || order.FieldByName("created_at").JSONName != "createdAt" {
+		t.Errorf("Unexpected JSON names")
+	}
+	if counts := order.FieldByName("counts"); !counts.IsMap || counts.KeyType != "string" || counts.Type != "int32" {
+		t.Errorf("Expected map<string, int32>, got %+v", counts)
+	}
+	if card := order.FieldByName("card"); card.Oneof == nil || card.Oneof.Name != "payment" {
+		t.Errorf("Expected card to belong to oneof payment")
+	}
+
+	line, _, err := registry.ResolveField(order.FieldByName("lines"))
+	if err != nil || line == nil || line.FullName != "com.acme.Order.Line" {
+		t.Errorf("Expected lines to resolve to com.acme.Order.Line, got %v (%v)", line, err)
+	}
+	_, status, err := registry.ResolveField(order.FieldByName("status"))
+	if err != nil || status == nil || status.FullName != "com.acme.Status" {
+		t.Errorf("Expected status to resolve to com.acme.Status, got %v (%v)", status, err)
+	}
+	created, _, err := registry.ResolveField(order.FieldByName("created_at"))
+	if err != nil || created == nil || created.FullName != "google.protobuf.Timestamp" {
+		t.Errorf("Expected well-known Timestamp type, got %v (%v)", created, err)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		contains string
+	}{
+		{
+			name:     "syntax error",
+			content:  `syntax = "proto3"; message A { string id = 1 }`,
+			contains: `expected ";"`,
+		},
+		{
+			name:     "duplicate field number",
+			content:  `syntax = "proto3"; message A { string a = 1; string b = 1; }`,
+			contains: "same number",
+		},
+		{
+			name:     "reserved number",
+			content:  `syntax = "proto3"; message A { reserved 2 to 4; string a = 3; }`,
+			contains: "reserved number",
+		},
+		{
+			name:     "proto3 enum must start at zero",
+			content:  `syntax = "proto3"; enum E { A = 1; }`,
+			contains: "must be zero",
+		},
+		{
+			name:     "proto3 required field",
+			content:  `syntax = "proto3"; message A { required string a = 1; }`,
+			contains: "required fields are not allowed",
+		},
+		{
+			name:     "unterminated string",
+			content:  `syntax = "proto3`,
+			contains: "unterminated string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.content)
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("Expected error containing %q, got %v", tt.contains, err)
+			}
+		})
+	}
+}
+
+func TestParseWithReferences_UnknownType(t *testing.T) {
+	_, _, err := ParseWithReferences(`syntax = "proto3"; message A { Missing m = 1; }`, nil)
+	if err == nil || !strings.Contains(err.Error(), "unknown type Missing") {
+		t.Errorf("Expected unknown type error, got %v", err)
+	}
+
+	reference := `syntax = "proto3"; package common; message Money { int64 units = 1; }`
+	if _, _, err := ParseWithReferences(`syntax = "proto3"; import "common.proto"; message A { common.Money m = 1; }`, []string{reference}); err != nil {
+		t.Errorf("Expected referenced type to resolve, got %v", err)
+	}
+}
@@ -0,0 +1,226 @@
+package protobuf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Registry resolves type names across a set of parsed files
+type Registry struct {
+	files    map[string]*File
+	messages map[string]*Message
+	enums    map[string]*Enum
+}
+
+// NewRegistry creates a registry that already knows the google.protobuf well-known types
+func NewRegistry() *Registry {
+	r := &Registry{
+		files:    map[string]*File{},
+		messages: map[string]*Message{},
+		enums:    map[string]*Enum{},
+	}
+
+	paths := make([]string, 0, len(wellKnownFiles))
+	for path := range wellKnownFiles {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		f, err := Parse(wellKnownFiles[path])
+		if err != nil {
+			panic(fmt.Sprintf("invalid well-known type definition %s: %v", path, err))
+		}
+		if err := r.AddFile(path, f); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// IsWellKnownImport reports whether an import path is one of the google.protobuf
+// well-known type files, which need no schema reference
+func IsWellKnownImport(path string) bool {
+	_, ok := wellKnownFiles[path]
+	return ok
+}
+
+// AddFile registers the types defined by a file under an import path
+func (r *Registry) AddFile(path string, f *File) error {
+	if _, ok := r.files[path]; ok {
+		return fmt.Errorf("file %s is already registered", path)
+	}
+	r.files[path] = f
+
+	var addMessage func(m *Message) error
+	addEnum := func(e *Enum) error {
+		if r.defined(e.FullName) {
+			return fmt.Errorf("%s is already defined", e.FullName)
+		}
+		r.enums[e.FullName] = e
+		return nil
+	}
+	addMessage = func(m *Message) error {
+		if r.defined(m.FullName) {
+			return fmt.Errorf("%s is already defined", m.FullName)
+		}
+		r.messages[m.FullName] = m
+		for _, nested := range m.Messages {
+			if err := addMessage(nested); err != nil {
+				return err
+			}
+		}
+		for _, e := range m.Enums {
+			if err := addEnum(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, m := range f.Messages {
+		if err := addMessage(m); err != nil {
+			return err
+		}
+	}
+	for _, e := range f.Enums {
+		if err := addEnum(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Registry) defined(fullName string) bool {
+	_, isMessage := r.messages[fullName]
+	_, isEnum := r.enums[fullName]
+	return isMessage || isEnum
+}
+
+// Message returns a message by full name
+func (r *Registry) Message(fullName string) (*Message, bool) {
+	m, ok := r.messages[strings.TrimPrefix(fullName, ".")]
+	return m, ok
+}
+
+// Enum returns an enum by full name
+func (r *Registry) Enum(fullName string) (*Enum, bool) {
+	e, ok := r.enums[strings.TrimPrefix(fullName, ".")]
+	return e, ok
+}
+
+// Resolve finds the message or enum a type name refers to from within a scope (a package
+// or message full name), searching from the innermost scope outwards as protoc does
+func (r *Registry) Resolve(scope, name string) (*Message, *Enum, error) {
+	if strings.HasPrefix(name, ".") {
+		if m, ok := r.Message(name); ok {
+			return m, nil, nil
+		}
+		if e, ok := r.Enum(name); ok {
+			return nil, e, nil
+		}
+		return nil, nil, fmt.Errorf("unknown type %s", name)
+	}
+
+	for {
+		candidate := name
+		if scope != "" {
+			candidate = scope + "." + name
+		}
+		if m, ok := r.messages[candidate]; ok {
+			return m, nil, nil
+		}
+		if e, ok := r.enums[candidate]; ok {
+			return nil, e, nil
+		}
+		if scope == "" {
+			return nil, nil, fmt.Errorf("unknown type %s", name)
+		}
+		if i := strings.LastIndex(scope, "."); i >= 0 {
+			scope = scope[:i]
+		} else {
+			scope = ""
+		}
+	}
+}
+
+// ResolveField resolves the type of a message or enum field; scalar fields return nil values
+func (r *Registry) ResolveField(field *Field) (*Message, *Enum, error) {
+	if IsScalar(field.Type) {
+		return nil, nil, nil
+	}
+	return r.Resolve(fieldScope(field), field.Type)
+}
+
+// fieldScope is the scope in which a field's type name is resolved
+func fieldScope(field *Field) string {
+	if field.Message != nil {
+		return field.Message.FullName
+	}
+	return ""
+}
+
+// Check verifies that every type referenced by a file is defined in the registry
+func (r *Registry) Check(f *File) error {
+	var checkMessage func(m *Message) error
+	checkMessage = func(m *Message) error {
+		for _, field := range m.Fields {
+			if _, _, err := r.ResolveField(field); err != nil {
+				return fmt.Errorf("%d:1: field %s of message %s: %w", field.Line, field.Name, m.FullName, err)
+			}
+		}
+		for _, nested := range m.Messages {
+			if err := checkMessage(nested); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, m := range f.Messages {
+		if err := checkMessage(m); err != nil {
+			return err
+		}
+	}
+	for _, service := range f.Services {
+		for _, method := range service.Methods {
+			for _, typeName := range []string{method.InputType, method.OutputType} {
+				if m, _, err := r.Resolve(f.Package, typeName); err != nil || m == nil {
+					return fmt.Errorf("%d:1: rpc %s.%s: unknown message type %s", method.Line, service.Name, method.Name, typeName)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ParseWithReferences parses a schema together with the contents of the schemas it
+// references, which are registered first, and checks that every type name resolves
+func ParseWithReferences(content string, references []string) (*File, *Registry, error) {
+	registry := NewRegistry()
+	for i, ref := range references {
+		refFile, err := Parse(ref)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse reference %d: %w", i+1, err)
+		}
+		if refFile.Package == "google.protobuf" {
+			// Well-known types are always available
+			continue
+		}
+		if err := registry.AddFile(fmt.Sprintf("reference-%d.proto", i+1), refFile); err != nil {
+			return nil, nil, fmt.Errorf("failed to register reference %d: %w", i+1, err)
+		}
+	}
+
+	f, err := Parse(content)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := registry.AddFile("schema.proto", f); err != nil {
+		return nil, nil, err
+	}
+	if err := registry.Check(f); err != nil {
+		return nil, nil, err
+	}
+	return f, registry, nil
+}
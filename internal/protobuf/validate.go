@@ -0,0 +1,139 @@
+package protobuf
+
+import (
+	"fmt"
+)
+
+// validateFile checks the rules protoc enforces within a single file: unique names and
+// numbers, reserved numbers and names, and the proto3 restrictions
+func validateFile(f *File) error {
+	proto3 := f.Syntax == "proto3"
+
+	var validateMessage func(m *Message) error
+	validateEnum := func(e *Enum) error {
+		if proto3 && e.Values[0].Number != 0 {
+			return fmt.Errorf("%d:1: the first value of enum %s must be zero in proto3", e.Values[0].Line, e.FullName)
+		}
+
+		allowAlias := false
+		if value, ok := optionValue(e.Options, "allow_alias"); ok && value == "true" {
+			allowAlias = true
+		}
+
+		names := map[string]bool{}
+		numbers := map[int]string{}
+		for _, value := range e.Values {
+			if names[value.Name] {
+				return fmt.Errorf("%d:1: duplicate value %s in enum %s", value.Line, value.Name, e.FullName)
+			}
+			names[value.Name] = true
+			if other, ok := numbers[value.Number]; ok && !allowAlias {
+				return fmt.Errorf("%d:1: values %s and %s of enum %s use the same number %d (set allow_alias to permit this)", value.Line, other, value.Name, e.FullName, value.Number)
+			}
+			numbers[value.Number] = value.Name
+			if err := checkReserved(e.Reserved, value.Name, value.Number, value.Line, "enum "+e.FullName); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	validateMessage = func(m *Message) error {
+		names := map[string]bool{}
+		numbers := map[int]string{}
+		for _, field := range m.Fields {
+			if names[field.Name] {
+				return fmt.Errorf("%d:1: duplicate field %s in message %s", field.Line, field.Name, m.FullName)
+			}
+			names[field.Name] = true
+			if other, ok := numbers[field.Number]; ok {
+				return fmt.Errorf("%d:1: fields %s and %s of message %s use the same number %d", field.Line, other, field.Name, m.FullName, field.Number)
+			}
+			numbers[field.Number] = field.Name
+			if err := checkReserved(m.Reserved, field.Name, field.Number, field.Line, "message "+m.FullName); err != nil {
+				return err
+			}
+
+			if proto3 {
+				if field.Label == "required" {
+					return fmt.Errorf("%d:1: required fields are not allowed in proto3 (field %s)", field.Line, field.Name)
+				}
+				if _, ok := field.Option("default"); ok {
+					return fmt.Errorf("%d:1: explicit default values are not allowed in proto3 (field %s)", field.Line, field.Name)
+				}
+			}
+			if field.IsMap && !validMapKey(field.KeyType) {
+				return fmt.Errorf("%d:1: invalid map key type %s for field %s", field.Line, field.KeyType, field.Name)
+			}
+		}
+
+		nested := map[string]bool{}
+		for _, child := range m.Messages {
+			if nested[child.Name] {
+				return fmt.Errorf("%d:1: duplicate message %s", child.Line, child.FullName)
+			}
+			nested[child.Name] = true
+			if err := validateMessage(child); err != nil {
+				return err
+			}
+		}
+		for _, enum := range m.Enums {
+			if nested[enum.Name] {
+				return fmt.Errorf("%d:1: duplicate type %s", enum.Line, enum.FullName)
+			}
+			nested[enum.Name] = true
+			if err := validateEnum(enum); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	topLevel := map[string]bool{}
+	for _, m := range f.Messages {
+		if topLevel[m.Name] {
+			return fmt.Errorf("%d:1: duplicate message %s", m.Line, m.FullName)
+		}
+		topLevel[m.Name] = true
+		if err := validateMessage(m); err != nil {
+			return err
+		}
+	}
+	for _, e := range f.Enums {
+		if topLevel[e.Name] {
+			return fmt.Errorf("%d:1: duplicate type %s", e.Line, e.FullName)
+		}
+		topLevel[e.Name] = true
+		if err := validateEnum(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkReserved reports a field or enum value that uses a reserved number or name
+func checkReserved(reserved []*Reserved, name string, number, line int, owner string) error {
+	for _, r := range reserved {
+		for _, reservedName := range r.Names {
+			if reservedName == name {
+				return fmt.Errorf("%d:1: %s uses reserved name %s", line, owner, name)
+			}
+		}
+		for _, rng := range r.Ranges {
+			if number >= rng.Start && number <= rng.End {
+				return fmt.Errorf("%d:1: %s uses reserved number %d for %s", line, owner, number, name)
+			}
+		}
+	}
+	return nil
+}
+
+func validMapKey(keyType string) bool {
+	switch keyType {
+	case "int32", "int64", "uint32", "uint64", "sint32", "sint64", "fixed32", "fixed64",
+		"sfixed32", "sfixed64", "bool", "string":
+		return true
+	default:
+		return false
+	}
+}
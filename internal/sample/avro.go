@@ -0,0 +1,177 @@
+package sample
+
+import (
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/internal/avro"
+	"github.com/aywengo/ksr-cli/internal/jsonfmt"
+)
+
+// Avro generates a value for an Avro schema in JSON form
+func (g *Generator) Avro(s *avro.Schema) interface{} {
+	return g.avroValue(s, "", 0)
+}
+
+func (g *Generator) avroValue(s *avro.Schema, name string, depth int) interface{} {
+	switch s.Type {
+	case avro.Null:
+		return nil
+	case avro.Boolean:
+		return g.chance(0.5)
+	case avro.Int:
+		switch s.LogicalType {
+		case "date":
+			return g.timestamp().Unix() / 86400
+		case "time-millis":
+			return g.intn(0, 86400*1000-1)
+		}
+		return g.intFor(name)
+	case avro.Long:
+		ts := g.timestamp()
+		switch strings.TrimPrefix(s.LogicalType, "local-") {
+		case "timestamp-millis":
+			return ts.UnixMilli()
+		case "timestamp-micros":
+			return ts.UnixMicro()
+		case "timestamp-nanos":
+			return ts.UnixNano()
+		case "time-micros":
+			return g.intn(0, 86400*1000*1000-1)
+		}
+		return g.intFor(name)
+	case avro.Float, avro.Double:
+		return g.amount(0, 1000)
+	case avro.Bytes:
+		if s.LogicalType == "decimal" {
+			return bytesToString(g.decimal(s.Precision, 0))
+		}
+		return g.byteString(int(g.intn(4, 8)))
+	case avro.String:
+		if s.LogicalType == "uuid" {
+			return g.uuid()
+		}
+		return g.stringFor(name)
+	case avro.Fixed:
+		if s.LogicalType == "decimal" {
+			return bytesToString(g.decimal(s.Precision, s.Size))
+		}
+		return g.byteString(s.Size)
+	case avro.Enum:
+		return pick(g, s.Symbols)
+	case avro.Array:
+		items := make([]interface{}, g.count(depth))
+		for i := range items {
+			items[i] = g.avroValue(s.Items, name, depth+1)
+		}
+		return items
+	case avro.Map:
+		entries := &jsonfmt.Object{}
+		for i := g.count(depth); i > 0; i-- {
+			entries.Set(pick(g, words), g.avroValue(s.Values, name, depth+1))
+		}
+		return entries
+	case avro.Union:
+		return g.avroUnion(s, name, depth)
+	case avro.Record, avro.Error:
+		record := &jsonfmt.Object{}
+		for _, field := range s.Fields {
+			var value interface{}
+			if field.HasDefault && g.chance(0.2) {
+				value = g.avroDefault(field)
+			} else {
+				value = g.avroValue(field.Type, field.Name, depth+1)
+			}
+			record.Members = append(record.Members, jsonfmt.Member{Key: field.Name, Value: value})
+		}
+		return record
+	default:
+		return nil
+	}
+}
+
+// avroUnion picks a branch of a union, preferring null when nesting gets deep
+func (g *Generator) avroUnion(s *avro.Schema, name string, depth int) interface{} {
+	var branches []*avro.Schema
+	nullable := false
+	for _, branch := range s.Types {
+		if branch.Type == avro.Null {
+			nullable = true
+		} else {
+			branches = append(branches, branch)
+		}
+	}
+	if len(branches) == 0 || (nullable && (depth > maxDepth || g.chance(0.2))) {
+		return nil
+	}
+
+	branch := pick(g, branches)
+	return g.wrapUnion(branch, g.avroValue(branch, name, depth))
+}
+
+// wrapUnion wraps a union value in an object keyed by its branch type, in the Avro JSON encoding
+func (g *Generator) wrapUnion(branch *avro.Schema, value interface{}) interface{} {
+	if !g.avroJSON || value == nil {
+		return value
+	}
+	return &jsonfmt.Object{Members: []jsonfmt.Member{{Key: branch.TypeName(), Value: value}}}
+}
+
+// avroDefault returns the default value of a field. Union defaults belong to the first branch.
+func (g *Generator) avroDefault(field *avro.Field) interface{} {
+	if field.Type.Type == avro.Union && len(field.Type.Types) > 0 {
+		return g.wrapUnion(field.Type.Types[0], field.Default)
+	}
+	return field.Default
+}
+
+// intFor returns a realistic integer for a field, guessed from its name
+func (g *Generator) intFor(name string) int64 {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.Contains(lower, "age"):
+		return g.intn(18, 90)
+	case strings.Contains(lower, "year"):
+		return g.intn(1990, 2030)
+	case strings.Contains(lower, "quantity"), strings.Contains(lower, "count"), strings.Contains(lower, "qty"):
+		return g.intn(1, 10)
+	default:
+		return g.intn(0, 1000)
+	}
+}
+
+// amount returns a number with two decimals in [lo, hi]
+func (g *Generator) amount(lo, hi float64) float64 {
+	value := lo + g.rng.Float64()*(hi-lo)
+	rounded := math.Round(value*100) / 100
+	if rounded < lo || rounded > hi {
+		return value
+	}
+	return rounded
+}
+
+// decimal returns the big-endian two's complement bytes of a random unscaled decimal value
+// that fits the precision, sign-extended to size bytes for fixed types
+func (g *Generator) decimal(precision, size int) []byte {
+	digits := precision
+	if digits <= 0 || digits > 9 {
+		digits = 9
+	}
+	max := int64(math.Pow10(digits)) - 1
+	if size > 0 && size < 8 {
+		// Keep the value within the fixed size
+		limit := int64(1)<<(uint(size)*8-1) - 1
+		if limit < max {
+			max = limit
+		}
+	}
+	b := big.NewInt(g.intn(0, max)).Bytes()
+	if len(b) == 0 || b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	}
+	for len(b) < size {
+		b = append([]byte{0}, b...)
+	}
+	return b
+}
@@ -0,0 +1,396 @@
+package sample
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+
+	"github.com/aywengo/ksr-cli/internal/jsonschema"
+)
+
+// JSONSchema generates a value that validates against a JSON Schema
+func (g *Generator) JSONSchema(doc *jsonschema.Document) interface{} {
+	return g.jsonValue(doc, doc.Root, "", 0)
+}
+
+func (g *Generator) jsonValue(doc *jsonschema.Document, raw interface{}, name string, depth int) interface{} {
+	resolved, _ := doc.Resolve(raw)
+	schema, ok := resolved.(map[string]interface{})
+	if !ok {
+		// true accepts anything; nothing satisfies false
+		if accept, isBool := resolved.(bool); isBool && !accept {
+			return nil
+		}
+		return g.stringFor(name)
+	}
+
+	if value, ok := schema["const"]; ok {
+		return value
+	}
+	if values, ok := schema["enum"].([]interface{}); ok && len(values) > 0 {
+		return pick(g, values)
+	}
+	if value, ok := schema["default"]; ok && g.chance(0.2) {
+		return value
+	}
+	if examples, ok := schema["examples"].([]interface{}); ok && len(examples) > 0 && g.chance(0.3) {
+		return pick(g, examples)
+	}
+
+	if subschemas, ok := schema["allOf"].([]interface{}); ok {
+		merged := without(schema, "allOf")
+		for _, sub := range subschemas {
+			merged = mergeSchemas(doc, merged, sub)
+		}
+		return g.jsonValue(doc, merged, name, depth)
+	}
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if branches, ok := schema[keyword].([]interface{}); ok && len(branches) > 0 {
+			return g.jsonValue(doc, mergeSchemas(doc, without(schema, keyword), pick(g, branches)), name, depth)
+		}
+	}
+
+	switch g.jsonType(schema, depth) {
+	case "null":
+		return nil
+	case "boolean":
+		return g.chance(0.5)
+	case "integer":
+		return g.jsonInteger(schema, name)
+	case "number":
+		return g.jsonNumber(schema)
+	case "object":
+		return g.jsonObject(doc, schema, depth)
+	case "array":
+		return g.jsonArray(doc, schema, name, depth)
+	default:
+		return g.jsonString(schema, name)
+	}
+}
+
+// jsonType chooses one of the types a schema allows, inferring it from keywords when absent
+func (g *Generator) jsonType(schema map[string]interface{}, depth int) string {
+	var types []string
+	switch v := schema["type"].(type) {
+	case string:
+		types = []string{v}
+	case []interface{}:
+		for _, t := range v {
+			if s, ok := t.(string); ok {
+				types = append(types, s)
+			}
+		}
+	}
+
+	if len(types) == 0 {
+		switch {
+		case has(schema, "properties", "required", "additionalProperties", "patternProperties", "minProperties"):
+			return "object"
+		case has(schema, "items", "prefixItems", "minItems", "maxItems", "uniqueItems"):
+			return "array"
+		case has(schema, "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf"):
+			return "number"
+		default:
+			return "string"
+		}
+	}
+
+	var nonNull []string
+	for _, t := range types {
+		if t != "null" {
+			nonNull = append(nonNull, t)
+		}
+	}
+	if len(nonNull) == 0 || (len(nonNull) < len(types) && (depth > maxDepth || g.chance(0.2))) {
+		return "null"
+	}
+	return pick(g, nonNull)
+}
+
+func (g *Generator) jsonString(schema map[string]interface{}, name string) string {
+	minLength, maxLength := 0, -1
+	if n, ok := schema["minLength"].(float64); ok {
+		minLength = int(n)
+	}
+	if n, ok := schema["maxLength"].(float64); ok {
+		maxLength = int(n)
+	}
+
+	if pattern, ok := schema["pattern"].(string); ok {
+		var candidate string
+		for attempt := 0; attempt < 10; attempt++ {
+			s, ok := g.fromPattern(pattern)
+			if !ok {
+				break
+			}
+			candidate = s
+			if n := len([]rune(s)); n >= minLength && (maxLength < 0 || n <= maxLength) {
+				return s
+			}
+		}
+		if candidate != "" {
+			return candidate
+		}
+	}
+
+	value := ""
+	if format, ok := schema["format"].(string); ok {
+		value = g.formatted(format)
+	}
+	if value == "" {
+		value = g.stringFor(name)
+	}
+	return g.fitLength(value, minLength, maxLength)
+}
+
+// numericBounds returns the inclusive bounds of a numeric schema and whether they are exclusive.
+// Draft 4 declares exclusive bounds as booleans next to minimum and maximum.
+func numericBounds(schema map[string]interface{}) (lo float64, hasLo, loExclusive bool, hi float64, hasHi, hiExclusive bool) {
+	lo, hasLo = schema["minimum"].(float64)
+	hi, hasHi = schema["maximum"].(float64)
+	switch v := schema["exclusiveMinimum"].(type) {
+	case float64:
+		if !hasLo || v >= lo {
+			lo, hasLo, loExclusive = v, true, true
+		}
+	case bool:
+		loExclusive = v && hasLo
+	}
+	switch v := schema["exclusiveMaximum"].(type) {
+	case float64:
+		if !hasHi || v <= hi {
+			hi, hasHi, hiExclusive = v, true, true
+		}
+	case bool:
+		hiExclusive = v && hasHi
+	}
+	return
+}
+
+func (g *Generator) jsonInteger(schema map[string]interface{}, name string) int64 {
+	lo, hasLo, loExclusive, hi, hasHi, hiExclusive := numericBounds(schema)
+	min, max := math.Ceil(lo), math.Floor(hi)
+	if loExclusive && min == lo {
+		min++
+	}
+	if hiExclusive && max == hi {
+		max--
+	}
+
+	switch {
+	case !hasLo && !hasHi:
+		value := g.intFor(name)
+		min, max = float64(value), float64(value)
+	case !hasLo:
+		min = max - 1000
+	case !hasHi:
+		max = min + 1000
+	}
+
+	if step, ok := schema["multipleOf"].(float64); ok && step > 0 {
+		first, last := math.Ceil(min/step), math.Floor(max/step)
+		if !hasLo && !hasHi {
+			first, last = 1, 10
+		}
+		for attempt := 0; attempt < 10; attempt++ {
+			value := float64(g.intn(int64(first), int64(last))) * step
+			if value == math.Trunc(value) {
+				return int64(value)
+			}
+		}
+	}
+	return g.intn(int64(min), int64(max))
+}
+
+func (g *Generator) jsonNumber(schema map[string]interface{}) float64 {
+	lo, hasLo, loExclusive, hi, hasHi, hiExclusive := numericBounds(schema)
+	switch {
+	case !hasLo && !hasHi:
+		lo, hi = 0, 1000
+	case !hasLo:
+		lo = hi - 1000
+	case !hasHi:
+		hi = lo + 1000
+	}
+
+	if step, ok := schema["multipleOf"].(float64); ok && step > 0 {
+		first, last := math.Ceil(lo/step), math.Floor(hi/step)
+		if loExclusive && first*step == lo {
+			first++
+		}
+		if hiExclusive && last*step == hi {
+			last--
+		}
+		return float64(g.intn(int64(first), int64(last))) * step
+	}
+
+	value := g.amount(lo, hi)
+	if (loExclusive && value <= lo) || (hiExclusive && value >= hi) {
+		return (lo + hi) / 2
+	}
+	return value
+}
+
+func (g *Generator) jsonObject(doc *jsonschema.Document, schema map[string]interface{}, depth int) interface{} {
+	object := map[string]interface{}{}
+
+	required := map[string]bool{}
+	var requiredNames []string
+	if names, ok := schema["required"].([]interface{}); ok {
+		for _, n := range names {
+			if s, ok := n.(string); ok && !required[s] {
+				required[s] = true
+				requiredNames = append(requiredNames, s)
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var skipped []string
+	for _, name := range names {
+		if required[name] || (depth < maxDepth && g.chance(0.7)) {
+			object[name] = g.jsonValue(doc, properties[name], name, depth+1)
+		} else {
+			skipped = append(skipped, name)
+		}
+	}
+	// Required properties without a definition still have to be present
+	for _, name := range requiredNames {
+		if _, ok := object[name]; !ok && properties[name] == nil {
+			object[name] = g.stringFor(name)
+		}
+	}
+
+	if n, ok := schema["minProperties"].(float64); ok {
+		for _, name := range skipped {
+			if len(object) >= int(n) {
+				break
+			}
+			object[name] = g.jsonValue(doc, properties[name], name, depth+1)
+		}
+	}
+
+	if len(properties) == 0 {
+		if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+			for i := g.count(depth); i > 0; i-- {
+				object[pick(g, words)] = g.jsonValue(doc, additional, "", depth+1)
+			}
+		}
+	}
+	return object
+}
+
+func (g *Generator) jsonArray(doc *jsonschema.Document, schema map[string]interface{}, name string, depth int) []interface{} {
+	var tuple []interface{}
+	if prefix, ok := schema["prefixItems"].([]interface{}); ok {
+		tuple = prefix
+	} else if items, ok := schema["items"].([]interface{}); ok {
+		tuple = items
+	}
+	if tuple != nil {
+		values := make([]interface{}, len(tuple))
+		for i, item := range tuple {
+			values[i] = g.jsonValue(doc, item, name, depth+1)
+		}
+		return values
+	}
+
+	minItems, maxItems := 1, -1
+	if n, ok := schema["minItems"].(float64); ok {
+		minItems = int(n)
+	}
+	if n, ok := schema["maxItems"].(float64); ok {
+		maxItems = int(n)
+	}
+	if maxItems < 0 || maxItems > minItems+2 {
+		maxItems = minItems + 2
+	}
+	count := int(g.intn(int64(minItems), int64(maxItems)))
+	if depth > maxDepth {
+		count = minItems
+	}
+
+	unique, _ := schema["uniqueItems"].(bool)
+	seen := map[string]bool{}
+	values := make([]interface{}, 0, count)
+	for attempt := 0; len(values) < count && attempt < count*10; attempt++ {
+		value := g.jsonValue(doc, schema["items"], name, depth+1)
+		if unique {
+			key, _ := json.Marshal(value)
+			if seen[string(key)] {
+				continue
+			}
+			seen[string(key)] = true
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// mergeSchemas combines a subschema of allOf, oneOf or anyOf into its parent, so that the
+// generated value satisfies both: properties and required names are combined, and other
+// keywords of the subschema apply unless the parent sets them
+func mergeSchemas(doc *jsonschema.Document, parent map[string]interface{}, raw interface{}) map[string]interface{} {
+	resolved, _ := doc.Resolve(raw)
+	sub, ok := resolved.(map[string]interface{})
+	if !ok {
+		return parent
+	}
+
+	merged := without(parent)
+	for key, value := range sub {
+		switch key {
+		case "properties":
+			properties := map[string]interface{}{}
+			if existing, ok := merged["properties"].(map[string]interface{}); ok {
+				for name, schema := range existing {
+					properties[name] = schema
+				}
+			}
+			added, _ := value.(map[string]interface{})
+			for name, schema := range added {
+				if _, exists := properties[name]; !exists {
+					properties[name] = schema
+				}
+			}
+			merged["properties"] = properties
+		case "required":
+			existing, _ := merged["required"].([]interface{})
+			required, _ := value.([]interface{})
+			merged["required"] = append(append([]interface{}{}, existing...), required...)
+		default:
+			if _, exists := merged[key]; !exists {
+				merged[key] = value
+			}
+		}
+	}
+	return merged
+}
+
+// without returns a shallow copy of a schema without the given keywords
+func without(schema map[string]interface{}, keywords ...string) map[string]interface{} {
+	copied := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		copied[key] = value
+	}
+	for _, keyword := range keywords {
+		delete(copied, keyword)
+	}
+	return copied
+}
+
+// has reports whether a schema uses any of the keywords
+func has(schema map[string]interface{}, keywords ...string) bool {
+	for _, keyword := range keywords {
+		if _, ok := schema[keyword]; ok {
+			return true
+		}
+	}
+	return false
+}
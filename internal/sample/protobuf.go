@@ -0,0 +1,161 @@
+package sample
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aywengo/ksr-cli/internal/jsonfmt"
+	"github.com/aywengo/ksr-cli/internal/protobuf"
+)
+
+// Protobuf generates a message in the Protobuf JSON mapping: fields use their JSON names,
+// 64-bit integers are strings, bytes are base64 and enums are value names
+func (g *Generator) Protobuf(m *protobuf.Message, registry *protobuf.Registry) interface{} {
+	return g.protoMessage(m, registry, 0)
+}
+
+func (g *Generator) protoMessage(m *protobuf.Message, registry *protobuf.Registry, depth int) interface{} {
+	if protobuf.IsWellKnownType(m.FullName) {
+		return g.protoWellKnown(m.FullName)
+	}
+
+	// One member of each oneof is set, or none
+	chosen := map[*protobuf.Oneof]*protobuf.Field{}
+	for _, oneof := range m.Oneofs {
+		if len(oneof.Fields) > 0 && depth <= maxDepth && g.chance(0.9) {
+			chosen[oneof] = pick(g, oneof.Fields)
+		}
+	}
+
+	message := &jsonfmt.Object{}
+	for _, field := range m.Fields {
+		if field.Oneof != nil && chosen[field.Oneof] != field {
+			continue
+		}
+		if field.Label == "optional" && (depth > maxDepth || g.chance(0.3)) {
+			continue
+		}
+
+		value, ok := g.protoField(field, registry, depth)
+		if ok {
+			message.Members = append(message.Members, jsonfmt.Member{Key: field.JSONName, Value: value})
+		}
+	}
+	return message
+}
+
+// protoField generates the value of a field, or returns false to leave it out
+func (g *Generator) protoField(field *protobuf.Field, registry *protobuf.Registry, depth int) (interface{}, bool) {
+	switch {
+	case field.IsMap:
+		entries := &jsonfmt.Object{}
+		for i := g.count(depth); i > 0; i-- {
+			value, ok := g.protoSingle(field, registry, depth)
+			if !ok {
+				break
+			}
+			entries.Set(g.protoMapKey(field.KeyType), value)
+		}
+		return entries, true
+	case field.Label == "repeated":
+		values := []interface{}{}
+		for i := g.count(depth); i > 0; i-- {
+			value, ok := g.protoSingle(field, registry, depth)
+			if !ok {
+				break
+			}
+			values = append(values, value)
+		}
+		return values, true
+	default:
+		return g.protoSingle(field, registry, depth)
+	}
+}
+
+// protoSingle generates one value of a field's type
+func (g *Generator) protoSingle(field *protobuf.Field, registry *protobuf.Registry, depth int) (interface{}, bool) {
+	if protobuf.IsScalar(field.Type) {
+		return g.protoScalar(field.Type, field.Name), true
+	}
+
+	message, enum, err := registry.ResolveField(field)
+	if err != nil {
+		return nil, false
+	}
+	if enum != nil {
+		return pick(g, enum.Values).Name, true
+	}
+	// Singular message fields are optional, so recursion stops by leaving them out
+	if depth > maxDepth && field.Label != "required" {
+		return nil, false
+	}
+	return g.protoMessage(message, registry, depth+1), true
+}
+
+// protoScalar generates a scalar value in its JSON form
+func (g *Generator) protoScalar(scalarType, name string) interface{} {
+	switch scalarType {
+	case "double", "float":
+		return g.amount(0, 1000)
+	case "int32", "sint32", "sfixed32", "uint32", "fixed32":
+		return g.intFor(name)
+	case "int64", "sint64", "sfixed64", "uint64", "fixed64":
+		return strconv.FormatInt(g.intFor(name), 10)
+	case "bool":
+		return g.chance(0.5)
+	case "bytes":
+		b := make([]byte, g.intn(4, 8))
+		g.rng.Read(b)
+		return base64.StdEncoding.EncodeToString(b)
+	default:
+		return g.stringFor(name)
+	}
+}
+
+// protoMapKey generates a map key, which the JSON mapping always writes as a string
+func (g *Generator) protoMapKey(keyType string) string {
+	switch keyType {
+	case "string":
+		return pick(g, words)
+	case "bool":
+		return strconv.FormatBool(g.chance(0.5))
+	default:
+		return strconv.FormatInt(g.intn(1, 1000), 10)
+	}
+}
+
+// protoWellKnown generates a well-known type in its special JSON form
+func (g *Generator) protoWellKnown(fullName string) interface{} {
+	switch fullName {
+	case "google.protobuf.Timestamp":
+		return g.timestamp().Format(time.RFC3339Nano)
+	case "google.protobuf.Duration":
+		return fmt.Sprintf("%ds", g.intn(1, 86400))
+	case "google.protobuf.FieldMask":
+		return pick(g, words) + "." + pick(g, words)
+	case "google.protobuf.Struct":
+		return &jsonfmt.Object{Members: []jsonfmt.Member{{Key: pick(g, words), Value: pick(g, words)}}}
+	case "google.protobuf.Value":
+		return pick(g, words)
+	case "google.protobuf.ListValue":
+		return []interface{}{pick(g, words)}
+	case "google.protobuf.Any":
+		return &jsonfmt.Object{Members: []jsonfmt.Member{{Key: "@type", Value: "type.googleapis.com/google.protobuf.Empty"}}}
+	case "google.protobuf.DoubleValue", "google.protobuf.FloatValue":
+		return g.protoScalar("double", "")
+	case "google.protobuf.Int64Value", "google.protobuf.UInt64Value":
+		return g.protoScalar("int64", "")
+	case "google.protobuf.Int32Value", "google.protobuf.UInt32Value":
+		return g.protoScalar("int32", "")
+	case "google.protobuf.BoolValue":
+		return g.protoScalar("bool", "")
+	case "google.protobuf.StringValue":
+		return g.protoScalar("string", "")
+	case "google.protobuf.BytesValue":
+		return g.protoScalar("bytes", "")
+	default:
+		return &jsonfmt.Object{}
+	}
+}
@@ -0,0 +1,115 @@
+// Package sample generates random records that match Avro, JSON Schema and Protobuf schemas.
+package sample
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/aywengo/ksr-cli/internal/avro"
+	"github.com/aywengo/ksr-cli/internal/jsonschema"
+	"github.com/aywengo/ksr-cli/internal/protobuf"
+)
+
+// maxDepth bounds nesting: beyond it optional values are left out and collections are
+// kept as small as the schema allows, so recursive schemas terminate
+const maxDepth = 4
+
+// Options controls sample generation
+type Options struct {
+	// Seed makes generation reproducible
+	Seed int64
+	// AvroJSON produces the Avro JSON encoding for Avro schemas, which wraps non-null
+	// union values in an object keyed by the branch type
+	AvroJSON bool
+}
+
+// Generator produces random values that match schemas
+type Generator struct {
+	rng      *rand.Rand
+	avroJSON bool
+	// base is the reference time around which dates and timestamps are generated,
+	// fixed so that a seed always produces the same records
+	base time.Time
+}
+
+// New returns a generator
+func New(opts Options) *Generator {
+	return &Generator{
+		rng:      rand.New(rand.NewSource(opts.Seed)),
+		avroJSON: opts.AvroJSON,
+		base:     time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// Records generates count records for schema content of the given type. References holds
+// the contents of referenced schemas. Protobuf records are generated for the first message.
+func Records(schemaType, content string, references []string, count int, opts Options) ([]interface{}, error) {
+	g := New(opts)
+
+	var generate func() interface{}
+	switch strings.ToUpper(schemaType) {
+	case "", "AVRO":
+		s, err := avro.ParseWithReferences(content, references)
+		if err != nil {
+			return nil, err
+		}
+		generate = func() interface{} { return g.Avro(s) }
+	case "JSON":
+		doc, err := jsonschema.Parse(content)
+		if err != nil {
+			return nil, err
+		}
+		generate = func() interface{} { return g.JSONSchema(doc) }
+	case "PROTOBUF":
+		f, registry, err := protobuf.ParseWithReferences(content, references)
+		if err != nil {
+			return nil, err
+		}
+		if len(f.Messages) == 0 {
+			return nil, fmt.Errorf("schema defines no messages")
+		}
+		generate = func() interface{} { return g.Protobuf(f.Messages[0], registry) }
+	default:
+		return nil, fmt.Errorf("unsupported schema type: %s", schemaType)
+	}
+
+	records := make([]interface{}, 0, count)
+	for i := 0; i < count; i++ {
+		records = append(records, generate())
+	}
+	return records, nil
+}
+
+// intn returns a random integer in [lo, hi]
+func (g *Generator) intn(lo, hi int64) int64 {
+	if hi <= lo {
+		return lo
+	}
+	return lo + g.rng.Int63n(hi-lo+1)
+}
+
+// chance returns true with probability p
+func (g *Generator) chance(p float64) bool {
+	return g.rng.Float64() < p
+}
+
+// pick returns a random element
+func pick[T any](g *Generator, values []T) T {
+	return values[g.rng.Intn(len(values))]
+}
+
+// count returns the size of a generated collection
+func (g *Generator) count(depth int) int {
+	if depth > maxDepth {
+		return 0
+	}
+	return int(g.intn(1, 3))
+}
+
+// timestamp returns a random time within a year of the reference time
+func (g *Generator) timestamp() time.Time {
+	offset := time.Duration(g.intn(-365*24*3600, 365*24*3600)) * time.Second
+	return g.base.Add(offset).Add(time.Duration(g.intn(0, 999)) * time.Millisecond)
+}
@@ -0,0 +1,166 @@
+package sample
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+)
+
+// decodeRecords round-trips generated records through JSON
+func decodeRecords(t *testing.T, records []interface{}) []map[string]interface{} {
+	t.Helper()
+	var decoded []map[string]interface{}
+	data, err := json.Marshal(records)
+	if err != nil {
+		t.Fatalf("Failed to encode records: %v", err)
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to decode records: %v", err)
+	}
+	return decoded
+}
+
+const avroSchema = `{"type":"record","name":"Order","namespace":"com.acme","fields":[
+	{"name":"id","type":{"type":"string","logicalType":"uuid"}},
+	{"name":"created_at","type":{"type":"long","logicalType":"timestamp-millis"}},
+	{"name":"status","type":{"type":"enum","name":"Status","symbols":["NEW","DONE"]}},
+	{"name":"note","type":["null","string"],"default":null},
+	{"name":"lines","type":{"type":"array","items":{"type":"record","name":"Line","fields":[{"name":"qty","type":"int"}]}}},
+	{"name":"parent","type":["null","Order"],"default":null}
+]}`
+
+func TestRecords_Avro(t *testing.T) {
+	records, err := Records("AVRO", avroSchema, nil, 20, Options{Seed: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	for i, record := range decodeRecords(t, records) {
+		if len(record) != 6 {
+			t.Errorf("Record %d: expected 6 fields, got %v", i, record)
+		}
+		if id, _ := record["id"].(string); !uuid.MatchString(id) {
+			t.Errorf("Record %d: expected a UUID, got %v", i, record["id"])
+		}
+		if _, ok := record["created_at"].(float64); !ok {
+			t.Errorf("Record %d: expected a numeric timestamp, got %v", i, record["created_at"])
+		}
+		if status := record["status"]; status != "NEW" && status != "DONE" {
+			t.Errorf("Record %d: unexpected enum symbol %v", i, status)
+		}
+		if note := record["note"]; note != nil {
+			if _, ok := note.(string); !ok {
+				t.Errorf("Record %d: expected plain string or null, got %v", i, note)
+			}
+		}
+	}
+}
+
+func TestRecords_AvroJSONEncoding(t *testing.T) {
+	records, err := Records("AVRO", avroSchema, nil, 20, Options{Seed: 1, AvroJSON: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	wrapped := 0
+	for i, record := range decodeRecords(t, records) {
+		if note := record["note"]; note != nil {
+			branch, ok := note.(map[string]interface{})
+			if _, isString := branch["string"].(string); !ok || !isString || len(branch) != 1 {
+				t.Errorf("Record %d: expected {\"string\": ...}, got %v", i, note)
+			}
+			wrapped++
+		}
+		if parent := record["parent"]; parent != nil {
+			if _, ok := parent.(map[string]interface{})["com.acme.Order"]; !ok {
+				t.Errorf("Record %d: expected union branch keyed by full name, got %v", i, parent)
+			}
+		}
+	}
+	if wrapped == 0 {
+		t.Error("Expected some non-null union values")
+	}
+}
+
+func TestRecords_Deterministic(t *testing.T) {
+	first, _ := Records("AVRO", avroSchema, nil, 5, Options{Seed: 42})
+	second, _ := Records("AVRO", avroSchema, nil, 5, Options{Seed: 42})
+	a, _ := json.Marshal(first)
+	b, _ := json.Marshal(second)
+	if string(a) != string(b) {
+		t.Error("Expected the same seed to produce the same records")
+	}
+}
+
+func TestRecords_JSONSchema(t *testing.T) {
+	schema := `{"type":"object","required":["id","price","tags","address"],"properties":{
+		"id":{"type":"string","pattern":"^ORD-[0-9]{6}$"},
+		"price":{"type":"number","minimum":1,"exclusiveMaximum":50,"multipleOf":0.5},
+		"qty":{"type":"integer","minimum":1,"maximum":5},
+		"code":{"type":"string","minLength":10,"maxLength":12},
+		"tags":{"type":"array","items":{"enum":["a","b","c"]},"minItems":2,"maxItems":3,"uniqueItems":true},
+		"address":{"$ref":"#/definitions/address"}
+	},"definitions":{"address":{"type":"object","required":["city"],"properties":{"city":{"type":"string"}}}}}`
+
+	records, err := Records("JSON", schema, nil, 30, Options{Seed: 7})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	pattern := regexp.MustCompile(`^ORD-[0-9]{6}$`)
+	for i, record := range decodeRecords(t, records) {
+		if id, _ := record["id"].(string); !pattern.MatchString(id) {
+			t.Errorf("Record %d: id %v does not match the pattern", i, record["id"])
+		}
+		price, _ := record["price"].(float64)
+		if price < 1 || price >= 50 || price*2 != float64(int(price*2)) {
+			t.Errorf("Record %d: price %v violates the constraints", i, price)
+		}
+		if qty, ok := record["qty"].(float64); ok && (qty < 1 || qty > 5 || qty != float64(int(qty))) {
+			t.Errorf("Record %d: qty %v violates the constraints", i, qty)
+		}
+		if code, ok := record["code"].(string); ok && (len([]rune(code)) < 10 || len([]rune(code)) > 12) {
+			t.Errorf("Record %d: code %q violates the length constraints", i, code)
+		}
+		tags, _ := record["tags"].([]interface{})
+		if len(tags) < 2 || len(tags) > 3 || (len(tags) == 3 && tags[0] == tags[2]) || tags[0] == tags[1] {
+			t.Errorf("Record %d: tags %v violate the array constraints", i, tags)
+		}
+		if address, _ := record["address"].(map[string]interface{}); address["city"] == nil {
+			t.Errorf("Record %d: expected the referenced required property, got %v", i, record["address"])
+		}
+	}
+}
+
+func TestRecords_Protobuf(t *testing.T) {
+	schema := `syntax = "proto3";
+import "google/protobuf/timestamp.proto";
+message Order {
+  int64 total_cents = 1;
+  oneof payment { string card = 2; string iban = 3; }
+  Status status = 4;
+  google.protobuf.Timestamp created_at = 5;
+}
+enum Status { STATUS_UNKNOWN = 0; STATUS_OPEN = 1; }`
+
+	records, err := Records("PROTOBUF", schema, nil, 20, Options{Seed: 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for i, record := range decodeRecords(t, records) {
+		if _, ok := record["totalCents"].(string); !ok {
+			t.Errorf("Record %d: expected int64 as a string under its JSON name, got %v", i, record)
+		}
+		if record["card"] != nil && record["iban"] != nil {
+			t.Errorf("Record %d: expected at most one oneof member, got %v", i, record)
+		}
+		if status := record["status"]; status != "STATUS_UNKNOWN" && status != "STATUS_OPEN" {
+			t.Errorf("Record %d: unexpected enum value %v", i, status)
+		}
+		if _, ok := record["createdAt"].(string); !ok {
+			t.Errorf("Record %d: expected an RFC 3339 timestamp, got %v", i, record["createdAt"])
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package sample
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	firstNames = []string{"Alice", "Bob", "Carla", "David", "Elena", "Farid", "Grace", "Hiro", "Ines", "Jonas"}
+	lastNames  = []string{"Smith", "Garcia", "Müller", "Rossi", "Tanaka", "Dubois", "Kowalski", "Silva", "Novak", "Jensen"}
+	cities     = []string{"Amsterdam", "Berlin", "Lisbon", "Madrid", "Oslo", "Paris", "Prague", "Vienna", "Warsaw", "Zurich"}
+	countries  = []string{"DE", "ES", "FR", "GB", "IT", "NL", "NO", "PL", "PT", "US"}
+	currencies = []string{"CHF", "EUR", "GBP", "JPY", "NOK", "PLN", "USD"}
+	statuses   = []string{"active", "pending", "completed", "cancelled"}
+	words      = []string{
+		"alpha", "amber", "bright", "cedar", "delta", "ember", "forest", "garden", "harbor", "island",
+		"jasmine", "kernel", "lumen", "meadow", "nectar", "orbit", "prairie", "quartz", "river", "summit",
+		"timber", "umbra", "valley", "willow", "zephyr",
+	}
+)
+
+// stringFor returns a realistic string for a field, guessed from its name
+func (g *Generator) stringFor(name string) string {
+	lower := strings.ToLower(name)
+	has := func(parts ...string) bool {
+		for _, part := range parts {
+			if strings.Contains(lower, part) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case has("email", "mail"):
+		return strings.ToLower(fmt.Sprintf("%s.%s@example.com", pick(g, firstNames), pick(g, []string{"smith", "garcia", "rossi", "tanaka", "silva"})))
+	case has("firstname", "first_name", "givenname", "given_name"):
+		return pick(g, firstNames)
+	case has("lastname", "last_name", "surname", "familyname", "family_name"):
+		return pick(g, lastNames)
+	case lower == "name" || has("fullname", "full_name", "username", "user_name", "customer", "author", "owner"):
+		return pick(g, firstNames) + " " + pick(g, lastNames)
+	case lower == "id" || has("uuid", "guid") || strings.HasSuffix(lower, "_id") || strings.HasSuffix(name, "Id") || strings.HasSuffix(name, "ID"):
+		return g.uuid()
+	case has("url", "uri", "link", "website", "href"):
+		return "https://example.com/" + pick(g, words)
+	case has("host"):
+		return pick(g, words) + ".example.com"
+	case has("phone", "mobile"):
+		return fmt.Sprintf("+1-555-01%02d", g.intn(0, 99))
+	case has("country"):
+		return pick(g, countries)
+	case has("city", "town"):
+		return pick(g, cities)
+	case has("street", "address"):
+		return fmt.Sprintf("%d %s Street", g.intn(1, 250), capitalize(pick(g, words)))
+	case has("zip", "postal", "postcode"):
+		return fmt.Sprintf("%05d", g.intn(1000, 99999))
+	case has("currency"):
+		return pick(g, currencies)
+	case has("status", "state"):
+		return pick(g, statuses)
+	case has("sku", "code"):
+		return fmt.Sprintf("%s-%04d", strings.ToUpper(pick(g, words)[:3]), g.intn(0, 9999))
+	case has("description", "comment", "note", "text", "message", "summary", "title"):
+		return g.sentence()
+	default:
+		return pick(g, words)
+	}
+}
+
+// sentence returns a few random words
+func (g *Generator) sentence() string {
+	n := int(g.intn(3, 7))
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = pick(g, words)
+	}
+	parts[0] = capitalize(parts[0])
+	return strings.Join(parts, " ")
+}
+
+// capitalize upper-cases the first letter of an ASCII word
+func capitalize(word string) string {
+	return strings.ToUpper(word[:1]) + word[1:]
+}
+
+// uuid returns a random version 4 UUID
+func (g *Generator) uuid() string {
+	b := make([]byte, 16)
+	g.rng.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// formatted returns a string in a JSON Schema format, or "" for unknown formats
+func (g *Generator) formatted(format string) string {
+	switch format {
+	case "date-time":
+		return g.timestamp().Format("2006-01-02T15:04:05.000Z07:00")
+	case "date":
+		return g.timestamp().Format("2006-01-02")
+	case "time":
+		return g.timestamp().Format("15:04:05Z07:00")
+	case "email", "idn-email":
+		return g.stringFor("email")
+	case "uri", "url", "iri", "uri-reference", "iri-reference":
+		return g.stringFor("url")
+	case "uuid":
+		return g.uuid()
+	case "hostname", "idn-hostname":
+		return g.stringFor("host")
+	case "ipv4":
+		return fmt.Sprintf("10.%d.%d.%d", g.intn(0, 255), g.intn(0, 255), g.intn(1, 254))
+	case "ipv6":
+		return fmt.Sprintf("2001:db8::%x", g.intn(1, 0xffff))
+	case "duration":
+		return fmt.Sprintf("PT%dM", g.intn(1, 120))
+	default:
+		return ""
+	}
+}
+
+// fromPattern returns a string matching a regular expression
+func (g *Generator) fromPattern(pattern string) (string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	matcher, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", false
+	}
+
+	for attempt := 0; attempt < 10; attempt++ {
+		var b strings.Builder
+		g.writeRegexp(&b, re.Simplify())
+		if matcher.MatchString(b.String()) {
+			return b.String(), true
+		}
+	}
+	return "", false
+}
+
+// writeRegexp writes a random string matched by a parsed regular expression
+func (g *Generator) writeRegexp(b *strings.Builder, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 {
+			return
+		}
+		i := g.rng.Intn(len(re.Rune)/2) * 2
+		lo, hi := re.Rune[i], re.Rune[i+1]
+		// Stay within printable ASCII where the class allows it
+		if lo < ' ' && hi >= ' ' {
+			lo = ' '
+		}
+		if lo <= '~' && hi > '~' {
+			hi = '~'
+		}
+		b.WriteRune(lo + rune(g.rng.Intn(int(hi-lo)+1)))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteString(pick(g, words)[:1])
+	case syntax.OpCapture:
+		g.writeRegexp(b, re.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			g.writeRegexp(b, sub)
+		}
+	case syntax.OpAlternate:
+		g.writeRegexp(b, pick(g, re.Sub))
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := 0, 3
+		switch re.Op {
+		case syntax.OpPlus:
+			min = 1
+		case syntax.OpQuest:
+			max = 1
+		case syntax.OpRepeat:
+			min, max = re.Min, re.Max
+			if max < 0 {
+				max = min + 3
+			}
+		}
+		for n := int(g.intn(int64(min), int64(max))); n > 0; n-- {
+			g.writeRegexp(b, re.Sub[0])
+		}
+	}
+}
+
+// fitLength pads or truncates a string to a length range, counted in characters
+func (g *Generator) fitLength(s string, min, max int) string {
+	for utf8.RuneCountInString(s) < min {
+		s += pick(g, words)
+	}
+	if max >= 0 && utf8.RuneCountInString(s) > max {
+		s = string([]rune(s)[:max])
+	}
+	return s
+}
+
+// byteString returns random bytes as a string of code points U+0000 to U+00FF, the JSON
+// representation of Avro bytes
+func (g *Generator) byteString(n int) string {
+	b := make([]byte, n)
+	g.rng.Read(b)
+	return bytesToString(b)
+}
+
+// bytesToString maps each byte to the code point with the same value
+func bytesToString(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
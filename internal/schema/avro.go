@@ -0,0 +1,122 @@
+package schema
+
+import (
+	"github.com/aywengo/ksr-cli/internal/avro"
+)
+
+// parseAvro builds a Tree from an Avro schema
+func parseAvro(content string, references []string) (*Tree, error) {
+	s, err := avro.ParseWithReferences(content, references)
+	if err != nil {
+		return nil, err
+	}
+	return AvroTree(s), nil
+}
+
+// AvroTree builds a Tree from a parsed Avro schema
+func AvroTree(s *avro.Schema) *Tree {
+	tree := &Tree{
+		Format: FormatAvro,
+		Kind:   avroKind(s),
+		Type:   s.String(),
+	}
+
+	if s.IsNamed() {
+		tree.Name = s.Name
+		tree.Namespace = s.Namespace
+		tree.Doc = s.Doc
+	}
+
+	visiting := map[string]bool{}
+	tree.Fields = avroChildren(s, "", visiting)
+	return tree
+}
+
+func avroKind(s *avro.Schema) Kind {
+	switch s.Type {
+	case avro.Record, avro.Error:
+		return KindRecord
+	case avro.Enum:
+		return KindEnum
+	case avro.Array:
+		return KindArray
+	case avro.Map:
+		return KindMap
+	case avro.Union:
+		return KindUnion
+	case avro.Fixed:
+		return KindFixed
+	default:
+		return KindPrimitive
+	}
+}
+
+// avroChildren returns the nodes nested in a type: the fields of records reachable
+// through arrays, maps and unions
+func avroChildren(s *avro.Schema, path string, visiting map[string]bool) []*Node {
+	switch s.Type {
+	case avro.Record, avro.Error:
+		if visiting[s.FullName()] {
+			return nil
+		}
+		visiting[s.FullName()] = true
+		defer delete(visiting, s.FullName())
+
+		nodes := make([]*Node, 0, len(s.Fields))
+		for _, field := range s.Fields {
+			nodes = append(nodes, avroField(field, joinPath(path, field.Name), visiting))
+		}
+		return nodes
+	case avro.Array:
+		return avroChildren(s.Items, path+"[]", visiting)
+	case avro.Map:
+		return avroChildren(s.Values, path+"{}", visiting)
+	case avro.Union:
+		nonNull := s.NonNull()
+		if nonNull != s {
+			return avroChildren(nonNull, path, visiting)
+		}
+		var nodes []*Node
+		for _, branch := range s.Types {
+			if branch.IsRecord() {
+				nodes = append(nodes, avroChildren(branch, joinPath(path, branch.Name), visiting)...)
+			}
+		}
+		return nodes
+	default:
+		return nil
+	}
+}
+
+func avroField(field *avro.Field, path string, visiting map[string]bool) *Node {
+	effective := field.Type.NonNull()
+
+	node := &Node{
+		Name:        field.Name,
+		Path:        path,
+		Kind:        avroKind(effective),
+		Type:        field.Type.String(),
+		Nullable:    field.Type.IsNullable(),
+		Required:    !field.HasDefault,
+		HasDefault:  field.HasDefault,
+		Default:     field.Default,
+		Doc:         field.Doc,
+		LogicalType: effective.LogicalType,
+	}
+
+	if effective.IsNamed() {
+		node.TypeName = effective.FullName()
+		node.Namespace = effective.Namespace
+	}
+	if effective.Type == avro.Enum {
+		node.Symbols = effective.Symbols
+	}
+
+	if effective.IsRecord() && visiting[effective.FullName()] {
+		node.Recursive = true
+		return node
+	}
+
+	node.Children = avroChildren(field.Type, path, visiting)
+	return node
+}
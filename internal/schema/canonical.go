@@ -0,0 +1,49 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/internal/avro"
+)
+
+// CanonicalForm returns a normalized form of schema content suitable for fingerprinting.
+// Avro schemas use the Parsing Canonical Form from the Avro specification, so schemas that
+// differ only in docs, defaults, aliases, attribute order or whitespace share a form. JSON
+// schemas are re-encoded compactly with sorted keys. Protobuf sources are compared as text
+// with surrounding whitespace removed.
+func CanonicalForm(schemaType, content string, references ...string) (string, error) {
+	format, err := ParseFormat(schemaType)
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case FormatAvro:
+		s, err := avro.ParseWithReferences(content, references)
+		if err != nil {
+			return "", err
+		}
+		return avro.CanonicalForm(s), nil
+	case FormatJSON:
+		decoder := json.NewDecoder(strings.NewReader(content))
+		decoder.UseNumber()
+
+		var raw interface{}
+		if err := decoder.Decode(&raw); err != nil {
+			return "", fmt.Errorf("invalid schema JSON: %w", err)
+		}
+
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(raw); err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(buf.String(), "\n"), nil
+	default:
+		return strings.TrimSpace(content), nil
+	}
+}
@@ -0,0 +1,153 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ChangeType classifies a structural change between two schemas
+type ChangeType string
+
+const (
+	ChangeAdded              ChangeType = "added"
+	ChangeRemoved            ChangeType = "removed"
+	ChangeTypeChanged        ChangeType = "type_changed"
+	ChangeNullabilityChanged ChangeType = "nullability_changed"
+	ChangeRequiredChanged    ChangeType = "required_changed"
+	ChangeDefaultAdded       ChangeType = "default_added"
+	ChangeDefaultRemoved     ChangeType = "default_removed"
+	ChangeDefaultChanged     ChangeType = "default_changed"
+	ChangeDocChanged         ChangeType = "doc_changed"
+	ChangeSymbolsChanged     ChangeType = "symbols_changed"
+	ChangeLogicalTypeChanged ChangeType = "logical_type_changed"
+	ChangeNameChanged        ChangeType = "name_changed"
+)
+
+// Change is a single structural difference at a field path
+type Change struct {
+	Change ChangeType `json:"change"`
+	Path   string     `json:"path"`
+	Before string     `json:"before,omitempty"`
+	After  string     `json:"after,omitempty"`
+}
+
+// Diff compares two schema trees field by field. Added and removed subtrees are
+// reported once at their root rather than for every nested field.
+func Diff(before, after *Tree) []Change {
+	changes := []Change{}
+
+	if before.Format != after.Format {
+		changes = append(changes, Change{Change: ChangeTypeChanged, Path: "<root>", Before: string(before.Format), After: string(after.Format)})
+	}
+	if qualifiedName(before) != qualifiedName(after) {
+		changes = append(changes, Change{Change: ChangeNameChanged, Path: "<root>", Before: qualifiedName(before), After: qualifiedName(after)})
+	}
+	if before.Type != after.Type && before.Kind != after.Kind {
+		changes = append(changes, Change{Change: ChangeTypeChanged, Path: "<root>", Before: before.Type, After: after.Type})
+	}
+	if before.Doc != after.Doc {
+		changes = append(changes, Change{Change: ChangeDocChanged, Path: "<root>", Before: before.Doc, After: after.Doc})
+	}
+
+	return append(changes, diffNodes(before.Fields, after.Fields)...)
+}
+
+func qualifiedName(t *Tree) string {
+	if t.Namespace == "" {
+		return t.Name
+	}
+	return t.Namespace + "." + t.Name
+}
+
+func diffNodes(before, after []*Node) []Change {
+	var changes []Change
+
+	afterByName := make(map[string]*Node, len(after))
+	for _, node := range after {
+		afterByName[node.Path] = node
+	}
+	beforeByName := make(map[string]*Node, len(before))
+	for _, node := range before {
+		beforeByName[node.Path] = node
+	}
+
+	for _, old := range before {
+		updated, ok := afterByName[old.Path]
+		if !ok {
+			changes = append(changes, Change{Change: ChangeRemoved, Path: old.Path, Before: old.Type})
+			continue
+		}
+		changes = append(changes, diffNode(old, updated)...)
+	}
+
+	for _, updated := range after {
+		if _, ok := beforeByName[updated.Path]; !ok {
+			changes = append(changes, Change{Change: ChangeAdded, Path: updated.Path, After: updated.Type})
+		}
+	}
+
+	return changes
+}
+
+func diffNode(before, after *Node) []Change {
+	var changes []Change
+	path := before.Path
+
+	if before.Type != after.Type {
+		changes = append(changes, Change{Change: ChangeTypeChanged, Path: path, Before: before.Type, After: after.Type})
+	} else if before.Nullable != after.Nullable {
+		changes = append(changes, Change{Change: ChangeNullabilityChanged, Path: path, Before: nullability(before.Nullable), After: nullability(after.Nullable)})
+	}
+
+	if before.LogicalType != after.LogicalType && before.Type == after.Type {
+		changes = append(changes, Change{Change: ChangeLogicalTypeChanged, Path: path, Before: before.LogicalType, After: after.LogicalType})
+	}
+
+	switch {
+	case before.HasDefault && !after.HasDefault:
+		changes = append(changes, Change{Change: ChangeDefaultRemoved, Path: path, Before: FormatValue(before.Default)})
+	case !before.HasDefault && after.HasDefault:
+		changes = append(changes, Change{Change: ChangeDefaultAdded, Path: path, After: FormatValue(after.Default)})
+	case before.HasDefault && FormatValue(before.Default) != FormatValue(after.Default):
+		changes = append(changes, Change{Change: ChangeDefaultChanged, Path: path, Before: FormatValue(before.Default), After: FormatValue(after.Default)})
+	}
+
+	// For Avro, required follows from the default, which is already reported
+	if before.Required != after.Required && before.HasDefault == after.HasDefault {
+		changes = append(changes, Change{Change: ChangeRequiredChanged, Path: path, Before: requiredness(before.Required), After: requiredness(after.Required)})
+	}
+
+	if strings.Join(before.Symbols, ",") != strings.Join(after.Symbols, ",") {
+		changes = append(changes, Change{Change: ChangeSymbolsChanged, Path: path, Before: strings.Join(before.Symbols, ","), After: strings.Join(after.Symbols, ",")})
+	}
+
+	if before.Doc != after.Doc {
+		changes = append(changes, Change{Change: ChangeDocChanged, Path: path, Before: before.Doc, After: after.Doc})
+	}
+
+	return append(changes, diffNodes(before.Children, after.Children)...)
+}
+
+// FormatValue renders a default value as compact JSON
+func FormatValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+func nullability(nullable bool) string {
+	if nullable {
+		return "nullable"
+	}
+	return "non-null"
+}
+
+func requiredness(required bool) string {
+	if required {
+		return "required"
+	}
+	return "optional"
+}
@@ -0,0 +1,140 @@
+package schema
+
+import (
+	"testing"
+)
+
+func TestDiff_Avro(t *testing.T) {
+	before := `{"type":"record","name":"User","fields":[
+		{"name":"id","type":"long"},
+		{"name":"name","type":"string","doc":"Full name"},
+		{"name":"age","type":"int","default":0},
+		{"name":"address","type":{"type":"record","name":"Address","fields":[{"name":"zip","type":"string"}]}},
+		{"name":"tags","type":{"type":"array","items":"string"}}
+	]}`
+	after := `{"type":"record","name":"User","fields":[
+		{"name":"id","type":"string"},
+		{"name":"name","type":"string","doc":"Display name"},
+		{"name":"age","type":"int","default":18},
+		{"name":"address","type":{"type":"record","name":"Address","fields":[{"name":"zip","type":"string"},{"name":"city","type":"string"}]}},
+		{"name":"email","type":["null","string"],"default":null}
+	]}`
+
+	beforeTree, err := Parse("AVRO", before)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	afterTree, err := Parse("AVRO", after)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []Change{
+		{Change: ChangeTypeChanged, Path: "id", Before: "long", After: "string"},
+		{Change: ChangeDocChanged, Path: "name", Before: "Full name", After: "Display name"},
+		{Change: ChangeDefaultChanged, Path: "age", Before: "0", After: "18"},
+		{Change: ChangeAdded, Path: "address.city", After: "string"},
+		{Change: ChangeRemoved, Path: "tags", Before: "array<string>"},
+		{Change: ChangeAdded, Path: "email", After: "union[null,string]"},
+	}
+
+	changes := Diff(beforeTree, afterTree)
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %d: %+v", len(expected), len(changes), changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("Change %d: expected %+v, got %+v", i, expected[i], changes[i])
+		}
+	}
+}
+
+func TestDiff_Identical(t *testing.T) {
+	tree, err := Parse("AVRO", `{"type":"record","name":"User","fields":[{"name":"id","type":"long"}]}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if changes := Diff(tree, tree); changes == nil || len(changes) != 0 {
+		t.Errorf("Expected an empty, non-nil list of changes, got %#v", changes)
+	}
+}
+
+func TestDiff_JSONSchema(t *testing.T) {
+	before := `{"type":"object","properties":{"id":{"type":"integer"},"name":{"type":"string"}},"required":["id"]}`
+	after := `{"type":"object","properties":{"id":{"type":"integer"},"name":{"type":["string","null"]}},"required":["id","name"]}`
+
+	beforeTree, err := Parse("JSON", before)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	afterTree, err := Parse("JSON", after)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	changes := Diff(beforeTree, afterTree)
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %d: %+v", len(changes), changes)
+	}
+	if changes[0].Change != ChangeNullabilityChanged || changes[1].Change != ChangeRequiredChanged {
+		t.Errorf("Expected nullability and required changes, got %+v", changes)
+	}
+}
+
+func TestParse_NestedPaths(t *testing.T) {
+	tree, err := Parse("AVRO", `{"type":"record","name":"Order","fields":[
+		{"name":"lines","type":{"type":"array","items":{"type":"record","name":"Line","fields":[{"name":"sku","type":"string"}]}}},
+		{"name":"next","type":["null","Order"],"default":null}
+	]}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	_, byPath := tree.Flatten()
+	if _, ok := byPath["lines[].sku"]; !ok {
+		t.Errorf("Expected nested array field path lines[].sku, got %v", byPath)
+	}
+	if next, ok := byPath["next"]; !ok || !next.Recursive {
+		t.Errorf("Expected recursive field 'next' to be marked recursive")
+	}
+}
+
+func TestDiff_Protobuf(t *testing.T) {
+	before := `syntax = "proto3";
+message Order {
+  string id = 1;
+  repeated Line lines = 2;
+  message Line { string sku = 1; }
+}`
+	after := `syntax = "proto3";
+message Order {
+  string id = 1;
+  repeated Line lines = 2;
+  optional string note = 3;
+  message Line { string sku = 1; int32 qty = 2; }
+}`
+
+	beforeTree, err := Parse("PROTOBUF", before)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	afterTree, err := Parse("PROTOBUF", after)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []Change{
+		{Change: ChangeAdded, Path: "lines[].qty", After: "int32"},
+		{Change: ChangeAdded, Path: "note", After: "string"},
+	}
+
+	changes := Diff(beforeTree, afterTree)
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %d: %+v", len(expected), len(changes), changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("Change %d: expected %+v, got %+v", i, expected[i], changes[i])
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Version is one registered version of a schema
+type Version struct {
+	Version int
+	ID      int
+	Tree    *Tree
+}
+
+// FieldEvent is a change to a field in a version
+type FieldEvent struct {
+	Version int        `json:"version"`
+	Change  ChangeType `json:"change"`
+	Before  string     `json:"before,omitempty"`
+	After   string     `json:"after,omitempty"`
+}
+
+// FieldHistory is the timeline of a field path across versions
+type FieldHistory struct {
+	Path string `json:"path"`
+	// Introduced is the version the field first appeared in
+	Introduced int `json:"introduced"`
+	// Removed is the version the field was last removed in, if it is not in the last version
+	Removed int `json:"removed,omitempty"`
+	// Type is the type of the field in the last version it appears in
+	Type   string       `json:"type"`
+	Events []FieldEvent `json:"events"`
+}
+
+// VersionChanges is the changelog of a version bump
+type VersionChanges struct {
+	Version  int      `json:"version"`
+	ID       int      `json:"id,omitempty"`
+	Previous int      `json:"previous,omitempty"`
+	Changes  []Change `json:"changes"`
+}
+
+// History is the evolution of a schema across its versions
+type History struct {
+	Fields   []*FieldHistory   `json:"fields"`
+	Versions []*VersionChanges `json:"versions"`
+}
+
+// BuildHistory computes the field timelines and the changelog of schema versions, oldest first
+func BuildHistory(versions []Version) *History {
+	h := &History{Fields: []*FieldHistory{}, Versions: []*VersionChanges{}}
+	byPath := map[string]*FieldHistory{}
+
+	var previous map[string]*Node
+	for i, v := range versions {
+		nodes, current := v.Tree.Flatten()
+
+		entry := &VersionChanges{Version: v.Version, ID: v.ID, Changes: []Change{}}
+		if i > 0 {
+			entry.Previous = versions[i-1].Version
+			entry.Changes = Diff(versions[i-1].Tree, v.Tree)
+		}
+		h.Versions = append(h.Versions, entry)
+
+		for _, node := range nodes {
+			field, ok := byPath[node.Path]
+			if !ok {
+				field = &FieldHistory{Path: node.Path, Introduced: v.Version}
+				byPath[node.Path] = field
+				h.Fields = append(h.Fields, field)
+			}
+			field.Type = node.Type
+
+			old, existed := previous[node.Path]
+			if !existed {
+				field.Removed = 0
+				field.Events = append(field.Events, FieldEvent{Version: v.Version, Change: ChangeAdded, After: node.Type})
+				continue
+			}
+			for _, change := range diffNode(old, node) {
+				// Changes to nested fields belong to their own timeline
+				if change.Path == node.Path {
+					field.Events = append(field.Events, FieldEvent{Version: v.Version, Change: change.Change, Before: change.Before, After: change.After})
+				}
+			}
+		}
+
+		for path, old := range previous {
+			if _, ok := current[path]; !ok {
+				field := byPath[path]
+				field.Removed = v.Version
+				field.Events = append(field.Events, FieldEvent{Version: v.Version, Change: ChangeRemoved, Before: old.Type})
+			}
+		}
+		previous = current
+	}
+
+	// Removals are found by map iteration; keep each timeline in version order
+	for _, field := range h.Fields {
+		events := field.Events
+		sort.SliceStable(events, func(i, j int) bool { return events[i].Version < events[j].Version })
+	}
+	return h
+}
+
+// Summary describes the events of a field timeline on one line, e.g.
+// "added v1, type changed v3, removed v5"
+func (f *FieldHistory) Summary() string {
+	parts := make([]string, 0, len(f.Events))
+	for _, e := range f.Events {
+		parts = append(parts, fmt.Sprintf("%s v%d", strings.ReplaceAll(string(e.Change), "_", " "), e.Version))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Describe writes a change as a sentence for changelogs
+func Describe(c Change) string {
+	subject := "field " + c.Path
+	if c.Path == "<root>" {
+		subject = "the schema"
+	}
+
+	switch c.Change {
+	case ChangeAdded:
+		return fmt.Sprintf("Added %s (%s)", subject, c.After)
+	case ChangeRemoved:
+		return fmt.Sprintf("Removed %s (%s)", subject, c.Before)
+	case ChangeTypeChanged:
+		return fmt.Sprintf("Changed type of %s from %s to %s", subject, c.Before, c.After)
+	case ChangeNullabilityChanged:
+		return fmt.Sprintf("Made %s %s", subject, c.After)
+	case ChangeRequiredChanged:
+		return fmt.Sprintf("Made %s %s", subject, c.After)
+	case ChangeDefaultAdded:
+		return fmt.Sprintf("Added default %s to %s", c.After, subject)
+	case ChangeDefaultRemoved:
+		return fmt.Sprintf("Removed default %s from %s", c.Before, subject)
+	case ChangeDefaultChanged:
+		return fmt.Sprintf("Changed default of %s from %s to %s", subject, c.Before, c.After)
+	case ChangeDocChanged:
+		if c.Before == "" {
+			return fmt.Sprintf("Documented %s", subject)
+		}
+		return fmt.Sprintf("Changed documentation of %s", subject)
+	case ChangeSymbolsChanged:
+		return fmt.Sprintf("Changed symbols of %s from [%s] to [%s]", subject, c.Before, c.After)
+	case ChangeLogicalTypeChanged:
+		return fmt.Sprintf("Changed logical type of %s from %s to %s", subject, orNone(c.Before), orNone(c.After))
+	case ChangeNameChanged:
+		return fmt.Sprintf("Renamed %s from %s to %s", subject, c.Before, c.After)
+	default:
+		return fmt.Sprintf("%s %s", c.Change, subject)
+	}
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
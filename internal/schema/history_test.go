@@ -0,0 +1,85 @@
+package schema
+
+import (
+	"testing"
+)
+
+func TestBuildHistory(t *testing.T) {
+	contents := []string{
+		`{"type":"record","name":"User","fields":[
+			{"name":"id","type":"long"},
+			{"name":"age","type":"int","default":0},
+			{"name":"nick","type":"string"}
+		]}`,
+		`{"type":"record","name":"User","fields":[
+			{"name":"id","type":"string"},
+			{"name":"age","type":"int","default":18},
+			{"name":"email","type":["null","string"],"default":null}
+		]}`,
+		`{"type":"record","name":"User","fields":[
+			{"name":"id","type":"string"},
+			{"name":"age","type":"int","default":18},
+			{"name":"nick","type":"string","default":""}
+		]}`,
+	}
+	var versions []Version
+	for i, content := range contents {
+		tree, err := Parse("AVRO", content)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		versions = append(versions, Version{Version: i + 1, ID: 10 + i, Tree: tree})
+	}
+
+	h := BuildHistory(versions)
+
+	summaries := map[string]string{}
+	for _, field := range h.Fields {
+		summaries[field.Path] = field.Summary()
+	}
+	for path, want := range map[string]string{
+		"id":    "added v1, type changed v2",
+		"age":   "added v1, default changed v2",
+		"nick":  "added v1, removed v2, added v3",
+		"email": "added v2, removed v3",
+	} {
+		if summaries[path] != want {
+			t.Errorf("Expected %s: %s, got %s", path, want, summaries[path])
+		}
+	}
+
+	byPath := map[string]*FieldHistory{}
+	for _, field := range h.Fields {
+		byPath[field.Path] = field
+	}
+	if email := byPath["email"]; email.Introduced != 2 || email.Removed != 3 || email.Type != "union[null,string]" {
+		t.Errorf("Expected email introduced in v2 and removed in v3, got %+v", email)
+	}
+	if nick := byPath["nick"]; nick.Introduced != 1 || nick.Removed != 0 {
+		t.Errorf("Expected nick introduced in v1 and present in the last version, got %+v", nick)
+	}
+
+	if len(h.Versions) != 3 || len(h.Versions[0].Changes) != 0 || h.Versions[2].Previous != 2 || h.Versions[2].ID != 12 {
+		t.Fatalf("Unexpected version changelog: %+v", h.Versions)
+	}
+	if len(h.Versions[1].Changes) != 4 {
+		t.Errorf("Expected 4 changes in v2, got %+v", h.Versions[1].Changes)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	for _, tt := range []struct {
+		change   Change
+		expected string
+	}{
+		{Change{Change: ChangeAdded, Path: "email", After: "string"}, "Added field email (string)"},
+		{Change{Change: ChangeTypeChanged, Path: "id", Before: "long", After: "string"}, "Changed type of field id from long to string"},
+		{Change{Change: ChangeDefaultChanged, Path: "age", Before: "0", After: "18"}, "Changed default of field age from 0 to 18"},
+		{Change{Change: ChangeNameChanged, Path: "<root>", Before: "User", After: "Person"}, "Renamed the schema from User to Person"},
+		{Change{Change: ChangeLogicalTypeChanged, Path: "at", After: "timestamp-millis"}, "Changed logical type of field at from none to timestamp-millis"},
+	} {
+		if got := Describe(tt.change); got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, got)
+		}
+	}
+}
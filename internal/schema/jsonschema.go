@@ -0,0 +1,257 @@
+package schema
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/internal/jsonschema"
+)
+
+// parseJSONSchema builds a Tree from a JSON Schema document
+func parseJSONSchema(content string) (*Tree, error) {
+	doc, err := jsonschema.Parse(content)
+	if err != nil {
+		return nil, err
+	}
+	return JSONSchemaTree(doc), nil
+}
+
+// JSONSchemaTree builds a Tree from a parsed JSON Schema document
+func JSONSchemaTree(doc *jsonschema.Document) *Tree {
+	b := &jsonTreeBuilder{doc: doc, visiting: map[string]bool{}, describing: map[string]bool{}}
+
+	root, _ := doc.Resolve(doc.Root)
+	rootObj, _ := root.(map[string]interface{})
+
+	info := b.describe(doc.Root)
+	tree := &Tree{
+		Format: FormatJSON,
+		Kind:   info.kind,
+		Type:   info.typeName,
+	}
+	if rootObj != nil {
+		tree.Name, _ = rootObj["title"].(string)
+		tree.Doc, _ = rootObj["description"].(string)
+	}
+
+	tree.Fields = b.children(doc.Root, "")
+	return tree
+}
+
+type jsonTreeBuilder struct {
+	doc        *jsonschema.Document
+	visiting   map[string]bool
+	describing map[string]bool
+}
+
+// jsonTypeInfo summarizes the type of a JSON Schema node
+type jsonTypeInfo struct {
+	kind     Kind
+	typeName string
+	named    string
+	ref      string
+	nullable bool
+	format   string
+	symbols  []string
+	schema   map[string]interface{}
+}
+
+// describe resolves references and nullable combinations to determine a node's type
+func (b *jsonTreeBuilder) describe(raw interface{}) jsonTypeInfo {
+	resolved, ref := b.doc.Resolve(raw)
+	obj, _ := resolved.(map[string]interface{})
+	info := jsonTypeInfo{kind: KindAny, typeName: "any", schema: obj}
+	if obj == nil {
+		return info
+	}
+
+	if ref != "" {
+		info.ref = ref
+		info.named = ref[strings.LastIndex(ref, "/")+1:]
+
+		// A type defined in terms of itself is named rather than expanded
+		if b.describing[ref] {
+			info.typeName = info.named
+			return info
+		}
+		b.describing[ref] = true
+		defer delete(b.describing, ref)
+	}
+
+	// oneOf/anyOf of null and a single schema is an optional value
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if branches, ok := obj[keyword].([]interface{}); ok {
+			var nonNull []interface{}
+			for _, branch := range branches {
+				if branchObj, ok := branch.(map[string]interface{}); ok && branchObj["type"] == "null" {
+					info.nullable = true
+					continue
+				}
+				nonNull = append(nonNull, branch)
+			}
+			if len(nonNull) == 1 {
+				inner := b.describe(nonNull[0])
+				inner.nullable = inner.nullable || info.nullable
+				return inner
+			}
+			names := make([]string, 0, len(branches))
+			for _, branch := range branches {
+				names = append(names, b.describe(branch).typeName)
+			}
+			info.kind = KindUnion
+			info.typeName = keyword + "[" + strings.Join(names, ",") + "]"
+			return info
+		}
+	}
+
+	var types []string
+	switch v := obj["type"].(type) {
+	case string:
+		types = []string{v}
+	case []interface{}:
+		for _, t := range v {
+			if s, ok := t.(string); ok {
+				types = append(types, s)
+			}
+		}
+	}
+
+	var nonNullTypes []string
+	for _, t := range types {
+		if t == "null" {
+			info.nullable = true
+		} else {
+			nonNullTypes = append(nonNullTypes, t)
+		}
+	}
+
+	info.format, _ = obj["format"].(string)
+
+	if symbols, ok := obj["enum"].([]interface{}); ok {
+		info.kind = KindEnum
+		info.typeName = "enum"
+		for _, symbol := range symbols {
+			if s, ok := symbol.(string); ok {
+				info.symbols = append(info.symbols, s)
+			}
+		}
+		return info
+	}
+
+	switch {
+	case len(nonNullTypes) > 1:
+		info.kind = KindUnion
+		info.typeName = strings.Join(nonNullTypes, "|")
+	case len(nonNullTypes) == 1 && nonNullTypes[0] == "object":
+		if _, ok := obj["properties"]; !ok && isSchemaObject(obj["additionalProperties"]) {
+			info.kind = KindMap
+			info.typeName = "map<" + b.describe(obj["additionalProperties"]).typeName + ">"
+		} else {
+			info.kind = KindRecord
+			info.typeName = "object"
+		}
+	case len(nonNullTypes) == 1 && nonNullTypes[0] == "array":
+		info.kind = KindArray
+		info.typeName = "array<" + b.describe(obj["items"]).typeName + ">"
+	case len(nonNullTypes) == 1:
+		info.kind = KindPrimitive
+		info.typeName = nonNullTypes[0]
+	case obj["properties"] != nil:
+		info.kind = KindRecord
+		info.typeName = "object"
+	}
+
+	if info.named != "" && info.kind == KindRecord {
+		info.typeName = info.named
+	}
+	return info
+}
+
+// children returns the property nodes nested in a schema, descending into array items,
+// map values and nullable wrappers
+func (b *jsonTreeBuilder) children(raw interface{}, path string) []*Node {
+	info := b.describe(raw)
+	if info.ref != "" {
+		if b.visiting[info.ref] {
+			return nil
+		}
+		b.visiting[info.ref] = true
+		defer delete(b.visiting, info.ref)
+	}
+
+	obj := info.schema
+	if obj == nil {
+		return nil
+	}
+
+	switch info.kind {
+	case KindArray:
+		return b.children(obj["items"], path+"[]")
+	case KindMap:
+		return b.children(obj["additionalProperties"], path+"{}")
+	case KindRecord:
+	default:
+		return nil
+	}
+
+	properties, _ := obj["properties"].(map[string]interface{})
+	required := map[string]bool{}
+	if list, ok := obj["required"].([]interface{}); ok {
+		for _, name := range list {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	nodes := make([]*Node, 0, len(names))
+	for _, name := range names {
+		nodes = append(nodes, b.property(name, properties[name], joinPath(path, name), required[name]))
+	}
+	return nodes
+}
+
+func (b *jsonTreeBuilder) property(name string, raw interface{}, path string, required bool) *Node {
+	info := b.describe(raw)
+	node := &Node{
+		Name:        name,
+		Path:        path,
+		Kind:        info.kind,
+		Type:        info.typeName,
+		TypeName:    info.named,
+		Nullable:    info.nullable,
+		Required:    required,
+		LogicalType: info.format,
+		Symbols:     info.symbols,
+	}
+
+	if obj, ok := raw.(map[string]interface{}); ok {
+		node.Doc, _ = obj["description"].(string)
+		if def, ok := obj["default"]; ok {
+			node.Default = def
+			node.HasDefault = true
+		}
+	}
+	if node.Doc == "" && info.schema != nil {
+		node.Doc, _ = info.schema["description"].(string)
+	}
+
+	if info.ref != "" && b.visiting[info.ref] {
+		node.Recursive = true
+		return node
+	}
+
+	node.Children = b.children(raw, path)
+	return node
+}
+
+func isSchemaObject(v interface{}) bool {
+	_, ok := v.(map[string]interface{})
+	return ok
+}
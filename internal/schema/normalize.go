@@ -0,0 +1,36 @@
+package schema
+
+import (
+	"github.com/aywengo/ksr-cli/internal/avro"
+	"github.com/aywengo/ksr-cli/internal/jsonschema"
+	"github.com/aywengo/ksr-cli/internal/protobuf"
+)
+
+// Normalize returns schema content in the standard layout for its format, after checking
+// that it parses. Avro named types may come from references. Protobuf imports are not
+// resolved, so a .proto file can be formatted on its own.
+func Normalize(schemaType, content string, references ...string) (string, error) {
+	format, err := ParseFormat(schemaType)
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case FormatAvro:
+		if _, err := avro.ParseWithReferences(content, references); err != nil {
+			return "", err
+		}
+		return avro.Format(content)
+	case FormatJSON:
+		if _, err := jsonschema.Parse(content); err != nil {
+			return "", err
+		}
+		return jsonschema.Format(content)
+	default:
+		f, err := protobuf.Parse(content)
+		if err != nil {
+			return "", err
+		}
+		return protobuf.Format(f), nil
+	}
+}
@@ -0,0 +1,116 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/aywengo/ksr-cli/internal/protobuf"
+)
+
+// parseProtobuf builds a Tree from a Protobuf schema
+func parseProtobuf(content string, references []string) (*Tree, error) {
+	f, registry, err := protobuf.ParseWithReferences(content, references)
+	if err != nil {
+		return nil, err
+	}
+	return ProtobufTree(f, registry)
+}
+
+// ProtobufTree builds a Tree from the first message of a .proto file, which is the
+// message Schema Registry serializers use by default
+func ProtobufTree(f *protobuf.File, registry *protobuf.Registry) (*Tree, error) {
+	if len(f.Messages) == 0 {
+		return nil, fmt.Errorf("schema defines no messages")
+	}
+	return ProtobufMessageTree(f.Messages[0], f.Package, registry), nil
+}
+
+// ProtobufMessageTree builds a Tree from a message
+func ProtobufMessageTree(m *protobuf.Message, pkg string, registry *protobuf.Registry) *Tree {
+	b := &protoTreeBuilder{registry: registry, visiting: map[string]bool{}}
+	return &Tree{
+		Format:    FormatProtobuf,
+		Name:      m.Name,
+		Namespace: pkg,
+		Doc:       m.Doc(),
+		Kind:      KindRecord,
+		Type:      m.FullName,
+		Fields:    b.children(m, ""),
+	}
+}
+
+type protoTreeBuilder struct {
+	registry *protobuf.Registry
+	visiting map[string]bool
+}
+
+func (b *protoTreeBuilder) children(m *protobuf.Message, path string) []*Node {
+	if b.visiting[m.FullName] {
+		return nil
+	}
+	b.visiting[m.FullName] = true
+	defer delete(b.visiting, m.FullName)
+
+	nodes := make([]*Node, 0, len(m.Fields))
+	for _, field := range m.Fields {
+		nodes = append(nodes, b.field(field, joinPath(path, field.Name)))
+	}
+	return nodes
+}
+
+func (b *protoTreeBuilder) field(field *protobuf.Field, path string) *Node {
+	message, enum, _ := b.registry.ResolveField(field)
+
+	elementKind := KindPrimitive
+	elementType := field.Type
+	switch {
+	case message != nil:
+		elementKind = KindRecord
+		elementType = message.FullName
+	case enum != nil:
+		elementKind = KindEnum
+		elementType = enum.FullName
+	}
+
+	node := &Node{
+		Name:     field.Name,
+		Path:     path,
+		Kind:     elementKind,
+		Type:     elementType,
+		Nullable: field.Label == "optional" || field.Oneof != nil || (message != nil && protobuf.IsWrapperType(message.FullName)),
+		Required: field.Label == "required",
+		Doc:      field.Doc(),
+	}
+	if value, ok := field.Option("default"); ok {
+		node.HasDefault = true
+		node.Default = value
+	}
+	if message != nil || enum != nil {
+		node.TypeName = elementType
+	}
+	if enum != nil {
+		for _, value := range enum.Values {
+			node.Symbols = append(node.Symbols, value.Name)
+		}
+	}
+
+	childPath := path
+	switch {
+	case field.IsMap:
+		node.Kind = KindMap
+		node.Type = fmt.Sprintf("map<%s,%s>", field.KeyType, elementType)
+		childPath = path + "{}"
+	case field.Label == "repeated":
+		node.Kind = KindArray
+		node.Type = fmt.Sprintf("array<%s>", elementType)
+		childPath = path + "[]"
+	}
+
+	if message != nil && !protobuf.IsWellKnownType(message.FullName) {
+		if b.visiting[message.FullName] {
+			node.Recursive = true
+			return node
+		}
+		node.Children = b.children(message, childPath)
+	}
+	return node
+}
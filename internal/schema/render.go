@@ -0,0 +1,123 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ANSI styles used when rendering with color
+const (
+	ansiReset  = "\033[0m"
+	ansiBold   = "\033[1m"
+	ansiDim    = "\033[2m"
+	ansiCyan   = "\033[36m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+)
+
+// RenderOptions controls how a tree is rendered as text
+type RenderOptions struct {
+	// MaxDepth collapses fields nested deeper than this many levels; 0 expands every level
+	MaxDepth int
+	// Details adds defaults, requiredness, enum symbols and docs to each field
+	Details bool
+	// Color emphasizes names, types and annotations with ANSI escapes
+	Color bool
+}
+
+// Render draws the fields of a tree as an indented tree, one field per line:
+//
+//	├── id: string(uuid)  # Order id
+//	└── address: union[null,Address] (nullable, default null)
+//	    └── zip: string
+func (t *Tree) Render(opts RenderOptions) string {
+	return renderNodes(t.Fields, "", 1, t.Format, opts)
+}
+
+// Header describes the root of a tree: its name, kind, format and doc
+func (t *Tree) Header(opts RenderOptions) string {
+	name := qualifiedName(t)
+	if name == "" {
+		name = t.Type
+	}
+	header := style(opts.Color, ansiBold, name) + " " + style(opts.Color, ansiDim, fmt.Sprintf("(%s %s)", t.Format, t.Kind))
+	if opts.Details && t.Doc != "" {
+		header += "  " + style(opts.Color, ansiDim, "# "+t.Doc)
+	}
+	return header
+}
+
+// RenderNodes draws nodes at the given depth, prefixing every line with prefix
+func RenderNodes(nodes []*Node, prefix string, depth int, opts RenderOptions) string {
+	return renderNodes(nodes, prefix, depth, "", opts)
+}
+
+func renderNodes(nodes []*Node, prefix string, depth int, format Format, opts RenderOptions) string {
+	var sb strings.Builder
+	for i, node := range nodes {
+		branch, indent := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, indent = "└── ", "    "
+		}
+		sb.WriteString(prefix + branch + style(opts.Color, ansiBold, node.Name) + ": " + style(opts.Color, ansiCyan, node.Type))
+		if notes := annotations(node, format, opts.Details); len(notes) > 0 {
+			sb.WriteString(" " + style(opts.Color, ansiYellow, "("+strings.Join(notes, ", ")+")"))
+		}
+		collapsed := len(node.Children) > 0 && opts.MaxDepth > 0 && depth >= opts.MaxDepth
+		if collapsed {
+			sb.WriteString(" " + style(opts.Color, ansiDim, fmt.Sprintf("[+%d nested]", countNodes(node.Children))))
+		}
+		if opts.Details && node.Doc != "" {
+			sb.WriteString("  " + style(opts.Color, ansiGreen, "# "+singleLine(node.Doc)))
+		}
+		sb.WriteString("\n")
+		if !collapsed {
+			sb.WriteString(renderNodes(node.Children, prefix+indent, depth+1, format, opts))
+		}
+	}
+	return sb.String()
+}
+
+// annotations lists the properties of a node shown after its type
+func annotations(node *Node, format Format, details bool) []string {
+	var notes []string
+	if node.Nullable {
+		notes = append(notes, "nullable")
+	}
+	if details {
+		// Avro marks every field without a default as required, which says nothing new
+		if node.Required && format != FormatAvro && format != "" {
+			notes = append(notes, "required")
+		}
+		if node.HasDefault {
+			notes = append(notes, "default "+FormatValue(node.Default))
+		}
+		if len(node.Symbols) > 0 {
+			notes = append(notes, "symbols "+strings.Join(node.Symbols, "|"))
+		}
+	}
+	if node.Recursive {
+		notes = append(notes, "recursive")
+	}
+	return notes
+}
+
+// countNodes counts nodes and their nested nodes
+func countNodes(nodes []*Node) int {
+	count := len(nodes)
+	for _, node := range nodes {
+		count += countNodes(node.Children)
+	}
+	return count
+}
+
+func style(color bool, code, text string) string {
+	if !color {
+		return text
+	}
+	return code + text + ansiReset
+}
+
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,47 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tree, err := Parse("AVRO", `{"type":"record","name":"Order","namespace":"com.acme","doc":"An order","fields":[
+		{"name":"id","type":{"type":"string","logicalType":"uuid"},"doc":"Order id"},
+		{"name":"status","type":{"type":"enum","name":"Status","symbols":["NEW","DONE"]},"default":"NEW"},
+		{"name":"address","type":["null",{"type":"record","name":"Address","fields":[{"name":"zip","type":"string"}]}],"default":null}
+	]}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `├── id: string(uuid)  # Order id
+├── status: com.acme.Status (default "NEW", symbols NEW|DONE)
+└── address: union[null,com.acme.Address] (nullable, default null)
+    └── zip: string
+`
+	if got := tree.Render(RenderOptions{Details: true}); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+	if got := tree.Header(RenderOptions{Details: true}); got != "com.acme.Order (AVRO record)  # An order" {
+		t.Errorf("Unexpected header %q", got)
+	}
+
+	collapsed := tree.Render(RenderOptions{MaxDepth: 1})
+	if !strings.Contains(collapsed, "└── address: union[null,com.acme.Address] (nullable) [+1 nested]\n") || strings.Contains(collapsed, "#") {
+		t.Errorf("Expected collapsed fields without details, got:\n%s", collapsed)
+	}
+	if colored := tree.Render(RenderOptions{Color: true}); !strings.Contains(colored, ansiCyan+"string(uuid)"+ansiReset) {
+		t.Errorf("Expected colored types, got %q", colored)
+	}
+}
+
+func TestRenderRequired(t *testing.T) {
+	tree, err := Parse("JSON", `{"type":"object","required":["name"],"properties":{"name":{"type":"string"}}}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := tree.Render(RenderOptions{Details: true}); got != "└── name: string (required)\n" {
+		t.Errorf("Unexpected tree %q", got)
+	}
+}
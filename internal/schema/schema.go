@@ -0,0 +1,132 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Format identifies a schema format as named by Schema Registry
+type Format string
+
+const (
+	FormatAvro     Format = "AVRO"
+	FormatJSON     Format = "JSON"
+	FormatProtobuf Format = "PROTOBUF"
+)
+
+// ParseFormat normalizes a schema type name; an empty type means Avro, as in Schema Registry
+func ParseFormat(schemaType string) (Format, error) {
+	switch strings.ToUpper(schemaType) {
+	case "", "AVRO":
+		return FormatAvro, nil
+	case "JSON":
+		return FormatJSON, nil
+	case "PROTOBUF":
+		return FormatProtobuf, nil
+	default:
+		return "", fmt.Errorf("unsupported schema type: %s", schemaType)
+	}
+}
+
+// Kind classifies a node in a schema tree
+type Kind string
+
+const (
+	KindPrimitive Kind = "primitive"
+	KindRecord    Kind = "record"
+	KindEnum      Kind = "enum"
+	KindArray     Kind = "array"
+	KindMap       Kind = "map"
+	KindUnion     Kind = "union"
+	KindFixed     Kind = "fixed"
+	KindAny       Kind = "any"
+)
+
+// Tree is a format-independent view of a schema: the root type and its fields
+type Tree struct {
+	Format    Format  `json:"format"`
+	Name      string  `json:"name,omitempty"`
+	Namespace string  `json:"namespace,omitempty"`
+	Doc       string  `json:"doc,omitempty"`
+	Kind      Kind    `json:"kind"`
+	Type      string  `json:"type"`
+	Fields    []*Node `json:"fields,omitempty"`
+}
+
+// Node is a field in a schema tree. Nested records contribute child nodes whose
+// paths extend the parent's path ("address.street", "items[].sku", "tags{}.value").
+type Node struct {
+	Name        string      `json:"name"`
+	Path        string      `json:"path"`
+	Kind        Kind        `json:"kind"`
+	Type        string      `json:"type"`
+	TypeName    string      `json:"type_name,omitempty"`
+	Namespace   string      `json:"namespace,omitempty"`
+	Nullable    bool        `json:"nullable,omitempty"`
+	Required    bool        `json:"required,omitempty"`
+	HasDefault  bool        `json:"has_default,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+	Doc         string      `json:"doc,omitempty"`
+	LogicalType string      `json:"logical_type,omitempty"`
+	Symbols     []string    `json:"symbols,omitempty"`
+	Recursive   bool        `json:"recursive,omitempty"`
+	Children    []*Node     `json:"children,omitempty"`
+}
+
+// Parse parses schema content of the given type into a Tree. References holds the
+// contents of referenced schemas, which define named types used by the schema.
+func Parse(schemaType, content string, references ...string) (*Tree, error) {
+	format, err := ParseFormat(schemaType)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatAvro:
+		return parseAvro(content, references)
+	case FormatJSON:
+		return parseJSONSchema(content)
+	default:
+		return parseProtobuf(content, references)
+	}
+}
+
+// Validate checks that schema content of the given type is well formed and that every
+// type it uses is defined by the schema, its references or, for Protobuf, the well-known types
+func Validate(schemaType, content string, references ...string) error {
+	_, err := Parse(schemaType, content, references...)
+	return err
+}
+
+// Walk visits every node in depth-first order. Returning false from fn skips the node's children.
+func (t *Tree) Walk(fn func(node *Node, depth int) bool) {
+	var walk func(nodes []*Node, depth int)
+	walk = func(nodes []*Node, depth int) {
+		for _, node := range nodes {
+			if fn(node, depth) {
+				walk(node.Children, depth+1)
+			}
+		}
+	}
+	walk(t.Fields, 1)
+}
+
+// Flatten returns every node of the tree keyed by path
+func (t *Tree) Flatten() ([]*Node, map[string]*Node) {
+	var nodes []*Node
+	byPath := map[string]*Node{}
+	t.Walk(func(node *Node, depth int) bool {
+		nodes = append(nodes, node)
+		byPath[node.Path] = node
+		return true
+	})
+	return nodes, byPath
+}
+
+// joinPath builds a child path
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
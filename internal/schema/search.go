@@ -0,0 +1,169 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Query selects schema nodes. Every predicate that is set must match. Values are
+// compared case-insensitively, exactly for names and as a substring for docs, unless
+// Regex is set, in which case they are regular expressions matching anywhere in the
+// value unless anchored with ^ and $.
+type Query struct {
+	// Field matches the name of a field, or its path when the value contains a dot. Paths
+	// are compared without the [] and {} markers of arrays and maps, so address.zip and
+	// items.sku match address.zip and items[].sku. A regular expression is matched against
+	// both the name and the path.
+	Field string
+	// Type matches the type of a field or any named type it uses, e.g. com.acme.Money or long
+	Type string
+	// Namespace matches the namespace of the types a node defines or uses
+	Namespace string
+	// Doc matches the documentation of a field or of the schema
+	Doc   string
+	Regex bool
+}
+
+// Match is a node selected by a query
+type Match struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+	Doc  string `json:"doc,omitempty"`
+}
+
+// Matcher evaluates a compiled query
+type Matcher struct {
+	field, typ, namespace, doc func(string) bool
+	fieldPath, regex           bool
+}
+
+// Compile checks a query and prepares its predicates
+func (q *Query) Compile() (*Matcher, error) {
+	if q.Field == "" && q.Type == "" && q.Namespace == "" && q.Doc == "" {
+		return nil, fmt.Errorf("at least one of field, type, namespace or doc is required")
+	}
+
+	m := &Matcher{fieldPath: !q.Regex && strings.Contains(q.Field, "."), regex: q.Regex}
+	field := q.Field
+	if m.fieldPath {
+		field = stripPathMarkers(field)
+	}
+	for _, p := range []struct {
+		value     string
+		substring bool
+		target    *func(string) bool
+	}{
+		{field, false, &m.field},
+		{q.Type, false, &m.typ},
+		{q.Namespace, false, &m.namespace},
+		{q.Doc, true, &m.doc},
+	} {
+		if p.value == "" {
+			continue
+		}
+		predicate, err := compilePredicate(p.value, p.substring, q.Regex)
+		if err != nil {
+			return nil, err
+		}
+		*p.target = predicate
+	}
+	return m, nil
+}
+
+func compilePredicate(value string, substring, regex bool) (func(string) bool, error) {
+	if regex {
+		re, err := regexp.Compile("(?i)" + value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", value, err)
+		}
+		return re.MatchString, nil
+	}
+	value = strings.ToLower(value)
+	if substring {
+		return func(s string) bool { return strings.Contains(strings.ToLower(s), value) }, nil
+	}
+	return func(s string) bool { return strings.ToLower(s) == value }, nil
+}
+
+// Search returns the nodes of a tree a query selects. The schema itself is reported at
+// path <root> for type, namespace and doc queries.
+func (m *Matcher) Search(tree *Tree) []Match {
+	var matches []Match
+
+	if m.field == nil {
+		name := qualifiedName(tree)
+		root := &Node{Path: "<root>", Type: tree.Type, TypeName: name, Namespace: tree.Namespace, Doc: tree.Doc}
+		if m.matches(root) {
+			matches = append(matches, Match{Path: root.Path, Type: root.Type, Doc: root.Doc})
+		}
+	}
+
+	tree.Walk(func(node *Node, depth int) bool {
+		if m.matches(node) {
+			matches = append(matches, Match{Path: node.Path, Type: node.Type, Doc: node.Doc})
+		}
+		return true
+	})
+	return matches
+}
+
+func (m *Matcher) matches(node *Node) bool {
+	if m.field != nil {
+		targets := []string{node.Name}
+		switch {
+		case m.regex:
+			targets = append(targets, stripPathMarkers(node.Path))
+		case m.fieldPath:
+			targets = []string{stripPathMarkers(node.Path)}
+		}
+		if node.Path == "<root>" || !anyMatch(m.field, targets) {
+			return false
+		}
+	}
+	if m.typ != nil && !anyMatch(m.typ, append(typeNames(node), node.Type, node.LogicalType)) {
+		return false
+	}
+	if m.namespace != nil {
+		namespaces := []string{node.Namespace}
+		for _, name := range typeNames(node) {
+			if i := strings.LastIndex(name, "."); i > 0 {
+				namespaces = append(namespaces, name[:i])
+			}
+		}
+		if !anyMatch(m.namespace, namespaces) {
+			return false
+		}
+	}
+	if m.doc != nil && (node.Doc == "" || !m.doc(node.Doc)) {
+		return false
+	}
+	return true
+}
+
+// stripPathMarkers removes the [] and {} markers of array items and map values from a
+// field path, leaving the field names
+func stripPathMarkers(path string) string {
+	return strings.NewReplacer("[]", "", "{}", "").Replace(path)
+}
+
+// typeNames splits the type of a node into the type names it mentions, so that
+// array<com.acme.Money> and union[null,com.acme.Money] both use com.acme.Money
+func typeNames(node *Node) []string {
+	names := strings.FieldsFunc(node.Type, func(r rune) bool {
+		return strings.ContainsRune("[]<>{}(),| ", r)
+	})
+	if node.TypeName != "" {
+		names = append(names, node.TypeName)
+	}
+	return names
+}
+
+func anyMatch(predicate func(string) bool, values []string) bool {
+	for _, value := range values {
+		if value != "" && predicate(value) {
+			return true
+		}
+	}
+	return false
+}
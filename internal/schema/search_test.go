@@ -0,0 +1,62 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	tree, err := Parse("AVRO", `{"type":"record","name":"Order","namespace":"com.acme.orders","doc":"A customer order","fields":[
+		{"name":"customer_id","type":"string","doc":"Customer identifier"},
+		{"name":"total","type":{"type":"record","name":"Money","namespace":"com.acme","fields":[
+			{"name":"cents","type":"long"},
+			{"name":"currency","type":"string"}
+		]}},
+		{"name":"refunds","type":{"type":"array","items":"com.acme.Money"}},
+		{"name":"created","type":{"type":"long","logicalType":"timestamp-millis"}}
+	]}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		query    Query
+		expected string
+	}{
+		{"field name", Query{Field: "Customer_ID"}, "customer_id"},
+		{"field path", Query{Field: "total.cents"}, "total.cents"},
+		{"named type in arrays", Query{Type: "com.acme.Money"}, "total refunds"},
+		{"primitive type", Query{Type: "long"}, "total.cents refunds[].cents created"},
+		{"logical type", Query{Type: "timestamp-millis"}, "created"},
+		{"namespace", Query{Namespace: "com.acme.orders"}, "<root>"},
+		{"doc substring", Query{Doc: "customer"}, "<root> customer_id"},
+		{"regex", Query{Field: "^cur|^cen", Regex: true}, "total.cents total.currency refunds[].cents refunds[].currency"},
+		{"path through an array", Query{Field: "refunds.cents"}, "refunds[].cents"},
+		{"path with markers", Query{Field: "Refunds[].Currency"}, "refunds[].currency"},
+		{"regex on paths", Query{Field: `^refunds\.cur`, Regex: true}, "refunds[].currency"},
+		{"combined", Query{Field: "total", Type: "com.acme.Money"}, "total"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := tt.query.Compile()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var paths []string
+			for _, match := range m.Search(tree) {
+				paths = append(paths, match.Path)
+			}
+			if got := strings.Join(paths, " "); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	if _, err := (&Query{}).Compile(); err == nil {
+		t.Error("Expected error for an empty query")
+	}
+	if _, err := (&Query{Field: "(", Regex: true}).Compile(); err == nil {
+		t.Error("Expected error for an invalid regular expression")
+	}
+}
@@ -0,0 +1,180 @@
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// opKind is the kind of an edit operation on a line
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	line string
+	// 1-based line numbers in the old and new text
+	oldLine, newLine int
+}
+
+// Lines splits text into lines without trailing newline characters
+func Lines(text string) []string {
+	text = strings.TrimRight(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// edits computes a minimal line edit script using the longest common subsequence
+func edits(a, b []string) []op {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []op
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			ops = append(ops, op{kind: opEqual, line: a[i], oldLine: i + 1, newLine: j + 1})
+			i++
+			j++
+		case j < m && (i == n || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, op{kind: opInsert, line: b[j], oldLine: i, newLine: j + 1})
+			j++
+		default:
+			ops = append(ops, op{kind: opDelete, line: a[i], oldLine: i + 1, newLine: j})
+			i++
+		}
+	}
+	return ops
+}
+
+// Unified renders a unified diff between two texts with the given number of context lines.
+// It returns an empty string when the texts are identical.
+func Unified(oldName, newName, oldText, newText string, context int) string {
+	ops := edits(Lines(oldText), Lines(newText))
+
+	changed := false
+	for _, o := range ops {
+		if o.kind != opEqual {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+
+	for start := 0; start < len(ops); {
+		// Find the next change
+		first := start
+		for first < len(ops) && ops[first].kind == opEqual {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+
+		// Extend the hunk while changes are within 2*context lines of each other
+		hunkStart := max(first-context, start)
+		last := first
+		for k := first; k < len(ops); k++ {
+			if ops[k].kind != opEqual {
+				last = k
+			} else if k-last > 2*context {
+				break
+			}
+		}
+		hunkEnd := min(last+context+1, len(ops))
+
+		writeHunk(&sb, ops[hunkStart:hunkEnd])
+		start = hunkEnd
+	}
+
+	return sb.String()
+}
+
+func writeHunk(sb *strings.Builder, ops []op) {
+	oldStart, newStart := 0, 0
+	oldCount, newCount := 0, 0
+	for _, o := range ops {
+		switch o.kind {
+		case opEqual:
+			oldCount++
+			newCount++
+		case opDelete:
+			oldCount++
+		case opInsert:
+			newCount++
+		}
+	}
+
+	for _, o := range ops {
+		if o.kind != opInsert && oldStart == 0 {
+			oldStart = o.oldLine
+		}
+		if o.kind != opDelete && newStart == 0 {
+			newStart = o.newLine
+		}
+	}
+	if oldStart == 0 {
+		oldStart = ops[0].oldLine
+	}
+	if newStart == 0 {
+		newStart = ops[0].newLine
+	}
+
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+	for _, o := range ops {
+		switch o.kind {
+		case opEqual:
+			sb.WriteString(" " + o.line + "\n")
+		case opDelete:
+			sb.WriteString("-" + o.line + "\n")
+		case opInsert:
+			sb.WriteString("+" + o.line + "\n")
+		}
+	}
+}
+
+// Colorize adds ANSI colors to a unified diff: removals red, additions green, hunk headers cyan
+func Colorize(diff string) string {
+	var sb strings.Builder
+	for _, line := range Lines(diff) {
+		switch {
+		case strings.HasPrefix(line, "---") || strings.HasPrefix(line, "+++"):
+			sb.WriteString("\033[1m" + line + "\033[0m\n")
+		case strings.HasPrefix(line, "@@"):
+			sb.WriteString("\033[36m" + line + "\033[0m\n")
+		case strings.HasPrefix(line, "-"):
+			sb.WriteString("\033[31m" + line + "\033[0m\n")
+		case strings.HasPrefix(line, "+"):
+			sb.WriteString("\033[32m" + line + "\033[0m\n")
+		default:
+			sb.WriteString(line + "\n")
+		}
+	}
+	return sb.String()
+}
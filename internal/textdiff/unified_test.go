@@ -0,0 +1,53 @@
+package textdiff
+
+import (
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      string
+		new      string
+		expected string
+	}{
+		{
+			name:     "identical",
+			old:      "a\nb\n",
+			new:      "a\nb\n",
+			expected: "",
+		},
+		{
+			name:     "changed line",
+			old:      "a\nb\nc\n",
+			new:      "a\nx\nc\n",
+			expected: "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n",
+		},
+		{
+			name:     "appended line",
+			old:      "a\n",
+			new:      "a\nb\n",
+			expected: "--- old\n+++ new\n@@ -1,1 +1,2 @@\n a\n+b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Unified("old", "new", tt.old, tt.new, 3)
+			if got != tt.expected {
+				t.Errorf("Expected:\n%q\ngot:\n%q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestUnified_SeparateHunks(t *testing.T) {
+	old := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	new := "1\nX\n3\n4\n5\n6\n7\n8\nY\n10\n"
+
+	got := Unified("old", "new", old, new, 1)
+	expected := "--- old\n+++ new\n@@ -1,3 +1,3 @@\n 1\n-2\n+X\n 3\n@@ -8,3 +8,3 @@\n 8\n-9\n+Y\n 10\n"
+	if got != expected {
+		t.Errorf("Expected:\n%q\ngot:\n%q", expected, got)
+	}
+}
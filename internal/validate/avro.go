@@ -0,0 +1,196 @@
+package validate
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aywengo/ksr-cli/internal/avro"
+	"github.com/aywengo/ksr-cli/internal/jsonfmt"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// avroValidator checks JSON values against an Avro schema
+type avroValidator struct {
+	avroJSON   bool
+	violations []Violation
+}
+
+func (v *avroValidator) add(path, format string, args ...interface{}) {
+	v.violations = append(v.violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// expected reports a value of the wrong JSON type
+func (v *avroValidator) expected(path string, s *avro.Schema, value interface{}) {
+	v.add(path, "expected %s, got %s", s.String(), jsonfmt.Kind(value))
+}
+
+func (v *avroValidator) value(s *avro.Schema, value interface{}, path string) {
+	switch s.Type {
+	case avro.Null:
+		if value != nil {
+			v.expected(path, s, value)
+		}
+	case avro.Boolean:
+		if _, ok := value.(bool); !ok {
+			v.expected(path, s, value)
+		}
+	case avro.Int, avro.Long:
+		n, ok := integerValue(value)
+		if !ok {
+			v.expected(path, s, value)
+			return
+		}
+		if s.Type == avro.Int && (n < math.MinInt32 || n > math.MaxInt32) {
+			v.add(path, "value %d is out of range for int", n)
+		}
+	case avro.Float, avro.Double:
+		if _, ok := floatValue(value); !ok {
+			v.expected(path, s, value)
+		}
+	case avro.String:
+		str, ok := value.(string)
+		if !ok {
+			v.expected(path, s, value)
+			return
+		}
+		if s.LogicalType == "uuid" && !uuidPattern.MatchString(str) {
+			v.add(path, "%q is not a valid uuid", str)
+		}
+	case avro.Bytes, avro.Fixed:
+		str, ok := value.(string)
+		if !ok {
+			v.expected(path, s, value)
+			return
+		}
+		for _, r := range str {
+			if r > 0xFF {
+				v.add(path, "bytes must be encoded as characters U+0000 to U+00FF")
+				return
+			}
+		}
+		if s.Type == avro.Fixed && utf8.RuneCountInString(str) != s.Size {
+			v.add(path, "expected %d bytes for fixed %s, got %d", s.Size, s.FullName(), utf8.RuneCountInString(str))
+		}
+	case avro.Enum:
+		str, ok := value.(string)
+		if !ok {
+			v.expected(path, s, value)
+			return
+		}
+		for _, symbol := range s.Symbols {
+			if symbol == str {
+				return
+			}
+		}
+		v.add(path, "%q is not a symbol of enum %s (%s)", str, s.FullName(), strings.Join(s.Symbols, ", "))
+	case avro.Array:
+		items, ok := value.([]interface{})
+		if !ok {
+			v.expected(path, s, value)
+			return
+		}
+		for i, item := range items {
+			v.value(s.Items, item, childIndex(path, i))
+		}
+	case avro.Map:
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			v.expected(path, s, value)
+			return
+		}
+		for _, key := range sortedKeys(entries) {
+			v.value(s.Values, entries[key], childKey(path, key))
+		}
+	case avro.Record, avro.Error:
+		v.record(s, value, path)
+	case avro.Union:
+		v.union(s, value, path)
+	}
+}
+
+func (v *avroValidator) record(s *avro.Schema, value interface{}, path string) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		v.expected(path, s, value)
+		return
+	}
+
+	for _, field := range s.Fields {
+		fieldValue, present := object[field.Name]
+		if !present {
+			if !field.HasDefault {
+				v.add(childKey(path, field.Name), "missing required field %s", field.Name)
+			}
+			continue
+		}
+		v.value(field.Type, fieldValue, childKey(path, field.Name))
+	}
+	for _, key := range sortedKeys(object) {
+		if s.FieldByName(key) == nil {
+			v.add(childKey(path, key), "field %s is not defined in record %s", key, s.FullName())
+		}
+	}
+}
+
+func (v *avroValidator) union(s *avro.Schema, value interface{}, path string) {
+	if value == nil {
+		if !s.IsNullable() {
+			v.add(path, "null is not allowed by %s", s.String())
+		}
+		return
+	}
+
+	if v.avroJSON {
+		wrapper, ok := value.(map[string]interface{})
+		if !ok || len(wrapper) != 1 {
+			branch := "<type>"
+			if nonNull := s.NonNull(); nonNull != s {
+				branch = nonNull.TypeName()
+			}
+			v.add(path, "expected union value as {%q: ...}, got %s", branch, jsonfmt.Kind(value))
+			return
+		}
+		for name, inner := range wrapper {
+			for _, branch := range s.Types {
+				if branch.Type != avro.Null && branch.TypeName() == name {
+					v.value(branch, inner, childKey(path, name))
+					return
+				}
+			}
+			v.add(path, "%q is not a branch of %s", name, s.String())
+		}
+		return
+	}
+
+	// Plain JSON: the value must match one of the branches. Optional unions report the
+	// violations of their only branch, which are more useful than a generic message.
+	if nonNull := s.NonNull(); nonNull != s {
+		v.value(nonNull, value, path)
+		return
+	}
+	for _, branch := range s.Types {
+		if branch.Type == avro.Null {
+			continue
+		}
+		candidate := &avroValidator{}
+		candidate.value(branch, value, path)
+		if len(candidate.violations) == 0 {
+			return
+		}
+	}
+	v.add(path, "value does not match any branch of %s", s.String())
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,469 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aywengo/ksr-cli/internal/jsonfmt"
+	"github.com/aywengo/ksr-cli/internal/jsonschema"
+)
+
+var (
+	emailPattern    = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	hostnamePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*$`)
+)
+
+// jsonValidator checks JSON values against a JSON Schema document
+type jsonValidator struct {
+	doc      *jsonschema.Document
+	patterns map[string]*regexp.Regexp
+}
+
+func violation(path, format string, args ...interface{}) Violation {
+	return Violation{Path: path, Message: fmt.Sprintf(format, args...)}
+}
+
+// check returns the violations of a value against a schema
+func (v *jsonValidator) check(schema interface{}, value interface{}, path string) []Violation {
+	schema, _ = v.doc.Resolve(schema)
+
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			return []Violation{violation(path, "no value is allowed here")}
+		}
+		return nil
+	case map[string]interface{}:
+		return v.checkObject(s, value, path)
+	default:
+		return nil
+	}
+}
+
+// valid reports whether a value matches a schema
+func (v *jsonValidator) valid(schema interface{}, value interface{}, path string) bool {
+	return len(v.check(schema, value, path)) == 0
+}
+
+func (v *jsonValidator) checkObject(s map[string]interface{}, value interface{}, path string) []Violation {
+	var violations []Violation
+
+	if types := schemaTypes(s); len(types) > 0 && !matchesAnyType(types, value) {
+		// Further keywords would only repeat the type mismatch
+		return []Violation{violation(path, "expected %s, got %s", strings.Join(types, " or "), jsonfmt.Kind(value))}
+	}
+	if constant, ok := s["const"]; ok && !jsonEqual(constant, value) {
+		violations = append(violations, violation(path, "expected constant %s", toJSON(constant)))
+	}
+	if enum, ok := s["enum"].([]interface{}); ok && !enumContains(enum, value) {
+		violations = append(violations, violation(path, "value %s is not one of %s", toJSON(value), toJSON(enum)))
+	}
+
+	violations = append(violations, v.checkCombined(s, value, path)...)
+
+	switch value := value.(type) {
+	case string:
+		violations = append(violations, v.checkString(s, value, path)...)
+	case json.Number:
+		violations = append(violations, checkNumber(s, value, path)...)
+	case map[string]interface{}:
+		violations = append(violations, v.checkProperties(s, value, path)...)
+	case []interface{}:
+		violations = append(violations, v.checkItems(s, value, path)...)
+	}
+	return violations
+}
+
+// checkCombined applies allOf, anyOf, oneOf, not and if/then/else
+func (v *jsonValidator) checkCombined(s map[string]interface{}, value interface{}, path string) []Violation {
+	var violations []Violation
+
+	if all, ok := s["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			violations = append(violations, v.check(sub, value, path)...)
+		}
+	}
+	if any, ok := s["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, sub := range any {
+			if v.valid(sub, value, path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			violations = append(violations, violation(path, "value does not match any of the anyOf schemas"))
+		}
+	}
+	if one, ok := s["oneOf"].([]interface{}); ok {
+		matches := 0
+		for _, sub := range one {
+			if v.valid(sub, value, path) {
+				matches++
+			}
+		}
+		switch {
+		case matches == 0:
+			violations = append(violations, violation(path, "value does not match any of the oneOf schemas"))
+		case matches > 1:
+			violations = append(violations, violation(path, "value matches %d of the oneOf schemas, expected exactly one", matches))
+		}
+	}
+	if not, ok := s["not"]; ok && v.valid(not, value, path) {
+		violations = append(violations, violation(path, "value must not match the \"not\" schema"))
+	}
+	if condition, ok := s["if"]; ok {
+		if v.valid(condition, value, path) {
+			if then, ok := s["then"]; ok {
+				violations = append(violations, v.check(then, value, path)...)
+			}
+		} else if otherwise, ok := s["else"]; ok {
+			violations = append(violations, v.check(otherwise, value, path)...)
+		}
+	}
+	return violations
+}
+
+func (v *jsonValidator) checkString(s map[string]interface{}, value string, path string) []Violation {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(value)
+	if min, ok := schemaNumber(s, "minLength"); ok && float64(length) < min {
+		violations = append(violations, violation(path, "length %d is shorter than minLength %v", length, min))
+	}
+	if max, ok := schemaNumber(s, "maxLength"); ok && float64(length) > max {
+		violations = append(violations, violation(path, "length %d is longer than maxLength %v", length, max))
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		if re := v.pattern(pattern); re != nil && !re.MatchString(value) {
+			violations = append(violations, violation(path, "%q does not match pattern %s", value, pattern))
+		}
+	}
+	if format, ok := s["format"].(string); ok && !validFormat(format, value) {
+		violations = append(violations, violation(path, "%q is not a valid %s", value, format))
+	}
+	return violations
+}
+
+// pattern compiles a pattern once. Patterns that Go cannot compile are not checked.
+func (v *jsonValidator) pattern(pattern string) *regexp.Regexp {
+	re, ok := v.patterns[pattern]
+	if !ok {
+		re, _ = regexp.Compile(pattern)
+		v.patterns[pattern] = re
+	}
+	return re
+}
+
+// validFormat checks the common string formats; unknown formats are annotations only
+func validFormat(format, value string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, value)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	case "time":
+		_, err := time.Parse("15:04:05.999999999Z07:00", value)
+		return err == nil
+	case "email":
+		return emailPattern.MatchString(value)
+	case "uuid":
+		return uuidPattern.MatchString(value)
+	case "ipv4":
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() != nil && !strings.Contains(value, ":")
+	case "ipv6":
+		ip := net.ParseIP(value)
+		return ip != nil && strings.Contains(value, ":")
+	case "hostname":
+		return len(value) <= 253 && hostnamePattern.MatchString(value)
+	case "uri":
+		u, err := url.Parse(value)
+		return err == nil && u.Scheme != ""
+	default:
+		return true
+	}
+}
+
+func checkNumber(s map[string]interface{}, value json.Number, path string) []Violation {
+	n, err := value.Float64()
+	if err != nil {
+		return nil
+	}
+
+	var violations []Violation
+	if min, ok := schemaNumber(s, "minimum"); ok {
+		// Draft 4 makes the bound exclusive with a boolean exclusiveMinimum
+		if exclusive, _ := s["exclusiveMinimum"].(bool); exclusive && n <= min {
+			violations = append(violations, violation(path, "%s must be greater than %v", value, min))
+		} else if n < min {
+			violations = append(violations, violation(path, "%s is less than minimum %v", value, min))
+		}
+	}
+	if max, ok := schemaNumber(s, "maximum"); ok {
+		if exclusive, _ := s["exclusiveMaximum"].(bool); exclusive && n >= max {
+			violations = append(violations, violation(path, "%s must be less than %v", value, max))
+		} else if n > max {
+			violations = append(violations, violation(path, "%s is greater than maximum %v", value, max))
+		}
+	}
+	if min, ok := schemaNumber(s, "exclusiveMinimum"); ok && n <= min {
+		violations = append(violations, violation(path, "%s must be greater than %v", value, min))
+	}
+	if max, ok := schemaNumber(s, "exclusiveMaximum"); ok && n >= max {
+		violations = append(violations, violation(path, "%s must be less than %v", value, max))
+	}
+	if divisor, ok := schemaNumber(s, "multipleOf"); ok && divisor > 0 {
+		quotient := n / divisor
+		if math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			violations = append(violations, violation(path, "%s is not a multiple of %v", value, divisor))
+		}
+	}
+	return violations
+}
+
+func (v *jsonValidator) checkProperties(s map[string]interface{}, object map[string]interface{}, path string) []Violation {
+	var violations []Violation
+
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, present := object[name]; !present {
+					violations = append(violations, violation(childKey(path, name), "missing required property %s", name))
+				}
+			}
+		}
+	}
+	if min, ok := schemaNumber(s, "minProperties"); ok && float64(len(object)) < min {
+		violations = append(violations, violation(path, "%d properties are fewer than minProperties %v", len(object), min))
+	}
+	if max, ok := schemaNumber(s, "maxProperties"); ok && float64(len(object)) > max {
+		violations = append(violations, violation(path, "%d properties are more than maxProperties %v", len(object), max))
+	}
+
+	properties, _ := s["properties"].(map[string]interface{})
+	patternProperties, _ := s["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := s["additionalProperties"]
+
+	for _, key := range sortedKeys(object) {
+		value := object[key]
+		keyPath := childKey(path, key)
+
+		matched := false
+		if property, ok := properties[key]; ok {
+			matched = true
+			violations = append(violations, v.check(property, value, keyPath)...)
+		}
+		for pattern, property := range patternProperties {
+			if re := v.pattern(pattern); re != nil && re.MatchString(key) {
+				matched = true
+				violations = append(violations, v.check(property, value, keyPath)...)
+			}
+		}
+		if !matched && hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				violations = append(violations, violation(keyPath, "property %s is not allowed", key))
+			} else {
+				violations = append(violations, v.check(additional, value, keyPath)...)
+			}
+		}
+
+		if names, ok := s["propertyNames"]; ok && !v.valid(names, key, keyPath) {
+			violations = append(violations, violation(keyPath, "property name %q does not match propertyNames", key))
+		}
+		violations = append(violations, v.checkDependencies(s, object, key, path)...)
+	}
+	return violations
+}
+
+// checkDependencies applies dependentRequired, dependentSchemas and draft 4-7 dependencies
+// for a property that is present
+func (v *jsonValidator) checkDependencies(s map[string]interface{}, object map[string]interface{}, key, path string) []Violation {
+	var violations []Violation
+
+	requireAll := func(names []interface{}) {
+		for _, name := range names {
+			if name, ok := name.(string); ok {
+				if _, present := object[name]; !present {
+					violations = append(violations, violation(childKey(path, name), "property %s is required when %s is present", name, key))
+				}
+			}
+		}
+	}
+
+	if dependent, ok := s["dependentRequired"].(map[string]interface{}); ok {
+		if names, ok := dependent[key].([]interface{}); ok {
+			requireAll(names)
+		}
+	}
+	if dependent, ok := s["dependentSchemas"].(map[string]interface{}); ok {
+		if schema, ok := dependent[key]; ok {
+			violations = append(violations, v.check(schema, object, path)...)
+		}
+	}
+	if dependencies, ok := s["dependencies"].(map[string]interface{}); ok {
+		switch dependency := dependencies[key].(type) {
+		case []interface{}:
+			requireAll(dependency)
+		case map[string]interface{}, bool:
+			violations = append(violations, v.check(dependency, object, path)...)
+		}
+	}
+	return violations
+}
+
+func (v *jsonValidator) checkItems(s map[string]interface{}, items []interface{}, path string) []Violation {
+	var violations []Violation
+
+	if min, ok := schemaNumber(s, "minItems"); ok && float64(len(items)) < min {
+		violations = append(violations, violation(path, "%d items are fewer than minItems %v", len(items), min))
+	}
+	if max, ok := schemaNumber(s, "maxItems"); ok && float64(len(items)) > max {
+		violations = append(violations, violation(path, "%d items are more than maxItems %v", len(items), max))
+	}
+	if unique, _ := s["uniqueItems"].(bool); unique {
+		for i := 1; i < len(items); i++ {
+			for j := 0; j < i; j++ {
+				if jsonEqual(items[i], items[j]) {
+					violations = append(violations, violation(childIndex(path, i), "item duplicates item %d but uniqueItems is set", j))
+					break
+				}
+			}
+		}
+	}
+
+	// Tuple items come from prefixItems (2020-12) or an items array (earlier drafts);
+	// the remaining items are checked against items or additionalItems
+	tuple, _ := s["prefixItems"].([]interface{})
+	rest, hasRest := s["items"]
+	if tupleItems, ok := rest.([]interface{}); ok {
+		tuple = tupleItems
+		rest, hasRest = s["additionalItems"]
+	}
+	for i, item := range items {
+		itemPath := childIndex(path, i)
+		switch {
+		case i < len(tuple):
+			violations = append(violations, v.check(tuple[i], item, itemPath)...)
+		case hasRest:
+			if allowed, ok := rest.(bool); ok && !allowed {
+				violations = append(violations, violation(itemPath, "additional items are not allowed"))
+			} else {
+				violations = append(violations, v.check(rest, item, itemPath)...)
+			}
+		}
+	}
+
+	if contains, ok := s["contains"]; ok {
+		matches := 0
+		for i, item := range items {
+			if v.valid(contains, item, childIndex(path, i)) {
+				matches++
+			}
+		}
+		min := 1.0
+		if n, ok := schemaNumber(s, "minContains"); ok {
+			min = n
+		}
+		if float64(matches) < min {
+			violations = append(violations, violation(path, "%d items match \"contains\", expected at least %v", matches, min))
+		}
+		if max, ok := schemaNumber(s, "maxContains"); ok && float64(matches) > max {
+			violations = append(violations, violation(path, "%d items match \"contains\", expected at most %v", matches, max))
+		}
+	}
+	return violations
+}
+
+// schemaTypes returns the "type" keyword as a list
+func schemaTypes(s map[string]interface{}) []string {
+	switch t := s["type"].(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		var types []string
+		for _, item := range t {
+			if name, ok := item.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types
+	default:
+		return nil
+	}
+}
+
+func matchesAnyType(types []string, value interface{}) bool {
+	kind := jsonfmt.Kind(value)
+	for _, t := range types {
+		if t == kind {
+			return true
+		}
+		if t == "integer" && kind == "number" {
+			if f, ok := floatValue(value); ok && f == math.Trunc(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// schemaNumber reads a numeric keyword of a schema
+func schemaNumber(s map[string]interface{}, key string) (float64, bool) {
+	n, ok := s[key].(float64)
+	return n, ok
+}
+
+func enumContains(enum []interface{}, value interface{}) bool {
+	for _, candidate := range enum {
+		if jsonEqual(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonEqual compares JSON values, treating numbers by value regardless of how they were decoded
+func jsonEqual(a, b interface{}) bool {
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = normalize(item)
+		}
+		return items
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			object[key] = normalize(item)
+		}
+		return object
+	default:
+		return value
+	}
+}
+
+func toJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
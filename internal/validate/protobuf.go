@@ -0,0 +1,327 @@
+package validate
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aywengo/ksr-cli/internal/jsonfmt"
+	"github.com/aywengo/ksr-cli/internal/protobuf"
+)
+
+var durationPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]{1,9})?s$`)
+
+// protoValidator checks JSON values against the Protobuf JSON mapping of a message
+type protoValidator struct {
+	registry   *protobuf.Registry
+	violations []Violation
+}
+
+func (v *protoValidator) add(path, format string, args ...interface{}) {
+	v.violations = append(v.violations, violation(path, format, args...))
+}
+
+func (v *protoValidator) message(m *protobuf.Message, value interface{}, path string) {
+	if protobuf.IsWellKnownType(m.FullName) {
+		v.wellKnown(m.FullName, value, path)
+		return
+	}
+
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		v.add(path, "expected message %s as an object, got %s", m.FullName, jsonfmt.Kind(value))
+		return
+	}
+
+	// Parsers accept both the JSON name and the original field name
+	fields := map[string]*protobuf.Field{}
+	for _, field := range m.Fields {
+		fields[field.Name] = field
+		fields[field.JSONName] = field
+	}
+
+	set := map[*protobuf.Oneof]string{}
+	seen := map[*protobuf.Field]string{}
+	for _, key := range sortedKeys(object) {
+		keyPath := childKey(path, key)
+		field, ok := fields[key]
+		if !ok {
+			v.add(keyPath, "field %s is not defined in message %s", key, m.FullName)
+			continue
+		}
+		if other, ok := seen[field]; ok {
+			v.add(keyPath, "field %s is also set as %s", field.Name, other)
+			continue
+		}
+		seen[field] = key
+
+		fieldValue := object[key]
+		if fieldValue == nil {
+			// null means the default value
+			continue
+		}
+		if field.Oneof != nil {
+			if other, ok := set[field.Oneof]; ok {
+				v.add(keyPath, "oneof %s already has %s set", field.Oneof.Name, other)
+				continue
+			}
+			set[field.Oneof] = key
+		}
+		v.field(field, fieldValue, keyPath)
+	}
+
+	for _, field := range m.Fields {
+		if field.Label != "required" {
+			continue
+		}
+		if _, ok := seen[field]; !ok {
+			v.add(childKey(path, field.JSONName), "missing required field %s", field.Name)
+		}
+	}
+}
+
+func (v *protoValidator) field(field *protobuf.Field, value interface{}, path string) {
+	switch {
+	case field.IsMap:
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			v.add(path, "expected map<%s, %s> as an object, got %s", field.KeyType, field.Type, jsonfmt.Kind(value))
+			return
+		}
+		for _, key := range sortedKeys(entries) {
+			entryPath := childKey(path, key)
+			if !validMapKey(field.KeyType, key) {
+				v.add(entryPath, "%q is not a valid %s map key", key, field.KeyType)
+			}
+			v.single(field, entries[key], entryPath)
+		}
+	case field.Label == "repeated":
+		items, ok := value.([]interface{})
+		if !ok {
+			v.add(path, "expected repeated %s as an array, got %s", field.Type, jsonfmt.Kind(value))
+			return
+		}
+		for i, item := range items {
+			v.single(field, item, childIndex(path, i))
+		}
+	default:
+		v.single(field, value, path)
+	}
+}
+
+// single checks one value of a field's type
+func (v *protoValidator) single(field *protobuf.Field, value interface{}, path string) {
+	if protobuf.IsScalar(field.Type) {
+		v.scalar(field.Type, value, path)
+		return
+	}
+
+	message, enum, err := v.registry.ResolveField(field)
+	if err != nil {
+		v.add(path, "cannot resolve type %s: %v", field.Type, err)
+		return
+	}
+	if enum != nil {
+		v.enum(enum, value, path)
+		return
+	}
+	v.message(message, value, path)
+}
+
+func (v *protoValidator) scalar(scalarType string, value interface{}, path string) {
+	switch scalarType {
+	case "double", "float":
+		if str, ok := value.(string); ok {
+			if str == "NaN" || str == "Infinity" || str == "-Infinity" {
+				return
+			}
+			if _, err := strconv.ParseFloat(str, 64); err == nil {
+				return
+			}
+		}
+		if _, ok := floatValue(value); !ok {
+			v.add(path, "expected %s as a number, got %s", scalarType, jsonfmt.Kind(value))
+		}
+	case "int32", "sint32", "sfixed32", "uint32", "fixed32", "int64", "sint64", "sfixed64", "uint64", "fixed64":
+		v.integer(scalarType, value, path)
+	case "bool":
+		if _, ok := value.(bool); !ok {
+			v.add(path, "expected bool, got %s", jsonfmt.Kind(value))
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			v.add(path, "expected string, got %s", jsonfmt.Kind(value))
+		}
+	case "bytes":
+		str, ok := value.(string)
+		if !ok {
+			v.add(path, "expected bytes as a base64 string, got %s", jsonfmt.Kind(value))
+			return
+		}
+		if !validBase64(str) {
+			v.add(path, "%q is not valid base64", str)
+		}
+	}
+}
+
+// integer checks an integer, which the JSON mapping accepts as a number or a string
+func (v *protoValidator) integer(scalarType string, value interface{}, path string) {
+	var text string
+	switch n := value.(type) {
+	case string:
+		text = n
+	case json.Number:
+		text = n.String()
+	default:
+		v.add(path, "expected %s, got %s", scalarType, jsonfmt.Kind(value))
+		return
+	}
+
+	bits := 64
+	if strings.HasSuffix(scalarType, "32") {
+		bits = 32
+	}
+	unsigned := strings.HasPrefix(scalarType, "uint") || strings.HasPrefix(scalarType, "fixed")
+
+	var err error
+	if unsigned {
+		_, err = strconv.ParseUint(text, 10, bits)
+	} else {
+		_, err = strconv.ParseInt(text, 10, bits)
+	}
+	if err == nil {
+		return
+	}
+	// Numbers such as 1.0 or 1e3 are accepted when they are integral
+	if f, ferr := strconv.ParseFloat(text, 64); ferr == nil && f == math.Trunc(f) && inRange(f, bits, unsigned) {
+		return
+	}
+	v.add(path, "%s is not a valid %s", text, scalarType)
+}
+
+func inRange(f float64, bits int, unsigned bool) bool {
+	switch {
+	case unsigned && bits == 32:
+		return f >= 0 && f <= math.MaxUint32
+	case unsigned:
+		return f >= 0 && f < math.MaxUint64
+	case bits == 32:
+		return f >= math.MinInt32 && f <= math.MaxInt32
+	default:
+		return f >= math.MinInt64 && f < math.MaxInt64
+	}
+}
+
+// enum checks an enum value, written by name or by number
+func (v *protoValidator) enum(enum *protobuf.Enum, value interface{}, path string) {
+	switch value := value.(type) {
+	case string:
+		for _, enumValue := range enum.Values {
+			if enumValue.Name == value {
+				return
+			}
+		}
+		v.add(path, "%q is not a value of enum %s", value, enum.FullName)
+	case json.Number:
+		if _, ok := integerValue(value); !ok {
+			v.add(path, "%s is not a valid enum number", value)
+		}
+	default:
+		v.add(path, "expected enum %s as a name or number, got %s", enum.FullName, jsonfmt.Kind(value))
+	}
+}
+
+// wellKnown checks the special JSON forms of the google.protobuf types
+func (v *protoValidator) wellKnown(fullName string, value interface{}, path string) {
+	str, isString := value.(string)
+
+	switch fullName {
+	case "google.protobuf.Timestamp":
+		if _, err := time.Parse(time.RFC3339Nano, str); !isString || err != nil {
+			v.add(path, "expected %s as an RFC 3339 string", fullName)
+		}
+	case "google.protobuf.Duration":
+		if !isString || !durationPattern.MatchString(str) {
+			v.add(path, "expected %s as a string of seconds such as \"1.5s\"", fullName)
+		}
+	case "google.protobuf.FieldMask":
+		if !isString {
+			v.add(path, "expected %s as a comma-separated string", fullName)
+		}
+	case "google.protobuf.Struct":
+		if _, ok := value.(map[string]interface{}); !ok {
+			v.add(path, "expected %s as an object, got %s", fullName, jsonfmt.Kind(value))
+		}
+	case "google.protobuf.ListValue":
+		if _, ok := value.([]interface{}); !ok {
+			v.add(path, "expected %s as an array, got %s", fullName, jsonfmt.Kind(value))
+		}
+	case "google.protobuf.Value":
+		// Any JSON value
+	case "google.protobuf.Any":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			v.add(path, "expected %s as an object, got %s", fullName, jsonfmt.Kind(value))
+			return
+		}
+		if typeURL, _ := object["@type"].(string); typeURL == "" {
+			v.add(childKey(path, "@type"), "missing @type of %s", fullName)
+		}
+	case "google.protobuf.Empty":
+		if object, ok := value.(map[string]interface{}); !ok || len(object) > 0 {
+			v.add(path, "expected %s as an empty object", fullName)
+		}
+	case "google.protobuf.DoubleValue", "google.protobuf.FloatValue":
+		v.scalar("double", value, path)
+	case "google.protobuf.Int64Value":
+		v.scalar("int64", value, path)
+	case "google.protobuf.UInt64Value":
+		v.scalar("uint64", value, path)
+	case "google.protobuf.Int32Value":
+		v.scalar("int32", value, path)
+	case "google.protobuf.UInt32Value":
+		v.scalar("uint32", value, path)
+	case "google.protobuf.BoolValue":
+		v.scalar("bool", value, path)
+	case "google.protobuf.StringValue":
+		v.scalar("string", value, path)
+	case "google.protobuf.BytesValue":
+		v.scalar("bytes", value, path)
+	}
+}
+
+// validMapKey checks a map key, which the JSON mapping writes as a string
+func validMapKey(keyType, key string) bool {
+	switch keyType {
+	case "string":
+		return true
+	case "bool":
+		return key == "true" || key == "false"
+	case "uint32", "fixed32":
+		_, err := strconv.ParseUint(key, 10, 32)
+		return err == nil
+	case "uint64", "fixed64":
+		_, err := strconv.ParseUint(key, 10, 64)
+		return err == nil
+	case "int32", "sint32", "sfixed32":
+		_, err := strconv.ParseInt(key, 10, 32)
+		return err == nil
+	default:
+		_, err := strconv.ParseInt(key, 10, 64)
+		return err == nil
+	}
+}
+
+// validBase64 accepts standard and URL-safe base64, with or without padding
+func validBase64(s string) bool {
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if _, err := encoding.DecodeString(s); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+// Package validate checks JSON records against Avro, JSON Schema and Protobuf schemas.
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/internal/avro"
+	"github.com/aywengo/ksr-cli/internal/jsonschema"
+	"github.com/aywengo/ksr-cli/internal/protobuf"
+)
+
+// Violation is a place where a record does not match its schema. Path is a JSON path
+// such as "$.lines[0].qty".
+type Violation struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Options controls validation
+type Options struct {
+	// AvroJSON expects Avro records in the Avro JSON encoding, where non-null union values
+	// are wrapped in an object keyed by the branch type. Otherwise union values are plain
+	// JSON and must match one of the branches.
+	AvroJSON bool
+}
+
+// Validator checks decoded records against a schema
+type Validator struct {
+	validate func(value interface{}) []Violation
+}
+
+// New returns a validator for schema content of the given type. References holds the
+// contents of referenced schemas. Protobuf records are checked against the first message.
+func New(schemaType, content string, references []string, opts Options) (*Validator, error) {
+	switch strings.ToUpper(schemaType) {
+	case "", "AVRO":
+		s, err := avro.ParseWithReferences(content, references)
+		if err != nil {
+			return nil, err
+		}
+		return &Validator{validate: func(value interface{}) []Violation {
+			v := &avroValidator{avroJSON: opts.AvroJSON}
+			v.value(s, value, "$")
+			return v.violations
+		}}, nil
+	case "JSON":
+		doc, err := jsonschema.Parse(content)
+		if err != nil {
+			return nil, err
+		}
+		return &Validator{validate: func(value interface{}) []Violation {
+			v := &jsonValidator{doc: doc, patterns: map[string]*regexp.Regexp{}}
+			return v.check(doc.Root, value, "$")
+		}}, nil
+	case "PROTOBUF":
+		f, registry, err := protobuf.ParseWithReferences(content, references)
+		if err != nil {
+			return nil, err
+		}
+		if len(f.Messages) == 0 {
+			return nil, fmt.Errorf("schema defines no messages")
+		}
+		return &Validator{validate: func(value interface{}) []Violation {
+			v := &protoValidator{registry: registry}
+			v.message(f.Messages[0], value, "$")
+			return v.violations
+		}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported schema type: %s", schemaType)
+	}
+}
+
+// Validate returns the violations of a record decoded with Decode
+func (v *Validator) Validate(value interface{}) []Violation {
+	return v.validate(value)
+}
+
+// Decode parses one JSON record, keeping numbers as json.Number so that integer ranges
+// can be checked exactly
+func Decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return value, nil
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// childKey appends an object key to a JSON path
+func childKey(path, key string) string {
+	if identifier.MatchString(key) {
+		return path + "." + key
+	}
+	return path + "['" + strings.ReplaceAll(key, "'", `\'`) + "']"
+}
+
+// childIndex appends an array index to a JSON path
+func childIndex(path string, index int) string {
+	return path + "[" + strconv.Itoa(index) + "]"
+}
+
+// integerValue returns a number as an int64 if it is integral and in range
+func integerValue(value interface{}) (int64, bool) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	if i, err := n.Int64(); err == nil {
+		return i, true
+	}
+	// Integral values written with a fraction or exponent, such as 1.0 or 1e3
+	f, err := n.Float64()
+	if err != nil || f != float64(int64(f)) {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// floatValue returns a number as a float64
+func floatValue(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,138 @@
+package validate
+
+import (
+	"reflect"
+	"testing"
+)
+
+// validateRecord decodes and validates one record, returning the violations by path
+func validateRecord(t *testing.T, schemaType, schema, record string, opts Options) map[string]string {
+	t.Helper()
+	validator, err := New(schemaType, schema, nil, opts)
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	value, err := Decode([]byte(record))
+	if err != nil {
+		t.Fatalf("Failed to decode %s: %v", record, err)
+	}
+	violations := map[string]string{}
+	for _, violation := range validator.Validate(value) {
+		violations[violation.Path] = violation.Message
+	}
+	return violations
+}
+
+// assertPaths checks that violations were reported at exactly the given paths
+func assertPaths(t *testing.T, violations map[string]string, paths ...string) {
+	t.Helper()
+	want := map[string]bool{}
+	for _, path := range paths {
+		want[path] = true
+		if _, ok := violations[path]; !ok {
+			t.Errorf("Expected a violation at %s, got %v", path, violations)
+		}
+	}
+	for path, message := range violations {
+		if !want[path] {
+			t.Errorf("Unexpected violation at %s: %s", path, message)
+		}
+	}
+}
+
+const avroSchema = `{"type":"record","name":"Order","namespace":"com.acme","fields":[
+	{"name":"id","type":{"type":"string","logicalType":"uuid"}},
+	{"name":"status","type":{"type":"enum","name":"Status","symbols":["NEW","DONE"]}},
+	{"name":"note","type":["null","string"],"default":null},
+	{"name":"lines","type":{"type":"array","items":{"type":"record","name":"Line","fields":[{"name":"qty","type":"int"}]}}},
+	{"name":"amount","type":["int","string"],"default":0}
+]}`
+
+func TestValidate_Avro(t *testing.T) {
+	valid := `{"id":"52fdfc07-2182-454f-963f-5f0f9a621d72","status":"NEW","note":{"string":"x"},"lines":[{"qty":1}],"amount":{"int":5}}`
+	assertPaths(t, validateRecord(t, "AVRO", avroSchema, valid, Options{AvroJSON: true}))
+
+	invalid := `{"id":"nope","status":"LOST","note":"x","lines":[{"qty":1},{"qty":3000000000}],"amount":{"long":5},"extra":true}`
+	assertPaths(t, validateRecord(t, "AVRO", avroSchema, invalid, Options{AvroJSON: true}),
+		"$.id", "$.status", "$.note", "$.lines[1].qty", "$.amount", "$.extra")
+
+	missing := `{"status":"NEW"}`
+	assertPaths(t, validateRecord(t, "AVRO", avroSchema, missing, Options{AvroJSON: true}), "$.id", "$.lines")
+}
+
+func TestValidate_AvroPlainJSON(t *testing.T) {
+	valid := `{"id":"52fdfc07-2182-454f-963f-5f0f9a621d72","status":"DONE","note":"x","lines":[],"amount":"5"}`
+	assertPaths(t, validateRecord(t, "AVRO", avroSchema, valid, Options{}))
+
+	invalid := `{"id":"52fdfc07-2182-454f-963f-5f0f9a621d72","status":"DONE","note":1,"lines":[],"amount":true}`
+	violations := validateRecord(t, "AVRO", avroSchema, invalid, Options{})
+	assertPaths(t, violations, "$.note", "$.amount")
+	if violations["$.note"] != "expected string, got number" {
+		t.Errorf("Expected the optional union to report its branch, got %q", violations["$.note"])
+	}
+}
+
+func TestValidate_JSONSchema(t *testing.T) {
+	schema := `{"type":"object","required":["id","price"],"additionalProperties":false,"properties":{
+		"id":{"type":"string","pattern":"^ORD-[0-9]{6}$"},
+		"price":{"type":"number","exclusiveMinimum":0,"multipleOf":0.5},
+		"qty":{"type":"integer","maximum":5},
+		"email":{"type":"string","format":"email"},
+		"tags":{"type":"array","items":{"enum":["a","b"]},"uniqueItems":true},
+		"address":{"$ref":"#/definitions/address"},
+		"payment":{"oneOf":[{"required":["card"]},{"required":["iban"]}]}
+	},"definitions":{"address":{"type":"object","required":["city"],"properties":{"city":{"type":"string","minLength":2}}}}}`
+
+	valid := `{"id":"ORD-000001","price":2.5,"qty":5.0,"tags":["a","b"],"address":{"city":"Oslo"},"payment":{"card":"x"}}`
+	assertPaths(t, validateRecord(t, "JSON", schema, valid, Options{}))
+
+	invalid := `{"id":"ORD-1","price":0.3,"qty":6,"email":"nope","tags":["a","c","a"],"address":{"city":"O"},"payment":{"card":1,"iban":2},"my key":1}`
+	assertPaths(t, validateRecord(t, "JSON", schema, invalid, Options{}),
+		"$.id", "$.price", "$.qty", "$.email", "$.tags[1]", "$.tags[2]", "$.address.city", "$.payment", "$['my key']")
+
+	assertPaths(t, validateRecord(t, "JSON", schema, `{"price":"1"}`, Options{}), "$.id", "$.price")
+}
+
+func TestValidate_Protobuf(t *testing.T) {
+	schema := `syntax = "proto3";
+import "google/protobuf/timestamp.proto";
+message Order {
+  int64 total_cents = 1;
+  oneof payment { string card = 2; string iban = 3; }
+  Status status = 4;
+  google.protobuf.Timestamp created_at = 5;
+  repeated int32 quantities = 6;
+  map<int32, string> notes = 7;
+  bytes checksum = 8;
+}
+enum Status { STATUS_UNKNOWN = 0; STATUS_OPEN = 1; }`
+
+	valid := `{"totalCents":"100","card":"x","status":"STATUS_OPEN","created_at":"2025-01-01T00:00:00Z","quantities":[1,"2"],"notes":{"1":"a"},"checksum":"AQI="}`
+	assertPaths(t, validateRecord(t, "PROTOBUF", schema, valid, Options{}))
+
+	invalid := `{"totalCents":1.5,"card":"x","iban":"y","status":"CLOSED","createdAt":"yesterday","quantities":[3000000000],"notes":{"a":"b"},"checksum":"!","unknown":1}`
+	assertPaths(t, validateRecord(t, "PROTOBUF", schema, invalid, Options{}),
+		"$.totalCents", "$.iban", "$.status", "$.createdAt", "$.quantities[0]", "$.notes.a", "$.checksum", "$.unknown")
+}
+
+func TestDecode(t *testing.T) {
+	value, err := Decode([]byte(`{"n":9007199254740993}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n, ok := integerValue(value.(map[string]interface{})["n"]); !ok || n != 9007199254740993 {
+		t.Errorf("Expected the exact integer, got %v", n)
+	}
+
+	if _, err := Decode([]byte(`{} {}`)); err == nil {
+		t.Error("Expected an error for trailing data")
+	}
+}
+
+func TestChildKey(t *testing.T) {
+	paths := []string{childKey("$", "name"), childKey("$", "first name"), childIndex("$.a", 2), childKey("$", "it's")}
+	want := []string{"$.name", "$['first name']", "$.a[2]", `$['it\'s']`}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("Expected %v, got %v", want, paths)
+	}
+}
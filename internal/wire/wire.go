@@ -0,0 +1,72 @@
+// Package wire implements the Confluent Schema Registry wire format: a zero magic byte and
+// a 4-byte big-endian schema ID in front of the payload, followed for Protobuf by the
+// indexes of the message type within the schema.
+package wire
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// MagicByte starts every message in the wire format
+const MagicByte = 0
+
+// headerSize is the size of the magic byte and the schema ID
+const headerSize = 5
+
+// Parse splits a message into its schema ID and the data after the header
+func Parse(data []byte) (int, []byte, error) {
+	if len(data) < headerSize {
+		return 0, nil, fmt.Errorf("message is %d bytes, shorter than the 5-byte wire format header", len(data))
+	}
+	if data[0] != MagicByte {
+		return 0, nil, fmt.Errorf("unknown magic byte 0x%02x, expected 0x00", data[0])
+	}
+	return int(binary.BigEndian.Uint32(data[1:headerSize])), data[headerSize:], nil
+}
+
+// ParseMessageIndexes reads the Protobuf message indexes that follow the header: a
+// zig-zag varint count and that many indexes, each into the nested messages of the
+// previous one. A single zero byte stands for [0], the first message of the schema.
+func ParseMessageIndexes(data []byte) ([]int, []byte, error) {
+	count, n := binary.Varint(data)
+	if n <= 0 {
+		return nil, nil, fmt.Errorf("invalid message index count")
+	}
+	data = data[n:]
+	if count == 0 {
+		return []int{0}, data, nil
+	}
+	if count < 0 || count > int64(len(data)) {
+		return nil, nil, fmt.Errorf("invalid message index count %d", count)
+	}
+
+	indexes := make([]int, count)
+	for i := range indexes {
+		index, n := binary.Varint(data)
+		if n <= 0 || index < 0 {
+			return nil, nil, fmt.Errorf("invalid message index")
+		}
+		indexes[i] = int(index)
+		data = data[n:]
+	}
+	return indexes, data, nil
+}
+
+// AppendHeader appends the magic byte and a schema ID
+func AppendHeader(dst []byte, schemaID int) []byte {
+	dst = append(dst, MagicByte)
+	return binary.BigEndian.AppendUint32(dst, uint32(schemaID))
+}
+
+// AppendMessageIndexes appends Protobuf message indexes, writing [0] as a single zero byte
+func AppendMessageIndexes(dst []byte, indexes []int) []byte {
+	if len(indexes) == 1 && indexes[0] == 0 {
+		return append(dst, 0)
+	}
+	dst = binary.AppendVarint(dst, int64(len(indexes)))
+	for _, index := range indexes {
+		dst = binary.AppendVarint(dst, int64(index))
+	}
+	return dst
+}
@@ -0,0 +1,69 @@
+package wire
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	id, payload, err := Parse([]byte{0, 0, 0, 1, 2, 'x'})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if id != 258 || string(payload) != "x" {
+		t.Errorf("Expected ID 258 and payload x, got %d and %q", id, payload)
+	}
+
+	if _, _, err := Parse([]byte{1, 0, 0, 0, 1}); err == nil {
+		t.Error("Expected error for an unknown magic byte")
+	}
+	if _, _, err := Parse([]byte{0, 0, 1}); err == nil {
+		t.Error("Expected error for a truncated header")
+	}
+}
+
+func TestParseMessageIndexes(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		indexes []int
+		payload string
+	}{
+		{name: "first message shorthand", data: []byte{0, 'p'}, indexes: []int{0}, payload: "p"},
+		{name: "single index", data: []byte{2, 4, 'p'}, indexes: []int{2}, payload: "p"},
+		{name: "nested message", data: []byte{4, 2, 0, 'p'}, indexes: []int{1, 0}, payload: "p"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexes, payload, err := ParseMessageIndexes(tt.data)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(indexes, tt.indexes) || string(payload) != tt.payload {
+				t.Errorf("Expected %v and %q, got %v and %q", tt.indexes, tt.payload, indexes, payload)
+			}
+		})
+	}
+
+	if _, _, err := ParseMessageIndexes([]byte{20, 2}); err == nil {
+		t.Error("Expected error for a count beyond the data")
+	}
+}
+
+func TestAppendHeader(t *testing.T) {
+	message := AppendMessageIndexes(AppendHeader(nil, 258), []int{0})
+	if !reflect.DeepEqual(message, []byte{0, 0, 0, 1, 2, 0}) {
+		t.Errorf("Unexpected header %v", message)
+	}
+
+	message = AppendMessageIndexes(AppendHeader(nil, 7), []int{1, 0})
+	id, rest, err := Parse(message)
+	if err != nil || id != 7 {
+		t.Fatalf("Expected ID 7, got %d (%v)", id, err)
+	}
+	indexes, _, err := ParseMessageIndexes(rest)
+	if err != nil || !reflect.DeepEqual(indexes, []int{1, 0}) {
+		t.Errorf("Expected indexes [1 0], got %v (%v)", indexes, err)
+	}
+}
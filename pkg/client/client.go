@@ -1,13 +1,17 @@
+// Package client is a Schema Registry REST API client.
 package client
 
 import (
 	"bytes"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/viper"
@@ -20,53 +24,90 @@ type Client struct {
 	username   string
 	password   string
 	apiKey     string
+
+	// Schemas are immutable once registered, so lookups by ID are cached per context
+	schemasByID   map[string]*Schema
+	schemasByIDMu sync.Mutex
 }
 
-// NewClient creates a new Schema Registry client
-func NewClient() (*Client, error) {
-	baseURL := viper.GetString("registry-url")
-	if baseURL == "" {
+// Options configure a client created with New
+type Options struct {
+	// BaseURL is the URL of the Schema Registry
+	BaseURL string
+	// Username and Password enable basic authentication
+	Username string
+	Password string
+	// APIKey is sent as a bearer token, in place of the username and password
+	APIKey string
+	// Timeout of each request, 30 seconds when 0
+	Timeout time.Duration
+	// Insecure skips the verification of the registry's TLS certificate
+	Insecure bool
+	// HTTPClient replaces the HTTP client built from Timeout and Insecure
+	HTTPClient *http.Client
+}
+
+// New creates a Schema Registry client configured by the given options only
+func New(opts Options) (*Client, error) {
+	if opts.BaseURL == "" {
 		return nil, fmt.Errorf("registry URL is required")
 	}
 
-	client := &Client{
-		baseURL: strings.TrimRight(baseURL, "/"),
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		username: viper.GetString("username"),
-		password: viper.GetString("password"),
-		apiKey:   viper.GetString("api-key"),
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		timeout := opts.Timeout
+		if timeout == 0 {
+			timeout = 30 * time.Second
+		}
+		httpClient = &http.Client{Timeout: timeout}
+		if opts.Insecure {
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} // #nosec G402 -- requested with Insecure
+			httpClient.Transport = transport
+		}
 	}
 
-	return client, nil
+	return &Client{
+		baseURL:    strings.TrimRight(opts.BaseURL, "/"),
+		httpClient: httpClient,
+		username:   opts.Username,
+		password:   opts.Password,
+		apiKey:     opts.APIKey,
+	}, nil
 }
 
-// NewClientWithConfig creates a new Schema Registry client with the provided configuration
-func NewClientWithConfig(config *ClientConfig) (*Client, error) {
-	if config.BaseURL == "" {
-		return nil, fmt.Errorf("registry URL is required")
-	}
+// NewClient creates a new Schema Registry client from the registry-url, username,
+// password and api-key settings of the CLI's viper configuration.
+//
+// Deprecated: programs importing the client should use New, which does not depend on
+// global configuration.
+func NewClient() (*Client, error) {
+	return New(Options{
+		BaseURL:  viper.GetString("registry-url"),
+		Username: viper.GetString("username"),
+		Password: viper.GetString("password"),
+		APIKey:   viper.GetString("api-key"),
+	})
+}
 
-	// Parse timeout
-	timeout := 30 * time.Second
+// NewClientWithConfig creates a new Schema Registry client with the provided configuration.
+// An invalid timeout falls back to 30 seconds.
+func NewClientWithConfig(config *ClientConfig) (*Client, error) {
+	var timeout time.Duration
 	if config.Timeout != "" {
 		if parsedTimeout, err := time.ParseDuration(config.Timeout); err == nil {
 			timeout = parsedTimeout
 		}
 	}
 
-	client := &Client{
-		baseURL: strings.TrimRight(config.BaseURL, "/"),
-		httpClient: &http.Client{
-			Timeout: timeout,
-		},
-		username: config.Username,
-		password: config.Password,
-		apiKey:   config.APIKey,
-	}
-
-	return client, nil
+	return New(Options{
+		BaseURL:  config.BaseURL,
+		Username: config.Username,
+		Password: config.Password,
+		APIKey:   config.APIKey,
+		Timeout:  timeout,
+		Insecure: config.Insecure,
+	})
 }
 
 // makeRequest performs an HTTP request to the Schema Registry
@@ -125,6 +166,47 @@ func (c *Client) GetSubjects(context string) ([]string, error) {
 	return subjects, nil
 }
 
+// SchemaText returns the text of a schema as held by registry responses and exports: a
+// JSON string, or the schema itself in older formats
+func SchemaText(raw json.RawMessage) string {
+	var content string
+	if err := json.Unmarshal(raw, &content); err == nil {
+		return content
+	}
+	return string(raw)
+}
+
+// ReferenceContents resolves schema references recursively and returns the contents of
+// the referenced schemas, dependencies first, so they can be parsed before the schema
+// that uses them
+func (c *Client) ReferenceContents(refs []Reference, context string) ([]string, error) {
+	return c.referenceContents(refs, context, map[string]bool{})
+}
+
+func (c *Client) referenceContents(refs []Reference, context string, seen map[string]bool) ([]string, error) {
+	var contents []string
+	for _, ref := range refs {
+		key := fmt.Sprintf("%s:%d", ref.Subject, ref.Version)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		schema, err := c.GetSchema(ref.Subject, fmt.Sprintf("%d", ref.Version), context)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve reference %s (%s version %d): %w", ref.Name, ref.Subject, ref.Version, err)
+		}
+
+		nested, err := c.referenceContents(schema.References, context, seen)
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, nested...)
+		contents = append(contents, SchemaText(schema.Schema))
+	}
+	return contents, nil
+}
+
 // GetSchema returns a schema by subject and version
 func (c *Client) GetSchema(subject, version, context string) (*Schema, error) {
 	path := fmt.Sprintf("/subjects/%s/versions/%s", url.PathEscape(subject), url.PathEscape(version))
@@ -150,6 +232,48 @@ func (c *Client) GetSchema(subject, version, context string) (*Schema, error) {
 	return &schema, nil
 }
 
+// GetSchemaByID returns the schema registered under a global ID. Responses are cached,
+// as the schema for an ID never changes.
+func (c *Client) GetSchemaByID(id int, context string) (*Schema, error) {
+	key := fmt.Sprintf("%s:%d", context, id)
+	c.schemasByIDMu.Lock()
+	cached, ok := c.schemasByID[key]
+	c.schemasByIDMu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	path := fmt.Sprintf("/schemas/ids/%d", id)
+	if context != "" {
+		path += "?context=" + url.QueryEscape(context)
+	}
+
+	resp, err := c.makeRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleError(resp)
+	}
+
+	var schema Schema
+	if err := json.NewDecoder(resp.Body).Decode(&schema); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	schema.ID = id
+
+	c.schemasByIDMu.Lock()
+	if c.schemasByID == nil {
+		c.schemasByID = map[string]*Schema{}
+	}
+	c.schemasByID[key] = &schema
+	c.schemasByIDMu.Unlock()
+
+	return &schema, nil
+}
+
 // GetSubjectVersions returns all versions for a subject
 func (c *Client) GetSubjectVersions(subject, context string) ([]int, error) {
 	path := fmt.Sprintf("/subjects/%s/versions", url.PathEscape(subject))
@@ -175,6 +299,56 @@ func (c *Client) GetSubjectVersions(subject, context string) ([]int, error) {
 	return versions, nil
 }
 
+// GetReferencedBy returns the IDs of the schemas that reference a subject version
+func (c *Client) GetReferencedBy(subject, version, context string) ([]int, error) {
+	path := fmt.Sprintf("/subjects/%s/versions/%s/referencedby", url.PathEscape(subject), url.PathEscape(version))
+	if context != "" {
+		path += "?context=" + url.QueryEscape(context)
+	}
+
+	resp, err := c.makeRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleError(resp)
+	}
+
+	var ids []int
+	if err := json.NewDecoder(resp.Body).Decode(&ids); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return ids, nil
+}
+
+// GetSchemaVersionsByID returns the subject versions a schema ID is registered under
+func (c *Client) GetSchemaVersionsByID(id int, context string) ([]SubjectVersion, error) {
+	path := fmt.Sprintf("/schemas/ids/%d/versions", id)
+	if context != "" {
+		path += "?context=" + url.QueryEscape(context)
+	}
+
+	resp, err := c.makeRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleError(resp)
+	}
+
+	var versions []SubjectVersion
+	if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return versions, nil
+}
+
 // RegisterSchema registers a new schema
 func (c *Client) RegisterSchema(subject string, schemaData *SchemaRequest, context string) (*RegisterResponse, error) {
 	path := fmt.Sprintf("/subjects/%s/versions", url.PathEscape(subject))
@@ -200,9 +374,9 @@ func (c *Client) RegisterSchema(subject string, schemaData *SchemaRequest, conte
 	return &result, nil
 }
 
-// CheckCompatibility checks if a schema is compatible
-func (c *Client) CheckCompatibility(subject string, schemaData *SchemaRequest, context string) (*CompatibilityResponse, error) {
-	path := fmt.Sprintf("/compatibility/subjects/%s/versions/latest", url.PathEscape(subject))
+// LookupSchema returns the version of a subject under which a schema is registered
+func (c *Client) LookupSchema(subject string, schemaData *SchemaRequest, context string) (*Schema, error) {
+	path := fmt.Sprintf("/subjects/%s", url.PathEscape(subject))
 	if context != "" {
 		path += "?context=" + url.QueryEscape(context)
 	}
@@ -217,19 +391,33 @@ func (c *Client) CheckCompatibility(subject string, schemaData *SchemaRequest, c
 		return nil, c.handleError(resp)
 	}
 
-	var result CompatibilityResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	var schema Schema
+	if err := json.NewDecoder(resp.Body).Decode(&schema); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &result, nil
+	return &schema, nil
+}
+
+// CheckCompatibility checks if a schema is compatible with the latest version. With verbose
+// the registry explains incompatibilities in the response messages.
+func (c *Client) CheckCompatibility(subject string, schemaData *SchemaRequest, context string, verbose bool) (*CompatibilityResponse, error) {
+	return c.CheckCompatibilityWithVersion(subject, "latest", schemaData, context, verbose)
 }
 
-// CheckCompatibilityWithVersion checks if a schema is compatible with a specific version
-func (c *Client) CheckCompatibilityWithVersion(subject, version string, schemaData *SchemaRequest, context string) (*CompatibilityResponse, error) {
+// CheckCompatibilityWithVersion checks if a schema is compatible with a specific version. With
+// verbose the registry explains incompatibilities in the response messages.
+func (c *Client) CheckCompatibilityWithVersion(subject, version string, schemaData *SchemaRequest, context string, verbose bool) (*CompatibilityResponse, error) {
 	path := fmt.Sprintf("/compatibility/subjects/%s/versions/%s", url.PathEscape(subject), url.PathEscape(version))
+	query := url.Values{}
 	if context != "" {
-		path += "?context=" + url.QueryEscape(context)
+		query.Set("context", context)
+	}
+	if verbose {
+		query.Set("verbose", "true")
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
 	}
 
 	resp, err := c.makeRequest("POST", path, schemaData)
@@ -513,10 +701,31 @@ func (c *Client) handleError(resp *http.Response) error {
 
 	var errorResp ErrorResponse
 	if err := json.Unmarshal(body, &errorResp); err != nil {
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		return &APIError{StatusCode: resp.StatusCode, Message: string(body)}
 	}
 
-	return fmt.Errorf("HTTP %d: %s (code: %d)", resp.StatusCode, errorResp.Message, errorResp.ErrorCode)
+	return &APIError{StatusCode: resp.StatusCode, ErrorCode: errorResp.ErrorCode, Message: errorResp.Message}
+}
+
+// APIError is an error response from the Schema Registry
+type APIError struct {
+	StatusCode int
+	ErrorCode  int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.ErrorCode == 0 {
+		return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("HTTP %d: %s (code: %d)", e.StatusCode, e.Message, e.ErrorCode)
+}
+
+// IsNotFound reports whether an error is a Schema Registry response for a subject, version
+// or schema that does not exist
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
 }
 
 // Ping checks if the Schema Registry is accessible
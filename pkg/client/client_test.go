@@ -2,6 +2,7 @@ package client
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -385,3 +386,204 @@ func TestClient_SetSubjectMode(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_GetSchemaByID(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/schemas/ids/7":
+			if _, err := w.Write([]byte(`{"schema":"{\"type\":\"string\"}"}`)); err != nil {
+				t.Errorf("Failed to write response: %v", err)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			if _, err := w.Write([]byte(`{"error_code":40403,"message":"Schema not found"}`)); err != nil {
+				t.Errorf("Failed to write response: %v", err)
+			}
+		}
+	}))
+	defer server.Close()
+
+	viper.Set("registry-url", server.URL)
+
+	client, err := NewClient()
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		schema, err := client.GetSchemaByID(7, "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if schema.ID != 7 {
+			t.Errorf("Expected ID 7, got %d", schema.ID)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Expected the second lookup to be cached, got %d requests", requests)
+	}
+
+	_, err = client.GetSchemaByID(8, "")
+	if err == nil {
+		t.Fatal("Expected error for an unknown ID, but got none")
+	}
+	if !IsNotFound(err) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+	if err.Error() != "HTTP 404: Schema not found (code: 40403)" {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+	if IsNotFound(fmt.Errorf("wrapped: %w", &APIError{StatusCode: http.StatusInternalServerError})) {
+		t.Error("Expected a server error not to be a not found error")
+	}
+}
+
+func TestClient_GetReferencedBy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/subjects/money/versions/1/referencedby":
+			if _, err := w.Write([]byte(`[4]`)); err != nil {
+				t.Errorf("Failed to write response: %v", err)
+			}
+		case "/schemas/ids/4/versions":
+			if _, err := w.Write([]byte(`[{"subject":"orders-value","version":2}]`)); err != nil {
+				t.Errorf("Failed to write response: %v", err)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	viper.Set("registry-url", server.URL)
+
+	client, err := NewClient()
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ids, err := client.GetReferencedBy("money", "1", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != 4 {
+		t.Fatalf("Expected IDs [4], got %v", ids)
+	}
+
+	versions, err := client.GetSchemaVersionsByID(ids[0], "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(versions) != 1 || versions[0].Subject != "orders-value" || versions[0].Version != 2 {
+		t.Errorf("Expected orders-value version 2, got %+v", versions)
+	}
+}
+
+func TestClient_CheckCompatibilityWithVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/compatibility/subjects/orders-value/versions/2" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		response := `{"is_compatible":false}`
+		if r.URL.Query().Get("verbose") == "true" {
+			response = `{"is_compatible":false,"messages":["READER_FIELD_MISSING_DEFAULT_VALUE"]}`
+		}
+		if _, err := w.Write([]byte(response)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	viper.Set("registry-url", server.URL)
+
+	client, err := NewClient()
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	request := &SchemaRequest{Schema: `{"type":"string"}`}
+	result, err := client.CheckCompatibilityWithVersion("orders-value", "2", request, "", true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.IsCompatible || len(result.Messages) != 1 {
+		t.Errorf("Expected an incompatible result with a message, got %+v", result)
+	}
+
+	result, err = client.CheckCompatibilityWithVersion("orders-value", "2", request, "", false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Messages) != 0 {
+		t.Errorf("Expected no messages without verbose, got %v", result.Messages)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(Options{}); err == nil {
+		t.Error("Expected an error without a registry URL")
+	}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte(`{"mode":"READWRITE"}`)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	// The settings of the CLI are not used
+	viper.Set("registry-url", "http://localhost:1")
+
+	client, err := New(Options{BaseURL: server.URL + "/"})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := client.GetGlobalMode(""); err == nil {
+		t.Error("Expected the self-signed certificate to be rejected")
+	}
+
+	client, err = New(Options{BaseURL: server.URL, Insecure: true})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if mode, err := client.GetGlobalMode(""); err != nil || mode.Mode != "READWRITE" {
+		t.Errorf("Expected the mode with Insecure, got %+v, %v", mode, err)
+	}
+}
+
+func TestClient_ReferenceContents(t *testing.T) {
+	schemas := map[string]string{
+		"/subjects/order-value/versions/1":    `{"id":3,"version":1,"schema":"order","references":[{"name":"Customer","subject":"customer-value","version":2},{"name":"Money","subject":"money-value","version":1}]}`,
+		"/subjects/customer-value/versions/2": `{"id":2,"version":2,"schema":"customer","references":[{"name":"Money","subject":"money-value","version":1}]}`,
+		"/subjects/money-value/versions/1":    `{"id":1,"version":1,"schema":"money"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := schemas[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Errorf("Failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client, err := New(Options{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	contents, err := client.ReferenceContents([]Reference{{Name: "Order", Subject: "order-value", Version: 1}}, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := fmt.Sprint(contents); got != "[money customer order]" {
+		t.Errorf("Expected dependencies first, each once, got %s", got)
+	}
+	if SchemaText(json.RawMessage(`"{\"type\":\"string\"}"`)) != `{"type":"string"}` || SchemaText(json.RawMessage(`{"type":"string"}`)) != `{"type":"string"}` {
+		t.Error("Expected the schema text of JSON strings and raw schemas")
+	}
+}
@@ -178,19 +178,38 @@ type ContextDescription struct {
 
 // SubjectDescription contains comprehensive information about a subject
 type SubjectDescription struct {
-	Name              string   `json:"name"`
-	Versions          []int    `json:"versions,omitempty"`
-	LatestVersion     int      `json:"latest_version,omitempty"`
-	LatestSchema      *Schema  `json:"latest_schema,omitempty"`
-	Config            *Config  `json:"config,omitempty"`
-	Mode              *Mode    `json:"mode,omitempty"`
-	SchemaType        string   `json:"schema_type,omitempty"`
-	FieldCount        int      `json:"field_count,omitempty"`
-	SuggestedCommands []string `json:"suggested_commands,omitempty"`
-}
-
-// SchemaFieldInfo represents information about schema fields (for analysis)
+	Name              string              `json:"name"`
+	Versions          []int               `json:"versions,omitempty"`
+	LatestVersion     int                 `json:"latest_version,omitempty"`
+	LatestSchema      *Schema             `json:"latest_schema,omitempty"`
+	Config            *Config             `json:"config,omitempty"`
+	Mode              *Mode               `json:"mode,omitempty"`
+	SchemaType        string              `json:"schema_type,omitempty"`
+	FieldCount        int                 `json:"field_count,omitempty"`
+	Fields            *SchemaFieldInfo    `json:"fields,omitempty"`
+	Fingerprints      *SchemaFingerprints `json:"fingerprints,omitempty"`
+	SuggestedCommands []string            `json:"suggested_commands,omitempty"`
+}
+
+// SchemaFingerprints holds the canonical form of a schema and its fingerprints
+type SchemaFingerprints struct {
+	CanonicalForm string `json:"canonical_form,omitempty"`
+	CRC64Avro     string `json:"crc64_avro"`
+	MD5           string `json:"md5"`
+	SHA256        string `json:"sha256"`
+}
+
+// SchemaFieldInfo represents information about schema fields (for analysis). Nested
+// fields are counted and listed by path, e.g. "address.zip" or "items[].sku".
 type SchemaFieldInfo struct {
-	FieldCount int      `json:"field_count"`
-	FieldNames []string `json:"field_names,omitempty"`
+	FieldCount           int         `json:"field_count"`
+	FieldNames           []string    `json:"field_names,omitempty"`
+	TotalFields          int         `json:"total_fields"`
+	LeafFields           int         `json:"leaf_fields"`
+	MaxDepth             int         `json:"max_depth"`
+	NullableFields       []string    `json:"nullable_fields,omitempty"`
+	FieldsWithoutDefault []string    `json:"fields_without_default,omitempty"`
+	FieldsWithoutDoc     []string    `json:"fields_without_doc,omitempty"`
+	LogicalTypes         []string    `json:"logical_types,omitempty"`
+	References           []Reference `json:"references,omitempty"`
 }
@@ -0,0 +1,135 @@
+package serde
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aywengo/ksr-cli/internal/avro"
+	"github.com/aywengo/ksr-cli/internal/jsonfmt"
+	"github.com/aywengo/ksr-cli/internal/protobuf"
+	"github.com/aywengo/ksr-cli/internal/validate"
+	"github.com/aywengo/ksr-cli/internal/wire"
+)
+
+// codec converts between decoded JSON records and the payload that follows the schema
+// ID in the wire format
+type codec interface {
+	recordName() string
+	encode(value interface{}) ([]byte, error)
+	decode(payload []byte) (interface{}, error)
+}
+
+// newCodec parses a schema; messageName selects the Protobuf message to encode
+func newCodec(schemaType, content string, references []string, messageName string) (codec, error) {
+	switch schemaType {
+	case "AVRO":
+		s, err := avro.ParseWithReferences(content, references)
+		if err != nil {
+			return nil, err
+		}
+		return &avroCodec{schema: s}, nil
+	case "PROTOBUF":
+		f, registry, err := protobuf.ParseWithReferences(content, references)
+		if err != nil {
+			return nil, err
+		}
+		if len(f.Messages) == 0 {
+			return nil, fmt.Errorf("schema defines no messages")
+		}
+		c := &protobufCodec{file: f, registry: registry, indexes: []int{0}}
+		if messageName != "" {
+			if c.indexes, err = protobuf.MessageIndexes(f, messageName); err != nil {
+				return nil, err
+			}
+		}
+		c.message, _ = protobuf.MessageByIndexes(f, c.indexes)
+		return c, nil
+	case "JSON":
+		validator, err := validate.New("JSON", content, references, validate.Options{})
+		if err != nil {
+			return nil, err
+		}
+		var schema struct {
+			Title string `json:"title"`
+		}
+		_ = json.Unmarshal([]byte(content), &schema)
+		return &jsonCodec{validator: validator, title: schema.Title}, nil
+	default:
+		return nil, fmt.Errorf("unsupported schema type %s", schemaType)
+	}
+}
+
+// avroCodec writes Avro binary from plain JSON records
+type avroCodec struct {
+	schema *avro.Schema
+}
+
+func (c *avroCodec) recordName() string {
+	return c.schema.FullName()
+}
+
+func (c *avroCodec) encode(value interface{}) ([]byte, error) {
+	return avro.EncodeBinary(c.schema, value, false)
+}
+
+func (c *avroCodec) decode(payload []byte) (interface{}, error) {
+	return avro.DecodeBinary(c.schema, payload, false)
+}
+
+// protobufCodec writes one message type of a schema, preceded by its message indexes,
+// and reads any message type of it
+type protobufCodec struct {
+	file     *protobuf.File
+	registry *protobuf.Registry
+	message  *protobuf.Message
+	indexes  []int
+}
+
+func (c *protobufCodec) recordName() string {
+	return c.message.FullName
+}
+
+func (c *protobufCodec) encode(value interface{}) ([]byte, error) {
+	payload, err := protobuf.EncodeBinary(c.message, c.registry, value)
+	if err != nil {
+		return nil, err
+	}
+	return append(wire.AppendMessageIndexes(nil, c.indexes), payload...), nil
+}
+
+func (c *protobufCodec) decode(payload []byte) (interface{}, error) {
+	indexes, payload, err := wire.ParseMessageIndexes(payload)
+	if err != nil {
+		return nil, err
+	}
+	m, err := protobuf.MessageByIndexes(c.file, indexes)
+	if err != nil {
+		return nil, err
+	}
+	return protobuf.DecodeBinary(m, c.registry, payload)
+}
+
+// jsonCodec writes JSON records that are valid against a JSON Schema
+type jsonCodec struct {
+	validator *validate.Validator
+	title     string
+}
+
+func (c *jsonCodec) recordName() string {
+	return c.title
+}
+
+func (c *jsonCodec) encode(value interface{}) ([]byte, error) {
+	if violations := c.validator.Validate(value); len(violations) > 0 {
+		return nil, fmt.Errorf("%s: %s", violations[0].Path, violations[0].Message)
+	}
+	return json.Marshal(value)
+}
+
+func (c *jsonCodec) decode(payload []byte) (interface{}, error) {
+	value, err := jsonfmt.Decode(payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON payload: %w", err)
+	}
+	return value, nil
+}
@@ -0,0 +1,104 @@
+package serde
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aywengo/ksr-cli/internal/wire"
+	"github.com/aywengo/ksr-cli/pkg/client"
+)
+
+// Deserializer reads records in the Confluent wire format written with any Avro,
+// Protobuf or JSON Schema schema, fetching each writer schema by ID once. It is safe for
+// concurrent use.
+type Deserializer struct {
+	client *client.Client
+	config Config
+
+	mu      sync.Mutex
+	schemas map[int]codec
+}
+
+// NewDeserializer creates a deserializer; only the Context of the configuration applies
+func NewDeserializer(c *client.Client, config Config) *Deserializer {
+	return &Deserializer{client: c, config: config, schemas: map[int]codec{}}
+}
+
+// Deserialize reads a message into decoded JSON: maps, slices, strings, booleans, nil
+// and json.Number numbers
+func (d *Deserializer) Deserialize(data []byte) (interface{}, error) {
+	record, err := d.deserialize(data)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(record)))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to decode record: %w", err)
+	}
+	return value, nil
+}
+
+// DeserializeInto reads a message into a value as json.Unmarshal does. Protobuf 64-bit
+// integers are JSON strings, so struct fields for them need the ",string" option.
+func (d *Deserializer) DeserializeInto(data []byte, v interface{}) error {
+	record, err := d.deserialize(data)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(record, v); err != nil {
+		return fmt.Errorf("failed to unmarshal record: %w", err)
+	}
+	return nil
+}
+
+// deserialize reads a message into JSON
+func (d *Deserializer) deserialize(data []byte) ([]byte, error) {
+	id, payload, err := wire.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	c, err := d.schema(id)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := c.decode(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deserialize message with schema ID %d: %w", id, err)
+	}
+	record, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode record: %w", err)
+	}
+	return record, nil
+}
+
+// schema fetches and parses the writer schema registered under an ID
+func (d *Deserializer) schema(id int) (codec, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if c, ok := d.schemas[id]; ok {
+		return c, nil
+	}
+
+	registered, err := d.client.GetSchemaByID(id, d.config.Context)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema ID %d: %w", id, err)
+	}
+	references, err := d.client.ReferenceContents(registered.References, d.config.Context)
+	if err != nil {
+		return nil, err
+	}
+	c, err := newCodec(schemaType(registered), client.SchemaText(registered.Schema), references, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema ID %d: %w", id, err)
+	}
+
+	d.schemas[id] = c
+	return c, nil
+}
@@ -0,0 +1,105 @@
+// Package serde serializes and deserializes Kafka record values in the Confluent wire
+// format with Avro, Protobuf and JSON Schema schemas from a Schema Registry.
+//
+// Records are Go values that marshal to JSON: structs with json tags, maps, slices or
+// json.RawMessage. Avro records use plain JSON, Protobuf records the Protobuf JSON
+// mapping and JSON Schema records are validated against their schema.
+//
+//	c, _ := client.NewClientWithConfig(&client.ClientConfig{BaseURL: "http://localhost:8081"})
+//	serializer, _ := serde.NewAvroSerializer(c, userSchema, serde.Config{AutoRegister: true})
+//	message, _ := serializer.Serialize("users", user)
+//
+//	deserializer := serde.NewDeserializer(c, serde.Config{})
+//	_ = deserializer.DeserializeInto(message, &user)
+package serde
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aywengo/ksr-cli/pkg/client"
+)
+
+// Config configures serializers and deserializers
+type Config struct {
+	// Context is the registry context of subjects and schema IDs
+	Context string
+
+	// IsKey selects the key subject of a topic rather than the value subject
+	IsKey bool
+
+	// SubjectNameStrategy names the subject of a record; TopicNameStrategy by default
+	SubjectNameStrategy SubjectNameStrategy
+
+	// AutoRegister registers the schema of a serializer under its subject. Otherwise the
+	// schema must already be registered there.
+	AutoRegister bool
+
+	// UseLatest serializes with the latest schema of the subject instead of the schema of
+	// the serializer, which may then be empty
+	UseLatest bool
+
+	// References are the registered schemas that the schema of a serializer imports
+	References []client.Reference
+
+	// MessageName is the Protobuf message to serialize; the first message by default
+	MessageName string
+}
+
+// SubjectNameStrategy returns the subject of a record from its topic and the full name
+// of its record type: the Avro record name, the Protobuf message name or the JSON
+// Schema title
+type SubjectNameStrategy func(topic string, isKey bool, recordName string) (string, error)
+
+// TopicNameStrategy names subjects after the topic, as <topic>-key or <topic>-value
+func TopicNameStrategy(topic string, isKey bool, recordName string) (string, error) {
+	if isKey {
+		return topic + "-key", nil
+	}
+	return topic + "-value", nil
+}
+
+// RecordNameStrategy names subjects after the record type
+func RecordNameStrategy(topic string, isKey bool, recordName string) (string, error) {
+	if recordName == "" {
+		return "", fmt.Errorf("record name strategy requires a named record type")
+	}
+	return recordName, nil
+}
+
+// TopicRecordNameStrategy names subjects after the topic and the record type, as
+// <topic>-<record name>
+func TopicRecordNameStrategy(topic string, isKey bool, recordName string) (string, error) {
+	if recordName == "" {
+		return "", fmt.Errorf("topic record name strategy requires a named record type")
+	}
+	return topic + "-" + recordName, nil
+}
+
+// schemaType returns the type of a registered schema, which the registry omits for Avro
+func schemaType(schema *client.Schema) string {
+	if schema.Type == "" {
+		return "AVRO"
+	}
+	return strings.ToUpper(schema.Type)
+}
+
+// jsonValue converts a record to decoded JSON with json.Number numbers
+func jsonValue(value interface{}) (interface{}, error) {
+	data, ok := value.(json.RawMessage)
+	if !ok {
+		var err error
+		if data, err = json.Marshal(value); err != nil {
+			return nil, fmt.Errorf("failed to marshal record: %w", err)
+		}
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("invalid JSON record: %w", err)
+	}
+	return decoded, nil
+}
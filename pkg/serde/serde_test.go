@@ -0,0 +1,311 @@
+package serde
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aywengo/ksr-cli/pkg/client"
+)
+
+// fakeRegistry is an in-memory Schema Registry serving the endpoints used by this package
+type fakeRegistry struct {
+	mu       sync.Mutex
+	schemas  []client.Schema
+	requests map[string]int
+}
+
+func newFakeRegistry(t *testing.T) (*fakeRegistry, *client.Client) {
+	registry := &fakeRegistry{requests: map[string]int{}}
+	server := httptest.NewServer(registry)
+	t.Cleanup(server.Close)
+
+	c, err := client.NewClientWithConfig(&client.ClientConfig{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return registry, c
+}
+
+func (f *fakeRegistry) register(subject, schemaType, schema string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.registerLocked(subject, schemaType, schema).ID
+}
+
+func (f *fakeRegistry) registerLocked(subject, schemaType, schema string) client.Schema {
+	id, version := len(f.schemas)+1, 1
+	for _, s := range f.schemas {
+		if string(s.Schema) == schema && s.Type == schemaType {
+			if s.Subject == subject {
+				return s
+			}
+			id = s.ID
+		}
+		if s.Subject == subject {
+			version = s.Version + 1
+		}
+	}
+	registered := client.Schema{ID: id, Version: version, Subject: subject, Type: schemaType, Schema: json.RawMessage(schema)}
+	f.schemas = append(f.schemas, registered)
+	return registered
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests[r.Method+" "+r.URL.Path]++
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	respond := func(schema client.Schema) {
+		content, _ := json.Marshal(string(schema.Schema))
+		schema.Schema = content
+		_ = json.NewEncoder(w).Encode(schema)
+	}
+	notFound := func() {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error_code":40401,"message":"Not found"}`))
+	}
+
+	switch {
+	case len(parts) == 3 && parts[0] == "schemas" && parts[1] == "ids":
+		id, _ := strconv.Atoi(parts[2])
+		for _, s := range f.schemas {
+			if s.ID == id {
+				respond(s)
+				return
+			}
+		}
+		notFound()
+	case len(parts) == 3 && parts[0] == "subjects" && parts[2] == "versions" && r.Method == http.MethodPost:
+		var request client.SchemaRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		registered := f.registerLocked(parts[1], request.SchemaType, request.Schema)
+		_ = json.NewEncoder(w).Encode(client.RegisterResponse{ID: registered.ID})
+	case len(parts) == 2 && parts[0] == "subjects" && r.Method == http.MethodPost:
+		var request client.SchemaRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		for _, s := range f.schemas {
+			if s.Subject == parts[1] && string(s.Schema) == request.Schema && s.Type == request.SchemaType {
+				respond(s)
+				return
+			}
+		}
+		notFound()
+	case len(parts) == 4 && parts[0] == "subjects" && parts[2] == "versions":
+		var found *client.Schema
+		for i, s := range f.schemas {
+			if s.Subject == parts[1] && (parts[3] == "latest" || strconv.Itoa(s.Version) == parts[3]) {
+				found = &f.schemas[i]
+			}
+		}
+		if found == nil {
+			notFound()
+			return
+		}
+		respond(*found)
+	default:
+		notFound()
+	}
+}
+
+const userSchema = `{"type":"record","name":"User","namespace":"com.acme","fields":[
+	{"name":"name","type":"string"},
+	{"name":"age","type":["null","int"],"default":null}
+]}`
+
+type user struct {
+	Name string `json:"name"`
+	Age  *int   `json:"age"`
+}
+
+func TestAvroSerializer(t *testing.T) {
+	registry, c := newFakeRegistry(t)
+	serializer, err := NewAvroSerializer(c, userSchema, Config{AutoRegister: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	age := 42
+	for i := 0; i < 2; i++ {
+		message, err := serializer.Serialize("users", user{Name: "ann", Age: &age})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected := []byte{0, 0, 0, 0, 1, 0x06, 'a', 'n', 'n', 0x02, 0x54}
+		if string(message) != string(expected) {
+			t.Errorf("Expected %v, got %v", expected, message)
+		}
+	}
+	if registry.requests["POST /subjects/users-value/versions"] != 1 {
+		t.Errorf("Expected the schema to be registered once, got %d requests", registry.requests["POST /subjects/users-value/versions"])
+	}
+
+	deserializer := NewDeserializer(c, Config{})
+	message, _ := serializer.Serialize("users", user{Name: "bob"})
+	var decoded user
+	if err := deserializer.DeserializeInto(message, &decoded); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decoded.Name != "bob" || decoded.Age != nil {
+		t.Errorf("Expected bob without an age, got %+v", decoded)
+	}
+	if _, err := deserializer.Deserialize(message); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if registry.requests["GET /schemas/ids/1"] != 1 {
+		t.Errorf("Expected the writer schema to be fetched once, got %d requests", registry.requests["GET /schemas/ids/1"])
+	}
+
+	if _, err := serializer.Serialize("users", map[string]interface{}{"age": 1}); err == nil {
+		t.Error("Expected error for a record without a required field")
+	}
+	if _, err := deserializer.Deserialize([]byte{0, 0, 0, 0, 9, 0}); err == nil {
+		t.Error("Expected error for an unknown schema ID")
+	}
+}
+
+func TestSerializerSchemaResolution(t *testing.T) {
+	registry, c := newFakeRegistry(t)
+
+	lookup, err := NewAvroSerializer(c, userSchema, Config{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := lookup.Serialize("users", user{Name: "ann"}); err == nil {
+		t.Error("Expected error for a schema that is not registered")
+	}
+
+	registry.register("other-value", "", `{"type":"string"}`)
+	id := registry.register("users-value", "", userSchema)
+	lookup, _ = NewAvroSerializer(c, userSchema, Config{})
+	message, err := lookup.Serialize("users", user{Name: "ann"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if message[4] != byte(id) {
+		t.Errorf("Expected schema ID %d, got %d", id, message[4])
+	}
+
+	v2 := strings.Replace(userSchema, `]}`, `,{"name":"email","type":"string","default":""}]}`, 1)
+	latestID := registry.register("users-value", "", v2)
+	latest, err := NewAvroSerializer(c, "", Config{UseLatest: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	message, err = latest.Serialize("users", user{Name: "ann"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if message[4] != byte(latestID) || message[len(message)-1] != 0 {
+		t.Errorf("Expected schema ID %d with a default email, got %v", latestID, message)
+	}
+
+	if _, err := NewAvroSerializer(c, "", Config{}); err == nil {
+		t.Error("Expected error for a serializer without a schema")
+	}
+	jsonLatest, err := NewJSONSchemaSerializer(c, "", Config{UseLatest: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := jsonLatest.Serialize("users", user{Name: "ann"}); err == nil {
+		t.Error("Expected error for a latest schema of another type")
+	}
+}
+
+func TestProtobufSerializer(t *testing.T) {
+	registry, c := newFakeRegistry(t)
+	schema := `syntax = "proto3";
+package shop;
+message Order {
+  int64 total_cents = 1;
+  message Line { string sku = 1; }
+  repeated Line lines = 2;
+}`
+	serializer, err := NewProtobufSerializer(c, schema, Config{
+		AutoRegister:        true,
+		MessageName:         "Order.Line",
+		SubjectNameStrategy: RecordNameStrategy,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	message, err := serializer.Serialize("orders", json.RawMessage(`{"sku":"A1"}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []byte{0, 0, 0, 0, 1, 0x04, 0x00, 0x00, 0x0a, 0x02, 'A', '1'}
+	if string(message) != string(expected) {
+		t.Errorf("Expected %v, got %v", expected, message)
+	}
+	if registry.requests["POST /subjects/shop.Order.Line/versions"] != 1 {
+		t.Errorf("Expected registration under shop.Order.Line, got %v", registry.requests)
+	}
+
+	value, err := NewDeserializer(c, Config{}).Deserialize(message)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if encoded, _ := json.Marshal(value); string(encoded) != `{"sku":"A1"}` {
+		t.Errorf("Expected the line back, got %s", encoded)
+	}
+}
+
+func TestJSONSchemaSerializer(t *testing.T) {
+	_, c := newFakeRegistry(t)
+	schema := `{"title":"Payment","type":"object","properties":{"amount":{"type":"number","minimum":0}},"required":["amount"]}`
+	serializer, err := NewJSONSchemaSerializer(c, schema, Config{
+		AutoRegister:        true,
+		IsKey:               true,
+		SubjectNameStrategy: TopicRecordNameStrategy,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	message, err := serializer.Serialize("payments", map[string]interface{}{"amount": 12.5})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(message[5:]) != `{"amount":12.5}` {
+		t.Errorf("Expected the JSON record, got %s", message[5:])
+	}
+	if _, err := serializer.Serialize("payments", map[string]interface{}{"amount": -1}); err == nil {
+		t.Error("Expected error for an invalid record")
+	}
+
+	value, err := NewDeserializer(c, Config{}).Deserialize(message)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if fmt.Sprint(value) != "map[amount:12.5]" {
+		t.Errorf("Expected the record back, got %v", value)
+	}
+}
+
+func TestSubjectNameStrategies(t *testing.T) {
+	tests := []struct {
+		strategy SubjectNameStrategy
+		isKey    bool
+		expected string
+	}{
+		{TopicNameStrategy, false, "orders-value"},
+		{TopicNameStrategy, true, "orders-key"},
+		{RecordNameStrategy, false, "shop.Order"},
+		{TopicRecordNameStrategy, true, "orders-shop.Order"},
+	}
+	for _, tt := range tests {
+		if subject, err := tt.strategy("orders", tt.isKey, "shop.Order"); err != nil || subject != tt.expected {
+			t.Errorf("Expected %s, got %s (%v)", tt.expected, subject, err)
+		}
+	}
+	if _, err := RecordNameStrategy("orders", false, ""); err == nil {
+		t.Error("Expected error for an unnamed record type")
+	}
+}
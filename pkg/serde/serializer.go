@@ -0,0 +1,150 @@
+package serde
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/aywengo/ksr-cli/internal/wire"
+	"github.com/aywengo/ksr-cli/pkg/client"
+)
+
+// Serializer writes records in the Confluent wire format with one schema. It is safe for
+// concurrent use.
+type Serializer struct {
+	client     *client.Client
+	config     Config
+	schemaType string
+	schema     string
+	codec      codec
+
+	mu sync.Mutex
+	// subjects caches the schema ID and the codec to use for each subject
+	subjects map[string]*subjectSchema
+}
+
+// subjectSchema is the registered schema used to serialize the records of a subject
+type subjectSchema struct {
+	id    int
+	codec codec
+}
+
+// NewAvroSerializer creates a serializer for an Avro schema
+func NewAvroSerializer(c *client.Client, schema string, config Config) (*Serializer, error) {
+	return newSerializer(c, "AVRO", schema, config)
+}
+
+// NewProtobufSerializer creates a serializer for a message of a Protobuf schema
+func NewProtobufSerializer(c *client.Client, schema string, config Config) (*Serializer, error) {
+	return newSerializer(c, "PROTOBUF", schema, config)
+}
+
+// NewJSONSchemaSerializer creates a serializer for a JSON Schema
+func NewJSONSchemaSerializer(c *client.Client, schema string, config Config) (*Serializer, error) {
+	return newSerializer(c, "JSON", schema, config)
+}
+
+func newSerializer(c *client.Client, schemaType, schema string, config Config) (*Serializer, error) {
+	if config.SubjectNameStrategy == nil {
+		config.SubjectNameStrategy = TopicNameStrategy
+	}
+	s := &Serializer{
+		client:     c,
+		config:     config,
+		schemaType: schemaType,
+		schema:     schema,
+		subjects:   map[string]*subjectSchema{},
+	}
+
+	if schema == "" {
+		if !config.UseLatest {
+			return nil, fmt.Errorf("a schema is required unless UseLatest is set")
+		}
+		return s, nil
+	}
+
+	references, err := c.ReferenceContents(config.References, config.Context)
+	if err != nil {
+		return nil, err
+	}
+	if s.codec, err = newCodec(schemaType, schema, references, config.MessageName); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	return s, nil
+}
+
+// Serialize writes a record for a topic: the magic byte, the schema ID and the payload
+func (s *Serializer) Serialize(topic string, value interface{}) ([]byte, error) {
+	recordName := ""
+	if s.codec != nil {
+		recordName = s.codec.recordName()
+	}
+	subject, err := s.config.SubjectNameStrategy(topic, s.config.IsKey, recordName)
+	if err != nil {
+		return nil, err
+	}
+	registered, err := s.subjectSchema(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := jsonValue(value)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := registered.codec.encode(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize record for %s: %w", subject, err)
+	}
+	return append(wire.AppendHeader(nil, registered.id), payload...), nil
+}
+
+// subjectSchema resolves the schema ID for a subject once: the latest version with
+// UseLatest, or the ID of the serializer schema, registering it with AutoRegister
+func (s *Serializer) subjectSchema(subject string) (*subjectSchema, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if registered, ok := s.subjects[subject]; ok {
+		return registered, nil
+	}
+
+	var registered *subjectSchema
+	if s.config.UseLatest {
+		latest, err := s.client.GetSchema(subject, "latest", s.config.Context)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get latest schema of %s: %w", subject, err)
+		}
+		if latestType := schemaType(latest); latestType != s.schemaType {
+			return nil, fmt.Errorf("latest schema of %s is %s, not %s", subject, latestType, s.schemaType)
+		}
+		references, err := s.client.ReferenceContents(latest.References, s.config.Context)
+		if err != nil {
+			return nil, err
+		}
+		c, err := newCodec(s.schemaType, client.SchemaText(latest.Schema), references, s.config.MessageName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse latest schema of %s: %w", subject, err)
+		}
+		registered = &subjectSchema{id: latest.ID, codec: c}
+	} else {
+		request := &client.SchemaRequest{Schema: s.schema, References: s.config.References}
+		if s.schemaType != "AVRO" {
+			request.SchemaType = s.schemaType
+		}
+		if s.config.AutoRegister {
+			response, err := s.client.RegisterSchema(subject, request, s.config.Context)
+			if err != nil {
+				return nil, fmt.Errorf("failed to register schema under %s: %w", subject, err)
+			}
+			registered = &subjectSchema{id: response.ID, codec: s.codec}
+		} else {
+			found, err := s.client.LookupSchema(subject, request, s.config.Context)
+			if err != nil {
+				return nil, fmt.Errorf("schema is not registered under %s: %w", subject, err)
+			}
+			registered = &subjectSchema{id: found.ID, codec: s.codec}
+		}
+	}
+
+	s.subjects[subject] = registered
+	return registered, nil
+}